@@ -39,6 +39,8 @@ import (
 	"mosn.io/layotto/pkg/actuator/health"
 	"mosn.io/layotto/pkg/integrate/actuator"
 
+	"mosn.io/layotto/pkg/filter/network/tcpcopy/replay"
+
 	"github.com/urfave/cli"
 	"mosn.io/mosn/pkg/featuregate"
 )
@@ -229,6 +231,57 @@ var (
 			return nil
 		},
 	}
+
+	cmdTcpcopy = cli.Command{
+		Name:  "tcpcopy",
+		Usage: "tools for the traffic dumped by the tcpcopy filter",
+		Subcommands: []cli.Command{
+			{
+				Name:  "replay",
+				Usage: "replay the dumped traffic. For example: ./layotto tcpcopy replay -d '/home/admin/logs/mosn/dump/dump_tcp_copy.log*' -t 127.0.0.1:34904",
+				Flags: []cli.Flag{
+					cli.StringSliceFlag{
+						Name:  "dump, d",
+						Usage: "dump `FILE` to replay, glob patterns like dump_tcp_copy.log* are supported",
+					},
+					cli.StringFlag{
+						Name:  "target, t",
+						Usage: "address to replay the traffic against",
+					},
+					cli.StringFlag{
+						Name:  "baseline, b",
+						Usage: "address to replay the traffic against too, whose responses are compared with the target's",
+					},
+					cli.StringFlag{
+						Name:  "window, w",
+						Usage: "only replay the traffic of the sample window",
+					},
+					cli.StringFlag{
+						Name:  "port, p",
+						Usage: "only replay the traffic dumped from the port",
+					},
+					cli.Float64Flag{
+						Name:  "speed",
+						Usage: "replay speed relative to the recorded, 0 means as fast as possible",
+						Value: 1,
+					},
+					cli.DurationFlag{
+						Name:  "idle-timeout",
+						Usage: "how long to wait for more response data",
+						Value: time.Second,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					return replay.Run(os.Stdout, c.StringSlice("dump"), c.String("window"), c.String("port"), replay.Options{
+						Target:      c.String("target"),
+						Baseline:    c.String("baseline"),
+						Speed:       c.Float64("speed"),
+						IdleTimeout: c.Duration("idle-timeout"),
+					})
+				},
+			},
+		},
+	}
 )
 
 func SetActuatorAfterStart(_ stagemanager.Application) {
@@ -32,6 +32,9 @@ import (
 
 	ceph_oss "mosn.io/layotto/components/oss/ceph"
 
+	local_oss "mosn.io/layotto/components/oss/local"
+	s3compatible_oss "mosn.io/layotto/components/oss/s3compatible"
+
 	aliyun_file "mosn.io/layotto/components/file/aliyun"
 	"mosn.io/layotto/components/file/local"
 
@@ -53,6 +56,8 @@ import (
 	secretstores_loader "mosn.io/layotto/pkg/runtime/secretstores"
 	_ "mosn.io/layotto/pkg/wasm"
 	_ "mosn.io/layotto/pkg/wasm/install"
+	_ "mosn.io/layotto/pkg/wasm/list"
+	_ "mosn.io/layotto/pkg/wasm/status"
 	_ "mosn.io/layotto/pkg/wasm/uninstall"
 	_ "mosn.io/layotto/pkg/wasm/update"
 
@@ -150,9 +155,14 @@ import (
 
 	// Actuator
 	_ "mosn.io/layotto/pkg/actuator"
+	actuatorConfig "mosn.io/layotto/pkg/actuator/config"
 	"mosn.io/layotto/pkg/actuator/health"
 	actuatorInfo "mosn.io/layotto/pkg/actuator/info"
+	_ "mosn.io/layotto/pkg/actuator/logger"
+	_ "mosn.io/layotto/pkg/actuator/metrics"
+	_ "mosn.io/layotto/pkg/actuator/tracing"
 	_ "mosn.io/layotto/pkg/filter/stream/actuator/http"
+	dispatch "mosn.io/layotto/pkg/filter/stream/common/http"
 	"mosn.io/layotto/pkg/integrate/actuator"
 
 	"github.com/urfave/cli"
@@ -250,6 +260,8 @@ func NewRuntimeGrpcServer(data json.RawMessage, opts ...grpc.ServerOption) (mgrp
 	// 2. new instance
 	rt := runtime.NewMosnRuntime(cfg)
 	rt.AppendInitRuntimeStage(runtime.DefaultInitRuntimeStage)
+	actuatorConfig.SetSource(cfg, rt.Components)
+	dispatch.SetSecretGetter(rt.GetSecret)
 	// 3. run
 	server, err := rt.Run(
 		runtime.WithGrpcOptions(opts...),
@@ -306,6 +318,8 @@ func NewRuntimeGrpcServer(data json.RawMessage, opts ...grpc.ServerOption) (mgrp
 			oss.NewFactory("aws.oss", aws_oss.NewAwsOss),
 			oss.NewFactory("aliyun.oss", aliyun_oss.NewAliyunOss),
 			oss.NewFactory("ceph", ceph_oss.NewCephOss),
+			oss.NewFactory("local.oss", local_oss.NewLocalOss),
+			oss.NewFactory("s3compatible.oss", s3compatible_oss.NewS3CompatibleOss),
 		),
 
 		// PubSub
@@ -526,6 +540,7 @@ func newRuntimeApp(startCmd *cli.Command) *cli.App {
 		cmdStart,
 		cmdStop,
 		cmdReload,
+		cmdTcpcopy,
 	}
 	// action
 	app.Action = func(c *cli.Context) error {
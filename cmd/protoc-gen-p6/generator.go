@@ -0,0 +1,455 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	directiveSkip    = "skip"
+	directiveExtends = "extends"
+	// the value of `@exclude skip xxx` which makes this generator ignore the file
+	skipTarget = "code_generator"
+)
+
+var directiveRegexp = regexp.MustCompile(`@exclude\s+(\S+)\s+(\S+)`)
+
+// api is the model of an extension API, i.e. a gRPC service declared in a proto file.
+type api struct {
+	// the go package name of the proto file, e.g. "email"
+	PkgName string
+	// the import path of the .pb.go code, e.g. "mosn.io/layotto/spec/proto/extension/v1/email"
+	ImportPath string
+	// the service name, e.g. "EmailService"
+	ServiceName string
+	// the ApplicationContext field this API extends, e.g. "PubSubs".
+	// Empty if the API has its own kind of component.
+	Extends string
+	Methods []*method
+
+	file    *protogen.File
+	service *protogen.Service
+}
+
+type method struct {
+	Name     string
+	Input    string
+	Output   string
+	Comments string
+	// streaming methods can't be delegated to the components automatically
+	ClientStreaming bool
+	ServerStreaming bool
+}
+
+// Streaming reports whether it's a client streaming or server streaming method
+func (m *method) Streaming() bool {
+	return m.ClientStreaming || m.ServerStreaming
+}
+
+type structField struct {
+	Name     string
+	Type     string
+	JSONName string
+	Comments string
+}
+
+type structType struct {
+	Name     string
+	Comments string
+	Fields   []*structField
+}
+
+type enumValue struct {
+	Name     string
+	Number   int32
+	Comments string
+}
+
+type enumType struct {
+	Name     string
+	Comments string
+	Values   []*enumValue
+}
+
+// HasComponent reports whether the API has its own kind of component,
+// which needs a registry, a config section and runtime wiring.
+func (a *api) HasComponent() bool {
+	return a.Extends == ""
+}
+
+// FieldName is the name of the component map in runtime and ApplicationContext, e.g. "EmailService"
+func (a *api) FieldName() string {
+	return a.ServiceName
+}
+
+// PrivateFieldName is the unexported name of the component map, e.g. "emailService"
+func (a *api) PrivateFieldName() string {
+	return lowerFirst(a.ServiceName)
+}
+
+// UnaryMethods returns the methods which can be delegated to the components.
+func (a *api) UnaryMethods() []*method {
+	var result []*method
+	for _, m := range a.Methods {
+		if !m.Streaming() {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+func generate(gen *protogen.Plugin) error {
+	var apis []*api
+	for _, f := range gen.Files {
+		if !f.Generate || len(f.Services) == 0 {
+			continue
+		}
+		a, err := newAPI(f)
+		if err != nil {
+			return err
+		}
+		if a == nil {
+			continue
+		}
+		apis = append(apis, a)
+	}
+	if len(apis) == 0 {
+		return nil
+	}
+	sort.Slice(apis, func(i, j int) bool {
+		return apis[i].PkgName < apis[j].PkgName
+	})
+
+	for _, a := range apis {
+		if err := genComponent(gen, a); err != nil {
+			return err
+		}
+		if err := render(gen.NewGeneratedFile(fmt.Sprintf("grpc/%s/server.go", a.PkgName), ""), serverTemplate, a); err != nil {
+			return err
+		}
+	}
+	return genAggregation(gen, apis)
+}
+
+// newAPI parses the service in the file. It returns nil if the file should be skipped.
+func newAPI(f *protogen.File) (*api, error) {
+	var result *api
+	for _, s := range f.Services {
+		directives := parseDirectives(s.Comments)
+		if contains(directives[directiveSkip], skipTarget) {
+			continue
+		}
+		if result != nil {
+			return nil, fmt.Errorf("%s: only one service per file is supported, found %s and %s",
+				f.Desc.Path(), result.ServiceName, s.GoName)
+		}
+		result = &api{
+			PkgName:     string(f.GoPackageName),
+			ImportPath:  string(f.GoImportPath),
+			ServiceName: s.GoName,
+			file:        f,
+			service:     s,
+		}
+		switch targets := directives[directiveExtends]; len(targets) {
+		case 0:
+		case 1:
+			result.Extends = camelCase(targets[0])
+		default:
+			return nil, fmt.Errorf("%s: service %s extends more than one field: %s",
+				f.Desc.Path(), s.GoName, strings.Join(targets, ", "))
+		}
+		for _, m := range s.Methods {
+			result.Methods = append(result.Methods, &method{
+				Name:            m.GoName,
+				Input:           m.Input.GoIdent.GoName,
+				Output:          m.Output.GoIdent.GoName,
+				Comments:        trimComments(m.Comments.Leading),
+				ClientStreaming: m.Desc.IsStreamingClient(),
+				ServerStreaming: m.Desc.IsStreamingServer(),
+			})
+		}
+		sort.Slice(result.Methods, func(i, j int) bool {
+			return result.Methods[i].Name < result.Methods[j].Name
+		})
+	}
+	return result, nil
+}
+
+// parseDirectives collects the `@exclude key value` directives in the comments of a service,
+// the values of a key are kept in the order they appear
+func parseDirectives(comments protogen.CommentSet) map[string][]string {
+	texts := []string{string(comments.Leading)}
+	for _, c := range comments.LeadingDetached {
+		texts = append(texts, string(c))
+	}
+	result := make(map[string][]string)
+	for _, text := range texts {
+		for _, match := range directiveRegexp.FindAllStringSubmatch(text, -1) {
+			result[match[1]] = append(result[match[1]], match[2])
+		}
+	}
+	return result
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func genComponent(gen *protogen.Plugin, a *api) error {
+	dir := "components/" + a.PkgName + "/"
+
+	// interface
+	data := struct {
+		*api
+		Methods []*method
+		Imports importSet
+	}{api: a, Imports: importSet{}}
+	for _, m := range a.UnaryMethods() {
+		data.Methods = append(data.Methods, &method{
+			Name:     m.Name,
+			Input:    componentType(data.Imports, a, m.Input),
+			Output:   componentType(data.Imports, a, m.Output),
+			Comments: m.Comments,
+		})
+	}
+	if err := render(gen.NewGeneratedFile(dir+"interface_generated.go", ""), interfaceTemplate, data); err != nil {
+		return err
+	}
+
+	// structs
+	structs := struct {
+		PkgName string
+		Enums   []*enumType
+		Structs []*structType
+		Imports importSet
+	}{PkgName: a.PkgName, Imports: importSet{}}
+	for _, e := range a.file.Enums {
+		structs.Enums = append(structs.Enums, newEnumType(e))
+	}
+	for _, m := range a.file.Messages {
+		collectStructs(structs.Imports, a, m, &structs.Structs, &structs.Enums)
+	}
+	if err := render(gen.NewGeneratedFile(dir+"struct_generated.go", ""), structTemplate, structs); err != nil {
+		return err
+	}
+
+	// registry and factory
+	if !a.HasComponent() {
+		return nil
+	}
+	return render(gen.NewGeneratedFile(dir+"types_generated.go", ""), typesTemplate, a)
+}
+
+func genAggregation(gen *protogen.Plugin, apis []*api) error {
+	var components []*api
+	for _, a := range apis {
+		if a.HasComponent() {
+			components = append(components, a)
+		}
+	}
+	files := []struct {
+		name string
+		tpl  *template.Template
+		data interface{}
+	}{
+		{"grpc/context_generated.go", applicationContextTemplate, components},
+		{"runtime/component_generated.go", runtimeComponentTemplate, components},
+		{"runtime/config_generated.go", runtimeConfigTemplate, components},
+		{"runtime/options_generated.go", runtimeOptionsTemplate, components},
+		{"runtime/context_generated.go", runtimeContextTemplate, components},
+		{"runtime/extension_api_generated.go", runtimeAPITemplate, apis},
+		{"client/client_generated.go", clientTemplate, apis},
+	}
+	for _, f := range files {
+		if err := render(gen.NewGeneratedFile(f.name, ""), f.tpl, f.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func collectStructs(imports importSet, a *api, m *protogen.Message, structs *[]*structType, enums *[]*enumType) {
+	if m.Desc.IsMapEntry() {
+		return
+	}
+	st := &structType{
+		Name:     m.GoIdent.GoName,
+		Comments: trimComments(m.Comments.Leading),
+	}
+	for _, f := range m.Fields {
+		st.Fields = append(st.Fields, &structField{
+			Name:     f.GoName,
+			Type:     fieldType(imports, a, f),
+			JSONName: string(f.Desc.Name()),
+			Comments: trimComments(f.Comments.Leading),
+		})
+	}
+	*structs = append(*structs, st)
+	for _, e := range m.Enums {
+		*enums = append(*enums, newEnumType(e))
+	}
+	for _, nested := range m.Messages {
+		collectStructs(imports, a, nested, structs, enums)
+	}
+}
+
+func newEnumType(e *protogen.Enum) *enumType {
+	et := &enumType{
+		Name:     e.GoIdent.GoName,
+		Comments: trimComments(e.Comments.Leading),
+	}
+	for _, v := range e.Values {
+		et.Values = append(et.Values, &enumValue{
+			Name:     v.GoIdent.GoName,
+			Number:   int32(v.Desc.Number()),
+			Comments: trimComments(v.Comments.Leading),
+		})
+	}
+	return et
+}
+
+// fieldType returns the go type of the field in the component package.
+// Messages and enums declared in the same proto file are mapped to the generated structs,
+// others (e.g. the well-known types) refer to their .pb.go types.
+func fieldType(imports importSet, a *api, f *protogen.Field) string {
+	if f.Desc.IsMap() {
+		return "map[" + fieldType(imports, a, f.Message.Fields[0]) + "]" + fieldType(imports, a, f.Message.Fields[1])
+	}
+	var typ string
+	switch f.Desc.Kind() {
+	case protoreflect.BoolKind:
+		typ = "bool"
+	case protoreflect.StringKind:
+		typ = "string"
+	case protoreflect.BytesKind:
+		typ = "[]byte"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		typ = "int32"
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		typ = "uint32"
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		typ = "int64"
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		typ = "uint64"
+	case protoreflect.FloatKind:
+		typ = "float32"
+	case protoreflect.DoubleKind:
+		typ = "float64"
+	case protoreflect.EnumKind:
+		if f.Enum.GoIdent.GoImportPath == a.file.GoImportPath {
+			typ = f.Enum.GoIdent.GoName
+		} else {
+			typ = "int32"
+		}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if f.Message.GoIdent.GoImportPath == a.file.GoImportPath {
+			typ = "*" + f.Message.GoIdent.GoName
+		} else {
+			typ = "*" + imports.qualified(f.Message.GoIdent)
+		}
+	}
+	if f.Desc.IsList() {
+		return "[]" + typ
+	}
+	return typ
+}
+
+// componentType returns the type used by the component interface for a request or response message
+func componentType(imports importSet, a *api, name string) string {
+	for _, m := range a.service.Methods {
+		for _, msg := range []*protogen.Message{m.Input, m.Output} {
+			if msg.GoIdent.GoName == name && msg.GoIdent.GoImportPath != a.file.GoImportPath {
+				return imports.qualified(msg.GoIdent)
+			}
+		}
+	}
+	return name
+}
+
+// importSet records the packages referred by a generated file, keyed by the import path
+type importSet map[string]string
+
+// qualified returns the qualified name of the identifier and records its package
+func (s importSet) qualified(id protogen.GoIdent) string {
+	name := path.Base(string(id.GoImportPath))
+	name = strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, name)
+	s[string(id.GoImportPath)] = name
+	return name + "." + id.GoName
+}
+
+func render(g *protogen.GeneratedFile, tpl *template.Template, data interface{}) error {
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("failed to execute template %s: %v", tpl.Name(), err)
+	}
+	// format it to sort the imports
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to format the code generated by template %s: %v", tpl.Name(), err)
+	}
+	_, err = g.Write(src)
+	return err
+}
+
+// trimComments removes the trailing newline of the comments so that they can be put in templates
+func trimComments(c protogen.Comments) string {
+	if c == "" {
+		return ""
+	}
+	return strings.TrimSuffix(c.String(), "\n")
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// camelCase converts a snake case name like "pub_subs" into "PubSubs"
+func camelCase(s string) string {
+	parts := strings.Split(s, "_")
+	for i, p := range parts {
+		if p == "" {
+			continue
+		}
+		parts[i] = strings.ToUpper(p[:1]) + p[1:]
+	}
+	return strings.Join(parts, "")
+}
@@ -0,0 +1,155 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/pluginpb"
+
+	"mosn.io/layotto/spec/proto/extension/v1/delay_queue"
+	"mosn.io/layotto/spec/proto/extension/v1/email"
+	"mosn.io/layotto/spec/proto/extension/v1/s3"
+)
+
+// runGenerator runs the plugin against the files, using the comments as the leading detached comments of their services.
+// The whitespaces in the generated code are collapsed so that the assertions don't depend on the alignment.
+func runGenerator(t *testing.T, files []protoreflect.FileDescriptor, comments map[string]string) map[string]string {
+	req := &pluginpb.CodeGeneratorRequest{}
+	for _, f := range files {
+		fdp := protodesc.ToFileDescriptorProto(f)
+		if c, ok := comments[f.Path()]; ok {
+			fdp.SourceCodeInfo = &descriptorpb.SourceCodeInfo{
+				Location: []*descriptorpb.SourceCodeInfo_Location{
+					{
+						// the first service
+						Path:                    []int32{6, 0},
+						Span:                    []int32{0, 0, 0},
+						LeadingDetachedComments: []string{c},
+					},
+				},
+			}
+		}
+		req.ProtoFile = append(req.ProtoFile, fdp)
+		if f.Services().Len() > 0 {
+			req.FileToGenerate = append(req.FileToGenerate, f.Path())
+		}
+	}
+	req.Parameter = proto.String("paths=source_relative")
+
+	gen, err := protogen.Options{}.New(req)
+	assert.Nil(t, err)
+	assert.Nil(t, generate(gen))
+	resp := gen.Response()
+	assert.Nil(t, resp.Error)
+
+	result := make(map[string]string)
+	for _, f := range resp.File {
+		result[f.GetName()] = strings.Join(strings.Fields(f.GetContent()), " ")
+	}
+	return result
+}
+
+func TestGenerate(t *testing.T) {
+	files := runGenerator(t, []protoreflect.FileDescriptor{email.File_spec_proto_extension_v1_email_email_proto}, nil)
+
+	t.Run("component", func(t *testing.T) {
+		assert.Contains(t, files["components/email/interface_generated.go"], "type EmailService interface")
+		assert.Contains(t, files["components/email/interface_generated.go"], "Init(context.Context, *Config) error")
+		assert.Contains(t, files["components/email/interface_generated.go"],
+			"SendEmail(context.Context, *SendEmailRequest) (*SendEmailResponse, error)")
+		assert.Contains(t, files["components/email/struct_generated.go"],
+			"TemplateParams map[string]string `json:\"template_params,omitempty\"`")
+		assert.Contains(t, files["components/email/struct_generated.go"],
+			"Address *EmailAddress `json:\"address,omitempty\"`")
+		assert.Contains(t, files["components/email/types_generated.go"], "func NewRegistry(info *info.RuntimeInfo) Registry")
+	})
+
+	t.Run("server", func(t *testing.T) {
+		server := files["grpc/email/server.go"]
+		assert.Contains(t, server, "components: ac.EmailService,")
+		assert.Contains(t, server, "resp, err := comp.SendEmailWithTemplate(ctx, req)")
+		assert.Contains(t, server, "email1.RegisterEmailServiceServer(rawGrpcServer, s)")
+	})
+
+	t.Run("runtime", func(t *testing.T) {
+		assert.Contains(t, files["runtime/component_generated.go"], "func (m *MosnRuntime) initEmailService(factorys ...*email.Factory) error")
+		assert.Contains(t, files["runtime/component_generated.go"], "m.initEmailService(s.email...)")
+		assert.Contains(t, files["runtime/config_generated.go"], "EmailService map[string]email.Config `json:\"email\"`")
+		assert.Contains(t, files["runtime/options_generated.go"], "func WithEmailServiceFactory(email ...*email.Factory) Option")
+		assert.Contains(t, files["runtime/context_generated.go"], "EmailService: m.emailService,")
+		assert.Contains(t, files["runtime/extension_api_generated.go"], "email.NewAPI,")
+		assert.Contains(t, files["grpc/context_generated.go"], "EmailService map[string]email.EmailService")
+	})
+
+	t.Run("sdk", func(t *testing.T) {
+		assert.Contains(t, files["client/client_generated.go"], "EmailServiceClient: email.NewEmailServiceClient(conn),")
+	})
+}
+
+func TestGenerateExtends(t *testing.T) {
+	files := runGenerator(t, []protoreflect.FileDescriptor{delay_queue.File_spec_proto_extension_v1_delay_queue_delay_queue_proto}, map[string]string{
+		delay_queue.File_spec_proto_extension_v1_delay_queue_delay_queue_proto.Path(): " @exclude extends pub_subs ",
+	})
+
+	assert.Contains(t, files["components/delay_queue/interface_generated.go"],
+		"PublishDelayMessage(context.Context, *DelayMessageRequest) (*DelayMessageResponse, error)")
+	assert.NotContains(t, files["components/delay_queue/interface_generated.go"], "Init(")
+	_, ok := files["components/delay_queue/types_generated.go"]
+	assert.False(t, ok)
+	assert.Contains(t, files["grpc/delay_queue/server.go"], "for k, v := range ac.PubSubs {")
+	assert.Contains(t, files["runtime/extension_api_generated.go"], "delay_queue.NewAPI,")
+	assert.NotContains(t, files["runtime/component_generated.go"], "delay_queue")
+}
+
+func TestGenerateSkip(t *testing.T) {
+	files := runGenerator(t, []protoreflect.FileDescriptor{emptypb.File_google_protobuf_empty_proto, s3.File_oss_proto}, map[string]string{
+		s3.File_oss_proto.Path(): " @exclude skip code_generator ",
+	})
+	assert.Len(t, files, 0)
+}
+
+func TestGenerateSkipRepeated(t *testing.T) {
+	files := runGenerator(t, []protoreflect.FileDescriptor{emptypb.File_google_protobuf_empty_proto, s3.File_oss_proto}, map[string]string{
+		s3.File_oss_proto.Path(): " @exclude skip code_generator \n @exclude skip quickstart_generator ",
+	})
+	assert.Len(t, files, 0)
+}
+
+func TestParseDirectives(t *testing.T) {
+	directives := parseDirectives(protogen.CommentSet{
+		Leading:         " @exclude skip quickstart_generator ",
+		LeadingDetached: []protogen.Comments{" @exclude skip code_generator \n @exclude extends pub_subs "},
+	})
+	assert.Equal(t, map[string][]string{
+		directiveSkip:    {"quickstart_generator", "code_generator"},
+		directiveExtends: {"pub_subs"},
+	}, directives)
+}
+
+func TestCamelCase(t *testing.T) {
+	assert.Equal(t, "PubSubs", camelCase("pub_subs"))
+	assert.Equal(t, "Oss", camelCase("oss"))
+}
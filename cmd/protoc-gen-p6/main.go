@@ -0,0 +1,60 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// protoc-gen-p6 is a protoc plugin which generates the sidecar and sdk code for Layotto extension APIs.
+//
+// For every proto file that declares a gRPC service it generates:
+//   - components/<pkg>: the component interface, the request/response structs and the registry/factory
+//   - grpc/<pkg>: the gRPC server skeleton which delegates to the components
+//   - grpc/context_generated.go: the ApplicationContext holding all the components
+//   - runtime/*_generated.go: the runtime config, options and init wiring
+//   - client/client_generated.go: the go-sdk client methods
+//
+// The generation of a proto file can be tuned with comment directives above its service:
+//   - /* @exclude skip code_generator */ skips the file.
+//   - /* @exclude extends pub_subs */ means the API is implemented by an existing kind of component
+//     (here the pubsub components), so no registry or runtime wiring is generated for it.
+//
+// Usage:
+//
+//	protoc -I . --p6_out _output/tmp --p6_opt=paths=source_relative xxx.proto
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+const version = "v0.1.0"
+
+func main() {
+	if len(os.Args) == 2 && os.Args[1] == "--version" {
+		fmt.Fprintf(os.Stdout, "protoc-gen-p6 %v\n", version)
+		os.Exit(0)
+	}
+
+	var flags flag.FlagSet
+	protogen.Options{
+		ParamFunc: flags.Set,
+	}.Run(func(gen *protogen.Plugin) error {
+		gen.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+		return generate(gen)
+	})
+}
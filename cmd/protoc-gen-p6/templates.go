@@ -0,0 +1,533 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"text/template"
+)
+
+const license = `// Copyright 2021 Layotto Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+`
+
+const header = `// Code generated by mosn.io/layotto/cmd/protoc-gen-p6 .
+
+` + license
+
+const headerDoNotEdit = `// Code generated by mosn.io/layotto/cmd/protoc-gen-p6. DO NOT EDIT.
+
+` + license
+
+func newTemplate(name string, text string) *template.Template {
+	return template.Must(template.New(name).Parse(text))
+}
+
+var interfaceTemplate = newTemplate("interface", header+`
+package {{.PkgName}}
+
+import (
+	context "context"
+{{range $path, $name := .Imports}}
+	{{$name}} "{{$path}}"
+{{- end}}
+)
+
+type {{.ServiceName}} interface {
+{{- if .HasComponent}}
+	Init(context.Context, *Config) error
+{{end}}
+{{- range .Methods}}
+{{if .Comments}}{{.Comments}}
+{{end}}	{{.Name}}(context.Context, *{{.Input}}) (*{{.Output}}, error)
+{{end -}}
+}
+`)
+
+var structTemplate = newTemplate("struct", header+`
+package {{.PkgName}}
+{{if .Imports}}
+import (
+{{- range $path, $name := .Imports}}
+	{{$name}} "{{$path}}"
+{{- end}}
+)
+{{end}}
+{{- range .Enums}}
+{{$enum := .Name}}
+{{- if .Comments}}{{.Comments}}
+{{end}}type {{.Name}} int32
+
+const (
+{{- range .Values}}
+{{if .Comments}}{{.Comments}}
+{{end}}	{{.Name}} {{$enum}} = {{.Number}}
+{{- end}}
+)
+{{end}}
+{{- range .Structs}}
+{{if .Comments}}{{.Comments}}
+{{end}}type {{.Name}} struct {
+{{- range .Fields}}
+{{if .Comments}}{{.Comments}}
+{{end}}	{{.Name}} {{.Type}} `+"`"+`json:"{{.JSONName}},omitempty"`+"`"+`
+{{- end}}
+}
+{{end -}}
+`)
+
+var typesTemplate = newTemplate("types", header+`
+package {{.PkgName}}
+
+import (
+	fmt "fmt"
+
+	info "mosn.io/layotto/components/pkg/info"
+	ref "mosn.io/layotto/components/ref"
+)
+
+const (
+	serviceName = "{{.PkgName}}"
+)
+
+// Config is the component's configuration
+type Config struct {
+	ref.Config
+	Type     string            `+"`json:\"type\"`"+`
+	Metadata map[string]string `+"`json:\"metadata\"`"+`
+}
+
+type Registry interface {
+	Register(fs ...*Factory)
+	Create(compType string) ({{.ServiceName}}, error)
+}
+
+type Factory struct {
+	CompType      string
+	FactoryMethod func() {{.ServiceName}}
+}
+
+func NewFactory(compType string, f func() {{.ServiceName}}) *Factory {
+	return &Factory{
+		CompType:      compType,
+		FactoryMethod: f,
+	}
+}
+
+type registry struct {
+	stores map[string]func() {{.ServiceName}}
+	info   *info.RuntimeInfo
+}
+
+func NewRegistry(info *info.RuntimeInfo) Registry {
+	info.AddService(serviceName)
+	return &registry{
+		stores: make(map[string]func() {{.ServiceName}}),
+		info:   info,
+	}
+}
+
+func (r *registry) Register(fs ...*Factory) {
+	for _, f := range fs {
+		r.stores[f.CompType] = f.FactoryMethod
+		r.info.RegisterComponent(serviceName, f.CompType)
+	}
+}
+
+func (r *registry) Create(compType string) ({{.ServiceName}}, error) {
+	if f, ok := r.stores[compType]; ok {
+		r.info.LoadComponent(serviceName, compType)
+		return f(), nil
+	}
+	return nil, fmt.Errorf("service component %s is not registered", compType)
+}
+`)
+
+var serverTemplate = newTemplate("server", headerDoNotEdit+`
+package {{.PkgName}}
+
+import (
+{{- if .UnaryMethods}}
+	"context"
+{{- end}}
+	"fmt"
+{{if .UnaryMethods}}
+	"github.com/jinzhu/copier"
+{{- end}}
+	"mosn.io/pkg/log"
+
+	{{.PkgName}} "mosn.io/layotto/components/{{.PkgName}}"
+	{{.PkgName}}1 "{{.ImportPath}}"
+
+	rawGRPC "google.golang.org/grpc"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	grpc_api "mosn.io/layotto/pkg/grpc"
+)
+
+func NewAPI(ac *grpc_api.ApplicationContext) grpc_api.GrpcAPI {
+{{- if .HasComponent}}
+	return &server{
+		appId:      ac.AppId,
+		components: ac.{{.FieldName}},
+	}
+{{- else}}
+	result := &server{
+		appId:      ac.AppId,
+		components: make(map[string]{{.PkgName}}.{{.ServiceName}}),
+	}
+
+	for k, v := range ac.{{.Extends}} {
+		comp, ok := v.({{.PkgName}}.{{.ServiceName}})
+		if !ok {
+			continue
+		}
+		// put it in the components map
+		result.components[k] = comp
+	}
+	return result
+{{- end}}
+}
+
+type server struct {
+	appId      string
+	components map[string]{{.PkgName}}.{{.ServiceName}}
+}
+{{range .Methods}}
+{{- if .ClientStreaming}}
+func (s *server) {{.Name}}(stream {{$.PkgName}}1.{{$.ServiceName}}_{{.Name}}Server) error {
+	return status.Errorf(codes.Unimplemented, "method {{.Name}} not implemented")
+}
+{{else if .ServerStreaming}}
+func (s *server) {{.Name}}(in *{{$.PkgName}}1.{{.Input}}, stream {{$.PkgName}}1.{{$.ServiceName}}_{{.Name}}Server) error {
+	return status.Errorf(codes.Unimplemented, "method {{.Name}} not implemented")
+}
+{{else}}
+func (s *server) {{.Name}}(ctx context.Context, in *{{$.PkgName}}1.{{.Input}}) (*{{$.PkgName}}1.{{.Output}}, error) {
+	// find the component
+	comp := s.components[in.ComponentName]
+	if comp == nil {
+		return nil, invalidArgumentError("{{.Name}}", grpc_api.ErrComponentNotFound, "{{$.PkgName}}", in.ComponentName)
+	}
+
+	// convert request
+	req := &{{$.PkgName}}.{{.Input}}{}
+	err := copier.CopyWithOption(req, in, copier.Option{IgnoreEmpty: true, DeepCopy: true, Converters: []copier.TypeConverter{}})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Error when converting the request: %s", err.Error())
+	}
+
+	// delegate to the component
+	resp, err := comp.{{.Name}}(ctx, req)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	// convert response
+	out := &{{$.PkgName}}1.{{.Output}}{}
+	err = copier.CopyWithOption(out, resp, copier.Option{IgnoreEmpty: true, DeepCopy: true, Converters: []copier.TypeConverter{}})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Error when converting the response: %s", err.Error())
+	}
+	return out, nil
+}
+{{end}}
+{{- end}}
+func invalidArgumentError(method string, format string, a ...interface{}) error {
+	err := status.Errorf(codes.InvalidArgument, format, a...)
+	log.DefaultLogger.Errorf(fmt.Sprintf("%s fail: %+v", method, err))
+	return err
+}
+
+func (s *server) Init(conn *rawGRPC.ClientConn) error {
+	return nil
+}
+
+func (s *server) Register(rawGrpcServer *rawGRPC.Server) error {
+	{{.PkgName}}1.Register{{.ServiceName}}Server(rawGrpcServer, s)
+	return nil
+}
+`)
+
+var applicationContextTemplate = newTemplate("application_context", header+`
+package grpc
+
+import (
+	bindings "github.com/dapr/components-contrib/bindings"
+	pubsub "github.com/dapr/components-contrib/pubsub"
+	secretstores "github.com/dapr/components-contrib/secretstores"
+	state "github.com/dapr/components-contrib/state"
+
+	configstores "mosn.io/layotto/components/configstores"
+	custom "mosn.io/layotto/components/custom"
+	file "mosn.io/layotto/components/file"
+	hello "mosn.io/layotto/components/hello"
+	lock "mosn.io/layotto/components/lock"
+	oss "mosn.io/layotto/components/oss"
+	common "mosn.io/layotto/components/pkg/common"
+	rpc "mosn.io/layotto/components/rpc"
+	sequencer "mosn.io/layotto/components/sequencer"
+	lifecycle "mosn.io/layotto/pkg/runtime/lifecycle"
+{{- range .}}
+	{{.PkgName}} "mosn.io/layotto/components/{{.PkgName}}"
+{{- end}}
+)
+
+// ApplicationContext contains all you need to construct your GrpcAPI, such as all the components.
+// For example, your "SuperState" GrpcAPI can hold the "StateStores" components and use them to implement your own "Super State API" logic.
+type ApplicationContext struct {
+	AppId                 string
+	Hellos                map[string]hello.HelloService
+	ConfigStores          map[string]configstores.Store
+	Rpcs                  map[string]rpc.Invoker
+	PubSubs               map[string]pubsub.PubSub
+	StateStores           map[string]state.Store
+	Files                 map[string]file.File
+	Oss                   map[string]oss.Oss
+	LockStores            map[string]lock.LockStore
+	Sequencers            map[string]sequencer.Store
+	SendToOutputBindingFn func(name string, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error)
+	SecretStores          map[string]secretstores.SecretStore
+	DynamicComponents     map[lifecycle.ComponentKey]common.DynamicComponent
+	CustomComponent       map[string]map[string]custom.Component
+{{- range .}}
+	{{.FieldName}} map[string]{{.PkgName}}.{{.ServiceName}}
+{{- end}}
+}
+`)
+
+var runtimeComponentTemplate = newTemplate("runtime_component", header+`
+package runtime
+
+import (
+	"context"
+
+	"mosn.io/pkg/log"
+{{range .}}
+	{{.PkgName}} "mosn.io/layotto/components/{{.PkgName}}"
+{{- end}}
+)
+
+type extensionComponents struct {
+{{- range .}}
+	{{.PrivateFieldName}} map[string]{{.PkgName}}.{{.ServiceName}}
+{{end -}}
+}
+
+func newExtensionComponents() *extensionComponents {
+	return &extensionComponents{
+{{- range .}}
+		{{.PrivateFieldName}}: make(map[string]{{.PkgName}}.{{.ServiceName}}),
+{{end -}}
+	}
+}
+{{range .}}
+func (m *MosnRuntime) init{{.ServiceName}}(factorys ...*{{.PkgName}}.Factory) error {
+	log.DefaultLogger.Infof("[runtime] init {{.ServiceName}}")
+
+	// 1. register all implementation
+	reg := {{.PkgName}}.NewRegistry(m.info)
+	reg.Register(factorys...)
+	// 2. loop initializing
+	for name, config := range m.runtimeConfig.{{.FieldName}} {
+		// 2.1. create the component
+		c, err := reg.Create(config.Type)
+		if err != nil {
+			m.errInt(err, "create the component %s failed", name)
+			return err
+		}
+		//inject secret to component
+		if config.Metadata, err = m.Injector.InjectSecretRef(config.SecretRef, config.Metadata); err != nil {
+			return err
+		}
+		// 2.2. init
+		if err := c.Init(context.TODO(), &config); err != nil {
+			m.errInt(err, "init the component %s failed", name)
+			return err
+		}
+		m.{{.PrivateFieldName}}[name] = c
+	}
+	return nil
+}
+{{end}}
+func (m *MosnRuntime) initExtensionComponent(s services) error {
+{{- range .}}
+	if err := m.init{{.ServiceName}}(s.{{.PkgName}}...); err != nil {
+		return err
+	}
+{{end}}
+	return nil
+}
+`)
+
+var runtimeConfigTemplate = newTemplate("runtime_config", header+`
+package runtime
+
+import (
+{{- range .}}
+	{{.PkgName}} "mosn.io/layotto/components/{{.PkgName}}"
+{{- end}}
+)
+
+type ExtensionComponentConfig struct {
+{{- range .}}
+	// "{{.ImportPath}}"
+	// {{.PkgName}}.
+	{{.FieldName}} map[string]{{.PkgName}}.Config `+"`"+`json:"{{.PkgName}}"`+"`"+`
+{{end -}}
+}
+`)
+
+var runtimeOptionsTemplate = newTemplate("runtime_options", header+`
+package runtime
+
+import (
+{{- range .}}
+	{{.PkgName}} "mosn.io/layotto/components/{{.PkgName}}"
+{{- end}}
+)
+
+type extensionComponentFactorys struct {
+{{- range .}}
+	// "{{.ImportPath}}"
+	// {{.PkgName}}.
+	{{.PkgName}} []*{{.PkgName}}.Factory
+{{end -}}
+}
+{{range .}}
+func With{{.ServiceName}}Factory({{.PkgName}} ...*{{.PkgName}}.Factory) Option {
+	return func(o *runtimeOptions) {
+		o.services.{{.PkgName}} = append(o.services.{{.PkgName}}, {{.PkgName}}...)
+	}
+}
+{{end -}}
+`)
+
+var runtimeContextTemplate = newTemplate("runtime_context", header+`
+package runtime
+
+import (
+	grpc "mosn.io/layotto/pkg/grpc"
+)
+
+func newApplicationContext(m *MosnRuntime) *grpc.ApplicationContext {
+	return &grpc.ApplicationContext{
+		AppId:                 m.runtimeConfig.AppManagement.AppId,
+		Hellos:                m.hellos,
+		ConfigStores:          m.configStores,
+		Rpcs:                  m.rpcs,
+		PubSubs:               m.pubSubs,
+		StateStores:           m.states,
+		Files:                 m.files,
+		Oss:                   m.oss,
+		LockStores:            m.locks,
+		Sequencers:            m.sequencers,
+		SendToOutputBindingFn: m.sendToOutputBinding,
+		SecretStores:          m.secretStores,
+		DynamicComponents:     m.dynamicComponents,
+		CustomComponent:       m.customComponent,
+{{- range .}}
+		{{.FieldName}}: m.{{.PrivateFieldName}},
+{{- end}}
+	}
+}
+`)
+
+var runtimeAPITemplate = newTemplate("runtime_api", header+`
+package runtime
+
+import (
+	s3 "mosn.io/layotto/pkg/grpc/extension/s3"
+{{- range .}}
+	{{.PkgName}} "mosn.io/layotto/pkg/grpc/{{.PkgName}}"
+{{- end}}
+)
+
+func WithExtensionGrpcAPI() Option {
+	return WithGrpcAPI(
+		s3.NewS3Server,
+{{- range .}}
+		{{.PkgName}}.NewAPI,
+{{- end}}
+	)
+}
+`)
+
+var clientTemplate = newTemplate("client", header+`
+package client
+
+import (
+	context "context"
+
+	grpc "google.golang.org/grpc"
+
+	s3 "mosn.io/layotto/spec/proto/extension/v1/s3"
+	v1 "mosn.io/layotto/spec/proto/runtime/v1"
+{{- range .}}
+	{{.PkgName}} "{{.ImportPath}}"
+{{- end}}
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+
+// Client is the interface for runtime client implementation.
+type Client interface {
+	runtimeAPI
+
+	s3.ObjectStorageServiceClient
+{{range .}}
+	// "{{.ImportPath}}"
+	{{.PkgName}}.{{.ServiceName}}Client
+{{end -}}
+}
+
+// NewClientWithConnection instantiates runtime client using specific connection.
+func NewClientWithConnection(conn *grpc.ClientConn) Client {
+	return &GRPCClient{
+		connection:                 conn,
+		protoClient:                v1.NewRuntimeClient(conn),
+		ObjectStorageServiceClient: s3.NewObjectStorageServiceClient(conn),
+{{- range .}}
+		// "{{.ImportPath}}"
+		{{.ServiceName}}Client: {{.PkgName}}.New{{.ServiceName}}Client(conn),
+{{end -}}
+	}
+}
+
+// GRPCClient is the gRPC implementation of runtime client.
+type GRPCClient struct {
+	connection  *grpc.ClientConn
+	protoClient v1.RuntimeClient
+	s3.ObjectStorageServiceClient
+{{- range .}}
+	// "{{.ImportPath}}"
+	{{.PkgName}}.{{.ServiceName}}Client
+{{- end}}
+}
+`)
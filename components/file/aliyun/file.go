@@ -17,6 +17,7 @@
 package aliyun
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -32,6 +33,8 @@ import (
 
 const (
 	storageTypeKey = "storageType"
+	// minPartSize is the minimum size of the parts except the last one
+	minPartSize = 100 << 10
 )
 
 // AliyunFile is a binding for an AliCloud OSS storage bucketKey
@@ -95,8 +98,17 @@ func (s *AliyunFile) Get(ctx context.Context, st *file.GetFileStu) (io.ReadClose
 	if err != nil {
 		return nil, fmt.Errorf("get file[%s] fail, err: %s", st.FileName, err.Error())
 	}
-
-	return bucket.GetObject(fileNameWithoutBucket)
+	if err = util.CheckRange(st.Offset, st.Length); err != nil {
+		return nil, err
+	}
+	if st.Offset == 0 && st.Length == 0 {
+		return bucket.GetObject(fileNameWithoutBucket)
+	}
+	r := fmt.Sprintf("%d-", st.Offset)
+	if st.Length > 0 {
+		r = fmt.Sprintf("%d-%d", st.Offset, st.Offset+st.Length-1)
+	}
+	return bucket.GetObject(fileNameWithoutBucket, oss.NormalizedRange(r))
 }
 
 func (s *AliyunFile) List(ctx context.Context, request *file.ListRequest) (*file.ListResp, error) {
@@ -214,3 +226,132 @@ func (s *AliyunFile) getClient() (*oss.Client, error) {
 	}
 	return s.client, nil
 }
+
+// InitUpload initiates a multipart upload, the chunks are uploaded as the parts
+func (s *AliyunFile) InitUpload(ctx context.Context, st *file.InitUploadStu) (*file.UploadSession, error) {
+	bucket, key, err := s.getBucketAndKey(st.FileName, st.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("init upload file[%s] fail, err: %s", st.FileName, err.Error())
+	}
+	if st.UploadId != "" {
+		chunkSize, uploadId, err := util.DecodeUploadId(st.UploadId)
+		if err != nil {
+			return nil, err
+		}
+		parts, err := s.listParts(bucket, key, uploadId)
+		if err != nil {
+			return nil, err
+		}
+		return &file.UploadSession{UploadId: st.UploadId, ChunkSize: chunkSize, Offset: util.UploadedOffset(parts, chunkSize)}, nil
+	}
+	chunkSize, err := util.ChunkSize(st.ChunkSize, minPartSize)
+	if err != nil {
+		return nil, err
+	}
+	imur, err := bucket.InitiateMultipartUpload(key)
+	if err != nil {
+		return nil, fmt.Errorf("init upload file[%s] fail, err: %s", st.FileName, err.Error())
+	}
+	return &file.UploadSession{UploadId: util.EncodeUploadId(chunkSize, imur.UploadID), ChunkSize: chunkSize}, nil
+}
+
+func (s *AliyunFile) PutChunk(ctx context.Context, st *file.PutChunkStu) error {
+	bucket, key, err := s.getBucketAndKey(st.FileName, st.Metadata)
+	if err != nil {
+		return fmt.Errorf("put chunk of file[%s] fail, err: %s", st.FileName, err.Error())
+	}
+	chunkSize, uploadId, err := util.DecodeUploadId(st.UploadId)
+	if err != nil {
+		return err
+	}
+	number, err := util.PartNumber(st.Offset, chunkSize)
+	if err != nil {
+		return err
+	}
+	data, err := util.ReadChunk(st.DataStream, chunkSize)
+	if err != nil {
+		return err
+	}
+	imur := oss.InitiateMultipartUploadResult{Bucket: bucket.BucketName, Key: key, UploadID: uploadId}
+	_, err = bucket.UploadPart(imur, bytes.NewReader(data), int64(len(data)), number)
+	return uploadError(err)
+}
+
+func (s *AliyunFile) CommitUpload(ctx context.Context, st *file.CommitUploadStu) error {
+	bucket, key, err := s.getBucketAndKey(st.FileName, st.Metadata)
+	if err != nil {
+		return fmt.Errorf("commit upload file[%s] fail, err: %s", st.FileName, err.Error())
+	}
+	chunkSize, uploadId, err := util.DecodeUploadId(st.UploadId)
+	if err != nil {
+		return err
+	}
+	parts, err := s.listParts(bucket, key, uploadId)
+	if err != nil {
+		return err
+	}
+	if err = util.CheckParts(parts, chunkSize); err != nil {
+		return err
+	}
+	completed := make([]oss.UploadPart, 0, len(parts))
+	for _, p := range parts {
+		completed = append(completed, oss.UploadPart{PartNumber: p.Number, ETag: p.ETag})
+	}
+	imur := oss.InitiateMultipartUploadResult{Bucket: bucket.BucketName, Key: key, UploadID: uploadId}
+	_, err = bucket.CompleteMultipartUpload(imur, completed)
+	return uploadError(err)
+}
+
+func (s *AliyunFile) AbortUpload(ctx context.Context, st *file.AbortUploadStu) error {
+	bucket, key, err := s.getBucketAndKey(st.FileName, st.Metadata)
+	if err != nil {
+		return fmt.Errorf("abort upload file[%s] fail, err: %s", st.FileName, err.Error())
+	}
+	_, uploadId, err := util.DecodeUploadId(st.UploadId)
+	if err != nil {
+		return err
+	}
+	imur := oss.InitiateMultipartUploadResult{Bucket: bucket.BucketName, Key: key, UploadID: uploadId}
+	return uploadError(bucket.AbortMultipartUpload(imur))
+}
+
+func (s *AliyunFile) getBucketAndKey(fileName string, metaData map[string]string) (*oss.Bucket, string, error) {
+	bucket, err := s.getBucket(fileName, metaData)
+	if err != nil {
+		return nil, "", err
+	}
+	key, err := util.GetFileName(fileName)
+	if err != nil {
+		return nil, "", err
+	}
+	return bucket, key, nil
+}
+
+func (s *AliyunFile) listParts(bucket *oss.Bucket, key, uploadId string) ([]util.Part, error) {
+	imur := oss.InitiateMultipartUploadResult{Bucket: bucket.BucketName, Key: key, UploadID: uploadId}
+	var parts []util.Part
+	marker := 0
+	for {
+		out, err := bucket.ListUploadedParts(imur, oss.PartNumberMarker(marker))
+		if err != nil {
+			return nil, uploadError(err)
+		}
+		for _, p := range out.UploadedParts {
+			parts = append(parts, util.Part{Number: p.PartNumber, Size: int64(p.Size), ETag: p.ETag})
+		}
+		if !out.IsTruncated {
+			return parts, nil
+		}
+		if marker, err = strconv.Atoi(out.NextPartNumberMarker); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// uploadError converts the error of an unknown upload id to file.ErrNotExist
+func uploadError(err error) error {
+	if e, ok := err.(oss.ServiceError); ok && e.Code == "NoSuchUpload" {
+		return file.ErrNotExist
+	}
+	return err
+}
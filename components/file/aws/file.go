@@ -17,6 +17,7 @@
 package aws
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -31,6 +32,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 
 	"mosn.io/layotto/components/file"
 	"mosn.io/layotto/components/file/util"
@@ -38,6 +40,8 @@ import (
 
 const (
 	defaultCredentialsSource = "provider"
+	// minPartSize is the minimum size of the parts except the last one
+	minPartSize = 5 << 20
 )
 
 // AwsOss is a binding for aws oss storage.
@@ -69,6 +73,15 @@ func (a *AwsOss) Init(ctx context.Context, config *file.FileConfig) error {
 	return nil
 }
 
+// Ping checks the connection to aws s3 by listing the buckets
+func (a *AwsOss) Ping(ctx context.Context) error {
+	if a.client == nil {
+		return utils.ErrNotInitClient
+	}
+	_, err := a.client.ListBuckets(ctx, &s3.ListBucketsInput{})
+	return err
+}
+
 // isAwsMetaValid check if the metadata valid.
 func (a *AwsOss) isAwsMetaValid(v *utils.OssMetadata) bool {
 	if v.AccessKeySecret == "" || v.Endpoint == "" || v.AccessKeyID == "" {
@@ -136,10 +149,16 @@ func (a *AwsOss) Get(ctx context.Context, st *file.GetFileStu) (io.ReadCloser, e
 	if err != nil {
 		return nil, fmt.Errorf("aws.s3 get file[%s] fail,err: %s", st.FileName, err.Error())
 	}
+	if err = util.CheckRange(st.Offset, st.Length); err != nil {
+		return nil, err
+	}
 	input := &s3.GetObjectInput{
 		Bucket: &bucket,
 		Key:    &key,
 	}
+	if r := util.RangeHeader(st.Offset, st.Length); r != "" {
+		input.Range = &r
+	}
 	client, err := a.selectClient()
 	if err != nil {
 		return nil, err
@@ -240,3 +259,157 @@ func (a *AwsOss) Stat(ctx context.Context, st *file.FileMetaRequest) (*file.File
 	}
 	return resp, nil
 }
+
+// InitUpload creates a multipart upload, the chunks are uploaded as the parts
+func (a *AwsOss) InitUpload(ctx context.Context, st *file.InitUploadStu) (*file.UploadSession, error) {
+	bucket, key, err := a.splitFileName(st.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("aws.s3 init upload file[%s] fail,err: %s", st.FileName, err.Error())
+	}
+	client, err := a.selectClient()
+	if err != nil {
+		return nil, err
+	}
+	if st.UploadId != "" {
+		chunkSize, uploadId, err := util.DecodeUploadId(st.UploadId)
+		if err != nil {
+			return nil, err
+		}
+		parts, err := a.listParts(ctx, client, bucket, key, uploadId)
+		if err != nil {
+			return nil, err
+		}
+		return &file.UploadSession{UploadId: st.UploadId, ChunkSize: chunkSize, Offset: util.UploadedOffset(parts, chunkSize)}, nil
+	}
+	chunkSize, err := util.ChunkSize(st.ChunkSize, minPartSize)
+	if err != nil {
+		return nil, err
+	}
+	out, err := client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{Bucket: &bucket, Key: &key})
+	if err != nil {
+		return nil, err
+	}
+	return &file.UploadSession{UploadId: util.EncodeUploadId(chunkSize, *out.UploadId), ChunkSize: chunkSize}, nil
+}
+
+func (a *AwsOss) PutChunk(ctx context.Context, st *file.PutChunkStu) error {
+	bucket, key, err := a.splitFileName(st.FileName)
+	if err != nil {
+		return fmt.Errorf("aws.s3 put chunk of file[%s] fail,err: %s", st.FileName, err.Error())
+	}
+	chunkSize, uploadId, err := util.DecodeUploadId(st.UploadId)
+	if err != nil {
+		return err
+	}
+	number, err := util.PartNumber(st.Offset, chunkSize)
+	if err != nil {
+		return err
+	}
+	data, err := util.ReadChunk(st.DataStream, chunkSize)
+	if err != nil {
+		return err
+	}
+	client, err := a.selectClient()
+	if err != nil {
+		return err
+	}
+	_, err = client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:        &bucket,
+		Key:           &key,
+		UploadId:      &uploadId,
+		PartNumber:    int32(number),
+		Body:          bytes.NewReader(data),
+		ContentLength: int64(len(data)),
+	})
+	return a.uploadError(err)
+}
+
+func (a *AwsOss) CommitUpload(ctx context.Context, st *file.CommitUploadStu) error {
+	bucket, key, err := a.splitFileName(st.FileName)
+	if err != nil {
+		return fmt.Errorf("aws.s3 commit upload file[%s] fail,err: %s", st.FileName, err.Error())
+	}
+	chunkSize, uploadId, err := util.DecodeUploadId(st.UploadId)
+	if err != nil {
+		return err
+	}
+	client, err := a.selectClient()
+	if err != nil {
+		return err
+	}
+	parts, err := a.listParts(ctx, client, bucket, key, uploadId)
+	if err != nil {
+		return err
+	}
+	if err = util.CheckParts(parts, chunkSize); err != nil {
+		return err
+	}
+	completed := &types.CompletedMultipartUpload{}
+	for _, p := range parts {
+		etag := p.ETag
+		completed.Parts = append(completed.Parts, types.CompletedPart{ETag: &etag, PartNumber: int32(p.Number)})
+	}
+	_, err = client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          &bucket,
+		Key:             &key,
+		UploadId:        &uploadId,
+		MultipartUpload: completed,
+	})
+	return a.uploadError(err)
+}
+
+func (a *AwsOss) AbortUpload(ctx context.Context, st *file.AbortUploadStu) error {
+	bucket, key, err := a.splitFileName(st.FileName)
+	if err != nil {
+		return fmt.Errorf("aws.s3 abort upload file[%s] fail,err: %s", st.FileName, err.Error())
+	}
+	_, uploadId, err := util.DecodeUploadId(st.UploadId)
+	if err != nil {
+		return err
+	}
+	client, err := a.selectClient()
+	if err != nil {
+		return err
+	}
+	_, err = client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{Bucket: &bucket, Key: &key, UploadId: &uploadId})
+	return a.uploadError(err)
+}
+
+func (a *AwsOss) splitFileName(fileName string) (string, string, error) {
+	bucket, err := util.GetBucketName(fileName)
+	if err != nil {
+		return "", "", err
+	}
+	key, err := util.GetFileName(fileName)
+	if err != nil {
+		return "", "", err
+	}
+	return bucket, key, nil
+}
+
+func (a *AwsOss) listParts(ctx context.Context, client *s3.Client, bucket, key, uploadId string) ([]util.Part, error) {
+	var parts []util.Part
+	input := &s3.ListPartsInput{Bucket: &bucket, Key: &key, UploadId: &uploadId}
+	for {
+		out, err := client.ListParts(ctx, input)
+		if err != nil {
+			return nil, a.uploadError(err)
+		}
+		for _, p := range out.Parts {
+			parts = append(parts, util.Part{Number: int(p.PartNumber), Size: p.Size, ETag: aws.ToString(p.ETag)})
+		}
+		if !out.IsTruncated {
+			return parts, nil
+		}
+		input.PartNumberMarker = out.NextPartNumberMarker
+	}
+}
+
+// uploadError converts the error of an unknown upload id to file.ErrNotExist
+func (a *AwsOss) uploadError(err error) error {
+	var noSuchUpload *types.NoSuchUpload
+	if errors.As(err, &noSuchUpload) {
+		return file.ErrNotExist
+	}
+	return err
+}
@@ -26,4 +26,5 @@ var (
 	ErrExist      = errors.New("file already exists")
 	ErrNotExist   = errors.New("file does not exist")
 	ErrExpired    = errors.New("file expired")
+	ErrNotSupport = errors.New("method not supported")
 )
@@ -30,4 +30,12 @@ type File interface {
 	List(context.Context, *ListRequest) (*ListResp, error)
 	Del(context.Context, *DelRequest) error
 	Stat(context.Context, *FileMetaRequest) (*FileMetaResp, error)
+	// InitUpload starts a resumable upload session, or resumes the session if UploadId is specified
+	InitUpload(context.Context, *InitUploadStu) (*UploadSession, error)
+	// PutChunk uploads a chunk of the file at the offset
+	PutChunk(context.Context, *PutChunkStu) error
+	// CommitUpload composes the uploaded chunks into the file
+	CommitUpload(context.Context, *CommitUploadStu) error
+	// AbortUpload discards the uploaded chunks
+	AbortUpload(context.Context, *AbortUploadStu) error
 }
@@ -27,6 +27,7 @@ import (
 	"strconv"
 
 	"mosn.io/layotto/components/file"
+	"mosn.io/layotto/components/file/util"
 
 	store "go.beyondstorage.io/services/hdfs"
 	"go.beyondstorage.io/v5/pairs"
@@ -116,6 +117,9 @@ func (h *hdfs) Get(ctx context.Context, stu *file.GetFileStu) (io.ReadCloser, er
 	if _, ok := stu.Metadata[endpointKey]; !ok {
 		return nil, ErrMissingEndPoint
 	}
+	if err := util.CheckRange(stu.Offset, stu.Length); err != nil {
+		return nil, err
+	}
 	client, err := h.selectClient(stu.Metadata)
 	if err != nil {
 		return nil, err
@@ -128,7 +132,7 @@ func (h *hdfs) Get(ctx context.Context, stu *file.GetFileStu) (io.ReadCloser, er
 	}
 	r := ioutil.NopCloser(bytes.NewReader(w.Bytes()))
 
-	return r, nil
+	return util.NewRangeReader(r, stu.Offset, stu.Length)
 }
 
 func (h *hdfs) List(ctx context.Context, request *file.ListRequest) (*file.ListResp, error) {
@@ -260,3 +264,19 @@ func (h *hdfs) createHdfsClient(meta *HdfsMetaData) (types.Storager, error) {
 func (hm *HdfsMetaData) isHdfsMetaValid() bool {
 	return hm.EndPoint != ""
 }
+
+func (h *hdfs) InitUpload(ctx context.Context, st *file.InitUploadStu) (*file.UploadSession, error) {
+	return nil, file.ErrNotSupport
+}
+
+func (h *hdfs) PutChunk(ctx context.Context, st *file.PutChunkStu) error {
+	return file.ErrNotSupport
+}
+
+func (h *hdfs) CommitUpload(ctx context.Context, st *file.CommitUploadStu) error {
+	return file.ErrNotSupport
+}
+
+func (h *hdfs) AbortUpload(ctx context.Context, st *file.AbortUploadStu) error {
+	return file.ErrNotSupport
+}
@@ -18,14 +18,18 @@ package local
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
 	"mosn.io/layotto/components/file"
+	"mosn.io/layotto/components/file/util"
 )
 
 const (
@@ -90,12 +94,30 @@ func (lf *LocalStore) Put(ctx context.Context, f *file.PutFileStu) error {
 	return nil
 }
 func (lf *LocalStore) Get(ctx context.Context, f *file.GetFileStu) (io.ReadCloser, error) {
+	if err := util.CheckRange(f.Offset, f.Length); err != nil {
+		return nil, err
+	}
 	fileObj, err := os.Open(f.FileName)
 	if err != nil {
 		return nil, err
 	}
+	if f.Offset > 0 {
+		if _, err = fileObj.Seek(f.Offset, io.SeekStart); err != nil {
+			fileObj.Close()
+			return nil, err
+		}
+	}
+	if f.Length > 0 {
+		return &limitedFile{Reader: io.LimitReader(fileObj, f.Length), Closer: fileObj}, nil
+	}
 	return fileObj, nil
 }
+
+type limitedFile struct {
+	io.Reader
+	io.Closer
+}
+
 func (lf *LocalStore) List(ctx context.Context, f *file.ListRequest) (*file.ListResp, error) {
 	res := &file.ListResp{}
 	files, err := ioutil.ReadDir(f.DirectoryName)
@@ -166,3 +188,152 @@ func (lf *LocalStore) Stat(ctx context.Context, f *file.FileMetaRequest) (*file.
 	resp.Metadata[FileIsDir] = append(resp.Metadata[FileIsDir], isDir)
 	return resp, nil
 }
+
+// InitUpload creates a hidden directory next to the file, in which the chunks are saved as `<part number>`
+func (lf *LocalStore) InitUpload(ctx context.Context, st *file.InitUploadStu) (*file.UploadSession, error) {
+	if st.UploadId != "" {
+		chunkSize, dir, err := lf.uploadDir(st.FileName, st.UploadId)
+		if err != nil {
+			return nil, err
+		}
+		parts, err := lf.listParts(dir)
+		if err != nil {
+			return nil, err
+		}
+		return &file.UploadSession{UploadId: st.UploadId, ChunkSize: chunkSize, Offset: util.UploadedOffset(parts, chunkSize)}, nil
+	}
+	chunkSize, err := util.ChunkSize(st.ChunkSize, 1)
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 16)
+	if _, err = rand.Read(b); err != nil {
+		return nil, err
+	}
+	uploadId := util.EncodeUploadId(chunkSize, hex.EncodeToString(b))
+	_, dir, _ := lf.uploadDir(st.FileName, uploadId)
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &file.UploadSession{UploadId: uploadId, ChunkSize: chunkSize}, nil
+}
+
+func (lf *LocalStore) PutChunk(ctx context.Context, st *file.PutChunkStu) error {
+	chunkSize, dir, err := lf.uploadDir(st.FileName, st.UploadId)
+	if err != nil {
+		return err
+	}
+	number, err := util.PartNumber(st.Offset, chunkSize)
+	if err != nil {
+		return err
+	}
+	if _, err = os.Stat(dir); err != nil {
+		if os.IsNotExist(err) {
+			return file.ErrNotExist
+		}
+		return err
+	}
+	// write to a temporary file first, so that a broken chunk is never listed as uploaded
+	tmp, err := ioutil.TempFile(dir, "tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	n, err := io.Copy(tmp, io.LimitReader(st.DataStream, chunkSize+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if n > chunkSize {
+		return fmt.Errorf("%w: chunk is larger than %d", file.ErrInvalid, chunkSize)
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, strconv.Itoa(number)))
+}
+
+func (lf *LocalStore) CommitUpload(ctx context.Context, st *file.CommitUploadStu) error {
+	chunkSize, dir, err := lf.uploadDir(st.FileName, st.UploadId)
+	if err != nil {
+		return err
+	}
+	parts, err := lf.listParts(dir)
+	if err != nil {
+		return err
+	}
+	if err = util.CheckParts(parts, chunkSize); err != nil {
+		return err
+	}
+	mode := os.FileMode(0644)
+	if m, ok := st.Metadata[FileMode]; ok {
+		v, err := strconv.ParseUint(m, 10, 32)
+		if err != nil {
+			return fmt.Errorf("wrong fileMode value:%+v in metadata", err)
+		}
+		mode = os.FileMode(v)
+	}
+	fileObj, err := os.OpenFile(st.FileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer fileObj.Close()
+	for _, p := range parts {
+		if err = appendPart(fileObj, filepath.Join(dir, strconv.Itoa(p.Number))); err != nil {
+			return err
+		}
+	}
+	return os.RemoveAll(dir)
+}
+
+func (lf *LocalStore) AbortUpload(ctx context.Context, st *file.AbortUploadStu) error {
+	_, dir, err := lf.uploadDir(st.FileName, st.UploadId)
+	if err != nil {
+		return err
+	}
+	if _, err = os.Stat(dir); os.IsNotExist(err) {
+		return file.ErrNotExist
+	}
+	return os.RemoveAll(dir)
+}
+
+// uploadDir returns the chunk size and the directory of the session
+func (lf *LocalStore) uploadDir(fileName string, uploadId string) (int64, string, error) {
+	chunkSize, id, err := util.DecodeUploadId(uploadId)
+	if err != nil {
+		return 0, "", err
+	}
+	if strings.ContainsAny(id, `/\.`) {
+		return 0, "", fmt.Errorf("%w: invalid upload id %s", file.ErrInvalid, uploadId)
+	}
+	dir, base := filepath.Split(fileName)
+	return chunkSize, filepath.Join(dir, "."+base+".upload-"+id), nil
+}
+
+func (lf *LocalStore) listParts(dir string) ([]util.Part, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, file.ErrNotExist
+		}
+		return nil, err
+	}
+	parts := make([]util.Part, 0, len(infos))
+	for _, info := range infos {
+		number, err := strconv.Atoi(info.Name())
+		if err != nil {
+			continue
+		}
+		parts = append(parts, util.Part{Number: number, Size: info.Size()})
+	}
+	return parts, nil
+}
+
+func appendPart(w io.Writer, name string) error {
+	part, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer part.Close()
+	_, err = io.Copy(w, part)
+	return err
+}
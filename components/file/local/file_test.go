@@ -18,9 +18,13 @@ package local
 
 import (
 	"context"
+	"errors"
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -117,3 +121,79 @@ func TestFile(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, exist, false)
 }
+
+func TestGetRange(t *testing.T) {
+	dir, err := ioutil.TempDir("", "local-file")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "range.txt")
+	assert.Nil(t, ioutil.WriteFile(name, []byte("hello world"), 0644))
+
+	ls := &LocalStore{}
+	r, err := ls.Get(context.TODO(), &file.GetFileStu{FileName: name, Offset: 6, Length: 3})
+	assert.Nil(t, err)
+	data, _ := ioutil.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "wor", string(data))
+
+	r, err = ls.Get(context.TODO(), &file.GetFileStu{FileName: name, Offset: 6})
+	assert.Nil(t, err)
+	data, _ = ioutil.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "world", string(data))
+
+	_, err = ls.Get(context.TODO(), &file.GetFileStu{FileName: name, Offset: -1})
+	assert.True(t, errors.Is(err, file.ErrInvalid))
+}
+
+func TestUpload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "local-file")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "upload.txt")
+	ls := &LocalStore{}
+	ctx := context.TODO()
+
+	session, err := ls.InitUpload(ctx, &file.InitUploadStu{FileName: name, ChunkSize: 5})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), session.ChunkSize)
+	id := session.UploadId
+
+	err = ls.PutChunk(ctx, &file.PutChunkStu{FileName: name, UploadId: id, Offset: 0, DataStream: strings.NewReader("hello")})
+	assert.Nil(t, err)
+	err = ls.PutChunk(ctx, &file.PutChunkStu{FileName: name, UploadId: id, Offset: 10, DataStream: strings.NewReader("d")})
+	assert.Nil(t, err)
+	err = ls.PutChunk(ctx, &file.PutChunkStu{FileName: name, UploadId: id, Offset: 3, DataStream: strings.NewReader("lo")})
+	assert.True(t, errors.Is(err, file.ErrInvalid))
+	err = ls.PutChunk(ctx, &file.PutChunkStu{FileName: name, UploadId: id, Offset: 5, DataStream: strings.NewReader(" world")})
+	assert.True(t, errors.Is(err, file.ErrInvalid))
+	err = ls.CommitUpload(ctx, &file.CommitUploadStu{FileName: name, UploadId: id})
+	assert.True(t, errors.Is(err, file.ErrInvalid))
+
+	// resume the upload from the first missing chunk
+	session, err = ls.InitUpload(ctx, &file.InitUploadStu{FileName: name, UploadId: id})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), session.Offset)
+	err = ls.PutChunk(ctx, &file.PutChunkStu{FileName: name, UploadId: id, Offset: session.Offset, DataStream: strings.NewReader(" wor")})
+	assert.Nil(t, err)
+	err = ls.CommitUpload(ctx, &file.CommitUploadStu{FileName: name, UploadId: id})
+	assert.True(t, errors.Is(err, file.ErrInvalid))
+	err = ls.PutChunk(ctx, &file.PutChunkStu{FileName: name, UploadId: id, Offset: session.Offset, DataStream: strings.NewReader(" worl")})
+	assert.Nil(t, err)
+	err = ls.CommitUpload(ctx, &file.CommitUploadStu{FileName: name, UploadId: id})
+	assert.Nil(t, err)
+	data, _ := ioutil.ReadFile(name)
+	assert.Equal(t, "hello world", string(data))
+
+	// the session is removed once committed
+	_, err = ls.InitUpload(ctx, &file.InitUploadStu{FileName: name, UploadId: id})
+	assert.Equal(t, file.ErrNotExist, err)
+
+	session, err = ls.InitUpload(ctx, &file.InitUploadStu{FileName: name})
+	assert.Nil(t, err)
+	assert.Equal(t, file.DefaultChunkSize, session.ChunkSize)
+	assert.Nil(t, ls.AbortUpload(ctx, &file.AbortUploadStu{FileName: name, UploadId: session.UploadId}))
+	assert.Equal(t, file.ErrNotExist, ls.AbortUpload(ctx, &file.AbortUploadStu{FileName: name, UploadId: session.UploadId}))
+	infos, _ := ioutil.ReadDir(dir)
+	assert.Len(t, infos, 1)
+}
@@ -17,6 +17,7 @@
 package minio
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -36,6 +37,8 @@ import (
 const (
 	endpointKey = "endpoint"
 	fileSize    = "fileSize"
+	// minPartSize is the minimum size of the parts except the last one
+	minPartSize = 5 << 20
 )
 
 var (
@@ -85,6 +88,19 @@ func (m *MinioOss) Init(ctx context.Context, config *file.FileConfig) error {
 	return nil
 }
 
+// Ping checks the connections to the minio endpoints by listing the buckets
+func (m *MinioOss) Ping(ctx context.Context) error {
+	if len(m.client) == 0 {
+		return ErrClientNotExist
+	}
+	for endpoint, client := range m.client {
+		if _, err := client.ListBuckets(ctx); err != nil {
+			return fmt.Errorf("ping minio %s fail, err: %v", endpoint, err)
+		}
+	}
+	return nil
+}
+
 func (m *MinioOss) Put(ctx context.Context, st *file.PutFileStu) error {
 	var (
 		size int64 = -1
@@ -128,7 +144,19 @@ func (m *MinioOss) Get(ctx context.Context, st *file.GetFileStu) (io.ReadCloser,
 	if err != nil {
 		return nil, err
 	}
-	obj, err := core.Client.GetObject(ctx, bucket, key, minio.GetObjectOptions{})
+	if err = util.CheckRange(st.Offset, st.Length); err != nil {
+		return nil, err
+	}
+	opts := minio.GetObjectOptions{}
+	if st.Length > 0 {
+		err = opts.SetRange(st.Offset, st.Offset+st.Length-1)
+	} else if st.Offset > 0 {
+		err = opts.SetRange(st.Offset, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	obj, err := core.Client.GetObject(ctx, bucket, key, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -256,3 +284,131 @@ func (mm *MinioMetaData) isMinioMetaValid() bool {
 	}
 	return true
 }
+
+// InitUpload creates a multipart upload, the chunks are uploaded as the parts
+func (m *MinioOss) InitUpload(ctx context.Context, st *file.InitUploadStu) (*file.UploadSession, error) {
+	core, bucket, key, err := m.selectObject(st.FileName, st.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("minio init upload file[%s] fail,err: %s", st.FileName, err.Error())
+	}
+	if st.UploadId != "" {
+		chunkSize, uploadId, err := util.DecodeUploadId(st.UploadId)
+		if err != nil {
+			return nil, err
+		}
+		parts, err := m.listParts(ctx, core, bucket, key, uploadId)
+		if err != nil {
+			return nil, err
+		}
+		return &file.UploadSession{UploadId: st.UploadId, ChunkSize: chunkSize, Offset: util.UploadedOffset(parts, chunkSize)}, nil
+	}
+	chunkSize, err := util.ChunkSize(st.ChunkSize, minPartSize)
+	if err != nil {
+		return nil, err
+	}
+	uploadId, err := core.NewMultipartUpload(ctx, bucket, key, minio.PutObjectOptions{ContentType: "application/octet-stream"})
+	if err != nil {
+		return nil, err
+	}
+	return &file.UploadSession{UploadId: util.EncodeUploadId(chunkSize, uploadId), ChunkSize: chunkSize}, nil
+}
+
+func (m *MinioOss) PutChunk(ctx context.Context, st *file.PutChunkStu) error {
+	core, bucket, key, err := m.selectObject(st.FileName, st.Metadata)
+	if err != nil {
+		return fmt.Errorf("minio put chunk of file[%s] fail,err: %s", st.FileName, err.Error())
+	}
+	chunkSize, uploadId, err := util.DecodeUploadId(st.UploadId)
+	if err != nil {
+		return err
+	}
+	number, err := util.PartNumber(st.Offset, chunkSize)
+	if err != nil {
+		return err
+	}
+	data, err := util.ReadChunk(st.DataStream, chunkSize)
+	if err != nil {
+		return err
+	}
+	_, err = core.PutObjectPart(ctx, bucket, key, uploadId, number, bytes.NewReader(data), int64(len(data)), "", "", nil)
+	return uploadError(err)
+}
+
+func (m *MinioOss) CommitUpload(ctx context.Context, st *file.CommitUploadStu) error {
+	core, bucket, key, err := m.selectObject(st.FileName, st.Metadata)
+	if err != nil {
+		return fmt.Errorf("minio commit upload file[%s] fail,err: %s", st.FileName, err.Error())
+	}
+	chunkSize, uploadId, err := util.DecodeUploadId(st.UploadId)
+	if err != nil {
+		return err
+	}
+	parts, err := m.listParts(ctx, core, bucket, key, uploadId)
+	if err != nil {
+		return err
+	}
+	if err = util.CheckParts(parts, chunkSize); err != nil {
+		return err
+	}
+	completed := make([]minio.CompletePart, 0, len(parts))
+	for _, p := range parts {
+		completed = append(completed, minio.CompletePart{PartNumber: p.Number, ETag: p.ETag})
+	}
+	_, err = core.CompleteMultipartUpload(ctx, bucket, key, uploadId, completed, minio.PutObjectOptions{})
+	return uploadError(err)
+}
+
+func (m *MinioOss) AbortUpload(ctx context.Context, st *file.AbortUploadStu) error {
+	core, bucket, key, err := m.selectObject(st.FileName, st.Metadata)
+	if err != nil {
+		return fmt.Errorf("minio abort upload file[%s] fail,err: %s", st.FileName, err.Error())
+	}
+	_, uploadId, err := util.DecodeUploadId(st.UploadId)
+	if err != nil {
+		return err
+	}
+	return uploadError(core.AbortMultipartUpload(ctx, bucket, key, uploadId))
+}
+
+// selectObject returns the client, the bucket and the key of the file
+func (m *MinioOss) selectObject(fileName string, meta map[string]string) (*minio.Core, string, string, error) {
+	bucket, err := util.GetBucketName(fileName)
+	if err != nil {
+		return nil, "", "", err
+	}
+	key, err := util.GetFileName(fileName)
+	if err != nil {
+		return nil, "", "", err
+	}
+	core, err := m.selectClient(meta)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return core, bucket, key, nil
+}
+
+func (m *MinioOss) listParts(ctx context.Context, core *minio.Core, bucket, key, uploadId string) ([]util.Part, error) {
+	var parts []util.Part
+	marker := 0
+	for {
+		out, err := core.ListObjectParts(ctx, bucket, key, uploadId, marker, 1000)
+		if err != nil {
+			return nil, uploadError(err)
+		}
+		for _, p := range out.ObjectParts {
+			parts = append(parts, util.Part{Number: p.PartNumber, Size: p.Size, ETag: p.ETag})
+		}
+		if !out.IsTruncated {
+			return parts, nil
+		}
+		marker = out.NextPartNumberMarker
+	}
+}
+
+// uploadError converts the error of an unknown upload id to file.ErrNotExist
+func uploadError(err error) error {
+	if err != nil && minio.ToErrorResponse(err).Code == "NoSuchUpload" {
+		return file.ErrNotExist
+	}
+	return err
+}
@@ -24,6 +24,7 @@ import (
 	"strconv"
 
 	"mosn.io/layotto/components/file"
+	"mosn.io/layotto/components/file/util"
 )
 
 const (
@@ -141,12 +142,19 @@ func (q *QiniuOSS) Put(ctx context.Context, st *file.PutFileStu) error {
 }
 
 func (q *QiniuOSS) Get(ctx context.Context, st *file.GetFileStu) (io.ReadCloser, error) {
+	if err := util.CheckRange(st.Offset, st.Length); err != nil {
+		return nil, err
+	}
 	client, err := q.selectClient(st.Metadata)
 	if err != nil {
 		return nil, err
 	}
 
-	return client.get(ctx, st.FileName)
+	r, err := client.get(ctx, st.FileName)
+	if err != nil {
+		return nil, err
+	}
+	return util.NewRangeReader(r, st.Offset, st.Length)
 }
 
 func (q *QiniuOSS) List(ctx context.Context, st *file.ListRequest) (*file.ListResp, error) {
@@ -212,3 +220,19 @@ func (q *QiniuOSS) Stat(ctx context.Context, st *file.FileMetaRequest) (*file.Fi
 
 	return resp, nil
 }
+
+func (q *QiniuOSS) InitUpload(ctx context.Context, st *file.InitUploadStu) (*file.UploadSession, error) {
+	return nil, file.ErrNotSupport
+}
+
+func (q *QiniuOSS) PutChunk(ctx context.Context, st *file.PutChunkStu) error {
+	return file.ErrNotSupport
+}
+
+func (q *QiniuOSS) CommitUpload(ctx context.Context, st *file.CommitUploadStu) error {
+	return file.ErrNotSupport
+}
+
+func (q *QiniuOSS) AbortUpload(ctx context.Context, st *file.AbortUploadStu) error {
+	return file.ErrNotSupport
+}
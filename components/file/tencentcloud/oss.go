@@ -31,6 +31,7 @@ import (
 	"github.com/tencentyun/cos-go-sdk-v5"
 
 	"mosn.io/layotto/components/file"
+	"mosn.io/layotto/components/file/util"
 )
 
 const (
@@ -161,7 +162,14 @@ func (t *TencentCloudOSS) Get(ctx context.Context, st *file.GetFileStu) (io.Read
 		return nil, err
 	}
 
-	clientResp, err := client.Object.Get(ctx, st.FileName, nil)
+	if err = util.CheckRange(st.Offset, st.Length); err != nil {
+		return nil, err
+	}
+	var opt *cos.ObjectGetOptions
+	if r := util.RangeHeader(st.Offset, st.Length); r != "" {
+		opt = &cos.ObjectGetOptions{Range: r}
+	}
+	clientResp, err := client.Object.Get(ctx, st.FileName, opt)
 	if err != nil {
 		return nil, err
 	}
@@ -295,3 +303,19 @@ func (t *TencentCloudOSS) checkFileName(fileName string) error {
 
 	return nil
 }
+
+func (t *TencentCloudOSS) InitUpload(ctx context.Context, st *file.InitUploadStu) (*file.UploadSession, error) {
+	return nil, file.ErrNotSupport
+}
+
+func (t *TencentCloudOSS) PutChunk(ctx context.Context, st *file.PutChunkStu) error {
+	return file.ErrNotSupport
+}
+
+func (t *TencentCloudOSS) CommitUpload(ctx context.Context, st *file.CommitUploadStu) error {
+	return file.ErrNotSupport
+}
+
+func (t *TencentCloudOSS) AbortUpload(ctx context.Context, st *file.AbortUploadStu) error {
+	return file.ErrNotSupport
+}
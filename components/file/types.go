@@ -38,6 +38,9 @@ type PutFileStu struct {
 
 type GetFileStu struct {
 	FileName string
+	// Offset and Length select a range of the file, 0 Length means reading to the end
+	Offset   int64
+	Length   int64
 	Metadata map[string]string
 }
 
@@ -76,3 +79,48 @@ type FileMetaResp struct {
 	LastModified string
 	Metadata     map[string][]string
 }
+
+// DefaultChunkSize is the chunk size of the upload sessions if not specified
+const DefaultChunkSize int64 = 8 << 20
+
+// MaxChunkSize is the max chunk size of the upload sessions, as a chunk is buffered in memory before it's uploaded
+const MaxChunkSize int64 = 64 << 20
+
+type InitUploadStu struct {
+	FileName string
+	// UploadId is the session to resume, a new session is started if it's empty
+	UploadId string
+	// ChunkSize is the size of every chunk except the last one, DefaultChunkSize is used if it's 0.
+	// It can't be larger than MaxChunkSize
+	ChunkSize int64
+	Metadata  map[string]string
+}
+
+type UploadSession struct {
+	// UploadId identifies the session, it should be treated as an opaque string
+	UploadId  string
+	ChunkSize int64
+	// Offset is the size of the chunks uploaded continuously from the beginning, the upload can be resumed from it
+	Offset int64
+}
+
+type PutChunkStu struct {
+	FileName string
+	UploadId string
+	// Offset must be a multiple of the chunk size
+	Offset     int64
+	DataStream io.Reader
+	Metadata   map[string]string
+}
+
+type CommitUploadStu struct {
+	FileName string
+	UploadId string
+	Metadata map[string]string
+}
+
+type AbortUploadStu struct {
+	FileName string
+	UploadId string
+	Metadata map[string]string
+}
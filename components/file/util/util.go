@@ -18,7 +18,13 @@ package util
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
 	"strings"
+
+	"mosn.io/layotto/components/file"
 )
 
 const (
@@ -52,3 +58,143 @@ func GetFileName(fileName string) (string, error) {
 	}
 	return name, nil
 }
+
+// Part is a chunk uploaded as a part of the multipart upload
+type Part struct {
+	Number int
+	Size   int64
+	ETag   string
+}
+
+// EncodeUploadId wraps the upload id of the storage with the chunk size of the session,
+// so that the offsets of the chunks can be mapped to the part numbers without keeping any state
+func EncodeUploadId(chunkSize int64, uploadId string) string {
+	return strconv.FormatInt(chunkSize, 10) + "." + uploadId
+}
+
+// DecodeUploadId returns the chunk size and the upload id of the storage
+func DecodeUploadId(id string) (int64, string, error) {
+	index := strings.Index(id, ".")
+	if index == -1 {
+		return 0, "", fmt.Errorf("%w: invalid upload id %s", file.ErrInvalid, id)
+	}
+	chunkSize, err := strconv.ParseInt(id[:index], 10, 64)
+	// the chunk size is read from the client, so it's checked again to bound the memory used by ReadChunk
+	if err != nil || chunkSize <= 0 || chunkSize > file.MaxChunkSize || index == len(id)-1 {
+		return 0, "", fmt.Errorf("%w: invalid upload id %s", file.ErrInvalid, id)
+	}
+	return chunkSize, id[index+1:], nil
+}
+
+// ChunkSize returns the chunk size of a new session, min is the minimum part size of the storage
+func ChunkSize(size int64, min int64) (int64, error) {
+	if size == 0 {
+		size = file.DefaultChunkSize
+	}
+	if size < min {
+		return 0, fmt.Errorf("%w: chunk size must be at least %d", file.ErrInvalid, min)
+	}
+	if size > file.MaxChunkSize {
+		return 0, fmt.Errorf("%w: chunk size must be at most %d", file.ErrInvalid, file.MaxChunkSize)
+	}
+	return size, nil
+}
+
+// PartNumber maps the offset of a chunk to the part number, which starts from 1
+func PartNumber(offset int64, chunkSize int64) (int, error) {
+	if offset < 0 || offset%chunkSize != 0 {
+		return 0, fmt.Errorf("%w: offset %d is not a multiple of the chunk size %d", file.ErrInvalid, offset, chunkSize)
+	}
+	return int(offset/chunkSize) + 1, nil
+}
+
+// ReadChunk reads a chunk into memory, as the storages need the size of the part before uploading it
+func ReadChunk(r io.Reader, chunkSize int64) ([]byte, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, chunkSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > chunkSize {
+		return nil, fmt.Errorf("%w: chunk is larger than %d", file.ErrInvalid, chunkSize)
+	}
+	return data, nil
+}
+
+// UploadedOffset returns the size of the parts uploaded continuously from the first one
+func UploadedOffset(parts []Part, chunkSize int64) int64 {
+	sortParts(parts)
+	var offset int64
+	for i, p := range parts {
+		if p.Number != i+1 {
+			break
+		}
+		offset += p.Size
+		if p.Size < chunkSize {
+			break
+		}
+	}
+	return offset
+}
+
+// CheckParts checks that the parts are continuous from the first one, and only the last one is smaller than the chunk size
+func CheckParts(parts []Part, chunkSize int64) error {
+	sortParts(parts)
+	if len(parts) == 0 {
+		return fmt.Errorf("%w: no chunk uploaded", file.ErrInvalid)
+	}
+	for i, p := range parts {
+		if p.Number != i+1 {
+			return fmt.Errorf("%w: chunk at offset %d is missing", file.ErrInvalid, int64(i)*chunkSize)
+		}
+		if i != len(parts)-1 && p.Size != chunkSize {
+			return fmt.Errorf("%w: chunk at offset %d is incomplete", file.ErrInvalid, int64(i)*chunkSize)
+		}
+	}
+	return nil
+}
+
+func sortParts(parts []Part) {
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].Number < parts[j].Number
+	})
+}
+
+// CheckRange checks the range of GetFileStu
+func CheckRange(offset, length int64) error {
+	if offset < 0 || length < 0 {
+		return fmt.Errorf("%w: negative offset or length", file.ErrInvalid)
+	}
+	return nil
+}
+
+// RangeHeader returns the value of the http Range header, or "" for the whole file
+func RangeHeader(offset, length int64) string {
+	if length > 0 {
+		return fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+	}
+	if offset > 0 {
+		return fmt.Sprintf("bytes=%d-", offset)
+	}
+	return ""
+}
+
+type rangeReader struct {
+	io.Reader
+	io.Closer
+}
+
+// NewRangeReader skips the data before the offset and limits the length, for the storages which can't read a range
+func NewRangeReader(rc io.ReadCloser, offset, length int64) (io.ReadCloser, error) {
+	if offset == 0 && length == 0 {
+		return rc, nil
+	}
+	if _, err := io.CopyN(ioutil.Discard, rc, offset); err != nil && err != io.EOF {
+		rc.Close()
+		return nil, err
+	}
+	r := &rangeReader{Reader: rc, Closer: rc}
+	if length > 0 {
+		r.Reader = io.LimitReader(rc, length)
+	}
+	return r, nil
+}
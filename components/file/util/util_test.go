@@ -17,9 +17,15 @@
 package util
 
 import (
+	"errors"
+	"io/ioutil"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"mosn.io/layotto/components/file"
 )
 
 func TestGetBucketName(t *testing.T) {
@@ -45,3 +51,70 @@ func TestGetFileName(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "aaa", name)
 }
+
+func TestUploadId(t *testing.T) {
+	id := EncodeUploadId(1024, "upload.id")
+	chunkSize, uploadId, err := DecodeUploadId(id)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1024), chunkSize)
+	assert.Equal(t, "upload.id", uploadId)
+
+	for _, id := range []string{"", "upload", "a.upload", "0.upload", "1024.", strconv.FormatInt(file.MaxChunkSize+1, 10) + ".upload"} {
+		_, _, err = DecodeUploadId(id)
+		assert.True(t, errors.Is(err, file.ErrInvalid))
+	}
+}
+
+func TestChunk(t *testing.T) {
+	size, err := ChunkSize(0, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, file.DefaultChunkSize, size)
+	_, err = ChunkSize(10, 100)
+	assert.True(t, errors.Is(err, file.ErrInvalid))
+	size, err = ChunkSize(file.MaxChunkSize, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, file.MaxChunkSize, size)
+	_, err = ChunkSize(file.MaxChunkSize+1, 100)
+	assert.True(t, errors.Is(err, file.ErrInvalid))
+
+	n, err := PartNumber(200, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+	_, err = PartNumber(150, 100)
+	assert.True(t, errors.Is(err, file.ErrInvalid))
+
+	data, err := ReadChunk(strings.NewReader("hello"), 5)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", string(data))
+	_, err = ReadChunk(strings.NewReader("hello"), 4)
+	assert.True(t, errors.Is(err, file.ErrInvalid))
+}
+
+func TestParts(t *testing.T) {
+	parts := []Part{{Number: 2, Size: 10}, {Number: 1, Size: 10}, {Number: 4, Size: 10}}
+	assert.Equal(t, int64(20), UploadedOffset(parts, 10))
+	assert.NotNil(t, CheckParts(parts, 10))
+	assert.Equal(t, int64(15), UploadedOffset([]Part{{Number: 1, Size: 10}, {Number: 2, Size: 5}, {Number: 3, Size: 10}}, 10))
+	assert.Equal(t, int64(0), UploadedOffset(nil, 10))
+
+	assert.Nil(t, CheckParts([]Part{{Number: 1, Size: 10}, {Number: 2, Size: 5}}, 10))
+	assert.NotNil(t, CheckParts([]Part{{Number: 1, Size: 5}, {Number: 2, Size: 5}}, 10))
+	assert.NotNil(t, CheckParts(nil, 10))
+}
+
+func TestRange(t *testing.T) {
+	assert.NotNil(t, CheckRange(-1, 0))
+	assert.Nil(t, CheckRange(1, 0))
+	assert.Equal(t, "", RangeHeader(0, 0))
+	assert.Equal(t, "bytes=10-", RangeHeader(10, 0))
+	assert.Equal(t, "bytes=10-14", RangeHeader(10, 5))
+
+	r, err := NewRangeReader(ioutil.NopCloser(strings.NewReader("hello world")), 6, 3)
+	assert.Nil(t, err)
+	data, _ := ioutil.ReadAll(r)
+	assert.Equal(t, "wor", string(data))
+	r, err = NewRangeReader(ioutil.NopCloser(strings.NewReader("hello")), 10, 0)
+	assert.Nil(t, err)
+	data, _ = ioutil.ReadAll(r)
+	assert.Equal(t, "", string(data))
+}
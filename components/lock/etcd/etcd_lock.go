@@ -65,6 +65,12 @@ func (e *EtcdLock) Init(metadata lock.Metadata) error {
 	return err
 }
 
+// Ping checks the connection to etcd
+func (e *EtcdLock) Ping(ctx context.Context) error {
+	_, err := e.client.Get(ctx, "ping")
+	return err
+}
+
 // Features is to get EtcdLock's features
 func (e *EtcdLock) Features() []lock.Feature {
 	return e.features
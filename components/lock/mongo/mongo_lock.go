@@ -103,6 +103,11 @@ func (e *MongoLock) Init(metadata lock.Metadata) error {
 	return err
 }
 
+// Ping checks the connection to mongo
+func (e *MongoLock) Ping(ctx context.Context) error {
+	return e.client.Ping(ctx, nil)
+}
+
 // Features is to get MongoLock's features
 func (e *MongoLock) Features() []lock.Feature {
 	return e.features
@@ -76,6 +76,22 @@ func (c *ClusterRedisLock) Init(metadata lock.Metadata) error {
 	return err
 }
 
+// Ping checks the connections to the redis nodes, it fails if the lock can't get the quorum
+func (c *ClusterRedisLock) Ping(ctx context.Context) error {
+	var lastErr error
+	failed := 0
+	for _, client := range c.clients {
+		if err := client.Ping(ctx).Err(); err != nil {
+			failed++
+			lastErr = err
+		}
+	}
+	if len(c.clients)-failed < len(c.clients)/2+1 {
+		return fmt.Errorf("[clusterRedisLock]: %d of %d redis nodes are unavailable, last error: %v", failed, len(c.clients), lastErr)
+	}
+	return nil
+}
+
 func (c *ClusterRedisLock) Features() []lock.Feature {
 	return c.features
 }
@@ -128,6 +128,11 @@ func newInternalErrorUnlockResponse() *lock.UnlockResponse {
 	}
 }
 
+// Ping checks the connection to redis
+func (p *StandaloneRedisLock) Ping(ctx context.Context) error {
+	return p.client.Ping(ctx).Err()
+}
+
 // Close shuts down the client's redis connections.
 func (p *StandaloneRedisLock) Close() error {
 	if p.cancel != nil {
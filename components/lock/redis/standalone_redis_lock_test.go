@@ -13,6 +13,7 @@
 package redis
 
 import (
+	"context"
 	"sync"
 	"testing"
 
@@ -149,3 +150,22 @@ func TestStandaloneRedisLock_TryLock(t *testing.T) {
 	}()
 	wg.Wait()
 }
+
+func TestStandaloneRedisLock_Ping(t *testing.T) {
+	s, err := miniredis.Run()
+	assert.NoError(t, err)
+	comp := NewStandaloneRedisLock(log.DefaultLogger)
+	defer comp.Close()
+
+	cfg := lock.Metadata{
+		Properties: make(map[string]string),
+	}
+	cfg.Properties["redisHost"] = s.Addr()
+	cfg.Properties["redisPassword"] = ""
+	err = comp.Init(cfg)
+	assert.NoError(t, err)
+	assert.NoError(t, comp.Ping(context.Background()))
+
+	s.Close()
+	assert.Error(t, comp.Ping(context.Background()))
+}
@@ -17,6 +17,7 @@
 package aliyun
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
@@ -207,11 +208,14 @@ func IfNoneMatch(value string) oss.Option {
 	return oss.IfNoneMatch(value)
 }
 
-// Range is an option to set Range header, [start, end]
+// Range is an option to set Range header, [start, end], a zero end means the end of the object
 func Range(start, end int64) oss.Option {
 	if start == 0 && end == 0 {
 		return nil
 	}
+	if end == 0 {
+		return oss.NormalizedRange(fmt.Sprintf("%d-", start))
+	}
 	return oss.Range(start, end)
 }
 
@@ -104,6 +104,7 @@ func TestOptionNotNil(t *testing.T) {
 	assert.NotNil(t, IfMatch(" "))
 	assert.NotNil(t, IfNoneMatch(" "))
 	assert.NotNil(t, Range(1, 1))
+	assert.NotNil(t, Range(1, 0))
 	assert.NotNil(t, CopySourceIfMatch(" "))
 	assert.NotNil(t, CopySourceIfNoneMatch(" "))
 	assert.NotNil(t, CopySourceIfModifiedSince(1))
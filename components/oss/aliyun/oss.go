@@ -74,6 +74,16 @@ func (a *AliyunOSS) Init(ctx context.Context, config *l8oss.Config) error {
 	return nil
 }
 
+// Ping checks the connection to aliyun oss by listing a bucket
+func (a *AliyunOSS) Ping(ctx context.Context) error {
+	client, err := a.getClient()
+	if err != nil {
+		return err
+	}
+	_, err = client.ListBuckets(oss.MaxKeys(1))
+	return err
+}
+
 func (a *AliyunOSS) GetObject(ctx context.Context, req *l8oss.GetObjectInput) (*l8oss.GetObjectOutput, error) {
 	client, err := a.getClient()
 	if err != nil {
@@ -85,19 +95,37 @@ func (a *AliyunOSS) GetObject(ctx context.Context, req *l8oss.GetObjectInput) (*
 	}
 	//user can use SignedUrl to get file without ak、sk
 	if req.SignedUrl != "" {
-		body, err := bucket.GetObjectWithURL(req.SignedUrl)
-		return &l8oss.GetObjectOutput{DataStream: body}, err
+		result, err := bucket.DoGetObjectWithURL(req.SignedUrl, nil)
+		if err != nil {
+			return nil, err
+		}
+		return toGetObjectOutput(result), nil
 	}
-	body, err := bucket.GetObject(req.Key,
+	result, err := bucket.DoGetObject(&oss.GetObjectRequest{ObjectKey: req.Key}, []oss.Option{
 		IfUnmodifiedSince(req.IfUnmodifiedSince),
 		IfModifiedSince(req.IfModifiedSince),
 		IfMatch(req.IfMatch),
 		IfNoneMatch(req.IfNoneMatch),
 		Range(req.Start, req.End),
 		AcceptEncoding(req.AcceptEncoding),
-	)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toGetObjectOutput(result), nil
+}
 
-	return &l8oss.GetObjectOutput{DataStream: body}, err
+// toGetObjectOutput reports the size and the range of the object besides the data
+func toGetObjectOutput(result *oss.GetObjectResult) *l8oss.GetObjectOutput {
+	out := &l8oss.GetObjectOutput{
+		DataStream:    result.Response,
+		ContentLength: -1,
+		ContentRange:  result.Response.Headers.Get("Content-Range"),
+	}
+	if n, err := strconv.ParseInt(result.Response.Headers.Get("Content-Length"), 10, 64); err == nil {
+		out.ContentLength = n
+	}
+	return out
 }
 
 func (a *AliyunOSS) PutObject(ctx context.Context, req *l8oss.PutObjectInput) (*l8oss.PutObjectOutput, error) {
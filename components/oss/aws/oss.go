@@ -75,6 +75,16 @@ func (a *AwsOss) Init(ctx context.Context, config *oss.Config) error {
 	return nil
 }
 
+// Ping checks the connection to aws s3 by listing the buckets
+func (a *AwsOss) Ping(ctx context.Context) error {
+	client, err := a.getClient()
+	if err != nil {
+		return err
+	}
+	_, err = client.ListBuckets(ctx, &s3.ListBucketsInput{})
+	return err
+}
+
 func (a *AwsOss) GetObject(ctx context.Context, req *oss.GetObjectInput) (*oss.GetObjectOutput, error) {
 	input := &s3.GetObjectInput{}
 	client, err := a.getClient()
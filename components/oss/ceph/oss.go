@@ -81,6 +81,16 @@ func (c *CephOSS) Init(ctx context.Context, config *oss.Config) error {
 	return nil
 }
 
+// Ping checks the connection to ceph by listing the buckets
+func (c *CephOSS) Ping(ctx context.Context) error {
+	client, err := c.getClient()
+	if err != nil {
+		return err
+	}
+	_, err = client.ListBuckets(ctx, &s3.ListBucketsInput{})
+	return err
+}
+
 func (c *CephOSS) GetObject(ctx context.Context, req *oss.GetObjectInput) (*oss.GetObjectOutput, error) {
 	client, err := c.getClient()
 	if err != nil {
@@ -0,0 +1,304 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"mosn.io/layotto/components/oss"
+)
+
+const defaultMaxKeys = 1000
+
+func (l *LocalOss) ListObjects(ctx context.Context, req *oss.ListObjectsInput) (*oss.ListObjectsOutput, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	keys, err := l.currentKeys(req.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	out := &oss.ListObjectsOutput{
+		Delimiter:    req.Delimiter,
+		EncodingType: req.EncodingType,
+		Marker:       req.Marker,
+		MaxKeys:      maxKeys(req.MaxKeys),
+		Name:         req.Bucket,
+		Prefix:       req.Prefix,
+	}
+	g := &grouper{prefix: req.Prefix, delimiter: req.Delimiter}
+	for _, key := range keys {
+		if key <= req.Marker {
+			continue
+		}
+		cp, ok := g.group(key)
+		if !ok || (cp != "" && strings.HasPrefix(req.Marker, cp)) {
+			continue
+		}
+		if len(out.Contents)+len(out.CommonPrefixes) == int(out.MaxKeys) {
+			out.IsTruncated = true
+			break
+		}
+		if cp != "" {
+			out.CommonPrefixes = append(out.CommonPrefixes, cp)
+			out.NextMarker = cp
+			continue
+		}
+		meta, err := l.readMeta(req.Bucket, key)
+		if err != nil {
+			return nil, err
+		}
+		out.Contents = append(out.Contents, &oss.Object{
+			ETag:         meta.ETag,
+			Key:          key,
+			LastModified: meta.LastModified.Unix(),
+			Size:         meta.Size,
+			StorageClass: meta.StorageClass,
+		})
+		out.NextMarker = key
+	}
+	if !out.IsTruncated {
+		out.NextMarker = ""
+	}
+	return out, nil
+}
+
+func (l *LocalOss) ListObjectVersions(ctx context.Context, req *oss.ListObjectVersionsInput) (*oss.ListObjectVersionsOutput, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	keys, err := l.versionedKeys(req.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	out := &oss.ListObjectVersionsOutput{
+		Delimiter:       req.Delimiter,
+		EncodingType:    req.EncodingType,
+		KeyMarker:       req.KeyMarker,
+		MaxKeys:         maxKeys(req.MaxKeys),
+		Name:            req.Bucket,
+		Prefix:          req.Prefix,
+		VersionIdMarker: req.VersionIdMarker,
+	}
+	count := 0
+	g := &grouper{prefix: req.Prefix, delimiter: req.Delimiter}
+	for _, key := range keys {
+		if key < req.KeyMarker || (key == req.KeyMarker && req.VersionIdMarker == "") {
+			continue
+		}
+		cp, ok := g.group(key)
+		if !ok || (cp != "" && strings.HasPrefix(req.KeyMarker, cp)) {
+			continue
+		}
+		if cp != "" {
+			if count == int(out.MaxKeys) {
+				out.IsTruncated = true
+				break
+			}
+			out.CommonPrefixes = append(out.CommonPrefixes, cp)
+			out.NextKeyMarker, out.NextVersionIdMarker = cp, ""
+			count++
+			continue
+		}
+		versions, err := l.allVersions(req.Bucket, key)
+		if err != nil {
+			return nil, err
+		}
+		// skip the versions up to the version id marker
+		if key == req.KeyMarker {
+			for i, v := range versions {
+				if v.VersionId == req.VersionIdMarker {
+					versions = versions[i+1:]
+					break
+				}
+			}
+		}
+		for i, v := range versions {
+			if count == int(out.MaxKeys) {
+				out.IsTruncated = true
+				break
+			}
+			latest := i == 0 && key != req.KeyMarker
+			if v.DeleteMarker {
+				out.DeleteMarkers = append(out.DeleteMarkers, &oss.DeleteMarkerEntry{
+					IsLatest:     latest,
+					Key:          key,
+					LastModified: v.LastModified.Unix(),
+					VersionId:    versionIdOrNull(v.VersionId),
+				})
+			} else {
+				out.Versions = append(out.Versions, &oss.ObjectVersion{
+					ETag:         v.ETag,
+					IsLatest:     latest,
+					Key:          key,
+					LastModified: v.LastModified.Unix(),
+					Size:         v.Size,
+					StorageClass: v.StorageClass,
+					VersionId:    versionIdOrNull(v.VersionId),
+				})
+			}
+			out.NextKeyMarker, out.NextVersionIdMarker = key, v.VersionId
+			count++
+		}
+		if out.IsTruncated {
+			break
+		}
+	}
+	if !out.IsTruncated {
+		out.NextKeyMarker, out.NextVersionIdMarker = "", ""
+	}
+	return out, nil
+}
+
+// allVersions returns the current version and the noncurrent versions of an object, the latest first
+func (l *LocalOss) allVersions(bucket, key string) ([]*objectMeta, error) {
+	versions, err := l.listVersions(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	current, err := l.readMeta(bucket, key)
+	if err == ErrNoSuchKey {
+		return versions, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append([]*objectMeta{current}, versions...), nil
+}
+
+// grouper rolls up the keys which contain the delimiter after the prefix into common prefixes
+type grouper struct {
+	prefix    string
+	delimiter string
+	last      string
+}
+
+// group returns the common prefix of the key, or "" if the key is not rolled up.
+// It returns false if the key should be skipped, i.e. it doesn't match the prefix or the common prefix has been returned.
+func (g *grouper) group(key string) (string, bool) {
+	if !strings.HasPrefix(key, g.prefix) {
+		return "", false
+	}
+	if g.delimiter == "" {
+		return "", true
+	}
+	rest := key[len(g.prefix):]
+	idx := strings.Index(rest, g.delimiter)
+	if idx < 0 {
+		return "", true
+	}
+	cp := g.prefix + rest[:idx+len(g.delimiter)]
+	if cp == g.last {
+		return "", false
+	}
+	g.last = cp
+	return cp, true
+}
+
+// currentKeys returns the sorted keys of the current versions in the bucket.
+// The buckets are created on demand, so a bucket which doesn't exist is empty.
+func (l *LocalOss) currentKeys(bucket string) ([]string, error) {
+	dir, err := l.bucketPath(bucket)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if rel == systemDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode().IsRegular() {
+			keys = append(keys, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// versionedKeys returns the sorted keys which have either a current version or noncurrent versions
+func (l *LocalOss) versionedKeys(bucket string) ([]string, error) {
+	keys, err := l.currentKeys(bucket)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		set[k] = struct{}{}
+	}
+	dir, _ := l.bucketPath(bucket)
+	versionsRoot := filepath.Join(dir, systemDir, versionsDir)
+	err = filepath.Walk(versionsRoot, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, metaSuffix) {
+			return nil
+		}
+		rel, err := filepath.Rel(versionsRoot, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		set[filepath.ToSlash(rel)] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	keys = keys[:0]
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func maxKeys(n int32) int32 {
+	if n <= 0 || n > defaultMaxKeys {
+		return defaultMaxKeys
+	}
+	return n
+}
+
+// versionIdOrNull returns "null" for the versions put before versioning was enabled, as S3 does
+func versionIdOrNull(id string) string {
+	if id == "" {
+		return "null"
+	}
+	return id
+}
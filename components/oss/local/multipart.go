@@ -0,0 +1,422 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mosn.io/layotto/components/oss"
+)
+
+const uploadFile = "upload.json"
+
+// upload is the metadata of a multipart upload. The parts are kept in `<part number>` and `<part number>.json`
+type upload struct {
+	UploadId  string     `json:"upload_id"`
+	Initiated time.Time  `json:"initiated"`
+	Meta      objectMeta `json:"meta"`
+}
+
+type part struct {
+	PartNumber   int64     `json:"part_number"`
+	ETag         string    `json:"etag"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+func (l *LocalOss) uploadPath(bucket, uploadId string) (string, error) {
+	dir, err := l.bucketPath(bucket)
+	if err != nil {
+		return "", err
+	}
+	if uploadId == "" || strings.ContainsAny(uploadId, "/\\.") {
+		return "", ErrNoSuchUpload
+	}
+	return filepath.Join(dir, systemDir, uploadsDir, uploadId), nil
+}
+
+// readUpload reads the upload and checks that it belongs to the key
+func (l *LocalOss) readUpload(bucket, key, uploadId string) (*upload, string, error) {
+	dir, err := l.uploadPath(bucket, uploadId)
+	if err != nil {
+		return nil, "", err
+	}
+	u := &upload{}
+	if err := readJSON(filepath.Join(dir, uploadFile), u); err != nil {
+		if os.IsNotExist(err) {
+			return nil, "", ErrNoSuchUpload
+		}
+		return nil, "", err
+	}
+	if u.Meta.Key != key {
+		return nil, "", ErrNoSuchUpload
+	}
+	return u, dir, nil
+}
+
+func (l *LocalOss) CreateMultipartUpload(ctx context.Context, req *oss.CreateMultipartUploadInput) (*oss.CreateMultipartUploadOutput, error) {
+	if _, _, err := l.objectPaths(req.Bucket, req.Key); err != nil {
+		return nil, err
+	}
+	u := &upload{
+		UploadId:  newID(),
+		Initiated: time.Now(),
+		Meta: objectMeta{
+			Key:                  req.Key,
+			ACL:                  req.ACL,
+			CacheControl:         req.CacheControl,
+			ContentDisposition:   req.ContentDisposition,
+			ContentEncoding:      req.ContentEncoding,
+			ContentLanguage:      req.ContentLanguage,
+			ContentType:          req.ContentType,
+			Expires:              req.Expires,
+			ServerSideEncryption: req.ServerSideEncryption,
+			StorageClass:         req.StorageClass,
+			Metadata:             copyMap(req.MetaData),
+			Tags:                 copyMap(req.Tagging),
+		},
+	}
+	dir, err := l.uploadPath(req.Bucket, u.UploadId)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeJSON(filepath.Join(dir, uploadFile), u); err != nil {
+		return nil, err
+	}
+	return &oss.CreateMultipartUploadOutput{Bucket: req.Bucket, Key: req.Key, UploadId: u.UploadId}, nil
+}
+
+func (l *LocalOss) UploadPart(ctx context.Context, req *oss.UploadPartInput) (*oss.UploadPartOutput, error) {
+	p, err := l.writePart(req.Bucket, req.Key, req.UploadId, req.PartNumber, req.DataStream)
+	if err != nil {
+		return nil, err
+	}
+	return &oss.UploadPartOutput{ETag: p.ETag}, nil
+}
+
+// UploadPartCopy copies `PartSize` bytes from `StartPosition` of the source object.
+// The whole object is copied if `PartSize` is 0.
+func (l *LocalOss) UploadPartCopy(ctx context.Context, req *oss.UploadPartCopyInput) (*oss.UploadPartCopyOutput, error) {
+	if req.CopySource == nil {
+		return nil, fmt.Errorf("must specific copy_source")
+	}
+	src := req.CopySource
+	l.mu.RLock()
+	meta, srcPath, err := l.readVersion(src.CopySourceBucket, src.CopySourceKey, src.CopySourceVersionId)
+	if err == nil && meta.DeleteMarker {
+		err = ErrNoSuchKey
+	}
+	if err != nil {
+		l.mu.RUnlock()
+		return nil, err
+	}
+	f, err := os.Open(srcPath)
+	l.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if req.StartPosition > 0 || req.PartSize > 0 {
+		size := req.PartSize
+		if size <= 0 {
+			size = meta.Size - req.StartPosition
+		}
+		if req.StartPosition < 0 || req.StartPosition+size > meta.Size {
+			return nil, fmt.Errorf("%w: the range is out of the source object", oss.ErrInvalid)
+		}
+		r = io.NewSectionReader(f, req.StartPosition, size)
+	}
+	p, err := l.writePart(req.Bucket, req.Key, req.UploadId, req.PartNumber, r)
+	if err != nil {
+		return nil, err
+	}
+	return &oss.UploadPartCopyOutput{
+		CopyPartResult:      &oss.CopyPartResult{ETag: p.ETag, LastModified: p.LastModified.Unix()},
+		CopySourceVersionId: meta.VersionId,
+	}, nil
+}
+
+func (l *LocalOss) writePart(bucket, key, uploadId string, partNumber int32, r io.Reader) (*part, error) {
+	if partNumber < 1 || partNumber > 10000 {
+		return nil, fmt.Errorf("%w: part number must be between 1 and 10000", oss.ErrInvalid)
+	}
+	_, dir, err := l.readUpload(bucket, key, uploadId)
+	if err != nil {
+		return nil, err
+	}
+	tmp, size, sum, err := l.writeTemp(bucket, r)
+	if err != nil {
+		return nil, err
+	}
+	p := &part{PartNumber: int64(partNumber), ETag: etag(sum), Size: size, LastModified: time.Now()}
+	name := filepath.Join(dir, strconv.Itoa(int(partNumber)))
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// the upload may be completed or aborted meanwhile
+	if _, _, err := l.readUpload(bucket, key, uploadId); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+	if err := writeJSON(name+metaSuffix, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// listParts returns the parts of an upload, ordered by the part number
+func listParts(dir string) ([]*part, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var parts []*part
+	for _, f := range files {
+		if f.Name() == uploadFile || !strings.HasSuffix(f.Name(), metaSuffix) {
+			continue
+		}
+		p := &part{}
+		if err := readJSON(filepath.Join(dir, f.Name()), p); err != nil {
+			return nil, err
+		}
+		parts = append(parts, p)
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].PartNumber < parts[j].PartNumber
+	})
+	return parts, nil
+}
+
+// CompleteMultipartUpload composes the parts into the object.
+// The etag of the object is the md5 of the concatenated md5 of the parts, followed by `-<number of parts>`, as S3 does.
+func (l *LocalOss) CompleteMultipartUpload(ctx context.Context, req *oss.CompleteMultipartUploadInput) (*oss.CompleteMultipartUploadOutput, error) {
+	if req.MultipartUpload == nil || len(req.MultipartUpload.Parts) == 0 {
+		return nil, fmt.Errorf("%w: at least one part is required", ErrInvalidPart)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	u, dir, err := l.readUpload(req.Bucket, req.Key, req.UploadId)
+	if err != nil {
+		return nil, err
+	}
+	uploaded, err := listParts(dir)
+	if err != nil {
+		return nil, err
+	}
+	byNumber := make(map[int64]*part, len(uploaded))
+	for _, p := range uploaded {
+		byNumber[p.PartNumber] = p
+	}
+	// check the parts
+	var sums []byte
+	prev := int32(0)
+	for _, cp := range req.MultipartUpload.Parts {
+		p, ok := byNumber[int64(cp.PartNumber)]
+		if !ok || cp.PartNumber <= prev || (cp.ETag != "" && trimQuotes(cp.ETag) != trimQuotes(p.ETag)) {
+			return nil, ErrInvalidPart
+		}
+		prev = cp.PartNumber
+		sum, err := hex.DecodeString(trimQuotes(p.ETag))
+		if err != nil {
+			return nil, err
+		}
+		sums = append(sums, sum...)
+	}
+	// compose the parts
+	f, err := l.tempFile(req.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	var size int64
+	for _, cp := range req.MultipartUpload.Parts {
+		n, err := appendFile(f, filepath.Join(dir, strconv.Itoa(int(cp.PartNumber))))
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+			return nil, err
+		}
+		size += n
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return nil, err
+	}
+	sum := md5.Sum(sums)
+	meta := u.Meta
+	meta.Size = size
+	meta.ETag = fmt.Sprintf("\"%s-%d\"", hex.EncodeToString(sum[:]), len(req.MultipartUpload.Parts))
+	meta.PartsCount = int64(len(req.MultipartUpload.Parts))
+	if err := l.commit(req.Bucket, f.Name(), &meta); err != nil {
+		return nil, err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	return &oss.CompleteMultipartUploadOutput{
+		Bucket:    req.Bucket,
+		Key:       req.Key,
+		ETag:      meta.ETag,
+		VersionId: meta.VersionId,
+	}, nil
+}
+
+func appendFile(dst io.Writer, path string) (int64, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+	return io.Copy(dst, src)
+}
+
+func (l *LocalOss) AbortMultipartUpload(ctx context.Context, req *oss.AbortMultipartUploadInput) (*oss.AbortMultipartUploadOutput, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, dir, err := l.readUpload(req.Bucket, req.Key, req.UploadId)
+	if err != nil {
+		return nil, err
+	}
+	return &oss.AbortMultipartUploadOutput{}, os.RemoveAll(dir)
+}
+
+func (l *LocalOss) ListParts(ctx context.Context, req *oss.ListPartsInput) (*oss.ListPartsOutput, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	_, dir, err := l.readUpload(req.Bucket, req.Key, req.UploadId)
+	if err != nil {
+		return nil, err
+	}
+	parts, err := listParts(dir)
+	if err != nil {
+		return nil, err
+	}
+	max := req.MaxParts
+	if max <= 0 || max > defaultMaxKeys {
+		max = defaultMaxKeys
+	}
+	out := &oss.ListPartsOutput{Bucket: req.Bucket, Key: req.Key, UploadId: req.UploadId, MaxParts: max}
+	for _, p := range parts {
+		if p.PartNumber <= req.PartNumberMarker {
+			continue
+		}
+		if int64(len(out.Parts)) == max {
+			out.IsTruncated = true
+			break
+		}
+		out.Parts = append(out.Parts, &oss.Part{
+			Etag:         p.ETag,
+			LastModified: p.LastModified.Unix(),
+			PartNumber:   p.PartNumber,
+			Size:         p.Size,
+		})
+		out.NextPartNumberMarker = strconv.FormatInt(p.PartNumber, 10)
+	}
+	return out, nil
+}
+
+func (l *LocalOss) ListMultipartUploads(ctx context.Context, req *oss.ListMultipartUploadsInput) (*oss.ListMultipartUploadsOutput, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	dir, err := l.bucketPath(req.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	files, err := ioutil.ReadDir(filepath.Join(dir, systemDir, uploadsDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	var uploads []*upload
+	for _, f := range files {
+		u := &upload{}
+		if err := readJSON(filepath.Join(dir, systemDir, uploadsDir, f.Name(), uploadFile), u); err != nil {
+			continue
+		}
+		uploads = append(uploads, u)
+	}
+	// ordered by the key, then the initiated time
+	sort.Slice(uploads, func(i, j int) bool {
+		if uploads[i].Meta.Key != uploads[j].Meta.Key {
+			return uploads[i].Meta.Key < uploads[j].Meta.Key
+		}
+		return uploads[i].Initiated.Before(uploads[j].Initiated)
+	})
+	max := req.MaxUploads
+	if max <= 0 || max > defaultMaxKeys {
+		max = defaultMaxKeys
+	}
+	out := &oss.ListMultipartUploadsOutput{
+		Bucket:         req.Bucket,
+		Delimiter:      req.Delimiter,
+		EncodingType:   req.EncodingType,
+		KeyMarker:      req.KeyMarker,
+		MaxUploads:     int32(max),
+		Prefix:         req.Prefix,
+		UploadIDMarker: req.UploadIdMarker,
+	}
+	g := &grouper{prefix: req.Prefix, delimiter: req.Delimiter}
+	// the uploads of the key marker are skipped up to the upload id marker
+	skipping := req.UploadIdMarker != ""
+	for _, u := range uploads {
+		key := u.Meta.Key
+		if key < req.KeyMarker || (key == req.KeyMarker && req.UploadIdMarker == "") {
+			continue
+		}
+		if key == req.KeyMarker && skipping {
+			skipping = u.UploadId != req.UploadIdMarker
+			continue
+		}
+		cp, ok := g.group(key)
+		if !ok || (cp != "" && strings.HasPrefix(req.KeyMarker, cp)) {
+			continue
+		}
+		if int64(len(out.Uploads)+len(out.CommonPrefixes)) == max {
+			out.IsTruncated = true
+			break
+		}
+		if cp != "" {
+			out.CommonPrefixes = append(out.CommonPrefixes, cp)
+			out.NextKeyMarker, out.NextUploadIDMarker = cp, ""
+			continue
+		}
+		out.Uploads = append(out.Uploads, &oss.MultipartUpload{
+			Initiated:    u.Initiated.Unix(),
+			Key:          key,
+			StorageClass: u.Meta.StorageClass,
+			UploadId:     u.UploadId,
+		})
+		out.NextKeyMarker, out.NextUploadIDMarker = key, u.UploadId
+	}
+	if !out.IsTruncated {
+		out.NextKeyMarker, out.NextUploadIDMarker = "", ""
+	}
+	return out, nil
+}
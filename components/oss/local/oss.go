@@ -0,0 +1,582 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package local is an oss component backed by a local directory, which is useful in development and tests.
+// Each bucket is a sub directory of the root, and the metadata and tags of objects are kept in sidecar json files.
+package local
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mosn.io/layotto/components/oss"
+)
+
+var (
+	ErrNoSuchKey        = errors.New("the specified key does not exist")
+	ErrNoSuchVersion    = errors.New("the specified version does not exist")
+	ErrNoSuchUpload     = errors.New("the specified multipart upload does not exist")
+	ErrInvalidName      = errors.New("invalid bucket or key")
+	ErrKeyConflict      = errors.New("the key conflicts with another key, e.g. `a` and `a/b`")
+	ErrInvalidPart      = errors.New("one or more of the specified parts could not be found or the etag doesn't match")
+	ErrPositionMismatch = errors.New("the position of append is not equal to the size of the object")
+)
+
+// localConfig is the `basic_config` in the metadata
+type localConfig struct {
+	// The directory which contains the buckets
+	Root string `json:"root"`
+	// Keep the previous versions when objects are overwritten or deleted
+	EnableVersioning bool `json:"enable_versioning"`
+	// The HMAC key of the signed urls. A random key is generated if it's empty,
+	// so that the urls are only valid in the current process.
+	SignKey string `json:"sign_key"`
+	// The prefix of the signed urls, e.g. `http://127.0.0.1:34906`
+	URLPrefix string `json:"url_prefix"`
+}
+
+type LocalOss struct {
+	config localConfig
+	root   string
+	// guards the metadata. The data streams are written into temporary files without the lock.
+	mu sync.RWMutex
+}
+
+func NewLocalOss() oss.Oss {
+	return &LocalOss{}
+}
+
+func (l *LocalOss) Init(ctx context.Context, config *oss.Config) error {
+	if err := json.Unmarshal(config.Metadata[oss.BasicConfiguration], &l.config); err != nil {
+		return oss.ErrInvalid
+	}
+	if l.config.Root == "" {
+		return fmt.Errorf("%w: root is required", oss.ErrInvalid)
+	}
+	if l.config.SignKey == "" {
+		l.config.SignKey = newID()
+	}
+	if l.config.URLPrefix == "" {
+		l.config.URLPrefix = defaultURLPrefix
+	}
+	l.root = l.config.Root
+	return os.MkdirAll(l.root, dirPerm)
+}
+
+// Ping checks the root directory is still there
+func (l *LocalOss) Ping(ctx context.Context) error {
+	_, err := os.Stat(l.root)
+	return err
+}
+
+func (l *LocalOss) GetObject(ctx context.Context, req *oss.GetObjectInput) (*oss.GetObjectOutput, error) {
+	bucket, key, versionId := req.Bucket, req.Key, req.VersionId
+	if req.SignedUrl != "" {
+		signed, err := l.VerifySignedURL(req.SignedUrl, "GET")
+		if err != nil {
+			return nil, err
+		}
+		bucket, key = signed.Bucket, signed.Key
+	}
+	l.mu.RLock()
+	meta, dataPath, err := l.readVersion(bucket, key, versionId)
+	if err != nil {
+		l.mu.RUnlock()
+		return nil, err
+	}
+	if meta.DeleteMarker {
+		l.mu.RUnlock()
+		return nil, ErrNoSuchKey
+	}
+	// the file can still be read after being renamed or removed
+	f, err := os.Open(dataPath)
+	l.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkConditions(meta, req); err != nil {
+		f.Close()
+		return nil, err
+	}
+	out := toGetObjectOutput(meta)
+	out.DataStream = f
+	if req.Start > 0 || req.End > 0 {
+		end := req.End
+		if end == 0 || end >= meta.Size {
+			end = meta.Size - 1
+		}
+		if req.Start >= meta.Size || end < req.Start {
+			f.Close()
+			return nil, oss.ErrInvalidRange
+		}
+		out.DataStream = &sectionReadCloser{io.NewSectionReader(f, req.Start, end-req.Start+1), f}
+		out.ContentLength = end - req.Start + 1
+		out.ContentRange = fmt.Sprintf("bytes %d-%d/%d", req.Start, end, meta.Size)
+	}
+	return out, nil
+}
+
+// sectionReadCloser reads a section of the file and closes the file
+type sectionReadCloser struct {
+	*io.SectionReader
+	f *os.File
+}
+
+func (s *sectionReadCloser) Close() error {
+	return s.f.Close()
+}
+
+// checkConditions checks the If-Match, If-None-Match, If-Modified-Since and If-Unmodified-Since conditions
+func checkConditions(meta *objectMeta, req *oss.GetObjectInput) error {
+	if req.IfMatch != "" && req.IfMatch != meta.ETag && req.IfMatch != trimQuotes(meta.ETag) {
+		return errors.New("precondition failed: If-Match")
+	}
+	if req.IfNoneMatch != "" && (req.IfNoneMatch == meta.ETag || req.IfNoneMatch == trimQuotes(meta.ETag)) {
+		return errors.New("not modified: If-None-Match")
+	}
+	if req.IfModifiedSince != 0 && meta.LastModified.Unix() <= req.IfModifiedSince {
+		return errors.New("not modified: If-Modified-Since")
+	}
+	if req.IfUnmodifiedSince != 0 && meta.LastModified.Unix() > req.IfUnmodifiedSince {
+		return errors.New("precondition failed: If-Unmodified-Since")
+	}
+	return nil
+}
+
+func toGetObjectOutput(meta *objectMeta) *oss.GetObjectOutput {
+	out := &oss.GetObjectOutput{
+		CacheControl:       meta.CacheControl,
+		ContentDisposition: meta.ContentDisposition,
+		ContentEncoding:    meta.ContentEncoding,
+		ContentLanguage:    meta.ContentLanguage,
+		ContentLength:      meta.Size,
+		ContentType:        meta.ContentType,
+		Etag:               meta.ETag,
+		LastModified:       meta.LastModified.Unix(),
+		VersionId:          meta.VersionId,
+		TagCount:           int64(len(meta.Tags)),
+		StorageClass:       meta.StorageClass,
+		PartsCount:         meta.PartsCount,
+		Metadata:           copyMap(meta.Metadata),
+	}
+	if meta.Expires != 0 {
+		out.Expires = time.Unix(meta.Expires, 0).UTC().Format(time.RFC1123)
+	}
+	return out
+}
+
+func (l *LocalOss) PutObject(ctx context.Context, req *oss.PutObjectInput) (*oss.PutObjectOutput, error) {
+	bucket, key := req.Bucket, req.Key
+	if req.SignedUrl != "" {
+		signed, err := l.VerifySignedURL(req.SignedUrl, "PUT")
+		if err != nil {
+			return nil, err
+		}
+		bucket, key = signed.Bucket, signed.Key
+	}
+	if _, _, err := l.objectPaths(bucket, key); err != nil {
+		return nil, err
+	}
+	tmp, size, sum, err := l.writeTemp(bucket, req.DataStream)
+	if err != nil {
+		return nil, err
+	}
+	meta := &objectMeta{
+		Key:                  key,
+		ETag:                 etag(sum),
+		Size:                 size,
+		ACL:                  req.ACL,
+		CacheControl:         req.CacheControl,
+		ContentDisposition:   req.ContentDisposition,
+		ContentEncoding:      req.ContentEncoding,
+		Expires:              req.Expires,
+		ServerSideEncryption: req.ServerSideEncryption,
+		StorageClass:         req.StorageClass,
+		Metadata:             copyMap(req.Meta),
+		Tags:                 copyMap(req.Tagging),
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.commit(bucket, tmp, meta); err != nil {
+		return nil, err
+	}
+	return &oss.PutObjectOutput{ETag: meta.ETag}, nil
+}
+
+func (l *LocalOss) DeleteObject(ctx context.Context, req *oss.DeleteObjectInput) (*oss.DeleteObjectOutput, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.deleteObject(req.Bucket, req.Key, req.VersionId)
+}
+
+// deleteObject deletes a version, or creates a delete marker if versioning is enabled and no version is specified.
+// Deleting objects which don't exist is not an error, as in S3. It must be called with the lock held.
+func (l *LocalOss) deleteObject(bucket, key, versionId string) (*oss.DeleteObjectOutput, error) {
+	dataPath, metaPath, err := l.objectPaths(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	if versionId == "null" {
+		versionId = ""
+	}
+	if versionId == "" {
+		if !l.config.EnableVersioning {
+			if err := removeIfExist(dataPath); err != nil {
+				return nil, err
+			}
+			l.removeEmptyParents(bucket, dataPath)
+			return &oss.DeleteObjectOutput{}, removeIfExist(metaPath)
+		}
+		if err := l.archiveCurrent(bucket, key); err != nil {
+			return nil, err
+		}
+		marker := &objectMeta{Key: key, VersionId: newID(), LastModified: time.Now(), DeleteMarker: true}
+		_, markerPath, err := l.versionPaths(bucket, key, marker.VersionId)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeJSON(markerPath, marker); err != nil {
+			return nil, err
+		}
+		return &oss.DeleteObjectOutput{DeleteMarker: true, VersionId: marker.VersionId}, nil
+	}
+	// delete the specified version
+	out := &oss.DeleteObjectOutput{VersionId: versionId}
+	if current, err := l.readMeta(bucket, key); err == nil && current.VersionId == versionId {
+		if err := removeIfExist(dataPath); err != nil {
+			return nil, err
+		}
+		l.removeEmptyParents(bucket, dataPath)
+		if err := removeIfExist(metaPath); err != nil {
+			return nil, err
+		}
+		return out, l.promoteLatest(bucket, key)
+	}
+	versionData, versionMeta, err := l.versionPaths(bucket, key, versionId)
+	if err != nil {
+		return nil, err
+	}
+	meta := &objectMeta{}
+	if err := readJSON(versionMeta, meta); err != nil {
+		if os.IsNotExist(err) {
+			return out, nil
+		}
+		return nil, err
+	}
+	out.DeleteMarker = meta.DeleteMarker
+	if err := removeIfExist(versionData); err != nil {
+		return nil, err
+	}
+	if err := removeIfExist(versionMeta); err != nil {
+		return nil, err
+	}
+	return out, l.promoteLatest(bucket, key)
+}
+
+func (l *LocalOss) DeleteObjects(ctx context.Context, req *oss.DeleteObjectsInput) (*oss.DeleteObjectsOutput, error) {
+	out := &oss.DeleteObjectsOutput{}
+	if req.Delete == nil {
+		return out, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, o := range req.Delete.Objects {
+		resp, err := l.deleteObject(req.Bucket, o.Key, o.VersionId)
+		if err != nil {
+			return nil, fmt.Errorf("delete %s failed: %w", o.Key, err)
+		}
+		deleted := &oss.DeletedObject{Key: o.Key, VersionId: o.VersionId, DeleteMarker: resp.DeleteMarker}
+		if resp.DeleteMarker && o.VersionId == "" {
+			deleted.DeleteMarkerVersionId = resp.VersionId
+		}
+		out.Deleted = append(out.Deleted, deleted)
+	}
+	return out, nil
+}
+
+func (l *LocalOss) PutObjectTagging(ctx context.Context, req *oss.PutObjectTaggingInput) (*oss.PutObjectTaggingOutput, error) {
+	err := l.updateMeta(req.Bucket, req.Key, req.VersionId, func(meta *objectMeta) {
+		meta.Tags = copyMap(req.Tags)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &oss.PutObjectTaggingOutput{}, nil
+}
+
+func (l *LocalOss) DeleteObjectTagging(ctx context.Context, req *oss.DeleteObjectTaggingInput) (*oss.DeleteObjectTaggingOutput, error) {
+	var versionId string
+	err := l.updateMeta(req.Bucket, req.Key, req.VersionId, func(meta *objectMeta) {
+		meta.Tags = nil
+		versionId = meta.VersionId
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &oss.DeleteObjectTaggingOutput{VersionId: versionId}, nil
+}
+
+func (l *LocalOss) GetObjectTagging(ctx context.Context, req *oss.GetObjectTaggingInput) (*oss.GetObjectTaggingOutput, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	meta, _, err := l.readVersion(req.Bucket, req.Key, req.VersionId)
+	if err != nil {
+		return nil, err
+	}
+	if meta.DeleteMarker {
+		return nil, ErrNoSuchKey
+	}
+	tags := copyMap(meta.Tags)
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	return &oss.GetObjectTaggingOutput{Tags: tags, VersionId: meta.VersionId}, nil
+}
+
+// updateMeta updates the metadata of a version
+func (l *LocalOss) updateMeta(bucket, key, versionId string, update func(meta *objectMeta)) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	meta, _, err := l.readVersion(bucket, key, versionId)
+	if err != nil {
+		return err
+	}
+	if meta.DeleteMarker {
+		return ErrNoSuchKey
+	}
+	update(meta)
+	return l.writeVersionMeta(bucket, meta)
+}
+
+func (l *LocalOss) CopyObject(ctx context.Context, req *oss.CopyObjectInput) (*oss.CopyObjectOutput, error) {
+	if req.CopySource == nil {
+		return nil, errors.New("must specific copy_source")
+	}
+	src := req.CopySource
+	l.mu.RLock()
+	srcMeta, srcPath, err := l.readVersion(src.CopySourceBucket, src.CopySourceKey, src.CopySourceVersionId)
+	if err != nil {
+		l.mu.RUnlock()
+		return nil, err
+	}
+	if srcMeta.DeleteMarker {
+		l.mu.RUnlock()
+		return nil, ErrNoSuchKey
+	}
+	f, err := os.Open(srcPath)
+	l.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, _, err := l.objectPaths(req.Bucket, req.Key); err != nil {
+		return nil, err
+	}
+	tmp, size, sum, err := l.writeTemp(req.Bucket, f)
+	if err != nil {
+		return nil, err
+	}
+	meta := *srcMeta
+	meta.Key = req.Key
+	meta.VersionId = ""
+	meta.Size = size
+	meta.ETag = etag(sum)
+	meta.PartsCount = 0
+	meta.LastModified = time.Now()
+	meta.Metadata = copyMap(srcMeta.Metadata)
+	meta.Tags = copyMap(srcMeta.Tags)
+	if req.MetadataDirective == "REPLACE" {
+		meta.Metadata = copyMap(req.Metadata)
+	}
+	if req.Tagging != nil {
+		meta.Tags = copyMap(req.Tagging)
+	}
+	if req.Expires != 0 {
+		meta.Expires = req.Expires
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.commit(req.Bucket, tmp, &meta); err != nil {
+		return nil, err
+	}
+	return &oss.CopyObjectOutput{CopyObjectResult: &oss.CopyObjectResult{
+		ETag:         meta.ETag,
+		LastModified: meta.LastModified.Unix(),
+	}}, nil
+}
+
+func (l *LocalOss) GetObjectCannedAcl(ctx context.Context, req *oss.GetObjectCannedAclInput) (*oss.GetObjectCannedAclOutput, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	meta, _, err := l.readVersion(req.Bucket, req.Key, req.VersionId)
+	if err != nil {
+		return nil, err
+	}
+	acl := meta.ACL
+	if acl == "" {
+		acl = "private"
+	}
+	return &oss.GetObjectCannedAclOutput{CannedAcl: acl}, nil
+}
+
+func (l *LocalOss) PutObjectCannedAcl(ctx context.Context, req *oss.PutObjectCannedAclInput) (*oss.PutObjectCannedAclOutput, error) {
+	err := l.updateMeta(req.Bucket, req.Key, req.VersionId, func(meta *objectMeta) {
+		meta.ACL = req.Acl
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &oss.PutObjectCannedAclOutput{}, nil
+}
+
+func (l *LocalOss) RestoreObject(ctx context.Context, req *oss.RestoreObjectInput) (*oss.RestoreObjectOutput, error) {
+	return nil, oss.NewErrNotSupportMethod("RestoreObject", "local oss")
+}
+
+func (l *LocalOss) HeadObject(ctx context.Context, req *oss.HeadObjectInput) (*oss.HeadObjectOutput, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	meta, _, err := l.readVersion(req.Bucket, req.Key, req.VersionId)
+	if err != nil {
+		return nil, err
+	}
+	if meta.DeleteMarker {
+		return nil, ErrNoSuchKey
+	}
+	result := copyMap(meta.Metadata)
+	if result == nil {
+		result = map[string]string{}
+	}
+	if req.WithDetails {
+		result["Content-Length"] = strconv.FormatInt(meta.Size, 10)
+		result["Etag"] = meta.ETag
+		result["Last-Modified"] = meta.LastModified.UTC().Format(time.RFC1123)
+		if meta.VersionId != "" {
+			result["X-Amz-Version-Id"] = meta.VersionId
+		}
+	}
+	return &oss.HeadObjectOutput{ResultMetadata: result}, nil
+}
+
+func (l *LocalOss) IsObjectExist(ctx context.Context, req *oss.IsObjectExistInput) (*oss.IsObjectExistOutput, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	_, err := l.readMeta(req.Bucket, req.Key)
+	if err == ErrNoSuchKey {
+		return &oss.IsObjectExistOutput{FileExist: false}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &oss.IsObjectExistOutput{FileExist: true}, nil
+}
+
+func (l *LocalOss) UpdateDownloadBandwidthRateLimit(ctx context.Context, req *oss.UpdateBandwidthRateLimitInput) error {
+	return oss.NewErrNotSupportMethod("UpdateDownloadBandwidthRateLimit", "local oss")
+}
+
+func (l *LocalOss) UpdateUploadBandwidthRateLimit(ctx context.Context, req *oss.UpdateBandwidthRateLimitInput) error {
+	return oss.NewErrNotSupportMethod("UpdateUploadBandwidthRateLimit", "local oss")
+}
+
+// AppendObject appends the stream to the object. The position must be equal to the current size of the object,
+// and it creates the object if the position is 0 and the object doesn't exist.
+func (l *LocalOss) AppendObject(ctx context.Context, req *oss.AppendObjectInput) (*oss.AppendObjectOutput, error) {
+	dataPath, metaPath, err := l.objectPaths(req.Bucket, req.Key)
+	if err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	meta, err := l.readMeta(req.Bucket, req.Key)
+	if err == ErrNoSuchKey {
+		if req.Position != 0 {
+			return nil, ErrPositionMismatch
+		}
+		tmp, _, _, err := l.writeTemp(req.Bucket, strings.NewReader(""))
+		if err != nil {
+			return nil, err
+		}
+		meta = &objectMeta{
+			Key:                  req.Key,
+			ACL:                  req.ACL,
+			CacheControl:         req.CacheControl,
+			ContentDisposition:   req.ContentDisposition,
+			ContentEncoding:      req.ContentEncoding,
+			Expires:              req.Expires,
+			ServerSideEncryption: req.ServerSideEncryption,
+			StorageClass:         req.StorageClass,
+			Tags:                 copyMap(req.Tags),
+		}
+		if err := l.commit(req.Bucket, tmp, meta); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	if meta.Size != req.Position {
+		return nil, ErrPositionMismatch
+	}
+	f, err := os.OpenFile(dataPath, os.O_RDWR, filePerm)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	n, err := f.Seek(0, io.SeekEnd)
+	if err == nil && n != req.Position {
+		err = ErrPositionMismatch
+	}
+	if err == nil {
+		_, err = io.Copy(f, req.DataStream)
+	}
+	if err != nil {
+		// roll back the partially appended data
+		f.Truncate(req.Position)
+		return nil, err
+	}
+	// recompute the etag of the whole object
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	h := md5.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return nil, err
+	}
+	meta.Size = size
+	meta.ETag = etag(h.Sum(nil))
+	meta.LastModified = time.Now()
+	if err := writeJSON(metaPath, meta); err != nil {
+		return nil, err
+	}
+	return &oss.AppendObjectOutput{AppendPosition: size}, nil
+}
+
+func trimQuotes(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
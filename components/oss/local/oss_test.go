@@ -0,0 +1,361 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"mosn.io/layotto/components/oss"
+)
+
+const bucket = "layotto"
+
+func newLocalOss(t *testing.T, versioning bool) *LocalOss {
+	dir, err := ioutil.TempDir("", "local-oss")
+	assert.Nil(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	conf, _ := json.Marshal(map[string]interface{}{"root": dir, "enable_versioning": versioning, "sign_key": "key"})
+	l := NewLocalOss().(*LocalOss)
+	err = l.Init(context.TODO(), &oss.Config{Metadata: map[string]json.RawMessage{oss.BasicConfiguration: conf}})
+	assert.Nil(t, err)
+	return l
+}
+
+func put(t *testing.T, l *LocalOss, key, data string) *oss.PutObjectOutput {
+	out, err := l.PutObject(context.TODO(), &oss.PutObjectInput{Bucket: bucket, Key: key, DataStream: strings.NewReader(data)})
+	assert.Nil(t, err)
+	return out
+}
+
+func get(t *testing.T, l *LocalOss, key, versionId string) string {
+	out, err := l.GetObject(context.TODO(), &oss.GetObjectInput{Bucket: bucket, Key: key, VersionId: versionId})
+	assert.Nil(t, err)
+	data, err := ioutil.ReadAll(out.DataStream)
+	assert.Nil(t, err)
+	out.DataStream.Close()
+	return string(data)
+}
+
+func TestInit(t *testing.T) {
+	l := NewLocalOss()
+	err := l.Init(context.TODO(), &oss.Config{Metadata: map[string]json.RawMessage{oss.BasicConfiguration: []byte("{}")}})
+	assert.NotNil(t, err)
+	err = l.Init(context.TODO(), &oss.Config{Metadata: map[string]json.RawMessage{oss.BasicConfiguration: []byte("[")}})
+	assert.Equal(t, oss.ErrInvalid, err)
+}
+
+func TestPing(t *testing.T) {
+	l := newLocalOss(t, false)
+	assert.Nil(t, l.Ping(context.TODO()))
+	assert.Nil(t, os.RemoveAll(l.root))
+	assert.NotNil(t, l.Ping(context.TODO()))
+}
+
+func TestObject(t *testing.T) {
+	l := newLocalOss(t, false)
+	ctx := context.TODO()
+
+	out, err := l.PutObject(ctx, &oss.PutObjectInput{
+		Bucket:     bucket,
+		Key:        "dir/a.txt",
+		DataStream: strings.NewReader("hello"),
+		Meta:       map[string]string{"owner": "layotto"},
+		Tagging:    map[string]string{"k": "v"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "\"5d41402abc4b2a76b9719d911017c592\"", out.ETag)
+
+	getOut, err := l.GetObject(ctx, &oss.GetObjectInput{Bucket: bucket, Key: "dir/a.txt"})
+	assert.Nil(t, err)
+	data, _ := ioutil.ReadAll(getOut.DataStream)
+	getOut.DataStream.Close()
+	assert.Equal(t, "hello", string(data))
+	assert.Equal(t, int64(5), getOut.ContentLength)
+	assert.Equal(t, "layotto", getOut.Metadata["owner"])
+	assert.Equal(t, int64(1), getOut.TagCount)
+
+	_, err = l.GetObject(ctx, &oss.GetObjectInput{Bucket: bucket, Key: "dir/a.txt", IfNoneMatch: out.ETag})
+	assert.NotNil(t, err)
+
+	// range
+	getOut, err = l.GetObject(ctx, &oss.GetObjectInput{Bucket: bucket, Key: "dir/a.txt", Start: 1, End: 3})
+	assert.Nil(t, err)
+	data, _ = ioutil.ReadAll(getOut.DataStream)
+	getOut.DataStream.Close()
+	assert.Equal(t, "ell", string(data))
+	assert.Equal(t, int64(3), getOut.ContentLength)
+	assert.Equal(t, "bytes 1-3/5", getOut.ContentRange)
+	getOut, err = l.GetObject(ctx, &oss.GetObjectInput{Bucket: bucket, Key: "dir/a.txt", Start: 3})
+	assert.Nil(t, err)
+	data, _ = ioutil.ReadAll(getOut.DataStream)
+	getOut.DataStream.Close()
+	assert.Equal(t, "lo", string(data))
+	assert.Equal(t, "bytes 3-4/5", getOut.ContentRange)
+	_, err = l.GetObject(ctx, &oss.GetObjectInput{Bucket: bucket, Key: "dir/a.txt", Start: 5})
+	assert.True(t, oss.IsInvalidRange(err))
+
+	exist, err := l.IsObjectExist(ctx, &oss.IsObjectExistInput{Bucket: bucket, Key: "dir/a.txt"})
+	assert.Nil(t, err)
+	assert.True(t, exist.FileExist)
+
+	head, err := l.HeadObject(ctx, &oss.HeadObjectInput{Bucket: bucket, Key: "dir/a.txt", WithDetails: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "5", head.ResultMetadata["Content-Length"])
+
+	// tagging
+	_, err = l.PutObjectTagging(ctx, &oss.PutObjectTaggingInput{Bucket: bucket, Key: "dir/a.txt", Tags: map[string]string{"a": "b"}})
+	assert.Nil(t, err)
+	tags, err := l.GetObjectTagging(ctx, &oss.GetObjectTaggingInput{Bucket: bucket, Key: "dir/a.txt"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"a": "b"}, tags.Tags)
+	_, err = l.DeleteObjectTagging(ctx, &oss.DeleteObjectTaggingInput{Bucket: bucket, Key: "dir/a.txt"})
+	assert.Nil(t, err)
+	tags, _ = l.GetObjectTagging(ctx, &oss.GetObjectTaggingInput{Bucket: bucket, Key: "dir/a.txt"})
+	assert.Len(t, tags.Tags, 0)
+
+	// copy
+	_, err = l.CopyObject(ctx, &oss.CopyObjectInput{
+		Bucket:            bucket,
+		Key:               "b.txt",
+		CopySource:        &oss.CopySource{CopySourceBucket: bucket, CopySourceKey: "dir/a.txt"},
+		MetadataDirective: "REPLACE",
+		Metadata:          map[string]string{"owner": "copy"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", get(t, l, "b.txt", ""))
+	getOut, _ = l.GetObject(ctx, &oss.GetObjectInput{Bucket: bucket, Key: "b.txt"})
+	getOut.DataStream.Close()
+	assert.Equal(t, "copy", getOut.Metadata["owner"])
+
+	// delete, and the key can be reused as a file
+	_, err = l.DeleteObject(ctx, &oss.DeleteObjectInput{Bucket: bucket, Key: "dir/a.txt"})
+	assert.Nil(t, err)
+	_, err = l.GetObject(ctx, &oss.GetObjectInput{Bucket: bucket, Key: "dir/a.txt"})
+	assert.Equal(t, ErrNoSuchKey, err)
+	put(t, l, "dir", "file")
+	assert.Equal(t, "file", get(t, l, "dir", ""))
+
+	// invalid keys
+	_, err = l.PutObject(ctx, &oss.PutObjectInput{Bucket: bucket, Key: "../escape", DataStream: strings.NewReader("")})
+	assert.True(t, strings.Contains(err.Error(), ErrInvalidName.Error()))
+	_, err = l.PutObject(ctx, &oss.PutObjectInput{Bucket: bucket, Key: ".layotto/meta", DataStream: strings.NewReader("")})
+	assert.NotNil(t, err)
+
+	_, err = l.RestoreObject(ctx, &oss.RestoreObjectInput{Bucket: bucket, Key: "b.txt"})
+	assert.True(t, oss.IsNotSupportMethod(err))
+}
+
+func TestAppendObject(t *testing.T) {
+	l := newLocalOss(t, false)
+	ctx := context.TODO()
+	out, err := l.AppendObject(ctx, &oss.AppendObjectInput{Bucket: bucket, Key: "log", DataStream: strings.NewReader("hello")})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), out.AppendPosition)
+	_, err = l.AppendObject(ctx, &oss.AppendObjectInput{Bucket: bucket, Key: "log", Position: 3, DataStream: strings.NewReader("!")})
+	assert.Equal(t, ErrPositionMismatch, err)
+	out, err = l.AppendObject(ctx, &oss.AppendObjectInput{Bucket: bucket, Key: "log", Position: 5, DataStream: strings.NewReader(" world")})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(11), out.AppendPosition)
+	assert.Equal(t, "hello world", get(t, l, "log", ""))
+}
+
+func TestListObjects(t *testing.T) {
+	l := newLocalOss(t, false)
+	ctx := context.TODO()
+	for _, key := range []string{"a", "b/1", "b/2", "c/d/e", "c-d"} {
+		put(t, l, key, key)
+	}
+
+	out, err := l.ListObjects(ctx, &oss.ListObjectsInput{Bucket: bucket})
+	assert.Nil(t, err)
+	var keys []string
+	for _, o := range out.Contents {
+		keys = append(keys, o.Key)
+	}
+	assert.Equal(t, []string{"a", "b/1", "b/2", "c-d", "c/d/e"}, keys)
+
+	out, err = l.ListObjects(ctx, &oss.ListObjectsInput{Bucket: bucket, Delimiter: "/", MaxKeys: 2})
+	assert.Nil(t, err)
+	assert.Len(t, out.Contents, 1)
+	assert.Equal(t, []string{"b/"}, out.CommonPrefixes)
+	assert.True(t, out.IsTruncated)
+	assert.Equal(t, "b/", out.NextMarker)
+
+	out, err = l.ListObjects(ctx, &oss.ListObjectsInput{Bucket: bucket, Delimiter: "/", Marker: out.NextMarker})
+	assert.Nil(t, err)
+	assert.Equal(t, "c-d", out.Contents[0].Key)
+	assert.Equal(t, []string{"c/"}, out.CommonPrefixes)
+	assert.False(t, out.IsTruncated)
+
+	out, err = l.ListObjects(ctx, &oss.ListObjectsInput{Bucket: bucket, Prefix: "c/", Delimiter: "/"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"c/d/"}, out.CommonPrefixes)
+
+	// buckets are created on demand
+	out, err = l.ListObjects(ctx, &oss.ListObjectsInput{Bucket: "empty"})
+	assert.Nil(t, err)
+	assert.Len(t, out.Contents, 0)
+}
+
+func TestVersions(t *testing.T) {
+	l := newLocalOss(t, true)
+	ctx := context.TODO()
+	put(t, l, "key", "v1")
+	put(t, l, "key", "v2")
+
+	out, err := l.ListObjectVersions(ctx, &oss.ListObjectVersionsInput{Bucket: bucket})
+	assert.Nil(t, err)
+	assert.Len(t, out.Versions, 2)
+	assert.True(t, out.Versions[0].IsLatest)
+	v1 := out.Versions[1].VersionId
+	assert.Equal(t, "v1", get(t, l, "key", v1))
+	assert.Equal(t, "v2", get(t, l, "key", ""))
+
+	// a delete marker hides the object
+	del, err := l.DeleteObject(ctx, &oss.DeleteObjectInput{Bucket: bucket, Key: "key"})
+	assert.Nil(t, err)
+	assert.True(t, del.DeleteMarker)
+	_, err = l.GetObject(ctx, &oss.GetObjectInput{Bucket: bucket, Key: "key"})
+	assert.Equal(t, ErrNoSuchKey, err)
+	out, _ = l.ListObjectVersions(ctx, &oss.ListObjectVersionsInput{Bucket: bucket})
+	assert.Len(t, out.DeleteMarkers, 1)
+	assert.True(t, out.DeleteMarkers[0].IsLatest)
+
+	// removing the delete marker restores the latest version
+	_, err = l.DeleteObject(ctx, &oss.DeleteObjectInput{Bucket: bucket, Key: "key", VersionId: del.VersionId})
+	assert.Nil(t, err)
+	assert.Equal(t, "v2", get(t, l, "key", ""))
+
+	// pagination
+	out, err = l.ListObjectVersions(ctx, &oss.ListObjectVersionsInput{Bucket: bucket, MaxKeys: 1})
+	assert.Nil(t, err)
+	assert.True(t, out.IsTruncated)
+	out, err = l.ListObjectVersions(ctx, &oss.ListObjectVersionsInput{
+		Bucket:          bucket,
+		KeyMarker:       out.NextKeyMarker,
+		VersionIdMarker: out.NextVersionIdMarker,
+	})
+	assert.Nil(t, err)
+	assert.Len(t, out.Versions, 1)
+	assert.Equal(t, v1, out.Versions[0].VersionId)
+	assert.False(t, out.Versions[0].IsLatest)
+}
+
+func TestMultipartUpload(t *testing.T) {
+	l := newLocalOss(t, false)
+	ctx := context.TODO()
+	put(t, l, "src", "0123456789")
+
+	create, err := l.CreateMultipartUpload(ctx, &oss.CreateMultipartUploadInput{
+		Bucket:   bucket,
+		Key:      "big",
+		MetaData: map[string]string{"owner": "layotto"},
+	})
+	assert.Nil(t, err)
+	id := create.UploadId
+
+	p1, err := l.UploadPart(ctx, &oss.UploadPartInput{Bucket: bucket, Key: "big", UploadId: id, PartNumber: 1, DataStream: strings.NewReader("hello ")})
+	assert.Nil(t, err)
+	p2, err := l.UploadPartCopy(ctx, &oss.UploadPartCopyInput{
+		Bucket:        bucket,
+		Key:           "big",
+		UploadId:      id,
+		PartNumber:    2,
+		CopySource:    &oss.CopySource{CopySourceBucket: bucket, CopySourceKey: "src"},
+		StartPosition: 2,
+		PartSize:      3,
+	})
+	assert.Nil(t, err)
+	_, err = l.UploadPart(ctx, &oss.UploadPartInput{Bucket: bucket, Key: "other", UploadId: id, PartNumber: 1, DataStream: strings.NewReader("")})
+	assert.Equal(t, ErrNoSuchUpload, err)
+
+	parts, err := l.ListParts(ctx, &oss.ListPartsInput{Bucket: bucket, Key: "big", UploadId: id})
+	assert.Nil(t, err)
+	assert.Len(t, parts.Parts, 2)
+	uploads, err := l.ListMultipartUploads(ctx, &oss.ListMultipartUploadsInput{Bucket: bucket})
+	assert.Nil(t, err)
+	assert.Equal(t, id, uploads.Uploads[0].UploadId)
+
+	_, err = l.CompleteMultipartUpload(ctx, &oss.CompleteMultipartUploadInput{
+		Bucket:   bucket,
+		Key:      "big",
+		UploadId: id,
+		MultipartUpload: &oss.CompletedMultipartUpload{Parts: []*oss.CompletedPart{
+			{PartNumber: 2, ETag: p2.CopyPartResult.ETag}, {PartNumber: 1, ETag: p1.ETag},
+		}},
+	})
+	assert.Equal(t, ErrInvalidPart, err)
+
+	complete, err := l.CompleteMultipartUpload(ctx, &oss.CompleteMultipartUploadInput{
+		Bucket:   bucket,
+		Key:      "big",
+		UploadId: id,
+		MultipartUpload: &oss.CompletedMultipartUpload{Parts: []*oss.CompletedPart{
+			{PartNumber: 1, ETag: p1.ETag}, {PartNumber: 2, ETag: p2.CopyPartResult.ETag},
+		}},
+	})
+	assert.Nil(t, err)
+	assert.True(t, strings.HasSuffix(complete.ETag, "-2\""))
+	assert.Equal(t, "hello 234", get(t, l, "big", ""))
+	getOut, _ := l.GetObject(ctx, &oss.GetObjectInput{Bucket: bucket, Key: "big"})
+	getOut.DataStream.Close()
+	assert.Equal(t, "layotto", getOut.Metadata["owner"])
+	assert.Equal(t, int64(2), getOut.PartsCount)
+
+	// the upload is removed once completed
+	_, err = l.AbortMultipartUpload(ctx, &oss.AbortMultipartUploadInput{Bucket: bucket, Key: "big", UploadId: id})
+	assert.Equal(t, ErrNoSuchUpload, err)
+	_, err = os.Stat(filepath.Join(l.root, bucket, systemDir, uploadsDir, id))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestSignURL(t *testing.T) {
+	l := newLocalOss(t, false)
+	ctx := context.TODO()
+
+	out, err := l.SignURL(ctx, &oss.SignURLInput{Bucket: bucket, Key: "dir/a b.txt", Method: "put", ExpiredInSec: 60})
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(out.SignedUrl, defaultURLPrefix+"/layotto/dir/a%20b.txt?"))
+
+	_, err = l.PutObject(ctx, &oss.PutObjectInput{SignedUrl: out.SignedUrl, DataStream: strings.NewReader("signed")})
+	assert.Nil(t, err)
+	assert.Equal(t, "signed", get(t, l, "dir/a b.txt", ""))
+
+	// signed for PUT only
+	_, err = l.GetObject(ctx, &oss.GetObjectInput{SignedUrl: out.SignedUrl})
+	assert.NotNil(t, err)
+
+	// tampered
+	_, err = l.VerifySignedURL(strings.Replace(out.SignedUrl, "a%20b", "c", 1), "")
+	assert.Equal(t, ErrInvalidSignature, err)
+
+	signed, err := l.VerifySignedURL(out.SignedUrl, "PUT")
+	assert.Nil(t, err)
+	assert.Equal(t, &SignedURL{Bucket: bucket, Key: "dir/a b.txt", Method: "PUT", Expires: signed.Expires}, signed)
+
+	_, err = l.SignURL(ctx, &oss.SignURLInput{Bucket: bucket, Key: "key", Method: "POST", ExpiredInSec: 60})
+	assert.Equal(t, fmt.Errorf("not supported method %+v now", "POST"), err)
+}
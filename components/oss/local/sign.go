@@ -0,0 +1,129 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"mosn.io/layotto/components/oss"
+)
+
+const (
+	defaultURLPrefix = "http://localhost"
+
+	paramMethod    = "x-layotto-method"
+	paramExpires   = "x-layotto-expires"
+	paramSignature = "x-layotto-signature"
+)
+
+var (
+	ErrInvalidSignature = errors.New("the signature of the url is invalid")
+	ErrURLExpired       = errors.New("the signed url has expired")
+)
+
+// SignedURL is the request which a signed url grants
+type SignedURL struct {
+	Bucket  string
+	Key     string
+	Method  string
+	Expires time.Time
+}
+
+// SignURL returns `<url_prefix>/<bucket>/<key>?x-layotto-method=<method>&x-layotto-expires=<unix>&x-layotto-signature=<hmac>`.
+// The url can be passed back as `SignedUrl` of GetObject and PutObject, or verified by VerifySignedURL in an http server.
+func (l *LocalOss) SignURL(ctx context.Context, req *oss.SignURLInput) (*oss.SignURLOutput, error) {
+	method := strings.ToUpper(req.Method)
+	switch method {
+	case "GET", "PUT", "HEAD", "DELETE":
+	default:
+		return nil, fmt.Errorf("not supported method %+v now", req.Method)
+	}
+	if req.ExpiredInSec <= 0 {
+		return nil, fmt.Errorf("%w: expired_in_sec must be positive", oss.ErrInvalid)
+	}
+	if _, _, err := l.objectPaths(req.Bucket, req.Key); err != nil {
+		return nil, err
+	}
+	expires := strconv.FormatInt(time.Now().Unix()+req.ExpiredInSec, 10)
+	query := url.Values{}
+	query.Set(paramMethod, method)
+	query.Set(paramExpires, expires)
+	query.Set(paramSignature, l.sign(method, req.Bucket, req.Key, expires))
+	u := strings.TrimSuffix(l.config.URLPrefix, "/") + "/" + url.PathEscape(req.Bucket) + "/" + escapeKey(req.Key) + "?" + query.Encode()
+	return &oss.SignURLOutput{SignedUrl: u}, nil
+}
+
+// VerifySignedURL checks the signature and the expiration of a url returned by SignURL.
+// The method is not checked if it's empty.
+func (l *LocalOss) VerifySignedURL(signedURL string, method string) (*SignedURL, error) {
+	u, err := url.Parse(signedURL)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	prefix, err := url.Parse(l.config.URLPrefix)
+	if err != nil {
+		return nil, err
+	}
+	path := strings.TrimPrefix(u.Path, strings.TrimSuffix(prefix.Path, "/"))
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)
+	if len(parts) != 2 {
+		return nil, ErrInvalidSignature
+	}
+	query := u.Query()
+	signed := &SignedURL{Bucket: parts[0], Key: parts[1], Method: query.Get(paramMethod)}
+	expires := query.Get(paramExpires)
+	expected := l.sign(signed.Method, signed.Bucket, signed.Key, expires)
+	if !hmac.Equal([]byte(expected), []byte(query.Get(paramSignature))) {
+		return nil, ErrInvalidSignature
+	}
+	if method != "" && !strings.EqualFold(method, signed.Method) {
+		return nil, fmt.Errorf("%w: the url is signed for %s", ErrInvalidSignature, signed.Method)
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	signed.Expires = time.Unix(unix, 0)
+	if time.Now().After(signed.Expires) {
+		return nil, ErrURLExpired
+	}
+	return signed, nil
+}
+
+func (l *LocalOss) sign(method, bucket, key, expires string) string {
+	h := hmac.New(sha256.New, []byte(l.config.SignKey))
+	h.Write([]byte(strings.Join([]string{method, bucket, key, expires}, "\n")))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// escapeKey escapes the segments of the key, keeping the slashes
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
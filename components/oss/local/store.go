@@ -0,0 +1,420 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The layout of a bucket:
+//
+//	<root>/<bucket>/<key>                                   the data of the current version
+//	<root>/<bucket>/.layotto/meta/<key>.json                the metadata of the current version
+//	<root>/<bucket>/.layotto/versions/<key>/<id>[.json]     the data and metadata of the noncurrent versions and delete markers
+//	<root>/<bucket>/.layotto/uploads/<upload id>/...        the multipart uploads
+//	<root>/<bucket>/.layotto/tmp/                           the temporary files which are renamed into place when completed
+const (
+	systemDir   = ".layotto"
+	metaDir     = "meta"
+	versionsDir = "versions"
+	uploadsDir  = "uploads"
+	tmpDir      = "tmp"
+	metaSuffix  = ".json"
+	dirPerm     = 0755
+	filePerm    = 0644
+)
+
+// objectMeta is the sidecar json file of an object version
+type objectMeta struct {
+	Key                  string            `json:"key"`
+	VersionId            string            `json:"version_id,omitempty"`
+	ETag                 string            `json:"etag,omitempty"`
+	Size                 int64             `json:"size"`
+	LastModified         time.Time         `json:"last_modified"`
+	DeleteMarker         bool              `json:"delete_marker,omitempty"`
+	ACL                  string            `json:"acl,omitempty"`
+	CacheControl         string            `json:"cache_control,omitempty"`
+	ContentDisposition   string            `json:"content_disposition,omitempty"`
+	ContentEncoding      string            `json:"content_encoding,omitempty"`
+	ContentLanguage      string            `json:"content_language,omitempty"`
+	ContentType          string            `json:"content_type,omitempty"`
+	Expires              int64             `json:"expires,omitempty"`
+	ServerSideEncryption string            `json:"server_side_encryption,omitempty"`
+	StorageClass         string            `json:"storage_class,omitempty"`
+	PartsCount           int64             `json:"parts_count,omitempty"`
+	Metadata             map[string]string `json:"metadata,omitempty"`
+	Tags                 map[string]string `json:"tags,omitempty"`
+}
+
+// bucketPath returns the directory of a bucket
+func (l *LocalOss) bucketPath(bucket string) (string, error) {
+	if bucket == "" || bucket == "." || bucket == ".." || strings.ContainsAny(bucket, "/\\\x00") {
+		return "", fmt.Errorf("%w: invalid bucket name %q", ErrInvalidName, bucket)
+	}
+	return filepath.Join(l.root, bucket), nil
+}
+
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.ContainsRune(key, '\x00') || strings.Contains(key, "\\") {
+		return fmt.Errorf("%w: invalid key %q", ErrInvalidName, key)
+	}
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return fmt.Errorf("%w: invalid key %q", ErrInvalidName, key)
+		}
+	}
+	if key == systemDir || strings.HasPrefix(key, systemDir+"/") {
+		return fmt.Errorf("%w: the prefix %s is reserved", ErrInvalidName, systemDir)
+	}
+	return nil
+}
+
+// objectPaths returns the paths of the data and the metadata of the current version of an object
+func (l *LocalOss) objectPaths(bucket, key string) (string, string, error) {
+	dir, err := l.bucketPath(bucket)
+	if err != nil {
+		return "", "", err
+	}
+	if err := checkKey(key); err != nil {
+		return "", "", err
+	}
+	rel := filepath.FromSlash(key)
+	return filepath.Join(dir, rel), filepath.Join(dir, systemDir, metaDir, rel+metaSuffix), nil
+}
+
+// versionPaths returns the paths of the data and the metadata of a noncurrent version
+func (l *LocalOss) versionPaths(bucket, key, versionId string) (string, string, error) {
+	dir, err := l.bucketPath(bucket)
+	if err != nil {
+		return "", "", err
+	}
+	if err := checkKey(key); err != nil {
+		return "", "", err
+	}
+	if versionId == "" || strings.ContainsAny(versionId, "/\\.") {
+		return "", "", ErrNoSuchVersion
+	}
+	base := filepath.Join(dir, systemDir, versionsDir, filepath.FromSlash(key), versionId)
+	return base, base + metaSuffix, nil
+}
+
+// readMeta reads the metadata of the current version.
+// Objects put into the directory by other programs have no metadata, so it's generated from the file.
+func (l *LocalOss) readMeta(bucket, key string) (*objectMeta, error) {
+	dataPath, metaPath, err := l.objectPaths(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(dataPath)
+	if err != nil || info.IsDir() {
+		return nil, ErrNoSuchKey
+	}
+	meta := &objectMeta{}
+	if err := readJSON(metaPath, meta); err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		meta = &objectMeta{Key: key, Size: info.Size(), LastModified: info.ModTime()}
+	}
+	return meta, nil
+}
+
+// readVersion reads the metadata of a version, which can be either the current version or a noncurrent one
+func (l *LocalOss) readVersion(bucket, key, versionId string) (meta *objectMeta, dataPath string, err error) {
+	// the version id of the objects put before versioning was enabled
+	if versionId == "null" {
+		versionId = ""
+	}
+	if versionId != "" {
+		if meta, err = l.readMeta(bucket, key); err == nil && meta.VersionId == versionId {
+			dataPath, _, err = l.objectPaths(bucket, key)
+			return meta, dataPath, err
+		}
+		dataPath, metaPath, err := l.versionPaths(bucket, key, versionId)
+		if err != nil {
+			return nil, "", err
+		}
+		meta = &objectMeta{}
+		if err := readJSON(metaPath, meta); err != nil {
+			if os.IsNotExist(err) {
+				return nil, "", ErrNoSuchVersion
+			}
+			return nil, "", err
+		}
+		return meta, dataPath, nil
+	}
+	if meta, err = l.readMeta(bucket, key); err != nil {
+		return nil, "", err
+	}
+	dataPath, _, err = l.objectPaths(bucket, key)
+	return meta, dataPath, err
+}
+
+// writeVersionMeta writes the metadata of either the current version or a noncurrent one
+func (l *LocalOss) writeVersionMeta(bucket string, meta *objectMeta) error {
+	current, err := l.readMeta(bucket, meta.Key)
+	if err == nil && current.VersionId == meta.VersionId {
+		_, metaPath, err := l.objectPaths(bucket, meta.Key)
+		if err != nil {
+			return err
+		}
+		return writeJSON(metaPath, meta)
+	}
+	_, metaPath, err := l.versionPaths(bucket, meta.Key, meta.VersionId)
+	if err != nil {
+		return err
+	}
+	return writeJSON(metaPath, meta)
+}
+
+// tempFile creates a temporary file in the bucket, which is on the same file system as the objects
+func (l *LocalOss) tempFile(bucket string) (*os.File, error) {
+	dir, err := l.bucketPath(bucket)
+	if err != nil {
+		return nil, err
+	}
+	tmp := filepath.Join(dir, systemDir, tmpDir)
+	if err := os.MkdirAll(tmp, dirPerm); err != nil {
+		return nil, err
+	}
+	return ioutil.TempFile(tmp, "object-")
+}
+
+// writeTemp writes the stream into a temporary file, returning the path, the size and the md5 of the data
+func (l *LocalOss) writeTemp(bucket string, r io.Reader) (string, int64, []byte, error) {
+	f, err := l.tempFile(bucket)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	h := md5.New()
+	n, err := io.Copy(io.MultiWriter(f, h), r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", 0, nil, err
+	}
+	return f.Name(), n, h.Sum(nil), nil
+}
+
+// commit moves the temporary file into place as the current version of the object.
+// The previous current version is kept as a noncurrent version if versioning is enabled.
+// It must be called with the lock held.
+func (l *LocalOss) commit(bucket, tmpPath string, meta *objectMeta) error {
+	dataPath, metaPath, err := l.objectPaths(bucket, meta.Key)
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := l.archiveCurrent(bucket, meta.Key); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if l.config.EnableVersioning {
+		meta.VersionId = newID()
+	}
+	if meta.LastModified.IsZero() {
+		meta.LastModified = time.Now()
+	}
+	if err := os.MkdirAll(filepath.Dir(dataPath), dirPerm); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("%w: %v", ErrKeyConflict, err)
+	}
+	if err := os.Rename(tmpPath, dataPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("%w: %v", ErrKeyConflict, err)
+	}
+	return writeJSON(metaPath, meta)
+}
+
+// archiveCurrent moves the current version to the noncurrent versions if versioning is enabled,
+// otherwise the current version is overwritten later. It must be called with the lock held.
+func (l *LocalOss) archiveCurrent(bucket, key string) error {
+	if !l.config.EnableVersioning {
+		return nil
+	}
+	current, err := l.readMeta(bucket, key)
+	if err == ErrNoSuchKey {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// the objects put before versioning was enabled
+	if current.VersionId == "" {
+		current.VersionId = newID()
+	}
+	dataPath, metaPath, err := l.objectPaths(bucket, key)
+	if err != nil {
+		return err
+	}
+	versionData, versionMeta, err := l.versionPaths(bucket, key, current.VersionId)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(versionData), dirPerm); err != nil {
+		return err
+	}
+	if err := os.Rename(dataPath, versionData); err != nil {
+		return err
+	}
+	l.removeEmptyParents(bucket, dataPath)
+	if err := writeJSON(versionMeta, current); err != nil {
+		return err
+	}
+	return removeIfExist(metaPath)
+}
+
+// listVersions lists the noncurrent versions and delete markers of an object, the latest first
+func (l *LocalOss) listVersions(bucket, key string) ([]*objectMeta, error) {
+	dir, err := l.bucketPath(bucket)
+	if err != nil {
+		return nil, err
+	}
+	files, err := ioutil.ReadDir(filepath.Join(dir, systemDir, versionsDir, filepath.FromSlash(key)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var versions []*objectMeta
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), metaSuffix) {
+			continue
+		}
+		meta := &objectMeta{}
+		if err := readJSON(filepath.Join(dir, systemDir, versionsDir, filepath.FromSlash(key), f.Name()), meta); err != nil {
+			return nil, err
+		}
+		versions = append(versions, meta)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].LastModified.After(versions[j].LastModified)
+	})
+	return versions, nil
+}
+
+// promoteLatest makes the latest noncurrent version current after the current version is deleted.
+// Nothing is promoted if the latest one is a delete marker. It must be called with the lock held.
+func (l *LocalOss) promoteLatest(bucket, key string) error {
+	if _, err := l.readMeta(bucket, key); err == nil {
+		return nil
+	}
+	versions, err := l.listVersions(bucket, key)
+	if err != nil || len(versions) == 0 || versions[0].DeleteMarker {
+		return err
+	}
+	latest := versions[0]
+	versionData, versionMeta, err := l.versionPaths(bucket, key, latest.VersionId)
+	if err != nil {
+		return err
+	}
+	dataPath, metaPath, err := l.objectPaths(bucket, key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dataPath), dirPerm); err != nil {
+		return err
+	}
+	if err := os.Rename(versionData, dataPath); err != nil {
+		return err
+	}
+	if err := writeJSON(metaPath, latest); err != nil {
+		return err
+	}
+	return removeIfExist(versionMeta)
+}
+
+func etag(sum []byte) string {
+	return "\"" + hex.EncodeToString(sum) + "\""
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// writeJSON writes the file atomically
+func writeJSON(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), dirPerm); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, filePerm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// removeEmptyParents removes the empty directories left by the removed objects, so that the keys can be reused,
+// e.g. `a` can be put after `a/b` is deleted.
+func (l *LocalOss) removeEmptyParents(bucket, path string) {
+	dir, err := l.bucketPath(bucket)
+	if err != nil {
+		return
+	}
+	for p := filepath.Dir(path); strings.HasPrefix(p, dir+string(filepath.Separator)); p = filepath.Dir(p) {
+		// fails if the directory is not empty
+		if os.Remove(p) != nil {
+			return
+		}
+	}
+}
+
+func removeIfExist(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func copyMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
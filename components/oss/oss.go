@@ -57,6 +57,8 @@ type Oss interface {
 	ListParts(context.Context, *ListPartsInput) (*ListPartsOutput, error)
 }
 
+// GetObjectInput is the input of GetObject. Start and End are the inclusive range of the object, a zero End
+// means the end of the object.
 type GetObjectInput struct {
 	Bucket                     string `json:"bucket,omitempty"`
 	ExpectedBucketOwner        string `json:"expected_bucket_owner,omitempty"`
@@ -83,6 +85,8 @@ type GetObjectInput struct {
 	SignedUrl                  string `json:"signed_url,omitempty"`
 }
 
+// GetObjectOutput is the output of GetObject. ContentRange is set if the range of the input is applied,
+// then ContentLength is the length of the range. A ContentLength of -1 means the size is unknown.
 type GetObjectOutput struct {
 	DataStream         io.ReadCloser
 	CacheControl       string            `json:"cache_control,omitempty"`
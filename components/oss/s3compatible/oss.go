@@ -0,0 +1,737 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package s3compatible implements the oss component for the services speaking the S3 protocol,
+// e.g. MinIO, SeaweedFS and Cloudflare R2.
+package s3compatible
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	aws_config "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/jinzhu/copier"
+
+	"mosn.io/layotto/components/oss"
+	"mosn.io/layotto/components/pkg/utils"
+)
+
+const (
+	componentName = "s3compatible"
+	defaultRegion = "us-east-1"
+)
+
+type s3Config struct {
+	Endpoint        string `json:"endpoint"`
+	Region          string `json:"region"`
+	AccessKeyID     string `json:"accessKeyID"`
+	AccessKeySecret string `json:"accessKeySecret"`
+	SessionToken    string `json:"sessionToken"`
+	// UsePathStyle addresses the buckets as `<endpoint>/<bucket>` instead of `<bucket>.<endpoint>`.
+	// Most self-hosted services only support the path style, so it defaults to true.
+	UsePathStyle *bool `json:"usePathStyle"`
+	// DisableSSL uses http for the endpoint without a scheme
+	DisableSSL bool `json:"disableSSL"`
+	// InsecureSkipVerify skips the verification of the server certificate
+	InsecureSkipVerify bool `json:"insecureSkipVerify"`
+	// CAFile is the PEM encoded CA certificates used to verify the server certificate
+	CAFile string `json:"caFile"`
+}
+
+type S3CompatibleOss struct {
+	client    *s3.Client
+	basicConf json.RawMessage
+}
+
+func NewS3CompatibleOss() oss.Oss {
+	return &S3CompatibleOss{}
+}
+
+func (s *S3CompatibleOss) Init(ctx context.Context, config *oss.Config) error {
+	s.basicConf = config.Metadata[oss.BasicConfiguration]
+	m := &s3Config{}
+	if err := json.Unmarshal(s.basicConf, m); err != nil {
+		return oss.ErrInvalid
+	}
+	if m.Endpoint == "" {
+		return fmt.Errorf("%w: endpoint is required", oss.ErrInvalid)
+	}
+	if m.Region == "" {
+		m.Region = defaultRegion
+	}
+	endpoint := m.Endpoint
+	if !strings.Contains(endpoint, "://") {
+		if m.DisableSSL {
+			endpoint = "http://" + endpoint
+		} else {
+			endpoint = "https://" + endpoint
+		}
+	}
+	tlsConfig, err := newTLSConfig(m)
+	if err != nil {
+		return err
+	}
+
+	resolver := aws.EndpointResolverWithOptionsFunc(func(service, region string, options ...interface{}) (aws.Endpoint, error) {
+		return aws.Endpoint{
+			URL:           endpoint,
+			SigningRegion: m.Region,
+			Source:        aws.EndpointSourceCustom,
+		}, nil
+	})
+	optFunc := []func(options *aws_config.LoadOptions) error{
+		aws_config.WithRegion(m.Region),
+		aws_config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(m.AccessKeyID, m.AccessKeySecret, m.SessionToken)),
+		aws_config.WithEndpointResolverWithOptions(resolver),
+	}
+	if tlsConfig != nil {
+		client := awshttp.NewBuildableClient().WithTransportOptions(func(tr *http.Transport) {
+			tr.TLSClientConfig = tlsConfig
+		})
+		optFunc = append(optFunc, aws_config.WithHTTPClient(client))
+	}
+	cfg, err := aws_config.LoadDefaultConfig(ctx, optFunc...)
+	if err != nil {
+		return err
+	}
+	usePathStyle := m.UsePathStyle == nil || *m.UsePathStyle
+	s.client = s3.NewFromConfig(cfg, func(options *s3.Options) {
+		options.UsePathStyle = usePathStyle
+	})
+	return nil
+}
+
+// Ping checks the connection to the store by listing the buckets
+func (s *S3CompatibleOss) Ping(ctx context.Context) error {
+	client, err := s.getClient()
+	if err != nil {
+		return err
+	}
+	_, err = client.ListBuckets(ctx, &s3.ListBucketsInput{})
+	return err
+}
+
+// newTLSConfig returns nil if the default tls config should be used
+func newTLSConfig(m *s3Config) (*tls.Config, error) {
+	if !m.InsecureSkipVerify && m.CAFile == "" {
+		return nil, nil
+	}
+	conf := &tls.Config{InsecureSkipVerify: m.InsecureSkipVerify}
+	if m.CAFile != "" {
+		pem, err := ioutil.ReadFile(m.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w: no certificate found in %s", oss.ErrInvalid, m.CAFile)
+		}
+		conf.RootCAs = pool
+	}
+	return conf, nil
+}
+
+func (s *S3CompatibleOss) GetObject(ctx context.Context, req *oss.GetObjectInput) (*oss.GetObjectOutput, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.GetObjectInput{}
+	err = copier.CopyWithOption(input, req, copier.Option{IgnoreEmpty: true, DeepCopy: true, Converters: []copier.TypeConverter{oss.Int64ToTime}})
+	if err != nil {
+		return nil, err
+	}
+	if req.End > 0 {
+		input.Range = aws.String(fmt.Sprintf("bytes=%d-%d", req.Start, req.End))
+	} else if req.Start > 0 {
+		input.Range = aws.String(fmt.Sprintf("bytes=%d-", req.Start))
+	}
+	ob, err := client.GetObject(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return oss.GetGetObjectOutput(ob)
+}
+
+func (s *S3CompatibleOss) PutObject(ctx context.Context, req *oss.PutObjectInput) (*oss.PutObjectOutput, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.PutObjectInput{}
+	err = copier.CopyWithOption(input, req, copier.Option{IgnoreEmpty: true, DeepCopy: true, Converters: []copier.TypeConverter{oss.Int64ToTime}})
+	if err != nil {
+		return nil, err
+	}
+	input.Body = req.DataStream
+	uploader := manager.NewUploader(client)
+	resp, err := uploader.Upload(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return oss.GetPutObjectOutput(resp)
+}
+
+func (s *S3CompatibleOss) DeleteObject(ctx context.Context, req *oss.DeleteObjectInput) (*oss.DeleteObjectOutput, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.DeleteObjectInput{}
+	err = copier.CopyWithOption(input, req, copier.Option{IgnoreEmpty: true, DeepCopy: true, Converters: []copier.TypeConverter{}})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.DeleteObject(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return oss.GetDeleteObjectOutput(resp)
+}
+
+func (s *S3CompatibleOss) PutObjectTagging(ctx context.Context, req *oss.PutObjectTaggingInput) (*oss.PutObjectTaggingOutput, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.PutObjectTaggingInput{Tagging: &types.Tagging{}}
+	err = copier.CopyWithOption(input, req, copier.Option{IgnoreEmpty: true, DeepCopy: true, Converters: []copier.TypeConverter{}})
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range req.Tags {
+		k, v := k, v
+		input.Tagging.TagSet = append(input.Tagging.TagSet, types.Tag{Key: &k, Value: &v})
+	}
+	_, err = client.PutObjectTagging(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return &oss.PutObjectTaggingOutput{}, nil
+}
+
+func (s *S3CompatibleOss) DeleteObjectTagging(ctx context.Context, req *oss.DeleteObjectTaggingInput) (*oss.DeleteObjectTaggingOutput, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.DeleteObjectTaggingInput{}
+	err = copier.CopyWithOption(input, req, copier.Option{IgnoreEmpty: true, DeepCopy: true, Converters: []copier.TypeConverter{}})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.DeleteObjectTagging(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return oss.GetDeleteObjectTaggingOutput(resp)
+}
+
+func (s *S3CompatibleOss) GetObjectTagging(ctx context.Context, req *oss.GetObjectTaggingInput) (*oss.GetObjectTaggingOutput, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.GetObjectTaggingInput{}
+	err = copier.CopyWithOption(input, req, copier.Option{IgnoreEmpty: true, DeepCopy: true, Converters: []copier.TypeConverter{}})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.GetObjectTagging(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return oss.GetGetObjectTaggingOutput(resp)
+}
+
+func (s *S3CompatibleOss) CopyObject(ctx context.Context, req *oss.CopyObjectInput) (*oss.CopyObjectOutput, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if req.CopySource == nil {
+		return nil, errors.New("must specific copy_source")
+	}
+
+	input := &s3.CopyObjectInput{}
+	err = copier.CopyWithOption(input, req, copier.Option{IgnoreEmpty: true, DeepCopy: true, Converters: []copier.TypeConverter{oss.Int64ToTime}})
+	if err != nil {
+		return nil, err
+	}
+	input.CopySource = copySource(req.CopySource)
+	resp, err := client.CopyObject(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return oss.GetCopyObjectOutput(resp)
+}
+
+func (s *S3CompatibleOss) DeleteObjects(ctx context.Context, req *oss.DeleteObjectsInput) (*oss.DeleteObjectsOutput, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.DeleteObjectsInput{
+		Bucket: &req.Bucket,
+		Delete: &types.Delete{},
+	}
+	if req.Delete != nil {
+		input.Delete.Quiet = req.Delete.Quiet
+		for _, v := range req.Delete.Objects {
+			object := &types.ObjectIdentifier{}
+			err = copier.CopyWithOption(object, v, copier.Option{IgnoreEmpty: true, DeepCopy: true, Converters: []copier.TypeConverter{}})
+			if err != nil {
+				return nil, err
+			}
+			input.Delete.Objects = append(input.Delete.Objects, *object)
+		}
+	}
+	resp, err := client.DeleteObjects(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &oss.DeleteObjectsOutput{}
+	err = copier.Copy(output, resp)
+	return output, err
+}
+
+func (s *S3CompatibleOss) ListObjects(ctx context.Context, req *oss.ListObjectsInput) (*oss.ListObjectsOutput, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.ListObjectsInput{}
+	err = copier.CopyWithOption(input, req, copier.Option{IgnoreEmpty: true, DeepCopy: true, Converters: []copier.TypeConverter{}})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.ListObjects(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := oss.GetListObjectsOutput(resp)
+	if err != nil {
+		return nil, err
+	}
+	output.CommonPrefixes = output.CommonPrefixes[:0]
+	for _, v := range resp.CommonPrefixes {
+		output.CommonPrefixes = append(output.CommonPrefixes, aws.ToString(v.Prefix))
+	}
+	return output, nil
+}
+
+func (s *S3CompatibleOss) GetObjectCannedAcl(ctx context.Context, req *oss.GetObjectCannedAclInput) (*oss.GetObjectCannedAclOutput, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.GetObjectAclInput{}
+	err = copier.CopyWithOption(input, req, copier.Option{IgnoreEmpty: true, DeepCopy: true, Converters: []copier.TypeConverter{}})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.GetObjectAcl(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return oss.GetGetObjectCannedAclOutput(resp)
+}
+
+func (s *S3CompatibleOss) PutObjectCannedAcl(ctx context.Context, req *oss.PutObjectCannedAclInput) (*oss.PutObjectCannedAclOutput, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.PutObjectAclInput{Bucket: &req.Bucket, Key: &req.Key, ACL: types.ObjectCannedACL(req.Acl)}
+	if req.VersionId != "" {
+		input.VersionId = &req.VersionId
+	}
+	resp, err := client.PutObjectAcl(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return &oss.PutObjectCannedAclOutput{RequestCharged: string(resp.RequestCharged)}, nil
+}
+
+func (s *S3CompatibleOss) CreateMultipartUpload(ctx context.Context, req *oss.CreateMultipartUploadInput) (*oss.CreateMultipartUploadOutput, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.CreateMultipartUploadInput{}
+	err = copier.CopyWithOption(input, req, copier.Option{IgnoreEmpty: true, DeepCopy: true, Converters: []copier.TypeConverter{oss.Int64ToTime}})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.CreateMultipartUpload(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &oss.CreateMultipartUploadOutput{}
+	err = copier.CopyWithOption(output, resp, copier.Option{IgnoreEmpty: true, DeepCopy: true, Converters: []copier.TypeConverter{oss.TimeToInt64}})
+	return output, err
+}
+
+func (s *S3CompatibleOss) UploadPart(ctx context.Context, req *oss.UploadPartInput) (*oss.UploadPartOutput, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.UploadPartInput{}
+	err = copier.CopyWithOption(input, req, copier.Option{IgnoreEmpty: true, DeepCopy: true, Converters: []copier.TypeConverter{}})
+	if err != nil {
+		return nil, err
+	}
+	input.Body = req.DataStream
+	// the stream can't be seeked to compute the payload hash
+	resp, err := client.UploadPart(ctx, input, s3.WithAPIOptions(v4.SwapComputePayloadSHA256ForUnsignedPayloadMiddleware))
+	if err != nil {
+		return nil, err
+	}
+
+	return oss.GetUploadPartOutput(resp)
+}
+
+func (s *S3CompatibleOss) UploadPartCopy(ctx context.Context, req *oss.UploadPartCopyInput) (*oss.UploadPartCopyOutput, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if req.CopySource == nil {
+		return nil, errors.New("must specific copy_source")
+	}
+
+	input := &s3.UploadPartCopyInput{}
+	err = copier.CopyWithOption(input, req, copier.Option{IgnoreEmpty: true, DeepCopy: true, Converters: []copier.TypeConverter{}})
+	if err != nil {
+		return nil, err
+	}
+	input.CopySource = copySource(req.CopySource)
+	if req.PartSize > 0 {
+		input.CopySourceRange = aws.String(fmt.Sprintf("bytes=%d-%d", req.StartPosition, req.StartPosition+req.PartSize-1))
+	}
+	resp, err := client.UploadPartCopy(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return oss.GetUploadPartCopyOutput(resp)
+}
+
+func (s *S3CompatibleOss) CompleteMultipartUpload(ctx context.Context, req *oss.CompleteMultipartUploadInput) (*oss.CompleteMultipartUploadOutput, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.CompleteMultipartUploadInput{MultipartUpload: &types.CompletedMultipartUpload{}}
+	err = copier.CopyWithOption(input, req, copier.Option{IgnoreEmpty: true, DeepCopy: true, Converters: []copier.TypeConverter{}})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.CompleteMultipartUpload(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &oss.CompleteMultipartUploadOutput{}
+	err = copier.Copy(output, resp)
+	return output, err
+}
+
+func (s *S3CompatibleOss) AbortMultipartUpload(ctx context.Context, req *oss.AbortMultipartUploadInput) (*oss.AbortMultipartUploadOutput, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.AbortMultipartUploadInput{}
+	err = copier.CopyWithOption(input, req, copier.Option{IgnoreEmpty: true, DeepCopy: true, Converters: []copier.TypeConverter{}})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.AbortMultipartUpload(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return &oss.AbortMultipartUploadOutput{RequestCharged: string(resp.RequestCharged)}, nil
+}
+
+func (s *S3CompatibleOss) ListParts(ctx context.Context, req *oss.ListPartsInput) (*oss.ListPartsOutput, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.ListPartsInput{}
+	err = copier.CopyWithOption(input, req, copier.Option{IgnoreEmpty: true, DeepCopy: true, Converters: []copier.TypeConverter{}})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.ListParts(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return oss.GetListPartsOutput(resp)
+}
+
+func (s *S3CompatibleOss) ListMultipartUploads(ctx context.Context, req *oss.ListMultipartUploadsInput) (*oss.ListMultipartUploadsOutput, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.ListMultipartUploadsInput{}
+	err = copier.CopyWithOption(input, req, copier.Option{IgnoreEmpty: true, DeepCopy: true, Converters: []copier.TypeConverter{}})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.ListMultipartUploads(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return oss.GetListMultipartUploadsOutput(resp)
+}
+
+func (s *S3CompatibleOss) ListObjectVersions(ctx context.Context, req *oss.ListObjectVersionsInput) (*oss.ListObjectVersionsOutput, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.ListObjectVersionsInput{}
+	err = copier.CopyWithOption(input, req, copier.Option{IgnoreEmpty: true, DeepCopy: true, Converters: []copier.TypeConverter{}})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.ListObjectVersions(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &oss.ListObjectVersionsOutput{}
+	err = copier.Copy(output, resp)
+	if err != nil {
+		return nil, err
+	}
+	output.CommonPrefixes, output.DeleteMarkers, output.Versions = nil, nil, nil
+	for _, v := range resp.CommonPrefixes {
+		output.CommonPrefixes = append(output.CommonPrefixes, aws.ToString(v.Prefix))
+	}
+	// the owner is not returned by some services, so the entries are converted here
+	// instead of oss.GetListObjectVersionsOutput which dereferences it
+	for _, v := range resp.DeleteMarkers {
+		entry := &oss.DeleteMarkerEntry{
+			IsLatest:     v.IsLatest,
+			Key:          aws.ToString(v.Key),
+			LastModified: unix(v.LastModified),
+			Owner:        owner(v.Owner),
+			VersionId:    aws.ToString(v.VersionId),
+		}
+		output.DeleteMarkers = append(output.DeleteMarkers, entry)
+	}
+	for _, v := range resp.Versions {
+		output.Versions = append(output.Versions, &oss.ObjectVersion{
+			ETag:         aws.ToString(v.ETag),
+			IsLatest:     v.IsLatest,
+			Key:          aws.ToString(v.Key),
+			LastModified: unix(v.LastModified),
+			Owner:        owner(v.Owner),
+			Size:         v.Size,
+			StorageClass: string(v.StorageClass),
+			VersionId:    aws.ToString(v.VersionId),
+		})
+	}
+	return output, nil
+}
+
+func (s *S3CompatibleOss) HeadObject(ctx context.Context, req *oss.HeadObjectInput) (*oss.HeadObjectOutput, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.HeadObjectInput{}
+	err = copier.CopyWithOption(input, req, copier.Option{IgnoreEmpty: true, DeepCopy: true, Converters: []copier.TypeConverter{oss.Int64ToTime}})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.HeadObject(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]string{}
+	for k, v := range resp.Metadata {
+		result[k] = v
+	}
+	if req.WithDetails {
+		result["Content-Length"] = strconv.FormatInt(resp.ContentLength, 10)
+		result["Etag"] = aws.ToString(resp.ETag)
+		if resp.LastModified != nil {
+			result["Last-Modified"] = resp.LastModified.UTC().Format(time.RFC1123)
+		}
+		if resp.ContentType != nil {
+			result["Content-Type"] = *resp.ContentType
+		}
+		if resp.VersionId != nil {
+			result["X-Amz-Version-Id"] = *resp.VersionId
+		}
+	}
+	return &oss.HeadObjectOutput{ResultMetadata: result}, nil
+}
+
+func (s *S3CompatibleOss) IsObjectExist(ctx context.Context, req *oss.IsObjectExistInput) (*oss.IsObjectExistOutput, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.HeadObjectInput{Bucket: &req.Bucket, Key: &req.Key}
+	_, err = client.HeadObject(ctx, input)
+	if err != nil {
+		var re *awshttp.ResponseError
+		if errors.As(err, &re) && re.HTTPStatusCode() == http.StatusNotFound {
+			return &oss.IsObjectExistOutput{FileExist: false}, nil
+		}
+		return nil, err
+	}
+	return &oss.IsObjectExistOutput{FileExist: true}, nil
+}
+
+func (s *S3CompatibleOss) SignURL(ctx context.Context, req *oss.SignURLInput) (*oss.SignURLOutput, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	presignClient := s3.NewPresignClient(client)
+	expires := s3.WithPresignExpires(time.Duration(req.ExpiredInSec) * time.Second)
+	var url string
+	switch strings.ToUpper(req.Method) {
+	case http.MethodGet:
+		resp, err := presignClient.PresignGetObject(ctx, &s3.GetObjectInput{Bucket: &req.Bucket, Key: &req.Key}, expires)
+		if err != nil {
+			return nil, err
+		}
+		url = resp.URL
+	case http.MethodPut:
+		resp, err := presignClient.PresignPutObject(ctx, &s3.PutObjectInput{Bucket: &req.Bucket, Key: &req.Key}, expires)
+		if err != nil {
+			return nil, err
+		}
+		url = resp.URL
+	case http.MethodHead:
+		resp, err := presignClient.PresignHeadObject(ctx, &s3.HeadObjectInput{Bucket: &req.Bucket, Key: &req.Key}, expires)
+		if err != nil {
+			return nil, err
+		}
+		url = resp.URL
+	default:
+		return nil, fmt.Errorf("%w: %s", oss.NewErrNotSupportMethod("SignURL", componentName), req.Method)
+	}
+	return &oss.SignURLOutput{SignedUrl: url}, nil
+}
+
+func (s *S3CompatibleOss) RestoreObject(ctx context.Context, req *oss.RestoreObjectInput) (*oss.RestoreObjectOutput, error) {
+	return nil, oss.NewErrNotSupportMethod("RestoreObject", componentName)
+}
+
+func (s *S3CompatibleOss) UpdateDownloadBandwidthRateLimit(ctx context.Context, req *oss.UpdateBandwidthRateLimitInput) error {
+	return oss.NewErrNotSupportMethod("UpdateDownloadBandwidthRateLimit", componentName)
+}
+
+func (s *S3CompatibleOss) UpdateUploadBandwidthRateLimit(ctx context.Context, req *oss.UpdateBandwidthRateLimitInput) error {
+	return oss.NewErrNotSupportMethod("UpdateUploadBandwidthRateLimit", componentName)
+}
+
+func (s *S3CompatibleOss) AppendObject(ctx context.Context, req *oss.AppendObjectInput) (*oss.AppendObjectOutput, error) {
+	return nil, oss.NewErrNotSupportMethod("AppendObject", componentName)
+}
+
+func (s *S3CompatibleOss) getClient() (*s3.Client, error) {
+	if s.client == nil {
+		return nil, utils.ErrNotInitClient
+	}
+	return s.client, nil
+}
+
+func copySource(source *oss.CopySource) *string {
+	copySource := source.CopySourceBucket + "/" + source.CopySourceKey
+	if source.CopySourceVersionId != "" {
+		copySource += "?versionId=" + source.CopySourceVersionId
+	}
+	return &copySource
+}
+
+func owner(o *types.Owner) *oss.Owner {
+	if o == nil {
+		return nil
+	}
+	return &oss.Owner{DisplayName: aws.ToString(o.DisplayName), ID: aws.ToString(o.ID)}
+}
+
+func unix(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.Unix()
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3compatible
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"mosn.io/layotto/components/oss"
+	"mosn.io/layotto/components/pkg/utils"
+)
+
+const conf = `
+	{
+		"endpoint": "127.0.0.1:9000",
+		"accessKeyID": "minioadmin",
+		"accessKeySecret": "minioadmin",
+		"disableSSL": true
+	}
+`
+
+func newOss(t *testing.T, basicConf string) *S3CompatibleOss {
+	instance := NewS3CompatibleOss().(*S3CompatibleOss)
+	err := instance.Init(context.TODO(), &oss.Config{Metadata: map[string]json.RawMessage{oss.BasicConfiguration: []byte(basicConf)}})
+	assert.Nil(t, err)
+	return instance
+}
+
+func TestInit(t *testing.T) {
+	instance := NewS3CompatibleOss()
+	err := instance.Init(context.TODO(), &oss.Config{Metadata: map[string]json.RawMessage{oss.BasicConfiguration: []byte("hello")}})
+	assert.Equal(t, oss.ErrInvalid, err)
+
+	err = instance.Init(context.TODO(), &oss.Config{Metadata: map[string]json.RawMessage{oss.BasicConfiguration: []byte("{}")}})
+	assert.True(t, errors.Is(err, oss.ErrInvalid))
+
+	err = instance.Init(context.TODO(), &oss.Config{Metadata: map[string]json.RawMessage{
+		oss.BasicConfiguration: []byte(`{"endpoint": "https://r2.example.com", "caFile": "/not/exist"}`),
+	}})
+	assert.NotNil(t, err)
+
+	_, err = instance.GetObject(context.TODO(), &oss.GetObjectInput{})
+	assert.Equal(t, utils.ErrNotInitClient, err)
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	tlsConfig, err := newTLSConfig(&s3Config{})
+	assert.Nil(t, err)
+	assert.Nil(t, tlsConfig)
+
+	tlsConfig, err = newTLSConfig(&s3Config{InsecureSkipVerify: true})
+	assert.Nil(t, err)
+	assert.True(t, tlsConfig.InsecureSkipVerify)
+}
+
+func TestSignURL(t *testing.T) {
+	instance := newOss(t, conf)
+	out, err := instance.SignURL(context.TODO(), &oss.SignURLInput{Bucket: "bucket", Key: "key", Method: "get", ExpiredInSec: 60})
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(out.SignedUrl, "http://127.0.0.1:9000/bucket/key?"))
+	assert.Contains(t, out.SignedUrl, "X-Amz-Expires=60")
+
+	// virtual hosted style
+	instance = newOss(t, `{"endpoint": "https://s3.example.com", "usePathStyle": false}`)
+	out, err = instance.SignURL(context.TODO(), &oss.SignURLInput{Bucket: "bucket", Key: "key", Method: "PUT", ExpiredInSec: 60})
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(out.SignedUrl, "https://bucket.s3.example.com/key?"))
+
+	_, err = instance.SignURL(context.TODO(), &oss.SignURLInput{Bucket: "bucket", Key: "key", Method: "POST", ExpiredInSec: 60})
+	assert.True(t, oss.IsNotSupportMethod(err))
+	assert.Contains(t, err.Error(), "POST")
+}
+
+func TestNotSupportMethod(t *testing.T) {
+	instance := newOss(t, conf)
+
+	_, err := instance.RestoreObject(context.TODO(), &oss.RestoreObjectInput{})
+	assert.Equal(t, &oss.ErrNotSupportMethod{Method: "RestoreObject", Component: componentName}, err)
+	_, err = instance.AppendObject(context.TODO(), &oss.AppendObjectInput{})
+	assert.True(t, oss.IsNotSupportMethod(err))
+	err = instance.UpdateDownloadBandwidthRateLimit(context.TODO(), &oss.UpdateBandwidthRateLimitInput{})
+	assert.True(t, oss.IsNotSupportMethod(err))
+	err = instance.UpdateUploadBandwidthRateLimit(context.TODO(), &oss.UpdateBandwidthRateLimitInput{})
+	assert.True(t, oss.IsNotSupportMethod(err))
+
+	_, err = instance.CopyObject(context.TODO(), &oss.CopyObjectInput{})
+	assert.Equal(t, errors.New("must specific copy_source"), err)
+	_, err = instance.UploadPartCopy(context.TODO(), &oss.UploadPartCopyInput{})
+	assert.Equal(t, errors.New("must specific copy_source"), err)
+}
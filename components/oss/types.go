@@ -19,6 +19,8 @@ package oss
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 
 	"mosn.io/layotto/components/ref"
 )
@@ -29,11 +31,38 @@ const (
 
 var (
 	ErrInvalid = errors.New("invalid argument")
+	// ErrInvalidRange is returned if the range of GetObjectInput is beyond the object
+	ErrInvalidRange = errors.New("InvalidRange: the requested range is not satisfiable")
 )
 
+// IsInvalidRange reports whether err is ErrInvalidRange or the InvalidRange error of the store
+func IsInvalidRange(err error) bool {
+	return errors.Is(err, ErrInvalidRange) || (err != nil && strings.Contains(err.Error(), "InvalidRange"))
+}
+
 // Config wraps configuration for a oss implementation
 type Config struct {
 	ref.Config
 	Metadata map[string]json.RawMessage `json:"metadata"`
 	Type     string                     `json:"type"`
 }
+
+// ErrNotSupportMethod is returned by the components for the methods of Oss which they can't support
+type ErrNotSupportMethod struct {
+	Method    string
+	Component string
+}
+
+func NewErrNotSupportMethod(method, component string) error {
+	return &ErrNotSupportMethod{Method: method, Component: component}
+}
+
+func (e *ErrNotSupportMethod) Error() string {
+	return fmt.Sprintf("%s method not supported on %s", e.Method, e.Component)
+}
+
+// IsNotSupportMethod reports whether any error in err's chain is an ErrNotSupportMethod
+func IsNotSupportMethod(err error) bool {
+	var e *ErrNotSupportMethod
+	return errors.As(err, &e)
+}
@@ -30,6 +30,8 @@ var (
 	UP = Status("UP")
 	// DOWN means it is unhealthy
 	DOWN = Status("DOWN")
+	// UNKNOWN means the health can't be checked, it doesn't fail the overall status
+	UNKNOWN = Status("UNKNOWN")
 )
 
 type Indicator interface {
@@ -0,0 +1,46 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package actuators
+
+import (
+	"sync"
+
+	"mosn.io/pkg/log"
+)
+
+var componentsLoggers sync.Map
+
+// SetComponentLogger registers a logger whose level can be changed at runtime through the actuator.
+func SetComponentLogger(name string, logger log.ErrorLogger) {
+	if logger == nil {
+		return
+	}
+	componentsLoggers.Store(name, logger)
+}
+
+func GetLoggerWithName(name string) log.ErrorLogger {
+	if v, ok := componentsLoggers.Load(name); ok {
+		return v.(log.ErrorLogger)
+	}
+	return nil
+}
+
+func RangeAllLoggers(f func(name string, logger log.ErrorLogger) bool) {
+	componentsLoggers.Range(func(k, v interface{}) bool {
+		return f(k.(string), v.(log.ErrorLogger))
+	})
+}
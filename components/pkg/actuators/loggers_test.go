@@ -0,0 +1,35 @@
+// Copyright 2021 Layotto Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package actuators
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mosn.io/pkg/log"
+)
+
+func TestRangeAllLoggers(t *testing.T) {
+	SetComponentLogger("nil", nil)
+	assert.Nil(t, GetLoggerWithName("nil"))
+
+	SetComponentLogger("test", log.DefaultLogger)
+	assert.Equal(t, log.DefaultLogger, GetLoggerWithName("test"))
+	cnt := 0
+	RangeAllLoggers(func(name string, logger log.ErrorLogger) bool {
+		cnt++
+		assert.Equal(t, "test", name)
+		return true
+	})
+	assert.Equal(t, 1, cnt)
+}
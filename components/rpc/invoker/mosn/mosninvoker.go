@@ -30,6 +30,7 @@ import (
 	"mosn.io/layotto/components/rpc"
 	"mosn.io/layotto/components/rpc/callback"
 	"mosn.io/layotto/components/rpc/invoker/mosn/channel"
+	"mosn.io/layotto/components/trace"
 )
 
 const (
@@ -110,8 +111,19 @@ func (m *mosnInvoker) Invoke(ctx context.Context, req *rpc.RPCRequest) (resp *rp
 		log.DefaultLogger.Errorf("[runtime][rpc]before filter error %s", err.Error())
 		return nil, err
 	}
-	// 3. do invocation
+	// 3. do invocation in a child span, whose context is propagated in the request header
+	ctx, span := trace.StartSpan(req.Ctx, trace.SpanKindClient, "rpc/"+req.Method, Name)
+	req.Ctx = ctx
+	if span != nil {
+		if req.Header == nil {
+			req.Header = rpc.RPCHeader{}
+		}
+		trace.Inject(span, func(key string, value string) {
+			req.Header[key] = []string{value}
+		})
+	}
 	resp, err = m.channel.Do(req)
+	trace.EndSpan(span, err)
 	if err != nil {
 		log.DefaultLogger.Errorf("[runtime][rpc]error %s", err.Error())
 		return nil, err
@@ -21,9 +21,13 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"mosn.io/api"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/types"
 
 	"mosn.io/layotto/components/rpc"
 	"mosn.io/layotto/components/rpc/invoker/mosn/channel"
+	"mosn.io/layotto/components/trace"
 )
 
 func Test_mosnInvoker_Init(t *testing.T) {
@@ -113,6 +117,31 @@ func Test_mosnInvoker_Invoke(t *testing.T) {
 		assert.Equal(t, int32(100000), req.Timeout)
 	})
 
+	t.Run("tracing", func(t *testing.T) {
+		trace.RegisterGenerator("mock_propagator", &mockPropagator{})
+		invoker := NewMosnInvoker()
+		conf := rpc.RpcConfig{
+			Config: []byte(`{"channel": [{"protocol":"fake", "size": 2, "listener": "mosn"}]}`),
+		}
+		assert.Nil(t, invoker.Init(conf))
+
+		parent := &trace.Span{}
+		parent.SetTraceId("trace")
+		parent.SetSpanId("0")
+		parent.SetTag(trace.LAYOTTO_GENERATOR_TYPE, "mock_propagator")
+		ctx := mosnctx.WithValue(context.Background(), types.ContextKeyActiveSpan, parent)
+		req := &rpc.RPCRequest{
+			Id:      "1",
+			Timeout: 100,
+			Method:  "Hello",
+			Data:    []byte("hello"),
+		}
+		rsp, err := invoker.Invoke(ctx, req)
+		assert.Nil(t, err)
+		assert.Equal(t, "trace", rsp.Header.Get("trace_id"))
+		assert.Equal(t, "0.1", rsp.Header.Get("span_id"))
+	})
+
 	t.Run("panic", func(t *testing.T) {
 		invoker := NewMosnInvoker()
 
@@ -130,6 +159,34 @@ func Test_mosnInvoker_Invoke(t *testing.T) {
 	})
 }
 
+type mockPropagator struct {
+}
+
+func (m *mockPropagator) GetTraceId(ctx context.Context) string {
+	return ""
+}
+
+func (m *mockPropagator) GetSpanId(ctx context.Context) string {
+	return ""
+}
+
+func (m *mockPropagator) GenerateNewContext(ctx context.Context, span api.Span) context.Context {
+	return ctx
+}
+
+func (m *mockPropagator) GetParentSpanId(ctx context.Context) string {
+	return ""
+}
+
+func (m *mockPropagator) Inject(span api.Span, set func(key string, value string)) {
+	set("trace_id", span.TraceId())
+	set("span_id", span.SpanId())
+}
+
+func (m *mockPropagator) Extract(ctx context.Context, get func(key string) string) context.Context {
+	return ctx
+}
+
 type fakeChannel struct {
 }
 
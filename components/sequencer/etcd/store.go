@@ -110,6 +110,12 @@ func (e *EtcdSequencer) GetSegment(req *sequencer.GetSegmentRequest) (support bo
 	return false, nil, nil
 }
 
+// Ping checks the connection to etcd
+func (e *EtcdSequencer) Ping(ctx context.Context) error {
+	_, err := e.client.Get(ctx, "ping")
+	return err
+}
+
 func (e *EtcdSequencer) Close() error {
 	e.cancel()
 
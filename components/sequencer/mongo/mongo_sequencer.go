@@ -109,6 +109,11 @@ func (e *MongoSequencer) Init(config sequencer.Configuration) error {
 	return err
 }
 
+// Ping checks the connection to mongo
+func (e *MongoSequencer) Ping(ctx context.Context) error {
+	return e.client.Ping(ctx, nil)
+}
+
 func (e *MongoSequencer) GetNextId(req *sequencer.GetNextIdRequest) (*sequencer.GetNextIdResponse, error) {
 	var err error
 	var document SequencerDocument
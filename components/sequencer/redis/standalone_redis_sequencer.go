@@ -118,6 +118,12 @@ func (s *StandaloneRedisSequencer) GetSegment(req *sequencer.GetSegmentRequest)
 		To:   by.Val(),
 	}, nil
 }
+
+// Ping checks the connection to redis
+func (s *StandaloneRedisSequencer) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
 func (s *StandaloneRedisSequencer) Close() error {
 	s.cancel()
 	return s.client.Close()
@@ -21,6 +21,12 @@ type Exporter interface {
 	ExportSpan(s *Span)
 }
 
+// ConfigurableExporter is implemented by the exporters which read the tracer config, e.g. the address of the collector.
+type ConfigurableExporter interface {
+	Exporter
+	Configure(config map[string]interface{}) error
+}
+
 var activeExporters []string
 
 var (
@@ -31,6 +37,17 @@ func SetActiveExporters(exporter []string) {
 	activeExporters = exporter
 }
 
+// ExportSpan exports the span through the active exporters.
+func ExportSpan(span *Span) {
+	for _, name := range activeExporters {
+		exporter := GetExporter(name)
+		if exporter == nil {
+			return
+		}
+		exporter.ExportSpan(span)
+	}
+}
+
 func GetExporter(name string) Exporter {
 	if v, ok := exporters.Load(name); ok {
 		return v.(Exporter)
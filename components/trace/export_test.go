@@ -14,6 +14,7 @@ package trace
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -39,3 +40,30 @@ func TestExport(t *testing.T) {
 	span.FinishSpan()
 	assert.Equal(t, m.runtime, 1)
 }
+
+type mockFinisher struct {
+	spans []*Span
+}
+
+func (m *mockFinisher) Finish(span *Span) {
+	m.spans = append(m.spans, span)
+}
+
+func TestFinisher(t *testing.T) {
+	m := &MockExporter{}
+	SetActiveExporters([]string{"mock"})
+	RegisterExporter("mock", m)
+	defer UnregisterExporter("mock")
+
+	f := &mockFinisher{}
+	span := &Span{}
+	span.SetFinisher(f)
+	child := span.SpawnChild("child", time.Now())
+	child.FinishSpan()
+	span.FinishSpan()
+	assert.Equal(t, 0, m.runtime)
+	assert.Equal(t, []*Span{child.(*Span), span}, f.spans)
+
+	ExportSpan(span)
+	assert.Equal(t, 1, m.runtime)
+}
@@ -35,6 +35,21 @@ type Generator interface {
 	GetParentSpanId(ctx context.Context) string
 }
 
+// ChildGenerator is implemented by the generators which generate the ids of the child spans in their own format
+type ChildGenerator interface {
+	// GetChildSpanId returns the id of the seq-th child of the parent span, seq starts from 1
+	GetChildSpanId(parent api.Span, seq uint32) string
+}
+
+// Propagator is implemented by the generators which propagate the span context across processes,
+// e.g. through the headers of the outbound rpc or the metadata of the pubsub messages
+type Propagator interface {
+	// Inject writes the context of the span through set
+	Inject(span api.Span, set func(key string, value string))
+	// Extract reads the context through get, and returns a new context from which the generator reads the ids of the new span
+	Extract(ctx context.Context, get func(key string) string) context.Context
+}
+
 func RegisterGenerator(name string, ge Generator) {
 	generators.Store(name, ge)
 }
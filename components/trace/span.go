@@ -13,6 +13,8 @@
 package trace
 
 import (
+	"fmt"
+	"sync/atomic"
 	"time"
 
 	"mosn.io/api"
@@ -29,6 +31,17 @@ type Span struct {
 	parentSpanId  string
 	tags          [sofa.TRACE_END]string
 	operationName string
+	kind          SpanKind
+	// the number of the spawned children, which is used to generate their ids
+	children uint32
+	// finisher takes over the span when it finishes, it's inherited by the children
+	finisher Finisher
+}
+
+// Finisher handles the finished spans instead of exporting them directly, e.g. to buffer the spans of a trace
+// until the sampling decision is made.
+type Finisher interface {
+	Finish(span *Span)
 }
 
 func (span *Span) SetTraceId(id string) {
@@ -59,6 +72,14 @@ func (span *Span) SetOperation(operation string) {
 	span.operationName = operation
 }
 
+func (span *Span) SetKind(kind SpanKind) {
+	span.kind = kind
+}
+
+func (span *Span) Kind() SpanKind {
+	return span.kind
+}
+
 func (span *Span) SetTag(key uint64, value string) {
 	span.tags[key] = value
 }
@@ -71,20 +92,42 @@ func (span *Span) Tag(key uint64) string {
 	return span.tags[key]
 }
 
+// SetFinisher sets the Finisher of the span and its children spawned later.
+func (span *Span) SetFinisher(f Finisher) {
+	span.finisher = f
+}
+
 func (span *Span) FinishSpan() {
 	span.EndTime = time.Now()
-	for _, name := range activeExporters {
-		exporter := GetExporter(name)
-		if exporter == nil {
-			return
-		}
-		exporter.ExportSpan(span)
+	if span.finisher != nil {
+		span.finisher.Finish(span)
+		return
 	}
+	ExportSpan(span)
 }
 func (span *Span) InjectContext(requestHeaders types.HeaderMap, requestInfo types.RequestInfo) {
 
 }
 
+// SpawnChild creates a child span in the same trace, e.g. for a component call.
+// The id of the child is generated by the generator of this span if it implements ChildGenerator,
+// otherwise it's the id of this span with a sequence suffix like the rpc id of SOFATracer, e.g. 0.1.2
 func (span *Span) SpawnChild(operationName string, startTime time.Time) api.Span {
-	return nil
+	child := &Span{StartTime: startTime, kind: SpanKindInternal, finisher: span.finisher}
+	child.SetTraceId(span.traceId)
+	child.SetParentSpanId(span.spanId)
+	child.SetOperation(operationName)
+	child.SetTag(LAYOTTO_METHOD_NAME, operationName)
+	child.SetTag(LAYOTTO_GENERATOR_TYPE, span.Tag(LAYOTTO_GENERATOR_TYPE))
+	child.SetTag(LAYOTTO_APP_NAME, span.Tag(LAYOTTO_APP_NAME))
+	child.SetTag(LAYOTTO_TRACE_FLAGS, span.Tag(LAYOTTO_TRACE_FLAGS))
+	child.SetTag(LAYOTTO_TRACE_STATE, span.Tag(LAYOTTO_TRACE_STATE))
+
+	seq := atomic.AddUint32(&span.children, 1)
+	if ge, ok := GetGenerator(span.Tag(LAYOTTO_GENERATOR_TYPE)).(ChildGenerator); ok {
+		child.SetSpanId(ge.GetChildSpanId(span, seq))
+	} else {
+		child.SetSpanId(fmt.Sprintf("%s.%d", span.spanId, seq))
+	}
+	return child
 }
@@ -19,4 +19,18 @@ const (
 	LAYOTTO_GENERATOR_TYPE
 	LAYOTTO_ATTRS_CONTENT
 	LAYOTTO_COMPONENT_DETAIL
+	LAYOTTO_TRACE_FLAGS
+	LAYOTTO_TRACE_STATE
+)
+
+// SpanKind has the same values as the SpanKind of OpenTelemetry
+type SpanKind int
+
+const (
+	SpanKindUnspecified SpanKind = iota
+	SpanKindInternal
+	SpanKindServer
+	SpanKindClient
+	SpanKindProducer
+	SpanKindConsumer
 )
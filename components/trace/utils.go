@@ -14,7 +14,9 @@ package trace
 
 import (
 	"context"
+	"time"
 
+	"mosn.io/api"
 	"mosn.io/mosn/pkg/types"
 
 	mosnctx "mosn.io/mosn/pkg/context"
@@ -27,3 +29,43 @@ func SetExtraComponentInfo(ctx context.Context, info string) {
 	}
 	span.(*Span).SetTag(LAYOTTO_COMPONENT_DETAIL, info)
 }
+
+// StartSpan starts a child span of the active span in ctx, and returns a new context in which the child is active.
+// component is the name of the component being called, e.g. redis.
+// The span is nil if there is no active span, e.g. when tracing is disabled.
+func StartSpan(ctx context.Context, kind SpanKind, operation string, component string) (context.Context, api.Span) {
+	parent, ok := mosnctx.Get(ctx, types.ContextKeyActiveSpan).(api.Span)
+	if !ok || parent == nil {
+		return ctx, nil
+	}
+	child, ok := parent.SpawnChild(operation, time.Now()).(*Span)
+	if !ok || child == nil {
+		return ctx, nil
+	}
+	child.SetKind(kind)
+	child.SetTag(LAYOTTO_COMPONENT_DETAIL, component)
+	return mosnctx.WithValue(ctx, types.ContextKeyActiveSpan, child), child
+}
+
+// EndSpan finishes the span, and marks it failed if err is not nil. It does nothing if span is nil.
+func EndSpan(span api.Span, err error) {
+	if span == nil {
+		return
+	}
+	if err != nil {
+		span.SetTag(LAYOTTO_REQUEST_RESULT, "1")
+	} else {
+		span.SetTag(LAYOTTO_REQUEST_RESULT, "0")
+	}
+	span.FinishSpan()
+}
+
+// Inject writes the context of the span through set if its generator is a Propagator. It does nothing if span is nil.
+func Inject(span api.Span, set func(key string, value string)) {
+	if span == nil {
+		return
+	}
+	if p, ok := GetGenerator(span.Tag(LAYOTTO_GENERATOR_TYPE)).(Propagator); ok {
+		p.Inject(span, set)
+	}
+}
@@ -14,6 +14,7 @@ package trace
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -45,3 +46,36 @@ func TestSetterAndGetter(t *testing.T) {
 	v = span.SpanId()
 	assert.Equal(t, v, "spanId")
 }
+
+func TestStartSpan(t *testing.T) {
+	// no active span
+	_, span := StartSpan(context.TODO(), SpanKindClient, "lock/TryLock", "redis")
+	assert.Nil(t, span)
+	EndSpan(span, nil)
+
+	parent := &Span{}
+	parent.SetTraceId("trace")
+	parent.SetSpanId("0.1")
+	parent.SetTag(LAYOTTO_APP_NAME, "app")
+	parent.SetTag(LAYOTTO_TRACE_FLAGS, "01")
+	parent.SetTag(LAYOTTO_TRACE_STATE, "vendor=value")
+	ctx := mosnctx.WithValue(context.TODO(), types.ContextKeyActiveSpan, parent)
+	childCtx, span := StartSpan(ctx, SpanKindClient, "lock/TryLock", "redis")
+	child := span.(*Span)
+	assert.Equal(t, "trace", child.TraceId())
+	assert.Equal(t, "0.1.1", child.SpanId())
+	assert.Equal(t, "0.1", child.ParentSpanId())
+	assert.Equal(t, SpanKindClient, child.Kind())
+	assert.Equal(t, "lock/TryLock", child.Tag(LAYOTTO_METHOD_NAME))
+	assert.Equal(t, "redis", child.Tag(LAYOTTO_COMPONENT_DETAIL))
+	assert.Equal(t, "app", child.Tag(LAYOTTO_APP_NAME))
+	assert.Equal(t, "01", child.Tag(LAYOTTO_TRACE_FLAGS))
+	assert.Equal(t, "vendor=value", child.Tag(LAYOTTO_TRACE_STATE))
+	assert.Equal(t, child, mosnctx.Get(childCtx, types.ContextKeyActiveSpan))
+
+	_, span = StartSpan(ctx, SpanKindClient, "lock/Unlock", "redis")
+	assert.Equal(t, "0.1.2", span.SpanId())
+	EndSpan(span, errors.New("fail"))
+	assert.Equal(t, "1", span.Tag(LAYOTTO_REQUEST_RESULT))
+	assert.False(t, span.(*Span).EndTime.IsZero())
+}
@@ -0,0 +1,314 @@
+package exporter_iml
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"mosn.io/pkg/log"
+
+	"mosn.io/layotto/components/trace"
+)
+
+const (
+	OtlpGrpcExporterName = "otlp_grpc"
+	OtlpHttpExporterName = "otlp_http"
+
+	otlpConfigKey = "otlp"
+
+	otlpGrpcMethod          = "/opentelemetry.proto.collector.trace.v1.TraceService/Export"
+	defaultOtlpGrpcEndpoint = "localhost:4317"
+	defaultOtlpHttpEndpoint = "http://localhost:4318/v1/traces"
+	defaultServiceName      = "layotto"
+	defaultOtlpTimeout      = 10 * time.Second
+	defaultFlushInterval    = time.Second
+	defaultBatchSize        = 512
+	defaultQueueSize        = 2048
+)
+
+func init() {
+	trace.RegisterExporter(OtlpGrpcExporterName, NewOtlpExporter(OtlpGrpcExporterName))
+	trace.RegisterExporter(OtlpHttpExporterName, NewOtlpExporter(OtlpHttpExporterName))
+}
+
+// OtlpConfig is the `otlp` field of the tracer config.
+// The endpoint and the service name default to the standard OTEL_* environment variables.
+type OtlpConfig struct {
+	// Endpoint is host:port for grpc, and the full url for http, e.g. http://localhost:4318/v1/traces
+	Endpoint string `json:"endpoint"`
+	// Insecure disables TLS for grpc
+	Insecure bool `json:"insecure"`
+	// Headers are sent with every export request, e.g. the authentication of the collector
+	Headers         map[string]string `json:"headers"`
+	ServiceName     string            `json:"service_name"`
+	TimeoutMs       int               `json:"timeout_ms"`
+	FlushIntervalMs int               `json:"flush_interval_ms"`
+	BatchSize       int               `json:"batch_size"`
+}
+
+// OtlpExporter exports the spans to an OpenTelemetry collector through OTLP/gRPC or OTLP/HTTP with protobuf encoding.
+// The spans are queued and exported in batches, and they are dropped if the queue is full.
+type OtlpExporter struct {
+	name  string
+	spans chan *trace.Span
+	once  sync.Once
+	// reconfigured notifies the exporting goroutine to apply the new flush interval
+	reconfigured chan struct{}
+
+	mu     sync.RWMutex
+	config *OtlpConfig
+	conn   *grpc.ClientConn
+	client *http.Client
+}
+
+var _ trace.ConfigurableExporter = &OtlpExporter{}
+
+func NewOtlpExporter(name string) *OtlpExporter {
+	e := &OtlpExporter{
+		name:         name,
+		spans:        make(chan *trace.Span, defaultQueueSize),
+		reconfigured: make(chan struct{}, 1),
+		client:       &http.Client{},
+	}
+	e.config = e.withDefaults(&OtlpConfig{})
+	return e
+}
+
+func (e *OtlpExporter) withDefaults(c *OtlpConfig) *OtlpConfig {
+	if c.Endpoint == "" {
+		c.Endpoint = os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	}
+	if c.Endpoint == "" {
+		if v := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); v != "" {
+			c.Endpoint = v
+			if e.name == OtlpHttpExporterName {
+				c.Endpoint = strings.TrimSuffix(v, "/") + "/v1/traces"
+			}
+		}
+	}
+	if c.Endpoint == "" {
+		c.Endpoint = defaultOtlpGrpcEndpoint
+		if e.name == OtlpHttpExporterName {
+			c.Endpoint = defaultOtlpHttpEndpoint
+		}
+	}
+	if e.name == OtlpGrpcExporterName {
+		// grpc dials host:port
+		c.Endpoint = strings.TrimPrefix(strings.TrimPrefix(c.Endpoint, "http://"), "https://")
+	}
+	if c.ServiceName == "" {
+		c.ServiceName = os.Getenv("OTEL_SERVICE_NAME")
+	}
+	if c.ServiceName == "" {
+		c.ServiceName = defaultServiceName
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = defaultBatchSize
+	}
+	return c
+}
+
+func (c *OtlpConfig) timeout() time.Duration {
+	if c.TimeoutMs > 0 {
+		return time.Duration(c.TimeoutMs) * time.Millisecond
+	}
+	return defaultOtlpTimeout
+}
+
+func (c *OtlpConfig) flushInterval() time.Duration {
+	if c.FlushIntervalMs > 0 {
+		return time.Duration(c.FlushIntervalMs) * time.Millisecond
+	}
+	return defaultFlushInterval
+}
+
+// Configure reads the `otlp` field of the tracer config.
+func (e *OtlpExporter) Configure(config map[string]interface{}) error {
+	c := &OtlpConfig{}
+	if v, ok := config[otlpConfigKey]; ok {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if err = json.Unmarshal(data, c); err != nil {
+			return fmt.Errorf("invalid otlp config: %v", err)
+		}
+	}
+	c = e.withDefaults(c)
+
+	e.mu.Lock()
+	old := e.conn
+	e.conn = nil
+	e.config = c
+	e.mu.Unlock()
+	// the connection is closed after it's swapped, so the next export dials the new endpoint
+	if old != nil {
+		old.Close()
+	}
+	select {
+	case e.reconfigured <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// ExportSpan queues the span without blocking.
+func (e *OtlpExporter) ExportSpan(s *trace.Span) {
+	e.once.Do(func() {
+		go e.run()
+	})
+	select {
+	case e.spans <- s:
+	default:
+		log.DefaultLogger.Warnf("[layotto][otlp] queue of %s is full, span %s dropped", e.name, s.SpanId())
+	}
+}
+
+func (e *OtlpExporter) run() {
+	newTicker := func() *time.Ticker {
+		e.mu.RLock()
+		defer e.mu.RUnlock()
+		return time.NewTicker(e.config.flushInterval())
+	}
+	ticker := newTicker()
+	defer func() {
+		ticker.Stop()
+	}()
+	var batch []*trace.Span
+	for {
+		select {
+		case <-e.reconfigured:
+			ticker.Stop()
+			ticker = newTicker()
+			continue
+		case s := <-e.spans:
+			batch = append(batch, s)
+			e.mu.RLock()
+			full := len(batch) >= e.config.BatchSize
+			e.mu.RUnlock()
+			if !full {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		if err := e.export(batch); err != nil {
+			log.DefaultLogger.Errorf("[layotto][otlp] %s failed to export %d spans: %v", e.name, len(batch), err)
+		}
+		batch = nil
+	}
+}
+
+func (e *OtlpExporter) export(spans []*trace.Span) error {
+	e.mu.RLock()
+	config := e.config
+	e.mu.RUnlock()
+	ctx, cancel := context.WithTimeout(context.Background(), config.timeout())
+	defer cancel()
+	body := encodeSpans(config.ServiceName, spans)
+	if e.name == OtlpGrpcExporterName {
+		return e.exportGrpc(ctx, config, body)
+	}
+	return e.exportHttp(ctx, config, body)
+}
+
+func (e *OtlpExporter) exportGrpc(ctx context.Context, config *OtlpConfig, body []byte) error {
+	conn, err := e.getConn()
+	if err != nil {
+		return err
+	}
+	if len(config.Headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(config.Headers))
+	}
+	var resp []byte
+	return conn.Invoke(ctx, otlpGrpcMethod, body, &resp, grpc.ForceCodec(rawCodec{}))
+}
+
+// getConn returns the connection to the endpoint of the current config, which is dialed if it's absent
+func (e *OtlpExporter) getConn() (*grpc.ClientConn, error) {
+	e.mu.RLock()
+	conn := e.conn
+	e.mu.RUnlock()
+	if conn != nil {
+		return conn, nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.conn != nil {
+		return e.conn, nil
+	}
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{}))}
+	if e.config.Insecure {
+		opts = []grpc.DialOption{grpc.WithInsecure()}
+	}
+	conn, err := grpc.Dial(e.config.Endpoint, opts...)
+	if err != nil {
+		return nil, err
+	}
+	e.conn = conn
+	return conn, nil
+}
+
+func (e *OtlpExporter) exportHttp(ctx context.Context, config *OtlpConfig, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	for k, v := range config.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// rawCodec sends the encoded protobuf bytes as they are.
+// It's named "proto" so that the content type is application/grpc+proto which the collectors accept.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	switch b := v.(type) {
+	case []byte:
+		return b, nil
+	case *[]byte:
+		return *b, nil
+	}
+	return nil, errors.New("rawCodec: unsupported type")
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return errors.New("rawCodec: unsupported type")
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
@@ -0,0 +1,159 @@
+package exporter_iml
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"mosn.io/layotto/components/trace"
+)
+
+// The field numbers of the OTLP trace protos, see
+// https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/trace/v1/trace.proto
+const (
+	// ExportTraceServiceRequest
+	fieldResourceSpans protowire.Number = 1
+	// ResourceSpans
+	fieldResource   protowire.Number = 1
+	fieldScopeSpans protowire.Number = 2
+	// Resource
+	fieldResourceAttributes protowire.Number = 1
+	// ScopeSpans
+	fieldScope protowire.Number = 1
+	fieldSpans protowire.Number = 2
+	// InstrumentationScope
+	fieldScopeName protowire.Number = 1
+	// Span
+	fieldTraceId      protowire.Number = 1
+	fieldSpanId       protowire.Number = 2
+	fieldTraceState   protowire.Number = 3
+	fieldParentSpanId protowire.Number = 4
+	fieldName         protowire.Number = 5
+	fieldKind         protowire.Number = 6
+	fieldStartTime    protowire.Number = 7
+	fieldEndTime      protowire.Number = 8
+	fieldAttributes   protowire.Number = 9
+	fieldStatus       protowire.Number = 15
+	// Status
+	fieldStatusCode protowire.Number = 3
+	// KeyValue
+	fieldKey   protowire.Number = 1
+	fieldValue protowire.Number = 2
+	// AnyValue
+	fieldStringValue protowire.Number = 1
+
+	statusCodeOk    = 1
+	statusCodeError = 2
+
+	scopeName = "mosn.io/layotto"
+)
+
+// encodeSpans encodes the spans as an ExportTraceServiceRequest in the protobuf wire format.
+func encodeSpans(serviceName string, spans []*trace.Span) []byte {
+	var resource []byte
+	resource = appendMessage(resource, fieldResourceAttributes, encodeKeyValue("service.name", serviceName))
+
+	var scopeSpans []byte
+	scopeSpans = appendMessage(scopeSpans, fieldScope, appendString(nil, fieldScopeName, scopeName))
+	for _, s := range spans {
+		scopeSpans = appendMessage(scopeSpans, fieldSpans, encodeSpan(s))
+	}
+
+	var resourceSpans []byte
+	resourceSpans = appendMessage(resourceSpans, fieldResource, resource)
+	resourceSpans = appendMessage(resourceSpans, fieldScopeSpans, scopeSpans)
+	return appendMessage(nil, fieldResourceSpans, resourceSpans)
+}
+
+func encodeSpan(s *trace.Span) []byte {
+	var b []byte
+	b = appendBytes(b, fieldTraceId, toOtlpId(s.TraceId(), 16))
+	b = appendBytes(b, fieldSpanId, toOtlpId(s.SpanId(), 8))
+	b = appendString(b, fieldTraceState, s.Tag(trace.LAYOTTO_TRACE_STATE))
+	b = appendBytes(b, fieldParentSpanId, toOtlpId(s.ParentSpanId(), 8))
+	name := s.Tag(trace.LAYOTTO_METHOD_NAME)
+	if name == "" {
+		name = "layotto"
+	}
+	b = appendString(b, fieldName, name)
+	kind := s.Kind()
+	if kind == trace.SpanKindUnspecified {
+		kind = trace.SpanKindServer
+	}
+	b = protowire.AppendTag(b, fieldKind, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(kind))
+	b = appendTime(b, fieldStartTime, s.StartTime.UnixNano())
+	b = appendTime(b, fieldEndTime, s.EndTime.UnixNano())
+	attributes := []struct {
+		key   string
+		value string
+	}{
+		{"layotto.app_name", s.Tag(trace.LAYOTTO_APP_NAME)},
+		{"layotto.component", s.Tag(trace.LAYOTTO_COMPONENT_DETAIL)},
+		{"layotto.attrs", s.Tag(trace.LAYOTTO_ATTRS_CONTENT)},
+		{"layotto.result", s.Tag(trace.LAYOTTO_REQUEST_RESULT)},
+	}
+	for _, attr := range attributes {
+		if attr.value != "" {
+			b = appendMessage(b, fieldAttributes, encodeKeyValue(attr.key, attr.value))
+		}
+	}
+	code := uint64(statusCodeOk)
+	if s.Tag(trace.LAYOTTO_REQUEST_RESULT) == "1" {
+		code = statusCodeError
+	}
+	var status []byte
+	status = protowire.AppendTag(status, fieldStatusCode, protowire.VarintType)
+	status = protowire.AppendVarint(status, code)
+	return appendMessage(b, fieldStatus, status)
+}
+
+func encodeKeyValue(key string, value string) []byte {
+	b := appendString(nil, fieldKey, key)
+	return appendMessage(b, fieldValue, appendString(nil, fieldStringValue, value))
+}
+
+// toOtlpId converts the id into the fixed size bytes required by OTLP.
+// The ids which are not hex strings of that size, e.g. the sofa rpc ids, are hashed.
+func toOtlpId(id string, size int) []byte {
+	if id == "" {
+		return nil
+	}
+	if len(id) == size*2 {
+		if b, err := hex.DecodeString(id); err == nil {
+			return b
+		}
+	}
+	sum := sha256.Sum256([]byte(id))
+	return sum[:size]
+}
+
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+func appendBytes(b []byte, num protowire.Number, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+func appendTime(b []byte, num protowire.Number, unixNano int64) []byte {
+	if unixNano <= 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, uint64(unixNano))
+}
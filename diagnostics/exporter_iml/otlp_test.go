@@ -0,0 +1,235 @@
+package exporter_iml
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"mosn.io/layotto/components/trace"
+)
+
+const (
+	testTraceId = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testSpanId  = "00f067aa0ba902b7"
+)
+
+func newTestSpan() *trace.Span {
+	span := &trace.Span{StartTime: time.Now(), EndTime: time.Now().Add(time.Millisecond)}
+	span.SetTraceId(testTraceId)
+	span.SetSpanId(testSpanId)
+	span.SetParentSpanId("0.1")
+	span.SetTag(trace.LAYOTTO_METHOD_NAME, "/spec.proto.runtime.v1.Runtime/GetState")
+	span.SetTag(trace.LAYOTTO_REQUEST_RESULT, "1")
+	return span
+}
+
+// checkPayload checks the ExportTraceServiceRequest received by the collector
+func checkPayload(t *testing.T, payload []byte) {
+	traceId, _ := hex.DecodeString(testTraceId)
+	spanId, _ := hex.DecodeString(testSpanId)
+	assert.Contains(t, string(payload), string(traceId))
+	assert.Contains(t, string(payload), string(spanId))
+	assert.Contains(t, string(payload), "/spec.proto.runtime.v1.Runtime/GetState")
+	assert.Contains(t, string(payload), "test-service")
+
+	// the payload is a valid protobuf message with one resource_spans
+	num, typ, n := protowire.ConsumeTag(payload)
+	assert.True(t, n > 0)
+	assert.Equal(t, fieldResourceSpans, num)
+	assert.Equal(t, protowire.BytesType, typ)
+	_, m := protowire.ConsumeBytes(payload[n:])
+	assert.Equal(t, len(payload), n+m)
+}
+
+// collectorCodec is used by the collector stub, which implements both grpc.Codec and encoding.Codec.
+type collectorCodec struct {
+	rawCodec
+}
+
+func (collectorCodec) String() string {
+	return "proto"
+}
+
+func TestOtlpGrpcExporter(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	received := make(chan []byte, 1)
+	methods := make(chan string, 1)
+	server := grpc.NewServer(
+		grpc.CustomCodec(collectorCodec{}),
+		grpc.UnknownServiceHandler(func(srv interface{}, stream grpc.ServerStream) error {
+			method, _ := grpc.MethodFromServerStream(stream)
+			var req []byte
+			if err := stream.RecvMsg(&req); err != nil {
+				return err
+			}
+			methods <- method
+			received <- req
+			return stream.SendMsg(&[]byte{})
+		}),
+	)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	e := NewOtlpExporter(OtlpGrpcExporterName)
+	err = e.Configure(map[string]interface{}{
+		"otlp": map[string]interface{}{
+			"endpoint":          lis.Addr().String(),
+			"insecure":          true,
+			"service_name":      "test-service",
+			"flush_interval_ms": 10,
+		},
+	})
+	assert.Nil(t, err)
+	e.ExportSpan(newTestSpan())
+
+	select {
+	case payload := <-received:
+		assert.Equal(t, otlpGrpcMethod, <-methods)
+		checkPayload(t, payload)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no span received by the collector")
+	}
+}
+
+func TestOtlpHttpExporter(t *testing.T) {
+	received := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("X-Token"))
+		body, _ := ioutil.ReadAll(r.Body)
+		received <- body
+	}))
+	defer server.Close()
+
+	e := NewOtlpExporter(OtlpHttpExporterName)
+	err := e.Configure(map[string]interface{}{
+		"otlp": map[string]interface{}{
+			"endpoint":          server.URL + "/v1/traces",
+			"headers":           map[string]string{"X-Token": "secret"},
+			"service_name":      "test-service",
+			"flush_interval_ms": 10,
+		},
+	})
+	assert.Nil(t, err)
+	e.ExportSpan(newTestSpan())
+
+	select {
+	case payload := <-received:
+		checkPayload(t, payload)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no span received by the collector")
+	}
+}
+
+func TestOtlpExporterReconfigure(t *testing.T) {
+	received := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received <- body
+	}))
+	defer server.Close()
+
+	e := NewOtlpExporter(OtlpHttpExporterName)
+	config := map[string]interface{}{
+		"otlp": map[string]interface{}{
+			"endpoint":          server.URL + "/v1/traces",
+			"service_name":      "test-service",
+			"flush_interval_ms": time.Hour.Milliseconds(),
+		},
+	}
+	assert.Nil(t, e.Configure(config))
+	e.ExportSpan(newTestSpan())
+
+	// the new flush interval takes effect without waiting for the old one
+	config["otlp"].(map[string]interface{})["flush_interval_ms"] = 10
+	assert.Nil(t, e.Configure(config))
+	select {
+	case payload := <-received:
+		checkPayload(t, payload)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no span received by the collector")
+	}
+}
+
+func TestOtlpExporterSwapConn(t *testing.T) {
+	e := NewOtlpExporter(OtlpGrpcExporterName)
+	assert.Nil(t, e.Configure(map[string]interface{}{
+		"otlp": map[string]interface{}{"endpoint": "127.0.0.1:1", "insecure": true},
+	}))
+	conn, err := e.getConn()
+	assert.Nil(t, err)
+	assert.Equal(t, "127.0.0.1:1", conn.Target())
+
+	assert.Nil(t, e.Configure(map[string]interface{}{
+		"otlp": map[string]interface{}{"endpoint": "127.0.0.1:2", "insecure": true},
+	}))
+	// the old connection is closed, and the new one dials the new endpoint
+	assert.Equal(t, connectivity.Shutdown, conn.GetState())
+	conn, err = e.getConn()
+	assert.Nil(t, err)
+	assert.Equal(t, "127.0.0.1:2", conn.Target())
+	conn.Close()
+}
+
+func TestOtlpConfig(t *testing.T) {
+	e := NewOtlpExporter(OtlpGrpcExporterName)
+	assert.Equal(t, defaultOtlpGrpcEndpoint, e.config.Endpoint)
+	assert.Equal(t, defaultServiceName, e.config.ServiceName)
+
+	assert.Nil(t, e.Configure(map[string]interface{}{
+		"otlp": map[string]interface{}{"endpoint": "http://collector:4317"},
+	}))
+	assert.Equal(t, "collector:4317", e.config.Endpoint)
+	assert.NotNil(t, e.Configure(map[string]interface{}{"otlp": "invalid"}))
+
+	e = NewOtlpExporter(OtlpHttpExporterName)
+	assert.Equal(t, defaultOtlpHttpEndpoint, e.config.Endpoint)
+}
+
+func TestEncodeSpanTraceState(t *testing.T) {
+	// fields returns the string or bytes fields of the span
+	fields := func(b []byte) map[protowire.Number]string {
+		m := make(map[protowire.Number]string)
+		for len(b) > 0 {
+			num, typ, n := protowire.ConsumeTag(b)
+			assert.True(t, n > 0)
+			b = b[n:]
+			if typ == protowire.BytesType {
+				v, _ := protowire.ConsumeBytes(b)
+				m[num] = string(v)
+			}
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			assert.True(t, n > 0)
+			b = b[n:]
+		}
+		return m
+	}
+
+	span := newTestSpan()
+	_, ok := fields(encodeSpan(span))[fieldTraceState]
+	assert.False(t, ok)
+
+	span.SetTag(trace.LAYOTTO_TRACE_STATE, "congo=t61rcWkgMzE,rojo=00f067aa0ba902b7")
+	assert.Equal(t, "congo=t61rcWkgMzE,rojo=00f067aa0ba902b7", fields(encodeSpan(span))[fieldTraceState])
+}
+
+func TestToOtlpId(t *testing.T) {
+	assert.Nil(t, toOtlpId("", 8))
+	id, _ := hex.DecodeString(testSpanId)
+	assert.Equal(t, id, toOtlpId(testSpanId, 8))
+	// the same rpc id is always hashed into the same span id
+	assert.Equal(t, 8, len(toOtlpId("0.1", 8)))
+	assert.Equal(t, toOtlpId("0.1", 8), toOtlpId("0.1", 8))
+	assert.NotEqual(t, toOtlpId("0.1", 8), toOtlpId("0.2", 8))
+}
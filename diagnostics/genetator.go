@@ -46,18 +46,19 @@ func (o *OpenGenerator) GetSpanId(ctx context.Context) string {
 	return spanId
 }
 
-// GetParentSpanId returns the same id as GetSpanId.
-// It's because currently Layotto don't know the parent id.
+// GetParentSpanId returns the rpc id of the caller, which is the rpc id without the last segment,
+// e.g. the parent of "0.1.2" is "0.1". It returns empty string if there is no parent.
 func (o *OpenGenerator) GetParentSpanId(ctx context.Context) string {
-	// TODO: need some design to get the parent id
-	var spanId string
 	md, _ := metadata.FromIncomingContext(ctx)
-	if v, ok := md[strings.ToLower(sofa.RPC_ID_KEY)]; ok {
-		spanId = v[0]
-	} else {
-		spanId = "0"
+	v, ok := md[strings.ToLower(sofa.RPC_ID_KEY)]
+	if !ok || len(v) == 0 {
+		return ""
 	}
-	return spanId
+	idx := strings.LastIndex(v[0], ".")
+	if idx < 0 {
+		return ""
+	}
+	return v[0][:idx]
 }
 
 func (o *OpenGenerator) GenerateNewContext(ctx context.Context, span api.Span) context.Context {
@@ -75,3 +76,20 @@ func (o *OpenGenerator) GenerateNewContext(ctx context.Context, span api.Span) c
 	ctx = mosnctx.WithValue(ctx, types.ContextKeyActiveSpan, span)
 	return ctx
 }
+
+// Inject writes the trace id and the rpc id of the span, which are the same headers as SOFARPC uses.
+func (o *OpenGenerator) Inject(span api.Span, set func(key string, value string)) {
+	set(sofa.TRACER_ID_KEY, span.TraceId())
+	set(sofa.RPC_ID_KEY, span.SpanId())
+}
+
+func (o *OpenGenerator) Extract(ctx context.Context, get func(key string) string) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	md = md.Copy()
+	for _, key := range []string{sofa.TRACER_ID_KEY, sofa.RPC_ID_KEY} {
+		if v := get(key); v != "" {
+			md.Set(key, v)
+		}
+	}
+	return metadata.NewIncomingContext(ctx, md)
+}
@@ -0,0 +1,85 @@
+package diagnostics
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"mosn.io/layotto/diagnostics/metrics"
+)
+
+// storeNameGetter covers the requests of the runtime api which specify a component
+type storeNameGetter interface {
+	GetStoreName() string
+}
+
+type pubsubNameGetter interface {
+	GetPubsubName() string
+}
+
+type componentNameGetter interface {
+	GetComponentName() string
+}
+
+// unknownStore is the store label of the requests to the components not initialized,
+// so the names sent by the clients can't grow the metrics without bound
+const unknownStore = "unknown"
+
+// map[string]struct{}, the names of the initialized components
+var componentNames atomic.Value
+
+// SetComponents sets the initialized components by kind, whose names are used as the store label of the metrics
+func SetComponents(components map[string][]string) {
+	names := make(map[string]struct{})
+	for _, kind := range components {
+		for _, name := range kind {
+			names[name] = struct{}{}
+		}
+	}
+	componentNames.Store(names)
+}
+
+// storeName returns the name of the component the request is sent to, empty if the request doesn't specify one
+func storeName(req interface{}) string {
+	var name string
+	switch r := req.(type) {
+	case storeNameGetter:
+		name = r.GetStoreName()
+	case pubsubNameGetter:
+		name = r.GetPubsubName()
+	case componentNameGetter:
+		name = r.GetComponentName()
+	default:
+		return ""
+	}
+	names, _ := componentNames.Load().(map[string]struct{})
+	if _, ok := names[name]; !ok {
+		return unknownStore
+	}
+	return name
+}
+
+func observe(method string, store string, start time.Time, err error) {
+	metrics.GrpcRequests.Inc(method, store, status.Code(err).String())
+	metrics.GrpcLatency.Observe(time.Since(start).Seconds(), method, store)
+}
+
+// UnaryMetricsInterceptor is an implementation of grpc.UnaryServerInterceptor which collects the request metrics
+func UnaryMetricsInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	start := time.Now()
+	resp, err = handler(ctx, req)
+	observe(info.FullMethod, storeName(req), start, err)
+	return resp, err
+}
+
+// StreamMetricsInterceptor is an implementation of grpc.StreamServerInterceptor which collects the request metrics.
+// The component of a stream is unknown before the first message, so it's left empty.
+func StreamMetricsInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	observe(info.FullMethod, "", start, err)
+	return err
+}
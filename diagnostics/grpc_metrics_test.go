@@ -0,0 +1,25 @@
+package diagnostics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"mosn.io/layotto/spec/proto/extension/v1/cryption"
+	runtimev1pb "mosn.io/layotto/spec/proto/runtime/v1"
+)
+
+func TestStoreName(t *testing.T) {
+	defer componentNames.Store(map[string]struct{}{})
+	// the components are unknown before the runtime is initialized
+	assert.Equal(t, unknownStore, storeName(&runtimev1pb.TryLockRequest{StoreName: "redis"}))
+
+	SetComponents(map[string][]string{"lock": {"redis"}, "pubsub": {"kafka"}, "cryption": {"aliyun"}})
+	assert.Equal(t, "redis", storeName(&runtimev1pb.TryLockRequest{StoreName: "redis"}))
+	assert.Equal(t, "kafka", storeName(&runtimev1pb.PublishEventRequest{PubsubName: "kafka"}))
+	assert.Equal(t, "aliyun", storeName(&cryption.EncryptRequest{ComponentName: "aliyun"}))
+	// the names sent by the clients are bounded by the components
+	assert.Equal(t, unknownStore, storeName(&runtimev1pb.TryLockRequest{StoreName: "random-1"}))
+	assert.Equal(t, unknownStore, storeName(&runtimev1pb.TryLockRequest{}))
+	assert.Equal(t, "", storeName(&runtimev1pb.SayHelloRequest{}))
+}
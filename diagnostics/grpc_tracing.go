@@ -2,7 +2,6 @@ package diagnostics
 
 import (
 	"context"
-	"time"
 
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 
@@ -10,8 +9,6 @@ import (
 	"mosn.io/mosn/pkg/trace"
 
 	ltrace "mosn.io/layotto/components/trace"
-	lgrpc "mosn.io/layotto/diagnostics/grpc"
-	"mosn.io/layotto/diagnostics/protocol"
 )
 
 // UnaryInterceptorFilter is an implementation of grpc.UnaryServerInterceptor
@@ -20,12 +17,13 @@ func UnaryInterceptorFilter(ctx context.Context, req interface{}, info *grpc.Una
 		resp, err = handler(ctx, req)
 		return resp, err
 	}
-	// get tracer
-	tracer := trace.Tracer(protocol.Layotto)
 	// start a span
-	span := tracer.Start(ctx, &lgrpc.RequestInfo{
-		FullMethod: info.FullMethod,
-	}, time.Now())
+	span := startSpan(ctx, info.FullMethod)
+	if span == nil {
+		// not sampled
+		resp, err = handler(ctx, req)
+		return resp, err
+	}
 	defer span.FinishSpan()
 
 	span.SetTag(ltrace.LAYOTTO_METHOD_NAME, info.FullMethod)
@@ -47,13 +45,14 @@ func StreamInterceptorFilter(srv interface{}, ss grpc.ServerStream, info *grpc.S
 		err := handler(srv, ss)
 		return err
 	}
-	// get tracer
-	tracer := trace.Tracer(protocol.Layotto)
 	ctx := ss.Context()
 	// start a span
-	span := tracer.Start(ctx, &lgrpc.RequestInfo{
-		FullMethod: info.FullMethod,
-	}, time.Now())
+	span := startSpan(ctx, info.FullMethod)
+	if span == nil {
+		// not sampled
+		err := handler(srv, ss)
+		return err
+	}
 	defer span.FinishSpan()
 
 	span.SetTag(ltrace.LAYOTTO_METHOD_NAME, info.FullMethod)
@@ -0,0 +1,39 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+var (
+	// GrpcRequests counts the grpc requests by the method, the component and the status code
+	GrpcRequests = NewCounterVec("layotto_grpc_requests_total",
+		"Total number of the grpc requests.", "method", "store", "code")
+	// GrpcLatency is the latency of the grpc requests by the method and the component
+	GrpcLatency = NewHistogramVec("layotto_grpc_request_duration_seconds",
+		"Latency of the grpc requests in seconds.", DefaultBuckets, "method", "store")
+
+	// PubsubDeliveries counts the messages delivered to the app by the result, i.e. success, retry, drop or error
+	PubsubDeliveries = NewCounterVec("layotto_pubsub_deliveries_total",
+		"Total number of the pubsub messages delivered to the app.", "pubsub", "topic", "result")
+	// LockContentions counts the TryLock requests failed because the lock is held by others
+	LockContentions = NewCounterVec("layotto_lock_contentions_total",
+		"Total number of the TryLock requests which failed to get the lock.", "store")
+	// SequencerRefills counts the segments fetched by the sequencer cache
+	SequencerRefills = NewCounterVec("layotto_sequencer_cache_refills_total",
+		"Total number of the segments fetched by the sequencer cache.")
+	// FileBytes counts the bytes transferred by the file api, direction is read or write
+	FileBytes = NewCounterVec("layotto_file_bytes_total",
+		"Total bytes transferred by the file api.", "store", "direction")
+)
@@ -0,0 +1,266 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultBuckets are the upper bounds of the latency histograms in seconds
+var DefaultBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// collector is a metric family which writes itself in the prometheus text format
+type collector interface {
+	name() string
+	write(buf *bytes.Buffer)
+}
+
+// Registry holds the metric families, it's safe for concurrent use
+type Registry struct {
+	mu         sync.RWMutex
+	collectors map[string]collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+// DefaultRegistry is the registry exposed by the actuator
+var DefaultRegistry = NewRegistry()
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.collectors[c.name()]; ok {
+		panic("duplicate metric: " + c.name())
+	}
+	r.collectors[c.name()] = c
+}
+
+// WriteTo writes all the metrics in the prometheus text format, sorted by name
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	buf := &bytes.Buffer{}
+	for _, name := range names {
+		r.collectors[name].write(buf)
+	}
+	r.mu.RUnlock()
+	return buf.WriteTo(w)
+}
+
+// vec holds the series of a metric family by their label values
+type vec struct {
+	metricName string
+	help       string
+	labels     []string
+	series     sync.Map // map[string]interface{}, the key is the joined label values
+	newSeries  func() interface{}
+}
+
+func (v *vec) name() string {
+	return v.metricName
+}
+
+func (v *vec) get(values []string) interface{} {
+	if len(values) != len(v.labels) {
+		panic("inconsistent label cardinality of " + v.metricName)
+	}
+	key := strings.Join(values, "\xff")
+	if s, ok := v.series.Load(key); ok {
+		return s
+	}
+	s, _ := v.series.LoadOrStore(key, v.newSeries())
+	return s
+}
+
+// sortedKeys returns the keys of the series in order, so the output is stable
+func (v *vec) sortedKeys() []string {
+	keys := make([]string, 0)
+	v.series.Range(func(k, _ interface{}) bool {
+		keys = append(keys, k.(string))
+		return true
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+func (v *vec) writeHeader(buf *bytes.Buffer, typ string) {
+	buf.WriteString("# HELP " + v.metricName + " " + v.help + "\n")
+	buf.WriteString("# TYPE " + v.metricName + " " + typ + "\n")
+}
+
+// labelPairs formats the labels as `{a="1",b="2"}`, extra is appended as is
+func (v *vec) labelPairs(key string, extra string) string {
+	pairs := make([]string, 0, len(v.labels)+1)
+	if len(v.labels) > 0 {
+		for i, value := range strings.Split(key, "\xff") {
+			pairs = append(pairs, v.labels[i]+`="`+escape(value)+`"`)
+		}
+	}
+	if extra != "" {
+		pairs = append(pairs, extra)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escape(value string) string {
+	return escaper.Replace(value)
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// CounterVec is a counter partitioned by the label values
+type CounterVec struct {
+	vec
+}
+
+// NewCounterVec creates a counter and registers it into the DefaultRegistry
+func NewCounterVec(name string, help string, labels ...string) *CounterVec {
+	c := newCounterVec(name, help, labels...)
+	DefaultRegistry.register(c)
+	return c
+}
+
+func newCounterVec(name string, help string, labels ...string) *CounterVec {
+	return &CounterVec{vec{
+		metricName: name,
+		help:       help,
+		labels:     labels,
+		newSeries: func() interface{} {
+			return new(int64)
+		},
+	}}
+}
+
+// Add adds delta to the counter of the label values, delta must be non-negative
+func (c *CounterVec) Add(delta int64, values ...string) {
+	if delta < 0 {
+		return
+	}
+	atomic.AddInt64(c.get(values).(*int64), delta)
+}
+
+// Inc increases the counter of the label values by 1
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Value returns the counter of the label values
+func (c *CounterVec) Value(values ...string) int64 {
+	return atomic.LoadInt64(c.get(values).(*int64))
+}
+
+func (c *CounterVec) write(buf *bytes.Buffer) {
+	c.writeHeader(buf, "counter")
+	for _, key := range c.sortedKeys() {
+		s, _ := c.series.Load(key)
+		buf.WriteString(c.metricName + c.labelPairs(key, "") + " " + strconv.FormatInt(atomic.LoadInt64(s.(*int64)), 10) + "\n")
+	}
+}
+
+// histogram is a series of HistogramVec
+type histogram struct {
+	// counts[i] is the count of observations <= buckets[i], the last one is +Inf
+	counts []int64
+	// sumBits is the float64 bits of the sum of the observations
+	sumBits uint64
+}
+
+// HistogramVec is a histogram partitioned by the label values
+type HistogramVec struct {
+	vec
+	buckets []float64
+}
+
+// NewHistogramVec creates a histogram and registers it into the DefaultRegistry
+func NewHistogramVec(name string, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := newHistogramVec(name, help, buckets, labels...)
+	DefaultRegistry.register(h)
+	return h
+}
+
+func newHistogramVec(name string, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{buckets: buckets}
+	h.vec = vec{
+		metricName: name,
+		help:       help,
+		labels:     labels,
+		newSeries: func() interface{} {
+			return &histogram{counts: make([]int64, len(buckets)+1)}
+		},
+	}
+	return h
+}
+
+// Observe adds an observation to the histogram of the label values
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	s := h.get(values).(*histogram)
+	i := sort.SearchFloat64s(h.buckets, v)
+	atomic.AddInt64(&s.counts[i], 1)
+	for {
+		old := atomic.LoadUint64(&s.sumBits)
+		sum := math.Float64bits(math.Float64frombits(old) + v)
+		if atomic.CompareAndSwapUint64(&s.sumBits, old, sum) {
+			return
+		}
+	}
+}
+
+func (h *HistogramVec) write(buf *bytes.Buffer) {
+	h.writeHeader(buf, "histogram")
+	for _, key := range h.sortedKeys() {
+		v, _ := h.series.Load(key)
+		s := v.(*histogram)
+		var cumulative int64
+		for i := range s.counts {
+			cumulative += atomic.LoadInt64(&s.counts[i])
+			le := math.Inf(1)
+			if i < len(h.buckets) {
+				le = h.buckets[i]
+			}
+			buf.WriteString(h.metricName + "_bucket" + h.labelPairs(key, `le="`+formatFloat(le)+`"`) + " " + strconv.FormatInt(cumulative, 10) + "\n")
+		}
+		labels := h.labelPairs(key, "")
+		buf.WriteString(h.metricName + "_sum" + labels + " " + formatFloat(math.Float64frombits(atomic.LoadUint64(&s.sumBits))) + "\n")
+		buf.WriteString(h.metricName + "_count" + labels + " " + strconv.FormatInt(cumulative, 10) + "\n")
+	}
+}
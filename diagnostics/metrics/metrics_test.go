@@ -0,0 +1,99 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounterVec(t *testing.T) {
+	r := NewRegistry()
+	c := newCounterVec("requests_total", "Total requests.", "method", "code")
+	r.register(c)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Inc("/a", "OK")
+		}()
+	}
+	wg.Wait()
+	c.Add(3, `/b"\`, "Internal")
+	c.Add(-1, "/a", "OK")
+	assert.Equal(t, int64(10), c.Value("/a", "OK"))
+
+	buf := &bytes.Buffer{}
+	_, err := r.WriteTo(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, `# HELP requests_total Total requests.
+# TYPE requests_total counter
+requests_total{method="/a",code="OK"} 10
+requests_total{method="/b\"\\",code="Internal"} 3
+`, buf.String())
+
+	assert.Panics(t, func() {
+		c.Inc("/a")
+	})
+	assert.Panics(t, func() {
+		r.register(newCounterVec("requests_total", ""))
+	})
+}
+
+func TestHistogramVec(t *testing.T) {
+	r := NewRegistry()
+	h := newHistogramVec("latency_seconds", "Latency.", []float64{0.1, 1}, "method")
+	r.register(h)
+	h.Observe(0.05, "/a")
+	h.Observe(0.1, "/a")
+	h.Observe(0.5, "/a")
+	h.Observe(2, "/a")
+
+	buf := &bytes.Buffer{}
+	_, err := r.WriteTo(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{method="/a",le="0.1"} 2
+latency_seconds_bucket{method="/a",le="1"} 3
+latency_seconds_bucket{method="/a",le="+Inf"} 4
+latency_seconds_sum{method="/a"} 2.65
+latency_seconds_count{method="/a"} 4
+`, buf.String())
+}
+
+func TestCounterWithoutLabels(t *testing.T) {
+	c := newCounterVec("refills_total", "Refills.")
+	c.Inc()
+	buf := &bytes.Buffer{}
+	c.write(buf)
+	assert.Contains(t, buf.String(), "\nrefills_total 1\n")
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	LockContentions.Inc("redis")
+	buf := &bytes.Buffer{}
+	_, err := DefaultRegistry.WriteTo(buf)
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), `layotto_lock_contentions_total{store="redis"}`)
+	assert.Contains(t, buf.String(), "# TYPE layotto_grpc_request_duration_seconds histogram")
+}
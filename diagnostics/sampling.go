@@ -0,0 +1,318 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	ltrace "mosn.io/layotto/components/trace"
+)
+
+const (
+	samplingConfigKey = "sampling"
+
+	defaultTailBufferSize      = 1000
+	defaultTailMaxSpansOfTrace = 256
+)
+
+// samplingRate holds the bits of the float64 ratio of the requests being traced
+var samplingRate = math.Float64bits(1)
+
+// SetSamplingRate sets the ratio of the requests being traced when tracing is enabled.
+// The rate is clamped into [0, 1].
+func SetSamplingRate(rate float64) {
+	if math.IsNaN(rate) || rate < 0 {
+		rate = 0
+	}
+	if rate > 1 {
+		rate = 1
+	}
+	atomic.StoreUint64(&samplingRate, math.Float64bits(rate))
+}
+
+// SamplingRate returns the ratio of the requests being traced.
+func SamplingRate() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&samplingRate))
+}
+
+// sampled decides whether the current request should be traced
+func sampled() bool {
+	return sample(SamplingRate())
+}
+
+func sample(rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+	return rand.Float64() < rate
+}
+
+// SamplingConfig is the config of the sampling, which is the "sampling" item of the tracer config.
+type SamplingConfig struct {
+	// Rate overrides the sampling rate if it's set
+	Rate *float64 `json:"rate,omitempty"`
+	// MaxPerSecond limits the sampled requests per second, 0 means no limit
+	MaxPerSecond float64 `json:"max_per_second,omitempty"`
+	// Rules take precedence over Rate, the first matched rule decides the rate of a request
+	Rules []*SamplingRule `json:"rules,omitempty"`
+	// Tail keeps the error and slow traces which are not sampled
+	Tail *TailSamplingConfig `json:"tail,omitempty"`
+}
+
+// SamplingRule matches the requests by the method and the app id.
+type SamplingRule struct {
+	// Method is the full grpc method, or a prefix ending with "*", empty matches all
+	Method string `json:"method,omitempty"`
+	// AppId matches the app id in the request metadata, empty matches all
+	AppId string  `json:"app_id,omitempty"`
+	Rate  float64 `json:"rate"`
+}
+
+type TailSamplingConfig struct {
+	// LatencyThresholdMs keeps the traces whose root span lasts at least the threshold, 0 means not to keep slow traces
+	LatencyThresholdMs int64 `json:"latency_threshold_ms,omitempty"`
+	// BufferSize is the max number of the traces being buffered
+	BufferSize int `json:"buffer_size,omitempty"`
+	// MaxSpansPerTrace is the max number of the spans buffered for a trace
+	MaxSpansPerTrace int `json:"max_spans_per_trace,omitempty"`
+}
+
+func (r *SamplingRule) match(method, appId string) bool {
+	if r.AppId != "" && r.AppId != appId {
+		return false
+	}
+	if strings.HasSuffix(r.Method, "*") {
+		return strings.HasPrefix(method, strings.TrimSuffix(r.Method, "*"))
+	}
+	return r.Method == "" || r.Method == method
+}
+
+// sampler is the sampling policy built from a SamplingConfig
+type sampler struct {
+	config  *SamplingConfig
+	limiter *rateLimiter
+	tail    *tailSampler
+}
+
+var currentSampler atomic.Value
+
+func init() {
+	currentSampler.Store(&sampler{config: &SamplingConfig{}})
+}
+
+func getSampler() *sampler {
+	return currentSampler.Load().(*sampler)
+}
+
+// ConfigureSampling applies the "sampling" item of the tracer config.
+// The default policy which only uses the sampling rate is applied if there is no such item.
+func ConfigureSampling(config map[string]interface{}) error {
+	c := &SamplingConfig{}
+	if v, ok := config[samplingConfigKey]; ok {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, c); err != nil {
+			return fmt.Errorf("invalid sampling config: %v", err)
+		}
+	}
+	return SetSamplingConfig(c)
+}
+
+// Validate checks the rates and the limits in the config.
+func (c *SamplingConfig) Validate() error {
+	if c.Rate != nil && (*c.Rate < 0 || *c.Rate > 1) {
+		return fmt.Errorf("invalid sampling rate %v, it should be in [0, 1]", *c.Rate)
+	}
+	if c.MaxPerSecond < 0 {
+		return fmt.Errorf("invalid max_per_second %v", c.MaxPerSecond)
+	}
+	for _, r := range c.Rules {
+		if r == nil || r.Rate < 0 || r.Rate > 1 {
+			return fmt.Errorf("invalid sampling rule %+v, the rate should be in [0, 1]", r)
+		}
+	}
+	if c.Tail != nil && (c.Tail.LatencyThresholdMs < 0 || c.Tail.BufferSize < 0 || c.Tail.MaxSpansPerTrace < 0) {
+		return fmt.Errorf("invalid tail sampling config %+v", c.Tail)
+	}
+	return nil
+}
+
+// SetSamplingConfig validates and applies the sampling config, it takes effect on the requests coming later.
+func SetSamplingConfig(c *SamplingConfig) error {
+	if c == nil {
+		c = &SamplingConfig{}
+	}
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	s := &sampler{config: c}
+	if c.MaxPerSecond > 0 {
+		s.limiter = newRateLimiter(c.MaxPerSecond)
+	}
+	if c.Tail != nil {
+		s.tail = newTailSampler(c.Tail)
+	}
+	if c.Rate != nil {
+		SetSamplingRate(*c.Rate)
+	}
+	currentSampler.Store(s)
+	return nil
+}
+
+// GetSamplingConfig returns the sampling config in use.
+func GetSamplingConfig() *SamplingConfig {
+	return getSampler().config
+}
+
+// decide returns whether the request is sampled at the head.
+// If the caller propagates its decision, i.e. parent is not nil, it's followed instead of the rules and the rate,
+// so that a trace isn't broken across the processes. MaxPerSecond still applies.
+func (s *sampler) decide(method, appId string, parent *bool) bool {
+	if parent != nil {
+		if !*parent {
+			return false
+		}
+	} else if !sample(s.rate(method, appId)) {
+		return false
+	}
+	return s.limiter == nil || s.limiter.allow(time.Now())
+}
+
+// rate returns the sampling rate of the first matched rule, or the global rate
+func (s *sampler) rate(method, appId string) float64 {
+	for _, r := range s.config.Rules {
+		if r.match(method, appId) {
+			return r.Rate
+		}
+	}
+	return SamplingRate()
+}
+
+// rateLimiter is a token bucket whose burst is the rate of a second
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64) *rateLimiter {
+	return &rateLimiter{rate: rate, tokens: math.Max(rate, 1)}
+}
+
+func (l *rateLimiter) allow(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.last.IsZero() {
+		l.tokens = math.Min(l.tokens+now.Sub(l.last).Seconds()*l.rate, math.Max(l.rate, 1))
+	}
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// tailSampler buffers the spans of the traces which are not sampled at the head,
+// and exports them when the root span finishes if there is an error span or the root span is slow.
+type tailSampler struct {
+	threshold  time.Duration
+	bufferSize int64
+	maxSpans   int
+	pending    int64
+}
+
+func newTailSampler(c *TailSamplingConfig) *tailSampler {
+	t := &tailSampler{
+		threshold:  time.Duration(c.LatencyThresholdMs) * time.Millisecond,
+		bufferSize: int64(c.BufferSize),
+		maxSpans:   c.MaxSpansPerTrace,
+	}
+	if t.bufferSize <= 0 {
+		t.bufferSize = defaultTailBufferSize
+	}
+	if t.maxSpans <= 0 {
+		t.maxSpans = defaultTailMaxSpansOfTrace
+	}
+	return t
+}
+
+// track starts buffering the trace of the root span, it returns false if the buffer is full
+func (t *tailSampler) track(root *ltrace.Span) bool {
+	if atomic.AddInt64(&t.pending, 1) > t.bufferSize {
+		atomic.AddInt64(&t.pending, -1)
+		return false
+	}
+	root.SetFinisher(&tailTrace{sampler: t, root: root})
+	return true
+}
+
+func (t *tailSampler) slow(span *ltrace.Span) bool {
+	return t.threshold > 0 && span.EndTime.Sub(span.StartTime) >= t.threshold
+}
+
+func isErrorSpan(span *ltrace.Span) bool {
+	return span.Tag(ltrace.LAYOTTO_REQUEST_RESULT) == "1"
+}
+
+// tailTrace is the buffer of a trace, which is the Finisher of all its spans
+type tailTrace struct {
+	sampler *tailSampler
+	root    *ltrace.Span
+
+	mu    sync.Mutex
+	spans []*ltrace.Span
+	// hasError is true if any finished span of the trace failed
+	hasError bool
+	done     bool
+	kept     bool
+}
+
+func (t *tailTrace) Finish(span *ltrace.Span) {
+	t.mu.Lock()
+	if t.done {
+		// the span finishes after the root span, follow the decision of the trace
+		kept := t.kept
+		t.mu.Unlock()
+		if kept || isErrorSpan(span) || t.sampler.slow(span) {
+			ltrace.ExportSpan(span)
+		}
+		return
+	}
+	if isErrorSpan(span) {
+		t.hasError = true
+	}
+	if span != t.root {
+		if len(t.spans) < t.sampler.maxSpans {
+			t.spans = append(t.spans, span)
+		}
+		t.mu.Unlock()
+		return
+	}
+	t.done = true
+	t.kept = t.hasError || t.sampler.slow(span)
+	spans := t.spans
+	t.spans = nil
+	kept := t.kept
+	t.mu.Unlock()
+
+	atomic.AddInt64(&t.sampler.pending, -1)
+	if !kept {
+		return
+	}
+	for _, s := range spans {
+		ltrace.ExportSpan(s)
+	}
+	ltrace.ExportSpan(span)
+}
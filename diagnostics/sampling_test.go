@@ -0,0 +1,148 @@
+package diagnostics
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+	"mosn.io/mosn/pkg/trace/sofa"
+
+	ltrace "mosn.io/layotto/components/trace"
+)
+
+func TestSamplingRate(t *testing.T) {
+	defer SetSamplingRate(1)
+	assert.Equal(t, float64(1), SamplingRate())
+	assert.True(t, sampled())
+
+	SetSamplingRate(0)
+	for i := 0; i < 100; i++ {
+		assert.False(t, sampled())
+	}
+
+	SetSamplingRate(0.5)
+	assert.Equal(t, 0.5, SamplingRate())
+	hits := 0
+	for i := 0; i < 10000; i++ {
+		if sampled() {
+			hits++
+		}
+	}
+	assert.InDelta(t, 5000, hits, 500)
+
+	SetSamplingRate(2)
+	assert.Equal(t, float64(1), SamplingRate())
+	SetSamplingRate(-1)
+	assert.Equal(t, float64(0), SamplingRate())
+	SetSamplingRate(math.NaN())
+	assert.Equal(t, float64(0), SamplingRate())
+}
+
+func TestConfigureSampling(t *testing.T) {
+	defer SetSamplingRate(1)
+	defer ConfigureSampling(nil)
+
+	err := ConfigureSampling(map[string]interface{}{
+		samplingConfigKey: map[string]interface{}{
+			"rate": 0,
+			"rules": []interface{}{
+				map[string]interface{}{"method": "/spec.proto.runtime.v1.Runtime/GetState", "rate": 1},
+				map[string]interface{}{"method": "/spec.proto.runtime.v1.Runtime/*", "app_id": "app1", "rate": 1},
+			},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, float64(0), SamplingRate())
+	s := getSampler()
+	assert.True(t, s.decide("/spec.proto.runtime.v1.Runtime/GetState", "", nil))
+	assert.True(t, s.decide("/spec.proto.runtime.v1.Runtime/SaveState", "app1", nil))
+	assert.False(t, s.decide("/spec.proto.runtime.v1.Runtime/SaveState", "app2", nil))
+	assert.False(t, s.decide("/spec.proto.runtime.v1.Lifecycle/ApplyConfiguration", "app1", nil))
+	// the decision of the caller takes precedence over the rules
+	sampled, notSampled := true, false
+	assert.True(t, s.decide("/spec.proto.runtime.v1.Runtime/SaveState", "app2", &sampled))
+	assert.False(t, s.decide("/spec.proto.runtime.v1.Runtime/GetState", "", &notSampled))
+
+	// the app id is read from the metadata
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(sofa.APP_NAME_KEY, "app1"))
+	assert.NotNil(t, newSpan(ctx, time.Now(), nil, "/spec.proto.runtime.v1.Runtime/SaveState"))
+	assert.Nil(t, newSpan(context.Background(), time.Now(), nil, "/spec.proto.runtime.v1.Runtime/SaveState"))
+
+	// invalid config
+	err = ConfigureSampling(map[string]interface{}{
+		samplingConfigKey: map[string]interface{}{
+			"rules": []interface{}{map[string]interface{}{"rate": 2}},
+		},
+	})
+	assert.NotNil(t, err)
+	err = ConfigureSampling(map[string]interface{}{samplingConfigKey: "all"})
+	assert.NotNil(t, err)
+
+	// reset to the default policy
+	assert.Nil(t, ConfigureSampling(nil))
+	assert.Nil(t, getSampler().tail)
+	assert.Empty(t, GetSamplingConfig().Rules)
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	l := newRateLimiter(2)
+	assert.True(t, l.allow(now))
+	assert.True(t, l.allow(now))
+	assert.False(t, l.allow(now))
+	assert.True(t, l.allow(now.Add(500*time.Millisecond)))
+	assert.False(t, l.allow(now.Add(500*time.Millisecond)))
+	// the burst is limited to the rate of a second
+	assert.True(t, l.allow(now.Add(time.Hour)))
+	assert.True(t, l.allow(now.Add(time.Hour)))
+	assert.False(t, l.allow(now.Add(time.Hour)))
+}
+
+type mockSamplingExporter struct {
+	spans []*ltrace.Span
+}
+
+func (m *mockSamplingExporter) ExportSpan(s *ltrace.Span) {
+	m.spans = append(m.spans, s)
+}
+
+func TestTailSampling(t *testing.T) {
+	defer SetSamplingRate(1)
+	defer ConfigureSampling(nil)
+	m := &mockSamplingExporter{}
+	ltrace.RegisterExporter("mock_sampling", m)
+	ltrace.SetActiveExporters([]string{"mock_sampling"})
+	defer ltrace.UnregisterExporter("mock_sampling")
+
+	rate := float64(0)
+	err := SetSamplingConfig(&SamplingConfig{
+		Rate: &rate,
+		Tail: &TailSamplingConfig{LatencyThresholdMs: 100, BufferSize: 1},
+	})
+	assert.Nil(t, err)
+
+	// a fast trace without error is dropped
+	root := newSpan(context.Background(), time.Now(), nil, "ok").(*ltrace.Span)
+	// the buffer is full
+	assert.Nil(t, newSpan(context.Background(), time.Now(), nil, "ok"))
+	child := root.SpawnChild("state/GetState", time.Now())
+	child.FinishSpan()
+	root.FinishSpan()
+	assert.Empty(t, m.spans)
+
+	// a trace with an error child is kept
+	root = newSpan(context.Background(), time.Now(), nil, "error").(*ltrace.Span)
+	child = root.SpawnChild("state/GetState", time.Now())
+	ltrace.EndSpan(child, assert.AnError)
+	root.FinishSpan()
+	assert.Equal(t, []*ltrace.Span{child.(*ltrace.Span), root}, m.spans)
+
+	// a slow trace is kept
+	m.spans = nil
+	root = newSpan(context.Background(), time.Now().Add(-time.Second), nil, "slow").(*ltrace.Span)
+	root.FinishSpan()
+	assert.Equal(t, []*ltrace.Span{root}, m.spans)
+}
@@ -5,11 +5,17 @@ import (
 	"encoding/json"
 	"time"
 
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"mosn.io/mosn/pkg/trace"
+	"mosn.io/mosn/pkg/trace/sofa"
 	"mosn.io/pkg/log"
 
 	"mosn.io/api"
 
 	ltrace "mosn.io/layotto/components/trace"
+	lgrpc "mosn.io/layotto/diagnostics/grpc"
+	"mosn.io/layotto/diagnostics/protocol"
 )
 
 const (
@@ -24,7 +30,17 @@ type grpcTracer struct {
 }
 
 func NewTracer(config map[string]interface{}) (api.Tracer, error) {
+	if err := ConfigureSampling(config); err != nil {
+		return nil, err
+	}
 	v := getActiveExportersFromConfig(config)
+	for _, name := range v {
+		if e, ok := ltrace.GetExporter(name).(ltrace.ConfigurableExporter); ok {
+			if err := e.Configure(config); err != nil {
+				return nil, err
+			}
+		}
+	}
 	ltrace.SetActiveExporters(v)
 	return &grpcTracer{config: config}, nil
 }
@@ -45,16 +61,22 @@ func getActiveExportersFromConfig(config map[string]interface{}) []string {
 }
 
 func (tracer *grpcTracer) Start(ctx context.Context, request interface{}, startTime time.Time) api.Span {
-	span := NewSpan(ctx, startTime, tracer.config)
-
-	return span
+	method := ""
+	if info, ok := request.(*lgrpc.RequestInfo); ok {
+		method = info.FullMethod
+	}
+	return newSpan(ctx, startTime, tracer.config, method)
 }
 
 // NewSpan constructs a span and tag it with span/trace/parentSpan IDs.
-// These IDs are generated using the Generator
+// These IDs are generated using the Generator.
+// It returns nil if the request is not sampled, see SamplingConfig.
 func NewSpan(ctx context.Context, startTime time.Time, config map[string]interface{}) api.Span {
-	// construct span
-	span := &ltrace.Span{StartTime: startTime}
+	method, _ := grpc.Method(ctx)
+	return newSpan(ctx, startTime, config, method)
+}
+
+func newSpan(ctx context.Context, startTime time.Time, config map[string]interface{}, method string) api.Span {
 	// get generator according to configuration
 	generatorName := defaultGenerator
 	if v, ok := config[generatorConfigKey]; ok {
@@ -65,6 +87,20 @@ func NewSpan(ctx context.Context, startTime time.Time, config map[string]interfa
 		log.DefaultLogger.Errorf("not support trace type: %+v", generatorName)
 		return nil
 	}
+	// the decision of the caller is only known when the trace context is propagated by the w3c generator
+	var parent *bool
+	if _, ok := ge.(*W3CGenerator); ok {
+		parent = parentSampled(ctx)
+	}
+	// the spans not sampled at the head are kept in the tail sampler if it's enabled
+	s := getSampler()
+	headSampled := s.decide(method, appIdFromContext(ctx), parent)
+	if !headSampled && s.tail == nil {
+		return nil
+	}
+	// construct span
+	span := &ltrace.Span{StartTime: startTime}
+	span.SetKind(ltrace.SpanKindServer)
 	// use generator to extract the span/trace/parentSpan IDs
 	spanId := ge.GetSpanId(ctx)
 	traceId := ge.GetTraceId(ctx)
@@ -74,9 +110,33 @@ func NewSpan(ctx context.Context, startTime time.Time, config map[string]interfa
 	span.SetParentSpanId(parentSpanId)
 	// tagging generator type
 	span.SetTag(ltrace.LAYOTTO_GENERATOR_TYPE, generatorName)
+	if !headSampled && !s.tail.track(span) {
+		return nil
+	}
 	return span
 }
 
+func appIdFromContext(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if v := md.Get(sofa.APP_NAME_KEY); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// startSpan starts a span of the layotto tracer, it returns nil if the request is not sampled
+func startSpan(ctx context.Context, method string) api.Span {
+	tracer := trace.Tracer(protocol.Layotto)
+	if tracer == nil {
+		return nil
+	}
+	// the sampling policy is applied in NewSpan, while the other tracers only support the sampling rate
+	if _, ok := tracer.(*grpcTracer); !ok && !sampled() {
+		return nil
+	}
+	return tracer.Start(ctx, &lgrpc.RequestInfo{FullMethod: method}, time.Now())
+}
+
 func GetNewContext(ctx context.Context, span api.Span) context.Context {
 	genType := span.Tag(ltrace.LAYOTTO_GENERATOR_TYPE)
 	ge := ltrace.GetGenerator(genType)
@@ -87,3 +147,29 @@ func GetNewContext(ctx context.Context, span api.Span) context.Context {
 	newCtx := ge.GenerateNewContext(ctx, span)
 	return newCtx
 }
+
+// StartSpanFromCarrier starts a span which continues the trace propagated through get, e.g. from the metadata of a pubsub message,
+// and returns a new context in which the span is active. The span is nil if tracing is disabled or not sampled.
+func StartSpanFromCarrier(ctx context.Context, kind ltrace.SpanKind, operation string, get func(key string) string) (context.Context, api.Span) {
+	if !trace.IsEnabled() {
+		return ctx, nil
+	}
+	if t, ok := trace.Tracer(protocol.Layotto).(*grpcTracer); ok {
+		generatorName := defaultGenerator
+		if v, ok := t.config[generatorConfigKey].(string); ok {
+			generatorName = v
+		}
+		if p, ok := ltrace.GetGenerator(generatorName).(ltrace.Propagator); ok {
+			ctx = p.Extract(ctx, get)
+		}
+	}
+	span := startSpan(ctx, operation)
+	if span == nil {
+		return ctx, nil
+	}
+	span.SetTag(ltrace.LAYOTTO_METHOD_NAME, operation)
+	if s, ok := span.(interface{ SetKind(ltrace.SpanKind) }); ok {
+		s.SetKind(kind)
+	}
+	return GetNewContext(ctx, span), span
+}
@@ -0,0 +1,194 @@
+package diagnostics
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+	"mosn.io/api"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/trace/sofa"
+	"mosn.io/mosn/pkg/types"
+
+	"mosn.io/layotto/components/trace"
+)
+
+const (
+	// W3CGeneratorName is the name of the generator which follows the W3C trace context.
+	W3CGeneratorName = "w3c"
+
+	TraceparentKey = "traceparent"
+	TracestateKey  = "tracestate"
+
+	traceparentVersion = "00"
+	defaultTraceFlags  = "01"
+)
+
+func init() {
+	trace.RegisterGenerator(W3CGeneratorName, &W3CGenerator{})
+}
+
+var (
+	_ trace.ChildGenerator = &W3CGenerator{}
+	_ trace.Propagator     = &W3CGenerator{}
+)
+
+// W3CGenerator extracts the trace context from the `traceparent` in the grpc metadata,
+// and injects the context of the new span into both the incoming and the outgoing metadata.
+// See https://www.w3.org/TR/trace-context/
+type W3CGenerator struct {
+}
+
+type traceparent struct {
+	traceId  string
+	parentId string
+	flags    string
+}
+
+// parseTraceparent parses the header like "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+func parseTraceparent(v string) (*traceparent, bool) {
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 {
+		return nil, false
+	}
+	version, traceId, parentId, flags := parts[0], parts[1], parts[2], parts[3]
+	// version ff is invalid, and version 00 has exactly 4 parts
+	if !isHex(version, 2) || version == "ff" || (version == traceparentVersion && len(parts) != 4) {
+		return nil, false
+	}
+	if !isHex(traceId, 32) || traceId == strings.Repeat("0", 32) {
+		return nil, false
+	}
+	if !isHex(parentId, 16) || parentId == strings.Repeat("0", 16) {
+		return nil, false
+	}
+	if !isHex(flags, 2) {
+		return nil, false
+	}
+	return &traceparent{traceId: traceId, parentId: parentId, flags: flags}, true
+}
+
+// sampled returns whether the caller sampled the trace, which is the last bit of the flags
+func (tp *traceparent) sampled() bool {
+	b, err := hex.DecodeString(tp.flags)
+	return err == nil && b[0]&1 == 1
+}
+
+// isHex checks whether s is a lowercase hex string of length n
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func incomingTraceparent(ctx context.Context) (*traceparent, bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	v := md.Get(TraceparentKey)
+	if len(v) == 0 {
+		return nil, false
+	}
+	return parseTraceparent(v[0])
+}
+
+// parentSampled returns the sampling decision in the incoming traceparent, or nil if there is no valid traceparent
+func parentSampled(ctx context.Context) *bool {
+	tp, ok := incomingTraceparent(ctx)
+	if !ok {
+		return nil
+	}
+	sampled := tp.sampled()
+	return &sampled
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (g *W3CGenerator) GetTraceId(ctx context.Context) string {
+	if tp, ok := incomingTraceparent(ctx); ok {
+		return tp.traceId
+	}
+	return randomHex(16)
+}
+
+// GetSpanId always generates a new span id, because the span in the traceparent belongs to the caller.
+func (g *W3CGenerator) GetSpanId(ctx context.Context) string {
+	return randomHex(8)
+}
+
+func (g *W3CGenerator) GetParentSpanId(ctx context.Context) string {
+	if tp, ok := incomingTraceparent(ctx); ok {
+		return tp.parentId
+	}
+	return ""
+}
+
+func (g *W3CGenerator) GenerateNewContext(ctx context.Context, span api.Span) context.Context {
+	flags := defaultTraceFlags
+	if tp, ok := incomingTraceparent(ctx); ok {
+		flags = tp.flags
+	}
+	value := strings.Join([]string{traceparentVersion, span.TraceId(), span.SpanId(), flags}, "-")
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	newMd := md.Copy()
+	newMd.Set(TraceparentKey, value)
+	if v := md.Get(sofa.APP_NAME_KEY); len(v) > 0 {
+		span.SetTag(trace.LAYOTTO_APP_NAME, v[0])
+	}
+	// the flags and the tracestate are kept in the span, so that they're injected into the outbound calls of its children
+	span.SetTag(trace.LAYOTTO_TRACE_FLAGS, flags)
+	tracestate := md.Get(TracestateKey)
+	if len(tracestate) > 0 {
+		span.SetTag(trace.LAYOTTO_TRACE_STATE, strings.Join(tracestate, ","))
+	}
+	ctx = metadata.NewIncomingContext(ctx, newMd)
+
+	// propagate to the outbound grpc calls made with this context
+	outMd, _ := metadata.FromOutgoingContext(ctx)
+	outMd = outMd.Copy()
+	outMd.Set(TraceparentKey, value)
+	if len(tracestate) > 0 {
+		outMd.Set(TracestateKey, tracestate...)
+	}
+	ctx = metadata.NewOutgoingContext(ctx, outMd)
+	ctx = mosnctx.WithValue(ctx, types.ContextKeyActiveSpan, span)
+	return ctx
+}
+
+func (g *W3CGenerator) GetChildSpanId(parent api.Span, seq uint32) string {
+	return randomHex(8)
+}
+
+// Inject carries the flags and the tracestate received by the root span of this process, see GenerateNewContext.
+func (g *W3CGenerator) Inject(span api.Span, set func(key string, value string)) {
+	flags := span.Tag(trace.LAYOTTO_TRACE_FLAGS)
+	if flags == "" {
+		flags = defaultTraceFlags
+	}
+	set(TraceparentKey, strings.Join([]string{traceparentVersion, span.TraceId(), span.SpanId(), flags}, "-"))
+	if v := span.Tag(trace.LAYOTTO_TRACE_STATE); v != "" {
+		set(TracestateKey, v)
+	}
+}
+
+func (g *W3CGenerator) Extract(ctx context.Context, get func(key string) string) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	md = md.Copy()
+	for _, key := range []string{TraceparentKey, TracestateKey} {
+		if v := get(key); v != "" {
+			md.Set(key, v)
+		}
+	}
+	return metadata.NewIncomingContext(ctx, md)
+}
@@ -0,0 +1,118 @@
+package diagnostics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+
+	"mosn.io/layotto/components/trace"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tp, ok := parseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tp.traceId)
+	assert.Equal(t, "00f067aa0ba902b7", tp.parentId)
+	assert.Equal(t, "01", tp.flags)
+
+	// future versions may have more fields
+	_, ok = parseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra")
+	assert.True(t, ok)
+
+	for _, v := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6-00f067aa0ba902b7-01",
+	} {
+		_, ok = parseTraceparent(v)
+		assert.False(t, ok, v)
+	}
+}
+
+func TestW3CGenerator(t *testing.T) {
+	ge := trace.GetGenerator(W3CGeneratorName)
+	assert.NotNil(t, ge)
+
+	// without traceparent
+	ctx := context.Background()
+	assert.Len(t, ge.GetTraceId(ctx), 32)
+	assert.Len(t, ge.GetSpanId(ctx), 16)
+	assert.Equal(t, "", ge.GetParentSpanId(ctx))
+
+	// with traceparent
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(
+		TraceparentKey, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		TracestateKey, "vendor=value",
+	))
+	span := NewSpan(ctx, time.Now(), map[string]interface{}{generatorConfigKey: W3CGeneratorName})
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.TraceId())
+	assert.Equal(t, "00f067aa0ba902b7", span.ParentSpanId())
+	assert.NotEqual(t, "00f067aa0ba902b7", span.SpanId())
+
+	newCtx := GetNewContext(ctx, span)
+	expected := "00-4bf92f3577b34da6a3ce929d0e0e4736-" + span.SpanId() + "-01"
+	in, _ := metadata.FromIncomingContext(newCtx)
+	assert.Equal(t, []string{expected}, in.Get(TraceparentKey))
+	out, _ := metadata.FromOutgoingContext(newCtx)
+	assert.Equal(t, []string{expected}, out.Get(TraceparentKey))
+	assert.Equal(t, []string{"vendor=value"}, out.Get(TracestateKey))
+}
+
+func TestW3CGeneratorInject(t *testing.T) {
+	config := map[string]interface{}{generatorConfigKey: W3CGeneratorName}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		TraceparentKey, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-03",
+		TracestateKey, "vendor1=value1",
+		TracestateKey, "vendor2=value2",
+	))
+	span := NewSpan(ctx, time.Now(), config)
+	GetNewContext(ctx, span)
+
+	// the flags and the tracestate of the caller are carried to the children
+	child := span.SpawnChild("pubsub/publish", time.Now())
+	injected := map[string]string{}
+	trace.Inject(child, func(k, v string) {
+		injected[k] = v
+	})
+	assert.Equal(t, map[string]string{
+		TraceparentKey: "00-4bf92f3577b34da6a3ce929d0e0e4736-" + child.SpanId() + "-03",
+		TracestateKey:  "vendor1=value1,vendor2=value2",
+	}, injected)
+
+	// the root span
+	ctx = context.Background()
+	span = NewSpan(ctx, time.Now(), config)
+	GetNewContext(ctx, span)
+	injected = map[string]string{}
+	trace.Inject(span, func(k, v string) {
+		injected[k] = v
+	})
+	assert.Equal(t, map[string]string{
+		TraceparentKey: "00-" + span.TraceId() + "-" + span.SpanId() + "-01",
+	}, injected)
+}
+
+func TestW3CGeneratorParentSampled(t *testing.T) {
+	defer SetSamplingRate(1)
+	config := map[string]interface{}{generatorConfigKey: W3CGeneratorName}
+	notSampled := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		TraceparentKey, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+	))
+	assert.Nil(t, NewSpan(notSampled, time.Now(), config))
+
+	// the caller's decision is followed even if the rate is 0
+	SetSamplingRate(0)
+	sampled := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		TraceparentKey, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	))
+	assert.NotNil(t, NewSpan(sampled, time.Now(), config))
+	assert.Nil(t, NewSpan(context.Background(), time.Now(), config))
+}
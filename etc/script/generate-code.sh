@@ -9,12 +9,9 @@ if [ $CON -eq 1 ]; then
   docker build -t layotto/protoc docker/proto
 fi
 
-# check if protoc-gen-p6 has been installed
-CON=$(which protoc-gen-p6 | wc -l)
-if [ $CON -eq 0 ]; then
-  # 0 means that protoc-gen-p6 does not exist
-  go install github.com/layotto/protoc-gen-p6@latest
-fi
+# install protoc-gen-p6 from cmd/protoc-gen-p6,
+# so that the generated code always matches the generator in this repo
+go install ./cmd/protoc-gen-p6
 
 needGenerate() {
   file=$1
@@ -53,11 +50,11 @@ generateSdkAndSidecar() {
   mv _output/tmp/runtime/* pkg/runtime/
 
   # api plugin
-  mv _output/tmp/grpc/* pkg/grpc/
+  cp -r _output/tmp/grpc/* pkg/grpc/
   #  rm -rf _output/tmp/grpc
 
   # component
-  mv _output/tmp/components/* components/
+  cp -r _output/tmp/components/* components/
   #  rm -rf _output/tmp/components
   rm -rf _output/tmp
 
@@ -0,0 +1,95 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"mosn.io/layotto/pkg/actuator"
+	"mosn.io/layotto/pkg/filter/stream/common/http"
+	"mosn.io/layotto/pkg/runtime/lifecycle"
+)
+
+// init config Endpoint.
+func init() {
+	actuator.GetDefault().AddEndpoint("config", NewEndpoint())
+}
+
+var (
+	runtimeConfig interface{}
+	components    func() map[string][]string
+)
+
+// SetSource sets the loaded runtime config and the function listing the initialized components.
+// It's not concurrent-safe,so please invoke it before the runtime starts.
+func SetSource(config interface{}, initialized func() map[string][]string) {
+	runtimeConfig = config
+	components = initialized
+}
+
+type Endpoint struct {
+}
+
+func NewEndpoint() *Endpoint {
+	return &Endpoint{}
+}
+
+// Handle returns the effective runtime configuration with the secrets redacted. The structure of the returned map is like:
+//
+//	{
+//	 "config": {"lock": {"redis": {"type": "redis", "metadata": {"redisPassword": "******"}}}},
+//	 "components": {"lock": ["redis"]},
+//	 "history": [{"time": "...", "kind": "lock", "name": "redis", "metadata": {}}]
+//	}
+func (e *Endpoint) Handle(ctx context.Context, params http.ParamsScanner) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	if runtimeConfig == nil {
+		return result, errors.New("runtime config not loaded")
+	}
+	data, err := json.Marshal(runtimeConfig)
+	if err != nil {
+		return result, err
+	}
+	var config interface{}
+	if err = json.Unmarshal(data, &config); err != nil {
+		return result, err
+	}
+	result["config"] = redact(config)
+	if components != nil {
+		result["components"] = components()
+	}
+
+	changes := lifecycle.History()
+	history := make([]map[string]interface{}, 0, len(changes))
+	for _, c := range changes {
+		change := map[string]interface{}{
+			"time":     c.Time.Format(time.RFC3339Nano),
+			"kind":     c.Kind,
+			"name":     c.Name,
+			"metadata": redactMetadata(c.Metadata),
+		}
+		if c.Error != "" {
+			change["error"] = c.Error
+		}
+		history = append(history, change)
+	}
+	result["history"] = history
+	return result, nil
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"mosn.io/layotto/pkg/actuator"
+	"mosn.io/layotto/pkg/runtime/lifecycle"
+)
+
+const testConfig = `{
+	"lock": {
+		"redis": {
+			"type": "redis",
+			"metadata": {"redisHost": "localhost:6379", "redisPassword": "123", "apiToken": "abc", "dbPwd": "456", "api_key": 789, "apiKey": "k"}
+		}
+	},
+	"state": {
+		"redis": {
+			"metadata": {"redisHost": "localhost:6379", "pwd": "injected", "user": "root"},
+			"secret_ref": [{"store_name": "local.file", "key": "db", "sub_key": "pwd"}, {"store_name": "local.file", "key": "db", "sub_key": "name", "inject_as": "user"}],
+			"component_ref": {"secret_store": "local.file"}
+		}
+	},
+	"oss": {"aws": {"metadata": {"accessKeyID": "id", "accessKeySecret": "secret", "endpoint": "e"}}},
+	"s3_gateway": {"credentials": [{"access_key_id": "id", "secret_access_key": "secret"}], "private_key": {"pem": "key"}}
+}`
+
+func TestRedact(t *testing.T) {
+	var config interface{}
+	assert.Nil(t, json.Unmarshal([]byte(testConfig), &config))
+	result := redact(config).(map[string]interface{})
+
+	lock := result["lock"].(map[string]interface{})["redis"].(map[string]interface{})["metadata"].(map[string]interface{})
+	assert.Equal(t, "localhost:6379", lock["redisHost"])
+	assert.Equal(t, redacted, lock["redisPassword"])
+	assert.Equal(t, redacted, lock["apiToken"])
+	assert.Equal(t, redacted, lock["dbPwd"])
+	assert.Equal(t, redacted, lock["api_key"])
+	assert.Equal(t, redacted, lock["apiKey"])
+
+	// the values of the sensitive keys are redacted whatever their types
+	gateway := result["s3_gateway"].(map[string]interface{})
+	assert.Equal(t, redacted, gateway["credentials"])
+	assert.Equal(t, redacted, gateway["private_key"])
+
+	state := result["state"].(map[string]interface{})["redis"].(map[string]interface{})
+	metadata := state["metadata"].(map[string]interface{})
+	assert.Equal(t, redacted, metadata["pwd"])
+	assert.Equal(t, redacted, metadata["user"])
+	assert.Equal(t, "localhost:6379", metadata["redisHost"])
+	assert.Equal(t, "local.file", state["component_ref"].(map[string]interface{})["secret_store"])
+	assert.Equal(t, "local.file", state["secret_ref"].([]interface{})[0].(map[string]interface{})["store_name"])
+
+	oss := result["oss"].(map[string]interface{})["aws"].(map[string]interface{})["metadata"].(map[string]interface{})
+	assert.Equal(t, redacted, oss["accessKeySecret"])
+	assert.Equal(t, redacted, oss["accessKeyID"])
+	assert.Equal(t, "e", oss["endpoint"])
+}
+
+func TestEndpoint(t *testing.T) {
+	ep, ok := actuator.GetDefault().GetEndpoint("config")
+	assert.True(t, ok)
+
+	runtimeConfig = nil
+	_, err := ep.Handle(context.Background(), nil)
+	assert.NotNil(t, err)
+
+	var config interface{}
+	assert.Nil(t, json.Unmarshal([]byte(testConfig), &config))
+	SetSource(config, func() map[string][]string {
+		return map[string][]string{"lock": {"redis"}}
+	})
+	lifecycle.RecordChange("lock", "redis", map[string]string{"redisPassword": "456", "timeout": "1"}, nil)
+	lifecycle.RecordChange("lock", "redis", map[string]string{"timeout": "a"}, errors.New("invalid timeout"))
+
+	result, err := ep.Handle(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]string{"lock": {"redis"}}, result["components"])
+	history := result["history"].([]map[string]interface{})
+	assert.Equal(t, 2, len(history))
+	assert.Equal(t, map[string]string{"redisPassword": redacted, "timeout": "1"}, history[0]["metadata"])
+	assert.Equal(t, "invalid timeout", history[1]["error"])
+	assert.NotContains(t, history[0], "error")
+
+	// the source config is untouched
+	lock := config.(map[string]interface{})["lock"].(map[string]interface{})["redis"].(map[string]interface{})["metadata"].(map[string]interface{})
+	assert.Equal(t, "123", lock["redisPassword"])
+}
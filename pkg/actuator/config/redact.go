@@ -0,0 +1,106 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"regexp"
+)
+
+const redacted = "******"
+
+// sensitiveKey matches the keys whose values are secrets
+var sensitiveKey = regexp.MustCompile(`(?i)(password|passwd|pwd|secret|token|credential|private_?key|access_?key|api_?key)`)
+
+// the keys matching sensitiveKey but referring to other components instead of secrets
+var referenceKeys = map[string]bool{
+	"secret_ref":   true,
+	"secret_store": true,
+	"store_name":   true,
+}
+
+// redact replaces the secrets in the json value, i.e. the values of the sensitive keys whatever their types
+// and the metadata injected by `secret_ref`
+func redact(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		injected := injectedKeys(value["secret_ref"])
+		for k, item := range value {
+			if k == "metadata" {
+				value[k] = redactInjected(item, injected)
+			}
+			if isSensitive(k) {
+				value[k] = redacted
+				continue
+			}
+			value[k] = redact(value[k])
+		}
+		return value
+	case []interface{}:
+		for i := range value {
+			value[i] = redact(value[i])
+		}
+		return value
+	}
+	return v
+}
+
+func isSensitive(key string) bool {
+	return !referenceKeys[key] && sensitiveKey.MatchString(key)
+}
+
+// injectedKeys returns the metadata keys injected by the secret refs
+func injectedKeys(refs interface{}) map[string]bool {
+	keys := make(map[string]bool)
+	items, _ := refs.([]interface{})
+	for _, item := range items {
+		ref, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if k, _ := ref["inject_as"].(string); k != "" {
+			keys[k] = true
+		} else if k, _ := ref["sub_key"].(string); k != "" {
+			keys[k] = true
+		}
+	}
+	return keys
+}
+
+func redactInjected(metadata interface{}, injected map[string]bool) interface{} {
+	m, ok := metadata.(map[string]interface{})
+	if !ok || len(injected) == 0 {
+		return metadata
+	}
+	for k := range m {
+		if injected[k] {
+			m[k] = redacted
+		}
+	}
+	return m
+}
+
+// redactMetadata returns a copy of the metadata with the sensitive values redacted
+func redactMetadata(metadata map[string]string) map[string]string {
+	result := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		if isSensitive(k) {
+			v = redacted
+		}
+		result[k] = v
+	}
+	return result
+}
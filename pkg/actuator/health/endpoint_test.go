@@ -73,3 +73,27 @@ func TestEndpoint_WhenNoIndicator(t *testing.T) {
 	assert.True(t, health.Status == DOWN)
 	assert.True(t, health.GetDetail("reason") == "mock")
 }
+
+type livenessScanner struct {
+	done bool
+}
+
+func (m *livenessScanner) Next() string {
+	m.done = true
+	return "liveness"
+}
+
+func (m *livenessScanner) HasNext() bool {
+	return !m.done
+}
+
+func TestEndpoint_Unknown(t *testing.T) {
+	ep := NewEndpoint()
+	AddLivenessIndicatorFunc("unknown", func() (string, map[string]interface{}) {
+		return UNKNOWN, nil
+	})
+	handle, err := ep.Handle(context.Background(), &livenessScanner{})
+	assert.Nil(t, err)
+	assert.Equal(t, UP, handle["status"])
+	assert.Equal(t, UNKNOWN, handle["components"].(map[string]Health)["unknown"].Status)
+}
@@ -37,6 +37,8 @@ var (
 	UP = Status("UP")
 	// DOWN means it is unhealthy
 	DOWN = Status("DOWN")
+	// UNKNOWN means the health can't be checked, it doesn't fail the overall status
+	UNKNOWN = Status("UNKNOWN")
 )
 
 // Details hold additional contextual details about the health of a component.
@@ -0,0 +1,153 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logger
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"mosn.io/pkg/log"
+
+	"mosn.io/layotto/components/pkg/actuators"
+	"mosn.io/layotto/pkg/actuator"
+	"mosn.io/layotto/pkg/filter/stream/common/http"
+)
+
+const defaultLoggerName = "default"
+
+// init logger Endpoint.
+func init() {
+	actuator.GetDefault().AddEndpoint("logger", NewEndpoint())
+}
+
+// the same names as the log-level flag of the command line
+var (
+	name2Level = map[string]log.Level{
+		"trace":    log.TRACE,
+		"debug":    log.DEBUG,
+		"info":     log.INFO,
+		"warn":     log.WARN,
+		"warning":  log.WARN,
+		"error":    log.ERROR,
+		"critical": log.FATAL,
+		"fatal":    log.FATAL,
+	}
+	level2Name = map[log.Level]string{
+		log.TRACE: "trace",
+		log.DEBUG: "debug",
+		log.INFO:  "info",
+		log.WARN:  "warning",
+		log.ERROR: "error",
+		log.FATAL: "critical",
+	}
+)
+
+type Endpoint struct {
+	reverter *actuator.Reverter
+}
+
+func NewEndpoint() *Endpoint {
+	return &Endpoint{
+		reverter: actuator.NewReverter(),
+	}
+}
+
+// Handle lists the loggers and their levels if the request has no body, otherwise it changes the level of one logger.
+// The request body is like:
+//
+//	{"logger": "tcpcopy.dump", "level": "debug", "ttl": "10m"}
+//
+// logger defaults to "default", and the level is restored when the optional ttl expires.
+func (e *Endpoint) Handle(ctx context.Context, params http.ParamsScanner) (map[string]interface{}, error) {
+	if !hasRequestData(ctx) {
+		return e.list(), nil
+	}
+	conf, err := http.GetRequestData(ctx)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}, err
+	}
+	name := defaultLoggerName
+	if v, ok := conf["logger"].(string); ok && v != "" {
+		name = v
+	}
+	logger := getLogger(name)
+	if logger == nil {
+		err = fmt.Errorf("logger %s not found", name)
+		return map[string]interface{}{"error": err.Error()}, err
+	}
+	levelName, _ := conf["level"].(string)
+	level, ok := name2Level[strings.ToLower(levelName)]
+	if !ok {
+		err = fmt.Errorf("invalid log level %q", levelName)
+		return map[string]interface{}{"error": err.Error()}, err
+	}
+	ttl, err := actuator.ParseTTL(conf["ttl"])
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}, err
+	}
+
+	previous := logger.GetLogLevel()
+	e.reverter.Apply(name, func() {
+		logger.SetLogLevel(level)
+	}, func() {
+		logger.SetLogLevel(previous)
+		log.DefaultLogger.Infof("[actuator][logger] log level of %s restored to %s", name, levelString(previous))
+	}, ttl)
+	log.DefaultLogger.Infof("[actuator][logger] log level of %s changed from %s to %s, ttl: %v", name, levelString(previous), levelString(level), ttl)
+
+	return map[string]interface{}{name: e.describe(name, logger)}, nil
+}
+
+func (e *Endpoint) list() map[string]interface{} {
+	loggers := map[string]interface{}{
+		defaultLoggerName: e.describe(defaultLoggerName, log.DefaultLogger),
+	}
+	actuators.RangeAllLoggers(func(name string, logger log.ErrorLogger) bool {
+		loggers[name] = e.describe(name, logger)
+		return true
+	})
+	return map[string]interface{}{"loggers": loggers}
+}
+
+func (e *Endpoint) describe(name string, logger log.ErrorLogger) map[string]interface{} {
+	desc := map[string]interface{}{"level": levelString(logger.GetLogLevel())}
+	if expireAt, ok := e.reverter.ExpireAt(name); ok {
+		desc["expire_at"] = expireAt.Format(time.RFC3339)
+	}
+	return desc
+}
+
+func getLogger(name string) log.ErrorLogger {
+	if name == defaultLoggerName {
+		return log.DefaultLogger
+	}
+	return actuators.GetLoggerWithName(name)
+}
+
+func levelString(level log.Level) string {
+	if name, ok := level2Name[level]; ok {
+		return name
+	}
+	return fmt.Sprintf("%d", level)
+}
+
+func hasRequestData(ctx context.Context) bool {
+	data, ok := ctx.Value(http.ContextKeyRequestData{}).([]byte)
+	return ok && len(strings.TrimSpace(string(data))) > 0
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package logger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"mosn.io/pkg/log"
+
+	"mosn.io/layotto/components/pkg/actuators"
+	"mosn.io/layotto/pkg/actuator"
+	"mosn.io/layotto/pkg/filter/stream/common/http"
+)
+
+func TestEndpoint(t *testing.T) {
+	ep, ok := actuator.GetDefault().GetEndpoint("logger")
+	assert.True(t, ok)
+
+	componentLogger := log.DefaultLogger
+	defer componentLogger.SetLogLevel(componentLogger.GetLogLevel())
+	componentLogger.SetLogLevel(log.INFO)
+	actuators.SetComponentLogger("test.component", componentLogger)
+
+	// list
+	result, err := ep.Handle(context.Background(), nil)
+	assert.Nil(t, err)
+	loggers := result["loggers"].(map[string]interface{})
+	assert.Contains(t, loggers, defaultLoggerName)
+	assert.Equal(t, "info", loggers["test.component"].(map[string]interface{})["level"])
+
+	// change with ttl
+	ctx := context.WithValue(context.Background(), http.ContextKeyRequestData{},
+		[]byte(`{"logger": "test.component", "level": "debug", "ttl": "50ms"}`))
+	result, err = ep.Handle(ctx, nil)
+	assert.Nil(t, err)
+	desc := result["test.component"].(map[string]interface{})
+	assert.Equal(t, "debug", desc["level"])
+	assert.Contains(t, desc, "expire_at")
+	assert.Equal(t, log.DEBUG, componentLogger.GetLogLevel())
+	assert.Eventually(t, func() bool {
+		return componentLogger.GetLogLevel() == log.INFO
+	}, time.Second, 10*time.Millisecond)
+
+	// invalid requests
+	ctx = context.WithValue(context.Background(), http.ContextKeyRequestData{},
+		[]byte(`{"logger": "not_exist", "level": "debug"}`))
+	_, err = ep.Handle(ctx, nil)
+	assert.NotNil(t, err)
+	ctx = context.WithValue(context.Background(), http.ContextKeyRequestData{},
+		[]byte(`{"logger": "test.component", "level": "verbose"}`))
+	_, err = ep.Handle(ctx, nil)
+	assert.NotNil(t, err)
+	ctx = context.WithValue(context.Background(), http.ContextKeyRequestData{},
+		[]byte(`{"logger": "test.component", "level": "debug", "ttl": "abc"}`))
+	_, err = ep.Handle(ctx, nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, log.INFO, componentLogger.GetLogLevel())
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"bytes"
+	"context"
+
+	"mosn.io/layotto/diagnostics/metrics"
+	"mosn.io/layotto/pkg/actuator"
+	"mosn.io/layotto/pkg/filter/stream/common/http"
+)
+
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// init metrics Endpoint.
+func init() {
+	actuator.GetDefault().AddEndpoint("metrics", NewEndpoint())
+}
+
+// Endpoint exposes the metrics in the prometheus text format
+type Endpoint struct {
+}
+
+func NewEndpoint() *Endpoint {
+	return &Endpoint{}
+}
+
+// HandleRaw writes the metrics of the default registry
+func (e *Endpoint) HandleRaw(ctx context.Context, params http.ParamsScanner) (string, []byte, error) {
+	buf := &bytes.Buffer{}
+	if _, err := metrics.DefaultRegistry.WriteTo(buf); err != nil {
+		return "", nil, err
+	}
+	return contentType, buf.Bytes(), nil
+}
+
+// Handle returns the metrics text in json, for the callers which don't support the raw endpoints
+func (e *Endpoint) Handle(ctx context.Context, params http.ParamsScanner) (map[string]interface{}, error) {
+	_, body, err := e.HandleRaw(ctx, params)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}, err
+	}
+	return map[string]interface{}{"metrics": string(body)}, nil
+}
@@ -0,0 +1,42 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"mosn.io/layotto/diagnostics/metrics"
+	"mosn.io/layotto/pkg/actuator"
+)
+
+func TestEndpoint(t *testing.T) {
+	ep, ok := actuator.GetDefault().GetEndpoint("metrics")
+	assert.True(t, ok)
+
+	metrics.FileBytes.Add(10, "local", "read")
+	contentType, body, err := ep.(*Endpoint).HandleRaw(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Contains(t, contentType, "text/plain")
+	assert.Contains(t, string(body), `layotto_file_bytes_total{store="local",direction="read"} 10`)
+
+	result, err := ep.Handle(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, string(body), result["metrics"])
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package actuator
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Reverter applies runtime changes and restores them automatically once their ttl expires.
+type Reverter struct {
+	mu      sync.Mutex
+	pending map[string]*pendingRevert
+}
+
+type pendingRevert struct {
+	timer    *time.Timer
+	restore  func()
+	expireAt time.Time
+}
+
+func NewReverter() *Reverter {
+	return &Reverter{
+		pending: make(map[string]*pendingRevert),
+	}
+}
+
+// Apply invokes apply to change the setting identified by key.
+// If ttl is positive, restore will be invoked when ttl expires.
+// When the setting is changed again before that, the restore of the first change is kept,
+// so the setting always goes back to the value it had before any temporary change.
+// A change without ttl is permanent and cancels the pending restore.
+func (r *Reverter) Apply(key string, apply func(), restore func(), ttl time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p, ok := r.pending[key]; ok {
+		p.timer.Stop()
+		restore = p.restore
+		delete(r.pending, key)
+	}
+	apply()
+	if ttl <= 0 {
+		return
+	}
+	p := &pendingRevert{
+		restore:  restore,
+		expireAt: time.Now().Add(ttl),
+	}
+	p.timer = time.AfterFunc(ttl, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		// the change has been overridden
+		if r.pending[key] != p {
+			return
+		}
+		delete(r.pending, key)
+		p.restore()
+	})
+	r.pending[key] = p
+}
+
+// ExpireAt returns the time when the setting will be restored.
+func (r *Reverter) ExpireAt(key string) (time.Time, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p, ok := r.pending[key]; ok {
+		return p.expireAt, true
+	}
+	return time.Time{}, false
+}
+
+// ParseTTL parses the ttl in the request body, which is either a duration string like "10m" or a number of seconds.
+func ParseTTL(v interface{}) (time.Duration, error) {
+	switch ttl := v.(type) {
+	case nil:
+		return 0, nil
+	case string:
+		if ttl == "" {
+			return 0, nil
+		}
+		d, err := time.ParseDuration(ttl)
+		if err != nil {
+			return 0, fmt.Errorf("invalid ttl %q: %v", ttl, err)
+		}
+		if d < 0 {
+			return 0, fmt.Errorf("invalid ttl %q: negative duration", ttl)
+		}
+		return d, nil
+	case float64:
+		if ttl < 0 {
+			return 0, fmt.Errorf("invalid ttl %v: negative duration", ttl)
+		}
+		return time.Duration(ttl * float64(time.Second)), nil
+	default:
+		return 0, fmt.Errorf("invalid ttl %v", v)
+	}
+}
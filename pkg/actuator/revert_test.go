@@ -0,0 +1,76 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package actuator
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReverter(t *testing.T) {
+	r := NewReverter()
+	var value int32 = 1
+	set := func(v int32) func() {
+		return func() { atomic.StoreInt32(&value, v) }
+	}
+
+	// temporary changes restore the value before the first change
+	r.Apply("k", set(2), set(1), 50*time.Millisecond)
+	r.Apply("k", set(3), set(2), 50*time.Millisecond)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&value))
+	_, ok := r.ExpireAt("k")
+	assert.True(t, ok)
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&value) == 1
+	}, time.Second, 10*time.Millisecond)
+	_, ok = r.ExpireAt("k")
+	assert.False(t, ok)
+
+	// a permanent change cancels the pending restore
+	r.Apply("k", set(4), set(1), 20*time.Millisecond)
+	r.Apply("k", set(5), set(4), 0)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(5), atomic.LoadInt32(&value))
+	_, ok = r.ExpireAt("k")
+	assert.False(t, ok)
+}
+
+func TestParseTTL(t *testing.T) {
+	d, err := ParseTTL(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), d)
+
+	d, err = ParseTTL("10m")
+	assert.Nil(t, err)
+	assert.Equal(t, 10*time.Minute, d)
+
+	d, err = ParseTTL(float64(30))
+	assert.Nil(t, err)
+	assert.Equal(t, 30*time.Second, d)
+
+	_, err = ParseTTL("abc")
+	assert.NotNil(t, err)
+	_, err = ParseTTL("-1s")
+	assert.NotNil(t, err)
+	_, err = ParseTTL(float64(-1))
+	assert.NotNil(t, err)
+	_, err = ParseTTL(true)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"mosn.io/mosn/pkg/trace"
+	"mosn.io/pkg/log"
+
+	"mosn.io/layotto/diagnostics"
+	"mosn.io/layotto/pkg/actuator"
+	"mosn.io/layotto/pkg/filter/stream/common/http"
+)
+
+const (
+	enabledKey      = "enabled"
+	samplingRateKey = "sampling_rate"
+	samplingKey     = "sampling"
+)
+
+// init tracing Endpoint.
+func init() {
+	actuator.GetDefault().AddEndpoint("tracing", NewEndpoint())
+}
+
+type Endpoint struct {
+	reverter *actuator.Reverter
+}
+
+func NewEndpoint() *Endpoint {
+	return &Endpoint{
+		reverter: actuator.NewReverter(),
+	}
+}
+
+// Handle returns the tracing status if the request has no body, otherwise it changes the status.
+// The request body is like:
+//
+//	{"enabled": true, "sampling_rate": 0.1, "sampling": {"rules": [{"method": "/spec.proto.runtime.v1.Runtime/*", "rate": 1}]}, "ttl": "10m"}
+//
+// All of enabled, sampling_rate and sampling are optional, and the changes are restored when the optional ttl expires.
+// The sampling is the same as the "sampling" item of the tracer config, see diagnostics.SamplingConfig.
+// sampling_rate and sampling are the same sampling state, so they're restored together.
+func (e *Endpoint) Handle(ctx context.Context, params http.ParamsScanner) (map[string]interface{}, error) {
+	data, _ := ctx.Value(http.ContextKeyRequestData{}).([]byte)
+	if len(data) == 0 {
+		return e.status(), nil
+	}
+	conf, err := http.GetRequestData(ctx)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}, err
+	}
+	enabled, hasEnabled := conf[enabledKey].(bool)
+	if _, ok := conf[enabledKey]; ok && !hasEnabled {
+		err = fmt.Errorf("invalid %s %v", enabledKey, conf[enabledKey])
+		return map[string]interface{}{"error": err.Error()}, err
+	}
+	rate, hasRate := conf[samplingRateKey].(float64)
+	if _, ok := conf[samplingRateKey]; ok && (!hasRate || rate < 0 || rate > 1) {
+		err = fmt.Errorf("invalid %s %v, it should be in [0, 1]", samplingRateKey, conf[samplingRateKey])
+		return map[string]interface{}{"error": err.Error()}, err
+	}
+	var sampling *diagnostics.SamplingConfig
+	if v, ok := conf[samplingKey]; ok {
+		if sampling, err = parseSamplingConfig(v); err != nil {
+			return map[string]interface{}{"error": err.Error()}, err
+		}
+	}
+	ttl, err := actuator.ParseTTL(conf["ttl"])
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}, err
+	}
+
+	if hasEnabled {
+		previous := trace.IsEnabled()
+		e.reverter.Apply(enabledKey, func() {
+			setEnabled(enabled)
+		}, func() {
+			setEnabled(previous)
+			log.DefaultLogger.Infof("[actuator][tracing] tracing enabled restored to %v", previous)
+		}, ttl)
+		log.DefaultLogger.Infof("[actuator][tracing] tracing enabled changed from %v to %v, ttl: %v", previous, enabled, ttl)
+	}
+	if hasRate || sampling != nil {
+		// the sampling config may override the rate, so both of them are kept under one key
+		previous := diagnostics.GetSamplingConfig()
+		previousRate := diagnostics.SamplingRate()
+		e.reverter.Apply(samplingKey, func() {
+			if sampling != nil {
+				_ = diagnostics.SetSamplingConfig(sampling)
+			}
+			if hasRate {
+				diagnostics.SetSamplingRate(rate)
+			}
+		}, func() {
+			_ = diagnostics.SetSamplingConfig(previous)
+			diagnostics.SetSamplingRate(previousRate)
+			log.DefaultLogger.Infof("[actuator][tracing] sampling restored, rate: %v", previousRate)
+		}, ttl)
+		log.DefaultLogger.Infof("[actuator][tracing] sampling changed, rate: %v, ttl: %v", diagnostics.SamplingRate(), ttl)
+	}
+	return e.status(), nil
+}
+
+func parseSamplingConfig(v interface{}) (*diagnostics.SamplingConfig, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	c := &diagnostics.SamplingConfig{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("invalid %s %v: %v", samplingKey, v, err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (e *Endpoint) status() map[string]interface{} {
+	result := map[string]interface{}{
+		enabledKey:      trace.IsEnabled(),
+		samplingRateKey: diagnostics.SamplingRate(),
+		samplingKey:     diagnostics.GetSamplingConfig(),
+	}
+	for _, key := range []string{enabledKey, samplingKey} {
+		if expireAt, ok := e.reverter.ExpireAt(key); ok {
+			result[key+"_expire_at"] = expireAt.Format(time.RFC3339)
+		}
+	}
+	return result
+}
+
+func setEnabled(enabled bool) {
+	if enabled {
+		trace.Enable()
+	} else {
+		trace.Disable()
+	}
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package tracing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"mosn.io/mosn/pkg/trace"
+
+	"mosn.io/layotto/diagnostics"
+	"mosn.io/layotto/pkg/actuator"
+	"mosn.io/layotto/pkg/filter/stream/common/http"
+)
+
+func TestEndpoint(t *testing.T) {
+	defer trace.Disable()
+	defer diagnostics.SetSamplingRate(1)
+	ep, ok := actuator.GetDefault().GetEndpoint("tracing")
+	assert.True(t, ok)
+
+	trace.Disable()
+	result, err := ep.Handle(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, false, result[enabledKey])
+	assert.Equal(t, float64(1), result[samplingRateKey])
+
+	// enable tracing temporarily
+	ctx := context.WithValue(context.Background(), http.ContextKeyRequestData{},
+		[]byte(`{"enabled": true, "sampling_rate": 0.1, "ttl": "50ms"}`))
+	result, err = ep.Handle(ctx, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, true, result[enabledKey])
+	assert.Equal(t, 0.1, result[samplingRateKey])
+	assert.Contains(t, result, enabledKey+"_expire_at")
+	assert.True(t, trace.IsEnabled())
+	assert.Eventually(t, func() bool {
+		return !trace.IsEnabled() && diagnostics.SamplingRate() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// change the sampling policy temporarily
+	ctx = context.WithValue(context.Background(), http.ContextKeyRequestData{},
+		[]byte(`{"sampling": {"rate": 0.2, "rules": [{"app_id": "app1", "rate": 1}]}, "ttl": "50ms"}`))
+	result, err = ep.Handle(ctx, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.2, result[samplingRateKey])
+	assert.Contains(t, result, samplingKey+"_expire_at")
+	assert.Len(t, diagnostics.GetSamplingConfig().Rules, 1)
+	assert.Eventually(t, func() bool {
+		return len(diagnostics.GetSamplingConfig().Rules) == 0 && diagnostics.SamplingRate() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// the rate and the config share the sampling state, which is restored to the value before both changes
+	ctx = context.WithValue(context.Background(), http.ContextKeyRequestData{},
+		[]byte(`{"sampling_rate": 0.3, "ttl": "1m"}`))
+	_, err = ep.Handle(ctx, nil)
+	assert.Nil(t, err)
+	ctx = context.WithValue(context.Background(), http.ContextKeyRequestData{},
+		[]byte(`{"sampling": {"rate": 0.2, "rules": [{"app_id": "app1", "rate": 1}]}, "ttl": "50ms"}`))
+	result, err = ep.Handle(ctx, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.2, result[samplingRateKey])
+	assert.Eventually(t, func() bool {
+		return len(diagnostics.GetSamplingConfig().Rules) == 0 && diagnostics.SamplingRate() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// invalid requests
+	ctx = context.WithValue(context.Background(), http.ContextKeyRequestData{},
+		[]byte(`{"sampling": {"max_per_second": -1}}`))
+	_, err = ep.Handle(ctx, nil)
+	assert.NotNil(t, err)
+	ctx = context.WithValue(context.Background(), http.ContextKeyRequestData{},
+		[]byte(`{"sampling_rate": 2}`))
+	_, err = ep.Handle(ctx, nil)
+	assert.NotNil(t, err)
+	ctx = context.WithValue(context.Background(), http.ContextKeyRequestData{},
+		[]byte(`{"enabled": "yes"}`))
+	_, err = ep.Handle(ctx, nil)
+	assert.NotNil(t, err)
+	assert.False(t, trace.IsEnabled())
+}
@@ -17,12 +17,20 @@
 package model
 
 import (
+	"time"
+
 	_type "mosn.io/layotto/pkg/filter/network/tcpcopy/type"
 )
 
 const (
 	AlertDumpKey = "DUMP"
 	LogDumpKey   = "[DUMP]"
+
+	DumpFormatText   = "text"   // the binary flow data is written as hex text into the log
+	DumpFormatBinary = "binary" // the binary flow data is written as records, see Record
+
+	DumpModeWindow     = "window"     // the traffic of all the connections is captured in the sample windows
+	DumpModeConnection = "connection" // the sampled connections are captured from the first to the last byte
 )
 
 type DumpConfig struct {
@@ -31,6 +39,17 @@ type DumpConfig struct {
 	Duration   int     `json:"duration"`     // Single sampling duration,unit: second
 	CpuMaxRate float64 `json:"cpu_max_rate"` // cpu max rate.When cpu rate bigger than this threshold,dump function will be fused
 	MemMaxRate float64 `json:"mem_max_rate"` // mem max rate.When memory rate bigger than this threshold,dump function will be fused
+	// sampling mode. 'window' or 'connection', 'window' by default
+	Mode string `json:"mode,omitempty"`
+	// the rate of the new connections to be sampled in the connection mode, (0, 1]
+	ConnectionSampleRate float64 `json:"connection_sample_rate,omitempty"`
+}
+
+type DumpFileConfig struct {
+	Format         string `json:"format"`            // dump format of the binary flow data. 'text' or 'binary', 'text' by default
+	MaxFileSizeMB  int    `json:"max_file_size_mb"`  // the binary file is rotated when its size reaches the limit, unit: MB
+	RotateInterval int    `json:"rotate_interval"`   // the binary file is rotated at the interval, unit: second
+	MaxTotalSizeMB int    `json:"max_total_size_mb"` // disk quota of the binary files, the oldest files are removed when it's exceeded, unit: MB
 }
 
 type DumpUploadDynamicConfig struct {
@@ -39,6 +58,9 @@ type DumpUploadDynamicConfig struct {
 	Port                 string             // Port
 	Binary_flow_data     []byte             // Binary data
 	Portrait_data        string             // Portrait data reported by users
+	Timestamp            time.Time          // When the data is received or written
+	ConnectionId         uint64             // Id of the connection which the binary data belongs to
+	Direction            Direction          // Direction of the binary data
 }
 
 func NewDumpUploadDynamicConfig(unique_sample_window string, businessType _type.BusinessType, port string, binary_flow_data []byte, portrait_data string) *DumpUploadDynamicConfig {
@@ -48,6 +70,8 @@ func NewDumpUploadDynamicConfig(unique_sample_window string, businessType _type.
 		Port:                 port,
 		Binary_flow_data:     binary_flow_data,
 		Portrait_data:        portrait_data,
+		Timestamp:            time.Now(),
+		Direction:            DirectionInbound,
 	}
 
 	return dynamicConfig
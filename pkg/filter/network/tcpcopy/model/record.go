@@ -0,0 +1,206 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// The binary dump file starts with RecordFileMagic and RecordFileVersion, followed by the records.
+// Each record is:
+//
+//	uint32 length of the rest of the record
+//	int64  timestamp in unix nanoseconds
+//	uint64 connection id
+//	uint8  direction, or the connection event
+//	uint16 port
+//	uint16 length of the sample window, followed by the sample window
+//	payload
+//
+// All the integers are big endian.
+const (
+	RecordFileMagic   = "LTCPDUMP"
+	RecordFileVersion = byte(1)
+
+	recordFixedSize = 8 + 8 + 1 + 2 + 2
+	// MaxRecordSize limits the size of a record to prevent reading a corrupted file from allocating too much memory
+	MaxRecordSize = 64 * 1024 * 1024
+)
+
+var (
+	ErrInvalidRecordFile = errors.New("invalid tcpcopy record file")
+	ErrRecordTooLarge    = errors.New("tcpcopy record is too large")
+)
+
+// Direction of the traffic
+type Direction uint8
+
+const (
+	// DirectionInbound is the data received from the client
+	DirectionInbound Direction = 1
+	// DirectionOutbound is the data written to the client
+	DirectionOutbound Direction = 2
+	// DirectionOpen marks the connection is opened, the record has no payload
+	DirectionOpen Direction = 3
+	// DirectionClose marks the connection is closed, the record has no payload
+	DirectionClose Direction = 4
+)
+
+func (d Direction) String() string {
+	switch d {
+	case DirectionInbound:
+		return "inbound"
+	case DirectionOutbound:
+		return "outbound"
+	case DirectionOpen:
+		return "open"
+	case DirectionClose:
+		return "close"
+	default:
+		return fmt.Sprintf("direction(%d)", uint8(d))
+	}
+}
+
+// IsEvent returns whether it's a connection event instead of the data.
+func (d Direction) IsEvent() bool {
+	return d == DirectionOpen || d == DirectionClose
+}
+
+// ParseDirection is the reverse of Direction.String.
+func ParseDirection(s string) (Direction, error) {
+	for _, d := range []Direction{DirectionInbound, DirectionOutbound, DirectionOpen, DirectionClose} {
+		if d.String() == s {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid direction %s", s)
+}
+
+// Record is a piece of traffic in the binary dump file
+type Record struct {
+	Timestamp    time.Time
+	ConnectionId uint64
+	Direction    Direction
+	Port         uint16
+	Window       string
+	Payload      []byte
+}
+
+// Size returns the number of the bytes of the encoded record.
+func (r *Record) Size() int {
+	return 4 + recordFixedSize + len(r.Window) + len(r.Payload)
+}
+
+// WriteRecordFileHeader writes the header of the binary dump file.
+func WriteRecordFileHeader(w io.Writer) (int, error) {
+	return w.Write(append([]byte(RecordFileMagic), RecordFileVersion))
+}
+
+// ReadRecordFileHeader reads and checks the header of the binary dump file.
+func ReadRecordFileHeader(r io.Reader) error {
+	header := make([]byte, len(RecordFileMagic)+1)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return ErrInvalidRecordFile
+	}
+	if !IsRecordFile(header) || header[len(RecordFileMagic)] != RecordFileVersion {
+		return ErrInvalidRecordFile
+	}
+	return nil
+}
+
+// IsRecordFile returns whether the data is the beginning of a binary dump file.
+func IsRecordFile(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(RecordFileMagic))
+}
+
+// SplitRecord splits the payload of r into the records within MaxRecordSize, which share the other fields of r.
+// r is returned as it is if it's small enough.
+func SplitRecord(r *Record) []*Record {
+	maxPayload := MaxRecordSize - recordFixedSize - len(r.Window)
+	if len(r.Payload) <= maxPayload || maxPayload <= 0 {
+		return []*Record{r}
+	}
+	var records []*Record
+	for payload := r.Payload; len(payload) > 0; {
+		n := len(payload)
+		if n > maxPayload {
+			n = maxPayload
+		}
+		part := *r
+		part.Payload = payload[:n]
+		records = append(records, &part)
+		payload = payload[n:]
+	}
+	return records
+}
+
+// WriteRecord encodes the record into w in a single Write call.
+// It returns ErrRecordTooLarge if the record exceeds MaxRecordSize, use SplitRecord to write a large payload.
+func WriteRecord(w io.Writer, r *Record) (int, error) {
+	if len(r.Window) > 0xffff {
+		return 0, fmt.Errorf("sample window is too long: %d", len(r.Window))
+	}
+	if r.Size()-4 > MaxRecordSize {
+		return 0, ErrRecordTooLarge
+	}
+	buf := make([]byte, r.Size())
+	binary.BigEndian.PutUint32(buf, uint32(len(buf)-4))
+	binary.BigEndian.PutUint64(buf[4:], uint64(r.Timestamp.UnixNano()))
+	binary.BigEndian.PutUint64(buf[12:], r.ConnectionId)
+	buf[20] = byte(r.Direction)
+	binary.BigEndian.PutUint16(buf[21:], r.Port)
+	binary.BigEndian.PutUint16(buf[23:], uint16(len(r.Window)))
+	copy(buf[25:], r.Window)
+	copy(buf[25+len(r.Window):], r.Payload)
+	return w.Write(buf)
+}
+
+// ReadRecord decodes a record from r. It returns io.EOF if there is no more record.
+func ReadRecord(r io.Reader) (*Record, error) {
+	var lengthBuf [4]byte
+	if _, err := io.ReadFull(r, lengthBuf[:]); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(lengthBuf[:])
+	if length < recordFixedSize || length > MaxRecordSize {
+		return nil, fmt.Errorf("invalid record length %d", length)
+	}
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	windowLength := int(binary.BigEndian.Uint16(buf[19:]))
+	if recordFixedSize+windowLength > len(buf) {
+		return nil, fmt.Errorf("invalid sample window length %d", windowLength)
+	}
+	return &Record{
+		Timestamp:    time.Unix(0, int64(binary.BigEndian.Uint64(buf))),
+		ConnectionId: binary.BigEndian.Uint64(buf[8:]),
+		Direction:    Direction(buf[16]),
+		Port:         binary.BigEndian.Uint16(buf[17:]),
+		Window:       string(buf[recordFixedSize : recordFixedSize+windowLength]),
+		Payload:      buf[recordFixedSize+windowLength:],
+	}, nil
+}
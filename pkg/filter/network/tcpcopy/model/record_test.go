@@ -0,0 +1,139 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecord(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := WriteRecordFileHeader(&buf)
+	assert.Nil(t, err)
+	assert.True(t, IsRecordFile(buf.Bytes()))
+
+	records := []*Record{
+		{
+			Timestamp:    time.Unix(0, 1638324000123456789),
+			ConnectionId: 1,
+			Direction:    DirectionInbound,
+			Port:         34904,
+			Window:       "window1",
+			Payload:      []byte("hello"),
+		},
+		{
+			Timestamp:    time.Unix(0, 1638324000223456789),
+			ConnectionId: 1,
+			Direction:    DirectionOutbound,
+			Port:         34904,
+			Payload:      []byte{},
+		},
+	}
+	for _, r := range records {
+		n, err := WriteRecord(&buf, r)
+		assert.Nil(t, err)
+		assert.Equal(t, r.Size(), n)
+	}
+
+	assert.Nil(t, ReadRecordFileHeader(&buf))
+	for _, want := range records {
+		got, err := ReadRecord(&buf)
+		assert.Nil(t, err)
+		assert.Equal(t, want.Timestamp.UnixNano(), got.Timestamp.UnixNano())
+		assert.Equal(t, want.ConnectionId, got.ConnectionId)
+		assert.Equal(t, want.Direction, got.Direction)
+		assert.Equal(t, want.Port, got.Port)
+		assert.Equal(t, want.Window, got.Window)
+		assert.Equal(t, want.Payload, got.Payload)
+	}
+	_, err = ReadRecord(&buf)
+	assert.Equal(t, io.EOF, err)
+
+	assert.Equal(t, "inbound", DirectionInbound.String())
+	assert.Equal(t, "outbound", DirectionOutbound.String())
+}
+
+func TestParseDirection(t *testing.T) {
+	for _, d := range []Direction{DirectionInbound, DirectionOutbound, DirectionOpen, DirectionClose} {
+		got, err := ParseDirection(d.String())
+		assert.Nil(t, err)
+		assert.Equal(t, d, got)
+	}
+	assert.False(t, DirectionInbound.IsEvent())
+	assert.True(t, DirectionClose.IsEvent())
+	_, err := ParseDirection("direction(9)")
+	assert.NotNil(t, err)
+}
+
+func TestReadRecord_invalid(t *testing.T) {
+	assert.Equal(t, ErrInvalidRecordFile, ReadRecordFileHeader(bytes.NewReader([]byte("LTCP"))))
+	assert.Equal(t, ErrInvalidRecordFile, ReadRecordFileHeader(bytes.NewReader([]byte("LTCPDUMP\x09"))))
+
+	var buf bytes.Buffer
+	_, err := WriteRecord(&buf, &Record{Window: "window", Payload: []byte("data")})
+	assert.Nil(t, err)
+	data := buf.Bytes()
+
+	// truncated
+	_, err = ReadRecord(bytes.NewReader(data[:len(data)-1]))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	_, err = ReadRecord(bytes.NewReader(data[:4]))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	// invalid length
+	_, err = ReadRecord(bytes.NewReader([]byte{0, 0, 0, 1}))
+	assert.NotNil(t, err)
+	// invalid window length
+	corrupted := append([]byte{}, data...)
+	corrupted[23] = 0xff
+	_, err = ReadRecord(bytes.NewReader(corrupted))
+	assert.NotNil(t, err)
+}
+
+func TestSplitRecord(t *testing.T) {
+	r := &Record{ConnectionId: 1, Direction: DirectionInbound, Window: "window", Payload: make([]byte, MaxRecordSize)}
+	_, err := WriteRecord(ioutil.Discard, r)
+	assert.Equal(t, ErrRecordTooLarge, err)
+
+	records := SplitRecord(r)
+	assert.Len(t, records, 2)
+	var buf bytes.Buffer
+	for _, part := range records {
+		assert.Equal(t, r.ConnectionId, part.ConnectionId)
+		assert.Equal(t, r.Window, part.Window)
+		_, err = WriteRecord(&buf, part)
+		assert.Nil(t, err)
+	}
+	var size int
+	for {
+		got, err := ReadRecord(&buf)
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		size += len(got.Payload)
+	}
+	assert.Equal(t, MaxRecordSize, size)
+
+	small := &Record{Payload: []byte("data")}
+	assert.Equal(t, []*Record{small}, SplitRecord(small))
+}
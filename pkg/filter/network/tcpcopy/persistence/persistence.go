@@ -17,10 +17,14 @@
 package persistence
 
 import (
+	"fmt"
+	"math/rand"
 	"os"
+	"strconv"
 	"sync"
 	"sync/atomic"
 
+	"mosn.io/layotto/components/pkg/actuators"
 	"mosn.io/layotto/pkg/common"
 	"mosn.io/layotto/pkg/filter/network/tcpcopy/model"
 	"mosn.io/layotto/pkg/filter/network/tcpcopy/strategy"
@@ -56,8 +60,47 @@ var (
 	memConfDumpFilePath string
 
 	initLoggerOnce sync.Once
+
+	// recordsWriter writes the binary flow data if the dump format is binary, otherwise it's nil
+	recordsWriter     *recordWriter
+	recordsWriterLock sync.RWMutex
 )
 
+// UpdateDumpFileConfig changes the format of the binary flow data, and the rotation of the binary dump files.
+func UpdateDumpFileConfig(config *model.DumpFileConfig) error {
+	var w *recordWriter
+	switch config.Format {
+	case "", model.DumpFormatText:
+	case model.DumpFormatBinary:
+		w = newRecordWriter(getLogPath(dumpBasePath), config)
+	default:
+		return fmt.Errorf("invalid dump format %s", config.Format)
+	}
+	recordsWriterLock.Lock()
+	old := recordsWriter
+	recordsWriter = w
+	recordsWriterLock.Unlock()
+	if old != nil {
+		old.Close()
+	}
+	return nil
+}
+
+func getRecordsWriter() *recordWriter {
+	recordsWriterLock.RLock()
+	defer recordsWriterLock.RUnlock()
+	return recordsWriter
+}
+
+// flushRecords writes the buffered records into the binary dump file
+func flushRecords() {
+	if w := getRecordsWriter(); w != nil {
+		if err := w.Flush(); err != nil {
+			log.DefaultLogger.Errorf("%s flush binary dump file error: %v", model.LogDumpKey, err)
+		}
+	}
+}
+
 func getMemConfDumpFilePath() string {
 	InitLogger()
 	return memConfDumpFilePath
@@ -122,6 +165,12 @@ func doInitLogger() {
 	} else {
 		portraitDataPersistence = portraitDataLogger
 	}
+
+	// expose the dump loggers so that their levels can be changed through the actuator
+	actuators.SetComponentLogger("tcpcopy.dump", tcpcopyPersistence)
+	actuators.SetComponentLogger("tcpcopy.mem_conf", memPersistence)
+	actuators.SetComponentLogger("tcpcopy.static_conf", staticConfPersistence)
+	actuators.SetComponentLogger("tcpcopy.portrait_data", portraitDataPersistence)
 }
 
 func IsPersistence() bool {
@@ -152,11 +201,40 @@ func IsPersistence() bool {
 	return true
 }
 
+// IsConnectionPersistence determines whether to continue capturing a sampled connection in the connection mode.
+// The sample window is ignored, while the connection is still not captured if the switch is off or it's fused.
+func IsConnectionPersistence() bool {
+	if !strategy.DumpSwitch {
+		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+			log.DefaultLogger.Debugf("%s the dump switch is %t", model.LogDumpKey, strategy.DumpSwitch)
+		}
+		return false
+	}
+
+	if !strategy.IsAvaliable() {
+		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+			log.DefaultLogger.Debugf("%s the system usages are beyond max rate.", model.LogDumpKey)
+		}
+		return false
+	}
+
+	return true
+}
+
+// SampleConnection determines whether to capture a new connection in the connection mode
+func SampleConnection() bool {
+	return IsConnectionPersistence() && rand.Float64() < strategy.DumpConnectionSampleRate
+}
+
 func persistence(config *model.DumpUploadDynamicConfig) {
-	// 1.Persist binary data
-	if config.Binary_flow_data != nil && config.Port != "" {
-		if GetTcpcopyLogger().GetLogLevel() >= log.INFO {
-			GetTcpcopyLogger().Infof("[%s][%s]% x", config.Unique_sample_window, config.Port, config.Binary_flow_data)
+	// 1.Persist binary data and connection events
+	if (config.Binary_flow_data != nil || config.Direction.IsEvent()) && config.Port != "" {
+		if w := getRecordsWriter(); w != nil {
+			persistRecord(w, config)
+		} else if GetTcpcopyLogger().GetLogLevel() >= log.INFO {
+			// the capture time in nanoseconds orders the chunks, as the log time is when they're written
+			GetTcpcopyLogger().Infof("[%s][%s][%d][%s][%d]% x", config.Unique_sample_window, config.Port, config.ConnectionId, config.Direction,
+				config.Timestamp.UnixNano(), config.Binary_flow_data)
 		}
 	}
 	if config.Portrait_data != "" && config.BusinessType != "" {
@@ -189,3 +267,22 @@ func persistence(config *model.DumpUploadDynamicConfig) {
 		}
 	}
 }
+
+func persistRecord(w *recordWriter, config *model.DumpUploadDynamicConfig) {
+	port, err := strconv.ParseUint(config.Port, 10, 16)
+	if err != nil {
+		log.DefaultLogger.Errorf("%s invalid port %s", model.LogDumpKey, config.Port)
+		return
+	}
+	err = w.Write(&model.Record{
+		Timestamp:    config.Timestamp,
+		ConnectionId: config.ConnectionId,
+		Direction:    config.Direction,
+		Port:         uint16(port),
+		Window:       config.Unique_sample_window,
+		Payload:      config.Binary_flow_data,
+	})
+	if err != nil {
+		log.DefaultLogger.Errorf("%s write binary dump file error: %v", model.LogDumpKey, err)
+	}
+}
@@ -122,3 +122,27 @@ func TestIsPersistence_success(t *testing.T) {
 		t.Errorf("IsPersistence() = %v, want %v", got, want)
 	}
 }
+
+func TestIsConnectionPersistence(t *testing.T) {
+	InitForTest()
+	log.DefaultLogger.SetLogLevel(log.DEBUG)
+
+	strategy.DumpSwitch = false
+	if IsConnectionPersistence() || SampleConnection() {
+		t.Errorf("IsConnectionPersistence() = true, want false when the switch is off")
+	}
+
+	// the sample window is ignored
+	strategy.DumpSwitch = true
+	strategy.DumpSampleFlag = 0
+	strategy.DumpCpuMaxRate = 100
+	strategy.DumpMemMaxRate = 100
+	if !IsConnectionPersistence() {
+		t.Errorf("IsConnectionPersistence() = false, want true")
+	}
+
+	strategy.DumpConnectionSampleRate = 1
+	if !SampleConnection() {
+		t.Errorf("SampleConnection() = false, want true")
+	}
+}
@@ -0,0 +1,195 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package persistence
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"mosn.io/layotto/pkg/filter/network/tcpcopy/model"
+)
+
+const (
+	recordFilePrefix     = "dump_tcp_copy."
+	recordFileSuffix     = ".bin"
+	recordFileTimeLayout = "20060102150405.000000000"
+
+	defaultMaxFileSizeMB  = 100
+	defaultRotateInterval = 60 * 60
+	defaultMaxTotalSizeMB = 1024
+
+	recordWriterBufferSize = 32 * 1024
+)
+
+// recordWriter writes the records into the binary dump files under dir.
+// The file is rotated when its size or age reaches the limit, and the oldest files are removed
+// when their total size exceeds the quota.
+type recordWriter struct {
+	dir            string
+	maxFileSize    int64
+	rotateInterval time.Duration
+	maxTotalSize   int64
+
+	mu       sync.Mutex
+	file     *os.File
+	buf      *bufio.Writer
+	size     int64
+	openedAt time.Time
+	// now is replaced in tests
+	now func() time.Time
+}
+
+func newRecordWriter(dir string, config *model.DumpFileConfig) *recordWriter {
+	w := &recordWriter{
+		dir:            dir,
+		maxFileSize:    int64(defaultMaxFileSizeMB) << 20,
+		rotateInterval: defaultRotateInterval * time.Second,
+		maxTotalSize:   int64(defaultMaxTotalSizeMB) << 20,
+		now:            time.Now,
+	}
+	if config.MaxFileSizeMB > 0 {
+		w.maxFileSize = int64(config.MaxFileSizeMB) << 20
+	}
+	if config.RotateInterval > 0 {
+		w.rotateInterval = time.Duration(config.RotateInterval) * time.Second
+	}
+	if config.MaxTotalSizeMB > 0 {
+		w.maxTotalSize = int64(config.MaxTotalSizeMB) << 20
+	}
+	return w
+}
+
+// Write appends the record to the current file, the data is flushed by Flush or when the file is rotated.
+// The payload larger than model.MaxRecordSize is split into several records.
+func (w *recordWriter) Write(r *model.Record) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, part := range model.SplitRecord(r) {
+		if err := w.write(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *recordWriter) write(r *model.Record) error {
+	if int64(r.Size()) > w.maxFileSize {
+		return errors.New("record is larger than the max file size")
+	}
+	if w.file != nil && (w.size+int64(r.Size()) > w.maxFileSize || w.now().Sub(w.openedAt) >= w.rotateInterval) {
+		w.closeFile()
+	}
+	if w.file == nil {
+		if err := w.openFile(); err != nil {
+			return err
+		}
+	}
+	n, err := model.WriteRecord(w.buf, r)
+	w.size += int64(n)
+	return err
+}
+
+// Flush writes the buffered records into the file.
+func (w *recordWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.buf == nil {
+		return nil
+	}
+	return w.buf.Flush()
+}
+
+// Close flushes and closes the current file.
+func (w *recordWriter) Close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closeFile()
+}
+
+func (w *recordWriter) openFile() error {
+	if err := os.MkdirAll(w.dir, 0755); err != nil {
+		return err
+	}
+	now := w.now()
+	name := filepath.Join(w.dir, recordFilePrefix+now.Format(recordFileTimeLayout)+recordFileSuffix)
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	w.file = file
+	w.buf = bufio.NewWriterSize(file, recordWriterBufferSize)
+	w.openedAt = now
+	n, err := model.WriteRecordFileHeader(w.buf)
+	w.size = int64(n)
+	if err != nil {
+		w.closeFile()
+		return err
+	}
+	// make room for the new file
+	w.removeOldFiles()
+	return nil
+}
+
+func (w *recordWriter) closeFile() {
+	if w.file == nil {
+		return
+	}
+	_ = w.buf.Flush()
+	_ = w.file.Close()
+	w.file = nil
+	w.buf = nil
+	w.size = 0
+}
+
+// removeOldFiles removes the oldest files until the total size of the files, including the max size of the current one, is within the quota
+func (w *recordWriter) removeOldFiles() {
+	files := listRecordFiles(w.dir)
+	current := w.file.Name()
+	total := w.maxFileSize
+	var old []string
+	sizes := make(map[string]int64)
+	for _, f := range files {
+		if f == current {
+			continue
+		}
+		info, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		sizes[f] = info.Size()
+		total += info.Size()
+		old = append(old, f)
+	}
+	for i := 0; i < len(old) && total > w.maxTotalSize; i++ {
+		if err := os.Remove(old[i]); err == nil {
+			total -= sizes[old[i]]
+		}
+	}
+}
+
+// listRecordFiles returns the binary dump files under dir, from the oldest to the newest
+func listRecordFiles(dir string) []string {
+	files, _ := filepath.Glob(filepath.Join(dir, recordFilePrefix+"*"+recordFileSuffix))
+	// the names contain the creation time
+	sort.Strings(files)
+	return files
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package persistence
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"mosn.io/layotto/pkg/filter/network/tcpcopy/model"
+)
+
+func readRecordFile(t *testing.T, name string) []*model.Record {
+	f, err := os.Open(name)
+	assert.Nil(t, err)
+	defer f.Close()
+	r := bufio.NewReader(f)
+	assert.Nil(t, model.ReadRecordFileHeader(r))
+	var records []*model.Record
+	for {
+		record, err := model.ReadRecord(r)
+		if err == io.EOF {
+			return records
+		}
+		assert.Nil(t, err)
+		records = append(records, record)
+	}
+}
+
+func TestRecordWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tcpcopy_records")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2021, 12, 1, 10, 0, 0, 0, time.Local)
+	w := newRecordWriter(dir, &model.DumpFileConfig{RotateInterval: 60})
+	w.now = func() time.Time {
+		return now
+	}
+	// 1MB payload, the max file size is at least 1MB
+	w.maxFileSize = 1<<20 + 1024
+	w.maxTotalSize = 3 << 20
+	newRecord := func(size int) *model.Record {
+		return &model.Record{Timestamp: now, ConnectionId: 1, Direction: model.DirectionInbound, Port: 34904, Window: "w", Payload: make([]byte, size)}
+	}
+
+	assert.Nil(t, w.Write(newRecord(1000)))
+	assert.Nil(t, w.Write(newRecord(1000)))
+	assert.Nil(t, w.Flush())
+	files := listRecordFiles(dir)
+	assert.Len(t, files, 1)
+	assert.Len(t, readRecordFile(t, files[0]), 2)
+
+	// rotated by size
+	now = now.Add(time.Second)
+	assert.Nil(t, w.Write(newRecord(1<<20)))
+	assert.Len(t, listRecordFiles(dir), 2)
+	// rotated by time
+	now = now.Add(time.Minute)
+	assert.Nil(t, w.Write(newRecord(100)))
+	files = listRecordFiles(dir)
+	assert.Len(t, files, 3)
+	assert.Len(t, readRecordFile(t, files[1]), 1)
+
+	// the oldest files are removed when the quota is exceeded
+	now = now.Add(time.Second)
+	assert.Nil(t, w.Write(newRecord(1<<20)))
+	assert.Nil(t, w.Write(newRecord(1<<20)))
+	files = listRecordFiles(dir)
+	assert.Len(t, files, 2)
+	records := readRecordFile(t, files[0])
+	assert.Len(t, records, 2)
+	assert.Equal(t, 1<<20, len(records[1].Payload))
+
+	// too large
+	assert.NotNil(t, w.Write(newRecord(2<<20)))
+	w.Close()
+	assert.Len(t, readRecordFile(t, files[1]), 1)
+}
+
+func TestUpdateDumpFileConfig(t *testing.T) {
+	InitForTest()
+	defer UpdateDumpFileConfig(&model.DumpFileConfig{})
+	assert.Nil(t, UpdateDumpFileConfig(&model.DumpFileConfig{Format: model.DumpFormatBinary}))
+	assert.NotNil(t, getRecordsWriter())
+	assert.Nil(t, UpdateDumpFileConfig(&model.DumpFileConfig{Format: model.DumpFormatText}))
+	assert.Nil(t, getRecordsWriter())
+	assert.NotNil(t, UpdateDumpFileConfig(&model.DumpFileConfig{Format: "pcap"}))
+}
@@ -21,9 +21,9 @@ import (
 	"sync"
 	"time"
 
+	"mosn.io/mosn/pkg/log"
 	"mosn.io/pkg/utils"
 
-	"mosn.io/layotto/pkg/common"
 	"mosn.io/layotto/pkg/filter/network/tcpcopy/model"
 )
 
@@ -33,38 +33,64 @@ func init() {
 
 var dumpWorkPoolInstance *DefaultWorkPool
 
+// maxWorkerTasks limits the tasks queued in a worker, the new tasks are dropped when it's reached
+const maxWorkerTasks = 10000
+
+// WorkGoroutine persists its tasks in the order they're added
 type WorkGoroutine struct {
-	tasks *sync.Map
+	lock  sync.Mutex
+	tasks []*model.DumpUploadDynamicConfig
+	// dropped is the count of the tasks dropped since the last work
+	dropped int
 }
 
 func NewWorkGoroutine() *WorkGoroutine {
-	worker := &WorkGoroutine{
-		tasks: new(sync.Map),
-	}
-	return worker
+	return &WorkGoroutine{}
 }
 
-func (g *WorkGoroutine) AddTask(key string, data *model.DumpUploadDynamicConfig) {
-	g.tasks.Store(key, data)
+// AddTask queues the data, it returns false if the queue is full and the data is dropped
+func (g *WorkGoroutine) AddTask(data *model.DumpUploadDynamicConfig) bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if len(g.tasks) >= maxWorkerTasks {
+		g.dropped++
+		return false
+	}
+	g.tasks = append(g.tasks, data)
+	return true
 }
 
 func (g *WorkGoroutine) Start() {
 	utils.GoWithRecover(func() {
 		tick := time.NewTicker(500 * time.Millisecond)
-		<-tick.C
-		g.work()
+		defer tick.Stop()
+		for range tick.C {
+			g.work()
+		}
 	}, func(r interface{}) {
 		g.Start()
 	})
 }
 
+// takeTasks removes the queued tasks and returns them in order, with the count of the tasks dropped meanwhile
+func (g *WorkGoroutine) takeTasks() ([]*model.DumpUploadDynamicConfig, int) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	tasks, dropped := g.tasks, g.dropped
+	g.tasks = nil
+	g.dropped = 0
+	return tasks, dropped
+}
+
 func (g *WorkGoroutine) work() {
-	g.tasks.Range(func(key, value interface{}) bool {
-		data := value.(*model.DumpUploadDynamicConfig)
+	tasks, dropped := g.takeTasks()
+	if dropped > 0 {
+		log.DefaultLogger.Warnf("%s the queue of the dump worker is full, %d tasks are dropped", model.LogDumpKey, dropped)
+	}
+	for _, data := range tasks {
 		persistence(data)
-		g.tasks.Delete(key)
-		return true
-	})
+	}
+	flushRecords()
 }
 
 type DefaultWorkPool struct {
@@ -94,19 +120,26 @@ func (w *DefaultWorkPool) random() int64 {
 	return w.randomInstance.Int63n(w.size)
 }
 
+// index returns the worker of the data. The data of a connection is always handled by the same worker,
+// so that its chunks are persisted in the order they're received. The ids of the connections are sequential,
+// so they're spread evenly by the modulo. The data without connection, e.g. the portrait data, goes to a random worker.
+func (w *DefaultWorkPool) index(data *model.DumpUploadDynamicConfig) int64 {
+	if data.ConnectionId == 0 {
+		return w.random()
+	}
+	return int64(data.ConnectionId % uint64(w.size))
+}
+
 func (w *DefaultWorkPool) Schedule(data *model.DumpUploadDynamicConfig) {
-	index := w.random()
-	key := common.CalculateMd5(string(data.BusinessType)) + common.CalculateMd5ForBytes(data.Binary_flow_data)
+	index := w.index(data)
 	if value, ok := w.workers.Load(index); ok {
-		worker := value.(*WorkGoroutine)
-		worker.AddTask(key, data)
-	} else {
-		worker := NewWorkGoroutine()
-		worker.AddTask(key, data)
-		if _, ok := w.workers.LoadOrStore(index, worker); !ok {
-			worker.Start()
-		} else {
-			worker.AddTask(key, data)
-		}
+		value.(*WorkGoroutine).AddTask(data)
+		return
+	}
+	value, loaded := w.workers.LoadOrStore(index, NewWorkGoroutine())
+	worker := value.(*WorkGoroutine)
+	worker.AddTask(data)
+	if !loaded {
+		worker.Start()
 	}
 }
@@ -21,6 +21,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"mosn.io/mosn/pkg/log"
 
 	"mosn.io/layotto/pkg/filter/network/tcpcopy/model"
@@ -79,10 +80,9 @@ func TestNewDefaultWorkPool(t *testing.T) {
 	workPool.workers.Range(func(key, value interface{}) bool {
 		if value, ok := workPool.workers.Load(key); ok {
 			worker := value.(*WorkGoroutine)
-			worker.tasks.Range(func(key, value interface{}) bool {
-				totalTasksCount++
-				return true
-			})
+			worker.lock.Lock()
+			totalTasksCount += len(worker.tasks)
+			worker.lock.Unlock()
 		}
 		return true
 	})
@@ -92,3 +92,49 @@ func TestNewDefaultWorkPool(t *testing.T) {
 		t.Errorf("Test_WorkPool Failed")
 	}
 }
+
+func TestDefaultWorkPoolIndex(t *testing.T) {
+	workPool := NewDefaultWorkPool(10)
+	// the data of a connection goes to the same worker
+	for i := 0; i < 10; i++ {
+		config := model.NewDumpUploadDynamicConfig("uuid", "", "12200", []byte{byte(i)}, "")
+		config.ConnectionId = 13
+		assert.Equal(t, int64(3), workPool.index(config))
+	}
+	config := model.NewDumpUploadDynamicConfig("uuid", _type.RPC, "12200", nil, "portrait")
+	index := workPool.index(config)
+	assert.True(t, index >= 0 && index < 10)
+}
+
+func TestWorkGoroutineOrder(t *testing.T) {
+	worker := NewWorkGoroutine()
+	var configs []*model.DumpUploadDynamicConfig
+	for i := 0; i < 100; i++ {
+		config := model.NewDumpUploadDynamicConfig("uuid", "", "12200", []byte{byte(i)}, "")
+		config.ConnectionId = 1
+		configs = append(configs, config)
+		worker.AddTask(config)
+	}
+	// the same data received at different time are different tasks
+	worker.AddTask(configs[0])
+	tasks, dropped := worker.takeTasks()
+	assert.Equal(t, append(configs, configs[0]), tasks)
+	assert.Equal(t, 0, dropped)
+	tasks, _ = worker.takeTasks()
+	assert.Empty(t, tasks)
+}
+
+func TestWorkGoroutineFull(t *testing.T) {
+	worker := NewWorkGoroutine()
+	config := model.NewDumpUploadDynamicConfig("uuid", "", "12200", []byte("data"), "")
+	for i := 0; i < maxWorkerTasks; i++ {
+		assert.True(t, worker.AddTask(config))
+	}
+	assert.False(t, worker.AddTask(config))
+	assert.False(t, worker.AddTask(config))
+	tasks, dropped := worker.takeTasks()
+	assert.Len(t, tasks, maxWorkerTasks)
+	assert.Equal(t, 2, dropped)
+	// the queue accepts the tasks again after it's drained
+	assert.True(t, worker.AddTask(config))
+}
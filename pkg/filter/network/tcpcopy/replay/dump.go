@@ -0,0 +1,228 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package replay
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mosn.io/layotto/pkg/filter/network/tcpcopy/model"
+)
+
+// logTimeLayout is the time layout of the mosn loggers which write the dump
+const logTimeLayout = "2006-01-02 15:04:05,000"
+
+var (
+	// a dump line is like: 2021-12-01 10:00:00,000 [INFO] [sample window][port][connection id][direction][capture time]0a 0b 0c
+	// The capture time is in nanoseconds. It's absent in the dump written by the earlier versions,
+	// and so are the connection id and the direction in the even earlier ones.
+	dumpLinePattern = regexp.MustCompile(`\[([^\[\]]+)\]\[(\d+)\](?:\[(\d+)\]\[([a-z]+)\](?:\[(\d+)\])?)?([0-9a-fA-F ]*)$`)
+	logTimePattern  = regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2},\d{3}`)
+)
+
+// Chunk is the data received by a single OnData call
+type Chunk struct {
+	Time time.Time
+	Data []byte
+}
+
+// Stream is the inbound byte stream of a port in a sample window.
+// The streams are also split by the connection if the dump contains the connection ids.
+type Stream struct {
+	Window       string
+	Port         string
+	ConnectionId uint64
+	Chunks       []*Chunk
+}
+
+func (s *Stream) key() string {
+	return s.Window + "/" + s.Port + "/" + strconv.FormatUint(s.ConnectionId, 10)
+}
+
+// Bytes returns the reassembled byte stream.
+func (s *Stream) Bytes() []byte {
+	var size int
+	for _, c := range s.Chunks {
+		size += len(c.Data)
+	}
+	data := make([]byte, 0, size)
+	for _, c := range s.Chunks {
+		data = append(data, c.Data...)
+	}
+	return data
+}
+
+// ParseDump parses the dump log written by the tcpcopy filter, and reassembles the inbound chunks into streams by the sample window, the port and the connection.
+// The streams are in the order of their first written chunks, and the chunks of a stream are sorted by time.
+func ParseDump(r io.Reader) ([]*Stream, error) {
+	var streams []*Stream
+	index := make(map[string]*Stream)
+	scanner := bufio.NewScanner(r)
+	// a chunk could be large, as it's written in hex
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), "\r ")
+		m := dumpLinePattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		s := &Stream{Window: m[1], Port: m[2]}
+		if m[3] != "" {
+			direction, err := model.ParseDirection(m[4])
+			if err != nil {
+				return nil, fmt.Errorf("invalid direction at line %d: %v", lineNo, err)
+			}
+			// only the inbound data is replayed
+			if direction != model.DirectionInbound {
+				continue
+			}
+			s.ConnectionId, err = strconv.ParseUint(m[3], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid connection id at line %d: %v", lineNo, err)
+			}
+		}
+		data, err := hex.DecodeString(strings.ReplaceAll(m[6], " ", ""))
+		if err != nil {
+			return nil, fmt.Errorf("invalid data at line %d: %v", lineNo, err)
+		}
+		chunk := &Chunk{Data: data}
+		if m[5] != "" {
+			nanos, err := strconv.ParseInt(m[5], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid capture time at line %d: %v", lineNo, err)
+			}
+			chunk.Time = time.Unix(0, nanos)
+		} else if t := logTimePattern.FindString(line); t != "" {
+			chunk.Time, _ = time.ParseInLocation(logTimeLayout, t, time.Local)
+		}
+		if exist, ok := index[s.key()]; ok {
+			s = exist
+		} else {
+			index[s.key()] = s
+			streams = append(streams, s)
+		}
+		s.Chunks = append(s.Chunks, chunk)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sortChunks(streams)
+	return streams, nil
+}
+
+// ParseRecords parses the binary dump file written in the binary format, and reassembles the inbound records into streams
+// by the sample window, the port and the connection. A truncated record at the end, e.g. of the file being written, is ignored.
+func ParseRecords(r io.Reader) ([]*Stream, error) {
+	if err := model.ReadRecordFileHeader(r); err != nil {
+		return nil, err
+	}
+	var streams []*Stream
+	index := make(map[string]*Stream)
+	for {
+		record, err := model.ReadRecord(r)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if record.Direction != model.DirectionInbound {
+			continue
+		}
+		s := &Stream{Window: record.Window, Port: strconv.Itoa(int(record.Port)), ConnectionId: record.ConnectionId}
+		if exist, ok := index[s.key()]; ok {
+			s = exist
+		} else {
+			index[s.key()] = s
+			streams = append(streams, s)
+		}
+		s.Chunks = append(s.Chunks, &Chunk{Time: record.Timestamp, Data: record.Payload})
+	}
+	return streams, nil
+}
+
+// ParseDumpFiles parses the dump files matched by the patterns, including the rotated ones like dump_tcp_copy.log.2021-12-01_10.
+// Both the text and the binary format are supported.
+// The files are parsed in the order of their names, the streams across the files are merged, and the chunks are sorted by time.
+func ParseDumpFiles(patterns ...string) ([]*Stream, error) {
+	var files []string
+	for _, p := range patterns {
+		matches, err := filepath.Glob(p)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no dump file matches %s", p)
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+
+	var streams []*Stream
+	index := make(map[string]*Stream)
+	for _, name := range files {
+		parsed, err := parseDumpFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s error: %v", name, err)
+		}
+		for _, s := range parsed {
+			if exist, ok := index[s.key()]; ok {
+				exist.Chunks = append(exist.Chunks, s.Chunks...)
+				continue
+			}
+			index[s.key()] = s
+			streams = append(streams, s)
+		}
+	}
+	// the chunks may be written out of order
+	sortChunks(streams)
+	return streams, nil
+}
+
+// sortChunks sorts the chunks of the streams by time, the chunks at the same time are kept in the written order
+func sortChunks(streams []*Stream) {
+	for _, s := range streams {
+		chunks := s.Chunks
+		sort.SliceStable(chunks, func(i, j int) bool {
+			return chunks[i].Time.Before(chunks[j].Time)
+		})
+	}
+}
+
+func parseDumpFile(name string) ([]*Stream, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	if header, _ := r.Peek(len(model.RecordFileMagic)); model.IsRecordFile(header) {
+		return ParseRecords(r)
+	}
+	return ParseDump(r)
+}
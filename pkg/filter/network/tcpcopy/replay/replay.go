@@ -0,0 +1,247 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package replay
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultDialTimeout = 3 * time.Second
+	defaultIdleTimeout = time.Second
+)
+
+// Options of the replay
+type Options struct {
+	// Target is the address which the streams are replayed against
+	Target string
+	// Baseline is the optional address which the streams are replayed against too, and its responses are compared with the target's
+	Baseline string
+	// Speed scales the intervals between the chunks, e.g. 2 replays twice as fast as recorded, and 0 sends the chunks without waiting
+	Speed float64
+	// IdleTimeout is how long to wait for more response data after the last byte received
+	IdleTimeout time.Duration
+	// DialTimeout is the timeout to connect the target
+	DialTimeout time.Duration
+}
+
+// Response of a stream from an address
+type Response struct {
+	Data []byte
+	// Latency is the time from the first byte sent to the first byte received
+	Latency time.Duration
+	// Duration is the time from the connection established to the response completed
+	Duration time.Duration
+	Err      error
+}
+
+// Result of replaying a stream
+type Result struct {
+	Stream   *Stream
+	Sent     int
+	Target   *Response
+	Baseline *Response
+	// DiffOffset is the first offset where the responses of the target and the baseline differ, -1 if they are the same
+	DiffOffset int
+}
+
+// Replayer replays the streams parsed from the dump
+type Replayer struct {
+	opts Options
+}
+
+func NewReplayer(opts Options) (*Replayer, error) {
+	if opts.Target == "" {
+		return nil, errors.New("target address is required")
+	}
+	if opts.Speed < 0 {
+		return nil, errors.New("speed should not be negative")
+	}
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = defaultIdleTimeout
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = defaultDialTimeout
+	}
+	return &Replayer{opts: opts}, nil
+}
+
+// Replay replays the streams one by one, and the callback is invoked with the result of each stream.
+func (r *Replayer) Replay(streams []*Stream, callback func(*Result)) []*Result {
+	results := make([]*Result, 0, len(streams))
+	for _, s := range streams {
+		result := r.ReplayStream(s)
+		if callback != nil {
+			callback(result)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// ReplayStream replays a stream against the target, and the baseline if it's set.
+func (r *Replayer) ReplayStream(s *Stream) *Result {
+	result := &Result{Stream: s, DiffOffset: -1}
+	for _, c := range s.Chunks {
+		result.Sent += len(c.Data)
+	}
+	if r.opts.Baseline == "" {
+		result.Target = r.send(r.opts.Target, s)
+		return result
+	}
+	// replay against both at the same time, so that they see the same timing
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		result.Target = r.send(r.opts.Target, s)
+	}()
+	go func() {
+		defer wg.Done()
+		result.Baseline = r.send(r.opts.Baseline, s)
+	}()
+	wg.Wait()
+	result.DiffOffset = diffOffset(result.Target.Data, result.Baseline.Data)
+	return result
+}
+
+func (r *Replayer) send(address string, s *Stream) *Response {
+	resp := &Response{}
+	conn, err := net.DialTimeout("tcp", address, r.opts.DialTimeout)
+	if err != nil {
+		resp.Err = err
+		return resp
+	}
+	defer conn.Close()
+
+	begin := time.Now()
+	var firstSent time.Time
+	var firstReceived time.Time
+	var buf bytes.Buffer
+	// the read deadline is only set when all the chunks are sent, as the chunks may be sent slowly
+	var sendDone int32
+	readDone := make(chan error, 1)
+	go func() {
+		data := make([]byte, 32*1024)
+		for {
+			n, err := conn.Read(data)
+			if n > 0 {
+				if firstReceived.IsZero() {
+					firstReceived = time.Now()
+				}
+				buf.Write(data[:n])
+				// wait for more data until idle timeout
+				if atomic.LoadInt32(&sendDone) == 1 {
+					_ = conn.SetReadDeadline(time.Now().Add(r.opts.IdleTimeout))
+				}
+			}
+			if err != nil {
+				readDone <- err
+				return
+			}
+		}
+	}()
+
+	var last time.Time
+	for _, c := range s.Chunks {
+		r.wait(last, c.Time)
+		if !c.Time.IsZero() {
+			last = c.Time
+		}
+		if firstSent.IsZero() {
+			firstSent = time.Now()
+		}
+		if _, err = conn.Write(c.Data); err != nil {
+			resp.Err = err
+			break
+		}
+	}
+	// the read deadline is extended once data arrives, or it's the deadline for the first byte
+	atomic.StoreInt32(&sendDone, 1)
+	_ = conn.SetReadDeadline(time.Now().Add(r.opts.IdleTimeout))
+	if err := <-readDone; resp.Err == nil && !isTimeout(err) && !errors.Is(err, io.EOF) {
+		resp.Err = err
+	}
+
+	resp.Data = buf.Bytes()
+	resp.Duration = time.Since(begin)
+	if !firstReceived.IsZero() && !firstSent.IsZero() {
+		resp.Latency = firstReceived.Sub(firstSent)
+	}
+	return resp
+}
+
+// wait sleeps for the scaled interval between the chunks recorded at last and next
+func (r *Replayer) wait(last, next time.Time) {
+	if r.opts.Speed <= 0 || last.IsZero() || next.IsZero() || !next.After(last) {
+		return
+	}
+	time.Sleep(time.Duration(float64(next.Sub(last)) / r.opts.Speed))
+}
+
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+func diffOffset(a, b []byte) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	if len(a) != len(b) {
+		return n
+	}
+	return -1
+}
+
+// Run parses the dump files, replays the streams of the window and the port if they're not empty, and writes the report to w.
+func Run(w io.Writer, dumpFiles []string, window, port string, opts Options) error {
+	replayer, err := NewReplayer(opts)
+	if err != nil {
+		return err
+	}
+	streams, err := ParseDumpFiles(dumpFiles...)
+	if err != nil {
+		return err
+	}
+	selected := streams[:0]
+	for _, s := range streams {
+		if (window == "" || s.Window == window) && (port == "" || s.Port == port) {
+			selected = append(selected, s)
+		}
+	}
+	if len(selected) == 0 {
+		return errors.New("no stream to replay")
+	}
+	results := replayer.Replay(selected, func(r *Result) {
+		WriteResult(w, r)
+	})
+	WriteSummary(w, Summarize(results), opts.Baseline != "")
+	return nil
+}
@@ -0,0 +1,255 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package replay
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"mosn.io/layotto/pkg/filter/network/tcpcopy/model"
+)
+
+const testDump = `2021-12-01 10:00:00,000 [INFO] [window1][34904]68 65 6c
+2021-12-01 10:00:00,100 [INFO] [window1][34904]6c 6f
+2021-12-01 10:00:00,200 [INFO] [window1][34905]61 62 63
+this line is not a dump
+2021-12-01 10:00:05,000 [INFO] [window2][34904]78 79 7a
+`
+
+func TestParseDump(t *testing.T) {
+	streams, err := ParseDump(strings.NewReader(testDump))
+	assert.Nil(t, err)
+	assert.Len(t, streams, 3)
+
+	assert.Equal(t, "window1", streams[0].Window)
+	assert.Equal(t, "34904", streams[0].Port)
+	assert.Equal(t, []byte("hello"), streams[0].Bytes())
+	assert.Len(t, streams[0].Chunks, 2)
+	assert.Equal(t, 100*time.Millisecond, streams[0].Chunks[1].Time.Sub(streams[0].Chunks[0].Time))
+
+	assert.Equal(t, "34905", streams[1].Port)
+	assert.Equal(t, []byte("abc"), streams[1].Bytes())
+	assert.Equal(t, "window2", streams[2].Window)
+	assert.Equal(t, []byte("xyz"), streams[2].Bytes())
+
+	_, err = ParseDump(strings.NewReader("[window][1]6"))
+	assert.NotNil(t, err)
+}
+
+const testConnectionDump = `2021-12-01 10:00:00,000 [INFO] [window1][34904][7][open]
+2021-12-01 10:00:00,001 [INFO] [window1][34904][7][inbound]68 65 6c
+2021-12-01 10:00:00,002 [INFO] [window1][34904][8][inbound]61 62 63
+2021-12-01 10:00:00,003 [INFO] [window1][34904][7][outbound]6f 6b
+2021-12-01 10:00:00,004 [INFO] [window1][34904][7][inbound]6c 6f
+2021-12-01 10:00:00,005 [INFO] [window1][34904][7][close]
+`
+
+func TestParseDump_connection(t *testing.T) {
+	streams, err := ParseDump(strings.NewReader(testConnectionDump))
+	assert.Nil(t, err)
+	assert.Len(t, streams, 2)
+	assert.Equal(t, uint64(7), streams[0].ConnectionId)
+	assert.Equal(t, "34904", streams[0].Port)
+	assert.Equal(t, []byte("hello"), streams[0].Bytes())
+	assert.Equal(t, uint64(8), streams[1].ConnectionId)
+	assert.Equal(t, []byte("abc"), streams[1].Bytes())
+
+	_, err = ParseDump(strings.NewReader("[window][1][7][sideways]61"))
+	assert.NotNil(t, err)
+}
+
+// the chunks are written out of order
+const testCaptureTimeDump = `2021-12-01 10:00:00,010 [INFO] [window1][34904][7][inbound][1638324000000000002]6c 6f
+2021-12-01 10:00:00,010 [INFO] [window1][34904][7][inbound][1638324000000000001]68 65 6c
+2021-12-01 10:00:00,011 [INFO] [window1][34904][8][inbound]61 62 63
+`
+
+func TestParseDump_captureTime(t *testing.T) {
+	streams, err := ParseDump(strings.NewReader(testCaptureTimeDump))
+	assert.Nil(t, err)
+	assert.Len(t, streams, 2)
+	assert.Equal(t, []byte("hello"), streams[0].Bytes())
+	assert.Equal(t, time.Unix(0, 1638324000000000001), streams[0].Chunks[0].Time)
+	// the log time is used if there is no capture time
+	assert.Equal(t, []byte("abc"), streams[1].Bytes())
+	assert.Equal(t, 11*time.Millisecond, streams[1].Chunks[0].Time.Sub(time.Date(2021, 12, 1, 10, 0, 0, 0, time.Local)))
+
+	_, err = ParseDump(strings.NewReader("[window][1][7][inbound][99999999999999999999]61"))
+	assert.NotNil(t, err)
+}
+
+func TestParseDumpFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tcpcopy_replay")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// the rotated file is older
+	err = ioutil.WriteFile(filepath.Join(dir, "dump_tcp_copy.log"), []byte("[window1][34904]6c 6f\n"), 0644)
+	assert.Nil(t, err)
+	err = ioutil.WriteFile(filepath.Join(dir, "dump_tcp_copy.log.2021-12-01_10"), []byte("[window1][34904]68 65 6c\n"), 0644)
+	assert.Nil(t, err)
+	streams, err := ParseDumpFiles(filepath.Join(dir, "dump_tcp_copy.log*"))
+	assert.Nil(t, err)
+	assert.Len(t, streams, 1)
+	// sorted by name, the file without suffix comes first
+	assert.Equal(t, []byte("lohel"), streams[0].Bytes())
+
+	_, err = ParseDumpFiles(filepath.Join(dir, "not_exist.log"))
+	assert.NotNil(t, err)
+}
+
+func TestParseDumpFiles_binary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tcpcopy_replay")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	var buf bytes.Buffer
+	_, err = model.WriteRecordFileHeader(&buf)
+	assert.Nil(t, err)
+	// the records may be written out of order
+	records := []*model.Record{
+		{Timestamp: now.Add(time.Millisecond), ConnectionId: 1, Direction: model.DirectionInbound, Port: 34904, Window: "w", Payload: []byte("lo")},
+		{Timestamp: now, ConnectionId: 1, Direction: model.DirectionInbound, Port: 34904, Window: "w", Payload: []byte("hel")},
+		{Timestamp: now, ConnectionId: 2, Direction: model.DirectionInbound, Port: 34904, Window: "w", Payload: []byte("abc")},
+		{Timestamp: now, ConnectionId: 1, Direction: model.DirectionOutbound, Port: 34904, Window: "w", Payload: []byte("ok")},
+	}
+	for _, r := range records {
+		_, err = model.WriteRecord(&buf, r)
+		assert.Nil(t, err)
+	}
+	// a truncated record at the end
+	buf.Write([]byte{0, 0, 1})
+	name := filepath.Join(dir, "dump_tcp_copy.20211201100000.000000000.bin")
+	assert.Nil(t, ioutil.WriteFile(name, buf.Bytes(), 0644))
+
+	streams, err := ParseDumpFiles(filepath.Join(dir, "*.bin"))
+	assert.Nil(t, err)
+	assert.Len(t, streams, 2)
+	assert.Equal(t, uint64(1), streams[0].ConnectionId)
+	assert.Equal(t, "34904", streams[0].Port)
+	assert.Equal(t, []byte("hello"), streams[0].Bytes())
+	assert.Equal(t, uint64(2), streams[1].ConnectionId)
+	assert.Equal(t, []byte("abc"), streams[1].Bytes())
+}
+
+// serve starts a server which responds the data received with prefix
+func serve(t *testing.T, prefix string) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				buf := make([]byte, 1024)
+				for {
+					n, err := conn.Read(buf)
+					if err != nil {
+						return
+					}
+					conn.Write(append([]byte(prefix), buf[:n]...))
+				}
+			}(conn)
+		}
+	}()
+	t.Cleanup(func() {
+		lis.Close()
+	})
+	return lis.Addr().String()
+}
+
+func TestReplay(t *testing.T) {
+	target := serve(t, "")
+	baseline := serve(t, ">")
+	streams, err := ParseDump(strings.NewReader(testDump))
+	assert.Nil(t, err)
+
+	r, err := NewReplayer(Options{Target: target, IdleTimeout: 100 * time.Millisecond})
+	assert.Nil(t, err)
+	result := r.ReplayStream(streams[1])
+	assert.Nil(t, result.Target.Err)
+	assert.Equal(t, 3, result.Sent)
+	assert.Equal(t, []byte("abc"), result.Target.Data)
+	assert.True(t, result.Target.Latency > 0)
+	assert.Nil(t, result.Baseline)
+	assert.Equal(t, -1, result.DiffOffset)
+
+	// the chunks are sent with the recorded interval
+	r, err = NewReplayer(Options{Target: target, Baseline: baseline, Speed: 1, IdleTimeout: 100 * time.Millisecond})
+	assert.Nil(t, err)
+	result = r.ReplayStream(streams[0])
+	assert.Nil(t, result.Target.Err)
+	assert.Nil(t, result.Baseline.Err)
+	assert.Equal(t, []byte("hello"), result.Target.Data)
+	assert.Equal(t, 0, result.DiffOffset)
+	assert.True(t, result.Target.Duration >= 100*time.Millisecond)
+
+	// the target is unavailable
+	r, err = NewReplayer(Options{Target: "127.0.0.1:1"})
+	assert.Nil(t, err)
+	result = r.ReplayStream(streams[0])
+	assert.NotNil(t, result.Target.Err)
+	s := Summarize([]*Result{result})
+	assert.Equal(t, 1, s.Errors)
+
+	_, err = NewReplayer(Options{})
+	assert.NotNil(t, err)
+	_, err = NewReplayer(Options{Target: target, Speed: -1})
+	assert.NotNil(t, err)
+}
+
+func TestRun(t *testing.T) {
+	target := serve(t, "")
+	dir, err := ioutil.TempDir("", "tcpcopy_replay")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	dump := filepath.Join(dir, "dump_tcp_copy.log")
+	assert.Nil(t, ioutil.WriteFile(dump, []byte(testDump), 0644))
+
+	var out bytes.Buffer
+	err = Run(&out, []string{dump}, "window1", "", Options{Target: target, Baseline: target, IdleTimeout: 100 * time.Millisecond})
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 4)
+	assert.True(t, strings.HasPrefix(lines[0], "[window1][34904] sent=5"))
+	assert.True(t, strings.HasSuffix(lines[0], "same"))
+	assert.True(t, strings.HasPrefix(lines[2], "streams=2 errors=0"))
+	assert.True(t, strings.HasPrefix(lines[3], "diffs=0"))
+
+	err = Run(&out, []string{dump}, "window3", "", Options{Target: target})
+	assert.NotNil(t, err)
+}
+
+func TestPercentile(t *testing.T) {
+	assert.Equal(t, time.Duration(0), percentile(nil, 0.5))
+	values := []time.Duration{5, 1, 3, 2, 4}
+	assert.Equal(t, time.Duration(3), percentile(values, 0.5))
+	assert.Equal(t, time.Duration(5), percentile(values, 0.99))
+	assert.Equal(t, time.Duration(1), percentile(values, 0))
+}
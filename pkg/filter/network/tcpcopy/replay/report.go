@@ -0,0 +1,116 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package replay
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Summary of the replay
+type Summary struct {
+	Streams int
+	Errors  int
+	// Diffs is the number of the streams whose responses differ between the target and the baseline
+	Diffs      int
+	LatencyP50 time.Duration
+	LatencyP99 time.Duration
+	LatencyMax time.Duration
+	// BaselineLatencyP50 is 0 if there is no baseline
+	BaselineLatencyP50 time.Duration
+	BaselineLatencyP99 time.Duration
+}
+
+// Summarize counts the errors and the diffs, and calculates the latency percentiles of the results.
+func Summarize(results []*Result) *Summary {
+	s := &Summary{Streams: len(results)}
+	var latencies, baselineLatencies []time.Duration
+	for _, r := range results {
+		if r.Target.Err != nil || (r.Baseline != nil && r.Baseline.Err != nil) {
+			s.Errors++
+		}
+		if r.DiffOffset >= 0 {
+			s.Diffs++
+		}
+		if r.Target.Err == nil {
+			latencies = append(latencies, r.Target.Latency)
+		}
+		if r.Baseline != nil && r.Baseline.Err == nil {
+			baselineLatencies = append(baselineLatencies, r.Baseline.Latency)
+		}
+	}
+	s.LatencyP50 = percentile(latencies, 0.5)
+	s.LatencyP99 = percentile(latencies, 0.99)
+	s.LatencyMax = percentile(latencies, 1)
+	s.BaselineLatencyP50 = percentile(baselineLatencies, 0.5)
+	s.BaselineLatencyP99 = percentile(baselineLatencies, 0.99)
+	return s
+}
+
+func percentile(values []time.Duration, p float64) time.Duration {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i] < values[j]
+	})
+	idx := int(float64(len(values))*p+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(values) {
+		idx = len(values) - 1
+	}
+	return values[idx]
+}
+
+// WriteResult writes a line for the result of a stream.
+func WriteResult(w io.Writer, r *Result) {
+	line := fmt.Sprintf("[%s][%s]", r.Stream.Window, r.Stream.Port)
+	if r.Stream.ConnectionId != 0 {
+		line += fmt.Sprintf("[%d]", r.Stream.ConnectionId)
+	}
+	line += fmt.Sprintf(" sent=%d %s", r.Sent, formatResponse("target", r.Target))
+	if r.Baseline != nil {
+		line += " " + formatResponse("baseline", r.Baseline)
+		if r.DiffOffset >= 0 {
+			line += fmt.Sprintf(" diff_offset=%d", r.DiffOffset)
+		} else {
+			line += " same"
+		}
+	}
+	fmt.Fprintln(w, line)
+}
+
+func formatResponse(name string, resp *Response) string {
+	if resp.Err != nil {
+		return fmt.Sprintf("%s_error=%q", name, resp.Err.Error())
+	}
+	return fmt.Sprintf("%s_received=%d %s_latency=%v", name, len(resp.Data), name, resp.Latency)
+}
+
+// WriteSummary writes the summary of the replay.
+func WriteSummary(w io.Writer, s *Summary, withBaseline bool) {
+	fmt.Fprintf(w, "streams=%d errors=%d latency_p50=%v latency_p99=%v latency_max=%v\n",
+		s.Streams, s.Errors, s.LatencyP50, s.LatencyP99, s.LatencyMax)
+	if withBaseline {
+		fmt.Fprintf(w, "diffs=%d baseline_latency_p50=%v baseline_latency_p99=%v\n",
+			s.Diffs, s.BaselineLatencyP50, s.BaselineLatencyP99)
+	}
+}
@@ -37,6 +37,8 @@ const (
 
 	defaultDuration = 1
 
+	defaultConnectionSampleRate = 0.1
+
 	kindOn       = "ON"        // ON
 	kindOff      = "OFF"       // OFF
 	kindForceOff = "FORCE_OFF" // Forced shutdown
@@ -80,6 +82,12 @@ var (
 	// Dump uuid
 	DumpSampleUuid = "inituuid"
 
+	// Sampling mode, model.DumpModeWindow or model.DumpModeConnection
+	DumpMode = model.DumpModeWindow
+
+	// The rate of the new connections to be sampled in the connection mode
+	DumpConnectionSampleRate = defaultConnectionSampleRate
+
 	// Sampling status of different Business
 	DumpBusinessCache = new(sync.Map)
 
@@ -122,6 +130,10 @@ func UpdateAppDumpConfig(value string) bool {
 		log.DefaultLogger.Alertf("dump", "[dumpConfig] update app dump config failed, the mem_max_rate should be between %v and %v, value=%s", 0, 100, value)
 		return false
 	}
+	if !validateMode(&temp) {
+		log.DefaultLogger.Alertf("dump", "[dumpConfig] update app dump config failed, the mode or the connection_sample_rate is illegal, value=%s", value)
+		return false
+	}
 	// publish config
 	appDumpConfig = &temp
 	updateDumpConfig()
@@ -165,6 +177,10 @@ func UpdateGlobalDumpConfig(value string) bool {
 		log.DefaultLogger.Alertf("dump", "[dumpConfig] update global dump config failed, the mem_max_rate should be between %v and %v, value=%s", 0, 100, value)
 		return false
 	}
+	if !validateMode(&temp) {
+		log.DefaultLogger.Alertf("dump", "[dumpConfig] update global dump config failed, the mode or the connection_sample_rate is illegal, value=%s", value)
+		return false
+	}
 	// publish config
 	globalDumpConfig = &temp
 	updateDumpConfig()
@@ -172,12 +188,28 @@ func UpdateGlobalDumpConfig(value string) bool {
 	return true
 }
 
+// validateMode checks the sampling mode, and fills the default connection sample rate
+func validateMode(config *model.DumpConfig) bool {
+	switch config.Mode {
+	case "", model.DumpModeWindow:
+		return true
+	case model.DumpModeConnection:
+		if config.ConnectionSampleRate == 0 {
+			config.ConnectionSampleRate = defaultConnectionSampleRate
+		}
+		return config.ConnectionSampleRate > 0 && config.ConnectionSampleRate <= 1
+	default:
+		return false
+	}
+}
+
 func updateDumpConfig() {
 	DumpSwitch = isDumpSwitchOpen()
 	DumpCpuMaxRate = getDumpCpuMaxRate()
 	DumpMemMaxRate = getDumpMemMaxRate()
 	DumpInterval = getDumpInterval()
 	DumpDuration = getDumpDuration()
+	DumpMode, DumpConnectionSampleRate = getDumpMode()
 
 	if DumpSwitch {
 		initOnce.Do(func() {
@@ -228,6 +260,17 @@ func getDumpDuration() int {
 	return globalDumpConfig.Duration
 }
 
+func getDumpMode() (string, float64) {
+	config := globalDumpConfig
+	if globalDumpConfig.Switch != kindForceOff && appDumpConfig.Switch == kindOn {
+		config = appDumpConfig
+	}
+	if config.Mode != model.DumpModeConnection {
+		return model.DumpModeWindow, defaultConnectionSampleRate
+	}
+	return config.Mode, config.ConnectionSampleRate
+}
+
 func getDumpCpuMaxRate() float64 {
 	global := globalDumpConfig.Switch
 	if global == kindForceOff {
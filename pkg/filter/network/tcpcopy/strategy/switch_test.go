@@ -87,6 +87,16 @@ func TestUpdateAppDumpConfig_invalid_value(t *testing.T) {
 			args: struct{ value string }{value: "{\"switch\":\"ON\",\"interval\":30,\"duration\":10,\"cpu_max_rate\":80,\"mem_max_rate\":101}"},
 			want: false,
 		},
+		{
+			name: "invalid mode",
+			args: struct{ value string }{value: "{\"switch\":\"ON\",\"interval\":30,\"duration\":10,\"cpu_max_rate\":80,\"mem_max_rate\":80,\"mode\":\"test\"}"},
+			want: false,
+		},
+		{
+			name: "connection sample rate larger than 1",
+			args: struct{ value string }{value: "{\"switch\":\"ON\",\"interval\":30,\"duration\":10,\"cpu_max_rate\":80,\"mem_max_rate\":80,\"mode\":\"connection\",\"connection_sample_rate\":2}"},
+			want: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -241,6 +251,24 @@ func TestUpdateGlobalDumpConfig_success(t *testing.T) {
 	}
 }
 
+func TestUpdateAppDumpConfig_mode(t *testing.T) {
+	defer UpdateAppDumpConfig("{\"switch\":\"OFF\",\"interval\":30,\"duration\":10,\"cpu_max_rate\":80,\"mem_max_rate\":80}")
+
+	check := func(wantMode string, wantRate float64) {
+		t.Helper()
+		if DumpMode != wantMode || DumpConnectionSampleRate != wantRate {
+			t.Errorf("DumpMode = %v, DumpConnectionSampleRate = %v, want %v, %v", DumpMode, DumpConnectionSampleRate, wantMode, wantRate)
+		}
+	}
+	UpdateAppDumpConfig("{\"switch\":\"ON\",\"interval\":30,\"duration\":10,\"cpu_max_rate\":80,\"mem_max_rate\":80,\"mode\":\"connection\",\"connection_sample_rate\":0.5}")
+	check("connection", 0.5)
+	// the default rate
+	UpdateAppDumpConfig("{\"switch\":\"ON\",\"interval\":30,\"duration\":10,\"cpu_max_rate\":80,\"mem_max_rate\":80,\"mode\":\"connection\"}")
+	check("connection", defaultConnectionSampleRate)
+	UpdateAppDumpConfig("{\"switch\":\"ON\",\"interval\":30,\"duration\":10,\"cpu_max_rate\":80,\"mem_max_rate\":80}")
+	check("window", defaultConnectionSampleRate)
+}
+
 func Test_isDumpSwitchOpen(t *testing.T) {
 	globalDumpConfig.Switch = "OFF"
 	appDumpConfig.Switch = "invalid"
@@ -26,6 +26,7 @@ import (
 	"mosn.io/api"
 	v2 "mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
 	"mosn.io/pkg/log"
 
 	"mosn.io/layotto/pkg/filter/network/tcpcopy/model"
@@ -60,6 +61,21 @@ func CreateTcpcopyFactory(cfg map[string]interface{}) (api.NetworkFilterChainFac
 			strategy.UpdateAppDumpConfig(string(data))
 		}
 	}
+	// Parse the format of the dump files
+	if dump, ok := cfg["dump"]; ok {
+		fileConfig := &model.DumpFileConfig{}
+		data, err := json.Marshal(dump)
+		if err == nil {
+			err = json.Unmarshal(data, fileConfig)
+		}
+		if err == nil {
+			err = persistence.UpdateDumpFileConfig(fileConfig)
+		}
+		if err != nil {
+			log.DefaultLogger.Errorf("tcpcopy parse dump config error: %v", err)
+			return nil, err
+		}
+	}
 	// TODO extract some other fields
 	return &tcpcopyFactory{
 		cfg: tcpConfig,
@@ -97,24 +113,97 @@ func (f *tcpcopyFactory) Init(param interface{}) error {
 }
 
 func (f *tcpcopyFactory) CreateFilterChain(context context.Context, callbacks api.NetWorkFilterChainFactoryCallbacks) {
-	callbacks.AddReadFilter(f)
+	filter := newTcpcopyFilter(f.cfg)
+	callbacks.AddReadFilter(filter)
+	callbacks.AddWriteFilter(filter)
+}
+
+// tcpcopyFilter captures the data received from and written to a connection, as well as its open and close events
+type tcpcopyFilter struct {
+	cfg    *config
+	connId uint64
+	// sampled is whether the connection is captured in the connection mode
+	sampled bool
+	// window is the sample window when the connection is sampled
+	window string
 }
 
-func (f *tcpcopyFactory) OnData(data types.IoBuffer) (res api.FilterStatus) {
-	// Determine whether to continue sampling
-	if !persistence.IsPersistence() {
+func newTcpcopyFilter(cfg *config) *tcpcopyFilter {
+	return &tcpcopyFilter{
+		cfg: cfg,
+	}
+}
+
+func (f *tcpcopyFilter) OnData(data types.IoBuffer) (res api.FilterStatus) {
+	if !f.isPersistence() {
 		return api.Continue
 	}
+	// The buffer is reused after OnData returns, so the data is copied
+	payload := make([]byte, data.Len())
+	copy(payload, data.Bytes())
+	f.schedule(model.DirectionInbound, payload)
+	return api.Continue
+}
 
-	// Asynchronous sampling
-	config := model.NewDumpUploadDynamicConfig(strategy.DumpSampleUuid, "", f.cfg.port, data.Bytes(), "")
-	persistence.GetDumpWorkPoolInstance().Schedule(config)
+func (f *tcpcopyFilter) OnWrite(buffers []buffer.IoBuffer) api.FilterStatus {
+	if !f.isPersistence() {
+		return api.Continue
+	}
+	var size int
+	for _, b := range buffers {
+		size += b.Len()
+	}
+	payload := make([]byte, 0, size)
+	for _, b := range buffers {
+		payload = append(payload, b.Bytes()...)
+	}
+	f.schedule(model.DirectionOutbound, payload)
 	return api.Continue
 }
 
-func (f *tcpcopyFactory) OnNewConnection() api.FilterStatus {
+func (f *tcpcopyFilter) OnNewConnection() api.FilterStatus {
+	if strategy.DumpMode == model.DumpModeConnection && persistence.SampleConnection() {
+		f.sampled = true
+		f.window = strategy.DumpSampleUuid
+	}
+	if f.isPersistence() {
+		f.schedule(model.DirectionOpen, nil)
+	}
 	return api.Continue
 }
 
-func (f *tcpcopyFactory) InitializeReadFilterCallbacks(cb api.ReadFilterCallbacks) {
+func (f *tcpcopyFilter) InitializeReadFilterCallbacks(cb api.ReadFilterCallbacks) {
+	conn := cb.Connection()
+	if conn == nil {
+		return
+	}
+	f.connId = conn.ID()
+	conn.AddConnectionEventListener(f)
+}
+
+// OnEvent captures the close event of the connection
+func (f *tcpcopyFilter) OnEvent(event api.ConnectionEvent) {
+	if event.IsClose() && f.isPersistence() {
+		f.schedule(model.DirectionClose, nil)
+	}
+}
+
+// isPersistence determines whether to capture the traffic of the connection now
+func (f *tcpcopyFilter) isPersistence() bool {
+	if f.sampled {
+		return persistence.IsConnectionPersistence()
+	}
+	return strategy.DumpMode == model.DumpModeWindow && persistence.IsPersistence()
+}
+
+// schedule persists the data asynchronously
+func (f *tcpcopyFilter) schedule(direction model.Direction, payload []byte) {
+	window := strategy.DumpSampleUuid
+	if f.sampled {
+		window = f.window
+	}
+	config := model.NewDumpUploadDynamicConfig(window, "", f.cfg.port, payload, "")
+	config.ConnectionId = f.connId
+	config.Direction = direction
+	persistence.GetDumpWorkPoolInstance().Schedule(config)
 }
@@ -24,6 +24,7 @@ import (
 	"mosn.io/mosn/pkg/types"
 	"mosn.io/pkg/buffer"
 
+	"mosn.io/layotto/pkg/filter/network/tcpcopy/model"
 	"mosn.io/layotto/pkg/filter/network/tcpcopy/strategy"
 )
 
@@ -60,7 +61,7 @@ func TestCreateTcpcopyFactory(t *testing.T) {
 	}
 }
 
-func Test_tcpcopyFactory_OnData_switch_off(t *testing.T) {
+func Test_tcpcopyFilter_OnData_switch_off(t *testing.T) {
 	strategy.DumpSwitch = false
 
 	type fields struct {
@@ -84,9 +85,7 @@ func Test_tcpcopyFactory_OnData_switch_off(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			f := &tcpcopyFactory{
-				cfg: tt.fields.tcpcopy,
-			}
+			f := newTcpcopyFilter(tt.fields.tcpcopy)
 			if gotRes := f.OnData(tt.args.data); gotRes != tt.wantRes {
 				t.Errorf("OnData() = %v, want %v", gotRes, tt.wantRes)
 			}
@@ -94,7 +93,7 @@ func Test_tcpcopyFactory_OnData_switch_off(t *testing.T) {
 	}
 }
 
-func Test_tcpcopyFactory_OnData_success(t *testing.T) {
+func Test_tcpcopyFilter_OnData_success(t *testing.T) {
 	strategy.DumpSwitch = true
 	strategy.DumpSampleFlag = 1
 
@@ -124,9 +123,7 @@ func Test_tcpcopyFactory_OnData_success(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			f := &tcpcopyFactory{
-				cfg: tt.fields.tcpcopy,
-			}
+			f := newTcpcopyFilter(tt.fields.tcpcopy)
 			if gotRes := f.OnData(tt.args.data); gotRes != tt.wantRes {
 				t.Errorf("OnData() = %v, want %v", gotRes, tt.wantRes)
 			}
@@ -134,7 +131,7 @@ func Test_tcpcopyFactory_OnData_success(t *testing.T) {
 	}
 }
 
-func Test_tcpcopyFactory_OnNewConnection(t *testing.T) {
+func Test_tcpcopyFilter_OnNewConnection(t *testing.T) {
 	type fields struct {
 		tcpcopy *config
 	}
@@ -144,19 +141,69 @@ func Test_tcpcopyFactory_OnNewConnection(t *testing.T) {
 		want   api.FilterStatus
 	}{
 		{
-			name:   "Test_tcpcopyFactory_OnNewConnection",
-			fields: struct{ tcpcopy *config }{tcpcopy: nil},
+			name:   "Test_tcpcopyFilter_OnNewConnection",
+			fields: struct{ tcpcopy *config }{tcpcopy: &config{port: "12220"}},
 			want:   api.Continue,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			f := &tcpcopyFactory{
-				cfg: tt.fields.tcpcopy,
-			}
+			f := newTcpcopyFilter(tt.fields.tcpcopy)
 			if got := f.OnNewConnection(); got != tt.want {
 				t.Errorf("OnNewConnection() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func Test_tcpcopyFilter_OnWrite(t *testing.T) {
+	strategy.DumpSwitch = true
+	strategy.DumpSampleFlag = 1
+
+	f := newTcpcopyFilter(&config{port: "12220"})
+	buffers := []buffer.IoBuffer{buffer.NewIoBufferString("te"), buffer.NewIoBufferString("st")}
+	if got := f.OnWrite(buffers); got != api.Continue {
+		t.Errorf("OnWrite() = %v, want %v", got, api.Continue)
+	}
+	f.OnEvent(api.RemoteClose)
+}
+
+func Test_tcpcopyFilter_connection_mode(t *testing.T) {
+	strategy.DumpSwitch = true
+	strategy.DumpSampleFlag = 0
+	strategy.DumpCpuMaxRate = 100
+	strategy.DumpMemMaxRate = 100
+	strategy.DumpMode = model.DumpModeConnection
+	defer func() {
+		strategy.DumpMode = model.DumpModeWindow
+	}()
+
+	// the connection is not sampled
+	strategy.DumpConnectionSampleRate = 0
+	f := newTcpcopyFilter(&config{port: "12220"})
+	f.OnNewConnection()
+	if f.sampled || f.isPersistence() {
+		t.Errorf("the connection should not be sampled")
+	}
+
+	// the sampled connection is captured out of the sample window
+	strategy.DumpConnectionSampleRate = 1
+	strategy.DumpSampleUuid = "window1"
+	f = newTcpcopyFilter(&config{port: "12220"})
+	f.OnNewConnection()
+	if !f.sampled || !f.isPersistence() || f.window != "window1" {
+		t.Errorf("the connection should be sampled")
+	}
+	// the window doesn't change
+	strategy.DumpSampleUuid = "window2"
+	f.OnData(buffer.NewIoBufferString("test"))
+	if f.window != "window1" {
+		t.Errorf("window = %s, want window1", f.window)
+	}
+
+	// the sampled connection is not captured once the switch is off
+	strategy.DumpSwitch = false
+	if f.isPersistence() {
+		t.Errorf("the connection should not be captured when the switch is off")
+	}
+}
@@ -0,0 +1,293 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"crypto/subtle"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"mosn.io/layotto/components/ref"
+)
+
+const (
+	// RoleRead is required by the requests which only read, i.e. GET and HEAD without body.
+	RoleRead = "read"
+	// RoleWrite is required by the other requests.
+	RoleWrite = "write"
+	// RoleAnonymous means that the endpoint can be accessed without authentication.
+	RoleAnonymous = "anonymous"
+
+	bearerPrefix = "Bearer "
+
+	defaultTokenTTL = time.Minute
+)
+
+// AuthConfig is the `auth` config of the dispatch filter.
+// If it's absent, the endpoints can be accessed without authentication.
+type AuthConfig struct {
+	// Tokens are the static bearer tokens, which are read from the secret stores.
+	Tokens []*TokenConfig `json:"tokens"`
+	// ClientSubjects are the subjects of the client certificates which are allowed when the listener uses mTLS.
+	ClientSubjects []*SubjectConfig `json:"client_subjects"`
+	// Endpoints overrides the roles required by the endpoints. The key is the endpoint name.
+	Endpoints map[string]*EndpointRoles `json:"endpoints"`
+	// TokenTTL is how long in seconds the tokens read from the secret stores are cached, 60 by default.
+	TokenTTL int `json:"token_ttl"`
+}
+
+type TokenConfig struct {
+	SecretRef *ref.SecretRefConfig `json:"secret_ref"`
+	// Roles granted to the token. It has both read and write roles if empty.
+	Roles []string `json:"roles"`
+}
+
+type SubjectConfig struct {
+	// Subject matches the whole subject of the verified client certificate, e.g. "CN=ops,O=layotto".
+	// The common name alone doesn't match, as it's easy to be taken by other certificates from the same CA.
+	Subject string `json:"subject"`
+	// Roles granted to the subject. It has both read and write roles if empty.
+	Roles []string `json:"roles"`
+}
+
+type EndpointRoles struct {
+	Read  string `json:"read"`
+	Write string `json:"write"`
+}
+
+// SecretGetter gets the secret of the key in the secret store.
+type SecretGetter func(storeName string, key string) (map[string]string, error)
+
+var (
+	secretGetterMu sync.RWMutex
+	secretGetter   SecretGetter
+)
+
+// SetSecretGetter sets the function used to read the tokens from the secret stores.
+func SetSecretGetter(getter SecretGetter) {
+	secretGetterMu.Lock()
+	defer secretGetterMu.Unlock()
+	secretGetter = getter
+}
+
+func getSecretGetter() SecretGetter {
+	secretGetterMu.RLock()
+	defer secretGetterMu.RUnlock()
+	return secretGetter
+}
+
+type authenticator struct {
+	config *AuthConfig
+
+	mu sync.Mutex
+	// tokens are the cached values of config.Tokens, which expire at expires
+	tokens  []string
+	expires time.Time
+}
+
+// newAuthenticator parses the `auth` field of the filter config. It returns nil if there is no auth config.
+func newAuthenticator(config map[string]interface{}) (*authenticator, error) {
+	raw, ok := config["auth"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	authConfig := &AuthConfig{}
+	if err = json.Unmarshal(data, authConfig); err != nil {
+		return nil, fmt.Errorf("invalid auth config: %v", err)
+	}
+	for _, t := range authConfig.Tokens {
+		if t == nil || t.SecretRef == nil || t.SecretRef.StoreName == "" || t.SecretRef.Key == "" {
+			return nil, errors.New("invalid auth config: the secret_ref of the token requires store_name and key")
+		}
+	}
+	for _, s := range authConfig.ClientSubjects {
+		if s == nil || s.Subject == "" {
+			return nil, errors.New("invalid auth config: empty client subject")
+		}
+	}
+	return &authenticator{config: authConfig}, nil
+}
+
+// authorize checks the credentials of a request to the endpoint.
+// It returns http.StatusOK if the request is allowed, otherwise http.StatusUnauthorized or http.StatusForbidden with the reason.
+func (a *authenticator) authorize(endpoint string, write bool, authorization string, subject string) (int, string) {
+	required := a.requiredRole(endpoint, write)
+	if required == RoleAnonymous {
+		return http.StatusOK, ""
+	}
+	roles, authenticated, err := a.roles(authorization, subject)
+	if !authenticated {
+		if err != nil {
+			return http.StatusUnauthorized, err.Error()
+		}
+		return http.StatusUnauthorized, "no valid credentials"
+	}
+	if !roles[required] {
+		return http.StatusForbidden, fmt.Sprintf("role %s is required", required)
+	}
+	return http.StatusOK, ""
+}
+
+func (a *authenticator) requiredRole(endpoint string, write bool) string {
+	if roles, ok := a.config.Endpoints[endpoint]; ok && roles != nil {
+		if write && roles.Write != "" {
+			return roles.Write
+		}
+		if !write && roles.Read != "" {
+			return roles.Read
+		}
+	}
+	if write {
+		return RoleWrite
+	}
+	return RoleRead
+}
+
+// roles collects the roles granted to the bearer token and the subject of the client certificate.
+// If the tokens can't be read, the token grants no roles, and the error is returned with the roles of the subject.
+func (a *authenticator) roles(authorization string, subject string) (map[string]bool, bool, error) {
+	roles := make(map[string]bool)
+	authenticated := false
+	grant := func(granted []string) {
+		authenticated = true
+		if len(granted) == 0 {
+			granted = []string{RoleRead, RoleWrite}
+		}
+		for _, r := range granted {
+			roles[r] = true
+		}
+	}
+
+	if subject != "" {
+		for _, s := range a.config.ClientSubjects {
+			if s.Subject == subject {
+				grant(s.Roles)
+			}
+		}
+	}
+
+	if !strings.HasPrefix(authorization, bearerPrefix) {
+		return roles, authenticated, nil
+	}
+	token := strings.TrimSpace(authorization[len(bearerPrefix):])
+	if token == "" || len(a.config.Tokens) == 0 {
+		return roles, authenticated, nil
+	}
+	tokens, err := a.readTokens()
+	if err != nil {
+		return roles, authenticated, err
+	}
+	for i, t := range a.config.Tokens {
+		expected := tokens[i]
+		if expected != "" && subtle.ConstantTimeCompare([]byte(expected), []byte(token)) == 1 {
+			grant(t.Roles)
+		}
+	}
+	return roles, authenticated, nil
+}
+
+// readTokens returns the values of the tokens, which are read from the secret stores when the cache expires.
+func (a *authenticator) readTokens() ([]string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	if a.tokens != nil && now.Before(a.expires) {
+		return a.tokens, nil
+	}
+	getter := getSecretGetter()
+	if getter == nil {
+		return nil, errors.New("secret stores are not ready")
+	}
+	tokens := make([]string, len(a.config.Tokens))
+	for i, t := range a.config.Tokens {
+		token, err := readToken(getter, t.SecretRef)
+		if err != nil {
+			return nil, err
+		}
+		tokens[i] = token
+	}
+	ttl := defaultTokenTTL
+	if a.config.TokenTTL > 0 {
+		ttl = time.Duration(a.config.TokenTTL) * time.Second
+	}
+	a.tokens = tokens
+	a.expires = now.Add(ttl)
+	return tokens, nil
+}
+
+func readToken(getter SecretGetter, secretRef *ref.SecretRefConfig) (string, error) {
+	secret, err := getter(secretRef.StoreName, secretRef.Key)
+	if err != nil {
+		return "", fmt.Errorf("fail to read token from secret store %s: %v", secretRef.StoreName, err)
+	}
+	subKey := secretRef.SubKey
+	if subKey == "" {
+		subKey = secretRef.Key
+	}
+	return secret[subKey], nil
+}
+
+// peerSubject returns the subject of the client certificate if the connection is over mTLS and the certificate is verified.
+// The unverified PeerCertificates are ignored, as the listener may request the certificate without verifying it.
+// mosn wraps the connection with its own fork of crypto/tls, so the certificate is accessed by reflection.
+func peerSubject(conn net.Conn) string {
+	if conn == nil {
+		return ""
+	}
+	method := reflect.ValueOf(conn).MethodByName("ConnectionState")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return ""
+	}
+	state := reflect.Indirect(method.Call(nil)[0])
+	if state.Kind() != reflect.Struct {
+		return ""
+	}
+	chains := state.FieldByName("VerifiedChains")
+	if !chains.IsValid() || chains.Kind() != reflect.Slice || chains.Len() == 0 {
+		return ""
+	}
+	chain := chains.Index(0)
+	if chain.Kind() != reflect.Slice || chain.Len() == 0 {
+		return ""
+	}
+	// the first one of the chain is the leaf certificate
+	leaf := reflect.Indirect(chain.Index(0))
+	if leaf.Kind() != reflect.Struct {
+		return ""
+	}
+	field := leaf.FieldByName("Subject")
+	if !field.IsValid() {
+		return ""
+	}
+	subject, ok := field.Interface().(pkix.Name)
+	if !ok {
+		return ""
+	}
+	return subject.String()
+}
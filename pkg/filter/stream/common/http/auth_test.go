@@ -0,0 +1,221 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+	mosnhttp "mosn.io/mosn/pkg/protocol/http"
+	"mosn.io/pkg/buffer"
+)
+
+func newTestAuthenticator(t *testing.T) *authenticator {
+	auth, err := newAuthenticator(map[string]interface{}{
+		"auth": map[string]interface{}{
+			"tokens": []interface{}{
+				map[string]interface{}{
+					"secret_ref": map[string]interface{}{"store_name": "local.file", "key": "actuator", "sub_key": "admin"},
+				},
+				map[string]interface{}{
+					"secret_ref": map[string]interface{}{"store_name": "local.file", "key": "actuator", "sub_key": "viewer"},
+					"roles":      []interface{}{"read"},
+				},
+			},
+			"client_subjects": []interface{}{
+				map[string]interface{}{"subject": "CN=ops,O=layotto", "roles": []interface{}{"read", "install"}},
+			},
+			"endpoints": map[string]interface{}{
+				"health":  map[string]interface{}{"read": "anonymous"},
+				"install": map[string]interface{}{"write": "install"},
+			},
+		},
+	})
+	assert.Nil(t, err)
+	assert.NotNil(t, auth)
+	return auth
+}
+
+func TestNewAuthenticator(t *testing.T) {
+	auth, err := newAuthenticator(map[string]interface{}{})
+	assert.Nil(t, err)
+	assert.Nil(t, auth)
+
+	_, err = newAuthenticator(map[string]interface{}{
+		"auth": map[string]interface{}{"tokens": []interface{}{map[string]interface{}{}}},
+	})
+	assert.NotNil(t, err)
+	_, err = newAuthenticator(map[string]interface{}{
+		"auth": map[string]interface{}{"client_subjects": []interface{}{map[string]interface{}{}}},
+	})
+	assert.NotNil(t, err)
+	_, err = newAuthenticator(map[string]interface{}{"auth": "invalid"})
+	assert.NotNil(t, err)
+}
+
+func TestAuthenticator_authorize(t *testing.T) {
+	defer SetSecretGetter(nil)
+	auth := newTestAuthenticator(t)
+
+	// secret stores not ready
+	code, _ := auth.authorize("info", false, "Bearer admin-token", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	SetSecretGetter(func(storeName string, key string) (map[string]string, error) {
+		if storeName != "local.file" || key != "actuator" {
+			return nil, errors.New("not found")
+		}
+		return map[string]string{"admin": "admin-token", "viewer": "viewer-token"}, nil
+	})
+	// anonymous
+	code, _ = auth.authorize("health", false, "", "")
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = auth.authorize("health", true, "", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = auth.authorize("info", false, "", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = auth.authorize("info", false, "Bearer wrong", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// token without roles has both read and write
+	code, _ = auth.authorize("info", false, "Bearer admin-token", "")
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = auth.authorize("logger", true, "Bearer admin-token", "")
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = auth.authorize("install", true, "Bearer admin-token", "")
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// read only token
+	code, _ = auth.authorize("info", false, "Bearer viewer-token", "")
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = auth.authorize("logger", true, "Bearer viewer-token", "")
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// client certificate
+	code, _ = auth.authorize("install", true, "", "CN=ops,O=layotto")
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = auth.authorize("logger", true, "", "CN=ops,O=layotto")
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = auth.authorize("info", false, "", "CN=dev,O=layotto")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	// the common name alone doesn't match
+	code, _ = auth.authorize("info", false, "", "CN=ops")
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
+
+func TestAuthenticator_authorizeTokensUnavailable(t *testing.T) {
+	defer SetSecretGetter(nil)
+	auth := newTestAuthenticator(t)
+	SetSecretGetter(func(storeName string, key string) (map[string]string, error) {
+		return nil, errors.New("unavailable")
+	})
+	// the roles of the client certificate are kept
+	code, _ := auth.authorize("install", true, "Bearer admin-token", "CN=ops,O=layotto")
+	assert.Equal(t, http.StatusOK, code)
+	code, reason := auth.authorize("info", false, "Bearer admin-token", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Contains(t, reason, "unavailable")
+}
+
+func TestAuthenticator_readTokens(t *testing.T) {
+	defer SetSecretGetter(nil)
+	auth := newTestAuthenticator(t)
+	reads := 0
+	secret := map[string]string{"admin": "admin-token", "viewer": "viewer-token"}
+	SetSecretGetter(func(storeName string, key string) (map[string]string, error) {
+		reads++
+		return secret, nil
+	})
+
+	code, _ := auth.authorize("info", false, "Bearer admin-token", "")
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = auth.authorize("info", false, "Bearer viewer-token", "")
+	assert.Equal(t, http.StatusOK, code)
+	// the tokens are read once until the cache expires
+	assert.Equal(t, 2, reads)
+
+	// the rotated token takes effect after the cache expires
+	secret = map[string]string{"admin": "new-token"}
+	code, _ = auth.authorize("info", false, "Bearer new-token", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	auth.expires = time.Now()
+	code, _ = auth.authorize("info", false, "Bearer new-token", "")
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = auth.authorize("info", false, "Bearer admin-token", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Equal(t, 4, reads)
+
+	// the failed reads aren't cached
+	auth.expires = time.Now()
+	SetSecretGetter(func(storeName string, key string) (map[string]string, error) {
+		return nil, errors.New("not found")
+	})
+	code, _ = auth.authorize("info", false, "Bearer new-token", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	_, err := auth.readTokens()
+	assert.NotNil(t, err)
+}
+
+func TestIsWrite(t *testing.T) {
+	get := &fasthttp.RequestHeader{}
+	get.SetMethod(http.MethodGet)
+	assert.False(t, isWrite(mosnhttp.RequestHeader{RequestHeader: get}, nil))
+	assert.True(t, isWrite(mosnhttp.RequestHeader{RequestHeader: get}, buffer.NewIoBufferString("body")))
+
+	post := &fasthttp.RequestHeader{}
+	post.SetMethod(http.MethodPost)
+	assert.True(t, isWrite(&mosnhttp.RequestHeader{RequestHeader: post}, nil))
+	// the method is unknown
+	assert.True(t, isWrite(nil, nil))
+}
+
+type tlsConn struct {
+	net.Conn
+	state tls.ConnectionState
+}
+
+func (c *tlsConn) ConnectionState() tls.ConnectionState {
+	return c.state
+}
+
+func TestPeerSubject(t *testing.T) {
+	assert.Equal(t, "", peerSubject(nil))
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	assert.Equal(t, "", peerSubject(server))
+	assert.Equal(t, "", peerSubject(&tlsConn{Conn: server}))
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "ops", Organization: []string{"layotto"}}}
+	// the certificate isn't verified
+	conn := &tlsConn{
+		Conn:  server,
+		state: tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}},
+	}
+	assert.Equal(t, "", peerSubject(conn))
+
+	conn.state.VerifiedChains = [][]*x509.Certificate{{cert}}
+	assert.Equal(t, "CN=ops,O=layotto", peerSubject(conn))
+}
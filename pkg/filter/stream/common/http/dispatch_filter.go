@@ -33,6 +33,7 @@ import (
 type DispatchFilter struct {
 	filterType     string
 	requestHandler RequestHandler
+	auth           *authenticator
 	handler        api.StreamReceiverFilterHandler
 }
 
@@ -66,12 +67,36 @@ func (dis *DispatchFilter) OnReceive(ctx context.Context, headers api.HeaderMap,
 		ctx = context.WithValue(ctx, ContextKeyRequestData{}, requestData.Bytes())
 	}
 	epName := resolver.Next()
+	// 4. authenticate before looking up the endpoint, so the endpoints can't be enumerated without credentials
+	if dis.auth != nil {
+		write := isWrite(headers, requestData)
+		authorization, _ := headers.Get("Authorization")
+		var subject string
+		if conn := dis.handler.Connection(); conn != nil {
+			subject = peerSubject(conn.RawConn())
+		}
+		if code, reason := dis.auth.authorize(epName, write, authorization, subject); code != http.StatusOK {
+			log.DefaultLogger.Warnf("[%v][dispatch_filter] request to %v rejected with %v: %v, client subject: %v",
+				dis.filterType, path, code, reason, subject)
+			dis.writeJsonResult(map[string]interface{}{"error": http.StatusText(code)}, code)
+			return api.StreamFilterStop
+		}
+	}
 	endpoint, ok := dis.requestHandler.GetEndpoint(epName)
 	if !ok {
 		// illegal
 		dis.write404()
 		return api.StreamFilterStop
 	}
+	if raw, ok := endpoint.(RawEndpoint); ok {
+		contentType, body, err := raw.HandleRaw(ctx, resolver)
+		if err != nil {
+			dis.writeJsonResult(map[string]interface{}{"error": err.Error()}, http.StatusInternalServerError)
+			return api.StreamFilterStop
+		}
+		dis.writeResult(contentType, body, http.StatusOK)
+		return api.StreamFilterStop
+	}
 	json, err := endpoint.Handle(ctx, resolver)
 	var code int
 	if err != nil {
@@ -83,6 +108,26 @@ func (dis *DispatchFilter) OnReceive(ctx context.Context, headers api.HeaderMap,
 	return api.StreamFilterStop
 }
 
+// isWrite returns false for the GET and HEAD requests without body.
+// The requests whose method is unknown are writes.
+func isWrite(headers api.HeaderMap, requestData buffer.IoBuffer) bool {
+	if requestData != nil && requestData.Len() > 0 {
+		return true
+	}
+	var method string
+	switch h := headers.(type) {
+	case mosnhttp.RequestHeader:
+		if h.RequestHeader != nil {
+			method = string(h.Method())
+		}
+	case *mosnhttp.RequestHeader:
+		if h != nil && h.RequestHeader != nil {
+			method = string(h.Method())
+		}
+	}
+	return method != http.MethodGet && method != http.MethodHead
+}
+
 func (dis *DispatchFilter) write404() {
 	dis.writeJsonResult(nil, http.StatusNotFound)
 }
@@ -101,15 +146,19 @@ func (dis *DispatchFilter) writeJsonResult(jsonObject map[string]interface{}, co
 			code = http.StatusInternalServerError
 		}
 	}
+	dis.writeResult("application/json", byteSlice, code)
+}
+
+func (dis *DispatchFilter) writeResult(contentType string, body []byte, code int) {
 	// 1. header
 	fastHttpHeader := &fasthttp.ResponseHeader{}
 	rspHeader := mosnhttp.ResponseHeader{
 		ResponseHeader: fastHttpHeader,
 	}
-	rspHeader.Set("Content-Type", "application/json")
+	rspHeader.Set("Content-Type", contentType)
 	rspHeader.SetStatusCode(code)
 	// 2. body
-	data := buffer.NewIoBufferBytes(byteSlice)
+	data := buffer.NewIoBufferBytes(body)
 	// 3. write response
 	dis.handler.SendDirectResponse(rspHeader, data, nil)
 }
@@ -23,3 +23,10 @@ import (
 type Endpoint interface {
 	Handle(ctx context.Context, params ParamsScanner) (jsonObject map[string]interface{}, err error)
 }
+
+// RawEndpoint is implemented by the endpoints which write the response body in their own format instead of json,
+// e.g. the metrics in the prometheus text format.
+type RawEndpoint interface {
+	Endpoint
+	HandleRaw(ctx context.Context, params ParamsScanner) (contentType string, body []byte, err error)
+}
@@ -26,9 +26,14 @@ import (
 func RegisterFilter(filterType string, handler RequestHandler) {
 	api.RegisterStream(filterType+"_filter", func(config map[string]interface{}) (api.StreamFilterChainFactory, error) {
 		log.DefaultLogger.Infof("[%v] create filter factory", filterType)
+		auth, err := newAuthenticator(config)
+		if err != nil {
+			return nil, err
+		}
 		return &ServiceFactory{
 			filterType:     filterType,
 			requestHandler: handler,
+			auth:           auth,
 		}, nil
 	})
 }
@@ -36,11 +41,13 @@ func RegisterFilter(filterType string, handler RequestHandler) {
 type ServiceFactory struct {
 	filterType     string
 	requestHandler RequestHandler
+	auth           *authenticator
 }
 
 func (f *ServiceFactory) CreateFilterChain(context context.Context, callbacks api.StreamFilterChainFactoryCallbacks) {
 	filter := &DispatchFilter{}
 	filter.filterType = f.filterType
 	filter.requestHandler = f.requestHandler
+	filter.auth = f.auth
 	callbacks.AddStreamReceiverFilter(filter, api.BeforeRoute)
 }
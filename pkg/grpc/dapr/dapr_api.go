@@ -41,6 +41,7 @@ import (
 	"mosn.io/layotto/components/rpc"
 	mosninvoker "mosn.io/layotto/components/rpc/invoker/mosn"
 	"mosn.io/layotto/components/sequencer"
+	"mosn.io/layotto/components/trace"
 	grpc_api "mosn.io/layotto/pkg/grpc"
 	dapr_common_v1pb "mosn.io/layotto/pkg/grpc/dapr/proto/common/v1"
 	dapr_v1pb "mosn.io/layotto/pkg/grpc/dapr/proto/runtime/v1"
@@ -145,7 +146,9 @@ func (d *daprGrpcAPI) InvokeBinding(ctx context.Context, in *dapr_v1pb.InvokeBin
 	}
 
 	r := &dapr_v1pb.InvokeBindingResponse{}
+	_, span := trace.StartSpan(ctx, trace.SpanKindClient, "bindings/InvokeBinding", in.Name)
 	resp, err := d.sendToOutputBindingFn(in.Name, req)
+	trace.EndSpan(span, err)
 	if err != nil {
 		err = status.Errorf(codes.Internal, messages.ErrInvokeOutputBinding, in.Name, err.Error())
 		log.DefaultLogger.Errorf("call out binding fail, err:%+v", err)
@@ -31,6 +31,8 @@ import (
 	"mosn.io/pkg/log"
 
 	l8_comp_pubsub "mosn.io/layotto/components/pubsub"
+	"mosn.io/layotto/components/trace"
+	"mosn.io/layotto/diagnostics"
 	dapr_v1pb "mosn.io/layotto/pkg/grpc/dapr/proto/runtime/v1"
 	"mosn.io/layotto/pkg/messages"
 )
@@ -100,6 +102,18 @@ func (d *daprGrpcAPI) doPublishEvent(ctx context.Context, pubsubName string, top
 		return &emptypb.Empty{}, err
 	}
 	// 4. publish
+	_, span := trace.StartSpan(ctx, trace.SpanKindProducer, "pubsub/publish", pubsubName)
+	if span != nil {
+		// copy the metadata so that the caller's map is not modified
+		md := make(map[string]string, len(metadata)+2)
+		for k, v := range metadata {
+			md[k] = v
+		}
+		trace.Inject(span, func(k, v string) {
+			md[k] = v
+		})
+		metadata = md
+	}
 	req := pubsub.PublishRequest{
 		PubsubName: pubsubName,
 		Topic:      topic,
@@ -109,6 +123,7 @@ func (d *daprGrpcAPI) doPublishEvent(ctx context.Context, pubsubName string, top
 
 	// TODO limit topic scope
 	err = component.Publish(&req)
+	trace.EndSpan(span, err)
 	if err != nil {
 		nerr := status.Errorf(codes.Internal, messages.ErrPubsubPublishMessage, topic, pubsubName, err.Error())
 		return &emptypb.Empty{}, nerr
@@ -257,8 +272,12 @@ func (d *daprGrpcAPI) publishMessageGRPC(ctx context.Context, msg *pubsub.NewMes
 	}
 
 	// 4. call appcallback
+	ctx, span := diagnostics.StartSpanFromCarrier(ctx, trace.SpanKindConsumer, "pubsub/deliver", func(key string) string {
+		return msg.Metadata[key]
+	})
 	clientV1 := dapr_v1pb.NewAppCallbackClient(d.AppCallbackConn)
 	res, err := clientV1.OnTopicEvent(ctx, envelope)
+	trace.EndSpan(span, err)
 
 	// 5. check result
 	return retryStrategy(err, res, cloudEvent)
@@ -28,6 +28,7 @@ import (
 	"google.golang.org/protobuf/types/known/emptypb"
 	"mosn.io/pkg/log"
 
+	"mosn.io/layotto/components/trace"
 	"mosn.io/layotto/pkg/common"
 	dapr_common_v1pb "mosn.io/layotto/pkg/grpc/dapr/proto/common/v1"
 	dapr_v1pb "mosn.io/layotto/pkg/grpc/dapr/proto/runtime/v1"
@@ -52,7 +53,9 @@ func (d *daprGrpcAPI) SaveState(ctx context.Context, in *dapr_v1pb.SaveStateRequ
 		reqs = append(reqs, *StateItem2SetRequest(s, key))
 	}
 	// 3. query
+	_, span := trace.StartSpan(ctx, trace.SpanKindClient, "state/SaveState", in.StoreName)
 	err = store.BulkSet(reqs)
+	trace.EndSpan(span, err)
 	// 4. check result
 	if err != nil {
 		err = d.wrapDaprComponentError(err, messages.ErrStateSave, in.StoreName, err.Error())
@@ -83,7 +86,9 @@ func (d *daprGrpcAPI) GetState(ctx context.Context, request *dapr_v1pb.GetStateR
 		},
 	}
 	// 3. query
+	_, span := trace.StartSpan(ctx, trace.SpanKindClient, "state/GetState", request.StoreName)
 	compResp, err := store.Get(req)
+	trace.EndSpan(span, err)
 	// 4. check result
 	if err != nil {
 		err = status.Errorf(codes.Internal, messages.ErrStateGet, request.Key, request.StoreName, err.Error())
@@ -121,7 +126,9 @@ func (d *daprGrpcAPI) GetBulkState(ctx context.Context, request *dapr_v1pb.GetBu
 		reqs[i] = r
 	}
 	// 2.2. query
+	_, span := trace.StartSpan(ctx, trace.SpanKindClient, "state/GetBulkState", request.StoreName)
 	support, responses, err := store.BulkGet(reqs)
+	trace.EndSpan(span, err)
 	if err != nil {
 		return bulkResp, err
 	}
@@ -219,7 +226,9 @@ func (d *daprGrpcAPI) DeleteState(ctx context.Context, request *dapr_v1pb.Delete
 		return &empty.Empty{}, err
 	}
 	// 3. convert and send request
+	_, span := trace.StartSpan(ctx, trace.SpanKindClient, "state/DeleteState", request.StoreName)
 	err = store.Delete(DeleteStateRequest2DeleteRequest(request, key))
+	trace.EndSpan(span, err)
 	// 4. check result
 	if err != nil {
 		err = d.wrapDaprComponentError(err, messages.ErrStateDelete, request.Key, err.Error())
@@ -246,7 +255,9 @@ func (d *daprGrpcAPI) DeleteBulkState(ctx context.Context, request *dapr_v1pb.De
 		reqs = append(reqs, *StateItem2DeleteRequest(item, key))
 	}
 	// 3. send request
+	_, span := trace.StartSpan(ctx, trace.SpanKindClient, "state/DeleteBulkState", request.StoreName)
 	err = store.BulkDelete(reqs)
+	trace.EndSpan(span, err)
 	// 4. check result
 	if err != nil {
 		log.DefaultLogger.Errorf("[runtime] [grpc.DeleteBulkState] error: %v", err)
@@ -310,10 +321,12 @@ func (d *daprGrpcAPI) ExecuteStateTransaction(ctx context.Context, request *dapr
 		operations = append(operations, operation)
 	}
 	// 4. submit transactional request
+	_, span := trace.StartSpan(ctx, trace.SpanKindClient, "state/ExecuteStateTransaction", storeName)
 	err := store.Multi(&state.TransactionalStateRequest{
 		Operations: operations,
 		Metadata:   request.Metadata,
 	})
+	trace.EndSpan(span, err)
 	// 5. check result
 	if err != nil {
 		err = status.Errorf(codes.Internal, messages.ErrStateTransaction, err.Error())
@@ -95,6 +95,14 @@ type API interface {
 	DelFile(ctx context.Context, in *runtimev1pb.DelFileRequest) (*emptypb.Empty, error)
 	// Get file meta data, if file not exist,return code.NotFound error
 	GetFileMeta(ctx context.Context, in *runtimev1pb.GetFileMetaRequest) (*runtimev1pb.GetFileMetaResponse, error)
+	// Start or resume a resumable upload session of the file
+	InitFileUpload(ctx context.Context, in *runtimev1pb.InitFileUploadRequest) (*runtimev1pb.InitFileUploadResponse, error)
+	// Put a chunk of the upload session with stream
+	PutFileChunk(runtimev1pb.Runtime_PutFileChunkServer) error
+	// Compose the uploaded chunks into the file
+	CommitFileUpload(ctx context.Context, in *runtimev1pb.FileUploadRequest) (*emptypb.Empty, error)
+	// Discard the uploaded chunks
+	AbortFileUpload(ctx context.Context, in *runtimev1pb.FileUploadRequest) (*emptypb.Empty, error)
 	// Distributed Lock API
 	TryLock(context.Context, *runtimev1pb.TryLockRequest) (*runtimev1pb.TryLockResponse, error)
 	Unlock(context.Context, *runtimev1pb.UnlockRequest) (*runtimev1pb.UnlockResponse, error)
@@ -27,6 +27,7 @@ import (
 	"mosn.io/pkg/utils"
 
 	"mosn.io/layotto/components/configstores"
+	"mosn.io/layotto/components/trace"
 	runtimev1pb "mosn.io/layotto/spec/proto/runtime/v1"
 )
 
@@ -45,7 +46,9 @@ func (a *api) GetConfiguration(ctx context.Context, req *runtimev1pb.GetConfigur
 	if strings.ReplaceAll(req.Label, " ", "") == "" {
 		req.Label = store.GetDefaultLabel()
 	}
-	items, err := store.Get(ctx, &configstores.GetRequest{AppId: req.AppId, Group: req.Group, Label: req.Label, Keys: req.Keys, Metadata: req.Metadata})
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "configuration/GetConfiguration", req.StoreName)
+	items, err := store.Get(spanCtx, &configstores.GetRequest{AppId: req.AppId, Group: req.Group, Label: req.Label, Keys: req.Keys, Metadata: req.Metadata})
+	trace.EndSpan(span, err)
 	if err != nil {
 		return nil, fmt.Errorf("get configuration failed with error: %+v", err)
 	}
@@ -74,7 +77,9 @@ func (a *api) SaveConfiguration(ctx context.Context, req *runtimev1pb.SaveConfig
 		}
 		setReq.Items = append(setReq.Items, &configstores.ConfigurationItem{Group: item.Group, Label: item.Label, Key: item.Key, Content: item.Content, Tags: item.Tags, Metadata: item.Metadata})
 	}
-	err := store.Set(ctx, setReq)
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "configuration/SaveConfiguration", req.StoreName)
+	err := store.Set(spanCtx, setReq)
+	trace.EndSpan(span, err)
 	return &emptypb.Empty{}, err
 }
 
@@ -90,7 +95,9 @@ func (a *api) DeleteConfiguration(ctx context.Context, req *runtimev1pb.DeleteCo
 	if strings.ReplaceAll(req.Label, " ", "") == "" {
 		req.Label = store.GetDefaultLabel()
 	}
-	err := store.Delete(ctx, &configstores.DeleteRequest{AppId: req.AppId, Group: req.Group, Label: req.Label, Keys: req.Keys, Metadata: req.Metadata})
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "configuration/DeleteConfiguration", req.StoreName)
+	err := store.Delete(spanCtx, &configstores.DeleteRequest{AppId: req.AppId, Group: req.Group, Label: req.Label, Keys: req.Keys, Metadata: req.Metadata})
+	trace.EndSpan(span, err)
 	return &emptypb.Empty{}, err
 }
 
@@ -29,5 +29,6 @@ var (
 		file.ErrExist:      codes.AlreadyExists,
 		file.ErrExpired:    codes.DataLoss,
 		file.ErrPermission: codes.PermissionDenied,
+		file.ErrNotSupport: codes.Unimplemented,
 	}
 )
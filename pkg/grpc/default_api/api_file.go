@@ -29,6 +29,8 @@ import (
 
 	"mosn.io/pkg/log"
 
+	"mosn.io/layotto/components/trace"
+	"mosn.io/layotto/diagnostics/metrics"
 	runtimev1pb "mosn.io/layotto/spec/proto/runtime/v1"
 )
 
@@ -39,8 +41,13 @@ func (a *api) GetFile(req *runtimev1pb.GetFileRequest, stream runtimev1pb.Runtim
 	if req.Metadata == nil {
 		req.Metadata = make(map[string]string)
 	}
-	st := &file.GetFileStu{FileName: req.Name, Metadata: req.Metadata}
-	data, err := a.fileOps[req.StoreName].Get(stream.Context(), st)
+	if req.Offset < 0 || req.Length < 0 {
+		return status.Errorf(codes.InvalidArgument, "invalid range, offset: %d, length: %d", req.Offset, req.Length)
+	}
+	st := &file.GetFileStu{FileName: req.Name, Offset: req.Offset, Length: req.Length, Metadata: req.Metadata}
+	ctx, span := trace.StartSpan(stream.Context(), trace.SpanKindClient, "file/GetFile", req.StoreName)
+	data, err := a.fileOps[req.StoreName].Get(ctx, st)
+	trace.EndSpan(span, err)
 	if err != nil {
 		return status.Errorf(codes.Internal, "get file fail,err: %+v", err)
 	}
@@ -67,6 +74,7 @@ func (a *api) GetFile(req *runtimev1pb.GetFileRequest, stream runtimev1pb.Runtim
 			if err = stream.Send(resp); err != nil {
 				return status.Errorf(codes.Internal, "send file data fail,err: %+v", err)
 			}
+			metrics.FileBytes.Add(int64(length), req.StoreName, "read")
 		}
 		if err == io.EOF {
 			return nil
@@ -77,6 +85,8 @@ func (a *api) GetFile(req *runtimev1pb.GetFileRequest, stream runtimev1pb.Runtim
 type putObjectStreamReader struct {
 	data   []byte
 	server runtimev1pb.Runtime_PutFileServer
+	// total is the count of bytes read
+	total int64
 }
 
 func newPutObjectStreamReader(data []byte, server runtimev1pb.Runtime_PutFileServer) *putObjectStreamReader {
@@ -91,6 +101,7 @@ func (r *putObjectStreamReader) Read(p []byte) (int, error) {
 			n := copy(p[count:], r.data)
 			r.data = r.data[n:]
 			count += n
+			r.total += int64(n)
 			if count == total {
 				return count, nil
 			}
@@ -124,7 +135,11 @@ func (a *api) PutFile(stream runtimev1pb.Runtime_PutFileServer) error {
 		req.Metadata = make(map[string]string)
 	}
 	st := &file.PutFileStu{DataStream: fileReader, FileName: req.Name, Metadata: req.Metadata}
-	if err = a.fileOps[req.StoreName].Put(stream.Context(), st); err != nil {
+	ctx, span := trace.StartSpan(stream.Context(), trace.SpanKindClient, "file/PutFile", req.StoreName)
+	err = a.fileOps[req.StoreName].Put(ctx, st)
+	trace.EndSpan(span, err)
+	metrics.FileBytes.Add(fileReader.total, req.StoreName, "write")
+	if err != nil {
 		return status.Errorf(codes.Internal, err.Error())
 	}
 	stream.SendAndClose(&empty.Empty{})
@@ -143,7 +158,9 @@ func (a *api) ListFile(ctx context.Context, in *runtimev1pb.ListFileRequest) (*r
 	if a.fileOps[in.Request.StoreName] == nil {
 		return nil, status.Errorf(codes.InvalidArgument, "not support store type: %+v", in.Request.StoreName)
 	}
+	ctx, span := trace.StartSpan(ctx, trace.SpanKindClient, "file/ListFile", in.Request.StoreName)
 	resp, err := a.fileOps[in.Request.StoreName].List(ctx, &file.ListRequest{DirectoryName: in.Request.Name, PageSize: in.PageSize, Marker: in.Marker, Metadata: in.Request.Metadata})
+	trace.EndSpan(span, err)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
@@ -171,7 +188,9 @@ func (a *api) DelFile(ctx context.Context, in *runtimev1pb.DelFileRequest) (*emp
 	if a.fileOps[in.Request.StoreName] == nil {
 		return nil, status.Errorf(codes.InvalidArgument, "not support store type: %+v", in.Request.StoreName)
 	}
+	ctx, span := trace.StartSpan(ctx, trace.SpanKindClient, "file/DelFile", in.Request.StoreName)
 	err := a.fileOps[in.Request.StoreName].Del(ctx, &file.DelRequest{FileName: in.Request.Name, Metadata: in.Request.Metadata})
+	trace.EndSpan(span, err)
 	if err != nil {
 		if code, ok := FileErrMap2GrpcErr[err]; ok {
 			errCode = code
@@ -193,7 +212,9 @@ func (a *api) GetFileMeta(ctx context.Context, in *runtimev1pb.GetFileMetaReques
 	if a.fileOps[in.Request.StoreName] == nil {
 		return nil, status.Errorf(codes.InvalidArgument, "not support store type: %+v", in.Request.StoreName)
 	}
+	ctx, span := trace.StartSpan(ctx, trace.SpanKindClient, "file/GetFileMeta", in.Request.StoreName)
 	resp, err := a.fileOps[in.Request.StoreName].Stat(ctx, &file.FileMetaRequest{FileName: in.Request.Name, Metadata: in.Request.Metadata})
+	trace.EndSpan(span, err)
 	if err != nil {
 		if code, ok := FileErrMap2GrpcErr[err]; ok {
 			errCode = code
@@ -208,3 +229,136 @@ func (a *api) GetFileMeta(ctx context.Context, in *runtimev1pb.GetFileMetaReques
 	}
 	return &runtimev1pb.GetFileMetaResponse{Size: resp.Size, LastModified: resp.LastModified, Response: meta}, nil
 }
+
+// InitFileUpload starts a resumable upload session, or resumes the session if upload id is specified
+func (a *api) InitFileUpload(ctx context.Context, in *runtimev1pb.InitFileUploadRequest) (*runtimev1pb.InitFileUploadResponse, error) {
+	errCode := codes.Internal
+	if a.fileOps[in.StoreName] == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "not support store type: %+v", in.StoreName)
+	}
+	if in.Metadata == nil {
+		in.Metadata = make(map[string]string)
+	}
+	st := &file.InitUploadStu{FileName: in.Name, UploadId: in.UploadId, ChunkSize: in.ChunkSize, Metadata: in.Metadata}
+	ctx, span := trace.StartSpan(ctx, trace.SpanKindClient, "file/InitFileUpload", in.StoreName)
+	session, err := a.fileOps[in.StoreName].InitUpload(ctx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
+		if code, ok := FileErrMap2GrpcErr[err]; ok {
+			errCode = code
+		}
+		return nil, status.Errorf(errCode, err.Error())
+	}
+	return &runtimev1pb.InitFileUploadResponse{UploadId: session.UploadId, ChunkSize: session.ChunkSize, Offset: session.Offset}, nil
+}
+
+type putChunkStreamReader struct {
+	data   []byte
+	server runtimev1pb.Runtime_PutFileChunkServer
+	// total is the count of bytes read
+	total int64
+}
+
+func newPutChunkStreamReader(data []byte, server runtimev1pb.Runtime_PutFileChunkServer) *putChunkStreamReader {
+	return &putChunkStreamReader{data: data, server: server}
+}
+
+func (r *putChunkStreamReader) Read(p []byte) (int, error) {
+	var count int
+	total := len(p)
+	for {
+		if len(r.data) > 0 {
+			n := copy(p[count:], r.data)
+			r.data = r.data[n:]
+			count += n
+			r.total += int64(n)
+			if count == total {
+				return count, nil
+			}
+		}
+		req, err := r.server.Recv()
+		if err != nil {
+			if err != io.EOF {
+				log.DefaultLogger.Errorf("recv data from grpc stream fail, err:%+v", err)
+			}
+			return count, err
+		}
+		r.data = req.Data
+	}
+}
+
+// PutFileChunk uploads a chunk of the upload session with stream
+func (a *api) PutFileChunk(stream runtimev1pb.Runtime_PutFileChunkServer) error {
+	errCode := codes.Internal
+	req, err := stream.Recv()
+	if err != nil {
+		//if client send eof error directly, return nil
+		if err == io.EOF {
+			return nil
+		}
+		return status.Errorf(codes.Internal, "receive file data fail: err: %+v", err)
+	}
+
+	if a.fileOps[req.StoreName] == nil {
+		return status.Errorf(codes.InvalidArgument, "not support store type: %+v", req.StoreName)
+	}
+	chunkReader := newPutChunkStreamReader(req.Data, stream)
+	if req.Metadata == nil {
+		req.Metadata = make(map[string]string)
+	}
+	st := &file.PutChunkStu{FileName: req.Name, UploadId: req.UploadId, Offset: req.Offset, DataStream: chunkReader, Metadata: req.Metadata}
+	ctx, span := trace.StartSpan(stream.Context(), trace.SpanKindClient, "file/PutFileChunk", req.StoreName)
+	err = a.fileOps[req.StoreName].PutChunk(ctx, st)
+	trace.EndSpan(span, err)
+	metrics.FileBytes.Add(chunkReader.total, req.StoreName, "write")
+	if err != nil {
+		if code, ok := FileErrMap2GrpcErr[err]; ok {
+			errCode = code
+		}
+		return status.Errorf(errCode, err.Error())
+	}
+	stream.SendAndClose(&empty.Empty{})
+	return nil
+}
+
+// CommitFileUpload composes the uploaded chunks into the file
+func (a *api) CommitFileUpload(ctx context.Context, in *runtimev1pb.FileUploadRequest) (*emptypb.Empty, error) {
+	errCode := codes.Internal
+	if a.fileOps[in.StoreName] == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "not support store type: %+v", in.StoreName)
+	}
+	if in.Metadata == nil {
+		in.Metadata = make(map[string]string)
+	}
+	ctx, span := trace.StartSpan(ctx, trace.SpanKindClient, "file/CommitFileUpload", in.StoreName)
+	err := a.fileOps[in.StoreName].CommitUpload(ctx, &file.CommitUploadStu{FileName: in.Name, UploadId: in.UploadId, Metadata: in.Metadata})
+	trace.EndSpan(span, err)
+	if err != nil {
+		if code, ok := FileErrMap2GrpcErr[err]; ok {
+			errCode = code
+		}
+		return nil, status.Errorf(errCode, err.Error())
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// AbortFileUpload discards the uploaded chunks of the upload session
+func (a *api) AbortFileUpload(ctx context.Context, in *runtimev1pb.FileUploadRequest) (*emptypb.Empty, error) {
+	errCode := codes.Internal
+	if a.fileOps[in.StoreName] == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "not support store type: %+v", in.StoreName)
+	}
+	if in.Metadata == nil {
+		in.Metadata = make(map[string]string)
+	}
+	ctx, span := trace.StartSpan(ctx, trace.SpanKindClient, "file/AbortFileUpload", in.StoreName)
+	err := a.fileOps[in.StoreName].AbortUpload(ctx, &file.AbortUploadStu{FileName: in.Name, UploadId: in.UploadId, Metadata: in.Metadata})
+	trace.EndSpan(span, err)
+	if err != nil {
+		if code, ok := FileErrMap2GrpcErr[err]; ok {
+			errCode = code
+		}
+		return nil, status.Errorf(errCode, err.Error())
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"io/ioutil"
 	"net"
 	"testing"
 
@@ -46,12 +47,14 @@ func TestGetFile(t *testing.T) {
 	api := NewAPI("", nil, nil, nil, nil, nil, map[string]file.File{"mock": mockFile}, nil, nil, nil, nil)
 	err := api.GetFile(&runtimev1pb.GetFileRequest{StoreName: "mock1"}, mockStream)
 	assert.Equal(t, err, status.Errorf(codes.InvalidArgument, "not supported store type: mock1"))
+	err = api.GetFile(&runtimev1pb.GetFileRequest{StoreName: "mock", Offset: -1}, mockStream)
+	assert.Equal(t, err, status.Errorf(codes.InvalidArgument, "invalid range, offset: -1, length: 0"))
 	metadata := make(map[string]string)
-	mockFile.EXPECT().Get(context.Background(), &file.GetFileStu{FileName: "", Metadata: metadata}).Return(r, nil).Times(1)
+	mockFile.EXPECT().Get(context.Background(), &file.GetFileStu{FileName: "", Offset: 2, Length: 8, Metadata: metadata}).Return(r, nil).Times(1)
 	mockStream.EXPECT().Send(&runtimev1pb.GetFileResponse{Data: []byte("testFile")}).Times(1)
 	mockStream.EXPECT().Context().Return(context.Background())
 	go SendData(w)
-	err = api.GetFile(&runtimev1pb.GetFileRequest{StoreName: "mock"}, mockStream)
+	err = api.GetFile(&runtimev1pb.GetFileRequest{StoreName: "mock", Offset: 2, Length: 8}, mockStream)
 	assert.Nil(t, err)
 }
 
@@ -151,3 +154,108 @@ func TestGetFileMeta(t *testing.T) {
 	assert.Equal(t, resp.LastModified, "123")
 	assert.Equal(t, int(resp.Size), 10)
 }
+
+func TestInitFileUpload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockFile := mock.NewMockFile(ctrl)
+	api := NewAPI("", nil, nil, nil, nil, nil, map[string]file.File{"mock": mockFile}, nil, nil, nil, nil)
+	resp, err := api.InitFileUpload(context.Background(), &runtimev1pb.InitFileUploadRequest{StoreName: "mock1"})
+	assert.Nil(t, resp)
+	assert.Equal(t, err, status.Errorf(codes.InvalidArgument, "not support store type: mock1"))
+
+	request := &runtimev1pb.InitFileUploadRequest{StoreName: "mock", Name: "test", ChunkSize: 1024}
+	st := &file.InitUploadStu{FileName: "test", ChunkSize: 1024, Metadata: map[string]string{}}
+	mockFile.EXPECT().InitUpload(context.Background(), st).Return(&file.UploadSession{UploadId: "id", ChunkSize: 1024, Offset: 2048}, nil).Times(1)
+	resp, err = api.InitFileUpload(context.Background(), request)
+	assert.Nil(t, err)
+	assert.Equal(t, "id", resp.UploadId)
+	assert.Equal(t, int64(1024), resp.ChunkSize)
+	assert.Equal(t, int64(2048), resp.Offset)
+
+	mockFile.EXPECT().InitUpload(context.Background(), st).Return(nil, file.ErrInvalid).Times(1)
+	_, err = api.InitFileUpload(context.Background(), request)
+	s, _ := status.FromError(err)
+	assert.Equal(t, codes.InvalidArgument, s.Code())
+}
+
+func TestPutFileChunk(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockFile := mock.NewMockFile(ctrl)
+	mockStream := runtime.NewMockRuntime_PutFileChunkServer(ctrl)
+	api := NewAPI("", nil, nil, nil, nil, nil, map[string]file.File{"mock": mockFile}, nil, nil, nil, nil)
+
+	mockStream.EXPECT().Recv().Return(nil, io.EOF).Times(1)
+	err := api.PutFileChunk(mockStream)
+	assert.Nil(t, err)
+
+	mockStream.EXPECT().Recv().Return(&runtimev1pb.PutFileChunkRequest{StoreName: "mock1"}, nil).Times(1)
+	err = api.PutFileChunk(mockStream)
+	assert.Equal(t, err, status.Errorf(codes.InvalidArgument, "not support store type: mock1"))
+
+	gomock.InOrder(
+		mockStream.EXPECT().Recv().Return(&runtimev1pb.PutFileChunkRequest{StoreName: "mock", Name: "test", UploadId: "id", Offset: 4, Data: []byte("ab")}, nil),
+		mockStream.EXPECT().Recv().Return(&runtimev1pb.PutFileChunkRequest{Data: []byte("cd")}, nil),
+		mockStream.EXPECT().Recv().Return(nil, io.EOF),
+	)
+	mockStream.EXPECT().Context().Return(context.Background())
+	mockStream.EXPECT().SendAndClose(gomock.Any()).Return(nil)
+	mockFile.EXPECT().PutChunk(context.Background(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, st *file.PutChunkStu) error {
+			assert.Equal(t, "test", st.FileName)
+			assert.Equal(t, "id", st.UploadId)
+			assert.Equal(t, int64(4), st.Offset)
+			data, err := ioutil.ReadAll(st.DataStream)
+			assert.Nil(t, err)
+			assert.Equal(t, "abcd", string(data))
+			return nil
+		})
+	err = api.PutFileChunk(mockStream)
+	assert.Nil(t, err)
+
+	mockStream.EXPECT().Recv().Return(&runtimev1pb.PutFileChunkRequest{StoreName: "mock", Name: "test"}, nil).Times(1)
+	mockStream.EXPECT().Context().Return(context.Background())
+	mockFile.EXPECT().PutChunk(context.Background(), gomock.Any()).Return(file.ErrNotExist).Times(1)
+	err = api.PutFileChunk(mockStream)
+	s, _ := status.FromError(err)
+	assert.Equal(t, codes.NotFound, s.Code())
+}
+
+func TestCommitFileUpload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockFile := mock.NewMockFile(ctrl)
+	api := NewAPI("", nil, nil, nil, nil, nil, map[string]file.File{"mock": mockFile}, nil, nil, nil, nil)
+	resp, err := api.CommitFileUpload(context.Background(), &runtimev1pb.FileUploadRequest{StoreName: "mock1"})
+	assert.Nil(t, resp)
+	assert.Equal(t, err, status.Errorf(codes.InvalidArgument, "not support store type: mock1"))
+
+	request := &runtimev1pb.FileUploadRequest{StoreName: "mock", Name: "test", UploadId: "id"}
+	st := &file.CommitUploadStu{FileName: "test", UploadId: "id", Metadata: map[string]string{}}
+	mockFile.EXPECT().CommitUpload(context.Background(), st).Return(nil).Times(1)
+	_, err = api.CommitFileUpload(context.Background(), request)
+	assert.Nil(t, err)
+
+	mockFile.EXPECT().CommitUpload(context.Background(), st).Return(errors.New("test fail")).Times(1)
+	_, err = api.CommitFileUpload(context.Background(), request)
+	s, _ := status.FromError(err)
+	assert.Equal(t, codes.Internal, s.Code())
+}
+
+func TestAbortFileUpload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockFile := mock.NewMockFile(ctrl)
+	api := NewAPI("", nil, nil, nil, nil, nil, map[string]file.File{"mock": mockFile}, nil, nil, nil, nil)
+	resp, err := api.AbortFileUpload(context.Background(), &runtimev1pb.FileUploadRequest{StoreName: "mock1"})
+	assert.Nil(t, resp)
+	assert.Equal(t, err, status.Errorf(codes.InvalidArgument, "not support store type: mock1"))
+
+	request := &runtimev1pb.FileUploadRequest{StoreName: "mock", Name: "test", UploadId: "id"}
+	st := &file.AbortUploadStu{FileName: "test", UploadId: "id", Metadata: map[string]string{}}
+	mockFile.EXPECT().AbortUpload(context.Background(), st).Return(nil).Times(1)
+	_, err = api.AbortFileUpload(context.Background(), request)
+	assert.Nil(t, err)
+
+	mockFile.EXPECT().AbortUpload(context.Background(), st).Return(file.ErrNotSupport).Times(1)
+	_, err = api.AbortFileUpload(context.Background(), request)
+	s, _ := status.FromError(err)
+	assert.Equal(t, codes.Unimplemented, s.Code())
+}
@@ -24,6 +24,8 @@ import (
 	"mosn.io/pkg/log"
 
 	"mosn.io/layotto/components/lock"
+	"mosn.io/layotto/components/trace"
+	"mosn.io/layotto/diagnostics/metrics"
 	"mosn.io/layotto/pkg/messages"
 	runtime_lock "mosn.io/layotto/pkg/runtime/lock"
 	runtimev1pb "mosn.io/layotto/spec/proto/runtime/v1"
@@ -63,11 +65,16 @@ func (a *api) TryLock(ctx context.Context, req *runtimev1pb.TryLockRequest) (*ru
 		return &runtimev1pb.TryLockResponse{}, err
 	}
 	// 4. delegate to the component
+	_, span := trace.StartSpan(ctx, trace.SpanKindClient, "lock/TryLock", req.StoreName)
 	compResp, err := store.TryLock(compReq)
+	trace.EndSpan(span, err)
 	if err != nil {
 		log.DefaultLogger.Errorf("[runtime] [grpc.TryLock] error: %v", err)
 		return &runtimev1pb.TryLockResponse{}, err
 	}
+	if compResp != nil && !compResp.Success {
+		metrics.LockContentions.Inc(req.StoreName)
+	}
 	// 5. convert response
 	resp := TryLockResponse2GrpcResponse(compResp)
 	return resp, nil
@@ -103,7 +110,9 @@ func (a *api) Unlock(ctx context.Context, req *runtimev1pb.UnlockRequest) (*runt
 		return newInternalErrorUnlockResponse(), err
 	}
 	// 4. delegate to the component
+	_, span := trace.StartSpan(ctx, trace.SpanKindClient, "lock/Unlock", req.StoreName)
 	compResp, err := store.Unlock(compReq)
+	trace.EndSpan(span, err)
 	if err != nil {
 		log.DefaultLogger.Errorf("[runtime] [grpc.Unlock] error: %v", err)
 		return newInternalErrorUnlockResponse(), err
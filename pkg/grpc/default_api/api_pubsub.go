@@ -28,10 +28,14 @@ import (
 	dapr_v1pb "mosn.io/layotto/pkg/grpc/dapr/proto/runtime/v1"
 
 	"encoding/base64"
+	"strings"
 
 	"github.com/dapr/components-contrib/contenttype"
 	"mosn.io/pkg/log"
 
+	"mosn.io/layotto/components/trace"
+	"mosn.io/layotto/diagnostics"
+	"mosn.io/layotto/diagnostics/metrics"
 	runtimev1pb "mosn.io/layotto/spec/proto/runtime/v1"
 )
 
@@ -194,11 +198,18 @@ func (a *api) publishMessageGRPC(ctx context.Context, msg *pubsub.NewMessage) er
 			envelope.Data, _ = a.json.Marshal(data)
 		}
 	}
-	// TODO tracing
-
-	// 4. Call appcallback
+	// 4. Call appcallback, continuing the trace propagated by the publisher
+	ctx, span := diagnostics.StartSpanFromCarrier(ctx, trace.SpanKindConsumer, "pubsub/deliver", func(key string) string {
+		return msg.Metadata[key]
+	})
 	clientV1 := runtimev1pb.NewAppCallbackClient(a.AppCallbackConn)
 	res, err := clientV1.OnTopicEvent(ctx, envelope)
+	trace.EndSpan(span, err)
+	result := "error"
+	if err == nil {
+		result = strings.ToLower(res.GetStatus().String())
+	}
+	metrics.PubsubDeliveries.Inc(envelope.PubsubName, envelope.Topic, result)
 
 	// 5. Check result
 	return retryStrategy(err, res, cloudEvent)
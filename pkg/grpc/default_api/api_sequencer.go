@@ -25,6 +25,7 @@ import (
 	"mosn.io/pkg/log"
 
 	"mosn.io/layotto/components/sequencer"
+	"mosn.io/layotto/components/trace"
 	"mosn.io/layotto/pkg/messages"
 	runtime_sequencer "mosn.io/layotto/pkg/runtime/sequencer"
 	runtimev1pb "mosn.io/layotto/spec/proto/runtime/v1"
@@ -59,6 +60,7 @@ func (a *api) GetNextId(ctx context.Context, req *runtimev1pb.GetNextIdRequest)
 	}
 	var next int64
 	// 4. invoke component
+	ctx, span := trace.StartSpan(ctx, trace.SpanKindClient, "sequencer/GetNextId", req.StoreName)
 	if compReq.Options.AutoIncrement == sequencer.WEAK {
 		// WEAK
 		next, err = a.getNextIdWithWeakAutoIncrement(ctx, store, compReq)
@@ -66,6 +68,7 @@ func (a *api) GetNextId(ctx context.Context, req *runtimev1pb.GetNextIdRequest)
 		// STRONG
 		next, err = a.getNextIdFromComponent(ctx, store, compReq)
 	}
+	trace.EndSpan(span, err)
 	// 5. convert response
 	if err != nil {
 		log.DefaultLogger.Errorf("[runtime] [grpc.GetNextId] error: %v", err)
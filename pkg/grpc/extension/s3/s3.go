@@ -25,6 +25,7 @@ import (
 	"mosn.io/layotto/spec/proto/extension/v1/s3"
 
 	l8s3 "mosn.io/layotto/components/oss"
+	"mosn.io/layotto/components/trace"
 
 	rawGRPC "google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -90,7 +91,9 @@ func (s *S3Server) GetObject(req *s3.GetObjectInput, stream s3.ObjectStorageServ
 		return status.Errorf(codes.InvalidArgument, "transfer request data fail for GetObject,err: %+v", err)
 	}
 	// 3. find the component
-	result, err := s.ossInstance[req.StoreName].GetObject(stream.Context(), st)
+	spanCtx, span := trace.StartSpan(stream.Context(), trace.SpanKindClient, "oss/GetObject", req.StoreName)
+	result, err := s.ossInstance[req.StoreName].GetObject(spanCtx, st)
+	trace.EndSpan(span, err)
 	if err != nil {
 		return status.Errorf(codes.Internal, "get file fail,err: %+v", err)
 	}
@@ -183,7 +186,10 @@ func (s *S3Server) PutObject(stream s3.ObjectStorageService_PutObjectServer) err
 	}
 	st.DataStream = fileReader
 	var resp *l8s3.PutObjectOutput
-	if resp, err = s.ossInstance[req.StoreName].PutObject(stream.Context(), st); err != nil {
+	spanCtx, span := trace.StartSpan(stream.Context(), trace.SpanKindClient, "oss/PutObject", req.StoreName)
+	resp, err = s.ossInstance[req.StoreName].PutObject(spanCtx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
 		return status.Errorf(codes.Internal, err.Error())
 	}
 	output := &s3.PutObjectOutput{}
@@ -204,7 +210,10 @@ func (s *S3Server) DeleteObject(ctx context.Context, req *s3.DeleteObjectInput)
 		return nil, status.Errorf(codes.InvalidArgument, "transfer request data fail for DeleteObject,err: %+v", err)
 	}
 	var resp *l8s3.DeleteObjectOutput
-	if resp, err = s.ossInstance[req.StoreName].DeleteObject(ctx, st); err != nil {
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "oss/DeleteObject", req.StoreName)
+	resp, err = s.ossInstance[req.StoreName].DeleteObject(spanCtx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 	output := &s3.DeleteObjectOutput{}
@@ -225,7 +234,10 @@ func (s *S3Server) PutObjectTagging(ctx context.Context, req *s3.PutObjectTaggin
 		return nil, status.Errorf(codes.InvalidArgument, "transfer request data fail for PutObjectTagging,err: %+v", err)
 	}
 	var resp *l8s3.PutObjectTaggingOutput
-	if resp, err = s.ossInstance[req.StoreName].PutObjectTagging(ctx, st); err != nil {
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "oss/PutObjectTagging", req.StoreName)
+	resp, err = s.ossInstance[req.StoreName].PutObjectTagging(spanCtx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 	output := &s3.PutObjectTaggingOutput{}
@@ -245,7 +257,10 @@ func (s *S3Server) DeleteObjectTagging(ctx context.Context, req *s3.DeleteObject
 		return nil, status.Errorf(codes.InvalidArgument, "transfer request data fail for DeleteObjectTagging,err: %+v", err)
 	}
 	var resp *l8s3.DeleteObjectTaggingOutput
-	if resp, err = s.ossInstance[req.StoreName].DeleteObjectTagging(ctx, st); err != nil {
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "oss/DeleteObjectTagging", req.StoreName)
+	resp, err = s.ossInstance[req.StoreName].DeleteObjectTagging(spanCtx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 	output := &s3.DeleteObjectTaggingOutput{}
@@ -265,7 +280,10 @@ func (s *S3Server) GetObjectTagging(ctx context.Context, req *s3.GetObjectTaggin
 		return nil, status.Errorf(codes.InvalidArgument, "transfer request data fail for GetObjectTagging,err: %+v", err)
 	}
 	var resp *l8s3.GetObjectTaggingOutput
-	if resp, err = s.ossInstance[req.StoreName].GetObjectTagging(ctx, st); err != nil {
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "oss/GetObjectTagging", req.StoreName)
+	resp, err = s.ossInstance[req.StoreName].GetObjectTagging(spanCtx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 	output := &s3.GetObjectTaggingOutput{}
@@ -285,7 +303,10 @@ func (s *S3Server) CopyObject(ctx context.Context, req *s3.CopyObjectInput) (*s3
 		return nil, status.Errorf(codes.InvalidArgument, "transfer request data fail for CopyObject,err: %+v", err)
 	}
 	var resp *l8s3.CopyObjectOutput
-	if resp, err = s.ossInstance[req.StoreName].CopyObject(ctx, st); err != nil {
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "oss/CopyObject", req.StoreName)
+	resp, err = s.ossInstance[req.StoreName].CopyObject(spanCtx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 	output := &s3.CopyObjectOutput{}
@@ -306,7 +327,10 @@ func (s *S3Server) DeleteObjects(ctx context.Context, req *s3.DeleteObjectsInput
 		return nil, status.Errorf(codes.InvalidArgument, "transfer request data fail for DeleteObjects,err: %+v", err)
 	}
 	var resp *l8s3.DeleteObjectsOutput
-	if resp, err = s.ossInstance[req.StoreName].DeleteObjects(ctx, st); err != nil {
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "oss/DeleteObjects", req.StoreName)
+	resp, err = s.ossInstance[req.StoreName].DeleteObjects(spanCtx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 	output := &s3.DeleteObjectsOutput{}
@@ -327,7 +351,10 @@ func (s *S3Server) ListObjects(ctx context.Context, req *s3.ListObjectsInput) (*
 		return nil, status.Errorf(codes.InvalidArgument, "transfer request data fail for ListObjects,err: %+v", err)
 	}
 	var resp *l8s3.ListObjectsOutput
-	if resp, err = s.ossInstance[req.StoreName].ListObjects(ctx, st); err != nil {
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "oss/ListObjects", req.StoreName)
+	resp, err = s.ossInstance[req.StoreName].ListObjects(spanCtx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 	output := &s3.ListObjectsOutput{}
@@ -348,7 +375,10 @@ func (s *S3Server) GetObjectCannedAcl(ctx context.Context, req *s3.GetObjectCann
 		return nil, status.Errorf(codes.InvalidArgument, "transfer request data fail for GetObjectAcl,err: %+v", err)
 	}
 	var resp *l8s3.GetObjectCannedAclOutput
-	if resp, err = s.ossInstance[req.StoreName].GetObjectCannedAcl(ctx, st); err != nil {
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "oss/GetObjectCannedAcl", req.StoreName)
+	resp, err = s.ossInstance[req.StoreName].GetObjectCannedAcl(spanCtx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 	output := &s3.GetObjectCannedAclOutput{}
@@ -369,7 +399,10 @@ func (s *S3Server) PutObjectCannedAcl(ctx context.Context, req *s3.PutObjectCann
 		return nil, status.Errorf(codes.InvalidArgument, "transfer request data fail for PutObjectAcl,err: %+v", err)
 	}
 	var resp *l8s3.PutObjectCannedAclOutput
-	if resp, err = s.ossInstance[req.StoreName].PutObjectCannedAcl(ctx, st); err != nil {
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "oss/PutObjectCannedAcl", req.StoreName)
+	resp, err = s.ossInstance[req.StoreName].PutObjectCannedAcl(spanCtx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 	output := &s3.PutObjectCannedAclOutput{}
@@ -390,7 +423,10 @@ func (s *S3Server) RestoreObject(ctx context.Context, req *s3.RestoreObjectInput
 		return nil, status.Errorf(codes.InvalidArgument, "transfer request data fail for RestoreObject,err: %+v", err)
 	}
 	var resp *l8s3.RestoreObjectOutput
-	if resp, err = s.ossInstance[req.StoreName].RestoreObject(ctx, st); err != nil {
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "oss/RestoreObject", req.StoreName)
+	resp, err = s.ossInstance[req.StoreName].RestoreObject(spanCtx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 	output := &s3.RestoreObjectOutput{}
@@ -411,7 +447,10 @@ func (s *S3Server) CreateMultipartUpload(ctx context.Context, req *s3.CreateMult
 		return nil, status.Errorf(codes.InvalidArgument, "transfer request data fail for CreateMultipartUpload,err: %+v", err)
 	}
 	var resp *l8s3.CreateMultipartUploadOutput
-	if resp, err = s.ossInstance[req.StoreName].CreateMultipartUpload(ctx, st); err != nil {
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "oss/CreateMultipartUpload", req.StoreName)
+	resp, err = s.ossInstance[req.StoreName].CreateMultipartUpload(spanCtx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 	output := &s3.CreateMultipartUploadOutput{}
@@ -477,7 +516,10 @@ func (s *S3Server) UploadPart(stream s3.ObjectStorageService_UploadPartServer) e
 	}
 	st.DataStream = fileReader
 	var resp *l8s3.UploadPartOutput
-	if resp, err = s.ossInstance[req.StoreName].UploadPart(stream.Context(), st); err != nil {
+	spanCtx, span := trace.StartSpan(stream.Context(), trace.SpanKindClient, "oss/UploadPart", req.StoreName)
+	resp, err = s.ossInstance[req.StoreName].UploadPart(spanCtx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
 		return status.Errorf(codes.Internal, err.Error())
 	}
 	output := &s3.UploadPartOutput{}
@@ -498,7 +540,10 @@ func (s *S3Server) UploadPartCopy(ctx context.Context, req *s3.UploadPartCopyInp
 		return nil, status.Errorf(codes.InvalidArgument, "transfer request data fail for UploadPartCopy,err: %+v", err)
 	}
 	var resp *l8s3.UploadPartCopyOutput
-	if resp, err = s.ossInstance[req.StoreName].UploadPartCopy(ctx, st); err != nil {
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "oss/UploadPartCopy", req.StoreName)
+	resp, err = s.ossInstance[req.StoreName].UploadPartCopy(spanCtx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 	output := &s3.UploadPartCopyOutput{}
@@ -519,7 +564,10 @@ func (s *S3Server) CompleteMultipartUpload(ctx context.Context, req *s3.Complete
 		return nil, status.Errorf(codes.InvalidArgument, "transfer request data fail for CompleteMultipartUpload,err: %+v", err)
 	}
 	var resp *l8s3.CompleteMultipartUploadOutput
-	if resp, err = s.ossInstance[req.StoreName].CompleteMultipartUpload(ctx, st); err != nil {
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "oss/CompleteMultipartUpload", req.StoreName)
+	resp, err = s.ossInstance[req.StoreName].CompleteMultipartUpload(spanCtx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 	output := &s3.CompleteMultipartUploadOutput{}
@@ -540,7 +588,10 @@ func (s *S3Server) AbortMultipartUpload(ctx context.Context, req *s3.AbortMultip
 		return nil, status.Errorf(codes.InvalidArgument, "transfer request data fail for AbortMultipartUpload,err: %+v", err)
 	}
 	var resp *l8s3.AbortMultipartUploadOutput
-	if resp, err = s.ossInstance[req.StoreName].AbortMultipartUpload(ctx, st); err != nil {
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "oss/AbortMultipartUpload", req.StoreName)
+	resp, err = s.ossInstance[req.StoreName].AbortMultipartUpload(spanCtx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 	output := &s3.AbortMultipartUploadOutput{}
@@ -561,7 +612,10 @@ func (s *S3Server) ListMultipartUploads(ctx context.Context, req *s3.ListMultipa
 		return nil, status.Errorf(codes.InvalidArgument, "transfer request data fail for AbortMultipartUpload,err: %+v", err)
 	}
 	var resp *l8s3.ListMultipartUploadsOutput
-	if resp, err = s.ossInstance[req.StoreName].ListMultipartUploads(ctx, st); err != nil {
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "oss/ListMultipartUploads", req.StoreName)
+	resp, err = s.ossInstance[req.StoreName].ListMultipartUploads(spanCtx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 	output := &s3.ListMultipartUploadsOutput{}
@@ -581,7 +635,10 @@ func (s *S3Server) ListObjectVersions(ctx context.Context, req *s3.ListObjectVer
 		return nil, status.Errorf(codes.InvalidArgument, "transfer request data fail for ListObjectVersions,err: %+v", err)
 	}
 	var resp *l8s3.ListObjectVersionsOutput
-	if resp, err = s.ossInstance[req.StoreName].ListObjectVersions(ctx, st); err != nil {
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "oss/ListObjectVersions", req.StoreName)
+	resp, err = s.ossInstance[req.StoreName].ListObjectVersions(spanCtx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 	output := &s3.ListObjectVersionsOutput{}
@@ -603,7 +660,10 @@ func (s *S3Server) HeadObject(ctx context.Context, req *s3.HeadObjectInput) (*s3
 		return nil, status.Errorf(codes.InvalidArgument, "transfer request data fail for ListObjectVersions,err: %+v", err)
 	}
 	var resp *l8s3.HeadObjectOutput
-	if resp, err = s.ossInstance[req.StoreName].HeadObject(ctx, st); err != nil {
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "oss/HeadObject", req.StoreName)
+	resp, err = s.ossInstance[req.StoreName].HeadObject(spanCtx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 	output := &s3.HeadObjectOutput{}
@@ -625,7 +685,10 @@ func (s *S3Server) IsObjectExist(ctx context.Context, req *s3.IsObjectExistInput
 		return nil, status.Errorf(codes.InvalidArgument, "transfer request data fail for IsObjectExist,err: %+v", err)
 	}
 	var resp *l8s3.IsObjectExistOutput
-	if resp, err = s.ossInstance[req.StoreName].IsObjectExist(ctx, st); err != nil {
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "oss/IsObjectExist", req.StoreName)
+	resp, err = s.ossInstance[req.StoreName].IsObjectExist(spanCtx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 	output := &s3.IsObjectExistOutput{}
@@ -643,7 +706,10 @@ func (s *S3Server) SignURL(ctx context.Context, req *s3.SignURLInput) (*s3.SignU
 		return nil, status.Errorf(codes.InvalidArgument, "transfer request data fail for SignURL,err: %+v", err)
 	}
 	var resp *l8s3.SignURLOutput
-	if resp, err = s.ossInstance[req.StoreName].SignURL(ctx, st); err != nil {
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "oss/SignURL", req.StoreName)
+	resp, err = s.ossInstance[req.StoreName].SignURL(spanCtx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 	output := &s3.SignURLOutput{}
@@ -660,7 +726,10 @@ func (s *S3Server) UpdateDownloadBandwidthRateLimit(ctx context.Context, req *s3
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "transfer request data fail for UpdateDownloadBandwidthRateLimit,err: %+v", err)
 	}
-	if err := s.ossInstance[req.StoreName].UpdateDownloadBandwidthRateLimit(ctx, st); err != nil {
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "oss/UpdateDownloadBandwidthRateLimit", req.StoreName)
+	err = s.ossInstance[req.StoreName].UpdateDownloadBandwidthRateLimit(spanCtx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 	return &emptypb.Empty{}, nil
@@ -675,7 +744,10 @@ func (s *S3Server) UpdateUploadBandwidthRateLimit(ctx context.Context, req *s3.U
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "transfer request data fail for UpdateUploadBandwidthRateLimit,err: %+v", err)
 	}
-	if err := s.ossInstance[req.StoreName].UpdateUploadBandwidthRateLimit(ctx, st); err != nil {
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "oss/UpdateUploadBandwidthRateLimit", req.StoreName)
+	err = s.ossInstance[req.StoreName].UpdateUploadBandwidthRateLimit(spanCtx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 	return &emptypb.Empty{}, nil
@@ -735,7 +807,10 @@ func (s *S3Server) AppendObject(stream s3.ObjectStorageService_AppendObjectServe
 	}
 	st.DataStream = fileReader
 	var resp *l8s3.AppendObjectOutput
-	if resp, err = s.ossInstance[req.StoreName].AppendObject(stream.Context(), st); err != nil {
+	spanCtx, span := trace.StartSpan(stream.Context(), trace.SpanKindClient, "oss/AppendObject", req.StoreName)
+	resp, err = s.ossInstance[req.StoreName].AppendObject(spanCtx, st)
+	trace.EndSpan(span, err)
+	if err != nil {
 		return status.Errorf(codes.Internal, err.Error())
 	}
 	output := &s3.AppendObjectOutput{}
@@ -753,7 +828,9 @@ func (s *S3Server) ListParts(ctx context.Context, req *s3.ListPartsInput) (*s3.L
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "transfer request data fail for ListParts,err: %+v", err)
 	}
-	resp, err := s.ossInstance[req.StoreName].ListParts(ctx, st)
+	spanCtx, span := trace.StartSpan(ctx, trace.SpanKindClient, "oss/ListParts", req.StoreName)
+	resp, err := s.ossInstance[req.StoreName].ListParts(spanCtx, st)
+	trace.EndSpan(span, err)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
@@ -26,8 +26,12 @@ import (
 
 	mockoss "mosn.io/layotto/pkg/mock/components/oss"
 
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/types"
 	"mosn.io/pkg/buffer"
 
+	"mosn.io/layotto/components/trace"
+
 	mocks3 "mosn.io/layotto/pkg/mock/runtime/oss"
 
 	"github.com/stretchr/testify/assert"
@@ -209,6 +213,31 @@ func TestDeleteObject(t *testing.T) {
 	assert.Equal(t, "123", resp.VersionId)
 }
 
+// TestDeleteObjectSpanContext checks that the component is called with the context of the child span
+func TestDeleteObjectSpanContext(t *testing.T) {
+	ac := &grpc.ApplicationContext{AppId: "test", Oss: map[string]l8s3.Oss{}}
+	ctrl := gomock.NewController(t)
+	mockossServer := mockoss.NewMockOss(ctrl)
+	ac.Oss[MOCKSERVER] = mockossServer
+	s3Server := &S3Server{appId: ac.AppId, ossInstance: ac.Oss}
+
+	parent := &trace.Span{}
+	parent.SetTraceId("trace")
+	parent.SetSpanId("0.1")
+	ctx := mosnctx.WithValue(context.TODO(), types.ContextKeyActiveSpan, parent)
+	mockossServer.EXPECT().DeleteObject(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *l8s3.DeleteObjectInput) (*l8s3.DeleteObjectOutput, error) {
+			span, ok := mosnctx.Get(ctx, types.ContextKeyActiveSpan).(*trace.Span)
+			assert.True(t, ok)
+			assert.Equal(t, "trace", span.TraceId())
+			assert.Equal(t, "0.1", span.ParentSpanId())
+			assert.Equal(t, "oss/DeleteObject", span.Tag(trace.LAYOTTO_METHOD_NAME))
+			return &l8s3.DeleteObjectOutput{}, nil
+		})
+	_, err := s3Server.DeleteObject(ctx, &s3.DeleteObjectInput{StoreName: MOCKSERVER, Bucket: "layotto", Key: "object"})
+	assert.Nil(t, err)
+}
+
 // TestPutObjectTagging
 func TestPutObjectTagging(t *testing.T) {
 	// prepare oss server
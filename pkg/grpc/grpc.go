@@ -29,8 +29,8 @@ func NewGrpcServer(opts ...Option) (mgrpc.RegisteredServer, error) {
 		opt(&o)
 	}
 	srvMaker := NewDefaultServer
-	o.options = append(o.options, grpc.ChainUnaryInterceptor(diagnostics.UnaryInterceptorFilter))
-	o.options = append(o.options, grpc.ChainStreamInterceptor(diagnostics.StreamInterceptorFilter))
+	o.options = append(o.options, grpc.ChainUnaryInterceptor(diagnostics.UnaryInterceptorFilter, diagnostics.UnaryMetricsInterceptor))
+	o.options = append(o.options, grpc.ChainStreamInterceptor(diagnostics.StreamInterceptorFilter, diagnostics.StreamMetricsInterceptor))
 	if o.maker != nil {
 		srvMaker = o.maker
 	}
@@ -68,6 +68,7 @@ func (s *server) ApplyConfiguration(ctx context.Context, in *runtimev1pb.Dynamic
 
 	// 3. delegate to the components
 	err := holder.ApplyConfig(ctx, in.GetComponentConfig().Metadata)
+	lifecycle.RecordChange(kind, name, in.GetComponentConfig().Metadata, err)
 	return &runtimev1pb.ApplyConfigurationResponse{}, err
 }
 
@@ -37,6 +37,34 @@ func (m *MockFile) EXPECT() *MockFileMockRecorder {
 	return m.recorder
 }
 
+// AbortUpload mocks base method.
+func (m *MockFile) AbortUpload(arg0 context.Context, arg1 *file.AbortUploadStu) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AbortUpload", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AbortUpload indicates an expected call of AbortUpload.
+func (mr *MockFileMockRecorder) AbortUpload(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AbortUpload", reflect.TypeOf((*MockFile)(nil).AbortUpload), arg0, arg1)
+}
+
+// CommitUpload mocks base method.
+func (m *MockFile) CommitUpload(arg0 context.Context, arg1 *file.CommitUploadStu) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CommitUpload", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CommitUpload indicates an expected call of CommitUpload.
+func (mr *MockFileMockRecorder) CommitUpload(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitUpload", reflect.TypeOf((*MockFile)(nil).CommitUpload), arg0, arg1)
+}
+
 // Del mocks base method.
 func (m *MockFile) Del(arg0 context.Context, arg1 *file.DelRequest) error {
 	m.ctrl.T.Helper()
@@ -80,6 +108,21 @@ func (mr *MockFileMockRecorder) Init(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Init", reflect.TypeOf((*MockFile)(nil).Init), arg0, arg1)
 }
 
+// InitUpload mocks base method.
+func (m *MockFile) InitUpload(arg0 context.Context, arg1 *file.InitUploadStu) (*file.UploadSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InitUpload", arg0, arg1)
+	ret0, _ := ret[0].(*file.UploadSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InitUpload indicates an expected call of InitUpload.
+func (mr *MockFileMockRecorder) InitUpload(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitUpload", reflect.TypeOf((*MockFile)(nil).InitUpload), arg0, arg1)
+}
+
 // List mocks base method.
 func (m *MockFile) List(arg0 context.Context, arg1 *file.ListRequest) (*file.ListResp, error) {
 	m.ctrl.T.Helper()
@@ -109,6 +152,20 @@ func (mr *MockFileMockRecorder) Put(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockFile)(nil).Put), arg0, arg1)
 }
 
+// PutChunk mocks base method.
+func (m *MockFile) PutChunk(arg0 context.Context, arg1 *file.PutChunkStu) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutChunk", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutChunk indicates an expected call of PutChunk.
+func (mr *MockFileMockRecorder) PutChunk(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutChunk", reflect.TypeOf((*MockFile)(nil).PutChunk), arg0, arg1)
+}
+
 // Stat mocks base method.
 func (m *MockFile) Stat(arg0 context.Context, arg1 *file.FileMetaRequest) (*file.FileMetaResp, error) {
 	m.ctrl.T.Helper()
@@ -39,6 +39,46 @@ func (m *MockRuntimeClient) EXPECT() *MockRuntimeClientMockRecorder {
 	return m.recorder
 }
 
+// AbortFileUpload mocks base method.
+func (m *MockRuntimeClient) AbortFileUpload(ctx context.Context, in *runtime.FileUploadRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "AbortFileUpload", varargs...)
+	ret0, _ := ret[0].(*emptypb.Empty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AbortFileUpload indicates an expected call of AbortFileUpload.
+func (mr *MockRuntimeClientMockRecorder) AbortFileUpload(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AbortFileUpload", reflect.TypeOf((*MockRuntimeClient)(nil).AbortFileUpload), varargs...)
+}
+
+// CommitFileUpload mocks base method.
+func (m *MockRuntimeClient) CommitFileUpload(ctx context.Context, in *runtime.FileUploadRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CommitFileUpload", varargs...)
+	ret0, _ := ret[0].(*emptypb.Empty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CommitFileUpload indicates an expected call of CommitFileUpload.
+func (mr *MockRuntimeClientMockRecorder) CommitFileUpload(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitFileUpload", reflect.TypeOf((*MockRuntimeClient)(nil).CommitFileUpload), varargs...)
+}
+
 // DelFile mocks base method.
 func (m *MockRuntimeClient) DelFile(ctx context.Context, in *runtime.DelFileRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	m.ctrl.T.Helper()
@@ -259,6 +299,26 @@ func (mr *MockRuntimeClientMockRecorder) GetState(ctx, in interface{}, opts ...i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetState", reflect.TypeOf((*MockRuntimeClient)(nil).GetState), varargs...)
 }
 
+// InitFileUpload mocks base method.
+func (m *MockRuntimeClient) InitFileUpload(ctx context.Context, in *runtime.InitFileUploadRequest, opts ...grpc.CallOption) (*runtime.InitFileUploadResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "InitFileUpload", varargs...)
+	ret0, _ := ret[0].(*runtime.InitFileUploadResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InitFileUpload indicates an expected call of InitFileUpload.
+func (mr *MockRuntimeClientMockRecorder) InitFileUpload(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitFileUpload", reflect.TypeOf((*MockRuntimeClient)(nil).InitFileUpload), varargs...)
+}
+
 // InvokeBinding mocks base method.
 func (m *MockRuntimeClient) InvokeBinding(ctx context.Context, in *runtime.InvokeBindingRequest, opts ...grpc.CallOption) (*runtime.InvokeBindingResponse, error) {
 	m.ctrl.T.Helper()
@@ -359,6 +419,26 @@ func (mr *MockRuntimeClientMockRecorder) PutFile(ctx interface{}, opts ...interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutFile", reflect.TypeOf((*MockRuntimeClient)(nil).PutFile), varargs...)
 }
 
+// PutFileChunk mocks base method.
+func (m *MockRuntimeClient) PutFileChunk(ctx context.Context, opts ...grpc.CallOption) (runtime.Runtime_PutFileChunkClient, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PutFileChunk", varargs...)
+	ret0, _ := ret[0].(runtime.Runtime_PutFileChunkClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PutFileChunk indicates an expected call of PutFileChunk.
+func (mr *MockRuntimeClientMockRecorder) PutFileChunk(ctx interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutFileChunk", reflect.TypeOf((*MockRuntimeClient)(nil).PutFileChunk), varargs...)
+}
+
 // SaveConfiguration mocks base method.
 func (m *MockRuntimeClient) SaveConfiguration(ctx context.Context, in *runtime.SaveConfigurationRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	m.ctrl.T.Helper()
@@ -876,6 +956,143 @@ func (mr *MockRuntime_PutFileClientMockRecorder) Trailer() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trailer", reflect.TypeOf((*MockRuntime_PutFileClient)(nil).Trailer))
 }
 
+// MockRuntime_PutFileChunkClient is a mock of Runtime_PutFileChunkClient interface.
+type MockRuntime_PutFileChunkClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockRuntime_PutFileChunkClientMockRecorder
+}
+
+// MockRuntime_PutFileChunkClientMockRecorder is the mock recorder for MockRuntime_PutFileChunkClient.
+type MockRuntime_PutFileChunkClientMockRecorder struct {
+	mock *MockRuntime_PutFileChunkClient
+}
+
+// NewMockRuntime_PutFileChunkClient creates a new mock instance.
+func NewMockRuntime_PutFileChunkClient(ctrl *gomock.Controller) *MockRuntime_PutFileChunkClient {
+	mock := &MockRuntime_PutFileChunkClient{ctrl: ctrl}
+	mock.recorder = &MockRuntime_PutFileChunkClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRuntime_PutFileChunkClient) EXPECT() *MockRuntime_PutFileChunkClientMockRecorder {
+	return m.recorder
+}
+
+// CloseAndRecv mocks base method.
+func (m *MockRuntime_PutFileChunkClient) CloseAndRecv() (*emptypb.Empty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseAndRecv")
+	ret0, _ := ret[0].(*emptypb.Empty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CloseAndRecv indicates an expected call of CloseAndRecv.
+func (mr *MockRuntime_PutFileChunkClientMockRecorder) CloseAndRecv() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseAndRecv", reflect.TypeOf((*MockRuntime_PutFileChunkClient)(nil).CloseAndRecv))
+}
+
+// CloseSend mocks base method.
+func (m *MockRuntime_PutFileChunkClient) CloseSend() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseSend")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseSend indicates an expected call of CloseSend.
+func (mr *MockRuntime_PutFileChunkClientMockRecorder) CloseSend() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseSend", reflect.TypeOf((*MockRuntime_PutFileChunkClient)(nil).CloseSend))
+}
+
+// Context mocks base method.
+func (m *MockRuntime_PutFileChunkClient) Context() context.Context {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Context")
+	ret0, _ := ret[0].(context.Context)
+	return ret0
+}
+
+// Context indicates an expected call of Context.
+func (mr *MockRuntime_PutFileChunkClientMockRecorder) Context() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockRuntime_PutFileChunkClient)(nil).Context))
+}
+
+// Header mocks base method.
+func (m *MockRuntime_PutFileChunkClient) Header() (metadata.MD, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Header")
+	ret0, _ := ret[0].(metadata.MD)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Header indicates an expected call of Header.
+func (mr *MockRuntime_PutFileChunkClientMockRecorder) Header() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Header", reflect.TypeOf((*MockRuntime_PutFileChunkClient)(nil).Header))
+}
+
+// RecvMsg mocks base method.
+func (m_2 *MockRuntime_PutFileChunkClient) RecvMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "RecvMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecvMsg indicates an expected call of RecvMsg.
+func (mr *MockRuntime_PutFileChunkClientMockRecorder) RecvMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecvMsg", reflect.TypeOf((*MockRuntime_PutFileChunkClient)(nil).RecvMsg), m)
+}
+
+// Send mocks base method.
+func (m *MockRuntime_PutFileChunkClient) Send(arg0 *runtime.PutFileChunkRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send.
+func (mr *MockRuntime_PutFileChunkClientMockRecorder) Send(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockRuntime_PutFileChunkClient)(nil).Send), arg0)
+}
+
+// SendMsg mocks base method.
+func (m_2 *MockRuntime_PutFileChunkClient) SendMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "SendMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMsg indicates an expected call of SendMsg.
+func (mr *MockRuntime_PutFileChunkClientMockRecorder) SendMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMsg", reflect.TypeOf((*MockRuntime_PutFileChunkClient)(nil).SendMsg), m)
+}
+
+// Trailer mocks base method.
+func (m *MockRuntime_PutFileChunkClient) Trailer() metadata.MD {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Trailer")
+	ret0, _ := ret[0].(metadata.MD)
+	return ret0
+}
+
+// Trailer indicates an expected call of Trailer.
+func (mr *MockRuntime_PutFileChunkClientMockRecorder) Trailer() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trailer", reflect.TypeOf((*MockRuntime_PutFileChunkClient)(nil).Trailer))
+}
+
 // MockRuntimeServer is a mock of RuntimeServer interface.
 type MockRuntimeServer struct {
 	ctrl     *gomock.Controller
@@ -899,6 +1116,36 @@ func (m *MockRuntimeServer) EXPECT() *MockRuntimeServerMockRecorder {
 	return m.recorder
 }
 
+// AbortFileUpload mocks base method.
+func (m *MockRuntimeServer) AbortFileUpload(arg0 context.Context, arg1 *runtime.FileUploadRequest) (*emptypb.Empty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AbortFileUpload", arg0, arg1)
+	ret0, _ := ret[0].(*emptypb.Empty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AbortFileUpload indicates an expected call of AbortFileUpload.
+func (mr *MockRuntimeServerMockRecorder) AbortFileUpload(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AbortFileUpload", reflect.TypeOf((*MockRuntimeServer)(nil).AbortFileUpload), arg0, arg1)
+}
+
+// CommitFileUpload mocks base method.
+func (m *MockRuntimeServer) CommitFileUpload(arg0 context.Context, arg1 *runtime.FileUploadRequest) (*emptypb.Empty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CommitFileUpload", arg0, arg1)
+	ret0, _ := ret[0].(*emptypb.Empty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CommitFileUpload indicates an expected call of CommitFileUpload.
+func (mr *MockRuntimeServerMockRecorder) CommitFileUpload(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitFileUpload", reflect.TypeOf((*MockRuntimeServer)(nil).CommitFileUpload), arg0, arg1)
+}
+
 // DelFile mocks base method.
 func (m *MockRuntimeServer) DelFile(arg0 context.Context, arg1 *runtime.DelFileRequest) (*emptypb.Empty, error) {
 	m.ctrl.T.Helper()
@@ -1063,6 +1310,21 @@ func (mr *MockRuntimeServerMockRecorder) GetState(arg0, arg1 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetState", reflect.TypeOf((*MockRuntimeServer)(nil).GetState), arg0, arg1)
 }
 
+// InitFileUpload mocks base method.
+func (m *MockRuntimeServer) InitFileUpload(arg0 context.Context, arg1 *runtime.InitFileUploadRequest) (*runtime.InitFileUploadResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InitFileUpload", arg0, arg1)
+	ret0, _ := ret[0].(*runtime.InitFileUploadResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InitFileUpload indicates an expected call of InitFileUpload.
+func (mr *MockRuntimeServerMockRecorder) InitFileUpload(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitFileUpload", reflect.TypeOf((*MockRuntimeServer)(nil).InitFileUpload), arg0, arg1)
+}
+
 // InvokeBinding mocks base method.
 func (m *MockRuntimeServer) InvokeBinding(arg0 context.Context, arg1 *runtime.InvokeBindingRequest) (*runtime.InvokeBindingResponse, error) {
 	m.ctrl.T.Helper()
@@ -1137,6 +1399,20 @@ func (mr *MockRuntimeServerMockRecorder) PutFile(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutFile", reflect.TypeOf((*MockRuntimeServer)(nil).PutFile), arg0)
 }
 
+// PutFileChunk mocks base method.
+func (m *MockRuntimeServer) PutFileChunk(arg0 runtime.Runtime_PutFileChunkServer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutFileChunk", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutFileChunk indicates an expected call of PutFileChunk.
+func (mr *MockRuntimeServerMockRecorder) PutFileChunk(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutFileChunk", reflect.TypeOf((*MockRuntimeServer)(nil).PutFileChunk), arg0)
+}
+
 // SaveConfiguration mocks base method.
 func (m *MockRuntimeServer) SaveConfiguration(arg0 context.Context, arg1 *runtime.SaveConfigurationRequest) (*emptypb.Empty, error) {
 	m.ctrl.T.Helper()
@@ -1612,3 +1888,137 @@ func (mr *MockRuntime_PutFileServerMockRecorder) SetTrailer(arg0 interface{}) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTrailer", reflect.TypeOf((*MockRuntime_PutFileServer)(nil).SetTrailer), arg0)
 }
+
+// MockRuntime_PutFileChunkServer is a mock of Runtime_PutFileChunkServer interface.
+type MockRuntime_PutFileChunkServer struct {
+	ctrl     *gomock.Controller
+	recorder *MockRuntime_PutFileChunkServerMockRecorder
+}
+
+// MockRuntime_PutFileChunkServerMockRecorder is the mock recorder for MockRuntime_PutFileChunkServer.
+type MockRuntime_PutFileChunkServerMockRecorder struct {
+	mock *MockRuntime_PutFileChunkServer
+}
+
+// NewMockRuntime_PutFileChunkServer creates a new mock instance.
+func NewMockRuntime_PutFileChunkServer(ctrl *gomock.Controller) *MockRuntime_PutFileChunkServer {
+	mock := &MockRuntime_PutFileChunkServer{ctrl: ctrl}
+	mock.recorder = &MockRuntime_PutFileChunkServerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRuntime_PutFileChunkServer) EXPECT() *MockRuntime_PutFileChunkServerMockRecorder {
+	return m.recorder
+}
+
+// Context mocks base method.
+func (m *MockRuntime_PutFileChunkServer) Context() context.Context {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Context")
+	ret0, _ := ret[0].(context.Context)
+	return ret0
+}
+
+// Context indicates an expected call of Context.
+func (mr *MockRuntime_PutFileChunkServerMockRecorder) Context() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockRuntime_PutFileChunkServer)(nil).Context))
+}
+
+// Recv mocks base method.
+func (m *MockRuntime_PutFileChunkServer) Recv() (*runtime.PutFileChunkRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Recv")
+	ret0, _ := ret[0].(*runtime.PutFileChunkRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Recv indicates an expected call of Recv.
+func (mr *MockRuntime_PutFileChunkServerMockRecorder) Recv() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recv", reflect.TypeOf((*MockRuntime_PutFileChunkServer)(nil).Recv))
+}
+
+// RecvMsg mocks base method.
+func (m_2 *MockRuntime_PutFileChunkServer) RecvMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "RecvMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecvMsg indicates an expected call of RecvMsg.
+func (mr *MockRuntime_PutFileChunkServerMockRecorder) RecvMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecvMsg", reflect.TypeOf((*MockRuntime_PutFileChunkServer)(nil).RecvMsg), m)
+}
+
+// SendAndClose mocks base method.
+func (m *MockRuntime_PutFileChunkServer) SendAndClose(arg0 *emptypb.Empty) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendAndClose", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendAndClose indicates an expected call of SendAndClose.
+func (mr *MockRuntime_PutFileChunkServerMockRecorder) SendAndClose(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendAndClose", reflect.TypeOf((*MockRuntime_PutFileChunkServer)(nil).SendAndClose), arg0)
+}
+
+// SendHeader mocks base method.
+func (m *MockRuntime_PutFileChunkServer) SendHeader(arg0 metadata.MD) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendHeader", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendHeader indicates an expected call of SendHeader.
+func (mr *MockRuntime_PutFileChunkServerMockRecorder) SendHeader(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendHeader", reflect.TypeOf((*MockRuntime_PutFileChunkServer)(nil).SendHeader), arg0)
+}
+
+// SendMsg mocks base method.
+func (m_2 *MockRuntime_PutFileChunkServer) SendMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "SendMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMsg indicates an expected call of SendMsg.
+func (mr *MockRuntime_PutFileChunkServerMockRecorder) SendMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMsg", reflect.TypeOf((*MockRuntime_PutFileChunkServer)(nil).SendMsg), m)
+}
+
+// SetHeader mocks base method.
+func (m *MockRuntime_PutFileChunkServer) SetHeader(arg0 metadata.MD) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetHeader", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetHeader indicates an expected call of SetHeader.
+func (mr *MockRuntime_PutFileChunkServerMockRecorder) SetHeader(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHeader", reflect.TypeOf((*MockRuntime_PutFileChunkServer)(nil).SetHeader), arg0)
+}
+
+// SetTrailer mocks base method.
+func (m *MockRuntime_PutFileChunkServer) SetTrailer(arg0 metadata.MD) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetTrailer", arg0)
+}
+
+// SetTrailer indicates an expected call of SetTrailer.
+func (mr *MockRuntime_PutFileChunkServerMockRecorder) SetTrailer(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTrailer", reflect.TypeOf((*MockRuntime_PutFileChunkServer)(nil).SetTrailer), arg0)
+}
@@ -22,6 +22,7 @@ import (
 	"mosn.io/layotto/components/lock"
 
 	"mosn.io/layotto/components/oss"
+	"mosn.io/layotto/pkg/s3gateway"
 
 	"mosn.io/layotto/pkg/runtime/secretstores"
 
@@ -62,6 +63,10 @@ type MosnRuntimeConfig struct {
 	// e.g. <"super_pubsub","etcd",config>
 	CustomComponent map[string]map[string]custom.Config `json:"custom_component,omitempty"`
 	Extends         map[string]json.RawMessage          `json:"extends,omitempty"` // extend config
+	// S3Gateway is an optional HTTP listener which speaks the S3 REST protocol in front of the oss components
+	S3Gateway *s3gateway.Config `json:"s3_gateway,omitempty"`
+	// Readiness configures which components are required by the readiness of the runtime
+	Readiness *ReadinessConfig `json:"readiness,omitempty"`
 	ExtensionComponentConfig
 }
 
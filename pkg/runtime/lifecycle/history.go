@@ -0,0 +1,68 @@
+// Copyright 2021 Layotto Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycle
+
+import (
+	"sync"
+	"time"
+)
+
+// maxHistory is the number of the latest changes kept in the history
+const maxHistory = 100
+
+// Change is a dynamic configuration applied to a component
+type Change struct {
+	Time     time.Time         `json:"time"`
+	Kind     string            `json:"kind"`
+	Name     string            `json:"name"`
+	Metadata map[string]string `json:"metadata"`
+	Error    string            `json:"error,omitempty"`
+}
+
+var (
+	historyMu sync.RWMutex
+	history   []Change
+)
+
+// RecordChange appends a change into the history, the oldest one is dropped if the history is full
+func RecordChange(kind string, name string, metadata map[string]string, err error) {
+	c := Change{
+		Time:     time.Now(),
+		Kind:     kind,
+		Name:     name,
+		Metadata: make(map[string]string, len(metadata)),
+	}
+	for k, v := range metadata {
+		c.Metadata[k] = v
+	}
+	if err != nil {
+		c.Error = err.Error()
+	}
+
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	if len(history) >= maxHistory {
+		history = history[1:]
+	}
+	history = append(history, c)
+}
+
+// History returns a copy of the changes in time order
+func History() []Change {
+	historyMu.RLock()
+	defer historyMu.RUnlock()
+	result := make([]Change, len(history))
+	copy(result, history)
+	return result
+}
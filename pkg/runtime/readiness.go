@@ -0,0 +1,208 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"mosn.io/pkg/utils"
+
+	"mosn.io/layotto/components/pkg/actuators"
+	"mosn.io/layotto/components/pkg/common"
+)
+
+const (
+	defaultPingTimeout = 500 * time.Millisecond
+	// the result of a ping is reused by the checks within the interval
+	defaultPingInterval = 5 * time.Second
+)
+
+var errPingTimeout = errors.New("ping timeout")
+
+// ReadinessConfig configures the readiness indicators of the components
+type ReadinessConfig struct {
+	// Optional are the components whose failure doesn't fail the readiness, as "kind" or "kind.name", e.g. "lock.redis".
+	// The components are required by default.
+	Optional []string `json:"optional,omitempty"`
+	// PingTimeout is the timeout of a ping in milliseconds
+	PingTimeout int `json:"ping_timeout,omitempty"`
+	// PingInterval is the interval in milliseconds to refresh the result of the ping
+	PingInterval int `json:"ping_interval,omitempty"`
+}
+
+// componentIndicator reports the readiness of a component by its Ping.
+// The component is pinged in background, and the last result is reported.
+type componentIndicator struct {
+	kind     string
+	name     string
+	pinger   common.Pinger
+	required bool
+	timeout  time.Duration
+	interval time.Duration
+
+	mu sync.Mutex
+	// err is the result of the last ping finished at pinged
+	err    error
+	pinged time.Time
+	// pinging is closed when the running ping finishes, nil if there isn't one
+	pinging chan struct{}
+}
+
+// pingFunc adapts the components with `Ping() error`, e.g. the dapr state stores
+type pingFunc func() error
+
+func (f pingFunc) Ping(context.Context) error {
+	return f()
+}
+
+func (idc *componentIndicator) Report() (actuators.Status, map[string]interface{}) {
+	details := map[string]interface{}{
+		"kind":     idc.kind,
+		"name":     idc.name,
+		"required": idc.required,
+	}
+	// the component can't be checked without Ping
+	if idc.pinger == nil {
+		return actuators.UNKNOWN, details
+	}
+	err := idc.result()
+	if err == nil {
+		return actuators.UP, details
+	}
+	details["reason"] = err.Error()
+	if idc.required {
+		return actuators.DOWN, details
+	}
+	details["degraded"] = true
+	return actuators.UP, details
+}
+
+// result returns the result of the last ping, and refreshes it in background if it's stale.
+// It waits for the first ping at most the timeout.
+func (idc *componentIndicator) result() error {
+	idc.mu.Lock()
+	pinged := !idc.pinged.IsZero()
+	stale := time.Since(idc.pinged) >= idc.interval
+	idc.mu.Unlock()
+	if stale {
+		done := idc.refresh()
+		if !pinged {
+			select {
+			case <-done:
+			case <-time.After(idc.timeout):
+			}
+		}
+	}
+
+	idc.mu.Lock()
+	defer idc.mu.Unlock()
+	if idc.pinged.IsZero() {
+		return errPingTimeout
+	}
+	return idc.err
+}
+
+// refresh pings the component in background if it isn't being pinged, the returned channel is closed when the ping finishes
+func (idc *componentIndicator) refresh() <-chan struct{} {
+	idc.mu.Lock()
+	defer idc.mu.Unlock()
+	if idc.pinging != nil {
+		return idc.pinging
+	}
+	done := make(chan struct{})
+	idc.pinging = done
+	utils.GoWithRecover(func() {
+		err := errPingTimeout
+		defer func() {
+			idc.mu.Lock()
+			idc.err = err
+			idc.pinged = time.Now()
+			idc.pinging = nil
+			idc.mu.Unlock()
+			close(done)
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), idc.timeout)
+		defer cancel()
+		err = idc.pinger.Ping(ctx)
+	}, nil)
+	return done
+}
+
+// isRequired checks whether the component is required for the readiness
+func (c *ReadinessConfig) isRequired(kind string, name string) bool {
+	if c == nil {
+		return true
+	}
+	for _, optional := range c.Optional {
+		if optional == kind || optional == kind+"."+name {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *ReadinessConfig) pingTimeout() time.Duration {
+	if c == nil || c.PingTimeout <= 0 {
+		return defaultPingTimeout
+	}
+	return time.Duration(c.PingTimeout) * time.Millisecond
+}
+
+func (c *ReadinessConfig) pingInterval() time.Duration {
+	if c == nil || c.PingInterval <= 0 {
+		return defaultPingInterval
+	}
+	return time.Duration(c.PingInterval) * time.Millisecond
+}
+
+// newComponentIndicator creates the readiness indicator of an initialized component
+func newComponentIndicator(config *ReadinessConfig, kind string, name string, comp interface{}) *componentIndicator {
+	idc := &componentIndicator{
+		kind:     kind,
+		name:     name,
+		required: config.isRequired(kind, name),
+		timeout:  config.pingTimeout(),
+		interval: config.pingInterval(),
+	}
+	switch c := comp.(type) {
+	case common.Pinger:
+		idc.pinger = c
+	case interface{ Ping() error }:
+		idc.pinger = pingFunc(c.Ping)
+	}
+	return idc
+}
+
+// registerComponentIndicator registers the readiness indicator of the component, named as "kind.name"
+func (m *MosnRuntime) registerComponentIndicator(kind string, name string, comp interface{}) {
+	var config *ReadinessConfig
+	if m.runtimeConfig != nil {
+		config = m.runtimeConfig.Readiness
+	}
+	idc := newComponentIndicator(config, kind, name, comp)
+	if idc.pinger != nil {
+		// the result is ready before the first check
+		idc.refresh()
+	}
+	actuators.SetComponentsIndicator(fmt.Sprintf("%s.%s", kind, name), &actuators.ComponentsIndicator{
+		ReadinessIndicator: idc,
+	})
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"mosn.io/layotto/components/pkg/actuators"
+)
+
+type mockPinger struct {
+	err   error
+	pings int32
+	// block blocks the ping until it's closed
+	block chan struct{}
+}
+
+func (p *mockPinger) Ping(ctx context.Context) error {
+	atomic.AddInt32(&p.pings, 1)
+	if p.block != nil {
+		<-p.block
+	}
+	return p.err
+}
+
+type mockDaprPinger struct {
+	err error
+}
+
+func (p *mockDaprPinger) Ping() error {
+	return p.err
+}
+
+func TestReadinessConfig(t *testing.T) {
+	var config *ReadinessConfig
+	assert.True(t, config.isRequired("lock", "redis"))
+	assert.Equal(t, defaultPingTimeout, config.pingTimeout())
+	assert.Equal(t, defaultPingInterval, config.pingInterval())
+
+	config = &ReadinessConfig{Optional: []string{"pub_subs", "lock.redis"}, PingTimeout: 100, PingInterval: 1000}
+	assert.False(t, config.isRequired("pub_subs", "kafka"))
+	assert.False(t, config.isRequired("lock", "redis"))
+	assert.True(t, config.isRequired("lock", "etcd"))
+	assert.Equal(t, 100*time.Millisecond, config.pingTimeout())
+	assert.Equal(t, time.Second, config.pingInterval())
+}
+
+func TestComponentIndicator(t *testing.T) {
+	config := &ReadinessConfig{Optional: []string{"sequencer"}}
+
+	// without Ping
+	status, details := newComponentIndicator(config, "hellos", "helloworld", struct{}{}).Report()
+	assert.Equal(t, actuators.UNKNOWN, status)
+	assert.Equal(t, true, details["required"])
+
+	pinger := &mockPinger{}
+	idc := newComponentIndicator(config, "lock", "redis", pinger)
+	status, _ = idc.Report()
+	assert.Equal(t, actuators.UP, status)
+
+	// the result is cached until it's refreshed
+	pinger.err = errors.New("connection refused")
+	status, _ = idc.Report()
+	assert.Equal(t, actuators.UP, status)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&pinger.pings))
+	<-idc.refresh()
+	status, details = idc.Report()
+	assert.Equal(t, actuators.DOWN, status)
+	assert.Equal(t, "connection refused", details["reason"])
+
+	// optional components are degraded instead of down
+	status, details = newComponentIndicator(config, "sequencer", "etcd", pinger).Report()
+	assert.Equal(t, actuators.UP, status)
+	assert.Equal(t, true, details["degraded"])
+	assert.Equal(t, false, details["required"])
+
+	status, _ = newComponentIndicator(config, "state", "redis", &mockDaprPinger{err: errors.New("timeout")}).Report()
+	assert.Equal(t, actuators.DOWN, status)
+}
+
+func TestComponentIndicatorBackground(t *testing.T) {
+	pinger := &mockPinger{block: make(chan struct{})}
+	idc := newComponentIndicator(&ReadinessConfig{PingTimeout: 10}, "lock", "redis", pinger)
+	idc.interval = 0
+
+	// the first check waits for the ping at most the timeout
+	status, details := idc.Report()
+	assert.Equal(t, actuators.DOWN, status)
+	assert.Equal(t, errPingTimeout.Error(), details["reason"])
+
+	// the stale result doesn't wait, and a single ping runs at a time
+	done := idc.refresh()
+	for i := 0; i < 3; i++ {
+		idc.Report()
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&pinger.pings))
+	close(pinger.block)
+	<-done
+	status, _ = idc.Report()
+	assert.Equal(t, actuators.UP, status)
+}
+
+func TestRegisterComponentIndicator(t *testing.T) {
+	m := NewMosnRuntime(&MosnRuntimeConfig{})
+	m.storeDynamicComponent("lock", "mock_ping", &mockPinger{err: errors.New("down")})
+	idc := actuators.GetIndicatorWithName("lock.mock_ping")
+	assert.NotNil(t, idc)
+	assert.Nil(t, idc.LivenessIndicator)
+	status, _ := idc.ReadinessIndicator.Report()
+	assert.Equal(t, actuators.DOWN, status)
+}
@@ -20,13 +20,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
+	"sort"
 	"strings"
 	"time"
 
 	"mosn.io/layotto/components/pkg/common"
+	"mosn.io/layotto/diagnostics"
 	"mosn.io/layotto/pkg/runtime/lifecycle"
 
 	"mosn.io/layotto/components/oss"
+	"mosn.io/layotto/pkg/s3gateway"
 
 	"mosn.io/layotto/pkg/runtime/ref"
 
@@ -67,6 +71,7 @@ type MosnRuntime struct {
 	runtimeConfig *MosnRuntimeConfig
 	info          *info.RuntimeInfo
 	srv           mgrpc.RegisteredServer
+	s3Gateway     *s3gateway.Server
 	// component registry
 	helloRegistry           hello.Registry
 	configStoreRegistry     configstores.Registry
@@ -151,6 +156,55 @@ func (m *MosnRuntime) GetInfo() *info.RuntimeInfo {
 	return m.info
 }
 
+// Components returns the names of the initialized components by kind
+func (m *MosnRuntime) Components() map[string][]string {
+	result := make(map[string][]string)
+	add := func(kind string, names []string) {
+		if len(names) > 0 {
+			sort.Strings(names)
+			result[kind] = names
+		}
+	}
+	keys := func(v interface{}) []string {
+		names := make([]string, 0)
+		for _, k := range reflect.ValueOf(v).MapKeys() {
+			names = append(names, k.String())
+		}
+		return names
+	}
+	add(lifecycle.KindHello, keys(m.hellos))
+	add(lifecycle.KindConfig, keys(m.configStores))
+	add(lifecycle.KindRPC, keys(m.rpcs))
+	add(lifecycle.KindPubsub, keys(m.pubSubs))
+	add(lifecycle.KindState, keys(m.states))
+	add(lifecycle.KindFile, keys(m.files))
+	add(lifecycle.KindOss, keys(m.oss))
+	add(lifecycle.KindLock, keys(m.locks))
+	add(lifecycle.KindSequencer, keys(m.sequencers))
+	add(lifecycle.KindBinding, keys(m.outputBindings))
+	add(lifecycle.KindSecret, keys(m.secretStores))
+	add("cryption", keys(m.cryptionService))
+	add("email", keys(m.emailService))
+	add("phone", keys(m.phoneCallService))
+	for kind, comps := range m.customComponent {
+		add(fmt.Sprintf("%s.%s", lifecycle.KindCustom, kind), keys(comps))
+	}
+	return result
+}
+
+// GetSecret returns the secret of the key in the secret store
+func (m *MosnRuntime) GetSecret(storeName string, key string) (map[string]string, error) {
+	store, ok := m.secretStores[storeName]
+	if !ok || store == nil {
+		return nil, fmt.Errorf("secret store %s not found", storeName)
+	}
+	resp, err := store.GetSecret(secretstores.GetSecretRequest{Name: key})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
 func (m *MosnRuntime) sendToOutputBinding(name string, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
 	if req.Operation == "" {
 		return nil, errors.New("operation field is missing from request")
@@ -193,6 +247,8 @@ func (m *MosnRuntime) Run(opts ...Option) (mgrpc.RegisteredServer, error) {
 	if err := m.initRuntime(o); err != nil {
 		return nil, err
 	}
+	// the metrics are labeled by the initialized components only
+	diagnostics.SetComponents(m.Components())
 	// prepare grpcOpts
 	var grpcOpts []grpc.Option
 	if o.srvMaker != nil {
@@ -215,19 +271,48 @@ func (m *MosnRuntime) Run(opts ...Option) (mgrpc.RegisteredServer, error) {
 		grpc.WithGrpcOptions(o.options...),
 		grpc.WithGrpcAPIs(apis),
 	)
-	// 3. create grpc server
+	// 3. start the s3 gateway
+	if err := m.startS3Gateway(); err != nil {
+		return nil, err
+	}
+	// 4. create grpc server
 	var err error
 	m.srv, err = grpc.NewGrpcServer(grpcOpts...)
 	return m.srv, err
 }
 
+func (m *MosnRuntime) startS3Gateway() error {
+	if m.runtimeConfig == nil || m.runtimeConfig.S3Gateway == nil {
+		return nil
+	}
+	log.DefaultLogger.Infof("[runtime] start s3 gateway")
+	gateway, err := s3gateway.NewServer(m.runtimeConfig.S3Gateway, m.oss)
+	if err != nil {
+		m.errInt(err, "create s3 gateway failed")
+		return err
+	}
+	if err := gateway.Start(); err != nil {
+		m.errInt(err, "start s3 gateway failed")
+		return err
+	}
+	m.s3Gateway = gateway
+	return nil
+}
+
 func (m *MosnRuntime) Stop() {
+	if m.s3Gateway != nil {
+		if err := m.s3Gateway.Stop(); err != nil {
+			log.DefaultLogger.Errorf("[runtime] stop s3 gateway failed: %v", err)
+		}
+	}
 	if m.srv != nil {
 		m.srv.Stop()
 	}
 }
 
 func (m *MosnRuntime) storeDynamicComponent(kind string, name string, store interface{}) {
+	// every initialized component is stored here, so register its readiness indicator as well
+	m.registerComponentIndicator(kind, name, store)
 	comp, ok := store.(common.DynamicComponent)
 	if !ok {
 		return
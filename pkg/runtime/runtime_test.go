@@ -864,3 +864,24 @@ func TestMosnRuntimeWithOssConfig(t *testing.T) {
 		rt.Stop()
 	})
 }
+
+func TestMosnRuntime_Components(t *testing.T) {
+	m := NewMosnRuntime(&MosnRuntimeConfig{})
+	assert.Equal(t, 0, len(m.Components()))
+
+	m.locks["redis"] = nil
+	m.locks["etcd"] = nil
+	m.states["in-memory"] = nil
+	m.SetCustomComponent("super_pubsub", "etcd", nil)
+	assert.Equal(t, map[string][]string{
+		"lock":                          {"etcd", "redis"},
+		"state":                         {"in-memory"},
+		"custom_component.super_pubsub": {"etcd"},
+	}, m.Components())
+}
+
+func TestMosnRuntime_GetSecret(t *testing.T) {
+	m := NewMosnRuntime(&MosnRuntimeConfig{})
+	_, err := m.GetSecret("local.file", "token")
+	assert.NotNil(t, err)
+}
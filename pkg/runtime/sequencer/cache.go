@@ -22,6 +22,7 @@ import (
 	"mosn.io/pkg/utils"
 
 	"mosn.io/layotto/components/sequencer"
+	"mosn.io/layotto/diagnostics/metrics"
 )
 
 const defaultSize = 10000
@@ -151,6 +152,7 @@ func (d *DoubleBuffer) getNewBuffer() (*Buffer, error) {
 	if !support {
 		return nil, errors.New("[DoubleBuffer] unSupport Segment id")
 	}
+	metrics.SequencerRefills.Inc()
 	return &Buffer{
 		from: result.From,
 		to:   result.To,
@@ -0,0 +1,352 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3gateway
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"mosn.io/layotto/components/oss"
+)
+
+const (
+	defaultMaxKeys = 1000
+	// the max size of the xml bodies in requests
+	maxXMLBodySize = 2 << 20
+)
+
+func (s *Server) serveBucket(w http.ResponseWriter, r *http.Request, t *target) {
+	query := r.URL.Query()
+	switch r.Method {
+	case http.MethodHead:
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		switch {
+		case has(query, "location"):
+			writeXML(w, http.StatusOK, &locationConstraint{Xmlns: s3Namespace, Location: s.config.Region})
+		case has(query, "uploads"):
+			s.listMultipartUploads(w, r, t, query)
+		case has(query, "versions"):
+			s.listObjectVersions(w, r, t, query)
+		case query.Get("list-type") == "2":
+			s.listObjectsV2(w, r, t, query)
+		default:
+			s.listObjects(w, r, t, query)
+		}
+	case http.MethodPost:
+		if !has(query, "delete") {
+			writeError(w, r, errNotImplemented)
+			return
+		}
+		s.deleteObjects(w, r, t)
+	default:
+		// creating and deleting buckets are not supported
+		writeError(w, r, errNotImplemented)
+	}
+}
+
+func (s *Server) listObjects(w http.ResponseWriter, r *http.Request, t *target, query url.Values) {
+	maxKeys, err := parseMaxKeys(query.Get("max-keys"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	out, err := t.store.ListObjects(r.Context(), &oss.ListObjectsInput{
+		Bucket:       t.bucket,
+		Delimiter:    query.Get("delimiter"),
+		EncodingType: query.Get("encoding-type"),
+		Marker:       query.Get("marker"),
+		MaxKeys:      maxKeys,
+		Prefix:       query.Get("prefix"),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	result := &listBucketResult{
+		Xmlns:          s3Namespace,
+		Name:           t.name,
+		Prefix:         query.Get("prefix"),
+		Marker:         query.Get("marker"),
+		MaxKeys:        maxKeys,
+		Delimiter:      query.Get("delimiter"),
+		EncodingType:   query.Get("encoding-type"),
+		IsTruncated:    out.IsTruncated,
+		Contents:       toObjectInfos(out.Contents),
+		CommonPrefixes: toCommonPrefixes(out.CommonPrefixes),
+	}
+	if out.IsTruncated {
+		result.NextMarker = nextMarker(out)
+	}
+	writeXML(w, http.StatusOK, result)
+}
+
+// listObjectsV2 is implemented with ListObjects of the components.
+// The continuation token is the base64 encoded marker.
+func (s *Server) listObjectsV2(w http.ResponseWriter, r *http.Request, t *target, query url.Values) {
+	maxKeys, err := parseMaxKeys(query.Get("max-keys"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	marker := query.Get("start-after")
+	if token := query.Get("continuation-token"); token != "" {
+		decoded, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			writeError(w, r, errInvalidArgument.withMessage("The continuation token provided is incorrect"))
+			return
+		}
+		marker = string(decoded)
+	}
+	out, err := t.store.ListObjects(r.Context(), &oss.ListObjectsInput{
+		Bucket:       t.bucket,
+		Delimiter:    query.Get("delimiter"),
+		EncodingType: query.Get("encoding-type"),
+		Marker:       marker,
+		MaxKeys:      maxKeys,
+		Prefix:       query.Get("prefix"),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	result := &listBucketV2Result{
+		Xmlns:             s3Namespace,
+		Name:              t.name,
+		Prefix:            query.Get("prefix"),
+		StartAfter:        query.Get("start-after"),
+		ContinuationToken: query.Get("continuation-token"),
+		KeyCount:          len(out.Contents) + len(out.CommonPrefixes),
+		MaxKeys:           maxKeys,
+		Delimiter:         query.Get("delimiter"),
+		EncodingType:      query.Get("encoding-type"),
+		IsTruncated:       out.IsTruncated,
+		Contents:          toObjectInfos(out.Contents),
+		CommonPrefixes:    toCommonPrefixes(out.CommonPrefixes),
+	}
+	if out.IsTruncated {
+		result.NextContinuationToken = base64.StdEncoding.EncodeToString([]byte(nextMarker(out)))
+	}
+	// the owners are only returned when fetch-owner is set
+	if query.Get("fetch-owner") != "true" {
+		for i := range result.Contents {
+			result.Contents[i].Owner = nil
+		}
+	}
+	writeXML(w, http.StatusOK, result)
+}
+
+// nextMarker falls back to the last key or common prefix, as some components don't return NextMarker
+func nextMarker(out *oss.ListObjectsOutput) string {
+	if out.NextMarker != "" {
+		return out.NextMarker
+	}
+	marker := ""
+	if n := len(out.Contents); n > 0 {
+		marker = out.Contents[n-1].Key
+	}
+	if n := len(out.CommonPrefixes); n > 0 && out.CommonPrefixes[n-1] > marker {
+		marker = out.CommonPrefixes[n-1]
+	}
+	return marker
+}
+
+func (s *Server) listObjectVersions(w http.ResponseWriter, r *http.Request, t *target, query url.Values) {
+	maxKeys, err := parseMaxKeys(query.Get("max-keys"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	out, err := t.store.ListObjectVersions(r.Context(), &oss.ListObjectVersionsInput{
+		Bucket:          t.bucket,
+		Delimiter:       query.Get("delimiter"),
+		EncodingType:    query.Get("encoding-type"),
+		KeyMarker:       query.Get("key-marker"),
+		MaxKeys:         maxKeys,
+		Prefix:          query.Get("prefix"),
+		VersionIdMarker: query.Get("version-id-marker"),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	result := &listVersionsResult{
+		Xmlns:               s3Namespace,
+		Name:                t.name,
+		Prefix:              query.Get("prefix"),
+		KeyMarker:           query.Get("key-marker"),
+		VersionIDMarker:     query.Get("version-id-marker"),
+		NextKeyMarker:       out.NextKeyMarker,
+		NextVersionIDMarker: out.NextVersionIdMarker,
+		MaxKeys:             maxKeys,
+		Delimiter:           query.Get("delimiter"),
+		IsTruncated:         out.IsTruncated,
+		CommonPrefixes:      toCommonPrefixes(out.CommonPrefixes),
+	}
+	for _, v := range out.Versions {
+		result.Versions = append(result.Versions, objectVersionInfo{
+			Key:          v.Key,
+			VersionID:    v.VersionId,
+			IsLatest:     v.IsLatest,
+			LastModified: formatTime(v.LastModified),
+			ETag:         v.ETag,
+			Size:         v.Size,
+			StorageClass: v.StorageClass,
+			Owner:        toOwner(v.Owner),
+		})
+	}
+	for _, m := range out.DeleteMarkers {
+		result.DeleteMarkers = append(result.DeleteMarkers, deleteMarkerInfo{
+			Key:          m.Key,
+			VersionID:    m.VersionId,
+			IsLatest:     m.IsLatest,
+			LastModified: formatTime(m.LastModified),
+			Owner:        toOwner(m.Owner),
+		})
+	}
+	writeXML(w, http.StatusOK, result)
+}
+
+func (s *Server) listMultipartUploads(w http.ResponseWriter, r *http.Request, t *target, query url.Values) {
+	maxUploads, err := parseMaxKeys(query.Get("max-uploads"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	out, err := t.store.ListMultipartUploads(r.Context(), &oss.ListMultipartUploadsInput{
+		Bucket:         t.bucket,
+		Delimiter:      query.Get("delimiter"),
+		EncodingType:   query.Get("encoding-type"),
+		KeyMarker:      query.Get("key-marker"),
+		MaxUploads:     int64(maxUploads),
+		Prefix:         query.Get("prefix"),
+		UploadIdMarker: query.Get("upload-id-marker"),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	result := &listMultipartUploadsResult{
+		Xmlns:              s3Namespace,
+		Bucket:             t.name,
+		KeyMarker:          query.Get("key-marker"),
+		UploadIDMarker:     query.Get("upload-id-marker"),
+		NextKeyMarker:      out.NextKeyMarker,
+		NextUploadIDMarker: out.NextUploadIDMarker,
+		Prefix:             query.Get("prefix"),
+		Delimiter:          query.Get("delimiter"),
+		MaxUploads:         maxUploads,
+		IsTruncated:        out.IsTruncated,
+		CommonPrefixes:     toCommonPrefixes(out.CommonPrefixes),
+	}
+	for _, u := range out.Uploads {
+		info := uploadInfo{
+			Key:          u.Key,
+			UploadID:     u.UploadId,
+			Owner:        toOwner(u.Owner),
+			StorageClass: u.StorageClass,
+			Initiated:    formatTime(u.Initiated),
+		}
+		if u.Initiator != nil {
+			info.Initiator = &owner{ID: u.Initiator.ID, DisplayName: u.Initiator.DisplayName}
+		}
+		result.Uploads = append(result.Uploads, info)
+	}
+	writeXML(w, http.StatusOK, result)
+}
+
+func (s *Server) deleteObjects(w http.ResponseWriter, r *http.Request, t *target) {
+	req := &deleteRequest{}
+	if err := xml.NewDecoder(http.MaxBytesReader(w, r.Body, maxXMLBodySize)).Decode(req); err != nil {
+		writeError(w, r, errMalformedXML)
+		return
+	}
+	input := &oss.DeleteObjectsInput{Bucket: t.bucket, Delete: &oss.Delete{Quiet: req.Quiet}}
+	for _, o := range req.Objects {
+		input.Delete.Objects = append(input.Delete.Objects, &oss.ObjectIdentifier{Key: o.Key, VersionId: o.VersionID})
+	}
+	out, err := t.store.DeleteObjects(r.Context(), input)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	result := &deleteResult{Xmlns: s3Namespace}
+	if !req.Quiet {
+		for _, d := range out.Deleted {
+			result.Deleted = append(result.Deleted, deletedObject{
+				Key:                   d.Key,
+				VersionID:             d.VersionId,
+				DeleteMarker:          d.DeleteMarker,
+				DeleteMarkerVersionID: d.DeleteMarkerVersionId,
+			})
+		}
+	}
+	writeXML(w, http.StatusOK, result)
+}
+
+func parseMaxKeys(s string) (int32, error) {
+	if s == "" {
+		return defaultMaxKeys, nil
+	}
+	n, err := strconv.ParseInt(s, 10, 32)
+	if err != nil || n < 0 {
+		return 0, errInvalidArgument.withMessage("Provided max-keys not an integer or within integer range")
+	}
+	if n > defaultMaxKeys {
+		n = defaultMaxKeys
+	}
+	return int32(n), nil
+}
+
+func toObjectInfos(objects []*oss.Object) []objectInfo {
+	infos := make([]objectInfo, 0, len(objects))
+	for _, o := range objects {
+		infos = append(infos, objectInfo{
+			Key:          o.Key,
+			LastModified: formatTime(o.LastModified),
+			ETag:         o.ETag,
+			Size:         o.Size,
+			StorageClass: o.StorageClass,
+			Owner:        toOwner(o.Owner),
+		})
+	}
+	return infos
+}
+
+func toCommonPrefixes(prefixes []string) []commonPrefix {
+	result := make([]commonPrefix, 0, len(prefixes))
+	for _, p := range prefixes {
+		result = append(result, commonPrefix{Prefix: p})
+	}
+	return result
+}
+
+func toOwner(o *oss.Owner) *owner {
+	if o == nil {
+		return nil
+	}
+	return &owner{ID: o.ID, DisplayName: o.DisplayName}
+}
+
+// has reports whether the sub-resource, e.g. `?uploads`, is in the query
+func has(query url.Values, key string) bool {
+	_, ok := query[key]
+	return ok
+}
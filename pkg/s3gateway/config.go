@@ -0,0 +1,79 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3gateway
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	defaultRegion = "us-east-1"
+)
+
+// Config is the configuration of the S3 gateway, i.e. the `s3_gateway` section in the runtime config.
+type Config struct {
+	// The address to listen on, e.g. "127.0.0.1:34906"
+	Address string `json:"address"`
+	// The region used in the credential scope of SigV4. Defaults to "us-east-1"
+	Region string `json:"region,omitempty"`
+	// The credentials which are accepted when verifying SigV4 signatures
+	Credentials []Credential `json:"credentials,omitempty"`
+	// Skip the signature verification. It's only for local development.
+	AllowAnonymous bool `json:"allow_anonymous,omitempty"`
+	// Buckets maps the bucket names in the S3 requests to the oss components.
+	Buckets map[string]BucketConfig `json:"buckets,omitempty"`
+	// DefaultStore is the oss component for the buckets which are not listed in `Buckets`.
+	// The bucket names are passed through as is. Leave it empty to reject unknown buckets.
+	DefaultStore string `json:"default_store,omitempty"`
+}
+
+// Credential is an access key pair of the S3 gateway.
+type Credential struct {
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+}
+
+// BucketConfig is the oss component and the real bucket name which a bucket is mapped to.
+type BucketConfig struct {
+	StoreName string `json:"store_name"`
+	// The bucket name in the oss component. Defaults to the bucket name in the request.
+	Bucket string `json:"bucket,omitempty"`
+}
+
+func (c *Config) validate() error {
+	if c.Address == "" {
+		return errors.New("[s3gateway] address is required")
+	}
+	if len(c.Credentials) == 0 && !c.AllowAnonymous {
+		return errors.New("[s3gateway] credentials are required unless allow_anonymous is set")
+	}
+	for _, cred := range c.Credentials {
+		if cred.AccessKeyID == "" || cred.SecretAccessKey == "" {
+			return errors.New("[s3gateway] access_key_id and secret_access_key are required in credentials")
+		}
+	}
+	for name, b := range c.Buckets {
+		if b.StoreName == "" {
+			return fmt.Errorf("[s3gateway] store_name of bucket %s is required", name)
+		}
+	}
+	if c.Region == "" {
+		c.Region = defaultRegion
+	}
+	return nil
+}
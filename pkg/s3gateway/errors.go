@@ -0,0 +1,83 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3gateway
+
+import (
+	"net/http"
+
+	"mosn.io/layotto/components/oss"
+)
+
+// apiError is an error in the S3 REST protocol.
+// See https://docs.aws.amazon.com/AmazonS3/latest/API/ErrorResponses.html
+type apiError struct {
+	Code       string
+	Message    string
+	StatusCode int
+}
+
+func (e *apiError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// withMessage returns a copy of the error with another message
+func (e *apiError) withMessage(msg string) *apiError {
+	return &apiError{Code: e.Code, Message: msg, StatusCode: e.StatusCode}
+}
+
+var (
+	errAccessDenied          = &apiError{"AccessDenied", "Access Denied.", http.StatusForbidden}
+	errExpiredRequest        = &apiError{"AccessDenied", "Request has expired.", http.StatusForbidden}
+	errHostNotSigned         = &apiError{"AccessDenied", "The host header must be signed.", http.StatusForbidden}
+	errSignatureDoesNotMatch = &apiError{"SignatureDoesNotMatch",
+		"The request signature we calculated does not match the signature you provided.", http.StatusForbidden}
+	errInvalidAccessKeyID = &apiError{"InvalidAccessKeyId",
+		"The AWS access key ID you provided does not exist in our records.", http.StatusForbidden}
+	errRequestTimeTooSkewed = &apiError{"RequestTimeTooSkewed",
+		"The difference between the request time and the server's time is too large.", http.StatusForbidden}
+	errAuthorizationMalformed = &apiError{"AuthorizationHeaderMalformed",
+		"The authorization header that you provided is not valid.", http.StatusBadRequest}
+	errUnsupportedSignature = &apiError{"InvalidRequest",
+		"Only AWS4-HMAC-SHA256 signatures are supported.", http.StatusBadRequest}
+	errContentSHA256Mismatch = &apiError{"XAmzContentSHA256Mismatch",
+		"The provided 'x-amz-content-sha256' header does not match what was computed.", http.StatusBadRequest}
+	errIncompleteBody = &apiError{"IncompleteBody",
+		"You did not provide the number of bytes specified by the Content-Length HTTP header.", http.StatusBadRequest}
+	errNoSuchBucket = &apiError{"NoSuchBucket", "The specified bucket does not exist.", http.StatusNotFound}
+	errNoSuchKey    = &apiError{"NoSuchKey", "The specified key does not exist.", http.StatusNotFound}
+	errMalformedXML = &apiError{"MalformedXML",
+		"The XML you provided was not well-formed or did not validate against our published schema.", http.StatusBadRequest}
+	errInvalidArgument = &apiError{"InvalidArgument", "Invalid Argument.", http.StatusBadRequest}
+	errInvalidRange    = &apiError{"InvalidRange", "The requested range is not satisfiable.", http.StatusRequestedRangeNotSatisfiable}
+	errNotImplemented  = &apiError{"NotImplemented",
+		"A header you provided implies functionality that is not implemented.", http.StatusNotImplemented}
+	errMethodNotAllowed = &apiError{"MethodNotAllowed",
+		"The specified method is not allowed against this resource.", http.StatusMethodNotAllowed}
+	errInternal = &apiError{"InternalError",
+		"We encountered an internal error. Please try again.", http.StatusInternalServerError}
+)
+
+// toAPIError converts the errors returned by the oss components into apiError
+func toAPIError(err error) *apiError {
+	if e, ok := err.(*apiError); ok {
+		return e
+	}
+	if oss.IsNotSupportMethod(err) {
+		return errNotImplemented.withMessage(err.Error())
+	}
+	return errInternal.withMessage(err.Error())
+}
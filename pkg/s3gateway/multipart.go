@@ -0,0 +1,224 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3gateway
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"mosn.io/layotto/components/oss"
+)
+
+func (s *Server) createMultipartUpload(w http.ResponseWriter, r *http.Request, t *target, key string) {
+	tags, err := parseTagging(r.Header.Get("X-Amz-Tagging"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	out, err := t.store.CreateMultipartUpload(r.Context(), &oss.CreateMultipartUploadInput{
+		Bucket:               t.bucket,
+		Key:                  key,
+		ACL:                  r.Header.Get("X-Amz-Acl"),
+		CacheControl:         r.Header.Get("Cache-Control"),
+		ContentDisposition:   r.Header.Get("Content-Disposition"),
+		ContentEncoding:      contentEncoding(r),
+		ContentLanguage:      r.Header.Get("Content-Language"),
+		ContentType:          r.Header.Get("Content-Type"),
+		Expires:              parseHTTPTime(r.Header.Get("Expires")),
+		MetaData:             userMetadata(r.Header),
+		ServerSideEncryption: r.Header.Get("X-Amz-Server-Side-Encryption"),
+		StorageClass:         r.Header.Get("X-Amz-Storage-Class"),
+		Tagging:              tags,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeXML(w, http.StatusOK, &initiateMultipartUploadResult{
+		Xmlns:    s3Namespace,
+		Bucket:   t.name,
+		Key:      key,
+		UploadID: out.UploadId,
+	})
+}
+
+// uploadPart serves both UploadPart and UploadPartCopy
+func (s *Server) uploadPart(w http.ResponseWriter, r *http.Request, t *target, key string, query url.Values) {
+	partNumber, err := strconv.ParseInt(query.Get("partNumber"), 10, 32)
+	if err != nil || partNumber < 1 || partNumber > maxPartNumber {
+		writeError(w, r, errInvalidArgument.withMessage("Part number must be an integer between 1 and 10000, inclusive"))
+		return
+	}
+	uploadID := query.Get("uploadId")
+	if r.Header.Get("X-Amz-Copy-Source") != "" {
+		s.uploadPartCopy(w, r, t, key, int32(partNumber), uploadID)
+		return
+	}
+	out, err := t.store.UploadPart(r.Context(), &oss.UploadPartInput{
+		DataStream:    r.Body,
+		Bucket:        t.bucket,
+		Key:           key,
+		ContentLength: contentLength(r),
+		ContentMd5:    r.Header.Get("Content-Md5"),
+		PartNumber:    int32(partNumber),
+		UploadId:      uploadID,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if out.ETag != "" {
+		w.Header().Set("ETag", out.ETag)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) uploadPartCopy(w http.ResponseWriter, r *http.Request, t *target, key string, partNumber int32, uploadID string) {
+	source, err := s.copySource(r, t)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	input := &oss.UploadPartCopyInput{
+		Bucket:     t.bucket,
+		Key:        key,
+		CopySource: source,
+		PartNumber: partNumber,
+		UploadId:   uploadID,
+	}
+	// x-amz-copy-source-range: bytes=first-last
+	if rng := r.Header.Get("X-Amz-Copy-Source-Range"); rng != "" {
+		parts := strings.SplitN(strings.TrimPrefix(rng, "bytes="), "-", 2)
+		var first, last int64
+		if len(parts) == 2 {
+			first, err = strconv.ParseInt(parts[0], 10, 64)
+			if err == nil {
+				last, err = strconv.ParseInt(parts[1], 10, 64)
+			}
+		}
+		if len(parts) != 2 || err != nil || last < first {
+			writeError(w, r, errInvalidArgument.withMessage("The x-amz-copy-source-range value must be of the form bytes=first-last"))
+			return
+		}
+		input.StartPosition = first
+		input.PartSize = last - first + 1
+	}
+	out, err := t.store.UploadPartCopy(r.Context(), input)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	result := &copyPartResult{Xmlns: s3Namespace}
+	if out.CopyPartResult != nil {
+		result.ETag = out.CopyPartResult.ETag
+		result.LastModified = formatTime(out.CopyPartResult.LastModified)
+	}
+	writeXML(w, http.StatusOK, result)
+}
+
+func (s *Server) completeMultipartUpload(w http.ResponseWriter, r *http.Request, t *target, key string, query url.Values) {
+	req := &completeMultipartUpload{}
+	if err := xml.NewDecoder(http.MaxBytesReader(w, r.Body, maxXMLBodySize)).Decode(req); err != nil {
+		writeError(w, r, errMalformedXML)
+		return
+	}
+	upload := &oss.CompletedMultipartUpload{}
+	for _, p := range req.Parts {
+		upload.Parts = append(upload.Parts, &oss.CompletedPart{ETag: p.ETag, PartNumber: p.PartNumber})
+	}
+	out, err := t.store.CompleteMultipartUpload(r.Context(), &oss.CompleteMultipartUploadInput{
+		Bucket:          t.bucket,
+		Key:             key,
+		UploadId:        query.Get("uploadId"),
+		MultipartUpload: upload,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if out.VersionId != "" {
+		w.Header().Set("X-Amz-Version-Id", out.VersionId)
+	}
+	writeXML(w, http.StatusOK, &completeMultipartUploadResult{
+		Xmlns:    s3Namespace,
+		Location: out.Location,
+		Bucket:   t.name,
+		Key:      key,
+		ETag:     out.ETag,
+	})
+}
+
+func (s *Server) abortMultipartUpload(w http.ResponseWriter, r *http.Request, t *target, key string, query url.Values) {
+	_, err := t.store.AbortMultipartUpload(r.Context(), &oss.AbortMultipartUploadInput{
+		Bucket:   t.bucket,
+		Key:      key,
+		UploadId: query.Get("uploadId"),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listParts(w http.ResponseWriter, r *http.Request, t *target, key string, query url.Values) {
+	maxParts, err := parseMaxKeys(query.Get("max-parts"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	var marker int64
+	if m := query.Get("part-number-marker"); m != "" {
+		if marker, err = strconv.ParseInt(m, 10, 64); err != nil {
+			writeError(w, r, errInvalidArgument.withMessage("Invalid part-number-marker"))
+			return
+		}
+	}
+	out, err := t.store.ListParts(r.Context(), &oss.ListPartsInput{
+		Bucket:           t.bucket,
+		Key:              key,
+		MaxParts:         int64(maxParts),
+		PartNumberMarker: marker,
+		UploadId:         query.Get("uploadId"),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	result := &listPartsResult{
+		Xmlns:                s3Namespace,
+		Bucket:               t.name,
+		Key:                  key,
+		UploadID:             query.Get("uploadId"),
+		PartNumberMarker:     marker,
+		NextPartNumberMarker: out.NextPartNumberMarker,
+		MaxParts:             int64(maxParts),
+		IsTruncated:          out.IsTruncated,
+	}
+	for _, p := range out.Parts {
+		result.Parts = append(result.Parts, partInfo{
+			PartNumber:   p.PartNumber,
+			LastModified: formatTime(p.LastModified),
+			ETag:         p.Etag,
+			Size:         p.Size,
+		})
+	}
+	writeXML(w, http.StatusOK, result)
+}
@@ -0,0 +1,555 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3gateway
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mosn.io/pkg/log"
+
+	"mosn.io/layotto/components/oss"
+)
+
+const (
+	metaPrefix    = "X-Amz-Meta-"
+	maxPartNumber = 10000
+)
+
+func (s *Server) serveObject(w http.ResponseWriter, r *http.Request, t *target, key string) {
+	query := r.URL.Query()
+	switch r.Method {
+	case http.MethodGet:
+		switch {
+		case has(query, "tagging"):
+			s.getObjectTagging(w, r, t, key, query)
+		case has(query, "uploadId"):
+			s.listParts(w, r, t, key, query)
+		default:
+			s.getObject(w, r, t, key, query)
+		}
+	case http.MethodHead:
+		s.getObject(w, r, t, key, query)
+	case http.MethodPut:
+		switch {
+		case has(query, "tagging"):
+			s.putObjectTagging(w, r, t, key, query)
+		case has(query, "uploadId") && has(query, "partNumber"):
+			s.uploadPart(w, r, t, key, query)
+		case r.Header.Get("X-Amz-Copy-Source") != "":
+			s.copyObject(w, r, t, key)
+		default:
+			s.putObject(w, r, t, key)
+		}
+	case http.MethodPost:
+		switch {
+		case has(query, "uploads"):
+			s.createMultipartUpload(w, r, t, key)
+		case has(query, "uploadId"):
+			s.completeMultipartUpload(w, r, t, key, query)
+		default:
+			writeError(w, r, errNotImplemented)
+		}
+	case http.MethodDelete:
+		switch {
+		case has(query, "tagging"):
+			s.deleteObjectTagging(w, r, t, key, query)
+		case has(query, "uploadId"):
+			s.abortMultipartUpload(w, r, t, key, query)
+		default:
+			s.deleteObject(w, r, t, key, query)
+		}
+	default:
+		writeError(w, r, errMethodNotAllowed)
+	}
+}
+
+// getObject serves both GetObject and HeadObject.
+// The range is passed to the component, and it's applied by the gateway on the stream if the component doesn't report
+// the range applied, e.g. suffix ranges or the components ignoring `Start` and `End`.
+func (s *Server) getObject(w http.ResponseWriter, r *http.Request, t *target, key string, query url.Values) {
+	input := &oss.GetObjectInput{
+		Bucket:                     t.bucket,
+		Key:                        key,
+		VersionId:                  query.Get("versionId"),
+		IfMatch:                    r.Header.Get("If-Match"),
+		IfNoneMatch:                r.Header.Get("If-None-Match"),
+		IfModifiedSince:            parseHTTPTime(r.Header.Get("If-Modified-Since")),
+		IfUnmodifiedSince:          parseHTTPTime(r.Header.Get("If-Unmodified-Since")),
+		ResponseCacheControl:       query.Get("response-cache-control"),
+		ResponseContentDisposition: query.Get("response-content-disposition"),
+		ResponseContentEncoding:    query.Get("response-content-encoding"),
+		ResponseContentLanguage:    query.Get("response-content-language"),
+		ResponseContentType:        query.Get("response-content-type"),
+		ResponseExpires:            query.Get("response-expires"),
+	}
+	if r.Method == http.MethodGet {
+		input.Start, input.End = rangeInput(r.Header.Get("Range"))
+	}
+	ranged := input.Start > 0 || input.End > 0
+	out, err := t.store.GetObject(r.Context(), input)
+	if err != nil {
+		if ranged && oss.IsInvalidRange(err) {
+			err = errInvalidRange
+		}
+		writeError(w, r, s.objectError(r, t, key, err))
+		return
+	}
+	if out.DataStream != nil {
+		defer out.DataStream.Close()
+	}
+	setObjectHeaders(w.Header(), out)
+
+	size := out.ContentLength
+	if ranged && out.ContentRange != "" && out.DataStream != nil {
+		w.Header().Set("Content-Range", out.ContentRange)
+		if size >= 0 {
+			w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+		}
+		w.WriteHeader(http.StatusPartialContent)
+		copyBody(w, out.DataStream, r)
+		return
+	}
+
+	rng, err := parseRange(r.Header.Get("Range"), size)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if r.Method == http.MethodHead || out.DataStream == nil {
+		if size >= 0 {
+			w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if rng == nil {
+		if size >= 0 {
+			w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+		}
+		w.WriteHeader(http.StatusOK)
+		copyBody(w, out.DataStream, r)
+		return
+	}
+	if rng.start > 0 {
+		if _, err := io.CopyN(ioutil.Discard, out.DataStream, rng.start); err != nil {
+			if err == io.EOF {
+				err = errInvalidRange
+			}
+			writeError(w, r, err)
+			return
+		}
+	}
+	w.Header().Set("Content-Range", rng.contentRange(size))
+	if size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(rng.length, 10))
+	}
+	w.WriteHeader(http.StatusPartialContent)
+	copyBody(w, io.LimitReader(out.DataStream, rng.length), r)
+}
+
+func (s *Server) putObject(w http.ResponseWriter, r *http.Request, t *target, key string) {
+	tags, err := parseTagging(r.Header.Get("X-Amz-Tagging"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	out, err := t.store.PutObject(r.Context(), &oss.PutObjectInput{
+		DataStream:           r.Body,
+		ACL:                  r.Header.Get("X-Amz-Acl"),
+		Bucket:               t.bucket,
+		Key:                  key,
+		CacheControl:         r.Header.Get("Cache-Control"),
+		ContentDisposition:   r.Header.Get("Content-Disposition"),
+		ContentEncoding:      contentEncoding(r),
+		Expires:              parseHTTPTime(r.Header.Get("Expires")),
+		ServerSideEncryption: r.Header.Get("X-Amz-Server-Side-Encryption"),
+		Meta:                 userMetadata(r.Header),
+		Tagging:              tags,
+		StorageClass:         r.Header.Get("X-Amz-Storage-Class"),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if out.ETag != "" {
+		w.Header().Set("ETag", out.ETag)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) copyObject(w http.ResponseWriter, r *http.Request, t *target, key string) {
+	source, err := s.copySource(r, t)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	tags, err := parseTagging(r.Header.Get("X-Amz-Tagging"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	out, err := t.store.CopyObject(r.Context(), &oss.CopyObjectInput{
+		Bucket:            t.bucket,
+		Key:               key,
+		CopySource:        source,
+		Tagging:           tags,
+		Expires:           parseHTTPTime(r.Header.Get("Expires")),
+		MetadataDirective: r.Header.Get("X-Amz-Metadata-Directive"),
+		Metadata:          userMetadata(r.Header),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	result := &copyObjectResult{Xmlns: s3Namespace}
+	if out.CopyObjectResult != nil {
+		result.ETag = out.CopyObjectResult.ETag
+		result.LastModified = formatTime(out.CopyObjectResult.LastModified)
+	}
+	writeXML(w, http.StatusOK, result)
+}
+
+// copySource parses `x-amz-copy-source`, i.e. `[/]<bucket>/<key>[?versionId=<id>]`.
+// The source must be in the same oss component as the destination.
+func (s *Server) copySource(r *http.Request, dst *target) (*oss.CopySource, error) {
+	raw := r.Header.Get("X-Amz-Copy-Source")
+	versionID := ""
+	if idx := strings.Index(raw, "?"); idx >= 0 {
+		q, err := url.ParseQuery(raw[idx+1:])
+		if err != nil {
+			return nil, errInvalidArgument.withMessage("Invalid copy source")
+		}
+		versionID = q.Get("versionId")
+		raw = raw[:idx]
+	}
+	decoded, err := url.PathUnescape(raw)
+	if err != nil {
+		return nil, errInvalidArgument.withMessage("Invalid copy source")
+	}
+	bucket, key := splitPath(decoded)
+	if bucket == "" || key == "" {
+		return nil, errInvalidArgument.withMessage("Copy Source must mention the source bucket and key: sourcebucket/sourcekey")
+	}
+	src, err := s.resolve(bucket)
+	if err != nil {
+		return nil, err
+	}
+	if src.storeName != dst.storeName {
+		return nil, errNotImplemented.withMessage("Copying objects across oss components is not supported")
+	}
+	return &oss.CopySource{
+		CopySourceBucket:    src.bucket,
+		CopySourceKey:       key,
+		CopySourceVersionId: versionID,
+	}, nil
+}
+
+func (s *Server) deleteObject(w http.ResponseWriter, r *http.Request, t *target, key string, query url.Values) {
+	out, err := t.store.DeleteObject(r.Context(), &oss.DeleteObjectInput{
+		Bucket:    t.bucket,
+		Key:       key,
+		VersionId: query.Get("versionId"),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if out.VersionId != "" {
+		w.Header().Set("X-Amz-Version-Id", out.VersionId)
+	}
+	if out.DeleteMarker {
+		w.Header().Set("X-Amz-Delete-Marker", "true")
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) getObjectTagging(w http.ResponseWriter, r *http.Request, t *target, key string, query url.Values) {
+	out, err := t.store.GetObjectTagging(r.Context(), &oss.GetObjectTaggingInput{
+		Bucket:    t.bucket,
+		Key:       key,
+		VersionId: query.Get("versionId"),
+	})
+	if err != nil {
+		writeError(w, r, s.objectError(r, t, key, err))
+		return
+	}
+	result := &tagging{Xmlns: s3Namespace, TagSet: []tag{}}
+	for k, v := range out.Tags {
+		result.TagSet = append(result.TagSet, tag{Key: k, Value: v})
+	}
+	sort.Slice(result.TagSet, func(i, j int) bool {
+		return result.TagSet[i].Key < result.TagSet[j].Key
+	})
+	if out.VersionId != "" {
+		w.Header().Set("X-Amz-Version-Id", out.VersionId)
+	}
+	writeXML(w, http.StatusOK, result)
+}
+
+func (s *Server) putObjectTagging(w http.ResponseWriter, r *http.Request, t *target, key string, query url.Values) {
+	req := &tagging{}
+	if err := xml.NewDecoder(http.MaxBytesReader(w, r.Body, maxXMLBodySize)).Decode(req); err != nil {
+		writeError(w, r, errMalformedXML)
+		return
+	}
+	tags := make(map[string]string, len(req.TagSet))
+	for _, tg := range req.TagSet {
+		tags[tg.Key] = tg.Value
+	}
+	_, err := t.store.PutObjectTagging(r.Context(), &oss.PutObjectTaggingInput{
+		Bucket:    t.bucket,
+		Key:       key,
+		Tags:      tags,
+		VersionId: query.Get("versionId"),
+	})
+	if err != nil {
+		writeError(w, r, s.objectError(r, t, key, err))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) deleteObjectTagging(w http.ResponseWriter, r *http.Request, t *target, key string, query url.Values) {
+	out, err := t.store.DeleteObjectTagging(r.Context(), &oss.DeleteObjectTaggingInput{
+		Bucket:    t.bucket,
+		Key:       key,
+		VersionId: query.Get("versionId"),
+	})
+	if err != nil {
+		writeError(w, r, s.objectError(r, t, key, err))
+		return
+	}
+	if out.VersionId != "" {
+		w.Header().Set("X-Amz-Version-Id", out.VersionId)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// objectError returns NoSuchKey if the object doesn't exist, as the components don't have typed errors for it
+func (s *Server) objectError(r *http.Request, t *target, key string, err error) error {
+	if _, ok := err.(*apiError); ok {
+		return err
+	}
+	out, e := t.store.IsObjectExist(r.Context(), &oss.IsObjectExistInput{Bucket: t.bucket, Key: key})
+	if e == nil && !out.FileExist {
+		return errNoSuchKey
+	}
+	return err
+}
+
+func setObjectHeaders(h http.Header, out *oss.GetObjectOutput) {
+	set := func(k, v string) {
+		if v != "" {
+			h.Set(k, v)
+		}
+	}
+	set("Cache-Control", out.CacheControl)
+	set("Content-Disposition", out.ContentDisposition)
+	set("Content-Encoding", out.ContentEncoding)
+	set("Content-Language", out.ContentLanguage)
+	set("Content-Type", out.ContentType)
+	set("ETag", out.Etag)
+	set("Expires", out.Expires)
+	set("X-Amz-Expiration", out.Expiration)
+	set("X-Amz-Version-Id", out.VersionId)
+	set("X-Amz-Storage-Class", out.StorageClass)
+	if out.LastModified != 0 {
+		h.Set("Last-Modified", time.Unix(out.LastModified, 0).UTC().Format(http.TimeFormat))
+	}
+	if out.TagCount > 0 {
+		h.Set("X-Amz-Tagging-Count", strconv.FormatInt(out.TagCount, 10))
+	}
+	if out.PartsCount > 0 {
+		h.Set("X-Amz-Mp-Parts-Count", strconv.FormatInt(out.PartsCount, 10))
+	}
+	if out.DeleteMarker {
+		h.Set("X-Amz-Delete-Marker", "true")
+	}
+	for k, v := range out.Metadata {
+		h.Set(metaPrefix+k, v)
+	}
+	h.Set("Accept-Ranges", "bytes")
+}
+
+// copyBody streams the object to the client. The status code has been sent, so errors can only be logged.
+func copyBody(w io.Writer, body io.Reader, r *http.Request) {
+	if _, err := io.Copy(w, body); err != nil {
+		log.DefaultLogger.Warnf("[s3gateway] %s %s: write body failed: %v", r.Method, r.URL.Path, err)
+	}
+}
+
+// byteRange is a range in the `Range` header
+type byteRange struct {
+	start  int64
+	length int64
+}
+
+func (b *byteRange) contentRange(size int64) string {
+	total := "*"
+	if size >= 0 {
+		total = strconv.FormatInt(size, 10)
+	}
+	return fmt.Sprintf("bytes %d-%d/%s", b.start, b.start+b.length-1, total)
+}
+
+// rangeInput returns the Start and End of GetObjectInput for `bytes=a-b` and `bytes=a-`,
+// the other ranges are applied by the gateway.
+func rangeInput(header string) (int64, int64) {
+	if !strings.HasPrefix(header, "bytes=") || strings.Contains(header, ",") {
+		return 0, 0
+	}
+	spec := strings.TrimSpace(strings.TrimPrefix(header, "bytes="))
+	idx := strings.IndexByte(spec, '-')
+	if idx <= 0 {
+		return 0, 0
+	}
+	start, err := strconv.ParseInt(spec[:idx], 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0
+	}
+	if spec[idx+1:] == "" {
+		return start, 0
+	}
+	end, err := strconv.ParseInt(spec[idx+1:], 10, 64)
+	if err != nil || end < start {
+		return 0, 0
+	}
+	return start, end
+}
+
+// parseRange parses a single byte range, i.e. `bytes=a-b`, `bytes=a-` or `bytes=-n`.
+// A size of -1 means the size is unknown. It returns nil if the range should be ignored,
+// e.g. multiple ranges or open ranges of objects with unknown size.
+func parseRange(header string, size int64) (*byteRange, error) {
+	if !strings.HasPrefix(header, "bytes=") || strings.Contains(header, ",") {
+		return nil, nil
+	}
+	spec := strings.TrimSpace(strings.TrimPrefix(header, "bytes="))
+	idx := strings.IndexByte(spec, '-')
+	if idx < 0 {
+		return nil, nil
+	}
+	startStr, endStr := spec[:idx], spec[idx+1:]
+	// suffix range
+	if startStr == "" {
+		n, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || size < 0 {
+			return nil, nil
+		}
+		if n == 0 {
+			return nil, errInvalidRange
+		}
+		if n > size {
+			n = size
+		}
+		return &byteRange{start: size - n, length: n}, nil
+	}
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil {
+		return nil, nil
+	}
+	if size >= 0 && start >= size {
+		return nil, errInvalidRange
+	}
+	if endStr == "" {
+		if size < 0 {
+			return nil, nil
+		}
+		return &byteRange{start: start, length: size - start}, nil
+	}
+	end, err := strconv.ParseInt(endStr, 10, 64)
+	if err != nil || end < start {
+		return nil, nil
+	}
+	if size >= 0 && end >= size {
+		end = size - 1
+	}
+	return &byteRange{start: start, length: end - start + 1}, nil
+}
+
+// userMetadata collects the `x-amz-meta-*` headers
+func userMetadata(h http.Header) map[string]string {
+	var meta map[string]string
+	for k, v := range h {
+		if !strings.HasPrefix(k, metaPrefix) || len(v) == 0 {
+			continue
+		}
+		if meta == nil {
+			meta = make(map[string]string)
+		}
+		meta[strings.ToLower(strings.TrimPrefix(k, metaPrefix))] = v[0]
+	}
+	return meta
+}
+
+// parseTagging parses the `x-amz-tagging` header, which is url-encoded like `k1=v1&k2=v2`
+func parseTagging(s string) (map[string]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	q, err := url.ParseQuery(s)
+	if err != nil {
+		return nil, errInvalidArgument.withMessage("The header 'x-amz-tagging' shall be encoded as UTF-8 then URLEncoded URL query parameters")
+	}
+	tags := make(map[string]string, len(q))
+	for k, v := range q {
+		tags[k] = v[0]
+	}
+	return tags, nil
+}
+
+// contentEncoding drops `aws-chunked`, which is the encoding of the request rather than the object
+func contentEncoding(r *http.Request) string {
+	var encodings []string
+	for _, e := range strings.Split(r.Header.Get("Content-Encoding"), ",") {
+		e = strings.TrimSpace(e)
+		if e != "" && e != "aws-chunked" {
+			encodings = append(encodings, e)
+		}
+	}
+	return strings.Join(encodings, ",")
+}
+
+// contentLength returns the size of the payload, excluding the chunk headers of aws-chunked payloads
+func contentLength(r *http.Request) int64 {
+	if s := r.Header.Get(headerDecodedLength); s != "" {
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+	}
+	return r.ContentLength
+}
+
+func parseHTTPTime(s string) int64 {
+	if s == "" {
+		return 0
+	}
+	t, err := http.ParseTime(s)
+	if err != nil {
+		return 0
+	}
+	return t.Unix()
+}
@@ -0,0 +1,217 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package s3gateway is an HTTP listener which speaks the S3 REST protocol and maps the requests onto the oss components,
+// so that aws-cli, rclone and the S3 SDKs in other languages can talk to the sidecar.
+// Only path-style requests, i.e. `http://host/<bucket>/<key>`, are supported.
+package s3gateway
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"mosn.io/pkg/log"
+
+	"mosn.io/layotto/components/oss"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+// Server is the S3 gateway
+type Server struct {
+	config   *Config
+	verifier *verifier
+	oss      map[string]oss.Oss
+	srv      *http.Server
+	// used as the creation date of buckets
+	created time.Time
+}
+
+// target is the oss component and the real bucket which a bucket in the request is mapped to
+type target struct {
+	store     oss.Oss
+	storeName string
+	// the bucket name in the request
+	name string
+	// the bucket name in the oss component
+	bucket string
+}
+
+// NewServer creates the S3 gateway in front of the oss components
+func NewServer(config *Config, ossMap map[string]oss.Oss) (*Server, error) {
+	if config == nil {
+		return nil, fmt.Errorf("[s3gateway] config is nil")
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	for name, b := range config.Buckets {
+		if _, ok := ossMap[b.StoreName]; !ok {
+			return nil, fmt.Errorf("[s3gateway] oss component %s of bucket %s not found", b.StoreName, name)
+		}
+	}
+	if config.DefaultStore != "" {
+		if _, ok := ossMap[config.DefaultStore]; !ok {
+			return nil, fmt.Errorf("[s3gateway] default oss component %s not found", config.DefaultStore)
+		}
+	}
+	s := &Server{
+		config:   config,
+		verifier: newVerifier(config),
+		oss:      ossMap,
+		created:  time.Now(),
+	}
+	s.srv = &http.Server{Handler: s}
+	return s, nil
+}
+
+// Start listens on the configured address and serves in the background
+func (s *Server) Start() error {
+	ln, err := net.Listen("tcp", s.config.Address)
+	if err != nil {
+		return fmt.Errorf("[s3gateway] listen on %s failed: %v", s.config.Address, err)
+	}
+	log.DefaultLogger.Infof("[s3gateway] listening on %s", ln.Addr())
+	go func() {
+		if err := s.srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.DefaultLogger.Errorf("[s3gateway] serve failed: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Stop shuts down the gateway gracefully
+func (s *Server) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return s.srv.Shutdown(ctx)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("x-amz-request-id", newRequestID())
+	w.Header().Set("Server", "Layotto")
+
+	body, err := s.verifier.verify(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	r.Body = body
+
+	bucket, key := splitPath(r.URL.Path)
+	if bucket == "" {
+		if r.Method != http.MethodGet {
+			writeError(w, r, errMethodNotAllowed)
+			return
+		}
+		s.listBuckets(w, r)
+		return
+	}
+	t, err := s.resolve(bucket)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if key == "" {
+		s.serveBucket(w, r, t)
+		return
+	}
+	s.serveObject(w, r, t, key)
+}
+
+func (s *Server) resolve(bucket string) (*target, error) {
+	if b, ok := s.config.Buckets[bucket]; ok {
+		t := &target{store: s.oss[b.StoreName], storeName: b.StoreName, name: bucket, bucket: b.Bucket}
+		if t.bucket == "" {
+			t.bucket = bucket
+		}
+		return t, nil
+	}
+	if s.config.DefaultStore != "" {
+		return &target{store: s.oss[s.config.DefaultStore], storeName: s.config.DefaultStore, name: bucket, bucket: bucket}, nil
+	}
+	return nil, errNoSuchBucket
+}
+
+// listBuckets lists the buckets in the config.
+// The buckets served by the default store can't be enumerated, so they are not listed.
+func (s *Server) listBuckets(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(s.config.Buckets))
+	for name := range s.config.Buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := &listAllMyBucketsResult{Xmlns: s3Namespace, Owner: owner{ID: "layotto", DisplayName: "layotto"}}
+	for _, name := range names {
+		result.Buckets = append(result.Buckets, bucketInfo{Name: name, CreationDate: formatTime(s.created.Unix())})
+	}
+	writeXML(w, http.StatusOK, result)
+}
+
+// splitPath splits `/<bucket>/<key>` into the bucket and the key
+func splitPath(path string) (string, string) {
+	path = strings.TrimPrefix(path, "/")
+	idx := strings.IndexByte(path, '/')
+	if idx < 0 {
+		return path, ""
+	}
+	return path[:idx], path[idx+1:]
+}
+
+func writeXML(w http.ResponseWriter, statusCode int, v interface{}) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		log.DefaultLogger.Errorf("[s3gateway] marshal response failed: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(statusCode)
+	w.Write([]byte(xml.Header))
+	w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	e := toAPIError(err)
+	if e.StatusCode >= http.StatusInternalServerError {
+		log.DefaultLogger.Errorf("[s3gateway] %s %s failed: %v", r.Method, r.URL.Path, err)
+	}
+	// no body for HEAD requests
+	if r.Method == http.MethodHead {
+		w.WriteHeader(e.StatusCode)
+		return
+	}
+	writeXML(w, e.StatusCode, &errorResponse{
+		Code:      e.Code,
+		Message:   e.Message,
+		Resource:  r.URL.Path,
+		RequestID: w.Header().Get("x-amz-request-id"),
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return strings.ToUpper(hex.EncodeToString(b))
+}
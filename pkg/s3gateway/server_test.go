@@ -0,0 +1,292 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3gateway
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"mosn.io/layotto/components/oss"
+	mockoss "mosn.io/layotto/pkg/mock/components/oss"
+)
+
+func newTestServer(t *testing.T) (*Server, *mockoss.MockOss) {
+	ctrl := gomock.NewController(t)
+	mock := mockoss.NewMockOss(ctrl)
+	s, err := NewServer(&Config{
+		Address:        "127.0.0.1:0",
+		AllowAnonymous: true,
+		Buckets:        map[string]BucketConfig{"photos": {StoreName: "mock", Bucket: "real-photos"}},
+	}, map[string]oss.Oss{"mock": mock})
+	assert.Nil(t, err)
+	return s, mock
+}
+
+func serve(s *Server, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w
+}
+
+func TestNewServer(t *testing.T) {
+	_, err := NewServer(&Config{Address: ":0"}, nil)
+	assert.NotNil(t, err)
+	_, err = NewServer(&Config{Address: ":0", AllowAnonymous: true, DefaultStore: "none"}, nil)
+	assert.NotNil(t, err)
+}
+
+func TestGetObject(t *testing.T) {
+	s, mock := newTestServer(t)
+	output := func() *oss.GetObjectOutput {
+		return &oss.GetObjectOutput{
+			DataStream:    ioutil.NopCloser(strings.NewReader("hello world")),
+			ContentLength: 11,
+			Etag:          "\"etag\"",
+			Metadata:      map[string]string{"owner": "layotto"},
+		}
+	}
+
+	mock.EXPECT().GetObject(gomock.Any(), &oss.GetObjectInput{Bucket: "real-photos", Key: "a/b.txt"}).Return(output(), nil)
+	w := serve(s, httptest.NewRequest(http.MethodGet, "/photos/a/b.txt", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hello world", w.Body.String())
+	assert.Equal(t, "\"etag\"", w.Header().Get("ETag"))
+	assert.Equal(t, "layotto", w.Header().Get("X-Amz-Meta-Owner"))
+
+	mock.EXPECT().GetObject(gomock.Any(), gomock.Any()).Return(output(), nil)
+	r := httptest.NewRequest(http.MethodGet, "/photos/a/b.txt", nil)
+	r.Header.Set("Range", "bytes=6-")
+	w = serve(s, r)
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "world", w.Body.String())
+	assert.Equal(t, "bytes 6-10/11", w.Header().Get("Content-Range"))
+
+	mock.EXPECT().GetObject(gomock.Any(), gomock.Any()).Return(output(), nil)
+	r = httptest.NewRequest(http.MethodGet, "/photos/a/b.txt", nil)
+	r.Header.Set("Range", "bytes=20-30")
+	w = serve(s, r)
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, w.Code)
+
+	// the range applied by the component
+	mock.EXPECT().GetObject(gomock.Any(), &oss.GetObjectInput{Bucket: "real-photos", Key: "a/b.txt", Start: 6, End: 8}).
+		Return(&oss.GetObjectOutput{
+			DataStream:    ioutil.NopCloser(strings.NewReader("wor")),
+			ContentLength: 3,
+			ContentRange:  "bytes 6-8/11",
+		}, nil)
+	r = httptest.NewRequest(http.MethodGet, "/photos/a/b.txt", nil)
+	r.Header.Set("Range", "bytes=6-8")
+	w = serve(s, r)
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "wor", w.Body.String())
+	assert.Equal(t, "bytes 6-8/11", w.Header().Get("Content-Range"))
+	assert.Equal(t, "3", w.Header().Get("Content-Length"))
+
+	mock.EXPECT().GetObject(gomock.Any(), gomock.Any()).Return(nil, oss.ErrInvalidRange)
+	r = httptest.NewRequest(http.MethodGet, "/photos/a/b.txt", nil)
+	r.Header.Set("Range", "bytes=20-")
+	w = serve(s, r)
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, w.Code)
+
+	// empty object
+	mock.EXPECT().GetObject(gomock.Any(), gomock.Any()).Return(&oss.GetObjectOutput{
+		DataStream: ioutil.NopCloser(strings.NewReader("")),
+	}, nil)
+	w = serve(s, httptest.NewRequest(http.MethodGet, "/photos/empty", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get("Content-Length"))
+
+	mock.EXPECT().GetObject(gomock.Any(), gomock.Any()).Return(nil, errors.New("not found"))
+	mock.EXPECT().IsObjectExist(gomock.Any(), &oss.IsObjectExistInput{Bucket: "real-photos", Key: "missing"}).
+		Return(&oss.IsObjectExistOutput{FileExist: false}, nil)
+	w = serve(s, httptest.NewRequest(http.MethodGet, "/photos/missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "<Code>NoSuchKey</Code>")
+}
+
+func TestHeadObject(t *testing.T) {
+	s, mock := newTestServer(t)
+	mock.EXPECT().GetObject(gomock.Any(), gomock.Any()).Return(&oss.GetObjectOutput{
+		DataStream:    ioutil.NopCloser(strings.NewReader("hello")),
+		ContentLength: 5,
+	}, nil)
+	w := serve(s, httptest.NewRequest(http.MethodHead, "/photos/key", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "5", w.Header().Get("Content-Length"))
+	assert.Equal(t, 0, w.Body.Len())
+}
+
+func TestPutObject(t *testing.T) {
+	s, mock := newTestServer(t)
+	mock.EXPECT().PutObject(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ interface{}, req *oss.PutObjectInput) (*oss.PutObjectOutput, error) {
+			data, err := ioutil.ReadAll(req.DataStream)
+			assert.Nil(t, err)
+			assert.Equal(t, "hello", string(data))
+			assert.Equal(t, "real-photos", req.Bucket)
+			assert.Equal(t, map[string]string{"owner": "layotto"}, req.Meta)
+			assert.Equal(t, map[string]string{"k": "v"}, req.Tagging)
+			return &oss.PutObjectOutput{ETag: "\"etag\""}, nil
+		})
+	r := httptest.NewRequest(http.MethodPut, "/photos/key", strings.NewReader("hello"))
+	r.Header.Set("X-Amz-Meta-Owner", "layotto")
+	r.Header.Set("X-Amz-Tagging", "k=v")
+	w := serve(s, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "\"etag\"", w.Header().Get("ETag"))
+}
+
+func TestCopyObject(t *testing.T) {
+	s, mock := newTestServer(t)
+	mock.EXPECT().CopyObject(gomock.Any(), &oss.CopyObjectInput{
+		Bucket:     "real-photos",
+		Key:        "dst",
+		CopySource: &oss.CopySource{CopySourceBucket: "real-photos", CopySourceKey: "a b", CopySourceVersionId: "v1"},
+	}).Return(&oss.CopyObjectOutput{CopyObjectResult: &oss.CopyObjectResult{ETag: "etag"}}, nil)
+	r := httptest.NewRequest(http.MethodPut, "/photos/dst", nil)
+	r.Header.Set("X-Amz-Copy-Source", "/photos/a%20b?versionId=v1")
+	w := serve(s, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "<ETag>etag</ETag>")
+}
+
+func TestListObjectsV2(t *testing.T) {
+	s, mock := newTestServer(t)
+	mock.EXPECT().ListObjects(gomock.Any(), &oss.ListObjectsInput{
+		Bucket:    "real-photos",
+		Delimiter: "/",
+		MaxKeys:   2,
+		Prefix:    "dir/",
+	}).Return(&oss.ListObjectsOutput{
+		Contents:       []*oss.Object{{Key: "dir/a", Size: 1, LastModified: 1651406400}},
+		CommonPrefixes: []string{"dir/sub/"},
+		IsTruncated:    true,
+	}, nil)
+	w := serve(s, httptest.NewRequest(http.MethodGet, "/photos?list-type=2&prefix=dir/&delimiter=/&max-keys=2", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, "<Name>photos</Name>")
+	assert.Contains(t, body, "<Key>dir/a</Key>")
+	assert.Contains(t, body, "<LastModified>2022-05-01T12:00:00.000Z</LastModified>")
+	assert.Contains(t, body, "<CommonPrefixes><Prefix>dir/sub/</Prefix></CommonPrefixes>")
+	assert.Contains(t, body, "<KeyCount>2</KeyCount>")
+	// the continuation token is the base64 encoded marker
+	assert.Contains(t, body, "<NextContinuationToken>ZGlyL3N1Yi8=</NextContinuationToken>")
+
+	mock.EXPECT().ListObjects(gomock.Any(), &oss.ListObjectsInput{
+		Bucket:  "real-photos",
+		Marker:  "dir/sub/",
+		MaxKeys: defaultMaxKeys,
+	}).Return(&oss.ListObjectsOutput{}, nil)
+	w = serve(s, httptest.NewRequest(http.MethodGet, "/photos?list-type=2&continuation-token=ZGlyL3N1Yi8=", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestMultipartUpload(t *testing.T) {
+	s, mock := newTestServer(t)
+
+	mock.EXPECT().CreateMultipartUpload(gomock.Any(), gomock.Any()).Return(&oss.CreateMultipartUploadOutput{UploadId: "upload"}, nil)
+	w := serve(s, httptest.NewRequest(http.MethodPost, "/photos/big?uploads", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "<UploadId>upload</UploadId>")
+
+	mock.EXPECT().UploadPart(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ interface{}, req *oss.UploadPartInput) (*oss.UploadPartOutput, error) {
+			assert.Equal(t, int32(1), req.PartNumber)
+			assert.Equal(t, "upload", req.UploadId)
+			assert.Equal(t, int64(4), req.ContentLength)
+			return &oss.UploadPartOutput{ETag: "\"p1\""}, nil
+		})
+	w = serve(s, httptest.NewRequest(http.MethodPut, "/photos/big?partNumber=1&uploadId=upload", strings.NewReader("part")))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "\"p1\"", w.Header().Get("ETag"))
+
+	w = serve(s, httptest.NewRequest(http.MethodPut, "/photos/big?partNumber=0&uploadId=upload", strings.NewReader("part")))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mock.EXPECT().CompleteMultipartUpload(gomock.Any(), &oss.CompleteMultipartUploadInput{
+		Bucket:          "real-photos",
+		Key:             "big",
+		UploadId:        "upload",
+		MultipartUpload: &oss.CompletedMultipartUpload{Parts: []*oss.CompletedPart{{ETag: "\"p1\"", PartNumber: 1}}},
+	}).Return(&oss.CompleteMultipartUploadOutput{ETag: "\"final\""}, nil)
+	body := `<CompleteMultipartUpload><Part><PartNumber>1</PartNumber><ETag>"p1"</ETag></Part></CompleteMultipartUpload>`
+	w = serve(s, httptest.NewRequest(http.MethodPost, "/photos/big?uploadId=upload", strings.NewReader(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "<ETag>&#34;final&#34;</ETag>")
+
+	mock.EXPECT().AbortMultipartUpload(gomock.Any(), gomock.Any()).Return(&oss.AbortMultipartUploadOutput{}, nil)
+	w = serve(s, httptest.NewRequest(http.MethodDelete, "/photos/big?uploadId=upload", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestNotSupportMethod(t *testing.T) {
+	s, mock := newTestServer(t)
+	mock.EXPECT().ListParts(gomock.Any(), gomock.Any()).Return(nil, oss.NewErrNotSupportMethod("ListParts", "AWS"))
+	w := serve(s, httptest.NewRequest(http.MethodGet, "/photos/big?uploadId=upload", nil))
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+	assert.Contains(t, w.Body.String(), "<Code>NotImplemented</Code>")
+}
+
+func TestNoSuchBucket(t *testing.T) {
+	s, _ := newTestServer(t)
+	w := serve(s, httptest.NewRequest(http.MethodGet, "/unknown/key", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "<Code>NoSuchBucket</Code>")
+
+	w = serve(s, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "<Name>photos</Name>")
+}
+
+func TestRangeInput(t *testing.T) {
+	start, end := rangeInput("bytes=5-9")
+	assert.Equal(t, []int64{5, 9}, []int64{start, end})
+	start, end = rangeInput("bytes=5-")
+	assert.Equal(t, []int64{5, 0}, []int64{start, end})
+	for _, header := range []string{"", "bytes=-3", "bytes=0-1,3-4", "bytes=9-5", "items=1-2"} {
+		start, end = rangeInput(header)
+		assert.Equal(t, []int64{0, 0}, []int64{start, end}, header)
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	rng, err := parseRange("bytes=0-4", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, &byteRange{start: 0, length: 5}, rng)
+	rng, _ = parseRange("bytes=-3", 10)
+	assert.Equal(t, &byteRange{start: 7, length: 3}, rng)
+	rng, _ = parseRange("bytes=5-100", 10)
+	assert.Equal(t, &byteRange{start: 5, length: 5}, rng)
+	rng, _ = parseRange("bytes=5-6", -1)
+	assert.Equal(t, "bytes 5-6/*", rng.contentRange(-1))
+	// ignored
+	rng, err = parseRange("bytes=0-1,3-4", 10)
+	assert.Nil(t, rng)
+	assert.Nil(t, err)
+	rng, _ = parseRange("bytes=5-", -1)
+	assert.Nil(t, rng)
+	_, err = parseRange("bytes=10-", 10)
+	assert.Equal(t, errInvalidRange, err)
+}
@@ -0,0 +1,524 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3gateway
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The implementation follows https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-authenticating-requests.html
+const (
+	signV4Algorithm         = "AWS4-HMAC-SHA256"
+	signV4ChunkAlgorithm    = "AWS4-HMAC-SHA256-PAYLOAD"
+	unsignedPayload         = "UNSIGNED-PAYLOAD"
+	streamingPayload        = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	streamingUnsignedPaylod = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+	emptySHA256             = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	iso8601Format           = "20060102T150405Z"
+	yyyymmdd                = "20060102"
+	serviceS3               = "s3"
+	scopeTerminator         = "aws4_request"
+
+	headerAuthorization    = "Authorization"
+	headerAmzDate          = "X-Amz-Date"
+	headerAmzContentSHA256 = "X-Amz-Content-Sha256"
+	headerDecodedLength    = "X-Amz-Decoded-Content-Length"
+
+	maxClockSkew      = 15 * time.Minute
+	maxPresignExpires = 7 * 24 * time.Hour
+	// the max size of a chunk in aws-chunked payloads, to protect the gateway from malformed requests
+	maxChunkSize = 64 << 20
+)
+
+// verifier verifies the AWS Signature Version 4 of requests.
+type verifier struct {
+	region string
+	// access key id -> secret access key
+	secrets   map[string]string
+	anonymous bool
+	now       func() time.Time
+}
+
+func newVerifier(config *Config) *verifier {
+	v := &verifier{
+		region:    config.Region,
+		secrets:   make(map[string]string),
+		anonymous: config.AllowAnonymous,
+		now:       time.Now,
+	}
+	for _, c := range config.Credentials {
+		v.secrets[c.AccessKeyID] = c.SecretAccessKey
+	}
+	return v
+}
+
+// credentialScope is the parsed `Credential` of a signature, i.e. `<key>/<date>/<region>/s3/aws4_request`
+type credentialScope struct {
+	accessKey string
+	date      string
+	region    string
+}
+
+func (c *credentialScope) String() string {
+	return strings.Join([]string{c.date, c.region, serviceS3, scopeTerminator}, "/")
+}
+
+type signatureV4 struct {
+	scope         credentialScope
+	signedHeaders []string
+	signature     string
+	amzDate       time.Time
+}
+
+// verify checks the signature of the request.
+// It returns the body to read the payload from, which verifies the payload hash or chunk signatures while reading.
+func (v *verifier) verify(r *http.Request) (io.ReadCloser, error) {
+	query := r.URL.Query()
+	presigned := query.Get("X-Amz-Algorithm") != ""
+	if r.Header.Get(headerAuthorization) == "" && !presigned {
+		if v.anonymous {
+			return r.Body, nil
+		}
+		return nil, errAccessDenied
+	}
+	if v.anonymous && len(v.secrets) == 0 {
+		return r.Body, nil
+	}
+	if presigned {
+		return v.verifyPresigned(r, query)
+	}
+	return v.verifyHeader(r)
+}
+
+func (v *verifier) verifyHeader(r *http.Request) (io.ReadCloser, error) {
+	sig, err := parseAuthorization(r.Header.Get(headerAuthorization))
+	if err != nil {
+		return nil, err
+	}
+	if !sig.signsHost() {
+		return nil, errHostNotSigned
+	}
+	// the request time
+	if date := r.Header.Get(headerAmzDate); date != "" {
+		sig.amzDate, err = time.Parse(iso8601Format, date)
+	} else {
+		sig.amzDate, err = http.ParseTime(r.Header.Get("Date"))
+	}
+	if err != nil {
+		return nil, errAccessDenied.withMessage("AWS authentication requires a valid Date or x-amz-date header")
+	}
+	if d := v.now().Sub(sig.amzDate); d > maxClockSkew || d < -maxClockSkew {
+		return nil, errRequestTimeTooSkewed
+	}
+	secret, err := v.checkScope(sig)
+	if err != nil {
+		return nil, err
+	}
+	payloadHash := r.Header.Get(headerAmzContentSHA256)
+	if payloadHash == "" {
+		return nil, errInvalidArgument.withMessage("Missing required header for this request: x-amz-content-sha256")
+	}
+	// check the seed signature
+	key := signingKey(secret, sig.scope)
+	canonical := canonicalRequest(r, sig.signedHeaders, payloadHash, false)
+	expected := hex.EncodeToString(hmacSHA256(key, stringToSign(sig.amzDate, sig.scope, canonical)))
+	if !hmac.Equal([]byte(expected), []byte(sig.signature)) {
+		return nil, errSignatureDoesNotMatch
+	}
+	// verify the payload
+	switch payloadHash {
+	case unsignedPayload:
+		return r.Body, nil
+	case streamingPayload:
+		return newChunkedReader(r.Body, &chunkSigner{
+			key:           key,
+			amzDate:       sig.amzDate.Format(iso8601Format),
+			scope:         sig.scope.String(),
+			prevSignature: sig.signature,
+		}), nil
+	case streamingUnsignedPaylod:
+		return newChunkedReader(r.Body, nil), nil
+	default:
+		expectedHash, err := hex.DecodeString(payloadHash)
+		if err != nil || len(expectedHash) != sha256.Size {
+			return nil, errContentSHA256Mismatch
+		}
+		return &hashReader{body: r.Body, hash: sha256.New(), expected: expectedHash}, nil
+	}
+}
+
+func (v *verifier) verifyPresigned(r *http.Request, query url.Values) (io.ReadCloser, error) {
+	if query.Get("X-Amz-Algorithm") != signV4Algorithm {
+		return nil, errUnsupportedSignature
+	}
+	scope, err := parseCredential(query.Get("X-Amz-Credential"))
+	if err != nil {
+		return nil, err
+	}
+	sig := &signatureV4{
+		scope:         *scope,
+		signedHeaders: strings.Split(query.Get("X-Amz-SignedHeaders"), ";"),
+		signature:     query.Get("X-Amz-Signature"),
+	}
+	if sig.signature == "" || len(sig.signedHeaders) == 0 {
+		return nil, errAccessDenied.withMessage("Query-string authentication requires the X-Amz-Signature and X-Amz-SignedHeaders parameters")
+	}
+	if !sig.signsHost() {
+		return nil, errHostNotSigned
+	}
+	sig.amzDate, err = time.Parse(iso8601Format, query.Get("X-Amz-Date"))
+	if err != nil {
+		return nil, errAccessDenied.withMessage("X-Amz-Date must be in the ISO8601 Long Format")
+	}
+	expires, err := strconv.ParseInt(query.Get("X-Amz-Expires"), 10, 64)
+	if err != nil || expires < 0 || time.Duration(expires)*time.Second > maxPresignExpires {
+		return nil, errAccessDenied.withMessage("X-Amz-Expires must be a non-negative integer no more than 604800")
+	}
+	now := v.now()
+	if sig.amzDate.Sub(now) > maxClockSkew {
+		return nil, errRequestTimeTooSkewed
+	}
+	if now.After(sig.amzDate.Add(time.Duration(expires) * time.Second)) {
+		return nil, errExpiredRequest
+	}
+	secret, err := v.checkScope(sig)
+	if err != nil {
+		return nil, err
+	}
+	payloadHash := query.Get(headerAmzContentSHA256)
+	if payloadHash == "" {
+		payloadHash = unsignedPayload
+	}
+	canonical := canonicalRequest(r, sig.signedHeaders, payloadHash, true)
+	expected := hex.EncodeToString(hmacSHA256(signingKey(secret, sig.scope), stringToSign(sig.amzDate, sig.scope, canonical)))
+	if !hmac.Equal([]byte(expected), []byte(sig.signature)) {
+		return nil, errSignatureDoesNotMatch
+	}
+	return r.Body, nil
+}
+
+// checkScope validates the credential scope and returns the secret access key
+func (v *verifier) checkScope(sig *signatureV4) (string, error) {
+	secret, ok := v.secrets[sig.scope.accessKey]
+	if !ok {
+		return "", errInvalidAccessKeyID
+	}
+	if sig.scope.date != sig.amzDate.UTC().Format(yyyymmdd) {
+		return "", errAuthorizationMalformed.withMessage("The credential date does not match the request date")
+	}
+	if sig.scope.region != v.region {
+		return "", errAuthorizationMalformed.withMessage(fmt.Sprintf("The region '%s' is wrong; expecting '%s'", sig.scope.region, v.region))
+	}
+	return secret, nil
+}
+
+// signsHost reports whether the host header is signed, which is required by SigV4
+func (sig *signatureV4) signsHost() bool {
+	for _, h := range sig.signedHeaders {
+		if strings.EqualFold(h, "host") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAuthorization parses the header like:
+// AWS4-HMAC-SHA256 Credential=AKID/20130524/us-east-1/s3/aws4_request, SignedHeaders=host;x-amz-date, Signature=fe5f80f7
+func parseAuthorization(auth string) (*signatureV4, error) {
+	if !strings.HasPrefix(auth, signV4Algorithm+" ") {
+		return nil, errUnsupportedSignature
+	}
+	sig := &signatureV4{}
+	for _, field := range strings.Split(strings.TrimPrefix(auth, signV4Algorithm), ",") {
+		kv := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(kv) != 2 {
+			return nil, errAuthorizationMalformed
+		}
+		switch kv[0] {
+		case "Credential":
+			scope, err := parseCredential(kv[1])
+			if err != nil {
+				return nil, err
+			}
+			sig.scope = *scope
+		case "SignedHeaders":
+			sig.signedHeaders = strings.Split(kv[1], ";")
+		case "Signature":
+			sig.signature = kv[1]
+		}
+	}
+	if sig.scope.accessKey == "" || len(sig.signedHeaders) == 0 || sig.signature == "" {
+		return nil, errAuthorizationMalformed
+	}
+	return sig, nil
+}
+
+func parseCredential(credential string) (*credentialScope, error) {
+	parts := strings.Split(credential, "/")
+	if len(parts) != 5 || parts[3] != serviceS3 || parts[4] != scopeTerminator {
+		return nil, errAuthorizationMalformed.withMessage("The credential is not well-formed: " + credential)
+	}
+	return &credentialScope{accessKey: parts[0], date: parts[1], region: parts[2]}, nil
+}
+
+func canonicalRequest(r *http.Request, signedHeaders []string, payloadHash string, presigned bool) string {
+	// uri
+	uri := uriEncode(r.URL.Path, false)
+	if uri == "" {
+		uri = "/"
+	}
+	// query string
+	query := r.URL.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		if presigned && k == "X-Amz-Signature" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var params []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			params = append(params, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	// headers
+	var headers bytes.Buffer
+	for _, h := range signedHeaders {
+		headers.WriteString(h)
+		headers.WriteByte(':')
+		headers.WriteString(headerValue(r, h))
+		headers.WriteByte('\n')
+	}
+	return strings.Join([]string{
+		r.Method,
+		uri,
+		strings.Join(params, "&"),
+		headers.String(),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+}
+
+// headerValue returns the canonical value of a header.
+// Some headers are moved out of the header map by net/http, so we restore them here.
+func headerValue(r *http.Request, name string) string {
+	var values []string
+	switch name {
+	case "host":
+		values = []string{r.Host}
+	case "content-length":
+		values = r.Header.Values(name)
+		if len(values) == 0 && r.ContentLength >= 0 {
+			values = []string{strconv.FormatInt(r.ContentLength, 10)}
+		}
+	case "transfer-encoding":
+		values = r.TransferEncoding
+	default:
+		values = r.Header.Values(name)
+	}
+	for i, v := range values {
+		values[i] = strings.Join(strings.Fields(v), " ")
+	}
+	return strings.Join(values, ",")
+}
+
+func stringToSign(t time.Time, scope credentialScope, canonicalRequest string) []byte {
+	return []byte(strings.Join([]string{
+		signV4Algorithm,
+		t.UTC().Format(iso8601Format),
+		scope.String(),
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n"))
+}
+
+func signingKey(secret string, scope credentialScope) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), []byte(scope.date))
+	key = hmacSHA256(key, []byte(scope.region))
+	key = hmacSHA256(key, []byte(serviceS3))
+	return hmacSHA256(key, []byte(scopeTerminator))
+}
+
+func hmacSHA256(key []byte, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// uriEncode encodes every byte except the unreserved characters, as SigV4 requires.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// hashReader verifies the sha256 of the payload when reaching EOF
+type hashReader struct {
+	body     io.ReadCloser
+	hash     hash.Hash
+	expected []byte
+}
+
+func (h *hashReader) Read(p []byte) (int, error) {
+	n, err := h.body.Read(p)
+	h.hash.Write(p[:n])
+	if err == io.EOF && !bytes.Equal(h.hash.Sum(nil), h.expected) {
+		return n, errContentSHA256Mismatch
+	}
+	return n, err
+}
+
+func (h *hashReader) Close() error {
+	return h.body.Close()
+}
+
+// chunkSigner verifies the signatures of aws-chunked payloads.
+// See https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-streaming.html
+type chunkSigner struct {
+	key           []byte
+	amzDate       string
+	scope         string
+	prevSignature string
+}
+
+func (s *chunkSigner) verify(data []byte, signature string) bool {
+	sts := strings.Join([]string{
+		signV4ChunkAlgorithm,
+		s.amzDate,
+		s.scope,
+		s.prevSignature,
+		emptySHA256,
+		sha256Hex(data),
+	}, "\n")
+	expected := hex.EncodeToString(hmacSHA256(s.key, []byte(sts)))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return false
+	}
+	s.prevSignature = signature
+	return true
+}
+
+// chunkedReader decodes aws-chunked payloads, i.e. `<hex size>[;chunk-signature=<sig>]\r\n<data>\r\n` ... `0...\r\n`.
+// The signatures are not verified if the signer is nil.
+type chunkedReader struct {
+	body   io.ReadCloser
+	reader *bufio.Reader
+	signer *chunkSigner
+	chunk  []byte
+	err    error
+}
+
+func newChunkedReader(body io.ReadCloser, signer *chunkSigner) *chunkedReader {
+	return &chunkedReader{body: body, reader: bufio.NewReader(body), signer: signer}
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	for len(c.chunk) == 0 {
+		if c.err != nil {
+			return 0, c.err
+		}
+		c.err = c.readChunk()
+	}
+	n := copy(p, c.chunk)
+	c.chunk = c.chunk[n:]
+	return n, nil
+}
+
+func (c *chunkedReader) readChunk() error {
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+	// parse the chunk header
+	sizeStr, signature := line, ""
+	if idx := strings.IndexByte(line, ';'); idx >= 0 {
+		sizeStr = line[:idx]
+		ext := strings.SplitN(line[idx+1:], "=", 2)
+		if len(ext) == 2 && ext[0] == "chunk-signature" {
+			signature = ext[1]
+		}
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(sizeStr), 16, 64)
+	if err != nil || size < 0 || size > maxChunkSize {
+		return errIncompleteBody.withMessage("The chunk header is malformed")
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(c.reader, data); err != nil {
+		return errIncompleteBody
+	}
+	if c.signer != nil && !c.signer.verify(data, signature) {
+		return errSignatureDoesNotMatch
+	}
+	if size == 0 {
+		// the trailers end with an empty line
+		for {
+			line, err := c.readLine()
+			if err != nil || line == "" {
+				return io.EOF
+			}
+		}
+	}
+	if line, err := c.readLine(); err != nil || line != "" {
+		return errIncompleteBody.withMessage("The chunk is not terminated with CRLF")
+	}
+	c.chunk = data
+	return nil
+}
+
+func (c *chunkedReader) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		if err == io.EOF {
+			return "", errIncompleteBody
+		}
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
+
+func (c *chunkedReader) Close() error {
+	return c.body.Close()
+}
@@ -0,0 +1,213 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3gateway
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testAccessKey = "AKIDEXAMPLE"
+	testSecret    = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+)
+
+var testNow = time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestVerifier() *verifier {
+	v := newVerifier(&Config{
+		Region:      defaultRegion,
+		Credentials: []Credential{{AccessKeyID: testAccessKey, SecretAccessKey: testSecret}},
+	})
+	v.now = func() time.Time { return testNow }
+	return v
+}
+
+func testScope(t time.Time) credentialScope {
+	return credentialScope{accessKey: testAccessKey, date: t.Format(yyyymmdd), region: defaultRegion}
+}
+
+// signRequest signs the request in the way the aws SDKs do, and returns the seed signature
+func signRequest(r *http.Request, secret string, t time.Time, payloadHash string) string {
+	r.Header.Set(headerAmzDate, t.Format(iso8601Format))
+	r.Header.Set(headerAmzContentSHA256, payloadHash)
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	scope := testScope(t)
+	canonical := canonicalRequest(r, signed, payloadHash, false)
+	sig := hex.EncodeToString(hmacSHA256(signingKey(secret, scope), stringToSign(t, scope, canonical)))
+	r.Header.Set(headerAuthorization, fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signV4Algorithm, testAccessKey, scope.String(), strings.Join(signed, ";"), sig))
+	return sig
+}
+
+func TestVerifyHeader(t *testing.T) {
+	v := newTestVerifier()
+
+	t.Run("signed payload", func(t *testing.T) {
+		body := []byte("hello")
+		r := httptest.NewRequest(http.MethodPut, "/bucket/dir/a%20b.txt?tagging", bytes.NewReader(body))
+		signRequest(r, testSecret, testNow, sha256Hex(body))
+		reader, err := v.verify(r)
+		assert.Nil(t, err)
+		data, err := ioutil.ReadAll(reader)
+		assert.Nil(t, err)
+		assert.Equal(t, body, data)
+	})
+
+	t.Run("payload mismatch", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPut, "/bucket/key", strings.NewReader("hello"))
+		signRequest(r, testSecret, testNow, sha256Hex([]byte("world")))
+		reader, err := v.verify(r)
+		assert.Nil(t, err)
+		_, err = ioutil.ReadAll(reader)
+		assert.Equal(t, errContentSHA256Mismatch, err)
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/bucket/key", nil)
+		signRequest(r, "wrong", testNow, emptySHA256)
+		_, err := v.verify(r)
+		assert.Equal(t, errSignatureDoesNotMatch, err)
+	})
+
+	t.Run("tampered query", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/bucket?list-type=2", nil)
+		signRequest(r, testSecret, testNow, emptySHA256)
+		r.URL.RawQuery = "list-type=2&prefix=a"
+		_, err := v.verify(r)
+		assert.Equal(t, errSignatureDoesNotMatch, err)
+	})
+
+	t.Run("unknown access key", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/bucket/key", nil)
+		signRequest(r, testSecret, testNow, emptySHA256)
+		r.Header.Set(headerAuthorization, strings.Replace(r.Header.Get(headerAuthorization), testAccessKey, "unknown", 1))
+		_, err := v.verify(r)
+		assert.Equal(t, errInvalidAccessKeyID, err)
+	})
+
+	t.Run("skewed", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/bucket/key", nil)
+		signRequest(r, testSecret, testNow.Add(-time.Hour), emptySHA256)
+		_, err := v.verify(r)
+		assert.Equal(t, errRequestTimeTooSkewed, err)
+	})
+
+	t.Run("host not signed", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/bucket/key", nil)
+		signRequest(r, testSecret, testNow, emptySHA256)
+		r.Header.Set(headerAuthorization, strings.Replace(r.Header.Get(headerAuthorization), "SignedHeaders=host;", "SignedHeaders=", 1))
+		_, err := v.verify(r)
+		assert.Equal(t, errHostNotSigned, err)
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/bucket/key", nil)
+		_, err := v.verify(r)
+		assert.Equal(t, errAccessDenied, err)
+	})
+}
+
+func TestVerifyStreaming(t *testing.T) {
+	v := newTestVerifier()
+	chunks := [][]byte{bytes.Repeat([]byte("a"), 1024), []byte("bc"), {}}
+
+	build := func(r *http.Request, seed string, tamper bool) {
+		scope := testScope(testNow)
+		key := signingKey(testSecret, scope)
+		prev := seed
+		var body bytes.Buffer
+		for _, c := range chunks {
+			sts := strings.Join([]string{signV4ChunkAlgorithm, testNow.Format(iso8601Format), scope.String(), prev, emptySHA256, sha256Hex(c)}, "\n")
+			prev = hex.EncodeToString(hmacSHA256(key, []byte(sts)))
+			if tamper && len(c) > 0 {
+				c = bytes.ToUpper(c)
+			}
+			fmt.Fprintf(&body, "%x;chunk-signature=%s\r\n%s\r\n", len(c), prev, c)
+		}
+		r.Body = ioutil.NopCloser(&body)
+	}
+
+	r := httptest.NewRequest(http.MethodPut, "/bucket/key", nil)
+	seed := signRequest(r, testSecret, testNow, streamingPayload)
+	build(r, seed, false)
+	reader, err := v.verify(r)
+	assert.Nil(t, err)
+	data, err := ioutil.ReadAll(reader)
+	assert.Nil(t, err)
+	assert.Equal(t, append(chunks[0], chunks[1]...), data)
+
+	r = httptest.NewRequest(http.MethodPut, "/bucket/key", nil)
+	seed = signRequest(r, testSecret, testNow, streamingPayload)
+	build(r, seed, true)
+	reader, err = v.verify(r)
+	assert.Nil(t, err)
+	_, err = ioutil.ReadAll(reader)
+	assert.Equal(t, errSignatureDoesNotMatch, err)
+}
+
+func TestVerifyUnsignedTrailer(t *testing.T) {
+	v := newTestVerifier()
+	r := httptest.NewRequest(http.MethodPut, "/bucket/key", nil)
+	signRequest(r, testSecret, testNow, streamingUnsignedPaylod)
+	r.Body = ioutil.NopCloser(strings.NewReader("5\r\nhello\r\n0\r\nx-amz-checksum-crc32:AAAAAA==\r\n\r\n"))
+	reader, err := v.verify(r)
+	assert.Nil(t, err)
+	data, err := ioutil.ReadAll(reader)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", string(data))
+}
+
+func TestVerifyPresigned(t *testing.T) {
+	v := newTestVerifier()
+	presign := func(expires int) *http.Request {
+		scope := testScope(testNow)
+		query := fmt.Sprintf("X-Amz-Algorithm=%s&X-Amz-Credential=%s&X-Amz-Date=%s&X-Amz-Expires=%d&X-Amz-SignedHeaders=host",
+			signV4Algorithm, uriEncode(testAccessKey+"/"+scope.String(), true), testNow.Format(iso8601Format), expires)
+		r := httptest.NewRequest(http.MethodGet, "/bucket/key?"+query, nil)
+		canonical := canonicalRequest(r, []string{"host"}, unsignedPayload, true)
+		sig := hex.EncodeToString(hmacSHA256(signingKey(testSecret, scope), stringToSign(testNow, scope, canonical)))
+		r.URL.RawQuery += "&X-Amz-Signature=" + sig
+		return r
+	}
+
+	_, err := v.verify(presign(3600))
+	assert.Nil(t, err)
+
+	r := presign(3600)
+	r.URL.RawQuery = strings.Replace(r.URL.RawQuery, "X-Amz-SignedHeaders=host", "X-Amz-SignedHeaders=x-amz-date", 1)
+	_, err = v.verify(r)
+	assert.Equal(t, errHostNotSigned, err)
+
+	v.now = func() time.Time { return testNow.Add(2 * time.Hour) }
+	_, err = v.verify(presign(3600))
+	assert.Equal(t, errExpiredRequest, err)
+}
+
+func TestURIEncode(t *testing.T) {
+	assert.Equal(t, "/bucket/a%20b/%E4%B8%AD~", uriEncode("/bucket/a b/中~", false))
+	assert.Equal(t, "a%2Fb%3D", uriEncode("a/b=", true))
+}
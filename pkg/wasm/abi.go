@@ -55,6 +55,12 @@ func (a *AbiV2Impl) GetABIExports() interface{} {
 	return a
 }
 
+// Register the imports of proxy-wasm and the Layotto host calls
+func (a *AbiV2Impl) OnInstanceCreate(instance types.WasmInstance) {
+	a.ABIContext.OnInstanceCreate(instance)
+	registerHostCalls(instance)
+}
+
 // Get id
 func (a *AbiV2Impl) ProxyGetID() (string, error) {
 	// store the funcName and common.WasmFunction, then return the common.WasmFunction
@@ -20,18 +20,35 @@ import (
 	"encoding/json"
 	"errors"
 	"runtime"
+	"time"
 
 	v2 "mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/log"
+
+	"mosn.io/layotto/pkg/wasm/fetch"
 )
 
+// memoryPagesLimit is the max size of the 32 bits linear memory in 64KiB pages
+const memoryPagesLimit = 65536
+
 type filterConfigItem struct {
-	FromWasmPlugin string            `json:"from_wasm_plugin,omitempty"`
-	VmConfig       *v2.WasmVmConfig  `json:"vm_config,omitempty"`
-	InstanceNum    int               `json:"instance_num,omitempty"`
-	RootContextID  int32             `json:"root_context_id,omitempty"`
-	UserData       map[string]string `json:"-"`
-	PluginName     string            `json:"-"`
+	Name           string           `json:"name,omitempty"`
+	FromWasmPlugin string           `json:"from_wasm_plugin,omitempty"`
+	Source         *fetch.Source    `json:"source,omitempty"`
+	VmConfig       *v2.WasmVmConfig `json:"vm_config,omitempty"`
+	InstanceNum    int              `json:"instance_num,omitempty"`
+	RootContextID  int32            `json:"root_context_id,omitempty"`
+	// MaxExecutionTime is the max time in milliseconds to handle a request, MaxMemoryPages is the max
+	// size of the linear memory in 64KiB pages. Zero means unlimited.
+	MaxExecutionTime int64 `json:"max_execution_time,omitempty"`
+	MaxMemoryPages   int64 `json:"max_memory_pages,omitempty"`
+	// MaxFileSize is the max size in bytes of the file read by proxy_get_file, zero means 4MiB
+	MaxFileSize int64             `json:"max_file_size,omitempty"`
+	UserData    map[string]string `json:"-"`
+	PluginName  string            `json:"-"`
+	// Digest is the sha256 of the module, InstallTime is when the plugin is installed or reloaded
+	Digest      string    `json:"-"`
+	InstallTime time.Time `json:"-"`
 }
 
 // Parse filterConfigItem
@@ -67,7 +84,27 @@ func parseFilterConfigItem(cfg map[string]interface{}) (*filterConfigItem, error
 
 // Check VMconfig of filterConfigItem
 func checkVmConfig(config *filterConfigItem) error {
+	if config.MaxExecutionTime < 0 || config.MaxMemoryPages < 0 {
+		log.DefaultLogger.Errorf("[proxywasm][config] checkVmConfig fail, negative limits")
+		return errors.New("negative limits")
+	}
+
+	if config.MaxFileSize < 0 {
+		log.DefaultLogger.Errorf("[proxywasm][config] checkVmConfig fail, negative max_file_size")
+		return errors.New("negative max_file_size")
+	}
+
+	if config.MaxMemoryPages > memoryPagesLimit {
+		log.DefaultLogger.Errorf("[proxywasm][config] checkVmConfig fail, max_memory_pages exceeds %d", memoryPagesLimit)
+		return errors.New("max_memory_pages out of range")
+	}
+
 	if config.FromWasmPlugin != "" {
+		// the limits are compiled into the module, which is loaded by the other plugin
+		if config.MaxExecutionTime > 0 || config.MaxMemoryPages > 0 {
+			log.DefaultLogger.Errorf("[proxywasm][config] checkVmConfig fail, limits with from_wasm_plugin")
+			return errors.New("limits are not supported with from_wasm_plugin")
+		}
 		config.VmConfig = nil
 		config.InstanceNum = 0
 	} else {
@@ -76,6 +113,13 @@ func checkVmConfig(config *filterConfigItem) error {
 			return errors.New("nil vm config")
 		}
 
+		if config.Source != nil {
+			if err := config.Source.Validate(); err != nil {
+				log.DefaultLogger.Errorf("[proxywasm][config] checkVmConfig fail, invalid source: %v", err)
+				return err
+			}
+		}
+
 		if config.InstanceNum <= 0 {
 			config.InstanceNum = runtime.NumCPU()
 		}
@@ -119,3 +163,18 @@ func parseUserData(rawConfigBytes []byte, config *filterConfigItem) error {
 
 	return nil
 }
+
+// setModuleInfo records the digest of the module and the install time
+func (config *filterConfigItem) setModuleInfo() {
+	config.InstallTime = time.Now()
+	config.Digest = ""
+	if config.VmConfig == nil || config.VmConfig.Path == "" {
+		return
+	}
+	digest, err := fetch.FileDigest(config.VmConfig.Path)
+	if err != nil {
+		log.DefaultLogger.Warnf("[proxywasm][config] fail to compute digest of %s, err: %v", config.VmConfig.Path, err)
+		return
+	}
+	config.Digest = digest
+}
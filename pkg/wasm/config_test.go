@@ -20,6 +20,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"mosn.io/layotto/pkg/wasm/fetch"
 )
 
 func TestXProxyWasmConfigFromGlobalPlugin(t *testing.T) {
@@ -71,3 +73,64 @@ func TestXProxyWasmConfigWithVM(t *testing.T) {
 	assert.Equal(t, config.InstanceNum, 2)
 	assert.Equal(t, len(config.UserData), 4)
 }
+
+func TestXProxyWasmConfigWithSource(t *testing.T) {
+	configMap := map[string]interface{}{
+		"vm_config": map[string]interface{}{
+			"engine": "wasmer",
+		},
+		"source": map[string]interface{}{
+			"url": "https://example.com/function_1.wasm",
+		},
+	}
+
+	_, err := parseFilterConfigItem(configMap)
+	assert.Equal(t, fetch.ErrNoVerification, err)
+
+	configMap["source"] = map[string]interface{}{
+		"url":    "https://example.com/function_1.wasm",
+		"sha256": "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+	}
+	config, err := parseFilterConfigItem(configMap)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://example.com/function_1.wasm", config.Source.URL)
+	assert.Equal(t, 0, len(config.UserData))
+}
+
+func TestXProxyWasmConfigWithLimits(t *testing.T) {
+	configMap := map[string]interface{}{
+		"vm_config": map[string]interface{}{
+			"engine": "wasmer",
+			"path":   "path",
+		},
+		"max_execution_time": 100,
+		"max_memory_pages":   256,
+		"max_file_size":      1024,
+	}
+
+	config, err := parseFilterConfigItem(configMap)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), config.MaxExecutionTime)
+	assert.Equal(t, int64(256), config.MaxMemoryPages)
+	assert.Equal(t, int64(1024), config.MaxFileSize)
+	assert.Equal(t, 0, len(config.UserData))
+
+	configMap["max_file_size"] = -1
+	_, err = parseFilterConfigItem(configMap)
+	assert.NotNil(t, err)
+	configMap["max_file_size"] = 1024
+
+	configMap["max_execution_time"] = -1
+	_, err = parseFilterConfigItem(configMap)
+	assert.NotNil(t, err)
+	configMap["max_execution_time"] = 100
+	configMap["max_memory_pages"] = 65537
+	_, err = parseFilterConfigItem(configMap)
+	assert.NotNil(t, err)
+
+	_, err = parseFilterConfigItem(map[string]interface{}{
+		"from_wasm_plugin":   "global_plugin",
+		"max_execution_time": 100,
+	})
+	assert.NotNil(t, err)
+}
@@ -14,43 +14,293 @@ package wasm
 
 import (
 	"errors"
+	"fmt"
 	"math/rand"
+	"sync"
+	"sync/atomic"
 
+	"mosn.io/api"
 	"mosn.io/mosn/pkg/log"
 )
 
+// RouteRule routes the requests whose headers match all the Headers to the Plugin
+type RouteRule struct {
+	Headers map[string]string `json:"headers"`
+	Plugin  string            `json:"plugin"`
+}
+
+// RouteConfig is the routing policy between the plugins registered with the same id.
+// Weights maps the plugin names to their weights, the plugins not in it get no traffic unless it's empty,
+// in which case every plugin has the same weight.
+// Rules are matched in order before the weights.
+type RouteConfig struct {
+	Weights map[string]int `json:"weights,omitempty"`
+	Rules   []*RouteRule   `json:"rules,omitempty"`
+}
+
+// Group is immutable once registered into the Router, updates replace it with a new one
 type Group struct {
 	count   int
 	plugins []*WasmPlugin
+
+	config *RouteConfig
+	// weights[i] is the weight of plugins[i], total is their sum
+	weights []int
+	total   int
+}
+
+// newGroup creates a group with the route config
+func newGroup(plugins []*WasmPlugin, config *RouteConfig) *Group {
+	group := &Group{
+		count:   len(plugins),
+		plugins: plugins,
+		config:  config,
+		weights: make([]int, len(plugins)),
+	}
+	for i, plugin := range plugins {
+		weight := 1
+		if config != nil && len(config.Weights) > 0 {
+			weight = config.Weights[plugin.name()]
+		}
+		group.weights[i] = weight
+		group.total += weight
+	}
+	return group
 }
 
+// find the plugin by name
+func (group *Group) find(name string) *WasmPlugin {
+	for _, plugin := range group.plugins {
+		if plugin.name() == name {
+			return plugin
+		}
+	}
+	return nil
+}
+
+// check whether the route config applies to the group
+func (group *Group) check(config *RouteConfig) error {
+	if config == nil {
+		return nil
+	}
+	total := 0
+	for name, weight := range config.Weights {
+		if weight < 0 {
+			return fmt.Errorf("weight of %s is negative", name)
+		}
+		if group.find(name) == nil {
+			return fmt.Errorf("plugin %s is not registered", name)
+		}
+		total += weight
+	}
+	if len(config.Weights) > 0 && total == 0 {
+		return errors.New("total weight should be greater than 0")
+	}
+	for _, rule := range config.Rules {
+		if rule == nil || len(rule.Headers) == 0 {
+			return errors.New("rule should have headers")
+		}
+		if group.find(rule.Plugin) == nil {
+			return fmt.Errorf("plugin %s is not registered", rule.Plugin)
+		}
+	}
+	return nil
+}
+
+// without returns a copy of the config in which the plugin of name gets no weight and no rule
+func (config *RouteConfig) without(name string) *RouteConfig {
+	if config == nil {
+		return nil
+	}
+	result := &RouteConfig{}
+	total := 0
+	for k, v := range config.Weights {
+		if k != name {
+			if result.Weights == nil {
+				result.Weights = make(map[string]int)
+			}
+			result.Weights[k] = v
+			total += v
+		}
+	}
+	if total == 0 {
+		result.Weights = nil
+	}
+	for _, rule := range config.Rules {
+		if rule.Plugin != name {
+			result.Rules = append(result.Rules, rule)
+		}
+	}
+	return result
+}
+
+// select a plugin by the rules, or by the weights if no rule matches
+func (group *Group) pick(headers api.HeaderMap) *WasmPlugin {
+	if group.config != nil && headers != nil {
+		for _, rule := range group.config.Rules {
+			if matchHeaders(headers, rule.Headers) {
+				if plugin := group.find(rule.Plugin); plugin != nil {
+					return plugin
+				}
+			}
+		}
+	}
+	if group.total <= 0 {
+		return nil
+	}
+	n := rand.Intn(group.total)
+	for i, weight := range group.weights {
+		if n < weight {
+			return group.plugins[i]
+		}
+		n -= weight
+	}
+	return nil
+}
+
+// matchHeaders returns true if headers contain all the expected pairs
+func matchHeaders(headers api.HeaderMap, expected map[string]string) bool {
+	for k, v := range expected {
+		if actual, ok := headers.Get(k); !ok || actual != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Router is safe for concurrent use, the writes are serialized and copy the routes on write,
+// the reads are lock free
 type Router struct {
-	routes map[string]*Group
+	mu sync.Mutex
+	// map[id]*Group
+	routes atomic.Value
+}
+
+// NewRouter creates an empty Router
+func NewRouter() *Router {
+	router := &Router{}
+	router.routes.Store(make(map[string]*Group))
+	return router
 }
 
-// RegisterRoute register a group with id
-// unsafe for concurrent
+// load the current routes, must not be modified
+func (route *Router) load() map[string]*Group {
+	return route.routes.Load().(map[string]*Group)
+}
+
+// update the group of id with f, f returns nil to remove the group
+func (route *Router) update(id string, f func(group *Group) (*Group, error)) error {
+	route.mu.Lock()
+	defer route.mu.Unlock()
+
+	old := route.load()
+	group, err := f(old[id])
+	if err != nil {
+		return err
+	}
+	routes := make(map[string]*Group, len(old)+1)
+	for k, v := range old {
+		routes[k] = v
+	}
+	if group == nil {
+		delete(routes, id)
+	} else {
+		routes[id] = group
+	}
+	route.routes.Store(routes)
+	return nil
+}
+
+// RegisterRoute register a plugin into the group with id, the plugin with the same name is replaced
 func (route *Router) RegisterRoute(id string, plugin *WasmPlugin) {
-	if group, found := route.routes[id]; found {
-		group.plugins = append(filter(group.plugins, func(item *WasmPlugin) bool {
+	_ = route.update(id, func(group *Group) (*Group, error) {
+		if group == nil {
+			return newGroup([]*WasmPlugin{plugin}, nil), nil
+		}
+		plugins := append(filter(group.plugins, func(item *WasmPlugin) bool {
 			return item.pluginName != plugin.pluginName
 		}).([]*WasmPlugin), plugin)
-		group.count = len(group.plugins)
-	} else {
-		route.routes[id] = &Group{
-			count:   1,
-			plugins: []*WasmPlugin{plugin},
+		return newGroup(plugins, group.config), nil
+	})
+}
+
+// UpdateRoute replaces the route config of the group with id atomically
+func (route *Router) UpdateRoute(id string, config *RouteConfig) error {
+	return route.update(id, func(group *Group) (*Group, error) {
+		if group == nil {
+			return nil, errors.New("id is not registered")
 		}
-	}
+		if err := group.check(config); err != nil {
+			return nil, err
+		}
+		return newGroup(group.plugins, config), nil
+	})
 }
 
 func (route *Router) RemoveRoute(id string) {
-	delete(route.routes, id)
+	_ = route.update(id, func(*Group) (*Group, error) {
+		return nil, nil
+	})
+}
+
+// RemovePlugin removes the plugin of the version from the group with id, and the group is removed with its last plugin.
+// The version is also removed from the route config. If none of the versions left has weight, they get the same weight.
+func (route *Router) RemovePlugin(id string, version string) {
+	_ = route.update(id, func(group *Group) (*Group, error) {
+		if group == nil {
+			return nil, nil
+		}
+		plugins := filter(group.plugins, func(item *WasmPlugin) bool {
+			return item.name() != version
+		}).([]*WasmPlugin)
+		if len(plugins) == 0 {
+			return nil, nil
+		}
+		return newGroup(plugins, group.config.without(version)), nil
+	})
+}
+
+// GetPlugin returns the plugin of the version in the group with id.
+// The version can be empty if there is only one plugin in the group.
+func (route *Router) GetPlugin(id string, version string) (*WasmPlugin, error) {
+	group, ok := route.load()[id]
+	if !ok {
+		return nil, errors.New("id is not registered")
+	}
+	if version == "" {
+		if group.count != 1 {
+			return nil, fmt.Errorf("%s has %d versions, the version is required", id, group.count)
+		}
+		return group.plugins[0], nil
+	}
+	plugin := group.find(version)
+	if plugin == nil {
+		return nil, fmt.Errorf("version %s of %s is not registered", version, id)
+	}
+	return plugin, nil
+}
+
+// Route returns the route config of the group with id
+func (route *Router) Route(id string) (*RouteConfig, error) {
+	group, ok := route.load()[id]
+	if !ok {
+		return nil, errors.New("id is not registered")
+	}
+	return group.config, nil
+}
+
+// CheckRoute checks whether the route config applies to the group with id, without updating it
+func (route *Router) CheckRoute(id string, config *RouteConfig) error {
+	group, ok := route.load()[id]
+	if !ok {
+		return errors.New("id is not registered")
+	}
+	return group.check(config)
 }
 
 // Get random plugin with rand id
 func (route *Router) GetRandomPluginByID(id string) (*WasmPlugin, error) {
-	group, ok := route.routes[id]
+	group, ok := route.load()[id]
 	if !ok {
 		log.DefaultLogger.Errorf("[proxywasm][filter] GetRandomPluginByID id not registered, id: %s", id)
 		return nil, errors.New("id is not registered")
@@ -61,3 +311,20 @@ func (route *Router) GetRandomPluginByID(id string) (*WasmPlugin, error) {
 	log.DefaultLogger.Infof("[proxywasm][dispatch] GetRandomPluginByID return index: %d, plugin: %s", idx, plugin.pluginName)
 	return plugin, nil
 }
+
+// GetPluginByRoute selects the plugin of id by the route config of it
+func (route *Router) GetPluginByRoute(id string, headers api.HeaderMap) (*WasmPlugin, error) {
+	group, ok := route.load()[id]
+	if !ok {
+		log.DefaultLogger.Errorf("[proxywasm][dispatch] GetPluginByRoute id not registered, id: %s", id)
+		return nil, errors.New("id is not registered")
+	}
+
+	plugin := group.pick(headers)
+	if plugin == nil {
+		log.DefaultLogger.Errorf("[proxywasm][dispatch] GetPluginByRoute no plugin available, id: %s", id)
+		return nil, errors.New("no plugin available")
+	}
+	log.DefaultLogger.Debugf("[proxywasm][dispatch] GetPluginByRoute return plugin: %s", plugin.pluginName)
+	return plugin, nil
+}
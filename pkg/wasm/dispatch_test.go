@@ -0,0 +1,197 @@
+// Copyright 2021 Layotto Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package wasm
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mosn.io/mosn/pkg/protocol"
+)
+
+func newTestPlugin(name string) *WasmPlugin {
+	return &WasmPlugin{pluginName: "uuid-" + name, config: &filterConfigItem{Name: name}}
+}
+
+func TestRouterRegisterRoute(t *testing.T) {
+	router := NewRouter()
+	_, err := router.GetPluginByRoute("id_1", nil)
+	assert.Error(t, err)
+
+	v1 := newTestPlugin("v1")
+	router.RegisterRoute("id_1", v1)
+	router.RegisterRoute("id_1", v1)
+	plugin, err := router.GetPluginByRoute("id_1", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, v1, plugin)
+	assert.Equal(t, 1, router.load()["id_1"].count)
+
+	router.RemoveRoute("id_1")
+	_, err = router.GetRandomPluginByID("id_1")
+	assert.Error(t, err)
+}
+
+func TestRouterRemovePlugin(t *testing.T) {
+	router := NewRouter()
+	v1, v2, v3 := newTestPlugin("v1"), newTestPlugin("v2"), newTestPlugin("v3")
+	router.RegisterRoute("id_1", v1)
+	router.RegisterRoute("id_1", v2)
+	router.RegisterRoute("id_1", v3)
+	err := router.UpdateRoute("id_1", &RouteConfig{
+		Weights: map[string]int{"v1": 1, "v2": 1},
+		Rules:   []*RouteRule{{Headers: map[string]string{"canary": "true"}, Plugin: "v2"}},
+	})
+	assert.Nil(t, err)
+
+	// only the version is removed, with its weight and rules
+	router.RemovePlugin("id_1", "v2")
+	assert.Equal(t, 2, router.load()["id_1"].count)
+	config, err := router.Route("id_1")
+	assert.Nil(t, err)
+	assert.Equal(t, &RouteConfig{Weights: map[string]int{"v1": 1}}, config)
+	plugin, err := router.GetPluginByRoute("id_1", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, v1, plugin)
+
+	// the versions left get the same weight if none of them has weight
+	router.RemovePlugin("id_1", "v1")
+	plugin, err = router.GetPluginByRoute("id_1", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, v3, plugin)
+
+	// the route is removed with the last version
+	router.RemovePlugin("id_1", "v3")
+	_, err = router.Route("id_1")
+	assert.Error(t, err)
+	router.RemovePlugin("id_1", "v3")
+}
+
+func TestRouterGetPlugin(t *testing.T) {
+	router := NewRouter()
+	_, err := router.GetPlugin("id_1", "")
+	assert.Error(t, err)
+
+	v1, v2 := newTestPlugin("v1"), newTestPlugin("v2")
+	router.RegisterRoute("id_1", v1)
+	// the version can be omitted if there is only one
+	plugin, err := router.GetPlugin("id_1", "")
+	assert.Nil(t, err)
+	assert.Equal(t, v1, plugin)
+
+	router.RegisterRoute("id_1", v2)
+	_, err = router.GetPlugin("id_1", "")
+	assert.Error(t, err)
+	plugin, err = router.GetPlugin("id_1", "v2")
+	assert.Nil(t, err)
+	assert.Equal(t, v2, plugin)
+	_, err = router.GetPlugin("id_1", "v3")
+	assert.Error(t, err)
+
+	assert.Nil(t, router.CheckRoute("id_1", &RouteConfig{Weights: map[string]int{"v2": 1}}))
+	assert.Error(t, router.CheckRoute("id_1", &RouteConfig{Weights: map[string]int{"v3": 1}}))
+	assert.Error(t, router.CheckRoute("id_2", nil))
+}
+
+func TestRouterWeights(t *testing.T) {
+	router := NewRouter()
+	v1, v2 := newTestPlugin("v1"), newTestPlugin("v2")
+	router.RegisterRoute("id_1", v1)
+	router.RegisterRoute("id_1", v2)
+
+	err := router.UpdateRoute("id_1", &RouteConfig{Weights: map[string]int{"v1": 100}})
+	assert.Nil(t, err)
+	for i := 0; i < 100; i++ {
+		plugin, err := router.GetPluginByRoute("id_1", nil)
+		assert.Nil(t, err)
+		assert.Equal(t, v1, plugin)
+	}
+
+	// the plugin registered later gets no traffic until it's in the weights
+	v3 := newTestPlugin("v3")
+	router.RegisterRoute("id_1", v3)
+	plugin, _ := router.GetPluginByRoute("id_1", nil)
+	assert.Equal(t, v1, plugin)
+
+	err = router.UpdateRoute("id_1", &RouteConfig{Weights: map[string]int{"v1": 0, "v3": 1}})
+	assert.Nil(t, err)
+	plugin, _ = router.GetPluginByRoute("id_1", nil)
+	assert.Equal(t, v3, plugin)
+
+	// empty weights means the same weight for every plugin
+	err = router.UpdateRoute("id_1", &RouteConfig{})
+	assert.Nil(t, err)
+	seen := make(map[*WasmPlugin]bool)
+	for i := 0; i < 1000; i++ {
+		plugin, _ := router.GetPluginByRoute("id_1", nil)
+		seen[plugin] = true
+	}
+	assert.Equal(t, 3, len(seen))
+}
+
+func TestRouterRules(t *testing.T) {
+	router := NewRouter()
+	v1, v2 := newTestPlugin("v1"), newTestPlugin("v2")
+	router.RegisterRoute("id_1", v1)
+	router.RegisterRoute("id_1", v2)
+
+	err := router.UpdateRoute("id_1", &RouteConfig{
+		Weights: map[string]int{"v1": 1},
+		Rules:   []*RouteRule{{Headers: map[string]string{"x-canary": "true"}, Plugin: "v2"}},
+	})
+	assert.Nil(t, err)
+
+	plugin, _ := router.GetPluginByRoute("id_1", protocol.CommonHeader{"x-canary": "true"})
+	assert.Equal(t, v2, plugin)
+	plugin, _ = router.GetPluginByRoute("id_1", protocol.CommonHeader{"x-canary": "false"})
+	assert.Equal(t, v1, plugin)
+	plugin, _ = router.GetPluginByRoute("id_1", nil)
+	assert.Equal(t, v1, plugin)
+}
+
+func TestRouterUpdateRouteInvalid(t *testing.T) {
+	router := NewRouter()
+	router.RegisterRoute("id_1", newTestPlugin("v1"))
+
+	assert.Error(t, router.UpdateRoute("id_2", &RouteConfig{}))
+	assert.Error(t, router.UpdateRoute("id_1", &RouteConfig{Weights: map[string]int{"v2": 1}}))
+	assert.Error(t, router.UpdateRoute("id_1", &RouteConfig{Weights: map[string]int{"v1": -1}}))
+	assert.Error(t, router.UpdateRoute("id_1", &RouteConfig{Weights: map[string]int{"v1": 0}}))
+	assert.Error(t, router.UpdateRoute("id_1", &RouteConfig{Rules: []*RouteRule{{Plugin: "v1"}}}))
+	assert.Error(t, router.UpdateRoute("id_1", &RouteConfig{Rules: []*RouteRule{{Headers: map[string]string{"a": "b"}, Plugin: "v2"}}}))
+
+	// the route is unchanged after the failed updates
+	assert.Nil(t, router.load()["id_1"].config)
+}
+
+func TestRouterConcurrent(t *testing.T) {
+	router := NewRouter()
+	router.RegisterRoute("id_1", newTestPlugin("v1"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			router.RegisterRoute("id_1", newTestPlugin("v2"))
+			_ = router.UpdateRoute("id_1", &RouteConfig{Weights: map[string]int{"v1": 1}})
+		}()
+		go func() {
+			defer wg.Done()
+			plugin, err := router.GetPluginByRoute("id_1", nil)
+			assert.Nil(t, err)
+			assert.NotNil(t, plugin)
+		}()
+	}
+	wg.Wait()
+}
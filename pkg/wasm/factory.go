@@ -19,6 +19,7 @@ package wasm
 import (
 	"context"
 	"errors"
+	"sync"
 
 	"mosn.io/mosn/pkg/wasm"
 	"mosn.io/pkg/utils"
@@ -28,6 +29,8 @@ import (
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/types"
 	"mosn.io/mosn/pkg/wasm/abi"
+
+	"mosn.io/layotto/pkg/wasm/fetch"
 )
 
 const LayottoWasm = "Layotto"
@@ -41,6 +44,8 @@ func init() {
 type FilterConfigFactory struct {
 	LayottoHandler
 
+	// mu guards config and plugins, which are replaced by the plugins recycled in background
+	mu            sync.RWMutex
 	config        []*filterConfigItem // contains multi wasm config
 	RootContextID int32
 
@@ -53,7 +58,7 @@ var factory = &FilterConfigFactory{
 	config:        make([]*filterConfigItem, 0),
 	RootContextID: 1,
 	plugins:       make(map[string]*WasmPlugin),
-	router:        &Router{routes: make(map[string]*Group)},
+	router:        NewRouter(),
 }
 
 var _ api.StreamFilterChainFactory = &FilterConfigFactory{}
@@ -70,7 +75,7 @@ func createProxyWasmFilterFactory(confs map[string]interface{}) (api.StreamFilte
 			log.DefaultLogger.Errorf("[proxywasm][factory] createProxyWasmFilterFactory config not a map, configID: %s", configID)
 			return nil, errors.New("config not a map")
 		}
-		err := factory.Install(conf)
+		err := factory.Install(context.Background(), conf)
 		if err != nil {
 			log.DefaultLogger.Errorf("[proxywasm][factory] createProxyWasmFilterFactory install error: %v", err)
 			return nil, err
@@ -98,21 +103,39 @@ func (f *FilterConfigFactory) IsRegister(id string) bool {
 	return err == nil && plugin != nil
 }
 
-func (f *FilterConfigFactory) Install(conf map[string]interface{}) error {
+// Install installs the plugin of conf, ctx bounds the download of the module
+func (f *FilterConfigFactory) Install(ctx context.Context, conf map[string]interface{}) error {
 	config, err := parseFilterConfigItem(conf)
 	if err != nil {
 		return err
 	}
 	var pluginName string
 	if config.FromWasmPlugin == "" {
+		if config.Source != nil {
+			// fetch and verify the module before the VM is created
+			path, err := fetch.Default().Fetch(ctx, config.Source)
+			if err != nil {
+				log.DefaultLogger.Errorf("[proxywasm][factory] fail to fetch wasm module from %s, err: %v", config.Source.URL, err)
+				return err
+			}
+			config.VmConfig.Path = path
+		}
+		vmConfig, err := config.limitedVmConfig()
+		if err != nil {
+			log.DefaultLogger.Errorf("[proxywasm][factory] fail to apply the limits to %s, err: %v", config.VmConfig.Path, err)
+			return err
+		}
 		pluginName = utils.GenerateUUID()
 		v2Config := v2.WasmPluginConfig{
 			PluginName:  pluginName,
-			VmConfig:    config.VmConfig,
+			VmConfig:    vmConfig,
 			InstanceNum: config.InstanceNum,
 		}
 		err = wasm.GetWasmManager().AddOrUpdateWasm(v2Config)
 		if err != nil {
+			if config.Source != nil {
+				return err
+			}
 			config.PluginName = pluginName
 			addWatchFile(config, f)
 			return nil
@@ -126,7 +149,12 @@ func (f *FilterConfigFactory) Install(conf map[string]interface{}) error {
 	if pw == nil {
 		return errors.New("plugin not found")
 	}
-	config.VmConfig = pw.GetConfig().VmConfig
+	// the vm config of a limited plugin runs the compiled module, the original one is kept
+	if config.FromWasmPlugin != "" {
+		config.VmConfig = pw.GetConfig().VmConfig
+	}
+	config.setModuleInfo()
+	f.mu.Lock()
 	f.config = append(filter(f.config, func(item *filterConfigItem) bool {
 		return item.PluginName != config.PluginName
 	}).([]*filterConfigItem), config)
@@ -137,16 +165,20 @@ func (f *FilterConfigFactory) Install(conf map[string]interface{}) error {
 		config:        config,
 	}
 	f.plugins[config.PluginName] = wasmPlugin
+	f.mu.Unlock()
 	pw.RegisterPluginHandler(f)
 	return nil
 }
 
-func (f *FilterConfigFactory) UpdateInstanceNum(id string, instanceNum int) error {
-	wasmPlugin, _ := f.router.GetRandomPluginByID(id)
-	if wasmPlugin == nil {
-		return errors.New(id + " is not registered")
+// UpdateInstanceNum changes the instance number of the plugin of the version in id.
+// The version can be empty if there is only one version of id.
+func (f *FilterConfigFactory) UpdateInstanceNum(id string, version string, instanceNum int) error {
+	wasmPlugin, err := f.router.GetPlugin(id, version)
+	if err != nil {
+		return err
 	}
 
+	f.mu.Lock()
 	var config *filterConfigItem
 	for _, item := range f.config {
 		if item.PluginName == wasmPlugin.pluginName {
@@ -155,43 +187,101 @@ func (f *FilterConfigFactory) UpdateInstanceNum(id string, instanceNum int) erro
 		}
 	}
 	if config == nil {
+		f.mu.Unlock()
 		return errors.New("can't find config for " + id)
 	}
 
 	if config.InstanceNum == instanceNum {
+		f.mu.Unlock()
 		return nil
 	}
 
+	vmConfig, err := config.limitedVmConfig()
+	if err != nil {
+		f.mu.Unlock()
+		return err
+	}
+	oldInstanceNum := config.InstanceNum
 	config.InstanceNum = instanceNum
 	v2Config := v2.WasmPluginConfig{
 		PluginName:  config.PluginName,
-		VmConfig:    config.VmConfig,
+		VmConfig:    vmConfig,
 		InstanceNum: config.InstanceNum,
 	}
-	err := wasm.GetWasmManager().AddOrUpdateWasm(v2Config)
+	f.mu.Unlock()
+	err = wasm.GetWasmManager().AddOrUpdateWasm(v2Config)
 	if err != nil {
+		f.mu.Lock()
+		config.InstanceNum = oldInstanceNum
+		f.mu.Unlock()
 		return err
 	}
 	pw := wasm.GetWasmManager().GetWasmPluginWrapperByName(config.PluginName)
 	if pw == nil {
 		return errors.New("plugin not found")
 	}
+	f.mu.Lock()
 	f.plugins[config.PluginName] = &WasmPlugin{
 		pluginName:    config.PluginName,
 		plugin:        pw.GetPlugin(),
 		rootContextID: config.RootContextID,
 		config:        config,
 	}
+	f.mu.Unlock()
 	pw.RegisterPluginHandler(f)
 	return nil
 }
 
-func (f *FilterConfigFactory) UnInstall(id string) error {
-	wasmPlugin, _ := f.router.GetRandomPluginByID(id)
-	if wasmPlugin == nil {
-		return errors.New(id + " is not registered")
+// UpdateRoute replaces the routing policy between the plugins of id
+func (f *FilterConfigFactory) UpdateRoute(id string, config *RouteConfig) error {
+	return f.router.UpdateRoute(id, config)
+}
+
+// Update applies the route config of id and the instance number of the plugin of the version together.
+// Both are checked before any is applied, and the route config is rolled back if the instance number fails to change.
+// A nil route config or a non-positive instance number is not changed.
+func (f *FilterConfigFactory) Update(id string, version string, route *RouteConfig, instanceNum int) error {
+	if route != nil {
+		if err := f.router.CheckRoute(id, route); err != nil {
+			return err
+		}
+	}
+	if instanceNum > 0 {
+		if _, err := f.router.GetPlugin(id, version); err != nil {
+			return err
+		}
+	}
+	var old *RouteConfig
+	if route != nil {
+		var err error
+		if old, err = f.router.Route(id); err != nil {
+			return err
+		}
+		if err = f.router.UpdateRoute(id, route); err != nil {
+			return err
+		}
+	}
+	if instanceNum > 0 {
+		if err := f.UpdateInstanceNum(id, version, instanceNum); err != nil {
+			if route != nil {
+				if rerr := f.router.UpdateRoute(id, old); rerr != nil {
+					log.DefaultLogger.Errorf("[proxywasm][factory] fail to roll back the route of %s, err: %v", id, rerr)
+				}
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// UnInstall removes the plugin of the version in id, and the route of id is removed with its last version.
+// The version can be empty if there is only one version of id.
+func (f *FilterConfigFactory) UnInstall(id string, version string) error {
+	wasmPlugin, err := f.router.GetPlugin(id, version)
+	if err != nil {
+		return err
 	}
-	err := wasm.GetWasmManager().UninstallWasmPluginByName(wasmPlugin.pluginName)
+	err = wasm.GetWasmManager().UninstallWasmPluginByName(wasmPlugin.pluginName)
 	if err != nil {
 		return err
 	}
@@ -203,12 +293,15 @@ func (f *FilterConfigFactory) UnInstall(id string) error {
 		}
 	}
 
+	f.mu.Lock()
 	f.config = filter(f.config, func(item *filterConfigItem) bool {
 		return item.PluginName != wasmPlugin.pluginName
 	}).([]*filterConfigItem)
 	delete(f.plugins, wasmPlugin.pluginName)
 	removeWatchFile(wasmPlugin.config)
-	f.router.RemoveRoute(id)
+	f.mu.Unlock()
+	removePluginStats(wasmPlugin.pluginName)
+	f.router.RemovePlugin(id, wasmPlugin.name())
 	return nil
 }
 
@@ -223,10 +316,15 @@ var _ types.WasmPluginHandler = &FilterConfigFactory{}
 
 // update config of FilterConfigFactory
 func (f *FilterConfigFactory) OnConfigUpdate(config v2.WasmPluginConfig) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 	for _, plugin := range f.config {
 		if plugin.PluginName == config.PluginName {
 			plugin.InstanceNum = config.InstanceNum
-			plugin.VmConfig = config.VmConfig
+			// the vm config of a limited plugin runs the compiled module, the original one is kept
+			if plugin.MaxExecutionTime <= 0 && plugin.MaxMemoryPages <= 0 {
+				plugin.VmConfig = config.VmConfig
+			}
 		}
 	}
 }
@@ -234,7 +332,9 @@ func (f *FilterConfigFactory) OnConfigUpdate(config v2.WasmPluginConfig) {
 // Execute the plugin of FilterConfigFactory
 func (f *FilterConfigFactory) OnPluginStart(plugin types.WasmPlugin) {
 	plugin.Exec(func(instance types.WasmInstance) bool {
+		f.mu.RLock()
 		wasmPlugin, ok := f.plugins[plugin.PluginName()]
+		f.mu.RUnlock()
 		if !ok {
 			log.DefaultLogger.Errorf("[proxywasm][factory] createProxyWasmFilterFactory fail to get wasm plugin, PluginName: %s",
 				plugin.PluginName())
@@ -13,6 +13,7 @@
 package wasm
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 
@@ -41,7 +42,7 @@ func TestFilterConfigFactory_Install(t *testing.T) {
 	config := "{\"name\":\"id_1\",\"instance_num\":2,\"vm_config\":{\"engine\":\"wasmer\",\"path\":\"nofile\"}}"
 	err := json.Unmarshal([]byte(config), &conf)
 	assert.NoError(t, err)
-	err = factory.Install(conf)
+	err = factory.Install(context.Background(), conf)
 	assert.NoError(t, err)
 }
 
@@ -55,7 +56,7 @@ func TestFilterConfigFactory_Install_WithErrorConfig(t *testing.T) {
 	config := "{\"name\":\"id_1\"}"
 	err := json.Unmarshal([]byte(config), &conf)
 	assert.NoError(t, err)
-	err = factory.Install(conf)
+	err = factory.Install(context.Background(), conf)
 	assert.Equal(t, "nil vm config", err.Error())
 }
 
@@ -94,3 +95,30 @@ func TestCreateProxyWasmFilterFactory(t *testing.T) {
 	_, err = createProxyWasmFilterFactory(conf.Config)
 	assert.NoError(t, err)
 }
+
+func TestFilterConfigFactory_Update(t *testing.T) {
+	p1, p2 := newTestPlugin("v1"), newTestPlugin("v2")
+	f := &FilterConfigFactory{
+		router:  NewRouter(),
+		plugins: map[string]*WasmPlugin{p1.pluginName: p1, p2.pluginName: p2},
+	}
+	f.router.RegisterRoute("id_1", p1)
+	f.router.RegisterRoute("id_1", p2)
+	route := &RouteConfig{Weights: map[string]int{"v2": 1}}
+
+	// nothing is applied if any is invalid
+	assert.Error(t, f.Update("id_1", "v3", route, 2))
+	assert.Error(t, f.Update("id_1", "", route, 2))
+	assert.Error(t, f.Update("id_1", "v1", &RouteConfig{Weights: map[string]int{"v3": 1}}, 2))
+	config, _ := f.router.Route("id_1")
+	assert.Nil(t, config)
+
+	// the route is rolled back if the instance number fails to change, as the config of v1 is absent
+	assert.Error(t, f.Update("id_1", "v1", route, 2))
+	config, _ = f.router.Route("id_1")
+	assert.Nil(t, config)
+
+	assert.Nil(t, f.Update("id_1", "", route, 0))
+	config, _ = f.router.Route("id_1")
+	assert.Equal(t, route, config)
+}
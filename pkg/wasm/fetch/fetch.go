@@ -0,0 +1,270 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package fetch downloads the WASM modules from https URLs or OCI registries
+// into a local content-addressed cache, and verifies them before they are loaded.
+package fetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"mosn.io/pkg/log"
+)
+
+const (
+	httpsScheme = "https://"
+	ociScheme   = "oci://"
+
+	digestPrefix = "sha256:"
+
+	// maxModuleSize limits the size of a module to download
+	maxModuleSize = 256 << 20
+
+	// CacheDirEnv overrides the default cache directory
+	CacheDirEnv = "LAYOTTO_WASM_CACHE_DIR"
+)
+
+var (
+	ErrUnsupportedURL   = errors.New("only https:// and oci:// urls are supported")
+	ErrNoVerification   = errors.New("either sha256 or signature is required")
+	ErrDigestMismatch   = errors.New("sha256 digest mismatch")
+	ErrInvalidDigest    = errors.New("invalid sha256 digest")
+	ErrModuleTooLarge   = errors.New("wasm module is too large")
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// Source describes where a WASM module comes from and how to verify it
+type Source struct {
+	// URL is an https:// URL, or an oci:// artifact reference like oci://ghcr.io/org/module:v1
+	URL string `json:"url"`
+	// Sha256 is the hex digest of the module, with an optional "sha256:" prefix.
+	// It's required unless Signature is set.
+	Sha256 string `json:"sha256,omitempty"`
+	// Signature is the base64 ECDSA signature of the module, like the output of `cosign sign-blob`
+	Signature string `json:"signature,omitempty"`
+	// PublicKey is the PEM encoded public key to verify Signature
+	PublicKey string `json:"public_key,omitempty"`
+}
+
+// Validate checks whether the source can be fetched and verified
+func (s *Source) Validate() error {
+	if !strings.HasPrefix(s.URL, httpsScheme) && !strings.HasPrefix(s.URL, ociScheme) {
+		return ErrUnsupportedURL
+	}
+	if s.Sha256 == "" && s.Signature == "" {
+		return ErrNoVerification
+	}
+	if s.Sha256 != "" {
+		if _, err := normalizeDigest(s.Sha256); err != nil {
+			return err
+		}
+	}
+	if s.Signature != "" && s.PublicKey == "" {
+		return errors.New("public_key is required to verify the signature")
+	}
+	return nil
+}
+
+// Fetcher downloads the modules into Dir, the file of a module is named by its sha256 digest,
+// so the downloaded modules are reused across restarts.
+type Fetcher struct {
+	Dir    string
+	Client *http.Client
+}
+
+// NewFetcher creates a Fetcher with the cache directory
+func NewFetcher(dir string) *Fetcher {
+	return &Fetcher{
+		Dir:    dir,
+		Client: &http.Client{Timeout: time.Minute},
+	}
+}
+
+var (
+	defaultFetcher     *Fetcher
+	defaultFetcherOnce sync.Once
+)
+
+// Default returns the Fetcher using the directory of CacheDirEnv, or the user cache directory
+func Default() *Fetcher {
+	defaultFetcherOnce.Do(func() {
+		dir := os.Getenv(CacheDirEnv)
+		if dir == "" {
+			cacheDir, err := os.UserCacheDir()
+			if err != nil {
+				cacheDir = os.TempDir()
+			}
+			dir = filepath.Join(cacheDir, "layotto", "wasm")
+		}
+		defaultFetcher = NewFetcher(dir)
+	})
+	return defaultFetcher
+}
+
+// Fetch downloads the module of the source if it's not cached, verifies it and returns the local path
+func (f *Fetcher) Fetch(ctx context.Context, source *Source) (string, error) {
+	if err := source.Validate(); err != nil {
+		return "", err
+	}
+	expected, _ := normalizeDigest(source.Sha256)
+
+	// reuse the cached module if the digest is known
+	if expected != "" {
+		if path, ok := f.cached(expected); ok {
+			if err := verifySignature(source, path); err != nil {
+				return "", err
+			}
+			log.DefaultLogger.Infof("[wasm][fetch] use cached module %s for %s", expected, source.URL)
+			return path, nil
+		}
+	}
+
+	var (
+		path string
+		err  error
+	)
+	if strings.HasPrefix(source.URL, ociScheme) {
+		path, err = f.fetchOCI(ctx, strings.TrimPrefix(source.URL, ociScheme), expected)
+	} else {
+		path, err = f.download(ctx, source.URL, nil, expected)
+	}
+	if err != nil {
+		return "", err
+	}
+	if err = verifySignature(source, path); err != nil {
+		return "", err
+	}
+	log.DefaultLogger.Infof("[wasm][fetch] fetched module %s from %s", filepath.Base(path), source.URL)
+	return path, nil
+}
+
+// cached returns the path of the module in the cache if it exists and isn't corrupted
+func (f *Fetcher) cached(digest string) (string, bool) {
+	path := f.path(digest)
+	actual, err := FileDigest(path)
+	if err != nil {
+		return "", false
+	}
+	if actual != digest {
+		log.DefaultLogger.Warnf("[wasm][fetch] remove corrupted cache file %s", path)
+		_ = os.Remove(path)
+		return "", false
+	}
+	return path, true
+}
+
+// path of the module in the cache
+func (f *Fetcher) path(digest string) string {
+	return filepath.Join(f.Dir, "sha256", digest+".wasm")
+}
+
+// download the url into the cache, and checks the digest of the content if expected isn't empty
+func (f *Fetcher) download(ctx context.Context, url string, header http.Header, expected string) (string, error) {
+	resp, err := f.get(ctx, url, header)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fail to download %s, status: %s", url, resp.Status)
+	}
+	return f.store(resp.Body, expected)
+}
+
+// get sends a GET request
+func (f *Fetcher) get(ctx context.Context, url string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	return f.Client.Do(req)
+}
+
+// store writes r into the cache atomically, and returns the path named by its digest
+func (f *Fetcher) store(r io.Reader, expected string) (string, error) {
+	dir := filepath.Join(f.Dir, "sha256")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	tmp, err := ioutil.TempFile(dir, ".download-")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), io.LimitReader(r, maxModuleSize+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+	if n > maxModuleSize {
+		return "", ErrModuleTooLarge
+	}
+	actual := hex.EncodeToString(h.Sum(nil))
+	if expected != "" && actual != expected {
+		return "", fmt.Errorf("%w, expected: %s, actual: %s", ErrDigestMismatch, expected, actual)
+	}
+	path := f.path(actual)
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// normalizeDigest returns the lower case hex of a sha256 digest
+func normalizeDigest(digest string) (string, error) {
+	if digest == "" {
+		return "", nil
+	}
+	digest = strings.ToLower(strings.TrimPrefix(digest, digestPrefix))
+	if b, err := hex.DecodeString(digest); err != nil || len(b) != sha256.Size {
+		return "", ErrInvalidDigest
+	}
+	return digest, nil
+}
+
+// FileDigest computes the sha256 hex digest of the file
+func FileDigest(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
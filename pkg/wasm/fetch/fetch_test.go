@@ -0,0 +1,218 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fetch
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var module = []byte("\x00asm\x01\x00\x00\x00")
+
+func digestOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, data []byte) string {
+	sum := sha256.Sum256(data)
+	r, s, err := ecdsa.Sign(rand.Reader, key, sum[:])
+	assert.Nil(t, err)
+	sig, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	assert.Nil(t, err)
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+func newTestFetcher(t *testing.T, server *httptest.Server) *Fetcher {
+	dir, err := ioutil.TempDir("", "wasm-cache")
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	f := NewFetcher(dir)
+	f.Client = server.Client()
+	return f
+}
+
+func TestSourceValidate(t *testing.T) {
+	assert.Equal(t, ErrUnsupportedURL, (&Source{URL: "http://a/b.wasm", Sha256: digestOf(module)}).Validate())
+	assert.Equal(t, ErrUnsupportedURL, (&Source{URL: "/tmp/b.wasm", Sha256: digestOf(module)}).Validate())
+	assert.Equal(t, ErrNoVerification, (&Source{URL: "https://a/b.wasm"}).Validate())
+	assert.Equal(t, ErrInvalidDigest, (&Source{URL: "https://a/b.wasm", Sha256: "abc"}).Validate())
+	assert.NotNil(t, (&Source{URL: "https://a/b.wasm", Signature: "abc"}).Validate())
+	assert.Nil(t, (&Source{URL: "oci://a/b:v1", Sha256: "sha256:" + digestOf(module)}).Validate())
+}
+
+func TestFetchHTTPS(t *testing.T) {
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(module)
+	}))
+	defer server.Close()
+	f := newTestFetcher(t, server)
+
+	path, err := f.Fetch(context.Background(), &Source{URL: server.URL + "/a.wasm", Sha256: digestOf(module)})
+	assert.Nil(t, err)
+	assert.True(t, strings.HasSuffix(path, digestOf(module)+".wasm"))
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, module, data)
+
+	// the cached module is reused, even by a new fetcher
+	path2, err := NewFetcher(f.Dir).Fetch(context.Background(), &Source{URL: server.URL + "/a.wasm", Sha256: digestOf(module)})
+	assert.Nil(t, err)
+	assert.Equal(t, path, path2)
+	assert.Equal(t, 1, requests)
+
+	_, err = f.Fetch(context.Background(), &Source{URL: server.URL + "/a.wasm", Sha256: digestOf([]byte("other"))})
+	assert.True(t, errors.Is(err, ErrDigestMismatch))
+}
+
+func TestFetchSignature(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(module)
+	}))
+	defer server.Close()
+	f := newTestFetcher(t, server)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.Nil(t, err)
+	publicKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	source := &Source{URL: server.URL + "/a.wasm", Signature: sign(t, key, module), PublicKey: publicKey}
+	path, err := f.Fetch(context.Background(), source)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasSuffix(path, digestOf(module)+".wasm"))
+
+	source.Signature = sign(t, key, []byte("other"))
+	_, err = f.Fetch(context.Background(), source)
+	assert.Equal(t, ErrInvalidSignature, err)
+}
+
+func TestParseReference(t *testing.T) {
+	r, err := parseReference("ghcr.io/org/module:v1")
+	assert.Nil(t, err)
+	assert.Equal(t, &reference{registry: "ghcr.io", repository: "org/module", reference: "v1"}, r)
+
+	r, err = parseReference("localhost:5000/module")
+	assert.Nil(t, err)
+	assert.Equal(t, &reference{registry: "localhost:5000", repository: "module", reference: "latest"}, r)
+
+	r, err = parseReference("ghcr.io/module@sha256:abc")
+	assert.Nil(t, err)
+	assert.Equal(t, &reference{registry: "ghcr.io", repository: "module", reference: "sha256:abc"}, r)
+
+	_, err = parseReference("module")
+	assert.NotNil(t, err)
+}
+
+func TestFetchOCI(t *testing.T) {
+	digest := "sha256:" + digestOf(module)
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			assert.Equal(t, "repository:org/module:pull", r.URL.Query().Get("scope"))
+			json.NewEncoder(w).Encode(map[string]string{"token": "t"})
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer t" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry",scope="repository:org/module:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/org/module/manifests/v1":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"mediaType": mediaTypeOCIManifest,
+				"layers": []map[string]interface{}{
+					{"mediaType": "application/vnd.oci.image.config.v1+json", "digest": "sha256:" + digestOf([]byte("{}"))},
+					{"mediaType": "application/vnd.module.wasm.content.layer.v1+wasm", "digest": digest},
+				},
+			})
+		case "/v2/org/module/blobs/" + digest:
+			w.Write(module)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	f := newTestFetcher(t, server)
+	ref := "oci://" + strings.TrimPrefix(server.URL, "https://") + "/org/module:v1"
+
+	path, err := f.Fetch(context.Background(), &Source{URL: ref, Sha256: digest})
+	assert.Nil(t, err)
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, module, data)
+
+	_, err = f.Fetch(context.Background(), &Source{URL: ref, Sha256: digestOf([]byte("other"))})
+	assert.True(t, errors.Is(err, ErrDigestMismatch))
+}
+
+func TestFetchOCILargeManifest(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"mediaType": strings.Repeat("x", maxJSONSize),
+		})
+	}))
+	defer server.Close()
+	f := newTestFetcher(t, server)
+	ref := "oci://" + strings.TrimPrefix(server.URL, "https://") + "/org/module:v1"
+
+	_, err := f.Fetch(context.Background(), &Source{URL: ref, Sha256: digestOf(module)})
+	// the manifest is truncated at maxJSONSize
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestFetchCanceled(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// hang until the client gives up
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	f := newTestFetcher(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := f.Fetch(ctx, &Source{URL: server.URL + "/module.wasm", Sha256: digestOf(module)})
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
@@ -0,0 +1,208 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	mediaTypeOCIManifest    = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIIndex       = "application/vnd.oci.image.index.v1+json"
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerList     = "application/vnd.docker.distribution.manifest.list.v2+json"
+	// maxJSONSize limits the size of the manifests and the token responses to decode
+	maxJSONSize = 4 << 20
+)
+
+// reference is a parsed OCI artifact reference, e.g. ghcr.io/org/module:v1 or ghcr.io/org/module@sha256:...
+type reference struct {
+	registry   string
+	repository string
+	// tag or digest
+	reference string
+}
+
+// parseReference parses the reference without the oci:// scheme
+func parseReference(ref string) (*reference, error) {
+	i := strings.Index(ref, "/")
+	if i <= 0 || i == len(ref)-1 {
+		return nil, fmt.Errorf("invalid oci reference: %s", ref)
+	}
+	r := &reference{registry: ref[:i], repository: ref[i+1:], reference: "latest"}
+	if j := strings.Index(r.repository, "@"); j >= 0 {
+		r.reference = r.repository[j+1:]
+		r.repository = r.repository[:j]
+	} else if j = strings.LastIndex(r.repository, ":"); j >= 0 {
+		r.reference = r.repository[j+1:]
+		r.repository = r.repository[:j]
+	}
+	if r.repository == "" || r.reference == "" {
+		return nil, fmt.Errorf("invalid oci reference: %s", ref)
+	}
+	return r, nil
+}
+
+func (r *reference) url(kind string, ref string) string {
+	return fmt.Sprintf("https://%s/v2/%s/%s/%s", r.registry, r.repository, kind, ref)
+}
+
+type descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+// manifest contains the fields of both the image manifest and the image index
+type manifest struct {
+	MediaType string        `json:"mediaType"`
+	Layers    []*descriptor `json:"layers"`
+	Manifests []*descriptor `json:"manifests"`
+}
+
+// wasmLayer returns the layer of the module, it's the only layer or the one with a wasm media type
+func (m *manifest) wasmLayer() (*descriptor, error) {
+	if len(m.Layers) == 1 {
+		return m.Layers[0], nil
+	}
+	for _, layer := range m.Layers {
+		if strings.Contains(layer.MediaType, "wasm") {
+			return layer, nil
+		}
+	}
+	return nil, errors.New("no wasm layer found in the oci manifest")
+}
+
+// fetchOCI pulls the wasm layer of the artifact into the cache
+func (f *Fetcher) fetchOCI(ctx context.Context, ref string, expected string) (string, error) {
+	r, err := parseReference(ref)
+	if err != nil {
+		return "", err
+	}
+	header := make(http.Header)
+	m, err := f.getManifest(ctx, r, r.reference, header)
+	if err != nil {
+		return "", err
+	}
+	// pick the first manifest of an index
+	if len(m.Manifests) > 0 {
+		if m, err = f.getManifest(ctx, r, m.Manifests[0].Digest, header); err != nil {
+			return "", err
+		}
+	}
+	layer, err := m.wasmLayer()
+	if err != nil {
+		return "", err
+	}
+	digest, err := normalizeDigest(layer.Digest)
+	if err != nil {
+		return "", err
+	}
+	if expected != "" && digest != expected {
+		return "", fmt.Errorf("%w, expected: %s, layer: %s", ErrDigestMismatch, expected, digest)
+	}
+	if path, ok := f.cached(digest); ok {
+		return path, nil
+	}
+	return f.download(ctx, r.url("blobs", layer.Digest), header, digest)
+}
+
+// getManifest gets the manifest, and sets the Authorization into header if the registry requires a token
+func (f *Fetcher) getManifest(ctx context.Context, r *reference, ref string, header http.Header) (*manifest, error) {
+	header.Set("Accept", strings.Join([]string{mediaTypeOCIManifest, mediaTypeOCIIndex, mediaTypeDockerManifest, mediaTypeDockerList}, ","))
+	resp, err := f.get(ctx, r.url("manifests", ref), header)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && header.Get("Authorization") == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		token, err := f.token(ctx, challenge)
+		if err != nil {
+			return nil, err
+		}
+		header.Set("Authorization", "Bearer "+token)
+		if resp, err = f.get(ctx, r.url("manifests", ref), header); err != nil {
+			return nil, err
+		}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fail to get oci manifest %s, status: %s", ref, resp.Status)
+	}
+	m := &manifest{}
+	if err = json.NewDecoder(io.LimitReader(resp.Body, maxJSONSize)).Decode(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// token gets an anonymous token by the Bearer challenge of the registry
+func (f *Fetcher) token(ctx context.Context, challenge string) (string, error) {
+	params := parseChallenge(challenge)
+	realm := params["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("unsupported auth challenge: %s", challenge)
+	}
+	query := url.Values{}
+	for _, k := range []string{"service", "scope"} {
+		if v := params[k]; v != "" {
+			query.Set(k, v)
+		}
+	}
+	resp, err := f.get(ctx, realm+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fail to get registry token, status: %s", resp.Status)
+	}
+	body := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err = json.NewDecoder(io.LimitReader(resp.Body, maxJSONSize)).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// parseChallenge parses `Bearer realm="...",service="...",scope="..."`
+func parseChallenge(challenge string) map[string]string {
+	params := make(map[string]string)
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return params
+	}
+	for _, pair := range strings.Split(challenge[len("bearer "):], ",") {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) == 2 {
+			params[kv[0]] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return params
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fetch
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"strings"
+)
+
+// verifySignature verifies the module at path against the signature of the source, if any.
+// The file name of the module in the cache is its digest, so it isn't read again.
+func verifySignature(source *Source, path string) error {
+	if source.Signature == "" {
+		return nil
+	}
+	digest, err := hex.DecodeString(strings.TrimSuffix(filepath.Base(path), ".wasm"))
+	if err != nil {
+		return err
+	}
+	key, err := parsePublicKey(source.PublicKey)
+	if err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(source.Signature))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	var esig struct {
+		R, S *big.Int
+	}
+	if rest, err := asn1.Unmarshal(sig, &esig); err != nil || len(rest) != 0 {
+		return ErrInvalidSignature
+	}
+	if !ecdsa.Verify(key, digest, esig.R, esig.S) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// parsePublicKey parses the PEM encoded ECDSA public key
+func parsePublicKey(data string) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("invalid PEM public key")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	ecKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("only ECDSA public key is supported")
+	}
+	return ecKey, nil
+}
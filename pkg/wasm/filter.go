@@ -22,6 +22,7 @@ import (
 	"reflect"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"mosn.io/mosn/pkg/variable"
 	"mosn.io/mosn/pkg/wasm/abi"
@@ -41,14 +42,15 @@ type Filter struct {
 	ctx     context.Context
 	factory *FilterConfigFactory
 
-	router  *Router
-	plugins map[string]*WasmPlugin
+	router *Router
 
 	contextID  int32
 	pluginUsed *WasmPlugin
 	instance   types.WasmInstance
 	abi        types.ABI
 	exports    Exports
+	// recycled is set if the plugin exceeds its limits, the instance mustn't be used anymore
+	recycled bool
 
 	receiverFilterHandler api.StreamReceiverFilterHandler
 	senderFilterHandler   api.StreamSenderFilterHandler
@@ -70,6 +72,14 @@ type WasmPlugin struct {
 	pluginConfigBytes buffer.IoBuffer
 }
 
+// name of the plugin in the route config, it's the name of the config item if set
+func (p *WasmPlugin) name() string {
+	if p.config != nil && p.config.Name != "" {
+		return p.config.Name
+	}
+	return p.pluginName
+}
+
 // Get the VmConfig of WasmPlugin
 func (p *WasmPlugin) GetVmConfig() common.IoBuffer {
 	if p.vmConfigBytes != nil {
@@ -128,12 +138,13 @@ func newContextID(rootContextID int32) int32 {
 // NewFilter create the filter for a request
 func NewFilter(ctx context.Context, factory *FilterConfigFactory) *Filter {
 	filter := &Filter{
+		LayottoHandler: LayottoHandler{ctx: ctx},
+
 		ctx:     ctx,
 		factory: factory,
 
 		contextID:      newContextID(factory.RootContextID),
 		router:         factory.router,
-		plugins:        factory.plugins,
 		requestBuffer:  buffer.NewIoBuffer(100),
 		responseBuffer: buffer.NewIoBuffer(100),
 	}
@@ -146,6 +157,12 @@ func (f *Filter) releaseUsedInstance() error {
 		return nil
 	}
 	plugin := f.pluginUsed
+	if f.recycled {
+		f.instance = nil
+		f.pluginUsed = nil
+		f.exports = nil
+		return nil
+	}
 	f.instance.Lock(f.abi)
 
 	_, err := f.exports.ProxyOnDone(f.contextID)
@@ -209,7 +226,7 @@ func (f *Filter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffe
 		return api.StreamFilterStop
 	}
 
-	wasmPlugin, err := f.router.GetRandomPluginByID(id)
+	wasmPlugin, err := f.router.GetPluginByRoute(id, headers)
 	if err != nil {
 		log.DefaultLogger.Errorf("[proxywasm][filter] OnReceive call ProxyOnRequestHeaders id, err: %v", err)
 		return api.StreamFilterStop
@@ -220,6 +237,8 @@ func (f *Filter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffe
 	instance := plugin.GetInstance()
 	f.instance = instance
 	f.LayottoHandler.Instance = instance
+	f.LayottoHandler.ctx = ctx
+	f.LayottoHandler.maxFileSize = wasmPlugin.maxFileSize()
 
 	pluginABI := abi.GetABI(instance, AbiV2)
 	if pluginABI == nil {
@@ -231,10 +250,38 @@ func (f *Filter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffe
 	exports := pluginABI.GetABIExports().(Exports)
 	f.exports = exports
 
-	instance.Lock(pluginABI)
-	defer instance.Unlock()
+	maxExecutionTime, maxMemoryPages := wasmPlugin.limits()
+	stats := GetPluginStats(wasmPlugin.pluginName)
+	start := time.Now()
+	var timeout bool
+	status := func() api.StreamFilterStatus {
+		instance.Lock(pluginABI)
+		defer instance.Unlock()
+		interrupted := setDeadline(instance, maxExecutionTime)
+		defer func() {
+			timeout = interrupted()
+		}()
+		return f.handleRequest(ctx, wasmPlugin, exports, headers, buf, trailers)
+	}()
+
+	pages := memoryPages(instance)
+	stats.Record(time.Since(start), status != api.StreamFilterContinue, pages)
+	if timeout {
+		f.onLimitExceeded(wasmPlugin, ErrExecutionTimeout)
+		return api.StreamFilterStop
+	}
+	// memory.grow fails at the max of the linear memory, which usually traps the module
+	if status != api.StreamFilterContinue && maxMemoryPages > 0 && pages >= maxMemoryPages {
+		f.onLimitExceeded(wasmPlugin, ErrMemoryLimitExceeded)
+		return api.StreamFilterStop
+	}
+	return status
+}
 
-	err = exports.ProxyOnContextCreate(f.contextID, wasmPlugin.rootContextID)
+// handleRequest calls the plugin to handle the request, the instance must be locked
+func (f *Filter) handleRequest(ctx context.Context, wasmPlugin *WasmPlugin, exports Exports,
+	headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	err := exports.ProxyOnContextCreate(f.contextID, wasmPlugin.rootContextID)
 	if err != nil {
 		log.DefaultLogger.Errorf("[proxywasm][filter] NewFilter fail to create context id: %v, rootContextID: %v, err: %v",
 			f.contextID, wasmPlugin.rootContextID, err)
@@ -0,0 +1,241 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wasm
+
+import (
+	"encoding/binary"
+
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/proxy-wasm-go-host/proxywasm/common"
+	proxywasm "mosn.io/proxy-wasm-go-host/proxywasm/v1"
+)
+
+// hostCalls are the Layotto host functions registered into the "env" namespace of every instance.
+// proxy_get_state and proxy_invoke_service are registered by proxy-wasm-go-host itself.
+var hostCalls = map[string]interface{}{
+	"proxy_publish_event": ProxyPublishEvent,
+	"proxy_get_config":    ProxyGetConfig,
+	"proxy_try_lock":      ProxyTryLock,
+	"proxy_unlock":        ProxyUnlock,
+	"proxy_get_next_id":   ProxyGetNextId,
+	"proxy_get_secret":    ProxyGetSecret,
+	"proxy_get_file":      ProxyGetFile,
+	"proxy_put_file":      ProxyPutFile,
+	// called by the modules compiled with max_execution_time
+	interruptFuncName: LayottoCheckInterrupt,
+}
+
+// registerHostCalls registers the Layotto host functions into the instance
+func registerHostCalls(instance common.WasmInstance) {
+	for name, f := range hostCalls {
+		if err := instance.RegisterFunc("env", name, f); err != nil {
+			log.DefaultLogger.Errorf("[proxywasm][hostcalls] fail to register %s, err: %v", name, err)
+		}
+	}
+}
+
+// getLayottoHandler returns the imports handler of the abi currently holding the instance
+func getLayottoHandler(instance common.WasmInstance) LayottoImportsHandler {
+	if a, ok := instance.GetData().(*AbiV2Impl); ok {
+		if handler, ok := a.Imports.(LayottoImportsHandler); ok {
+			return handler
+		}
+	}
+	return nil
+}
+
+// readStrings reads the strings located by the (ptr, size) pairs from the instance memory
+func readStrings(instance common.WasmInstance, ptrAndSizes ...int32) ([]string, bool) {
+	result := make([]string, 0, len(ptrAndSizes)/2)
+	for i := 0; i+1 < len(ptrAndSizes); i += 2 {
+		b, err := instance.GetMemory(uint64(ptrAndSizes[i]), uint64(ptrAndSizes[i+1]))
+		if err != nil {
+			return nil, false
+		}
+		result = append(result, string(b))
+	}
+	return result, true
+}
+
+// copyIntoInstance allocates the memory in the instance for value, and writes its address and size to retPtr and retSize
+func copyIntoInstance(instance common.WasmInstance, value []byte, retPtr int32, retSize int32) proxywasm.WasmResult {
+	addr, err := instance.Malloc(int32(len(value)))
+	if err != nil {
+		return proxywasm.WasmResultInvalidMemoryAccess
+	}
+	if err = instance.PutMemory(addr, uint64(len(value)), value); err != nil {
+		return proxywasm.WasmResultInvalidMemoryAccess
+	}
+	if err = instance.PutUint32(uint64(retPtr), uint32(addr)); err != nil {
+		return proxywasm.WasmResultInvalidMemoryAccess
+	}
+	if err = instance.PutUint32(uint64(retSize), uint32(len(value))); err != nil {
+		return proxywasm.WasmResultInvalidMemoryAccess
+	}
+	return proxywasm.WasmResultOk
+}
+
+// ProxyPublishEvent publishes data to the topic of the pubsub component
+func ProxyPublishEvent(instance common.WasmInstance, pubsubNamePtr int32, pubsubNameSize int32,
+	topicPtr int32, topicSize int32, dataPtr int32, dataSize int32) int32 {
+	args, ok := readStrings(instance, pubsubNamePtr, pubsubNameSize, topicPtr, topicSize, dataPtr, dataSize)
+	if !ok {
+		return proxywasm.WasmResultInvalidMemoryAccess.Int32()
+	}
+	handler := getLayottoHandler(instance)
+	if handler == nil {
+		return proxywasm.WasmResultUnimplemented.Int32()
+	}
+	return handler.PublishEvent(args[0], args[1], []byte(args[2])).Int32()
+}
+
+// ProxyGetConfig gets the content of the key from the configuration store
+func ProxyGetConfig(instance common.WasmInstance, storeNamePtr int32, storeNameSize int32, appIdPtr int32, appIdSize int32,
+	groupPtr int32, groupSize int32, keyPtr int32, keySize int32, valuePtr int32, valueSize int32) int32 {
+	args, ok := readStrings(instance, storeNamePtr, storeNameSize, appIdPtr, appIdSize, groupPtr, groupSize, keyPtr, keySize)
+	if !ok {
+		return proxywasm.WasmResultInvalidMemoryAccess.Int32()
+	}
+	handler := getLayottoHandler(instance)
+	if handler == nil {
+		return proxywasm.WasmResultUnimplemented.Int32()
+	}
+	ret, res := handler.GetConfig(args[0], args[1], args[2], args[3])
+	if res != proxywasm.WasmResultOk {
+		return res.Int32()
+	}
+	return copyIntoInstance(instance, []byte(ret), valuePtr, valueSize).Int32()
+}
+
+// ProxyTryLock tries to get the distributed lock, and writes 1 to successPtr if succeeded, otherwise 0
+func ProxyTryLock(instance common.WasmInstance, storeNamePtr int32, storeNameSize int32, resourceIdPtr int32, resourceIdSize int32,
+	lockOwnerPtr int32, lockOwnerSize int32, expire int32, successPtr int32) int32 {
+	args, ok := readStrings(instance, storeNamePtr, storeNameSize, resourceIdPtr, resourceIdSize, lockOwnerPtr, lockOwnerSize)
+	if !ok {
+		return proxywasm.WasmResultInvalidMemoryAccess.Int32()
+	}
+	handler := getLayottoHandler(instance)
+	if handler == nil {
+		return proxywasm.WasmResultUnimplemented.Int32()
+	}
+	success, res := handler.TryLock(args[0], args[1], args[2], expire)
+	if res != proxywasm.WasmResultOk {
+		return res.Int32()
+	}
+	var value uint32
+	if success {
+		value = 1
+	}
+	if err := instance.PutUint32(uint64(successPtr), value); err != nil {
+		return proxywasm.WasmResultInvalidMemoryAccess.Int32()
+	}
+	return proxywasm.WasmResultOk.Int32()
+}
+
+// ProxyUnlock releases the distributed lock, and writes the UnlockResponse_Status to statusPtr
+func ProxyUnlock(instance common.WasmInstance, storeNamePtr int32, storeNameSize int32, resourceIdPtr int32, resourceIdSize int32,
+	lockOwnerPtr int32, lockOwnerSize int32, statusPtr int32) int32 {
+	args, ok := readStrings(instance, storeNamePtr, storeNameSize, resourceIdPtr, resourceIdSize, lockOwnerPtr, lockOwnerSize)
+	if !ok {
+		return proxywasm.WasmResultInvalidMemoryAccess.Int32()
+	}
+	handler := getLayottoHandler(instance)
+	if handler == nil {
+		return proxywasm.WasmResultUnimplemented.Int32()
+	}
+	status, res := handler.Unlock(args[0], args[1], args[2])
+	if res != proxywasm.WasmResultOk {
+		return res.Int32()
+	}
+	if err := instance.PutUint32(uint64(statusPtr), uint32(status)); err != nil {
+		return proxywasm.WasmResultInvalidMemoryAccess.Int32()
+	}
+	return proxywasm.WasmResultOk.Int32()
+}
+
+// ProxyGetNextId gets the next id of the key, and writes it to idPtr as a little-endian int64
+func ProxyGetNextId(instance common.WasmInstance, storeNamePtr int32, storeNameSize int32,
+	keyPtr int32, keySize int32, idPtr int32) int32 {
+	args, ok := readStrings(instance, storeNamePtr, storeNameSize, keyPtr, keySize)
+	if !ok {
+		return proxywasm.WasmResultInvalidMemoryAccess.Int32()
+	}
+	handler := getLayottoHandler(instance)
+	if handler == nil {
+		return proxywasm.WasmResultUnimplemented.Int32()
+	}
+	id, res := handler.GetNextId(args[0], args[1])
+	if res != proxywasm.WasmResultOk {
+		return res.Int32()
+	}
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, uint64(id))
+	if err := instance.PutMemory(uint64(idPtr), 8, b); err != nil {
+		return proxywasm.WasmResultInvalidMemoryAccess.Int32()
+	}
+	return proxywasm.WasmResultOk.Int32()
+}
+
+// ProxyGetSecret gets the secret of the key, the result is encoded as a proxy-wasm map
+func ProxyGetSecret(instance common.WasmInstance, storeNamePtr int32, storeNameSize int32,
+	keyPtr int32, keySize int32, valuePtr int32, valueSize int32) int32 {
+	args, ok := readStrings(instance, storeNamePtr, storeNameSize, keyPtr, keySize)
+	if !ok {
+		return proxywasm.WasmResultInvalidMemoryAccess.Int32()
+	}
+	handler := getLayottoHandler(instance)
+	if handler == nil {
+		return proxywasm.WasmResultUnimplemented.Int32()
+	}
+	ret, res := handler.GetSecret(args[0], args[1])
+	if res != proxywasm.WasmResultOk {
+		return res.Int32()
+	}
+	return copyIntoInstance(instance, common.EncodeMap(ret), valuePtr, valueSize).Int32()
+}
+
+// ProxyGetFile reads the whole file
+func ProxyGetFile(instance common.WasmInstance, storeNamePtr int32, storeNameSize int32,
+	namePtr int32, nameSize int32, dataPtr int32, dataSize int32) int32 {
+	args, ok := readStrings(instance, storeNamePtr, storeNameSize, namePtr, nameSize)
+	if !ok {
+		return proxywasm.WasmResultInvalidMemoryAccess.Int32()
+	}
+	handler := getLayottoHandler(instance)
+	if handler == nil {
+		return proxywasm.WasmResultUnimplemented.Int32()
+	}
+	ret, res := handler.GetFile(args[0], args[1])
+	if res != proxywasm.WasmResultOk {
+		return res.Int32()
+	}
+	return copyIntoInstance(instance, ret, dataPtr, dataSize).Int32()
+}
+
+// ProxyPutFile writes the whole file
+func ProxyPutFile(instance common.WasmInstance, storeNamePtr int32, storeNameSize int32,
+	namePtr int32, nameSize int32, dataPtr int32, dataSize int32) int32 {
+	args, ok := readStrings(instance, storeNamePtr, storeNameSize, namePtr, nameSize, dataPtr, dataSize)
+	if !ok {
+		return proxywasm.WasmResultInvalidMemoryAccess.Int32()
+	}
+	handler := getLayottoHandler(instance)
+	if handler == nil {
+		return proxywasm.WasmResultUnimplemented.Int32()
+	}
+	return handler.PutFile(args[0], args[1], []byte(args[2])).Int32()
+}
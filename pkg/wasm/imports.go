@@ -17,9 +17,17 @@
 package wasm
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"io"
 
 	anypb "github.com/golang/protobuf/ptypes/any"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/wasm/abi/proxywasm010"
 	"mosn.io/proxy-wasm-go-host/proxywasm/common"
 	proxywasm "mosn.io/proxy-wasm-go-host/proxywasm/v1"
@@ -33,9 +41,64 @@ type LayottoHandler struct {
 	proxywasm010.DefaultImportsHandler
 
 	IoBuffer common.IoBuffer
+
+	// ctx is the context of the request being handled, it flows into the host calls
+	ctx context.Context
+	// maxFileSize is the max size of the file read by GetFile, zero means defaultMaxFileSize
+	maxFileSize int64
+}
+
+// defaultMaxFileSize is the max size of the file read by GetFile if the plugin doesn't set max_file_size
+const defaultMaxFileSize = 4 << 20
+
+var errFileTooLarge = errors.New("file too large")
+
+var (
+	_ proxywasm.ImportsHandler = &LayottoHandler{}
+	_ LayottoImportsHandler    = &LayottoHandler{}
+)
+
+// LayottoImportsHandler contains the host calls which Layotto provides besides the proxy-wasm ones
+type LayottoImportsHandler interface {
+	GetState(storeName string, key string) (string, proxywasm.WasmResult)
+	InvokeService(id string, method string, param string) (string, proxywasm.WasmResult)
+	PublishEvent(pubsubName string, topic string, data []byte) proxywasm.WasmResult
+	GetConfig(storeName string, appId string, group string, key string) (string, proxywasm.WasmResult)
+	TryLock(storeName string, resourceId string, lockOwner string, expire int32) (bool, proxywasm.WasmResult)
+	Unlock(storeName string, resourceId string, lockOwner string) (runtimev1pb.UnlockResponse_Status, proxywasm.WasmResult)
+	GetNextId(storeName string, key string) (int64, proxywasm.WasmResult)
+	GetSecret(storeName string, key string) (map[string]string, proxywasm.WasmResult)
+	GetFile(storeName string, name string) ([]byte, proxywasm.WasmResult)
+	PutFile(storeName string, name string, data []byte) proxywasm.WasmResult
 }
 
-var _ proxywasm.ImportsHandler = &LayottoHandler{}
+// the context of the host calls, context.Background() if no request is being handled
+func (d *LayottoHandler) requestContext() context.Context {
+	if d.ctx == nil {
+		return context.Background()
+	}
+	return d.ctx
+}
+
+// toWasmResult maps the grpc status of err to WasmResult
+func toWasmResult(err error) proxywasm.WasmResult {
+	switch status.Code(err) {
+	case codes.OK:
+		return proxywasm.WasmResultOk
+	case codes.NotFound:
+		return proxywasm.WasmResultNotFound
+	case codes.InvalidArgument, codes.OutOfRange:
+		return proxywasm.WasmResultBadArgument
+	case codes.AlreadyExists, codes.Aborted:
+		return proxywasm.WasmResultCasMismatch
+	case codes.Unimplemented:
+		return proxywasm.WasmResultUnimplemented
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled:
+		return proxywasm.WasmResultBrokenConnection
+	default:
+		return proxywasm.WasmResultInternalFailure
+	}
+}
 
 // Obtains the state for a specific key
 func (d *LayottoHandler) GetState(storeName string, key string) (string, proxywasm.WasmResult) {
@@ -43,9 +106,9 @@ func (d *LayottoHandler) GetState(storeName string, key string) (string, proxywa
 		StoreName: storeName,
 		Key:       key,
 	}
-	resp, err := default_api.LayottoAPISingleton.GetState(context.Background(), req)
+	resp, err := default_api.LayottoAPISingleton.GetState(d.requestContext(), req)
 	if err != nil {
-		return "", proxywasm.WasmResultInternalFailure
+		return "", toWasmResult(err)
 	}
 	return string(resp.Data), proxywasm.WasmResultOk
 }
@@ -59,13 +122,130 @@ func (d *LayottoHandler) InvokeService(id string, method string, param string) (
 			Data:   &anypb.Any{Value: []byte(param)},
 		},
 	}
-	resp, err := default_api.LayottoAPISingleton.InvokeService(context.Background(), req)
+	resp, err := default_api.LayottoAPISingleton.InvokeService(d.requestContext(), req)
 	if err != nil {
-		return "", proxywasm.WasmResultInternalFailure
+		return "", toWasmResult(err)
 	}
 	return string(resp.Data.Value), proxywasm.WasmResultOk
 }
 
+// Publishes an event to the specific topic
+func (d *LayottoHandler) PublishEvent(pubsubName string, topic string, data []byte) proxywasm.WasmResult {
+	req := &runtimev1pb.PublishEventRequest{
+		PubsubName: pubsubName,
+		Topic:      topic,
+		Data:       data,
+	}
+	_, err := default_api.LayottoAPISingleton.PublishEvent(d.requestContext(), req)
+	return toWasmResult(err)
+}
+
+// Gets the configuration of a specific key
+func (d *LayottoHandler) GetConfig(storeName string, appId string, group string, key string) (string, proxywasm.WasmResult) {
+	req := &runtimev1pb.GetConfigurationRequest{
+		StoreName: storeName,
+		AppId:     appId,
+		Group:     group,
+		Keys:      []string{key},
+	}
+	resp, err := default_api.LayottoAPISingleton.GetConfiguration(d.requestContext(), req)
+	if err != nil {
+		return "", toWasmResult(err)
+	}
+	for _, item := range resp.Items {
+		if item.Key == key {
+			return item.Content, proxywasm.WasmResultOk
+		}
+	}
+	return "", proxywasm.WasmResultNotFound
+}
+
+// Tries to get the distributed lock
+func (d *LayottoHandler) TryLock(storeName string, resourceId string, lockOwner string, expire int32) (bool, proxywasm.WasmResult) {
+	req := &runtimev1pb.TryLockRequest{
+		StoreName:  storeName,
+		ResourceId: resourceId,
+		LockOwner:  lockOwner,
+		Expire:     expire,
+	}
+	resp, err := default_api.LayottoAPISingleton.TryLock(d.requestContext(), req)
+	if err != nil {
+		return false, toWasmResult(err)
+	}
+	return resp.Success, proxywasm.WasmResultOk
+}
+
+// Releases the distributed lock
+func (d *LayottoHandler) Unlock(storeName string, resourceId string, lockOwner string) (runtimev1pb.UnlockResponse_Status, proxywasm.WasmResult) {
+	req := &runtimev1pb.UnlockRequest{
+		StoreName:  storeName,
+		ResourceId: resourceId,
+		LockOwner:  lockOwner,
+	}
+	resp, err := default_api.LayottoAPISingleton.Unlock(d.requestContext(), req)
+	if err != nil {
+		return runtimev1pb.UnlockResponse_INTERNAL_ERROR, toWasmResult(err)
+	}
+	return resp.Status, proxywasm.WasmResultOk
+}
+
+// Gets the next id of the sequencer
+func (d *LayottoHandler) GetNextId(storeName string, key string) (int64, proxywasm.WasmResult) {
+	req := &runtimev1pb.GetNextIdRequest{
+		StoreName: storeName,
+		Key:       key,
+	}
+	resp, err := default_api.LayottoAPISingleton.GetNextId(d.requestContext(), req)
+	if err != nil {
+		return 0, toWasmResult(err)
+	}
+	return resp.NextId, proxywasm.WasmResultOk
+}
+
+// Gets the secret of a specific key
+func (d *LayottoHandler) GetSecret(storeName string, key string) (map[string]string, proxywasm.WasmResult) {
+	req := &runtimev1pb.GetSecretRequest{
+		StoreName: storeName,
+		Key:       key,
+	}
+	resp, err := default_api.LayottoAPISingleton.GetSecret(d.requestContext(), req)
+	if err != nil {
+		return nil, toWasmResult(err)
+	}
+	return resp.Data, proxywasm.WasmResultOk
+}
+
+// Reads the whole file
+func (d *LayottoHandler) GetFile(storeName string, name string) ([]byte, proxywasm.WasmResult) {
+	req := &runtimev1pb.GetFileRequest{
+		StoreName: storeName,
+		Name:      name,
+	}
+	stream := &getFileStream{ctx: d.requestContext(), maxSize: d.maxFileSize}
+	if stream.maxSize <= 0 {
+		stream.maxSize = defaultMaxFileSize
+	}
+	if err := default_api.LayottoAPISingleton.GetFile(req, stream); err != nil {
+		if stream.exceeded {
+			log.DefaultLogger.Errorf("[proxywasm][imports] GetFile %s from %s fail, the file exceeds %d bytes", name, storeName, stream.maxSize)
+			return nil, proxywasm.WasmResultBadArgument
+		}
+		return nil, toWasmResult(err)
+	}
+	return stream.data.Bytes(), proxywasm.WasmResultOk
+}
+
+// Writes the whole file
+func (d *LayottoHandler) PutFile(storeName string, name string, data []byte) proxywasm.WasmResult {
+	req := &runtimev1pb.PutFileRequest{
+		StoreName: storeName,
+		Name:      name,
+		Data:      data,
+	}
+	err := default_api.LayottoAPISingleton.PutFile(&putFileStream{ctx: d.requestContext(), req: req})
+	return toWasmResult(err)
+}
+
 // Get the IoBuffer of LayottoHandler
 func (d *LayottoHandler) GetFuncCallData() common.IoBuffer {
 	if d.IoBuffer == nil {
@@ -73,3 +253,51 @@ func (d *LayottoHandler) GetFuncCallData() common.IoBuffer {
 	}
 	return d.IoBuffer
 }
+
+// getFileStream collects the data sent by GetFile in memory, up to maxSize bytes
+type getFileStream struct {
+	grpc.ServerStream
+
+	ctx      context.Context
+	maxSize  int64
+	exceeded bool
+	data     bytes.Buffer
+}
+
+func (s *getFileStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *getFileStream) Send(resp *runtimev1pb.GetFileResponse) error {
+	if int64(s.data.Len())+int64(len(resp.Data)) > s.maxSize {
+		s.exceeded = true
+		return errFileTooLarge
+	}
+	s.data.Write(resp.Data)
+	return nil
+}
+
+// putFileStream feeds PutFile with a single request
+type putFileStream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+	req *runtimev1pb.PutFileRequest
+}
+
+func (s *putFileStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *putFileStream) Recv() (*runtimev1pb.PutFileRequest, error) {
+	if s.req == nil {
+		return nil, io.EOF
+	}
+	req := s.req
+	s.req = nil
+	return req, nil
+}
+
+func (s *putFileStream) SendAndClose(*emptypb.Empty) error {
+	return nil
+}
@@ -18,17 +18,28 @@ package wasm
 
 import (
 	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
 	"testing"
 
+	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/components-contrib/state"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	proxywasm "mosn.io/proxy-wasm-go-host/proxywasm/v1"
 
+	"mosn.io/layotto/components/file"
+	"mosn.io/layotto/components/lock"
 	"mosn.io/layotto/components/rpc"
 	mosninvoker "mosn.io/layotto/components/rpc/invoker/mosn"
 	"mosn.io/layotto/pkg/grpc/default_api"
+	"mosn.io/layotto/pkg/mock"
 	mock_invoker "mosn.io/layotto/pkg/mock/components/invoker"
+	mock_lock "mosn.io/layotto/pkg/mock/components/lock"
+	mock_pubsub "mosn.io/layotto/pkg/mock/components/pubsub"
 	mock_state "mosn.io/layotto/pkg/mock/components/state"
 )
 
@@ -81,3 +92,74 @@ func TestInvokeService(t *testing.T) {
 		assert.Equal(t, "100", result)
 	})
 }
+
+func TestToWasmResult(t *testing.T) {
+	assert.Equal(t, proxywasm.WasmResultOk, toWasmResult(nil))
+	assert.Equal(t, proxywasm.WasmResultNotFound, toWasmResult(status.Error(codes.NotFound, "")))
+	assert.Equal(t, proxywasm.WasmResultBadArgument, toWasmResult(status.Error(codes.InvalidArgument, "")))
+	assert.Equal(t, proxywasm.WasmResultUnimplemented, toWasmResult(status.Error(codes.Unimplemented, "")))
+	assert.Equal(t, proxywasm.WasmResultBrokenConnection, toWasmResult(status.Error(codes.DeadlineExceeded, "")))
+	assert.Equal(t, proxywasm.WasmResultInternalFailure, toWasmResult(errors.New("unknown")))
+}
+
+func TestPublishEvent(t *testing.T) {
+	d := &LayottoHandler{}
+	mockPubSub := mock_pubsub.NewMockPubSub(gomock.NewController(t))
+	mockPubSub.EXPECT().Features().Return(nil)
+	mockPubSub.EXPECT().Publish(gomock.Any()).DoAndReturn(func(req *pubsub.PublishRequest) error {
+		assert.Equal(t, "topic", req.Topic)
+		return nil
+	})
+	default_api.LayottoAPISingleton = default_api.NewAPI("", nil, nil, nil, map[string]pubsub.PubSub{"mock": mockPubSub}, nil, nil, nil, nil, nil, nil)
+	assert.Equal(t, proxywasm.WasmResultOk, d.PublishEvent("mock", "topic", []byte("data")))
+	assert.Equal(t, proxywasm.WasmResultBadArgument, d.PublishEvent("mock", "", []byte("data")))
+}
+
+func TestTryLock(t *testing.T) {
+	d := &LayottoHandler{}
+	mockLockStore := mock_lock.NewMockLockStore(gomock.NewController(t))
+	mockLockStore.EXPECT().TryLock(gomock.Any()).Return(&lock.TryLockResponse{Success: true}, nil)
+	default_api.LayottoAPISingleton = default_api.NewAPI("", nil, nil, nil, nil, nil, nil, map[string]lock.LockStore{"mock": mockLockStore}, nil, nil, nil)
+	success, res := d.TryLock("mock", "resource", "owner", 10)
+	assert.Equal(t, proxywasm.WasmResultOk, res)
+	assert.True(t, success)
+	_, res = d.TryLock("mock", "resource", "owner", 0)
+	assert.Equal(t, proxywasm.WasmResultBadArgument, res)
+}
+
+func TestFile(t *testing.T) {
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+	d := &LayottoHandler{ctx: ctx}
+	mockFile := mock.NewMockFile(gomock.NewController(t))
+	default_api.LayottoAPISingleton = default_api.NewAPI("", nil, nil, nil, nil, nil, map[string]file.File{"mock": mockFile}, nil, nil, nil, nil)
+
+	mockFile.EXPECT().Get(ctx, gomock.Any()).Return(ioutil.NopCloser(strings.NewReader("hello")), nil)
+	data, res := d.GetFile("mock", "a.txt")
+	assert.Equal(t, proxywasm.WasmResultOk, res)
+	assert.Equal(t, "hello", string(data))
+
+	mockFile.EXPECT().Put(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, st *file.PutFileStu) error {
+		b, err := ioutil.ReadAll(st.DataStream)
+		assert.Nil(t, err)
+		assert.Equal(t, "world", string(b))
+		return nil
+	})
+	assert.Equal(t, proxywasm.WasmResultOk, d.PutFile("mock", "a.txt", []byte("world")))
+
+	_, res = d.GetFile("not-exist", "a.txt")
+	assert.Equal(t, proxywasm.WasmResultBadArgument, res)
+
+	// the file larger than max_file_size is rejected
+	d.maxFileSize = 4
+	mockFile.EXPECT().Get(ctx, gomock.Any()).Return(ioutil.NopCloser(strings.NewReader("hello")), nil)
+	data, res = d.GetFile("mock", "a.txt")
+	assert.Equal(t, proxywasm.WasmResultBadArgument, res)
+	assert.Nil(t, data)
+
+	d.maxFileSize = 5
+	mockFile.EXPECT().Get(ctx, gomock.Any()).Return(ioutil.NopCloser(strings.NewReader("hello")), nil)
+	data, res = d.GetFile("mock", "a.txt")
+	assert.Equal(t, proxywasm.WasmResultOk, res)
+	assert.Equal(t, "hello", string(data))
+}
@@ -58,7 +58,7 @@ func (e *Endpoint) Handle(ctx context.Context, params http.ParamsScanner) (map[s
 		return map[string]interface{}{"error": errorMessage}, errors.New(errorMessage)
 	}
 
-	err = factory.Install(conf)
+	err = factory.Install(ctx, conf)
 	if err != nil {
 		log.DefaultLogger.Errorf("[wasm][install] %v", err)
 		return map[string]interface{}{"error": err.Error()}, err
@@ -0,0 +1,820 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wasm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// The wasm engine can neither interrupt a running function nor limit the memory of an instance,
+// so the limits are compiled into the module before it's loaded:
+//   - the maximum of the linear memory is set to max_memory_pages, so memory.grow fails beyond it.
+//   - every function entry and loop header counts down a global, and calls the host function
+//     layotto_check_interrupt once the global reaches zero. The call traps with unreachable if
+//     the host reports the deadline of the request has passed.
+
+const (
+	interruptNamespace = "env"
+	interruptFuncName  = "layotto_check_interrupt"
+	// the number of function entries and loop iterations between the calls to the host
+	interruptCheckInterval = 1024
+)
+
+// the ids of the module sections
+const (
+	sectionCustom    = 0
+	sectionType      = 1
+	sectionImport    = 2
+	sectionFunction  = 3
+	sectionTable     = 4
+	sectionMemory    = 5
+	sectionGlobal    = 6
+	sectionExport    = 7
+	sectionStart     = 8
+	sectionElement   = 9
+	sectionCode      = 10
+	sectionData      = 11
+	sectionDataCount = 12
+	sectionTag       = 13
+)
+
+// sectionOrder returns the order of the section in the module, as the ids aren't ordered
+func sectionOrder(id byte) int {
+	switch id {
+	case sectionTag:
+		return sectionMemory*10 + 5
+	case sectionDataCount:
+		return sectionElement*10 + 5
+	}
+	return int(id) * 10
+}
+
+var errInvalidModule = errors.New("invalid wasm module")
+
+// instrumentModule compiles the limits into the module, maxMemoryPages is not set if it's zero,
+// and the interruption is injected if interrupt is set
+func instrumentModule(module []byte, maxMemoryPages uint32, interrupt bool) ([]byte, error) {
+	if len(module) < 8 || !bytes.Equal(module[:4], []byte("\x00asm")) {
+		return nil, errInvalidModule
+	}
+	ins := &instrumenter{maxMemoryPages: maxMemoryPages, interrupt: interrupt}
+	sections, err := readSections(module[8:])
+	if err != nil {
+		return nil, err
+	}
+	// the indices of the imports come first, so they're counted before the other sections are rewritten
+	for _, s := range sections {
+		switch s.id {
+		case sectionType:
+			ins.types, err = newReader(s.content).u32()
+		case sectionImport:
+			err = ins.countImports(s.content)
+		case sectionGlobal:
+			ins.definedGlobals, err = newReader(s.content).u32()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out := bytes.NewBuffer(append([]byte{}, module[:8]...))
+	// the sections created for the interruption, if the module doesn't have them
+	missing := map[byte]bool{}
+	if interrupt {
+		missing[sectionType], missing[sectionImport], missing[sectionGlobal] = true, true, true
+	}
+	for _, s := range sections {
+		for _, id := range []byte{sectionType, sectionImport, sectionGlobal} {
+			if missing[id] && s.id != sectionCustom && sectionOrder(id) <= sectionOrder(s.id) {
+				if id != s.id {
+					content, err := ins.rewrite(id, appendU32(nil, 0))
+					if err != nil {
+						return nil, err
+					}
+					writeSection(out, id, content)
+				}
+				missing[id] = false
+			}
+		}
+		// the function indices in the name section are shifted by the injected import
+		if s.id == sectionCustom && interrupt && s.name() == "name" {
+			continue
+		}
+		content, err := ins.rewrite(s.id, s.content)
+		if err != nil {
+			return nil, err
+		}
+		writeSection(out, s.id, content)
+	}
+	for _, id := range []byte{sectionType, sectionImport, sectionGlobal} {
+		if missing[id] {
+			content, err := ins.rewrite(id, appendU32(nil, 0))
+			if err != nil {
+				return nil, err
+			}
+			writeSection(out, id, content)
+		}
+	}
+	return out.Bytes(), nil
+}
+
+type section struct {
+	id      byte
+	content []byte
+}
+
+// name returns the name of the custom section
+func (s *section) name() string {
+	r := newReader(s.content)
+	name, err := r.bytes()
+	if err != nil {
+		return ""
+	}
+	return string(name)
+}
+
+func readSections(b []byte) ([]*section, error) {
+	var sections []*section
+	r := newReader(b)
+	for !r.done() {
+		id, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		content, err := r.bytes()
+		if err != nil {
+			return nil, err
+		}
+		if id > sectionTag {
+			return nil, fmt.Errorf("%w: unknown section %d", errInvalidModule, id)
+		}
+		sections = append(sections, &section{id: id, content: content})
+	}
+	return sections, nil
+}
+
+func writeSection(out *bytes.Buffer, id byte, content []byte) {
+	out.WriteByte(id)
+	out.Write(appendU32(nil, uint32(len(content))))
+	out.Write(content)
+}
+
+type instrumenter struct {
+	maxMemoryPages uint32
+	interrupt      bool
+
+	types           uint32
+	importedFuncs   uint32
+	importedGlobals uint32
+	definedGlobals  uint32
+}
+
+// the index of the injected function type, import and global
+func (ins *instrumenter) interruptType() uint32   { return ins.types }
+func (ins *instrumenter) interruptFunc() uint32   { return ins.importedFuncs }
+func (ins *instrumenter) interruptGlobal() uint32 { return ins.importedGlobals + ins.definedGlobals }
+
+// remapFunc maps the function index of the module to the index after the import is injected
+func (ins *instrumenter) remapFunc(idx uint32) uint32 {
+	if ins.interrupt && idx >= ins.importedFuncs {
+		return idx + 1
+	}
+	return idx
+}
+
+func (ins *instrumenter) countImports(content []byte) error {
+	r := newReader(content)
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		kind, err := r.importDesc(nil)
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case 0x00:
+			ins.importedFuncs++
+		case 0x03:
+			ins.importedGlobals++
+		}
+	}
+	return nil
+}
+
+// rewrite returns the content of the section with the limits
+func (ins *instrumenter) rewrite(id byte, content []byte) ([]byte, error) {
+	switch id {
+	case sectionType:
+		return ins.rewriteType(content)
+	case sectionImport:
+		return ins.rewriteImport(content)
+	case sectionMemory:
+		return ins.rewriteVec(content, func(r *reader, out []byte) ([]byte, error) {
+			return ins.rewriteLimits(r, out)
+		})
+	case sectionGlobal:
+		return ins.rewriteGlobal(content)
+	}
+	if !ins.interrupt {
+		return content, nil
+	}
+	switch id {
+	case sectionExport:
+		return ins.rewriteVec(content, func(r *reader, out []byte) ([]byte, error) {
+			name, err := r.bytes()
+			if err != nil {
+				return nil, err
+			}
+			kind, err := r.byte()
+			if err != nil {
+				return nil, err
+			}
+			idx, err := r.u32()
+			if err != nil {
+				return nil, err
+			}
+			if kind == 0x00 {
+				idx = ins.remapFunc(idx)
+			}
+			out = appendBytes(out, name)
+			return appendU32(append(out, kind), idx), nil
+		})
+	case sectionStart:
+		idx, err := newReader(content).u32()
+		if err != nil {
+			return nil, err
+		}
+		return appendU32(nil, ins.remapFunc(idx)), nil
+	case sectionElement:
+		return ins.rewriteVec(content, ins.rewriteElement)
+	case sectionCode:
+		return ins.rewriteVec(content, ins.rewriteBody)
+	}
+	return content, nil
+}
+
+// rewriteVec rewrites every item of the vector
+func (ins *instrumenter) rewriteVec(content []byte, item func(r *reader, out []byte) ([]byte, error)) ([]byte, error) {
+	r := newReader(content)
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	out := appendU32(nil, n)
+	for i := uint32(0); i < n; i++ {
+		if out, err = item(r, out); err != nil {
+			return nil, err
+		}
+	}
+	if !r.done() {
+		return nil, fmt.Errorf("%w: trailing bytes in section", errInvalidModule)
+	}
+	return out, nil
+}
+
+func (ins *instrumenter) rewriteType(content []byte) ([]byte, error) {
+	if !ins.interrupt {
+		return content, nil
+	}
+	r := newReader(content)
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	out := appendU32(nil, n+1)
+	out = append(out, r.rest()...)
+	// func type () -> i32
+	return append(out, 0x60, 0x00, 0x01, 0x7f), nil
+}
+
+func (ins *instrumenter) rewriteImport(content []byte) ([]byte, error) {
+	r := newReader(content)
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	count := n
+	if ins.interrupt {
+		count++
+	}
+	out := appendU32(nil, count)
+	for i := uint32(0); i < n; i++ {
+		if _, err = r.importDesc(func(kind byte, out2 []byte, r *reader) ([]byte, error) {
+			return ins.rewriteLimits(r, out2)
+		}); err != nil {
+			return nil, err
+		}
+		out = append(out, r.lastImport...)
+	}
+	if ins.interrupt {
+		out = appendBytes(out, []byte(interruptNamespace))
+		out = appendBytes(out, []byte(interruptFuncName))
+		out = appendU32(append(out, 0x00), ins.interruptType())
+	}
+	return out, nil
+}
+
+// rewriteLimits copies the limits of a memory, and sets its maximum to maxMemoryPages
+func (ins *instrumenter) rewriteLimits(r *reader, out []byte) ([]byte, error) {
+	flag, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	if flag > 0x03 {
+		return nil, fmt.Errorf("%w: unsupported memory limits 0x%x", errInvalidModule, flag)
+	}
+	min, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	hasMax := flag&0x01 != 0
+	var max uint32
+	if hasMax {
+		if max, err = r.u32(); err != nil {
+			return nil, err
+		}
+	}
+	if ins.maxMemoryPages > 0 {
+		if min > ins.maxMemoryPages {
+			return nil, fmt.Errorf("the module requires %d memory pages, more than the max %d", min, ins.maxMemoryPages)
+		}
+		if !hasMax || max > ins.maxMemoryPages {
+			max = ins.maxMemoryPages
+		}
+		hasMax = true
+		flag |= 0x01
+	}
+	out = appendU32(append(out, flag), min)
+	if hasMax {
+		out = appendU32(out, max)
+	}
+	return out, nil
+}
+
+func (ins *instrumenter) rewriteGlobal(content []byte) ([]byte, error) {
+	if !ins.interrupt {
+		return content, nil
+	}
+	out, err := ins.rewriteVec(content, func(r *reader, out []byte) ([]byte, error) {
+		t, err := r.take(2)
+		if err != nil {
+			return nil, err
+		}
+		return ins.rewriteConstExpr(r, append(out, t...))
+	})
+	if err != nil {
+		return nil, err
+	}
+	// (global (mut i32) (i32.const interruptCheckInterval))
+	r := newReader(out)
+	n, _ := r.u32()
+	global := append([]byte{0x7f, 0x01, 0x41}, appendS32(nil, interruptCheckInterval)...)
+	return append(append(appendU32(nil, n+1), r.rest()...), append(global, 0x0b)...), nil
+}
+
+func (ins *instrumenter) rewriteElement(r *reader, out []byte) ([]byte, error) {
+	flag, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	if flag > 7 {
+		return nil, fmt.Errorf("%w: unknown element segment %d", errInvalidModule, flag)
+	}
+	out = appendU32(out, flag)
+	if flag&0x02 != 0 && flag&0x01 == 0 {
+		// explicit table index
+		idx, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		out = appendU32(out, idx)
+	}
+	if flag&0x01 == 0 {
+		// active segment
+		if out, err = ins.rewriteConstExpr(r, out); err != nil {
+			return nil, err
+		}
+	}
+	if flag&0x03 != 0 {
+		// elemkind or reftype
+		b, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	out = appendU32(out, n)
+	for i := uint32(0); i < n; i++ {
+		if flag&0x04 != 0 {
+			out, err = ins.rewriteConstExpr(r, out)
+		} else {
+			var idx uint32
+			if idx, err = r.u32(); err == nil {
+				out = appendU32(out, ins.remapFunc(idx))
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// rewriteConstExpr copies the instructions until the end of the expression
+func (ins *instrumenter) rewriteConstExpr(r *reader, out []byte) ([]byte, error) {
+	for {
+		op, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		if out, err = ins.rewriteInstr(r, op, out); err != nil {
+			return nil, err
+		}
+		if op == 0x0b {
+			return out, nil
+		}
+	}
+}
+
+func (ins *instrumenter) rewriteBody(r *reader, out []byte) ([]byte, error) {
+	body, err := r.bytes()
+	if err != nil {
+		return nil, err
+	}
+	br := newReader(body)
+	n, err := br.u32()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint32(0); i < n; i++ {
+		if _, err = br.u32(); err != nil {
+			return nil, err
+		}
+		if _, err = br.byte(); err != nil {
+			return nil, err
+		}
+	}
+	// the locals are copied, and the check is injected at the entry
+	code := ins.appendCheck(append([]byte{}, body[:br.pos]...))
+	for !br.done() {
+		op, err := br.byte()
+		if err != nil {
+			return nil, err
+		}
+		if code, err = ins.rewriteInstr(br, op, code); err != nil {
+			return nil, err
+		}
+		if op == 0x03 {
+			code = ins.appendCheck(code)
+		}
+	}
+	return appendBytes(out, code), nil
+}
+
+// appendCheck appends the instructions counting down the global, and asking the host whether to interrupt when it's zero
+func (ins *instrumenter) appendCheck(code []byte) []byte {
+	g := appendU32(nil, ins.interruptGlobal())
+	code = append(append(code, 0x23), g...) // global.get
+	code = append(code, 0x41, 0x01, 0x6b)   // i32.const 1, i32.sub
+	code = append(append(code, 0x24), g...) // global.set
+	code = append(append(code, 0x23), g...) // global.get
+	code = append(code, 0x41, 0x00, 0x4c)   // i32.const 0, i32.le_s
+	code = append(code, 0x04, 0x40)         // if
+	code = append(appendS32(append(code, 0x41), interruptCheckInterval), 0x24)
+	code = append(code, g...)                                 // i32.const interval, global.set
+	code = appendU32(append(code, 0x10), ins.interruptFunc()) // call
+	return append(code, 0x04, 0x40, 0x00, 0x0b, 0x0b)         // if unreachable end end
+}
+
+// rewriteInstr copies the immediates of the instruction, and remaps the function indices
+func (ins *instrumenter) rewriteInstr(r *reader, op byte, out []byte) ([]byte, error) {
+	out = append(out, op)
+	var err error
+	var imm []byte
+	switch {
+	case op == 0x10 || op == 0x12 || op == 0xd2:
+		// call, return_call, ref.func
+		var idx uint32
+		if idx, err = r.u32(); err != nil {
+			return nil, err
+		}
+		return appendU32(out, ins.remapFunc(idx)), nil
+	case op == 0x02 || op == 0x03 || op == 0x04:
+		imm, err = r.blockType()
+	case op == 0x0c || op == 0x0d || (op >= 0x20 && op <= 0x26) || op == 0x3f || op == 0x40:
+		imm, err = r.leb(1)
+	case op == 0x0e:
+		imm, err = r.vecLeb(1)
+		if err == nil {
+			var def []byte
+			def, err = r.leb(1)
+			imm = append(imm, def...)
+		}
+	case op == 0x11 || op == 0x13:
+		imm, err = r.leb(2)
+	case op == 0x1c:
+		imm, err = r.vecBytes()
+	case op >= 0x28 && op <= 0x3e:
+		imm, err = r.leb(2)
+	case op == 0x41 || op == 0x42:
+		imm, err = r.leb(1)
+	case op == 0x43:
+		imm, err = r.take(4)
+	case op == 0x44:
+		imm, err = r.take(8)
+	case op == 0xd0:
+		imm, err = r.take(1)
+	case op == 0xfc:
+		imm, err = r.prefixed(miscImmediates)
+	case op == 0xfd:
+		imm, err = r.prefixed(simdImmediates)
+	case op == 0xfe:
+		imm, err = r.prefixed(atomicImmediates)
+	case op == 0x06 || op == 0x07 || op == 0x08 || op == 0x09 || op == 0x18 || op == 0x19:
+		return nil, fmt.Errorf("%w: unsupported instruction 0x%x", errInvalidModule, op)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(out, imm...), nil
+}
+
+// the immediates of the prefixed instructions, returns the count of LEB128 immediates and the count of following bytes
+func miscImmediates(sub uint32) (int, int) {
+	switch {
+	case sub <= 7:
+		return 0, 0
+	case sub == 8:
+		return 1, 1
+	case sub == 10:
+		return 0, 2
+	case sub == 11:
+		return 0, 1
+	case sub == 12 || sub == 14:
+		return 2, 0
+	}
+	return 1, 0
+}
+
+func simdImmediates(sub uint32) (int, int) {
+	switch {
+	case sub <= 11 || sub == 92 || sub == 93:
+		return 2, 0
+	case sub == 12 || sub == 13:
+		return 0, 16
+	case sub >= 21 && sub <= 34:
+		return 0, 1
+	case sub >= 84 && sub <= 91:
+		return 2, 1
+	}
+	return 0, 0
+}
+
+func atomicImmediates(sub uint32) (int, int) {
+	if sub == 0x03 {
+		return 0, 1
+	}
+	return 2, 0
+}
+
+type reader struct {
+	b   []byte
+	pos int
+	// lastImport is the import copied by importDesc
+	lastImport []byte
+}
+
+func newReader(b []byte) *reader {
+	return &reader{b: b}
+}
+
+func (r *reader) done() bool {
+	return r.pos >= len(r.b)
+}
+
+func (r *reader) rest() []byte {
+	return r.b[r.pos:]
+}
+
+func (r *reader) byte() (byte, error) {
+	if r.done() {
+		return 0, fmt.Errorf("%w: unexpected end", errInvalidModule)
+	}
+	b := r.b[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *reader) take(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.b) {
+		return nil, fmt.Errorf("%w: unexpected end", errInvalidModule)
+	}
+	b := r.b[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *reader) u32() (uint32, error) {
+	var v uint32
+	for shift := uint(0); shift < 35; shift += 7 {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		v |= uint32(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: invalid LEB128", errInvalidModule)
+}
+
+// leb returns the raw bytes of n LEB128 numbers
+func (r *reader) leb(n int) ([]byte, error) {
+	start := r.pos
+	for i := 0; i < n; i++ {
+		for {
+			b, err := r.byte()
+			if err != nil {
+				return nil, err
+			}
+			if b&0x80 == 0 {
+				break
+			}
+			if r.pos-start > 10*n {
+				return nil, fmt.Errorf("%w: invalid LEB128", errInvalidModule)
+			}
+		}
+	}
+	return r.b[start:r.pos], nil
+}
+
+func (r *reader) bytes() ([]byte, error) {
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	return r.take(int(n))
+}
+
+// vecLeb returns the raw bytes of a vector of LEB128 numbers, each item has n numbers
+func (r *reader) vecLeb(n int) ([]byte, error) {
+	start := r.pos
+	count, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint32(0); i < count; i++ {
+		if _, err := r.leb(n); err != nil {
+			return nil, err
+		}
+	}
+	return r.b[start:r.pos], nil
+}
+
+// vecBytes returns the raw bytes of a vector of bytes
+func (r *reader) vecBytes() ([]byte, error) {
+	start := r.pos
+	if _, err := r.bytes(); err != nil {
+		return nil, err
+	}
+	return r.b[start:r.pos], nil
+}
+
+func (r *reader) blockType() ([]byte, error) {
+	if r.done() {
+		return nil, fmt.Errorf("%w: unexpected end", errInvalidModule)
+	}
+	// the empty type and the value types are a single byte, otherwise it's a type index in signed LEB128
+	if b := r.b[r.pos]; b == 0x40 || (b >= 0x6f && b <= 0x7f) {
+		return r.take(1)
+	}
+	return r.leb(1)
+}
+
+// prefixed returns the raw bytes of the sub opcode and the immediates of a prefixed instruction
+func (r *reader) prefixed(immediates func(sub uint32) (int, int)) ([]byte, error) {
+	start := r.pos
+	sub, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	lebs, bytes := immediates(sub)
+	if _, err = r.leb(lebs); err != nil {
+		return nil, err
+	}
+	if _, err = r.take(bytes); err != nil {
+		return nil, err
+	}
+	return r.b[start:r.pos], nil
+}
+
+// importDesc reads an import, and keeps its raw bytes in lastImport.
+// The limits of the imported memory are rewritten by memory if it's set.
+func (r *reader) importDesc(memory func(kind byte, out []byte, r *reader) ([]byte, error)) (byte, error) {
+	start := r.pos
+	if _, err := r.bytes(); err != nil {
+		return 0, err
+	}
+	if _, err := r.bytes(); err != nil {
+		return 0, err
+	}
+	kind, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+	switch kind {
+	case 0x00:
+		_, err = r.u32()
+	case 0x01:
+		if _, err = r.byte(); err == nil {
+			err = r.skipLimits()
+		}
+	case 0x02:
+		if memory != nil {
+			prefix := append([]byte{}, r.b[start:r.pos]...)
+			out, err := memory(kind, prefix, r)
+			if err != nil {
+				return 0, err
+			}
+			r.lastImport = out
+			return kind, nil
+		}
+		err = r.skipLimits()
+	case 0x03:
+		_, err = r.take(2)
+	case 0x04:
+		if _, err = r.byte(); err == nil {
+			_, err = r.u32()
+		}
+	default:
+		err = fmt.Errorf("%w: unknown import kind %d", errInvalidModule, kind)
+	}
+	if err != nil {
+		return 0, err
+	}
+	r.lastImport = r.b[start:r.pos]
+	return kind, nil
+}
+
+func (r *reader) skipLimits() error {
+	flag, err := r.byte()
+	if err != nil {
+		return err
+	}
+	if _, err = r.u32(); err != nil {
+		return err
+	}
+	if flag&0x01 != 0 {
+		_, err = r.u32()
+	}
+	return err
+}
+
+func appendU32(b []byte, v uint32) []byte {
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
+
+func appendS32(b []byte, v int32) []byte {
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && c&0x40 == 0) || (v == -1 && c&0x40 != 0) {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
+
+func appendBytes(b []byte, v []byte) []byte {
+	return append(appendU32(b, uint32(len(v))), v...)
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wasm
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testModule builds a module with the sections
+func testModule(sections ...[]byte) []byte {
+	module := []byte("\x00asm\x01\x00\x00\x00")
+	for _, s := range sections {
+		module = append(module, s...)
+	}
+	return module
+}
+
+func testSection(id byte, items ...[]byte) []byte {
+	content := appendU32(nil, uint32(len(items)))
+	for _, item := range items {
+		content = append(content, item...)
+	}
+	return append(appendU32([]byte{id}, uint32(len(content))), content...)
+}
+
+func testBody(code ...byte) []byte {
+	// no locals
+	return appendBytes(nil, append([]byte{0x00}, code...))
+}
+
+// loopModule imports env.host, and exports the memory and the functions:
+// spin loops forever, run calls spin, grow grows the memory, indirect calls spin by the table
+func loopModule(memoryLimits ...byte) []byte {
+	return testModule(
+		testSection(sectionType, []byte{0x60, 0x00, 0x00}, []byte{0x60, 0x01, 0x7f, 0x01, 0x7f}),
+		testSection(sectionImport, append(appendBytes(appendBytes(nil, []byte("env")), []byte("host")), 0x00, 0x00)),
+		testSection(sectionFunction, []byte{0x00}, []byte{0x00}, []byte{0x01}, []byte{0x00}),
+		testSection(sectionTable, []byte{0x70, 0x00, 0x01}),
+		testSection(sectionMemory, memoryLimits),
+		testSection(sectionExport,
+			append(appendBytes(nil, []byte("memory")), 0x02, 0x00),
+			append(appendBytes(nil, []byte("spin")), 0x00, 0x01),
+			append(appendBytes(nil, []byte("run")), 0x00, 0x02),
+			append(appendBytes(nil, []byte("grow")), 0x00, 0x03),
+			append(appendBytes(nil, []byte("indirect")), 0x00, 0x04)),
+		testSection(sectionElement, []byte{0x00, 0x41, 0x00, 0x0b, 0x01, 0x01}),
+		testSection(sectionCode,
+			testBody(0x03, 0x40, 0x0c, 0x00, 0x0b, 0x0b),
+			testBody(0x10, 0x01, 0x0b),
+			testBody(0x20, 0x00, 0x40, 0x00, 0x0b),
+			testBody(0x41, 0x00, 0x11, 0x00, 0x00, 0x0b)),
+		// name section
+		append([]byte{sectionCustom, 0x05}, appendBytes(nil, []byte("name"))...),
+	)
+}
+
+func TestInstrumentModule_memory(t *testing.T) {
+	out, err := instrumentModule(loopModule(0x00, 0x01), 16, false)
+	assert.Nil(t, err)
+	// only the memory section is changed, from (memory 1) to (memory 1 16)
+	expected := bytes.Replace(loopModule(0x00, 0x01), testSection(sectionMemory, []byte{0x00, 0x01}),
+		testSection(sectionMemory, []byte{0x01, 0x01, 0x10}), 1)
+	assert.Equal(t, expected, out)
+
+	// the smaller max is kept
+	out, err = instrumentModule(loopModule(0x01, 0x01, 0x02), 16, false)
+	assert.Nil(t, err)
+	assert.Equal(t, loopModule(0x01, 0x01, 0x02), out)
+
+	// the module requires more memory than the max
+	_, err = instrumentModule(loopModule(0x00, 0x20), 16, false)
+	assert.NotNil(t, err)
+
+	out, err = instrumentModule(loopModule(0x00, 0x01), 0, false)
+	assert.Nil(t, err)
+	assert.Equal(t, loopModule(0x00, 0x01), out)
+}
+
+func TestInstrumentModule_interrupt(t *testing.T) {
+	out, err := instrumentModule(loopModule(0x00, 0x01), 0, true)
+	assert.Nil(t, err)
+	sections, err := readSections(out[8:])
+	assert.Nil(t, err)
+
+	ins := &instrumenter{interrupt: true, types: 2, importedFuncs: 1}
+	content := map[byte][]byte{}
+	for _, s := range sections {
+		// the name section is dropped
+		assert.NotEqual(t, byte(sectionCustom), s.id)
+		content[s.id] = s.content
+	}
+	// the type () -> i32 is appended
+	assert.Equal(t, []byte{0x03, 0x60, 0x00, 0x00, 0x60, 0x01, 0x7f, 0x01, 0x7f, 0x60, 0x00, 0x01, 0x7f}, content[sectionType])
+	// the import of the host function is appended with the new type
+	imports := newReader(content[sectionImport])
+	n, _ := imports.u32()
+	assert.Equal(t, uint32(2), n)
+	_, _ = imports.importDesc(nil)
+	kind, err := imports.importDesc(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, byte(0x00), kind)
+	assert.Equal(t, append(appendBytes(appendBytes(nil, []byte(interruptNamespace)), []byte(interruptFuncName)), 0x00, 0x02), imports.lastImport)
+	// the global section is created for the countdown
+	assert.Equal(t, append([]byte{0x01, 0x7f, 0x01, 0x41}, append(appendS32(nil, interruptCheckInterval), 0x0b)...), content[sectionGlobal])
+	// the function indices are shifted by the import
+	assert.Equal(t, []byte{0x05,
+		0x06, 'm', 'e', 'm', 'o', 'r', 'y', 0x02, 0x00,
+		0x04, 's', 'p', 'i', 'n', 0x00, 0x02,
+		0x03, 'r', 'u', 'n', 0x00, 0x03,
+		0x04, 'g', 'r', 'o', 'w', 0x00, 0x04,
+		0x08, 'i', 'n', 'd', 'i', 'r', 'e', 'c', 't', 0x00, 0x05}, content[sectionExport])
+	assert.Equal(t, []byte{0x01, 0x00, 0x41, 0x00, 0x0b, 0x01, 0x02}, content[sectionElement])
+
+	// the check is injected at the function entries and the loop headers
+	check := ins.appendCheck(nil)
+	code := newReader(content[sectionCode])
+	n, _ = code.u32()
+	assert.Equal(t, uint32(4), n)
+	body, _ := code.bytes()
+	assert.Equal(t, append(append(append([]byte{0x00}, check...), 0x03, 0x40), append(check, 0x0c, 0x00, 0x0b, 0x0b)...), body)
+	body, _ = code.bytes()
+	assert.Equal(t, append(append([]byte{0x00}, check...), 0x10, 0x02, 0x0b), body)
+}
+
+func TestInstrumentModule_invalid(t *testing.T) {
+	_, err := instrumentModule([]byte("not a module"), 16, true)
+	assert.True(t, errors.Is(err, errInvalidModule))
+
+	// truncated
+	module := loopModule(0x00, 0x01)
+	_, err = instrumentModule(module[:len(module)-10], 16, true)
+	assert.True(t, errors.Is(err, errInvalidModule))
+
+	// unsupported instruction
+	_, err = instrumentModule(testModule(
+		testSection(sectionType, []byte{0x60, 0x00, 0x00}),
+		testSection(sectionFunction, []byte{0x00}),
+		testSection(sectionCode, testBody(0x06, 0x40, 0x0b, 0x0b)),
+	), 0, true)
+	assert.True(t, errors.Is(err, errInvalidModule))
+}
+
+func TestInstrumentModule_noSections(t *testing.T) {
+	// the type, import and global sections are created in order
+	out, err := instrumentModule(testModule(
+		testSection(sectionFunction, []byte{0x00}),
+		testSection(sectionCode, testBody(0x0b)),
+	), 0, true)
+	assert.Nil(t, err)
+	sections, err := readSections(out[8:])
+	assert.Nil(t, err)
+	var ids []byte
+	for _, s := range sections {
+		ids = append(ids, s.id)
+	}
+	assert.Equal(t, []byte{sectionType, sectionImport, sectionFunction, sectionGlobal, sectionCode}, ids)
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wasm
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// ListPlugins returns the inventory and the metrics of the installed plugins
+func (f *FilterConfigFactory) ListPlugins() []map[string]interface{} {
+	// map[pluginName]route id
+	ids := make(map[string]string)
+	for id, group := range f.router.load() {
+		for _, plugin := range group.plugins {
+			ids[plugin.pluginName] = id
+		}
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	result := make([]map[string]interface{}, 0, len(f.plugins))
+	for _, plugin := range f.plugins {
+		result = append(result, pluginInfo(plugin, ids[plugin.pluginName]))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i]["name"].(string) < result[j]["name"].(string)
+	})
+	return result
+}
+
+// RouteStatus returns the route config and the plugins of the route id
+func (f *FilterConfigFactory) RouteStatus(id string) (map[string]interface{}, error) {
+	group, ok := f.router.load()[id]
+	if !ok {
+		return nil, errors.New(id + " is not registered")
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	plugins := make([]map[string]interface{}, 0, len(group.plugins))
+	for i, plugin := range group.plugins {
+		info := pluginInfo(plugin, id)
+		info["weight"] = group.weights[i]
+		plugins = append(plugins, info)
+	}
+	status := map[string]interface{}{
+		"id":      id,
+		"plugins": plugins,
+	}
+	if group.config != nil {
+		status["route"] = group.config
+	}
+	return status, nil
+}
+
+// pluginInfo describes the plugin
+func pluginInfo(plugin *WasmPlugin, id string) map[string]interface{} {
+	info := map[string]interface{}{
+		"name":        plugin.name(),
+		"plugin_name": plugin.pluginName,
+		"route_id":    id,
+		"stats":       GetPluginStats(plugin.pluginName).Snapshot(),
+	}
+	if plugin.plugin != nil {
+		info["instance_num"] = plugin.plugin.InstanceNum()
+	}
+	if config := plugin.config; config != nil {
+		if config.VmConfig != nil {
+			info["path"] = config.VmConfig.Path
+		}
+		info["digest"] = config.Digest
+		if !config.InstallTime.IsZero() {
+			info["install_time"] = config.InstallTime.Format(time.RFC3339)
+		}
+	}
+	return info
+}
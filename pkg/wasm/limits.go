@@ -0,0 +1,217 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wasm
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	mosnhttp "mosn.io/mosn/pkg/protocol/http"
+	"mosn.io/pkg/buffer"
+	"mosn.io/pkg/utils"
+	"mosn.io/proxy-wasm-go-host/proxywasm/common"
+
+	"mosn.io/layotto/pkg/actuator/health"
+	"mosn.io/layotto/pkg/wasm/fetch"
+)
+
+var (
+	ErrExecutionTimeout    = errors.New("wasm plugin exceeds the max execution time")
+	ErrMemoryLimitExceeded = errors.New("wasm plugin exceeds the max memory")
+)
+
+// the statuses of the requests failed by the limits
+var limitStatusCodes = map[error]int{
+	ErrExecutionTimeout:    http.StatusGatewayTimeout,
+	ErrMemoryLimitExceeded: http.StatusServiceUnavailable,
+}
+
+func init() {
+	health.AddReadinessIndicator("wasm", health.IndicatorAdapter(factory.reportViolations))
+	health.AddLivenessIndicator("wasm", health.IndicatorAdapter(factory.reportViolations))
+}
+
+// reportViolations reports the limit violations of the plugins, the plugins are recycled so the status is always UP
+func (f *FilterConfigFactory) reportViolations() (string, map[string]interface{}) {
+	details := health.NewDetails()
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for _, plugin := range f.plugins {
+		details[plugin.name()] = GetPluginStats(plugin.pluginName).Violations()
+	}
+	return health.UP, details
+}
+
+// limits returns the max execution time and the max memory pages of the plugin, zero means unlimited
+func (p *WasmPlugin) limits() (time.Duration, int64) {
+	if p.config == nil {
+		return 0, 0
+	}
+	return time.Duration(p.config.MaxExecutionTime) * time.Millisecond, p.config.MaxMemoryPages
+}
+
+// maxFileSize returns the max size of the file read by the plugin, zero means defaultMaxFileSize
+func (p *WasmPlugin) maxFileSize() int64 {
+	if p.config == nil {
+		return 0
+	}
+	return p.config.MaxFileSize
+}
+
+// map[common.WasmInstance]*deadline, the deadlines of the instances handling the requests
+var deadlines sync.Map
+
+type deadline struct {
+	at          time.Time
+	interrupted int32
+}
+
+// setDeadline interrupts the calls into the instance after timeout, until the returned function is called.
+// The returned function reports whether the instance has been interrupted.
+func setDeadline(instance common.WasmInstance, timeout time.Duration) func() bool {
+	if timeout <= 0 {
+		return func() bool { return false }
+	}
+	d := &deadline{at: time.Now().Add(timeout)}
+	deadlines.Store(instance, d)
+	return func() bool {
+		deadlines.Delete(instance)
+		return atomic.LoadInt32(&d.interrupted) == 1
+	}
+}
+
+// LayottoCheckInterrupt is called by the instrumented module periodically, it returns 1 if the deadline has passed,
+// then the module traps. A host function blocking the instance can't be interrupted until it returns.
+func LayottoCheckInterrupt(instance common.WasmInstance) int32 {
+	v, ok := deadlines.Load(instance)
+	if !ok {
+		return 0
+	}
+	d := v.(*deadline)
+	if time.Now().Before(d.at) {
+		return 0
+	}
+	atomic.StoreInt32(&d.interrupted, 1)
+	return 1
+}
+
+// limitedVmConfig returns the vm config running the module compiled with the limits of config.
+// The compiled module is cached by its digest, next to the fetched modules.
+func (config *filterConfigItem) limitedVmConfig() (*v2.WasmVmConfig, error) {
+	if config.MaxExecutionTime <= 0 && config.MaxMemoryPages <= 0 {
+		return config.VmConfig, nil
+	}
+	if config.VmConfig.Path == "" {
+		return nil, errors.New("limits require the path of the wasm module")
+	}
+	module, err := ioutil.ReadFile(config.VmConfig.Path)
+	if err != nil {
+		return nil, err
+	}
+	if config.VmConfig.Md5 != "" {
+		if sum := md5.Sum(module); hex.EncodeToString(sum[:]) != config.VmConfig.Md5 {
+			return nil, fmt.Errorf("md5 mismatch of %s", config.VmConfig.Path)
+		}
+	}
+	module, err = instrumentModule(module, uint32(config.MaxMemoryPages), config.MaxExecutionTime > 0)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(module)
+	dir := filepath.Join(fetch.Default().Dir, "limited")
+	path := filepath.Join(dir, hex.EncodeToString(sum[:])+".wasm")
+	if _, err = os.Stat(path); err != nil {
+		if err = os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+		tmp, err := ioutil.TempFile(dir, ".limit-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(tmp.Name())
+		_, err = tmp.Write(module)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err = os.Rename(tmp.Name(), path); err != nil {
+			return nil, err
+		}
+	}
+	vmConfig := *config.VmConfig
+	vmConfig.Path = path
+	vmConfig.Md5 = ""
+	return &vmConfig, nil
+}
+
+// map[pluginName]struct{}, the plugins being recycled
+var recycling sync.Map
+
+// recycle replaces the instances of the plugin in background
+func (f *FilterConfigFactory) recycle(plugin *WasmPlugin) {
+	if plugin.config == nil {
+		return
+	}
+	if _, loaded := recycling.LoadOrStore(plugin.pluginName, struct{}{}); loaded {
+		return
+	}
+	utils.GoWithRecover(func() {
+		defer recycling.Delete(plugin.pluginName)
+		if err := f.reload(plugin.config); err != nil {
+			log.DefaultLogger.Errorf("[proxywasm][limits] fail to recycle plugin %s, err: %v", plugin.name(), err)
+			return
+		}
+		log.DefaultLogger.Infof("[proxywasm][limits] recycle plugin %s success", plugin.name())
+	}, nil)
+}
+
+// onLimitExceeded fails the request and recycles the plugin
+func (f *Filter) onLimitExceeded(plugin *WasmPlugin, err error) {
+	log.DefaultLogger.Errorf("[proxywasm][limits] plugin %s fail to handle the request, err: %v", plugin.name(), err)
+	GetPluginStats(plugin.pluginName).RecordViolation(err)
+	// the instance traps in the middle of the call, so it's dropped instead of being released
+	f.recycled = true
+	f.factory.recycle(plugin)
+
+	if f.receiverFilterHandler == nil {
+		return
+	}
+	body, _ := json.Marshal(map[string]interface{}{"error": err.Error()})
+	header := mosnhttp.ResponseHeader{
+		ResponseHeader: &fasthttp.ResponseHeader{},
+	}
+	header.Set("Content-Type", "application/json")
+	header.SetStatusCode(limitStatusCodes[err])
+	f.receiverFilterHandler.SendDirectResponse(header, buffer.NewIoBufferBytes(body), nil)
+}
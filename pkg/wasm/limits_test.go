@@ -0,0 +1,130 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wasm
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/proxy-wasm-go-host/proxywasm/common"
+)
+
+func TestPluginLimits(t *testing.T) {
+	plugin := newTestPlugin("v1")
+	maxExecutionTime, maxMemoryPages := plugin.limits()
+	assert.Equal(t, time.Duration(0), maxExecutionTime)
+	assert.Equal(t, int64(0), maxMemoryPages)
+
+	plugin.config.MaxExecutionTime = 100
+	plugin.config.MaxMemoryPages = 16
+	maxExecutionTime, maxMemoryPages = plugin.limits()
+	assert.Equal(t, 100*time.Millisecond, maxExecutionTime)
+	assert.Equal(t, int64(16), maxMemoryPages)
+
+	_, maxMemoryPages = (&WasmPlugin{}).limits()
+	assert.Equal(t, int64(0), maxMemoryPages)
+}
+
+// testInstance is only used as the key of the deadline
+type testInstance struct {
+	common.WasmInstance
+}
+
+func TestCheckInterrupt(t *testing.T) {
+	instance := &testInstance{}
+	assert.Equal(t, int32(0), LayottoCheckInterrupt(instance))
+
+	interrupted := setDeadline(instance, time.Minute)
+	assert.Equal(t, int32(0), LayottoCheckInterrupt(instance))
+	assert.False(t, interrupted())
+
+	interrupted = setDeadline(instance, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	assert.Equal(t, int32(1), LayottoCheckInterrupt(instance))
+	assert.True(t, interrupted())
+	// the deadline is removed
+	assert.Equal(t, int32(0), LayottoCheckInterrupt(instance))
+
+	assert.False(t, setDeadline(instance, 0)())
+}
+
+func TestLimitedVmConfig(t *testing.T) {
+	module := loopModule(0x00, 0x01)
+	dir, err := ioutil.TempDir("", "wasm")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "plugin.wasm")
+	assert.Nil(t, ioutil.WriteFile(path, module, 0644))
+
+	config := &filterConfigItem{VmConfig: &v2.WasmVmConfig{Engine: "wasmer", Path: path}}
+	vmConfig, err := config.limitedVmConfig()
+	assert.Nil(t, err)
+	assert.Equal(t, config.VmConfig, vmConfig)
+
+	config.MaxExecutionTime = 100
+	config.MaxMemoryPages = 16
+	vmConfig, err = config.limitedVmConfig()
+	assert.Nil(t, err)
+	defer os.Remove(vmConfig.Path)
+	assert.Equal(t, path, config.VmConfig.Path)
+	assert.NotEqual(t, path, vmConfig.Path)
+	limited, err := ioutil.ReadFile(vmConfig.Path)
+	assert.Nil(t, err)
+	expected, _ := instrumentModule(module, 16, true)
+	assert.Equal(t, expected, limited)
+
+	config.VmConfig.Md5 = "0123"
+	_, err = config.limitedVmConfig()
+	assert.NotNil(t, err)
+
+	config.VmConfig = &v2.WasmVmConfig{Engine: "wasmer", Url: "http://example.com/plugin.wasm"}
+	_, err = config.limitedVmConfig()
+	assert.NotNil(t, err)
+}
+
+func TestRecordViolation(t *testing.T) {
+	s := newPluginStats()
+	assert.Equal(t, map[string]interface{}{"timeouts": int64(0), "memory_exceeded": int64(0)}, s.Violations())
+
+	s.RecordViolation(ErrExecutionTimeout)
+	s.RecordViolation(ErrExecutionTimeout)
+	s.RecordViolation(ErrMemoryLimitExceeded)
+	s.RecordViolation(errors.New("other"))
+	violations := s.Violations()
+	assert.Equal(t, int64(2), violations["timeouts"])
+	assert.Equal(t, int64(1), violations["memory_exceeded"])
+	assert.Equal(t, "other", violations["last"])
+	assert.NotEmpty(t, violations["last_time"])
+	assert.Equal(t, violations, s.Snapshot()["violations"])
+}
+
+func TestReportViolations(t *testing.T) {
+	v1 := newTestPlugin("v1")
+	f := &FilterConfigFactory{plugins: map[string]*WasmPlugin{v1.pluginName: v1}}
+	GetPluginStats(v1.pluginName).RecordViolation(ErrMemoryLimitExceeded)
+	defer removePluginStats(v1.pluginName)
+
+	status, details := f.reportViolations()
+	assert.Equal(t, "UP", status)
+	assert.Equal(t, int64(1), details["v1"].(map[string]interface{})["memory_exceeded"])
+}
@@ -0,0 +1,159 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wasm
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+// wasmPageSize is the size of a page of the WASM linear memory
+const wasmPageSize = 64 << 10
+
+// latencyBuckets are the upper bounds of the latency histogram in milliseconds
+var latencyBuckets = []int64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 5000}
+
+// PluginStats collects the invocation metrics of a plugin, it's safe for concurrent use
+type PluginStats struct {
+	invocations int64
+	errors      int64
+	// latencySum is in microseconds
+	latencySum  int64
+	memoryPages int64
+	// counts[i] is the count of latencies <= latencyBuckets[i], the last one is +Inf
+	counts []int64
+
+	timeouts        int64
+	memoryExceeded  int64
+	lastViolation   atomic.Value // string
+	lastViolationAt int64        // unix nano
+}
+
+func newPluginStats() *PluginStats {
+	return &PluginStats{counts: make([]int64, len(latencyBuckets)+1)}
+}
+
+// Record an invocation of the plugin, memoryPages is ignored if negative
+func (s *PluginStats) Record(latency time.Duration, failed bool, memoryPages int64) {
+	atomic.AddInt64(&s.invocations, 1)
+	if failed {
+		atomic.AddInt64(&s.errors, 1)
+	}
+	atomic.AddInt64(&s.latencySum, int64(latency/time.Microsecond))
+	ms := int64(latency / time.Millisecond)
+	i := 0
+	for i < len(latencyBuckets) && ms > latencyBuckets[i] {
+		i++
+	}
+	atomic.AddInt64(&s.counts[i], 1)
+	if memoryPages >= 0 {
+		atomic.StoreInt64(&s.memoryPages, memoryPages)
+	}
+}
+
+// RecordViolation records that the plugin exceeds its limit
+func (s *PluginStats) RecordViolation(err error) {
+	switch err {
+	case ErrExecutionTimeout:
+		atomic.AddInt64(&s.timeouts, 1)
+	case ErrMemoryLimitExceeded:
+		atomic.AddInt64(&s.memoryExceeded, 1)
+	}
+	s.lastViolation.Store(err.Error())
+	atomic.StoreInt64(&s.lastViolationAt, time.Now().UnixNano())
+}
+
+// Violations returns the count of violations and the last one in a json friendly form
+func (s *PluginStats) Violations() map[string]interface{} {
+	violations := map[string]interface{}{
+		"timeouts":        atomic.LoadInt64(&s.timeouts),
+		"memory_exceeded": atomic.LoadInt64(&s.memoryExceeded),
+	}
+	if at := atomic.LoadInt64(&s.lastViolationAt); at > 0 {
+		violations["last"] = s.lastViolation.Load()
+		violations["last_time"] = time.Unix(0, at).Format(time.RFC3339)
+	}
+	return violations
+}
+
+// Snapshot returns the metrics in a json friendly form
+func (s *PluginStats) Snapshot() map[string]interface{} {
+	buckets := make(map[string]int64, len(s.counts))
+	var cumulative int64
+	for i := range s.counts {
+		cumulative += atomic.LoadInt64(&s.counts[i])
+		le := "+Inf"
+		if i < len(latencyBuckets) {
+			le = strconv.FormatInt(latencyBuckets[i], 10)
+		}
+		buckets[le] = cumulative
+	}
+	return map[string]interface{}{
+		"invocations":  atomic.LoadInt64(&s.invocations),
+		"errors":       atomic.LoadInt64(&s.errors),
+		"memory_pages": atomic.LoadInt64(&s.memoryPages),
+		"violations":   s.Violations(),
+		"latency_ms": map[string]interface{}{
+			"buckets": buckets,
+			"sum":     float64(atomic.LoadInt64(&s.latencySum)) / 1000,
+		},
+	}
+}
+
+var (
+	statsLock sync.RWMutex
+	// map[pluginName]*PluginStats
+	pluginStats = make(map[string]*PluginStats)
+)
+
+// GetPluginStats returns the stats of the plugin, creates it if not exists
+func GetPluginStats(pluginName string) *PluginStats {
+	statsLock.RLock()
+	s, ok := pluginStats[pluginName]
+	statsLock.RUnlock()
+	if ok {
+		return s
+	}
+
+	statsLock.Lock()
+	defer statsLock.Unlock()
+	if s, ok = pluginStats[pluginName]; !ok {
+		s = newPluginStats()
+		pluginStats[pluginName] = s
+	}
+	return s
+}
+
+// removePluginStats drops the stats of the uninstalled plugin
+func removePluginStats(pluginName string) {
+	statsLock.Lock()
+	delete(pluginStats, pluginName)
+	statsLock.Unlock()
+}
+
+// memoryPages returns the size of the linear memory of the instance in pages, -1 if unknown
+func memoryPages(instance types.WasmInstance) int64 {
+	mem, err := instance.GetExportsMem("memory")
+	if err != nil {
+		return -1
+	}
+	return int64(len(mem) / wasmPageSize)
+}
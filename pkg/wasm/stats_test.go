@@ -0,0 +1,72 @@
+// Copyright 2021 Layotto Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package wasm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPluginStats(t *testing.T) {
+	s := newPluginStats()
+	s.Record(500*time.Microsecond, false, 2)
+	s.Record(30*time.Millisecond, true, -1)
+	s.Record(10*time.Second, false, 3)
+
+	snapshot := s.Snapshot()
+	assert.Equal(t, int64(3), snapshot["invocations"])
+	assert.Equal(t, int64(1), snapshot["errors"])
+	assert.Equal(t, int64(3), snapshot["memory_pages"])
+	latency := snapshot["latency_ms"].(map[string]interface{})
+	buckets := latency["buckets"].(map[string]int64)
+	assert.Equal(t, int64(1), buckets["1"])
+	assert.Equal(t, int64(1), buckets["25"])
+	assert.Equal(t, int64(2), buckets["50"])
+	assert.Equal(t, int64(2), buckets["5000"])
+	assert.Equal(t, int64(3), buckets["+Inf"])
+	assert.Equal(t, 10030.5, latency["sum"])
+
+	assert.Equal(t, GetPluginStats("plugin"), GetPluginStats("plugin"))
+	removePluginStats("plugin")
+}
+
+func TestListPlugins(t *testing.T) {
+	v1, v2 := newTestPlugin("v1"), newTestPlugin("v2")
+	v1.config.InstallTime = time.Now()
+	f := &FilterConfigFactory{
+		router:  NewRouter(),
+		plugins: map[string]*WasmPlugin{v1.pluginName: v1, v2.pluginName: v2},
+	}
+	f.router.RegisterRoute("id_1", v1)
+	GetPluginStats(v1.pluginName).Record(time.Millisecond, false, 1)
+	defer removePluginStats(v1.pluginName)
+
+	plugins := f.ListPlugins()
+	assert.Equal(t, 2, len(plugins))
+	assert.Equal(t, "v1", plugins[0]["name"])
+	assert.Equal(t, "id_1", plugins[0]["route_id"])
+	assert.NotEmpty(t, plugins[0]["install_time"])
+	assert.Equal(t, int64(1), plugins[0]["stats"].(map[string]interface{})["invocations"])
+	assert.Equal(t, "", plugins[1]["route_id"])
+
+	_, err := f.RouteStatus("id_2")
+	assert.Error(t, err)
+	assert.Nil(t, f.UpdateRoute("id_1", &RouteConfig{Weights: map[string]int{"v1": 1}}))
+	status, err := f.RouteStatus("id_1")
+	assert.Nil(t, err)
+	assert.Equal(t, "id_1", status["id"])
+	assert.Equal(t, 1, status["plugins"].([]map[string]interface{})[0]["weight"])
+	assert.NotNil(t, status["route"])
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package status
+
+import (
+	"context"
+	"errors"
+
+	"mosn.io/pkg/log"
+
+	"mosn.io/layotto/pkg/filter/stream/common/http"
+	"mosn.io/layotto/pkg/wasm"
+)
+
+func init() {
+	wasm.GetDefault().AddEndpoint("status", NewEndpoint())
+}
+
+type Endpoint struct {
+}
+
+func NewEndpoint() *Endpoint {
+	return &Endpoint{}
+}
+
+// Handle reports the route and the plugins of the id in the path /wasm/status/{id}
+func (e *Endpoint) Handle(ctx context.Context, params http.ParamsScanner) (map[string]interface{}, error) {
+	if params == nil || !params.HasNext() {
+		errorMessage := "can't get id in the path"
+		log.DefaultLogger.Errorf("[wasm][status] %v", errorMessage)
+		return map[string]interface{}{"error": errorMessage}, errors.New(errorMessage)
+	}
+
+	id := params.Next()
+	status, err := wasm.GetFactory().RouteStatus(id)
+	if err != nil {
+		log.DefaultLogger.Errorf("[wasm][status] %v", err)
+		return map[string]interface{}{"error": err.Error()}, err
+	}
+	return status, nil
+}
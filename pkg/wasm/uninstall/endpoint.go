@@ -51,8 +51,10 @@ func (e *Endpoint) Handle(ctx context.Context, params http.ParamsScanner) (map[s
 		return map[string]interface{}{"error": errorMessage}, errors.New(errorMessage)
 	}
 
+	// the version is the name the plugin is installed with, it's required if there are several versions of the id
+	version, _ := conf["version"].(string)
 	factory := wasm.GetFactory()
-	err = factory.UnInstall(conf["name"].(string))
+	err = factory.UnInstall(conf["name"].(string), version)
 	if err != nil {
 		log.DefaultLogger.Errorf("[wasm][uninstall] %v", err)
 		return map[string]interface{}{"error": err.Error()}, err
@@ -18,6 +18,7 @@ package update
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 
 	"mosn.io/layotto/pkg/wasm"
@@ -51,26 +52,52 @@ func (e *Endpoint) Handle(ctx context.Context, params http.ParamsScanner) (map[s
 		return map[string]interface{}{"error": errorMessage}, errors.New(errorMessage)
 	}
 
-	if conf["instance_num"] == nil {
-		errorMessage := "can't get instance_num property"
+	if conf["instance_num"] == nil && conf["route"] == nil {
+		errorMessage := "can't get instance_num or route property"
 		log.DefaultLogger.Errorf("[wasm][update] %v", errorMessage)
 		return map[string]interface{}{"error": errorMessage}, errors.New(errorMessage)
 	}
 
-	instanceNum := int(conf["instance_num"].(float64))
-	if instanceNum <= 0 {
-		errorMessage := "instance_num should be greater than 0"
-		log.DefaultLogger.Errorf("[wasm][update] %v", errorMessage)
-		return map[string]interface{}{"error": errorMessage}, errors.New(errorMessage)
+	var instanceNum int
+	if conf["instance_num"] != nil {
+		instanceNum = int(conf["instance_num"].(float64))
+		if instanceNum <= 0 {
+			errorMessage := "instance_num should be greater than 0"
+			log.DefaultLogger.Errorf("[wasm][update] %v", errorMessage)
+			return map[string]interface{}{"error": errorMessage}, errors.New(errorMessage)
+		}
 	}
 
 	id := (conf["name"]).(string)
-	factory := wasm.GetFactory()
-	err = factory.UpdateInstanceNum(id, instanceNum)
-	if err != nil {
+	// the version is the name the plugin is installed with, it's required if there are several versions of id
+	version, _ := conf["version"].(string)
+	var route *wasm.RouteConfig
+	if conf["route"] != nil {
+		route, err = parseRouteConfig(conf["route"])
+		if err != nil {
+			log.DefaultLogger.Errorf("[wasm][update] invalid route property, err: %v", err)
+			return map[string]interface{}{"error": err.Error()}, err
+		}
+	}
+
+	// the route and the instance number are checked before being applied together
+	if err = wasm.GetFactory().Update(id, version, route, instanceNum); err != nil {
 		log.DefaultLogger.Errorf("[wasm][update] %v", err)
 		return map[string]interface{}{"error": err.Error()}, err
 	}
-	log.DefaultLogger.Infof("[wasm] [update] wasm instance number updated success, id: %v, num: %v", id, instanceNum)
+	log.DefaultLogger.Infof("[wasm] [update] wasm updated success, id: %v, version: %v, route: %v, num: %v", id, version, route != nil, instanceNum)
 	return nil, nil
 }
+
+// parseRouteConfig converts the route property of the request body into wasm.RouteConfig
+func parseRouteConfig(route interface{}) (*wasm.RouteConfig, error) {
+	data, err := json.Marshal(route)
+	if err != nil {
+		return nil, err
+	}
+	config := &wasm.RouteConfig{}
+	if err = json.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
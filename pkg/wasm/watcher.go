@@ -19,9 +19,7 @@ import (
 
 	"mosn.io/pkg/utils"
 
-	v2 "mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/log"
-	"mosn.io/mosn/pkg/wasm"
 
 	"github.com/fsnotify/fsnotify"
 )
@@ -86,6 +84,10 @@ func runWatcher() {
 
 // Add watching file
 func addWatchFile(cfg *filterConfigItem, factory *FilterConfigFactory) {
+	// the fetched modules are immutable in the cache
+	if cfg.Source != nil {
+		return
+	}
 	path := cfg.VmConfig.Path
 	// Add starts watching the named file or directory (non-recursively).
 	if err := watcher.Add(path); err != nil {
@@ -105,6 +107,9 @@ func addWatchFile(cfg *filterConfigItem, factory *FilterConfigFactory) {
 
 // remove watching file
 func removeWatchFile(cfg *filterConfigItem) {
+	if cfg.Source != nil {
+		return
+	}
 	path := cfg.VmConfig.Path
 	// Add starts watching the named file or directory (non-recursively).
 	if err := watcher.Remove(path); err != nil {
@@ -140,45 +145,10 @@ func reloadWasm(fullPath string) {
 		if strings.HasSuffix(fullPath, path) {
 			found = true
 
-			vmConfig := *config.VmConfig
-			vmConfig.Md5 = ""
-			v2Config := v2.WasmPluginConfig{
-				PluginName:  config.PluginName,
-				VmConfig:    &vmConfig,
-				InstanceNum: config.InstanceNum,
-			}
-			err := wasm.GetWasmManager().AddOrUpdateWasm(v2Config)
-			if err != nil {
-				log.DefaultLogger.Errorf("[proxywasm] [watcher] reloadWasm fail to add plugin, err: %v", err)
-				return
-			}
-			// get WasmPluginWrapper
-			pw := wasm.GetWasmManager().GetWasmPluginWrapperByName(config.PluginName)
-			if pw == nil {
-				log.DefaultLogger.Errorf("[proxywasm] [watcher] reloadWasm plugin not found")
+			if err := factories[path].reload(config); err != nil {
+				log.DefaultLogger.Errorf("[proxywasm] [watcher] reloadWasm fail to reload wasm, err: %v", err)
 				return
 			}
-
-			factory := factories[path]
-			config.VmConfig = pw.GetConfig().VmConfig
-			factory.config = append(filter(factory.config, func(item *filterConfigItem) bool {
-				return item.PluginName != config.PluginName
-			}).([]*filterConfigItem), config)
-			wasmPlugin := &WasmPlugin{
-				pluginName:    config.PluginName,
-				plugin:        pw.GetPlugin(),
-				rootContextID: config.RootContextID,
-				config:        config,
-			}
-			factory.plugins[config.PluginName] = wasmPlugin
-			// register plugin
-			pw.RegisterPluginHandler(factory)
-
-			for _, plugin := range factory.plugins {
-				if plugin.pluginName == config.PluginName {
-					plugin.plugin = pw.GetPlugin()
-				}
-			}
 			log.DefaultLogger.Infof("[proxywasm] [watcher] reloadWasm reload wasm success: %s", path)
 		}
 	}
@@ -18,14 +18,17 @@ package client
 
 import (
 	"context"
+	"io"
 	"log"
 	"net"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
 
+	s3 "mosn.io/layotto/spec/proto/extension/v1/s3"
 	runtimev1pb "mosn.io/layotto/spec/proto/runtime/v1"
 )
 
@@ -70,6 +73,9 @@ type runtimeAPI interface {
 	// SubscribeConfiguration gets configuration from configuration store and subscribe the updates.
 	SubscribeConfiguration(ctx context.Context, in *ConfigurationRequestItem) WatchChan
 
+	// WatchConfiguration is like SubscribeConfiguration, but subscribes again with backoff when the subscription is broken.
+	WatchConfiguration(ctx context.Context, in *ConfigurationRequestItem) WatchChan
+
 	// SaveState saves the raw data into store using default state options.
 	SaveState(ctx context.Context, storeName, key string, data []byte, so ...StateOption) error
 
@@ -103,6 +109,8 @@ type runtimeAPI interface {
 	// Distributed Lock API
 	TryLock(context.Context, *runtimev1pb.TryLockRequest) (*runtimev1pb.TryLockResponse, error)
 	Unlock(context.Context, *runtimev1pb.UnlockRequest) (*runtimev1pb.UnlockResponse, error)
+	// NewLock creates a mutex-style lock of the resource, which is renewed while held.
+	NewLock(storeName, resourceId string, opts ...LockOption) *Lock
 
 	// Sequencer API
 	// Get next unique id with some auto-increment guarantee
@@ -112,6 +120,44 @@ type runtimeAPI interface {
 	GetSecret(ctx context.Context, in *runtimev1pb.GetSecretRequest, opts ...grpc.CallOption) (*runtimev1pb.GetSecretResponse, error)
 	GetBulkSecret(ctx context.Context, in *runtimev1pb.GetBulkSecretRequest, opts ...grpc.CallOption) (*runtimev1pb.GetBulkSecretResponse, error)
 
+	// File API
+	// GetFile reads the file and writes its data into w.
+	GetFile(ctx context.Context, req *GetFileRequest, w io.Writer) (int64, error)
+	// PutFile reads the data from r until EOF and writes it into the file.
+	PutFile(ctx context.Context, req *PutFileRequest, r io.Reader) error
+	ListFile(ctx context.Context, req *ListFileRequest) (*ListFileResp, error)
+	DelFile(ctx context.Context, req *FileRequest) error
+	GetFileMeta(ctx context.Context, req *FileRequest) (*FileMeta, error)
+	// InitFileUpload starts a resumable upload session, or resumes the session if UploadId is specified.
+	InitFileUpload(ctx context.Context, req *InitFileUploadRequest) (*FileUploadSession, error)
+	// PutFileChunk reads the data from r until EOF and uploads it as the chunk at req.Offset.
+	PutFileChunk(ctx context.Context, req *PutFileChunkRequest, r io.Reader) error
+	CommitFileUpload(ctx context.Context, req *FileUploadRequest) error
+	AbortFileUpload(ctx context.Context, req *FileUploadRequest) error
+
+	// Object storage helpers, the other methods are provided by s3.ObjectStorageServiceClient
+	// PutObjectFromReader reads the data from r until EOF and uploads it as the object.
+	PutObjectFromReader(ctx context.Context, in *s3.PutObjectInput, r io.Reader) (*s3.PutObjectOutput, error)
+	// GetObjectToWriter downloads the object and writes its data into w.
+	GetObjectToWriter(ctx context.Context, in *s3.GetObjectInput, w io.Writer) (*s3.GetObjectOutput, error)
+	// UploadMultipart uploads the data read from r as the object by a multipart upload.
+	UploadMultipart(ctx context.Context, in *s3.CreateMultipartUploadInput, r io.Reader, partSize int64) (*s3.CompleteMultipartUploadOutput, error)
+
+	// Delay queue API
+	// PublishDelayEvent publishes data onto topic, which is delivered to the subscribers after the delay.
+	PublishDelayEvent(ctx context.Context, componentName, topicName string, data []byte, delay time.Duration) (string, error)
+	// PublishDelayEventfromCustomContent serializes an struct and publishes its contents as data (JSON) onto topic after the delay.
+	PublishDelayEventfromCustomContent(ctx context.Context, componentName, topicName string, data interface{}, delay time.Duration) (string, error)
+
+	// Cryption API
+	EncryptData(ctx context.Context, componentName, keyId string, plainText []byte) ([]byte, error)
+	DecryptData(ctx context.Context, componentName string, cipherText []byte) ([]byte, error)
+
+	// Email and phone API
+	SendTextEmail(ctx context.Context, componentName string, e *Email) (string, error)
+	SendTemplateEmail(ctx context.Context, componentName string, e *Email) (string, error)
+	SendTemplateVoice(ctx context.Context, componentName string, v *Voice) (string, error)
+
 	// Close cleans up all resources created by the client.
 	Close()
 }
@@ -121,8 +167,9 @@ type runtimeAPI interface {
 // will return the already created instance. To create multiple instances of the runtime client,
 // use one of the parameterized factory functions:
 //
-// NewClientWithPort(port string) (client Client, err error)
-// NewClientWithAddress(address string) (client Client, err error)
+// NewClientWithPort(port string, opts ...ClientOption) (client Client, err error)
+// NewClientWithAddress(address string, opts ...ClientOption) (client Client, err error)
+// NewClientWithUnixSocket(path string, opts ...ClientOption) (client Client, err error)
 // NewClientWithConnection(conn *grpc.ClientConn) Client
 func NewClient() (client Client, err error) {
 	port := os.Getenv(runtimePortEnvVarName)
@@ -140,20 +187,33 @@ func NewClient() (client Client, err error) {
 }
 
 // NewClientWithPort instantiates runtime using specific port.
-func NewClientWithPort(port string) (client Client, err error) {
+func NewClientWithPort(port string, opts ...ClientOption) (client Client, err error) {
 	if port == "" {
 		return nil, errors.New("nil port")
 	}
-	return NewClientWithAddress(net.JoinHostPort("127.0.0.1", port))
+	return NewClientWithAddress(net.JoinHostPort("127.0.0.1", port), opts...)
+}
+
+// NewClientWithUnixSocket instantiates runtime using the unix domain socket Layotto listens on.
+func NewClientWithUnixSocket(path string, opts ...ClientOption) (client Client, err error) {
+	if path == "" {
+		return nil, errors.New("nil path")
+	}
+	return NewClientWithAddress("unix:"+path, opts...)
 }
 
 // NewClientWithAddress instantiates runtime using specific address (including port).
-func NewClientWithAddress(address string) (client Client, err error) {
+// The address can also be a unix domain socket like "unix:/tmp/layotto.sock".
+func NewClientWithAddress(address string, opts ...ClientOption) (client Client, err error) {
 	if address == "" {
 		return nil, errors.New("nil address")
 	}
+	o := &clientOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
 	logger.Printf("runtime client initializing for: %s", address)
-	conn, err := grpc.Dial(address, grpc.WithInsecure())
+	conn, err := grpc.Dial(address, o.toDialOptions()...)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error creating connection to '%s': %v", address, err)
 	}
@@ -19,17 +19,25 @@ package client
 import (
 	"context"
 	"fmt"
-
+	"io"
 	"net"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 	"google.golang.org/protobuf/types/known/anypb"
 	empty "google.golang.org/protobuf/types/known/emptypb"
 
+	"mosn.io/layotto/spec/proto/extension/v1/cryption"
+	"mosn.io/layotto/spec/proto/extension/v1/delay_queue"
+	"mosn.io/layotto/spec/proto/extension/v1/email"
+	"mosn.io/layotto/spec/proto/extension/v1/phone"
+	s3 "mosn.io/layotto/spec/proto/extension/v1/s3"
 	pb "mosn.io/layotto/spec/proto/runtime/v1"
 	runtimev1pb "mosn.io/layotto/spec/proto/runtime/v1"
 )
@@ -39,7 +47,8 @@ const (
 )
 
 var (
-	testClient Client
+	testClient      Client
+	testOssInstance *testOssServer
 )
 
 func TestMain(m *testing.M) {
@@ -83,7 +92,19 @@ func getTestClient(ctx context.Context) (client Client, closer func()) {
 		subscribed: make(map[string]bool),
 		state:      make(map[string][]byte),
 		lock:       make(map[string]string),
+		files:      make(map[string][]byte),
+		uploads:    make(map[string]map[int64][]byte),
 	})
+	testOssInstance = &testOssServer{
+		objects: make(map[string][]byte),
+		uploads: make(map[string]map[int32][]byte),
+	}
+	s3.RegisterObjectStorageServiceServer(s, testOssInstance)
+	ext := &testExtensionServer{}
+	cryption.RegisterCryptionServiceServer(s, ext)
+	delay_queue.RegisterDelayQueueServer(s, ext)
+	email.RegisterEmailServiceServer(s, ext)
+	phone.RegisterPhoneCallServiceServer(s, ext)
 
 	l := bufconn.Listen(testBufSize)
 	go func() {
@@ -116,6 +137,9 @@ type testRuntimeServer struct {
 	subscribed map[string]bool
 	state      map[string][]byte
 	lock       map[string]string
+	files      map[string][]byte
+	// uploads saves the chunks of the upload sessions by their offsets
+	uploads map[string]map[int64][]byte
 }
 
 func (t *testRuntimeServer) InvokeService(ctx context.Context, req *runtimev1pb.InvokeServiceRequest) (*runtimev1pb.InvokeResponse, error) {
@@ -300,3 +324,139 @@ func (t *testRuntimeServer) Unlock(ctx context.Context, in *runtimev1pb.UnlockRe
 		Status: pb.UnlockResponse_LOCK_BELONG_TO_OTHERS,
 	}, nil
 }
+
+func (t *testRuntimeServer) GetFile(req *runtimev1pb.GetFileRequest, srv runtimev1pb.Runtime_GetFileServer) error {
+	data, ok := t.files[req.Name]
+	if !ok {
+		return status.Errorf(codes.NotFound, "file %s not found", req.Name)
+	}
+	data = data[req.Offset:]
+	if req.Length > 0 && req.Length < int64(len(data)) {
+		data = data[:req.Length]
+	}
+	// send by small chunks
+	for len(data) > 0 {
+		n := 3
+		if n > len(data) {
+			n = len(data)
+		}
+		if err := srv.Send(&runtimev1pb.GetFileResponse{Data: data[:n]}); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+func (t *testRuntimeServer) PutFile(srv runtimev1pb.Runtime_PutFileServer) error {
+	var name string
+	var data []byte
+	for {
+		req, err := srv.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if req.Name != "" {
+			name = req.Name
+		}
+		data = append(data, req.Data...)
+	}
+	t.files[name] = data
+	return srv.SendAndClose(&empty.Empty{})
+}
+
+func (t *testRuntimeServer) InitFileUpload(ctx context.Context, in *runtimev1pb.InitFileUploadRequest) (*runtimev1pb.InitFileUploadResponse, error) {
+	chunkSize := in.ChunkSize
+	if chunkSize == 0 {
+		chunkSize = 4
+	}
+	if in.UploadId == "" {
+		id := fmt.Sprintf("upload-%d", len(t.uploads))
+		t.uploads[id] = make(map[int64][]byte)
+		return &runtimev1pb.InitFileUploadResponse{UploadId: id, ChunkSize: chunkSize}, nil
+	}
+	chunks, ok := t.uploads[in.UploadId]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "upload %s not found", in.UploadId)
+	}
+	var offset int64
+	for chunks[offset] != nil {
+		offset += chunkSize
+	}
+	return &runtimev1pb.InitFileUploadResponse{UploadId: in.UploadId, ChunkSize: chunkSize, Offset: offset}, nil
+}
+
+func (t *testRuntimeServer) PutFileChunk(srv runtimev1pb.Runtime_PutFileChunkServer) error {
+	var first *runtimev1pb.PutFileChunkRequest
+	var data []byte
+	for {
+		req, err := srv.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if first == nil {
+			first = req
+		}
+		data = append(data, req.Data...)
+	}
+	chunks, ok := t.uploads[first.UploadId]
+	if !ok {
+		return status.Errorf(codes.NotFound, "upload %s not found", first.UploadId)
+	}
+	chunks[first.Offset] = data
+	return srv.SendAndClose(&empty.Empty{})
+}
+
+func (t *testRuntimeServer) CommitFileUpload(ctx context.Context, in *runtimev1pb.FileUploadRequest) (*empty.Empty, error) {
+	chunks, ok := t.uploads[in.UploadId]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "upload %s not found", in.UploadId)
+	}
+	var data []byte
+	for offset := int64(0); chunks[offset] != nil; offset += int64(len(chunks[offset])) {
+		data = append(data, chunks[offset]...)
+	}
+	t.files[in.Name] = data
+	delete(t.uploads, in.UploadId)
+	return &empty.Empty{}, nil
+}
+
+func (t *testRuntimeServer) AbortFileUpload(ctx context.Context, in *runtimev1pb.FileUploadRequest) (*empty.Empty, error) {
+	delete(t.uploads, in.UploadId)
+	return &empty.Empty{}, nil
+}
+
+func (t *testRuntimeServer) ListFile(ctx context.Context, in *runtimev1pb.ListFileRequest) (*runtimev1pb.ListFileResp, error) {
+	resp := &runtimev1pb.ListFileResp{}
+	for name, data := range t.files {
+		if strings.HasPrefix(name, in.Request.Name) {
+			resp.Files = append(resp.Files, &runtimev1pb.FileInfo{FileName: name, Size: int64(len(data))})
+		}
+	}
+	return resp, nil
+}
+
+func (t *testRuntimeServer) DelFile(ctx context.Context, in *runtimev1pb.DelFileRequest) (*empty.Empty, error) {
+	delete(t.files, in.Request.Name)
+	return &empty.Empty{}, nil
+}
+
+func (t *testRuntimeServer) GetFileMeta(ctx context.Context, in *runtimev1pb.GetFileMetaRequest) (*runtimev1pb.GetFileMetaResponse, error) {
+	data, ok := t.files[in.Request.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "file %s not found", in.Request.Name)
+	}
+	return &runtimev1pb.GetFileMetaResponse{
+		Size:         int64(len(data)),
+		LastModified: "2021-12-01 10:00:00",
+		Response: &runtimev1pb.FileMeta{Metadata: map[string]*runtimev1pb.FileMetaValue{
+			"Content-Type": {Value: []string{"text/plain"}},
+		}},
+	}, nil
+}
@@ -18,6 +18,7 @@ package client
 
 import (
 	"context"
+	"time"
 
 	runtimev1pb "mosn.io/layotto/spec/proto/runtime/v1"
 )
@@ -146,23 +147,88 @@ func (c *GRPCClient) SubscribeConfiguration(ctx context.Context, in *Configurati
 				close(resCh)
 				return
 			}
-			item := &SubConfigurationResp{}
-			item.StoreName = resp.StoreName
-			item.AppId = resp.AppId
-			for _, v := range resp.Items {
-				c := &ConfigurationItem{}
-				c.Metadata = v.Metadata
-				c.Label = v.Label
-				c.Group = v.Group
-				c.Key = v.Key
-				c.Tags = v.Tags
-				c.Content = v.Content
-				item.Items = append(item.Items, c)
-			}
-			res.Item = item
+			res.Item = toSubConfigurationResp(resp)
 			res.Err = nil
 			resCh <- res
 		}
 	}, nil)
 	return resCh
 }
+
+func toSubConfigurationResp(resp *runtimev1pb.SubscribeConfigurationResponse) *SubConfigurationResp {
+	item := &SubConfigurationResp{}
+	item.StoreName = resp.StoreName
+	item.AppId = resp.AppId
+	for _, v := range resp.Items {
+		c := &ConfigurationItem{}
+		c.Metadata = v.Metadata
+		c.Label = v.Label
+		c.Group = v.Group
+		c.Key = v.Key
+		c.Tags = v.Tags
+		c.Content = v.Content
+		item.Items = append(item.Items, c)
+	}
+	return item
+}
+
+const (
+	defaultWatchMinBackoff = 100 * time.Millisecond
+	defaultWatchMaxBackoff = 10 * time.Second
+)
+
+// WatchConfiguration is like SubscribeConfiguration, but subscribes again with backoff when the subscription is broken, e.g. Layotto restarts.
+// The errors are logged rather than sent, and the channel is closed only when ctx is done.
+func (c *GRPCClient) WatchConfiguration(ctx context.Context, in *ConfigurationRequestItem) WatchChan {
+	return c.watchConfiguration(ctx, in, newBackoff(defaultWatchMinBackoff, defaultWatchMaxBackoff))
+}
+
+func (c *GRPCClient) watchConfiguration(ctx context.Context, in *ConfigurationRequestItem, b *backoff) WatchChan {
+	resCh := make(chan WatchResponse, 1)
+	GoWithRecover(func() {
+		defer close(resCh)
+		for {
+			received, err := c.subscribeConfigurationOnce(ctx, in, resCh)
+			if ctx.Err() != nil {
+				return
+			}
+			if received {
+				b.reset()
+			}
+			logger.Printf("configuration subscription of store %s is broken, subscribing again: %v", in.StoreName, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(b.next()):
+			}
+		}
+	}, nil)
+	return resCh
+}
+
+// subscribeConfigurationOnce sends the updates to ch until the subscription is broken, it returns whether any update is received
+func (c *GRPCClient) subscribeConfigurationOnce(ctx context.Context, in *ConfigurationRequestItem, ch chan<- WatchResponse) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cli, err := c.protoClient.SubscribeConfiguration(ctx)
+	if err != nil {
+		return false, err
+	}
+	request := &runtimev1pb.SubscribeConfigurationRequest{StoreName: in.StoreName, AppId: in.AppId, Group: in.Group, Label: in.Label, Keys: in.Keys, Metadata: in.Metadata}
+	if err := cli.Send(request); err != nil {
+		return false, err
+	}
+	received := false
+	for {
+		resp, err := cli.Recv()
+		if err != nil {
+			return received, err
+		}
+		received = true
+		select {
+		case ch <- WatchResponse{Item: toSubConfigurationResp(resp)}:
+		case <-ctx.Done():
+			return received, ctx.Err()
+		}
+	}
+}
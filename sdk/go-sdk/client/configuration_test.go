@@ -19,6 +19,7 @@ package client
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -71,3 +72,19 @@ func TestSubscribeConfiguration(t *testing.T) {
 		assert.Equal(t, wc.Item.Items[0].Content, "Test")
 	}
 }
+
+func TestWatchConfiguration(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	item := &ConfigurationRequestItem{StoreName: "etcd", AppId: "sofa", Keys: []string{"hello1"}}
+	// the test server closes the subscription after the first response, so the responses come from the subscriptions again
+	ch := testClient.(*GRPCClient).watchConfiguration(ctx, item, newBackoff(time.Millisecond, 10*time.Millisecond))
+	for i := 0; i < 3; i++ {
+		wc := <-ch
+		assert.NoError(t, wc.Err)
+		assert.Equal(t, "hello1", wc.Item.Items[0].Key)
+		assert.Equal(t, "Test", wc.Item.Items[0].Content)
+	}
+	cancel()
+	for range ch {
+	}
+}
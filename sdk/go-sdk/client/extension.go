@@ -0,0 +1,190 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"mosn.io/layotto/spec/proto/extension/v1/cryption"
+	"mosn.io/layotto/spec/proto/extension/v1/delay_queue"
+	"mosn.io/layotto/spec/proto/extension/v1/email"
+	"mosn.io/layotto/spec/proto/extension/v1/phone"
+)
+
+// Email is the email sent by SendTextEmail or SendTemplateEmail.
+type Email struct {
+	From    string
+	To      []string
+	Cc      []string
+	Subject string
+	// The content of the email sent by SendTextEmail.
+	Text string
+	// The setting of the component used by SendTextEmail, optional.
+	SettingId string
+	// The template and its parameters used by SendTemplateEmail.
+	TemplateId     string
+	TemplateParams map[string]string
+}
+
+// Voice is the voice call made by SendTemplateVoice.
+type Voice struct {
+	FromMobile     string
+	ToMobile       []string
+	TemplateId     string
+	TemplateParams map[string]string
+}
+
+// PublishDelayEvent publishes data onto topic, which is delivered to the subscribers after the delay.
+// The delay is truncated to seconds. It returns the id of the message.
+func (c *GRPCClient) PublishDelayEvent(ctx context.Context, componentName, topicName string, data []byte, delay time.Duration) (string, error) {
+	return c.publishDelayEvent(ctx, componentName, topicName, data, "", delay)
+}
+
+// PublishDelayEventfromCustomContent serializes an struct and publishes its contents as data (JSON) onto topic after the delay.
+func (c *GRPCClient) PublishDelayEventfromCustomContent(ctx context.Context, componentName, topicName string, data interface{}, delay time.Duration) (string, error) {
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return "", errors.WithMessage(err, "error serializing input struct")
+	}
+	return c.publishDelayEvent(ctx, componentName, topicName, bytes, "application/json", delay)
+}
+
+func (c *GRPCClient) publishDelayEvent(ctx context.Context, componentName, topicName string, data []byte, contentType string, delay time.Duration) (string, error) {
+	if componentName == "" {
+		return "", errors.New("component name required")
+	}
+	if topicName == "" {
+		return "", errors.New("topic name required")
+	}
+	if delay < 0 {
+		return "", errors.New("negative delay")
+	}
+	resp, err := c.DelayQueueClient.PublishDelayMessage(ctx, &delay_queue.DelayMessageRequest{
+		ComponentName:   componentName,
+		Topic:           topicName,
+		Data:            data,
+		DataContentType: contentType,
+		DelayInSeconds:  int32(delay / time.Second),
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "error publishing delay event unto %s topic", topicName)
+	}
+	return resp.MessageId, nil
+}
+
+// EncryptData encrypts the plain text with the key. The default key of the component is used if keyId is empty.
+func (c *GRPCClient) EncryptData(ctx context.Context, componentName, keyId string, plainText []byte) ([]byte, error) {
+	if componentName == "" {
+		return nil, errors.New("component name required")
+	}
+	resp, err := c.CryptionServiceClient.Encrypt(ctx, &cryption.EncryptRequest{
+		ComponentName: componentName,
+		PlainText:     plainText,
+		KeyId:         keyId,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error encrypting data")
+	}
+	return resp.CipherText, nil
+}
+
+// DecryptData decrypts the cipher text returned by EncryptData.
+func (c *GRPCClient) DecryptData(ctx context.Context, componentName string, cipherText []byte) ([]byte, error) {
+	if componentName == "" {
+		return nil, errors.New("component name required")
+	}
+	resp, err := c.CryptionServiceClient.Decrypt(ctx, &cryption.DecryptRequest{
+		ComponentName: componentName,
+		CipherText:    cipherText,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error decrypting data")
+	}
+	return resp.PlainText, nil
+}
+
+// SendTextEmail sends the email with the text content. It returns the id of the request.
+func (c *GRPCClient) SendTextEmail(ctx context.Context, componentName string, e *Email) (string, error) {
+	if err := validateEmail(componentName, e); err != nil {
+		return "", err
+	}
+	resp, err := c.EmailServiceClient.SendEmail(ctx, &email.SendEmailRequest{
+		ComponentName: componentName,
+		SettingId:     e.SettingId,
+		Subject:       e.Subject,
+		Content:       &email.Content{Text: e.Text},
+		Address:       &email.EmailAddress{From: e.From, To: e.To, Cc: e.Cc},
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "error sending email")
+	}
+	return resp.RequestId, nil
+}
+
+// SendTemplateEmail sends the email rendered by the template. It returns the id of the request.
+func (c *GRPCClient) SendTemplateEmail(ctx context.Context, componentName string, e *Email) (string, error) {
+	if err := validateEmail(componentName, e); err != nil {
+		return "", err
+	}
+	if e.TemplateId == "" {
+		return "", errors.New("template id required")
+	}
+	resp, err := c.EmailServiceClient.SendEmailWithTemplate(ctx, &email.SendEmailWithTemplateRequest{
+		ComponentName: componentName,
+		Subject:       e.Subject,
+		Template:      &email.EmailTemplate{TemplateId: e.TemplateId, TemplateParams: e.TemplateParams},
+		Address:       &email.EmailAddress{From: e.From, To: e.To, Cc: e.Cc},
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "error sending email")
+	}
+	return resp.RequestId, nil
+}
+
+func validateEmail(componentName string, e *Email) error {
+	if componentName == "" {
+		return errors.New("component name required")
+	}
+	if e == nil || len(e.To) == 0 {
+		return errors.New("email receiver required")
+	}
+	return nil
+}
+
+// SendTemplateVoice makes the voice call rendered by the template. It returns the id of the request.
+func (c *GRPCClient) SendTemplateVoice(ctx context.Context, componentName string, v *Voice) (string, error) {
+	if componentName == "" {
+		return "", errors.New("component name required")
+	}
+	if v == nil || len(v.ToMobile) == 0 || v.TemplateId == "" {
+		return "", errors.New("mobile and template id required")
+	}
+	resp, err := c.PhoneCallServiceClient.SendVoiceWithTemplate(ctx, &phone.SendVoiceWithTemplateRequest{
+		ComponentName: componentName,
+		Template:      &phone.VoiceTemplate{TemplateId: v.TemplateId, TemplateParams: v.TemplateParams},
+		ToMobile:      v.ToMobile,
+		FromMobile:    v.FromMobile,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "error sending voice")
+	}
+	return resp.RequestId, nil
+}
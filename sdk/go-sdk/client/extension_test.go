@@ -0,0 +1,135 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"mosn.io/layotto/spec/proto/extension/v1/cryption"
+	"mosn.io/layotto/spec/proto/extension/v1/delay_queue"
+	"mosn.io/layotto/spec/proto/extension/v1/email"
+	"mosn.io/layotto/spec/proto/extension/v1/phone"
+)
+
+type testExtensionServer struct {
+	cryption.UnimplementedCryptionServiceServer
+	delay_queue.UnimplementedDelayQueueServer
+	email.UnimplementedEmailServiceServer
+	phone.UnimplementedPhoneCallServiceServer
+}
+
+func (s *testExtensionServer) Encrypt(ctx context.Context, req *cryption.EncryptRequest) (*cryption.EncryptResponse, error) {
+	cipherText := make([]byte, len(req.PlainText))
+	for i, b := range req.PlainText {
+		cipherText[i] = b + 1
+	}
+	return &cryption.EncryptResponse{CipherText: cipherText, KeyId: req.KeyId}, nil
+}
+
+func (s *testExtensionServer) Decrypt(ctx context.Context, req *cryption.DecryptRequest) (*cryption.DecryptResponse, error) {
+	plainText := make([]byte, len(req.CipherText))
+	for i, b := range req.CipherText {
+		plainText[i] = b - 1
+	}
+	return &cryption.DecryptResponse{PlainText: plainText}, nil
+}
+
+func (s *testExtensionServer) PublishDelayMessage(ctx context.Context, req *delay_queue.DelayMessageRequest) (*delay_queue.DelayMessageResponse, error) {
+	return &delay_queue.DelayMessageResponse{
+		MessageId: fmt.Sprintf("%s/%d/%s/%s", req.Topic, req.DelayInSeconds, req.DataContentType, req.Data),
+	}, nil
+}
+
+func (s *testExtensionServer) SendEmail(ctx context.Context, req *email.SendEmailRequest) (*email.SendEmailResponse, error) {
+	return &email.SendEmailResponse{RequestId: req.Address.To[0] + "/" + req.Content.Text}, nil
+}
+
+func (s *testExtensionServer) SendEmailWithTemplate(ctx context.Context, req *email.SendEmailWithTemplateRequest) (*email.SendEmailWithTemplateResponse, error) {
+	return &email.SendEmailWithTemplateResponse{RequestId: req.Address.To[0] + "/" + req.Template.TemplateId}, nil
+}
+
+func (s *testExtensionServer) SendVoiceWithTemplate(ctx context.Context, req *phone.SendVoiceWithTemplateRequest) (*phone.SendVoiceWithTemplateResponse, error) {
+	return &phone.SendVoiceWithTemplateResponse{RequestId: req.ToMobile[0] + "/" + req.Template.TemplateId}, nil
+}
+
+func TestPublishDelayEvent(t *testing.T) {
+	ctx := context.Background()
+	id, err := testClient.PublishDelayEvent(ctx, "delay", "topic", []byte("data"), 1500*time.Millisecond)
+	assert.Nil(t, err)
+	assert.Equal(t, "topic/1//data", id)
+
+	id, err = testClient.PublishDelayEventfromCustomContent(ctx, "delay", "topic", map[string]int{"a": 1}, time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, "topic/60/application/json/{\"a\":1}", id)
+
+	_, err = testClient.PublishDelayEvent(ctx, "", "topic", nil, time.Second)
+	assert.Error(t, err)
+	_, err = testClient.PublishDelayEvent(ctx, "delay", "", nil, time.Second)
+	assert.Error(t, err)
+	_, err = testClient.PublishDelayEvent(ctx, "delay", "topic", nil, -time.Second)
+	assert.Error(t, err)
+}
+
+func TestCryption(t *testing.T) {
+	ctx := context.Background()
+	cipherText, err := testClient.EncryptData(ctx, "kms", "key", []byte("hello"))
+	assert.Nil(t, err)
+	assert.NotEqual(t, []byte("hello"), cipherText)
+	plainText, err := testClient.DecryptData(ctx, "kms", cipherText)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("hello"), plainText)
+
+	_, err = testClient.EncryptData(ctx, "", "key", []byte("hello"))
+	assert.Error(t, err)
+	_, err = testClient.DecryptData(ctx, "", cipherText)
+	assert.Error(t, err)
+}
+
+func TestSendEmail(t *testing.T) {
+	ctx := context.Background()
+	id, err := testClient.SendTextEmail(ctx, "email", &Email{From: "a@b.com", To: []string{"c@d.com"}, Subject: "hi", Text: "hello"})
+	assert.Nil(t, err)
+	assert.Equal(t, "c@d.com/hello", id)
+
+	id, err = testClient.SendTemplateEmail(ctx, "email", &Email{To: []string{"c@d.com"}, TemplateId: "t1"})
+	assert.Nil(t, err)
+	assert.Equal(t, "c@d.com/t1", id)
+
+	_, err = testClient.SendTemplateEmail(ctx, "email", &Email{To: []string{"c@d.com"}})
+	assert.Error(t, err)
+	_, err = testClient.SendTextEmail(ctx, "email", &Email{Text: "hello"})
+	assert.Error(t, err)
+	_, err = testClient.SendTextEmail(ctx, "", &Email{To: []string{"c@d.com"}})
+	assert.Error(t, err)
+}
+
+func TestSendTemplateVoice(t *testing.T) {
+	ctx := context.Background()
+	id, err := testClient.SendTemplateVoice(ctx, "phone", &Voice{ToMobile: []string{"123"}, TemplateId: "t1"})
+	assert.Nil(t, err)
+	assert.Equal(t, "123/t1", id)
+
+	_, err = testClient.SendTemplateVoice(ctx, "phone", &Voice{ToMobile: []string{"123"}})
+	assert.Error(t, err)
+	_, err = testClient.SendTemplateVoice(ctx, "", &Voice{ToMobile: []string{"123"}, TemplateId: "t1"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,359 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+
+	runtimev1pb "mosn.io/layotto/spec/proto/runtime/v1"
+)
+
+// fileChunkSize is the max size of the data sent in a single message of the streaming requests
+const fileChunkSize = 1024 * 1024
+
+// FileRequest specifies a file in the file store.
+type FileRequest struct {
+	// The name of file store.
+	StoreName string
+	// The name of the file or the directory.
+	Name string
+	// The metadata which will be sent to file store components.
+	Metadata map[string]string
+}
+
+// GetFileRequest specifies the file and the range to read.
+type GetFileRequest struct {
+	// The name of file store.
+	StoreName string
+	// The name of the file.
+	Name string
+	// The metadata which will be sent to file store components.
+	Metadata map[string]string
+	// The offset to read from.
+	Offset int64
+	// The number of bytes to read, 0 means to the end of the file.
+	Length int64
+}
+
+// PutFileRequest specifies the file to write.
+type PutFileRequest struct {
+	// The name of file store.
+	StoreName string
+	// The name of the file.
+	Name string
+	// The metadata which will be sent to file store components.
+	Metadata map[string]string
+}
+
+// InitFileUploadRequest starts a resumable upload session of the file.
+type InitFileUploadRequest struct {
+	// The name of file store.
+	StoreName string
+	// The name of the file.
+	Name string
+	// The metadata which will be sent to file store components.
+	Metadata map[string]string
+	// The session to resume, a new session is started if it's empty.
+	UploadId string
+	// The size of every chunk except the last one, 0 means the default size of the store.
+	ChunkSize int64
+}
+
+// FileUploadSession is a resumable upload session.
+type FileUploadSession struct {
+	// The id of the session, it should be treated as an opaque string.
+	UploadId string
+	// The size of every chunk except the last one.
+	ChunkSize int64
+	// The size of the chunks uploaded continuously from the beginning, the upload can be resumed from it.
+	Offset int64
+}
+
+// PutFileChunkRequest specifies the chunk to upload.
+type PutFileChunkRequest struct {
+	// The name of file store.
+	StoreName string
+	// The name of the file.
+	Name string
+	// The metadata which will be sent to file store components.
+	Metadata map[string]string
+	// The id of the upload session.
+	UploadId string
+	// The offset of the chunk in the file, it must be a multiple of the chunk size.
+	Offset int64
+}
+
+// FileUploadRequest specifies the upload session to commit or abort.
+type FileUploadRequest struct {
+	// The name of file store.
+	StoreName string
+	// The name of the file.
+	Name string
+	// The metadata which will be sent to file store components.
+	Metadata map[string]string
+	// The id of the upload session.
+	UploadId string
+}
+
+// ListFileRequest lists the files under a directory page by page.
+type ListFileRequest struct {
+	// The name of file store.
+	StoreName string
+	// The name of the directory.
+	Name string
+	// The metadata which will be sent to file store components.
+	Metadata map[string]string
+	// The max number of the files returned.
+	PageSize int32
+	// The marker returned by the previous page, empty for the first page.
+	Marker string
+}
+
+type ListFileResp struct {
+	Files []*FileInfo
+	// The marker to get the next page.
+	Marker string
+	// Whether there are more files.
+	IsTruncated bool
+}
+
+type FileInfo struct {
+	FileName     string
+	Size         int64
+	LastModified string
+	Metadata     map[string]string
+}
+
+type FileMeta struct {
+	Size         int64
+	LastModified string
+	Metadata     map[string][]string
+}
+
+// GetFile reads the file and writes its data into w. It returns the number of bytes written.
+func (c *GRPCClient) GetFile(ctx context.Context, req *GetFileRequest, w io.Writer) (int64, error) {
+	if req.StoreName == "" || req.Name == "" {
+		return 0, errors.New("store name and file name required")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cli, err := c.protoClient.GetFile(ctx, &runtimev1pb.GetFileRequest{
+		StoreName: req.StoreName,
+		Name:      req.Name,
+		Metadata:  req.Metadata,
+		Offset:    req.Offset,
+		Length:    req.Length,
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "error getting file %s", req.Name)
+	}
+	var written int64
+	for {
+		resp, err := cli.Recv()
+		if err == io.EOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, errors.Wrapf(err, "error getting file %s", req.Name)
+		}
+		n, err := w.Write(resp.Data)
+		written += int64(n)
+		if err != nil {
+			return written, errors.WithMessage(err, "error writing file data")
+		}
+	}
+}
+
+// PutFile reads the data from r until EOF and writes it into the file.
+func (c *GRPCClient) PutFile(ctx context.Context, req *PutFileRequest, r io.Reader) error {
+	if req.StoreName == "" || req.Name == "" {
+		return errors.New("store name and file name required")
+	}
+	// cancel the stream if the reader fails, so that the incomplete file is not committed
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cli, err := c.protoClient.PutFile(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "error putting file %s", req.Name)
+	}
+	// the store and the file are specified by the first message
+	first := &runtimev1pb.PutFileRequest{StoreName: req.StoreName, Name: req.Name, Metadata: req.Metadata}
+	err = sendChunks(r, func(data []byte) error {
+		msg := &runtimev1pb.PutFileRequest{Data: data}
+		if first != nil {
+			msg, first = first, nil
+			msg.Data = data
+		}
+		return cli.Send(msg)
+	})
+	// io.EOF means the stream is aborted by the server, and the error is returned by CloseAndRecv
+	if err != nil && err != io.EOF {
+		return errors.Wrapf(err, "error putting file %s", req.Name)
+	}
+	if _, err = cli.CloseAndRecv(); err != nil {
+		return errors.Wrapf(err, "error putting file %s", req.Name)
+	}
+	return nil
+}
+
+// ListFile lists the files under the directory.
+func (c *GRPCClient) ListFile(ctx context.Context, req *ListFileRequest) (*ListFileResp, error) {
+	resp, err := c.protoClient.ListFile(ctx, &runtimev1pb.ListFileRequest{
+		Request:  &runtimev1pb.FileRequest{StoreName: req.StoreName, Name: req.Name, Metadata: req.Metadata},
+		PageSize: req.PageSize,
+		Marker:   req.Marker,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := &ListFileResp{Marker: resp.Marker, IsTruncated: resp.IsTruncated}
+	for _, f := range resp.Files {
+		out.Files = append(out.Files, &FileInfo{FileName: f.FileName, Size: f.Size, LastModified: f.LastModified, Metadata: f.Metadata})
+	}
+	return out, nil
+}
+
+// DelFile deletes the file.
+func (c *GRPCClient) DelFile(ctx context.Context, req *FileRequest) error {
+	_, err := c.protoClient.DelFile(ctx, &runtimev1pb.DelFileRequest{
+		Request: &runtimev1pb.FileRequest{StoreName: req.StoreName, Name: req.Name, Metadata: req.Metadata},
+	})
+	return err
+}
+
+// GetFileMeta gets the size, the modification time and the metadata of the file.
+func (c *GRPCClient) GetFileMeta(ctx context.Context, req *FileRequest) (*FileMeta, error) {
+	resp, err := c.protoClient.GetFileMeta(ctx, &runtimev1pb.GetFileMetaRequest{
+		Request: &runtimev1pb.FileRequest{StoreName: req.StoreName, Name: req.Name, Metadata: req.Metadata},
+	})
+	if err != nil {
+		return nil, err
+	}
+	meta := &FileMeta{Size: resp.Size, LastModified: resp.LastModified, Metadata: make(map[string][]string)}
+	if resp.Response != nil {
+		for k, v := range resp.Response.Metadata {
+			meta.Metadata[k] = v.GetValue()
+		}
+	}
+	return meta, nil
+}
+
+// InitFileUpload starts a resumable upload session of the file, or resumes the session if UploadId is specified.
+func (c *GRPCClient) InitFileUpload(ctx context.Context, req *InitFileUploadRequest) (*FileUploadSession, error) {
+	if req.StoreName == "" || req.Name == "" {
+		return nil, errors.New("store name and file name required")
+	}
+	resp, err := c.protoClient.InitFileUpload(ctx, &runtimev1pb.InitFileUploadRequest{
+		StoreName: req.StoreName,
+		Name:      req.Name,
+		UploadId:  req.UploadId,
+		ChunkSize: req.ChunkSize,
+		Metadata:  req.Metadata,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error initiating the upload of file %s", req.Name)
+	}
+	return &FileUploadSession{UploadId: resp.UploadId, ChunkSize: resp.ChunkSize, Offset: resp.Offset}, nil
+}
+
+// PutFileChunk reads the data from r until EOF and uploads it as the chunk at req.Offset.
+func (c *GRPCClient) PutFileChunk(ctx context.Context, req *PutFileChunkRequest, r io.Reader) error {
+	if req.StoreName == "" || req.Name == "" || req.UploadId == "" {
+		return errors.New("store name, file name and upload id required")
+	}
+	// cancel the stream if the reader fails, so that the incomplete chunk is not saved
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cli, err := c.protoClient.PutFileChunk(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "error putting chunk of file %s", req.Name)
+	}
+	// the session and the offset are specified by the first message
+	first := &runtimev1pb.PutFileChunkRequest{
+		StoreName: req.StoreName,
+		Name:      req.Name,
+		UploadId:  req.UploadId,
+		Offset:    req.Offset,
+		Metadata:  req.Metadata,
+	}
+	err = sendChunks(r, func(data []byte) error {
+		msg := &runtimev1pb.PutFileChunkRequest{Data: data}
+		if first != nil {
+			msg, first = first, nil
+			msg.Data = data
+		}
+		return cli.Send(msg)
+	})
+	// io.EOF means the stream is aborted by the server, and the error is returned by CloseAndRecv
+	if err != nil && err != io.EOF {
+		return errors.Wrapf(err, "error putting chunk of file %s", req.Name)
+	}
+	if _, err = cli.CloseAndRecv(); err != nil {
+		return errors.Wrapf(err, "error putting chunk of file %s", req.Name)
+	}
+	return nil
+}
+
+// CommitFileUpload composes the uploaded chunks into the file.
+func (c *GRPCClient) CommitFileUpload(ctx context.Context, req *FileUploadRequest) error {
+	_, err := c.protoClient.CommitFileUpload(ctx, &runtimev1pb.FileUploadRequest{
+		StoreName: req.StoreName,
+		Name:      req.Name,
+		UploadId:  req.UploadId,
+		Metadata:  req.Metadata,
+	})
+	return err
+}
+
+// AbortFileUpload discards the uploaded chunks.
+func (c *GRPCClient) AbortFileUpload(ctx context.Context, req *FileUploadRequest) error {
+	_, err := c.protoClient.AbortFileUpload(ctx, &runtimev1pb.FileUploadRequest{
+		StoreName: req.StoreName,
+		Name:      req.Name,
+		UploadId:  req.UploadId,
+		Metadata:  req.Metadata,
+	})
+	return err
+}
+
+// sendChunks reads r until EOF and sends the data by chunks.
+// At least one chunk is sent, even if r is empty.
+func sendChunks(r io.Reader, send func(data []byte) error) error {
+	buf := make([]byte, fileChunkSize)
+	sent := false
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 || (!sent && (err == io.EOF || err == io.ErrUnexpectedEOF)) {
+			// the message may be used lazily after it is sent, so the buffer is not sent directly
+			data := make([]byte, n)
+			copy(data, buf[:n])
+			if sendErr := send(data); sendErr != nil {
+				return sendErr
+			}
+			sent = true
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return errors.WithMessage(err, "error reading data")
+		}
+	}
+}
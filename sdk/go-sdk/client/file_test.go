@@ -0,0 +1,143 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFile(t *testing.T) {
+	ctx := context.Background()
+	// larger than a chunk
+	data := bytes.Repeat([]byte("0123456789"), fileChunkSize/10+1)
+
+	t.Run("put file", func(t *testing.T) {
+		err := testClient.PutFile(ctx, &PutFileRequest{StoreName: "oss", Name: "dir/a.txt"}, bytes.NewReader(data))
+		assert.Nil(t, err)
+		err = testClient.PutFile(ctx, &PutFileRequest{StoreName: "oss", Name: "dir/empty.txt"}, strings.NewReader(""))
+		assert.Nil(t, err)
+	})
+
+	t.Run("get file", func(t *testing.T) {
+		var buf bytes.Buffer
+		n, err := testClient.GetFile(ctx, &GetFileRequest{StoreName: "oss", Name: "dir/a.txt"}, &buf)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(len(data)), n)
+		assert.Equal(t, data, buf.Bytes())
+
+		buf.Reset()
+		_, err = testClient.GetFile(ctx, &GetFileRequest{StoreName: "oss", Name: "dir/a.txt", Offset: 2, Length: 5}, &buf)
+		assert.Nil(t, err)
+		assert.Equal(t, "23456", buf.String())
+
+		_, err = testClient.GetFile(ctx, &GetFileRequest{StoreName: "oss", Name: "not_exist"}, &buf)
+		assert.Error(t, err)
+	})
+
+	t.Run("list and meta", func(t *testing.T) {
+		resp, err := testClient.ListFile(ctx, &ListFileRequest{StoreName: "oss", Name: "dir/"})
+		assert.Nil(t, err)
+		assert.Len(t, resp.Files, 2)
+
+		meta, err := testClient.GetFileMeta(ctx, &FileRequest{StoreName: "oss", Name: "dir/a.txt"})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(len(data)), meta.Size)
+		assert.Equal(t, []string{"text/plain"}, meta.Metadata["Content-Type"])
+	})
+
+	t.Run("delete file", func(t *testing.T) {
+		assert.Nil(t, testClient.DelFile(ctx, &FileRequest{StoreName: "oss", Name: "dir/a.txt"}))
+		assert.Nil(t, testClient.DelFile(ctx, &FileRequest{StoreName: "oss", Name: "dir/empty.txt"}))
+		resp, err := testClient.ListFile(ctx, &ListFileRequest{StoreName: "oss", Name: "dir/"})
+		assert.Nil(t, err)
+		assert.Len(t, resp.Files, 0)
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		err := testClient.PutFile(ctx, &PutFileRequest{StoreName: "oss"}, strings.NewReader("data"))
+		assert.Error(t, err)
+		_, err = testClient.GetFile(ctx, &GetFileRequest{Name: "a.txt"}, &bytes.Buffer{})
+		assert.Error(t, err)
+	})
+
+	t.Run("reader fails", func(t *testing.T) {
+		err := testClient.PutFile(ctx, &PutFileRequest{StoreName: "oss", Name: "b.txt"}, &errReader{})
+		assert.Error(t, err)
+	})
+}
+
+func TestFileUpload(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("upload and resume", func(t *testing.T) {
+		session, err := testClient.InitFileUpload(ctx, &InitFileUploadRequest{StoreName: "oss", Name: "upload.txt", ChunkSize: 4})
+		assert.Nil(t, err)
+		assert.NotEmpty(t, session.UploadId)
+		assert.Equal(t, int64(4), session.ChunkSize)
+		assert.Equal(t, int64(0), session.Offset)
+
+		req := &PutFileChunkRequest{StoreName: "oss", Name: "upload.txt", UploadId: session.UploadId}
+		assert.Nil(t, testClient.PutFileChunk(ctx, req, strings.NewReader("0123")))
+
+		session, err = testClient.InitFileUpload(ctx, &InitFileUploadRequest{StoreName: "oss", Name: "upload.txt", UploadId: session.UploadId, ChunkSize: 4})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(4), session.Offset)
+
+		req.Offset = session.Offset
+		assert.Nil(t, testClient.PutFileChunk(ctx, req, strings.NewReader("45")))
+		err = testClient.CommitFileUpload(ctx, &FileUploadRequest{StoreName: "oss", Name: "upload.txt", UploadId: session.UploadId})
+		assert.Nil(t, err)
+
+		var buf bytes.Buffer
+		_, err = testClient.GetFile(ctx, &GetFileRequest{StoreName: "oss", Name: "upload.txt"}, &buf)
+		assert.Nil(t, err)
+		assert.Equal(t, "012345", buf.String())
+		assert.Nil(t, testClient.DelFile(ctx, &FileRequest{StoreName: "oss", Name: "upload.txt"}))
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		session, err := testClient.InitFileUpload(ctx, &InitFileUploadRequest{StoreName: "oss", Name: "upload.txt"})
+		assert.Nil(t, err)
+		err = testClient.PutFileChunk(ctx, &PutFileChunkRequest{StoreName: "oss", Name: "upload.txt", UploadId: session.UploadId}, strings.NewReader("0123"))
+		assert.Nil(t, err)
+		err = testClient.AbortFileUpload(ctx, &FileUploadRequest{StoreName: "oss", Name: "upload.txt", UploadId: session.UploadId})
+		assert.Nil(t, err)
+		err = testClient.CommitFileUpload(ctx, &FileUploadRequest{StoreName: "oss", Name: "upload.txt", UploadId: session.UploadId})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		_, err := testClient.InitFileUpload(ctx, &InitFileUploadRequest{StoreName: "oss"})
+		assert.Error(t, err)
+		err = testClient.PutFileChunk(ctx, &PutFileChunkRequest{StoreName: "oss", Name: "upload.txt"}, strings.NewReader("data"))
+		assert.Error(t, err)
+		err = testClient.PutFileChunk(ctx, &PutFileChunkRequest{StoreName: "oss", Name: "upload.txt", UploadId: "not_exist"}, strings.NewReader("data"))
+		assert.Error(t, err)
+	})
+}
+
+type errReader struct{}
+
+func (r *errReader) Read(p []byte) (int, error) {
+	return 0, errors.New("read error")
+}
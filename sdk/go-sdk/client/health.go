@@ -0,0 +1,86 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	actuatorAddressDefault = "127.0.0.1:34999"
+	readinessPath          = "/actuator/health/readiness"
+	healthStatusUp         = "UP"
+
+	healthCheckTimeout    = 2 * time.Second
+	healthCheckMinBackoff = 100 * time.Millisecond
+	healthCheckMaxBackoff = 2 * time.Second
+)
+
+var healthClient = &http.Client{Timeout: healthCheckTimeout}
+
+// WaitForSidecar blocks until the readiness endpoint of Layotto reports UP, or ctx is done.
+// actuatorAddress is the address of the actuator, e.g. "127.0.0.1:34999" which is used if it's empty.
+func WaitForSidecar(ctx context.Context, actuatorAddress string) error {
+	if actuatorAddress == "" {
+		actuatorAddress = actuatorAddressDefault
+	}
+	url := actuatorAddress
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		url = "http://" + url
+	}
+	url = strings.TrimSuffix(url, "/") + readinessPath
+	b := newBackoff(healthCheckMinBackoff, healthCheckMaxBackoff)
+	for {
+		err := checkReadiness(ctx, url)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "sidecar is not ready: %v", err)
+		case <-time.After(b.next()):
+		}
+	}
+}
+
+func checkReadiness(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := healthClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var health struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return errors.Wrapf(err, "error decoding readiness with http status %d", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK || health.Status != healthStatusUp {
+		return errors.Errorf("readiness status is %s with http status %d", health.Status, resp.StatusCode)
+	}
+	return nil
+}
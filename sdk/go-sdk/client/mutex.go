@@ -0,0 +1,321 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	runtimev1pb "mosn.io/layotto/spec/proto/runtime/v1"
+)
+
+const (
+	defaultLockExpire     = 30 * time.Second
+	defaultLockMinBackoff = 50 * time.Millisecond
+	defaultLockMaxBackoff = 2 * time.Second
+)
+
+// ErrLockNotHeld is returned by Unlock if the lock is not held by the owner, e.g. it has expired.
+var ErrLockNotHeld = errors.New("lock not held")
+
+// locker is the distributed lock API used by Lock
+type locker interface {
+	TryLock(context.Context, *runtimev1pb.TryLockRequest) (*runtimev1pb.TryLockResponse, error)
+	Unlock(context.Context, *runtimev1pb.UnlockRequest) (*runtimev1pb.UnlockResponse, error)
+}
+
+// LockOption Lock's function type
+type LockOption func(*Lock)
+
+// WithLockOwner sets the owner of the lock, a random uuid is used by default.
+func WithLockOwner(owner string) LockOption {
+	return func(l *Lock) {
+		l.owner = owner
+	}
+}
+
+// WithLockExpire sets the expiry of the lock, which is truncated to seconds and at least one second. It's 30s by default.
+func WithLockExpire(expire time.Duration) LockOption {
+	return func(l *Lock) {
+		l.expire = expire.Truncate(time.Second)
+		if l.expire < time.Second {
+			l.expire = time.Second
+		}
+	}
+}
+
+// WithLockRenewInterval sets the interval to renew the lock, it's a third of the expiry by default.
+func WithLockRenewInterval(interval time.Duration) LockOption {
+	return func(l *Lock) {
+		l.renewInterval = interval
+	}
+}
+
+// WithLockBackoff sets the backoff between the retries of acquiring the lock, which doubles from min to max.
+func WithLockBackoff(min, max time.Duration) LockOption {
+	return func(l *Lock) {
+		l.minBackoff = min
+		l.maxBackoff = max
+	}
+}
+
+// Lock is a distributed mutex built on TryLock and Unlock.
+//
+//	l := client.NewLock("redis", "order_1")
+//	ctx, err := l.Lock(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	defer l.Unlock(context.Background())
+//
+// The lock is renewed in the background while it's held, by locking again with the same owner.
+// The context returned by Lock is canceled if the lock is lost, so the work under it should stop.
+// The in-tree lock stores don't allow the owner to lock again, so the renewal fails on them: it's logged and
+// not retried, and the lock is lost when it expires. Set the expiry longer than the work with WithLockExpire for such stores.
+type Lock struct {
+	client        locker
+	storeName     string
+	resourceId    string
+	owner         string
+	expire        time.Duration
+	renewInterval time.Duration
+	minBackoff    time.Duration
+	maxBackoff    time.Duration
+
+	mu        sync.Mutex
+	acquiring bool
+	held      *heldLock
+}
+
+// heldLock is the state of the lock acquired by Lock
+type heldLock struct {
+	// unlock receives the context of Unlock
+	unlock chan context.Context
+	// done is closed when the lock is released or lost
+	done chan struct{}
+	// err is the result of releasing the lock
+	err error
+}
+
+// NewLock creates a lock of the resource in the lock store.
+func (c *GRPCClient) NewLock(storeName, resourceId string, opts ...LockOption) *Lock {
+	return newLock(c, storeName, resourceId, opts...)
+}
+
+func newLock(client locker, storeName, resourceId string, opts ...LockOption) *Lock {
+	l := &Lock{
+		client:     client,
+		storeName:  storeName,
+		resourceId: resourceId,
+		owner:      uuid.New().String(),
+		expire:     defaultLockExpire,
+		minBackoff: defaultLockMinBackoff,
+		maxBackoff: defaultLockMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if l.renewInterval <= 0 {
+		l.renewInterval = l.expire / 3
+	}
+	return l
+}
+
+// Owner returns the owner of the lock.
+func (l *Lock) Owner() string {
+	return l.owner
+}
+
+// Lock blocks until the lock is acquired or ctx is done, retrying with backoff.
+// The returned context is derived from ctx, and canceled when the lock is released or lost.
+// The lock is released when ctx is done.
+func (l *Lock) Lock(ctx context.Context) (context.Context, error) {
+	if l.storeName == "" || l.resourceId == "" {
+		return nil, errors.New("store name and resource id required")
+	}
+	if l.owner == "" {
+		return nil, errors.New("lock owner required")
+	}
+	l.mu.Lock()
+	if l.acquiring || l.held != nil {
+		l.mu.Unlock()
+		return nil, errors.Errorf("lock %s is already held or being acquired", l.resourceId)
+	}
+	l.acquiring = true
+	l.mu.Unlock()
+
+	deadline, err := l.acquire(ctx)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.acquiring = false
+	if err != nil {
+		return nil, err
+	}
+	lockCtx, cancel := context.WithCancel(ctx)
+	h := &heldLock{
+		unlock: make(chan context.Context),
+		done:   make(chan struct{}),
+	}
+	l.held = h
+	go l.keep(lockCtx, cancel, h, deadline)
+	return lockCtx, nil
+}
+
+// acquire tries to lock until success, it returns the time the lock expires
+func (l *Lock) acquire(ctx context.Context) (time.Time, error) {
+	b := newBackoff(l.minBackoff, l.maxBackoff)
+	for {
+		start := time.Now()
+		ok, err := l.tryLock(ctx)
+		if ok {
+			return start.Add(l.expire), nil
+		}
+		if err != nil && !retryableLockError(err) {
+			return time.Time{}, err
+		}
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				return time.Time{}, err
+			}
+			return time.Time{}, ctx.Err()
+		case <-time.After(b.next()):
+		}
+	}
+}
+
+func (l *Lock) tryLock(ctx context.Context) (bool, error) {
+	resp, err := l.client.TryLock(ctx, &runtimev1pb.TryLockRequest{
+		StoreName:  l.storeName,
+		ResourceId: l.resourceId,
+		LockOwner:  l.owner,
+		Expire:     int32(l.expire / time.Second),
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "error locking %s", l.resourceId)
+	}
+	return resp.Success, nil
+}
+
+// retryableLockError returns false for the errors which won't be fixed by retrying, e.g. the store doesn't exist
+func retryableLockError(err error) bool {
+	switch status.Code(errors.Cause(err)) {
+	case codes.InvalidArgument, codes.NotFound, codes.Unimplemented, codes.PermissionDenied, codes.Unauthenticated:
+		return false
+	}
+	return true
+}
+
+// keep renews the lock until it's unlocked, lost or ctx is done
+func (l *Lock) keep(ctx context.Context, cancel context.CancelFunc, h *heldLock, deadline time.Time) {
+	defer func() {
+		cancel()
+		l.mu.Lock()
+		if l.held == h {
+			l.held = nil
+		}
+		l.mu.Unlock()
+		close(h.done)
+	}()
+	ticker := time.NewTicker(l.renewInterval)
+	defer ticker.Stop()
+	// renew is set to nil when the store refuses to renew, which stops the renewal
+	renew := ticker.C
+	for {
+		expired := time.NewTimer(time.Until(deadline))
+		select {
+		case uctx := <-h.unlock:
+			expired.Stop()
+			cancel()
+			h.err = l.release(uctx)
+			return
+		case <-ctx.Done():
+			expired.Stop()
+			rctx, rcancel := context.WithTimeout(context.Background(), l.expire)
+			if err := l.release(rctx); err != nil {
+				logger.Printf("failed to release lock %s when the context is done: %v", l.resourceId, err)
+			}
+			rcancel()
+			return
+		case <-expired.C:
+			logger.Printf("lock %s of store %s is lost", l.resourceId, l.storeName)
+			return
+		case <-renew:
+			expired.Stop()
+		}
+		start := time.Now()
+		rctx, rcancel := context.WithDeadline(ctx, deadline)
+		ok, err := l.tryLock(rctx)
+		rcancel()
+		switch {
+		case ok:
+			deadline = start.Add(l.expire)
+		case err != nil:
+			logger.Printf("failed to renew lock %s: %v", l.resourceId, err)
+		default:
+			// retrying won't help, as the store doesn't let the owner lock again, or the lock is taken by others
+			logger.Printf("failed to renew lock %s: store %s refuses to lock again, the renewal is stopped and the lock will be lost at %s",
+				l.resourceId, l.storeName, deadline.Format(time.RFC3339))
+			ticker.Stop()
+			renew = nil
+		}
+	}
+}
+
+func (l *Lock) release(ctx context.Context) error {
+	resp, err := l.client.Unlock(ctx, &runtimev1pb.UnlockRequest{
+		StoreName:  l.storeName,
+		ResourceId: l.resourceId,
+		LockOwner:  l.owner,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error unlocking %s", l.resourceId)
+	}
+	switch resp.Status {
+	case runtimev1pb.UnlockResponse_SUCCESS:
+		return nil
+	case runtimev1pb.UnlockResponse_LOCK_UNEXIST, runtimev1pb.UnlockResponse_LOCK_BELONG_TO_OTHERS:
+		return ErrLockNotHeld
+	default:
+		return errors.Errorf("error unlocking %s: %s", l.resourceId, resp.Status)
+	}
+}
+
+// Unlock releases the lock and cancels the context returned by Lock.
+// It returns ErrLockNotHeld if the lock isn't held, e.g. it's lost or released when the context is done.
+func (l *Lock) Unlock(ctx context.Context) error {
+	l.mu.Lock()
+	h := l.held
+	l.held = nil
+	l.mu.Unlock()
+	if h == nil {
+		return ErrLockNotHeld
+	}
+	select {
+	case h.unlock <- ctx:
+	case <-h.done:
+		return ErrLockNotHeld
+	}
+	<-h.done
+	return h.err
+}
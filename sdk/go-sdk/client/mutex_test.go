@@ -0,0 +1,228 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	runtimev1pb "mosn.io/layotto/spec/proto/runtime/v1"
+)
+
+// testLocker is an in-memory lock store with expiry
+type testLocker struct {
+	mu sync.Mutex
+	// reentrant allows the owner to lock again, which renews the lock
+	reentrant bool
+	owners    map[string]string
+	expiry    map[string]time.Time
+	tries     int
+	err       error
+}
+
+func newTestLocker(reentrant bool) *testLocker {
+	return &testLocker{
+		reentrant: reentrant,
+		owners:    make(map[string]string),
+		expiry:    make(map[string]time.Time),
+	}
+}
+
+func (t *testLocker) TryLock(ctx context.Context, in *runtimev1pb.TryLockRequest) (*runtimev1pb.TryLockResponse, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tries++
+	if t.err != nil {
+		return nil, t.err
+	}
+	owner := t.ownerLocked(in.ResourceId)
+	if owner == "" || (t.reentrant && owner == in.LockOwner) {
+		t.owners[in.ResourceId] = in.LockOwner
+		t.expiry[in.ResourceId] = time.Now().Add(time.Duration(in.Expire) * time.Second)
+		return &runtimev1pb.TryLockResponse{Success: true}, nil
+	}
+	return &runtimev1pb.TryLockResponse{Success: false}, nil
+}
+
+func (t *testLocker) Unlock(ctx context.Context, in *runtimev1pb.UnlockRequest) (*runtimev1pb.UnlockResponse, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch t.ownerLocked(in.ResourceId) {
+	case "":
+		return &runtimev1pb.UnlockResponse{Status: runtimev1pb.UnlockResponse_LOCK_UNEXIST}, nil
+	case in.LockOwner:
+		delete(t.owners, in.ResourceId)
+		return &runtimev1pb.UnlockResponse{Status: runtimev1pb.UnlockResponse_SUCCESS}, nil
+	default:
+		return &runtimev1pb.UnlockResponse{Status: runtimev1pb.UnlockResponse_LOCK_BELONG_TO_OTHERS}, nil
+	}
+}
+
+func (t *testLocker) ownerLocked(resourceId string) string {
+	if time.Now().After(t.expiry[resourceId]) {
+		delete(t.owners, resourceId)
+	}
+	return t.owners[resourceId]
+}
+
+func (t *testLocker) owner(resourceId string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.ownerLocked(resourceId)
+}
+
+func TestLock(t *testing.T) {
+	store := newTestLocker(true)
+	l := newLock(store, "demo", "lock", WithLockOwner("layotto"))
+	assert.Equal(t, "layotto", l.Owner())
+
+	ctx, err := l.Lock(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, ctx.Err())
+	assert.Equal(t, "layotto", store.owner("lock"))
+
+	// the lock is not reentrant
+	_, err = l.Lock(context.Background())
+	assert.Error(t, err)
+
+	assert.NoError(t, l.Unlock(context.Background()))
+	assert.Equal(t, context.Canceled, ctx.Err())
+	assert.Equal(t, "", store.owner("lock"))
+	assert.Equal(t, ErrLockNotHeld, l.Unlock(context.Background()))
+
+	// lock again
+	_, err = l.Lock(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, l.Unlock(context.Background()))
+}
+
+func TestLock_invalid(t *testing.T) {
+	_, err := newLock(newTestLocker(true), "", "lock").Lock(context.Background())
+	assert.Error(t, err)
+	_, err = newLock(newTestLocker(true), "demo", "").Lock(context.Background())
+	assert.Error(t, err)
+	_, err = newLock(newTestLocker(true), "demo", "lock", WithLockOwner("")).Lock(context.Background())
+	assert.Error(t, err)
+
+	// the errors which can't be fixed by retrying
+	store := newTestLocker(true)
+	store.err = status.Error(codes.InvalidArgument, "store not found")
+	_, err = newLock(store, "demo", "lock").Lock(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 1, store.tries)
+}
+
+func TestLock_retry(t *testing.T) {
+	store := newTestLocker(true)
+	other := newLock(store, "demo", "lock")
+	_, err := other.Lock(context.Background())
+	assert.NoError(t, err)
+
+	l := newLock(store, "demo", "lock", WithLockBackoff(time.Millisecond, 10*time.Millisecond))
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		assert.NoError(t, other.Unlock(context.Background()))
+	}()
+	_, err = l.Lock(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, l.Owner(), store.owner("lock"))
+	assert.NoError(t, l.Unlock(context.Background()))
+}
+
+func TestLock_timeout(t *testing.T) {
+	store := newTestLocker(true)
+	_, err := newLock(store, "demo", "lock").Lock(context.Background())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = newLock(store, "demo", "lock", WithLockBackoff(time.Millisecond, 10*time.Millisecond)).Lock(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// the last error is returned
+	store.err = status.Error(codes.Unavailable, "unavailable")
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = newLock(store, "demo", "lock2", WithLockBackoff(time.Millisecond, 10*time.Millisecond)).Lock(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, codes.Unavailable, status.Code(errors.Cause(err)))
+}
+
+func TestLock_renew(t *testing.T) {
+	store := newTestLocker(true)
+	l := newLock(store, "demo", "lock", WithLockExpire(time.Second), WithLockRenewInterval(100*time.Millisecond))
+	ctx, err := l.Lock(context.Background())
+	assert.NoError(t, err)
+
+	time.Sleep(1500 * time.Millisecond)
+	assert.Nil(t, ctx.Err())
+	assert.Equal(t, l.Owner(), store.owner("lock"))
+	assert.NoError(t, l.Unlock(context.Background()))
+}
+
+func TestLock_lost(t *testing.T) {
+	// the lock can't be renewed without reentrance
+	store := newTestLocker(false)
+	l := newLock(store, "demo", "lock", WithLockExpire(time.Second), WithLockRenewInterval(100*time.Millisecond))
+	ctx, err := l.Lock(context.Background())
+	assert.NoError(t, err)
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("the context is not canceled when the lock is lost")
+	}
+	assert.Equal(t, ErrLockNotHeld, l.Unlock(context.Background()))
+	// the renewal is stopped after the first refusal
+	store.mu.Lock()
+	assert.Equal(t, 2, store.tries)
+	store.mu.Unlock()
+
+	// it can be acquired again
+	_, err = l.Lock(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, l.Unlock(context.Background()))
+}
+
+func TestLock_parentDone(t *testing.T) {
+	store := newTestLocker(true)
+	l := newLock(store, "demo", "lock")
+	parent, cancel := context.WithCancel(context.Background())
+	ctx, err := l.Lock(parent)
+	assert.NoError(t, err)
+
+	cancel()
+	<-ctx.Done()
+	assert.Eventually(t, func() bool {
+		return store.owner("lock") == ""
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, ErrLockNotHeld, l.Unlock(context.Background()))
+}
+
+func TestNewLock(t *testing.T) {
+	l := testClient.NewLock("demo", "new_lock_test")
+	_, err := l.Lock(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, l.Unlock(context.Background()))
+}
@@ -0,0 +1,179 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	runtimev1pb "mosn.io/layotto/spec/proto/runtime/v1"
+)
+
+// serveTestRuntime serves a test runtime server on the listener
+func serveTestRuntime(lis net.Listener, opts ...grpc.ServerOption) func() {
+	s := grpc.NewServer(opts...)
+	runtimev1pb.RegisterRuntimeServer(s, &testRuntimeServer{
+		kv:         make(map[string]string),
+		subscribed: make(map[string]bool),
+		state:      make(map[string][]byte),
+		lock:       make(map[string]string),
+		files:      make(map[string][]byte),
+		uploads:    make(map[string]map[int64][]byte),
+	})
+	go s.Serve(lis)
+	return s.Stop
+}
+
+func TestNewClientWithUnixSocket(t *testing.T) {
+	_, err := NewClientWithUnixSocket("")
+	assert.Error(t, err)
+
+	dir, err := ioutil.TempDir("", "layotto")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "layotto.sock")
+	lis, err := net.Listen("unix", path)
+	assert.NoError(t, err)
+	defer serveTestRuntime(lis)()
+
+	var unaryCalls, streamCalls []string
+	c, err := NewClientWithUnixSocket(path,
+		WithKeepalive(10*time.Second, time.Second),
+		WithUnaryInterceptors(
+			func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+				unaryCalls = append(unaryCalls, "first "+method)
+				return invoker(ctx, method, req, reply, cc, opts...)
+			},
+			func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+				unaryCalls = append(unaryCalls, "second "+method)
+				return invoker(ctx, method, req, reply, cc, opts...)
+			}),
+		WithStreamInterceptors(
+			func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				streamCalls = append(streamCalls, method)
+				return streamer(ctx, desc, cc, method, opts...)
+			}))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	resp, err := c.SayHello(context.Background(), &SayHelloRequest{ServiceName: "helloworld"})
+	assert.NoError(t, err)
+	assert.Equal(t, "world", resp.Hello)
+	assert.Equal(t, []string{
+		"first /spec.proto.runtime.v1.Runtime/SayHello",
+		"second /spec.proto.runtime.v1.Runtime/SayHello",
+	}, unaryCalls)
+
+	for range c.SubscribeConfiguration(context.Background(), &ConfigurationRequestItem{StoreName: "etcd", Keys: []string{"hello"}}) {
+	}
+	assert.Equal(t, []string{"/spec.proto.runtime.v1.Runtime/SubscribeConfiguration"}, streamCalls)
+}
+
+// writeTestCert writes a self-signed certificate of 127.0.0.1 into dir
+func writeTestCert(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "layotto"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	assert.NoError(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return
+}
+
+func TestWithTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "layotto")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	certFile, keyFile := writeTestCert(t, dir)
+
+	// the server requires the client certificate
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	assert.NoError(t, err)
+	serverConfig, err := NewTLSConfig(certFile, "", "")
+	assert.NoError(t, err)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer serveTestRuntime(lis, grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    serverConfig.RootCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})))()
+
+	t.Run("mtls", func(t *testing.T) {
+		config, err := NewTLSConfig(certFile, certFile, keyFile)
+		assert.NoError(t, err)
+		c, err := NewClientWithAddress(lis.Addr().String(), WithTLS(config))
+		assert.NoError(t, err)
+		defer c.Close()
+		resp, err := c.SayHello(context.Background(), &SayHelloRequest{ServiceName: "helloworld"})
+		assert.NoError(t, err)
+		assert.Equal(t, "world", resp.Hello)
+	})
+
+	t.Run("no client certificate", func(t *testing.T) {
+		config, err := NewTLSConfig(certFile, "", "")
+		assert.NoError(t, err)
+		c, err := NewClientWithAddress(lis.Addr().String(), WithTLS(config))
+		assert.NoError(t, err)
+		defer c.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_, err = c.SayHello(ctx, &SayHelloRequest{ServiceName: "helloworld"})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid files", func(t *testing.T) {
+		_, err := NewTLSConfig(filepath.Join(dir, "none.pem"), "", "")
+		assert.Error(t, err)
+		_, err = NewTLSConfig(keyFile, "", "")
+		assert.Error(t, err)
+		_, err = NewTLSConfig("", certFile, "")
+		assert.Error(t, err)
+	})
+}
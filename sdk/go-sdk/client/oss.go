@@ -0,0 +1,208 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+
+	s3 "mosn.io/layotto/spec/proto/extension/v1/s3"
+)
+
+// DefaultPartSize is the part size used by UploadMultipart if it's not specified.
+// Most of the object storages require the parts except the last one to be at least 5MB.
+const DefaultPartSize = 5 * 1024 * 1024
+
+// abortTimeout bounds the abort of a failed multipart upload, which doesn't use the caller's context,
+// so that the upload is still aborted if the context is canceled.
+const abortTimeout = 10 * time.Second
+
+// PutObjectFromReader reads the data from r until EOF and uploads it as the object. The Body of the input is ignored.
+func (c *GRPCClient) PutObjectFromReader(ctx context.Context, in *s3.PutObjectInput, r io.Reader) (*s3.PutObjectOutput, error) {
+	if in.StoreName == "" || in.Bucket == "" || in.Key == "" {
+		return nil, errors.New("store name, bucket and key required")
+	}
+	// cancel the stream if the reader fails, so that the incomplete object is not committed
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cli, err := c.ObjectStorageServiceClient.PutObject(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error putting object %s", in.Key)
+	}
+	// the object is specified by the first message
+	first := proto.Clone(in).(*s3.PutObjectInput)
+	err = sendChunks(r, func(data []byte) error {
+		msg := &s3.PutObjectInput{Body: data}
+		if first != nil {
+			msg, first = first, nil
+			msg.Body = data
+		}
+		return cli.Send(msg)
+	})
+	// io.EOF means the stream is aborted by the server, and the error is returned by CloseAndRecv
+	if err != nil && err != io.EOF {
+		return nil, errors.Wrapf(err, "error putting object %s", in.Key)
+	}
+	out, err := cli.CloseAndRecv()
+	if err != nil {
+		return nil, errors.Wrapf(err, "error putting object %s", in.Key)
+	}
+	return out, nil
+}
+
+// GetObjectToWriter downloads the object and writes its data into w.
+// The returned output carries the attributes of the object without the Body.
+func (c *GRPCClient) GetObjectToWriter(ctx context.Context, in *s3.GetObjectInput, w io.Writer) (*s3.GetObjectOutput, error) {
+	if in.StoreName == "" || in.Bucket == "" || in.Key == "" {
+		return nil, errors.New("store name, bucket and key required")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cli, err := c.ObjectStorageServiceClient.GetObject(ctx, in)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting object %s", in.Key)
+	}
+	var out *s3.GetObjectOutput
+	for {
+		resp, err := cli.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting object %s", in.Key)
+		}
+		if _, err = w.Write(resp.Body); err != nil {
+			return nil, errors.WithMessage(err, "error writing object data")
+		}
+		if out == nil {
+			out = resp
+		}
+		resp.Body = nil
+	}
+	if out == nil {
+		// the object is empty
+		out = &s3.GetObjectOutput{}
+	}
+	return out, nil
+}
+
+// UploadMultipart uploads the data read from r as the object by a multipart upload, which is suitable for the large objects.
+// The data is split into the parts of partSize, DefaultPartSize is used if partSize is not positive.
+// The upload is aborted if any of the parts fails.
+func (c *GRPCClient) UploadMultipart(ctx context.Context, in *s3.CreateMultipartUploadInput, r io.Reader, partSize int64) (*s3.CompleteMultipartUploadOutput, error) {
+	if in.StoreName == "" || in.Bucket == "" || in.Key == "" {
+		return nil, errors.New("store name, bucket and key required")
+	}
+	if partSize <= 0 {
+		partSize = DefaultPartSize
+	}
+	created, err := c.ObjectStorageServiceClient.CreateMultipartUpload(ctx, in)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating multipart upload for %s", in.Key)
+	}
+	uploadId := created.UploadId
+
+	parts, err := c.uploadParts(ctx, in, uploadId, r, partSize)
+	if err != nil {
+		abortCtx, cancel := context.WithTimeout(context.Background(), abortTimeout)
+		_, abortErr := c.ObjectStorageServiceClient.AbortMultipartUpload(abortCtx, &s3.AbortMultipartUploadInput{
+			StoreName: in.StoreName,
+			Bucket:    in.Bucket,
+			Key:       in.Key,
+			UploadId:  uploadId,
+		})
+		cancel()
+		if abortErr != nil {
+			logger.Printf("abort multipart upload %s of %s error: %v", uploadId, in.Key, abortErr)
+		}
+		return nil, err
+	}
+
+	out, err := c.ObjectStorageServiceClient.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		StoreName:       in.StoreName,
+		Bucket:          in.Bucket,
+		Key:             in.Key,
+		UploadId:        uploadId,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error completing multipart upload for %s", in.Key)
+	}
+	return out, nil
+}
+
+func (c *GRPCClient) uploadParts(ctx context.Context, in *s3.CreateMultipartUploadInput, uploadId string, r io.Reader, partSize int64) ([]*s3.CompletedPart, error) {
+	var parts []*s3.CompletedPart
+	buf := make([]byte, partSize)
+	for partNumber := int32(1); ; partNumber++ {
+		n, err := io.ReadFull(r, buf)
+		if err == io.EOF && len(parts) > 0 {
+			return parts, nil
+		}
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, errors.WithMessage(err, "error reading data")
+		}
+		// the first part is uploaded even if it's empty
+		etag, uploadErr := c.uploadPart(ctx, &s3.UploadPartInput{
+			StoreName:     in.StoreName,
+			Bucket:        in.Bucket,
+			Key:           in.Key,
+			UploadId:      uploadId,
+			PartNumber:    partNumber,
+			ContentLength: int64(n),
+		}, buf[:n])
+		if uploadErr != nil {
+			return nil, errors.Wrapf(uploadErr, "error uploading part %d of %s", partNumber, in.Key)
+		}
+		parts = append(parts, &s3.CompletedPart{Etag: etag, PartNumber: partNumber})
+		if err != nil {
+			// the last part
+			return parts, nil
+		}
+	}
+}
+
+func (c *GRPCClient) uploadPart(ctx context.Context, in *s3.UploadPartInput, data []byte) (string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cli, err := c.ObjectStorageServiceClient.UploadPart(ctx)
+	if err != nil {
+		return "", err
+	}
+	first := in
+	err = sendChunks(bytes.NewReader(data), func(data []byte) error {
+		msg := &s3.UploadPartInput{Body: data}
+		if first != nil {
+			msg, first = first, nil
+			msg.Body = data
+		}
+		return cli.Send(msg)
+	})
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	out, err := cli.CloseAndRecv()
+	if err != nil {
+		return "", err
+	}
+	return out.Etag, nil
+}
@@ -0,0 +1,236 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	s3 "mosn.io/layotto/spec/proto/extension/v1/s3"
+)
+
+type testOssServer struct {
+	s3.UnimplementedObjectStorageServiceServer
+	mu      sync.Mutex
+	objects map[string][]byte
+	// the parts of the multipart uploads, by the upload id
+	uploads  map[string]map[int32][]byte
+	uploadId int
+	aborted  []string
+}
+
+func (s *testOssServer) PutObject(srv s3.ObjectStorageService_PutObjectServer) error {
+	var key string
+	var data []byte
+	for {
+		req, err := srv.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if req.Key != "" {
+			key = req.Key
+		}
+		data = append(data, req.Body...)
+	}
+	s.mu.Lock()
+	s.objects[key] = data
+	s.mu.Unlock()
+	return srv.SendAndClose(&s3.PutObjectOutput{Etag: fmt.Sprintf("etag-%d", len(data))})
+}
+
+func (s *testOssServer) GetObject(req *s3.GetObjectInput, srv s3.ObjectStorageService_GetObjectServer) error {
+	s.mu.Lock()
+	data, ok := s.objects[req.Key]
+	s.mu.Unlock()
+	if !ok {
+		return status.Errorf(codes.NotFound, "object %s not found", req.Key)
+	}
+	for len(data) > 0 {
+		n := 4
+		if n > len(data) {
+			n = len(data)
+		}
+		if err := srv.Send(&s3.GetObjectOutput{Body: data[:n], ContentType: "text/plain"}); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+func (s *testOssServer) CreateMultipartUpload(ctx context.Context, req *s3.CreateMultipartUploadInput) (*s3.CreateMultipartUploadOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.uploadId++
+	id := fmt.Sprintf("upload-%d", s.uploadId)
+	s.uploads[id] = make(map[int32][]byte)
+	return &s3.CreateMultipartUploadOutput{Bucket: req.Bucket, Key: req.Key, UploadId: id}, nil
+}
+
+func (s *testOssServer) UploadPart(srv s3.ObjectStorageService_UploadPartServer) error {
+	var uploadId string
+	var partNumber int32
+	var data []byte
+	for {
+		req, err := srv.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if req.UploadId != "" {
+			uploadId, partNumber = req.UploadId, req.PartNumber
+		}
+		data = append(data, req.Body...)
+	}
+	if strings.Contains(string(data), "fail") {
+		return status.Error(codes.Internal, "upload part failed")
+	}
+	s.mu.Lock()
+	s.uploads[uploadId][partNumber] = data
+	s.mu.Unlock()
+	return srv.SendAndClose(&s3.UploadPartOutput{Etag: fmt.Sprintf("%s-%d", uploadId, partNumber)})
+}
+
+func (s *testOssServer) CompleteMultipartUpload(ctx context.Context, req *s3.CompleteMultipartUploadInput) (*s3.CompleteMultipartUploadOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	parts := req.MultipartUpload.Parts
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].PartNumber < parts[j].PartNumber
+	})
+	var data []byte
+	for _, p := range parts {
+		if p.Etag != fmt.Sprintf("%s-%d", req.UploadId, p.PartNumber) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid etag %s", p.Etag)
+		}
+		data = append(data, s.uploads[req.UploadId][p.PartNumber]...)
+	}
+	s.objects[req.Key] = data
+	delete(s.uploads, req.UploadId)
+	return &s3.CompleteMultipartUploadOutput{Bucket: req.Bucket, Key: req.Key, Etag: req.UploadId}, nil
+}
+
+func (s *testOssServer) AbortMultipartUpload(ctx context.Context, req *s3.AbortMultipartUploadInput) (*s3.AbortMultipartUploadOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.uploads, req.UploadId)
+	s.aborted = append(s.aborted, req.UploadId)
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func TestPutAndGetObject(t *testing.T) {
+	ctx := context.Background()
+	data := bytes.Repeat([]byte("abc"), fileChunkSize/3+1)
+	in := &s3.PutObjectInput{StoreName: "oss", Bucket: "bucket", Key: "a.txt"}
+	out, err := testClient.PutObjectFromReader(ctx, in, bytes.NewReader(data))
+	assert.Nil(t, err)
+	assert.Equal(t, fmt.Sprintf("etag-%d", len(data)), out.Etag)
+	// the input is not changed
+	assert.Nil(t, in.Body)
+
+	var buf bytes.Buffer
+	got, err := testClient.GetObjectToWriter(ctx, &s3.GetObjectInput{StoreName: "oss", Bucket: "bucket", Key: "a.txt"}, &buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "text/plain", got.ContentType)
+	assert.Nil(t, got.Body)
+	assert.Equal(t, data, buf.Bytes())
+
+	_, err = testClient.GetObjectToWriter(ctx, &s3.GetObjectInput{StoreName: "oss", Bucket: "bucket", Key: "not_exist"}, &buf)
+	assert.Error(t, err)
+	_, err = testClient.PutObjectFromReader(ctx, &s3.PutObjectInput{StoreName: "oss", Bucket: "bucket"}, &buf)
+	assert.Error(t, err)
+}
+
+func TestUploadMultipart(t *testing.T) {
+	ctx := context.Background()
+	in := &s3.CreateMultipartUploadInput{StoreName: "oss", Bucket: "bucket", Key: "large.txt"}
+
+	t.Run("upload by parts", func(t *testing.T) {
+		data := "0123456789abcdefghij0"
+		out, err := testClient.UploadMultipart(ctx, in, strings.NewReader(data), 10)
+		assert.Nil(t, err)
+		assert.Equal(t, "large.txt", out.Key)
+
+		var buf bytes.Buffer
+		_, err = testClient.GetObjectToWriter(ctx, &s3.GetObjectInput{StoreName: "oss", Bucket: "bucket", Key: "large.txt"}, &buf)
+		assert.Nil(t, err)
+		assert.Equal(t, data, buf.String())
+	})
+
+	t.Run("the size is a multiple of the part size", func(t *testing.T) {
+		data := "0123456789abcdefghij"
+		_, err := testClient.UploadMultipart(ctx, in, strings.NewReader(data), 10)
+		assert.Nil(t, err)
+
+		var buf bytes.Buffer
+		_, err = testClient.GetObjectToWriter(ctx, &s3.GetObjectInput{StoreName: "oss", Bucket: "bucket", Key: "large.txt"}, &buf)
+		assert.Nil(t, err)
+		assert.Equal(t, data, buf.String())
+	})
+
+	t.Run("empty object", func(t *testing.T) {
+		_, err := testClient.UploadMultipart(ctx, in, strings.NewReader(""), 0)
+		assert.Nil(t, err)
+	})
+
+	t.Run("abort if a part fails", func(t *testing.T) {
+		_, err := testClient.UploadMultipart(ctx, in, strings.NewReader("0123456789fail"), 10)
+		assert.Error(t, err)
+		server := testOssInstance
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		assert.Len(t, server.aborted, 1)
+		assert.Len(t, server.uploads, 0)
+	})
+
+	t.Run("abort if the context is canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		_, err := testClient.UploadMultipart(ctx, in, &cancelReader{cancel: cancel}, 10)
+		assert.Error(t, err)
+		server := testOssInstance
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		assert.Len(t, server.aborted, 2)
+		assert.Len(t, server.uploads, 0)
+	})
+}
+
+// cancelReader cancels the context when it's read
+type cancelReader struct {
+	cancel context.CancelFunc
+}
+
+func (r *cancelReader) Read(p []byte) (int, error) {
+	r.cancel()
+	return 0, context.Canceled
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"mime"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	runtimev1pb "mosn.io/layotto/spec/proto/runtime/v1"
+)
+
+const cloudEventsContentType = "application/cloudevents+json"
+
+// TopicEvent is the event delivered to the topic handlers, which is compatible with CloudEvent spec v1.0
+type TopicEvent struct {
+	// ID identifies the event.
+	ID string
+	// Source identifies the context in which an event happened.
+	Source string
+	// Type of the event related to the originating occurrence.
+	Type string
+	// SpecVersion of the CloudEvents specification.
+	SpecVersion string
+	// DataContentType is the content type of the data.
+	DataContentType string
+	// Data of the event.
+	Data []byte
+	// Topic which publisher sent to.
+	Topic string
+	// PubsubName is the name of the pubsub the publisher sent to.
+	PubsubName string
+	// Metadata of the event.
+	Metadata map[string]string
+}
+
+func newTopicEvent(in *runtimev1pb.TopicEventRequest) (*TopicEvent, error) {
+	e := &TopicEvent{
+		ID:              in.Id,
+		Source:          in.Source,
+		Type:            in.Type,
+		SpecVersion:     in.SpecVersion,
+		DataContentType: in.DataContentType,
+		Data:            in.Data,
+		Topic:           in.Topic,
+		PubsubName:      in.PubsubName,
+		Metadata:        in.Metadata,
+	}
+	if mediaType(e.DataContentType) == cloudEventsContentType {
+		if err := e.unwrapCloudEvent(); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// cloudEvent is the JSON envelope of the CloudEvent spec v1.0
+type cloudEvent struct {
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	SpecVersion     string          `json:"specversion"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+	DataBase64      string          `json:"data_base64"`
+}
+
+// unwrapCloudEvent replaces the event with the CloudEvent carried in the data
+func (e *TopicEvent) unwrapCloudEvent() error {
+	var ce cloudEvent
+	if err := json.Unmarshal(e.Data, &ce); err != nil {
+		return errors.Wrap(err, "error decoding cloud event")
+	}
+	e.ID, e.Source, e.Type, e.SpecVersion, e.DataContentType = ce.ID, ce.Source, ce.Type, ce.SpecVersion, ce.DataContentType
+	switch {
+	case ce.DataBase64 != "":
+		data, err := base64.StdEncoding.DecodeString(ce.DataBase64)
+		if err != nil {
+			return errors.Wrap(err, "error decoding data_base64 of cloud event")
+		}
+		e.Data = data
+	case len(ce.Data) == 0 || string(ce.Data) == "null":
+		e.Data = nil
+	case !isJSON(e.DataContentType):
+		// the data of the other content types is a JSON string
+		var s string
+		if err := json.Unmarshal(ce.Data, &s); err != nil {
+			e.Data = ce.Data
+		} else {
+			e.Data = []byte(s)
+		}
+	default:
+		e.Data = ce.Data
+	}
+	return nil
+}
+
+// Decode decodes the data into v. The data is assigned directly if v is *[]byte or *string,
+// otherwise it's decoded as JSON, which is the only content type supported for now.
+func (e *TopicEvent) Decode(v interface{}) error {
+	switch out := v.(type) {
+	case *[]byte:
+		*out = e.Data
+		return nil
+	case *string:
+		*out = string(e.Data)
+		return nil
+	}
+	if !isJSON(e.DataContentType) {
+		return errors.Errorf("unsupported content type %s", e.DataContentType)
+	}
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return errors.Wrap(err, "error decoding event data")
+	}
+	return nil
+}
+
+func mediaType(contentType string) string {
+	t, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(contentType)
+	}
+	return t
+}
+
+// isJSON returns whether the content type is JSON. The data is considered as JSON if the content type is not set.
+func isJSON(contentType string) bool {
+	t := mediaType(contentType)
+	return t == "" || t == "application/json" || t == "text/json" || strings.HasSuffix(t, "+json")
+}
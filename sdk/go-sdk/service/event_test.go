@@ -0,0 +1,107 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	runtimev1pb "mosn.io/layotto/spec/proto/runtime/v1"
+)
+
+func TestNewTopicEvent(t *testing.T) {
+	t.Run("raw data", func(t *testing.T) {
+		e, err := newTopicEvent(&runtimev1pb.TopicEventRequest{
+			Id:              "1",
+			Source:          "app",
+			DataContentType: "application/json",
+			Data:            []byte(`{"id":"o1"}`),
+			Topic:           "orders",
+			PubsubName:      "redis",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "1", e.ID)
+		assert.Equal(t, "app", e.Source)
+		assert.Equal(t, `{"id":"o1"}`, string(e.Data))
+		assert.Equal(t, "orders", e.Topic)
+		assert.Equal(t, "redis", e.PubsubName)
+	})
+
+	tests := []struct {
+		name        string
+		data        string
+		contentType string
+		expected    string
+	}{
+		{"json data", `{"id":"1","source":"app","type":"t","specversion":"1.0","datacontenttype":"application/json","data":{"id":"o1"}}`, "application/json", `{"id":"o1"}`},
+		{"text data", `{"id":"1","source":"app","type":"t","specversion":"1.0","datacontenttype":"text/plain","data":"hello"}`, "text/plain", "hello"},
+		{"base64 data", `{"id":"1","source":"app","type":"t","specversion":"1.0","datacontenttype":"application/octet-stream","data_base64":"aGVsbG8="}`, "application/octet-stream", "hello"},
+		{"null data", `{"id":"1","source":"app","type":"t","specversion":"1.0","data":null}`, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := newTopicEvent(&runtimev1pb.TopicEventRequest{
+				Id:              "outer",
+				DataContentType: "application/cloudevents+json; charset=utf-8",
+				Data:            []byte(tt.data),
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, "1", e.ID)
+			assert.Equal(t, "app", e.Source)
+			assert.Equal(t, "t", e.Type)
+			assert.Equal(t, "1.0", e.SpecVersion)
+			assert.Equal(t, tt.contentType, e.DataContentType)
+			assert.Equal(t, tt.expected, string(e.Data))
+		})
+	}
+
+	t.Run("invalid base64 data", func(t *testing.T) {
+		_, err := newTopicEvent(&runtimev1pb.TopicEventRequest{
+			DataContentType: cloudEventsContentType,
+			Data:            []byte(`{"data_base64":"!"}`),
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestTopicEvent_Decode(t *testing.T) {
+	e := &TopicEvent{DataContentType: "application/json", Data: []byte(`{"id":"o1","count":2}`)}
+
+	var o order
+	assert.NoError(t, e.Decode(&o))
+	assert.Equal(t, order{ID: "o1", Count: 2}, o)
+
+	var s string
+	assert.NoError(t, e.Decode(&s))
+	assert.Equal(t, `{"id":"o1","count":2}`, s)
+
+	var b []byte
+	assert.NoError(t, e.Decode(&b))
+	assert.Equal(t, e.Data, b)
+
+	// the data is JSON if the content type is not set
+	e.DataContentType = ""
+	assert.NoError(t, e.Decode(&o))
+
+	e.DataContentType = "text/plain"
+	assert.Error(t, e.Decode(&o))
+
+	e.DataContentType = "application/json"
+	e.Data = []byte("{")
+	assert.Error(t, e.Decode(&o))
+}
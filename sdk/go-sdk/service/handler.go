@@ -0,0 +1,108 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	runtimev1pb "mosn.io/layotto/spec/proto/runtime/v1"
+)
+
+// statusError carries the status returned to Layotto
+type statusError struct {
+	status runtimev1pb.TopicEventResponse_TopicEventResponseStatus
+	err    error
+}
+
+func (e *statusError) Error() string {
+	if e.err == nil {
+		return e.status.String()
+	}
+	return e.status.String() + ": " + e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+// Retry wraps the error returned by the handler, so that Layotto retries the event without a warning.
+// It's the same as returning the error directly.
+func Retry(err error) error {
+	return &statusError{status: runtimev1pb.TopicEventResponse_RETRY, err: err}
+}
+
+// Drop wraps the error returned by the handler, so that Layotto drops the event with a warning.
+// It's used when the event can never be handled, e.g. it's malformed.
+func Drop(err error) error {
+	return &statusError{status: runtimev1pb.TopicEventResponse_DROP, err: err}
+}
+
+// statusOf maps the error returned by the handler to the status: nil is SUCCESS,
+// the error wrapped by Drop is DROP, and the others are RETRY.
+func statusOf(err error) runtimev1pb.TopicEventResponse_TopicEventResponseStatus {
+	if err == nil {
+		return runtimev1pb.TopicEventResponse_SUCCESS
+	}
+	// the error may be wrapped again by the handler
+	for e := err; e != nil; {
+		if se, ok := e.(*statusError); ok {
+			return se.status
+		}
+		u, ok := e.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+		e = u.Unwrap()
+	}
+	return runtimev1pb.TopicEventResponse_RETRY
+}
+
+var (
+	contextType    = reflect.TypeOf((*context.Context)(nil)).Elem()
+	topicEventType = reflect.TypeOf((*TopicEvent)(nil))
+	errorType      = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// TypedHandler converts fn into a TopicEventHandler which decodes the data of the event before calling fn.
+// fn must be like:
+//
+//	func(ctx context.Context, e *TopicEvent, data *T) error
+//
+// where a new T is decoded for every event by TopicEvent.Decode. The event is dropped if it can't be decoded.
+// It panics if fn is not a function like that.
+func TypedHandler(fn interface{}) TopicEventHandler {
+	v := reflect.ValueOf(fn)
+	t := v.Type()
+	if t.Kind() != reflect.Func || t.NumIn() != 3 || t.NumOut() != 1 ||
+		t.In(0) != contextType || t.In(1) != topicEventType || t.In(2).Kind() != reflect.Ptr || t.Out(0) != errorType {
+		panic(fmt.Sprintf("invalid typed handler %s, it should be like func(context.Context, *TopicEvent, *T) error", t))
+	}
+	dataType := t.In(2).Elem()
+	return func(ctx context.Context, e *TopicEvent) error {
+		data := reflect.New(dataType)
+		if err := e.Decode(data.Interface()); err != nil {
+			return Drop(err)
+		}
+		out := v.Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(e), data})
+		if err, _ := out[0].Interface().(error); err != nil {
+			return err
+		}
+		return nil
+	}
+}
@@ -0,0 +1,219 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package service provides the app-side callback server which receives the pubsub events from Layotto.
+//
+//	s, err := service.NewService(":9999")
+//	s.AddTopicEventHandler(&service.Subscription{PubsubName: "redis", Topic: "orders"},
+//		service.TypedHandler(func(ctx context.Context, e *service.TopicEvent, order *Order) error {
+//			...
+//		}))
+//	s.Start()
+package service
+
+import (
+	"context"
+	"log"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	runtimev1pb "mosn.io/layotto/spec/proto/runtime/v1"
+)
+
+var logger = log.New(os.Stdout, "", 0)
+
+// Subscription is a topic subscribed by the app.
+type Subscription struct {
+	// PubsubName is the name of the pubsub component.
+	PubsubName string
+	// Topic to subscribe.
+	Topic string
+	// Metadata is the properties used for this subscription.
+	Metadata map[string]string
+}
+
+func (s *Subscription) key() string {
+	return s.PubsubName + "/" + s.Topic
+}
+
+// TopicEventHandler handles the events of a topic.
+// The event is acknowledged if it returns nil, see Retry and Drop for the errors.
+type TopicEventHandler func(ctx context.Context, e *TopicEvent) error
+
+type topicHandler struct {
+	sub     *Subscription
+	handler TopicEventHandler
+}
+
+// Service is the callback server of the app, which implements the AppCallback service.
+type Service struct {
+	runtimev1pb.UnimplementedAppCallbackServer
+	listener net.Listener
+	server   *grpc.Server
+
+	mu       sync.RWMutex
+	handlers map[string]*topicHandler
+	// the order the subscriptions are added
+	subscriptions []*Subscription
+	stopped       bool
+}
+
+// NewService creates a service listening on address, e.g. ":9999".
+func NewService(address string, opts ...grpc.ServerOption) (*Service, error) {
+	if address == "" {
+		return nil, errors.New("nil address")
+	}
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to listen on %s", address)
+	}
+	return NewServiceWithListener(lis, opts...), nil
+}
+
+// NewServiceWithListener creates a service serving on the listener.
+func NewServiceWithListener(lis net.Listener, opts ...grpc.ServerOption) *Service {
+	return NewServiceWithGrpcServer(lis, grpc.NewServer(opts...))
+}
+
+// NewServiceWithGrpcServer creates a service registered on the grpc server, so that the server can serve the other services as well.
+func NewServiceWithGrpcServer(lis net.Listener, server *grpc.Server) *Service {
+	s := &Service{
+		listener: lis,
+		server:   server,
+		handlers: make(map[string]*topicHandler),
+	}
+	runtimev1pb.RegisterAppCallbackServer(server, s)
+	return s
+}
+
+// AddTopicEventHandler subscribes the topic with the handler. It should be called before Start.
+func (s *Service) AddTopicEventHandler(sub *Subscription, handler TopicEventHandler) error {
+	if sub == nil || sub.PubsubName == "" || sub.Topic == "" {
+		return errors.New("pubsub name and topic required")
+	}
+	if handler == nil {
+		return errors.New("nil handler")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.handlers[sub.key()]; ok {
+		return errors.Errorf("topic %s of pubsub %s is already subscribed", sub.Topic, sub.PubsubName)
+	}
+	s.handlers[sub.key()] = &topicHandler{sub: sub, handler: handler}
+	s.subscriptions = append(s.subscriptions, sub)
+	return nil
+}
+
+// Start serves the callbacks from Layotto. It blocks until the service is stopped.
+func (s *Service) Start() error {
+	err := s.server.Serve(s.listener)
+	if err == grpc.ErrServerStopped {
+		return nil
+	}
+	return err
+}
+
+// Stop stops the service immediately, the events being handled are redelivered by Layotto.
+func (s *Service) Stop() {
+	s.markStopped()
+	s.server.Stop()
+}
+
+// GracefulStop stops accepting new events, and waits for the events being handled until ctx is done.
+// The service is stopped immediately when ctx is done, and ctx.Err() is returned.
+func (s *Service) GracefulStop(ctx context.Context) error {
+	s.markStopped()
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (s *Service) markStopped() {
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+}
+
+// ListTopicSubscriptions lists the topics subscribed by AddTopicEventHandler.
+func (s *Service) ListTopicSubscriptions(ctx context.Context, in *empty.Empty) (*runtimev1pb.ListTopicSubscriptionsResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	resp := &runtimev1pb.ListTopicSubscriptionsResponse{}
+	for _, sub := range s.subscriptions {
+		resp.Subscriptions = append(resp.Subscriptions, &runtimev1pb.TopicSubscription{
+			PubsubName: sub.PubsubName,
+			Topic:      sub.Topic,
+			Metadata:   sub.Metadata,
+		})
+	}
+	return resp, nil
+}
+
+// OnTopicEvent dispatches the event to the handler of its topic.
+func (s *Service) OnTopicEvent(ctx context.Context, in *runtimev1pb.TopicEventRequest) (*runtimev1pb.TopicEventResponse, error) {
+	if in == nil || in.PubsubName == "" || in.Topic == "" {
+		return nil, status.Error(codes.InvalidArgument, "pubsub name and topic required")
+	}
+	s.mu.RLock()
+	h, ok := s.handlers[(&Subscription{PubsubName: in.PubsubName, Topic: in.Topic}).key()]
+	stopped := s.stopped
+	s.mu.RUnlock()
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "topic %s of pubsub %s is not subscribed", in.Topic, in.PubsubName)
+	}
+	// the events arriving while stopping are redelivered later
+	if stopped {
+		return &runtimev1pb.TopicEventResponse{Status: runtimev1pb.TopicEventResponse_RETRY}, nil
+	}
+	e, err := newTopicEvent(in)
+	if err != nil {
+		logger.Printf("drop the event %s of topic %s: %v", in.Id, in.Topic, err)
+		return &runtimev1pb.TopicEventResponse{Status: runtimev1pb.TopicEventResponse_DROP}, nil
+	}
+	return &runtimev1pb.TopicEventResponse{Status: s.handle(ctx, h, e)}, nil
+}
+
+func (s *Service) handle(ctx context.Context, h *topicHandler, e *TopicEvent) (st runtimev1pb.TopicEventResponse_TopicEventResponseStatus) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Printf("panic when handling the event %s of topic %s: %v", e.ID, e.Topic, r)
+			st = runtimev1pb.TopicEventResponse_RETRY
+		}
+	}()
+	err := h.handler(ctx, e)
+	st = statusOf(err)
+	if st == runtimev1pb.TopicEventResponse_DROP {
+		logger.Printf("drop the event %s of topic %s: %v", e.ID, e.Topic, err)
+	}
+	return st
+}
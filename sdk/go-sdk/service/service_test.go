@@ -0,0 +1,285 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	empty "google.golang.org/protobuf/types/known/emptypb"
+
+	runtimev1pb "mosn.io/layotto/spec/proto/runtime/v1"
+)
+
+const testBufSize = 1024 * 1024
+
+type order struct {
+	ID    string `json:"id"`
+	Count int    `json:"count"`
+}
+
+// startTestService starts the service on a bufconn listener, and returns the client calling it
+func startTestService(t *testing.T, s *Service, lis *bufconn.Listener) (runtimev1pb.AppCallbackClient, func()) {
+	go func() {
+		assert.NoError(t, s.Start())
+	}()
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+			return lis.Dial()
+		}), grpc.WithInsecure())
+	assert.NoError(t, err)
+	return runtimev1pb.NewAppCallbackClient(conn), func() {
+		conn.Close()
+		s.Stop()
+	}
+}
+
+func newTestService(t *testing.T) (*Service, runtimev1pb.AppCallbackClient, func()) {
+	lis := bufconn.Listen(testBufSize)
+	s := NewServiceWithListener(lis)
+	c, closer := startTestService(t, s, lis)
+	return s, c, closer
+}
+
+func TestNewService(t *testing.T) {
+	_, err := NewService("")
+	assert.Error(t, err)
+
+	s, err := NewService("127.0.0.1:0")
+	assert.NoError(t, err)
+	done := make(chan error)
+	go func() {
+		done <- s.Start()
+	}()
+	s.Stop()
+	assert.NoError(t, <-done)
+}
+
+func TestAddTopicEventHandler(t *testing.T) {
+	s := NewServiceWithListener(bufconn.Listen(testBufSize))
+	handler := func(ctx context.Context, e *TopicEvent) error { return nil }
+
+	assert.Error(t, s.AddTopicEventHandler(nil, handler))
+	assert.Error(t, s.AddTopicEventHandler(&Subscription{PubsubName: "redis"}, handler))
+	assert.Error(t, s.AddTopicEventHandler(&Subscription{PubsubName: "redis", Topic: "orders"}, nil))
+	assert.NoError(t, s.AddTopicEventHandler(&Subscription{PubsubName: "redis", Topic: "orders"}, handler))
+	assert.Error(t, s.AddTopicEventHandler(&Subscription{PubsubName: "redis", Topic: "orders"}, handler))
+}
+
+func TestListTopicSubscriptions(t *testing.T) {
+	s, c, closer := newTestService(t)
+	defer closer()
+	handler := func(ctx context.Context, e *TopicEvent) error { return nil }
+	assert.NoError(t, s.AddTopicEventHandler(&Subscription{PubsubName: "redis", Topic: "orders"}, handler))
+	assert.NoError(t, s.AddTopicEventHandler(&Subscription{
+		PubsubName: "kafka",
+		Topic:      "users",
+		Metadata:   map[string]string{"k": "v"},
+	}, handler))
+
+	resp, err := c.ListTopicSubscriptions(context.Background(), &empty.Empty{})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Subscriptions, 2)
+	assert.Equal(t, "redis", resp.Subscriptions[0].PubsubName)
+	assert.Equal(t, "orders", resp.Subscriptions[0].Topic)
+	assert.Equal(t, "kafka", resp.Subscriptions[1].PubsubName)
+	assert.Equal(t, "users", resp.Subscriptions[1].Topic)
+	assert.Equal(t, "v", resp.Subscriptions[1].Metadata["k"])
+}
+
+func TestOnTopicEvent(t *testing.T) {
+	s, c, closer := newTestService(t)
+	defer closer()
+	ctx := context.Background()
+	var received *TopicEvent
+	assert.NoError(t, s.AddTopicEventHandler(&Subscription{PubsubName: "redis", Topic: "orders"},
+		func(ctx context.Context, e *TopicEvent) error {
+			received = e
+			switch string(e.Data) {
+			case "retry":
+				return errors.New("retry")
+			case "drop":
+				return Drop(errors.New("drop"))
+			case "wrapped drop":
+				return fmt.Errorf("wrapped: %w", Drop(errors.New("drop")))
+			case "panic":
+				panic("panic")
+			}
+			return nil
+		}))
+	request := func(data string) *runtimev1pb.TopicEventRequest {
+		return &runtimev1pb.TopicEventRequest{
+			Id:              "1",
+			PubsubName:      "redis",
+			Topic:           "orders",
+			DataContentType: "text/plain",
+			Data:            []byte(data),
+			Metadata:        map[string]string{"k": "v"},
+		}
+	}
+
+	t.Run("invalid request", func(t *testing.T) {
+		_, err := c.OnTopicEvent(ctx, &runtimev1pb.TopicEventRequest{PubsubName: "redis"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("unknown topic", func(t *testing.T) {
+		_, err := c.OnTopicEvent(ctx, &runtimev1pb.TopicEventRequest{PubsubName: "redis", Topic: "users"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	tests := []struct {
+		data   string
+		status runtimev1pb.TopicEventResponse_TopicEventResponseStatus
+	}{
+		{"ok", runtimev1pb.TopicEventResponse_SUCCESS},
+		{"retry", runtimev1pb.TopicEventResponse_RETRY},
+		{"drop", runtimev1pb.TopicEventResponse_DROP},
+		{"wrapped drop", runtimev1pb.TopicEventResponse_DROP},
+		{"panic", runtimev1pb.TopicEventResponse_RETRY},
+	}
+	for _, tt := range tests {
+		t.Run(tt.data, func(t *testing.T) {
+			resp, err := c.OnTopicEvent(ctx, request(tt.data))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.Status)
+			assert.Equal(t, "1", received.ID)
+			assert.Equal(t, tt.data, string(received.Data))
+			assert.Equal(t, "v", received.Metadata["k"])
+		})
+	}
+
+	t.Run("malformed cloud event", func(t *testing.T) {
+		in := request("{")
+		in.DataContentType = cloudEventsContentType
+		resp, err := c.OnTopicEvent(ctx, in)
+		assert.NoError(t, err)
+		assert.Equal(t, runtimev1pb.TopicEventResponse_DROP, resp.Status)
+	})
+}
+
+func TestOnTopicEvent_typed(t *testing.T) {
+	s, c, closer := newTestService(t)
+	defer closer()
+	var received *order
+	assert.NoError(t, s.AddTopicEventHandler(&Subscription{PubsubName: "redis", Topic: "orders"},
+		TypedHandler(func(ctx context.Context, e *TopicEvent, o *order) error {
+			received = o
+			return nil
+		})))
+
+	resp, err := c.OnTopicEvent(context.Background(), &runtimev1pb.TopicEventRequest{
+		PubsubName:      "redis",
+		Topic:           "orders",
+		DataContentType: cloudEventsContentType,
+		Data:            []byte(`{"id":"1","specversion":"1.0","datacontenttype":"application/json","data":{"id":"o1","count":2}}`),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, runtimev1pb.TopicEventResponse_SUCCESS, resp.Status)
+	assert.Equal(t, &order{ID: "o1", Count: 2}, received)
+
+	// the data can't be decoded
+	resp, err = c.OnTopicEvent(context.Background(), &runtimev1pb.TopicEventRequest{
+		PubsubName:      "redis",
+		Topic:           "orders",
+		DataContentType: "application/json",
+		Data:            []byte(`"o1"`),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, runtimev1pb.TopicEventResponse_DROP, resp.Status)
+}
+
+func TestTypedHandler_invalid(t *testing.T) {
+	assert.Panics(t, func() { TypedHandler("handler") })
+	assert.Panics(t, func() { TypedHandler(func(ctx context.Context, e *TopicEvent) error { return nil }) })
+	assert.Panics(t, func() { TypedHandler(func(ctx context.Context, e *TopicEvent, o order) error { return nil }) })
+	assert.Panics(t, func() { TypedHandler(func(ctx context.Context, e *TopicEvent, o *order) {}) })
+}
+
+func TestGracefulStop(t *testing.T) {
+	lis := bufconn.Listen(testBufSize)
+	s := NewServiceWithListener(lis)
+	handling := make(chan struct{})
+	release := make(chan struct{})
+	assert.NoError(t, s.AddTopicEventHandler(&Subscription{PubsubName: "redis", Topic: "orders"},
+		func(ctx context.Context, e *TopicEvent) error {
+			if string(e.Data) == "slow" {
+				close(handling)
+				<-release
+			}
+			return nil
+		}))
+	c, closer := startTestService(t, s, lis)
+	defer closer()
+
+	done := make(chan *runtimev1pb.TopicEventResponse)
+	go func() {
+		resp, err := c.OnTopicEvent(context.Background(), &runtimev1pb.TopicEventRequest{
+			PubsubName: "redis", Topic: "orders", Data: []byte("slow"),
+		})
+		assert.NoError(t, err)
+		done <- resp
+	}()
+	<-handling
+
+	stopped := make(chan error)
+	go func() {
+		stopped <- s.GracefulStop(context.Background())
+	}()
+	// the events arriving while stopping are retried
+	time.Sleep(50 * time.Millisecond)
+	resp, err := s.OnTopicEvent(context.Background(), &runtimev1pb.TopicEventRequest{
+		PubsubName: "redis", Topic: "orders",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, runtimev1pb.TopicEventResponse_RETRY, resp.Status)
+
+	close(release)
+	assert.Equal(t, runtimev1pb.TopicEventResponse_SUCCESS, (<-done).Status)
+	assert.NoError(t, <-stopped)
+}
+
+func TestGracefulStop_timeout(t *testing.T) {
+	lis := bufconn.Listen(testBufSize)
+	s := NewServiceWithListener(lis)
+	handling := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	assert.NoError(t, s.AddTopicEventHandler(&Subscription{PubsubName: "redis", Topic: "orders"},
+		func(ctx context.Context, e *TopicEvent) error {
+			close(handling)
+			<-release
+			return nil
+		}))
+	c, closer := startTestService(t, s, lis)
+	defer closer()
+	go c.OnTopicEvent(context.Background(), &runtimev1pb.TopicEventRequest{PubsubName: "redis", Topic: "orders"})
+	<-handling
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, s.GracefulStop(ctx))
+}
@@ -68,6 +68,14 @@ type RuntimeClient interface {
 	DelFile(ctx context.Context, in *DelFileRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// Get file meta data, if file not exist,return code.NotFound error
 	GetFileMeta(ctx context.Context, in *GetFileMetaRequest, opts ...grpc.CallOption) (*GetFileMetaResponse, error)
+	// Starts a resumable upload session of the file, or resumes the session if upload_id is specified
+	InitFileUpload(ctx context.Context, in *InitFileUploadRequest, opts ...grpc.CallOption) (*InitFileUploadResponse, error)
+	// Uploads a chunk of the file at the offset with stream
+	PutFileChunk(ctx context.Context, opts ...grpc.CallOption) (Runtime_PutFileChunkClient, error)
+	// Composes the uploaded chunks into the file
+	CommitFileUpload(ctx context.Context, in *FileUploadRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Discards the uploaded chunks
+	AbortFileUpload(ctx context.Context, in *FileUploadRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// Invokes binding data to specific output bindings
 	InvokeBinding(ctx context.Context, in *InvokeBindingRequest, opts ...grpc.CallOption) (*InvokeBindingResponse, error)
 	// Gets secrets from secret stores.
@@ -343,6 +351,67 @@ func (c *runtimeClient) GetFileMeta(ctx context.Context, in *GetFileMetaRequest,
 	return out, nil
 }
 
+func (c *runtimeClient) InitFileUpload(ctx context.Context, in *InitFileUploadRequest, opts ...grpc.CallOption) (*InitFileUploadResponse, error) {
+	out := new(InitFileUploadResponse)
+	err := c.cc.Invoke(ctx, "/spec.proto.runtime.v1.Runtime/InitFileUpload", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *runtimeClient) PutFileChunk(ctx context.Context, opts ...grpc.CallOption) (Runtime_PutFileChunkClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Runtime_ServiceDesc.Streams[3], "/spec.proto.runtime.v1.Runtime/PutFileChunk", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &runtimePutFileChunkClient{stream}
+	return x, nil
+}
+
+type Runtime_PutFileChunkClient interface {
+	Send(*PutFileChunkRequest) error
+	CloseAndRecv() (*emptypb.Empty, error)
+	grpc.ClientStream
+}
+
+type runtimePutFileChunkClient struct {
+	grpc.ClientStream
+}
+
+func (x *runtimePutFileChunkClient) Send(m *PutFileChunkRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *runtimePutFileChunkClient) CloseAndRecv() (*emptypb.Empty, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(emptypb.Empty)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *runtimeClient) CommitFileUpload(ctx context.Context, in *FileUploadRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/spec.proto.runtime.v1.Runtime/CommitFileUpload", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *runtimeClient) AbortFileUpload(ctx context.Context, in *FileUploadRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/spec.proto.runtime.v1.Runtime/AbortFileUpload", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *runtimeClient) InvokeBinding(ctx context.Context, in *InvokeBindingRequest, opts ...grpc.CallOption) (*InvokeBindingResponse, error) {
 	out := new(InvokeBindingResponse)
 	err := c.cc.Invoke(ctx, "/spec.proto.runtime.v1.Runtime/InvokeBinding", in, out, opts...)
@@ -418,6 +487,14 @@ type RuntimeServer interface {
 	DelFile(context.Context, *DelFileRequest) (*emptypb.Empty, error)
 	// Get file meta data, if file not exist,return code.NotFound error
 	GetFileMeta(context.Context, *GetFileMetaRequest) (*GetFileMetaResponse, error)
+	// Starts a resumable upload session of the file, or resumes the session if upload_id is specified
+	InitFileUpload(context.Context, *InitFileUploadRequest) (*InitFileUploadResponse, error)
+	// Uploads a chunk of the file at the offset with stream
+	PutFileChunk(Runtime_PutFileChunkServer) error
+	// Composes the uploaded chunks into the file
+	CommitFileUpload(context.Context, *FileUploadRequest) (*emptypb.Empty, error)
+	// Discards the uploaded chunks
+	AbortFileUpload(context.Context, *FileUploadRequest) (*emptypb.Empty, error)
 	// Invokes binding data to specific output bindings
 	InvokeBinding(context.Context, *InvokeBindingRequest) (*InvokeBindingResponse, error)
 	// Gets secrets from secret stores.
@@ -493,6 +570,18 @@ func (UnimplementedRuntimeServer) DelFile(context.Context, *DelFileRequest) (*em
 func (UnimplementedRuntimeServer) GetFileMeta(context.Context, *GetFileMetaRequest) (*GetFileMetaResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetFileMeta not implemented")
 }
+func (UnimplementedRuntimeServer) InitFileUpload(context.Context, *InitFileUploadRequest) (*InitFileUploadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InitFileUpload not implemented")
+}
+func (UnimplementedRuntimeServer) PutFileChunk(Runtime_PutFileChunkServer) error {
+	return status.Errorf(codes.Unimplemented, "method PutFileChunk not implemented")
+}
+func (UnimplementedRuntimeServer) CommitFileUpload(context.Context, *FileUploadRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CommitFileUpload not implemented")
+}
+func (UnimplementedRuntimeServer) AbortFileUpload(context.Context, *FileUploadRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AbortFileUpload not implemented")
+}
 func (UnimplementedRuntimeServer) InvokeBinding(context.Context, *InvokeBindingRequest) (*InvokeBindingResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method InvokeBinding not implemented")
 }
@@ -911,6 +1000,86 @@ func _Runtime_GetFileMeta_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Runtime_InitFileUpload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InitFileUploadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RuntimeServer).InitFileUpload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/spec.proto.runtime.v1.Runtime/InitFileUpload",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RuntimeServer).InitFileUpload(ctx, req.(*InitFileUploadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Runtime_PutFileChunk_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(RuntimeServer).PutFileChunk(&runtimePutFileChunkServer{stream})
+}
+
+type Runtime_PutFileChunkServer interface {
+	SendAndClose(*emptypb.Empty) error
+	Recv() (*PutFileChunkRequest, error)
+	grpc.ServerStream
+}
+
+type runtimePutFileChunkServer struct {
+	grpc.ServerStream
+}
+
+func (x *runtimePutFileChunkServer) SendAndClose(m *emptypb.Empty) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *runtimePutFileChunkServer) Recv() (*PutFileChunkRequest, error) {
+	m := new(PutFileChunkRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Runtime_CommitFileUpload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FileUploadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RuntimeServer).CommitFileUpload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/spec.proto.runtime.v1.Runtime/CommitFileUpload",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RuntimeServer).CommitFileUpload(ctx, req.(*FileUploadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Runtime_AbortFileUpload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FileUploadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RuntimeServer).AbortFileUpload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/spec.proto.runtime.v1.Runtime/AbortFileUpload",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RuntimeServer).AbortFileUpload(ctx, req.(*FileUploadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Runtime_InvokeBinding_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(InvokeBindingRequest)
 	if err := dec(in); err != nil {
@@ -1044,6 +1213,18 @@ var Runtime_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetFileMeta",
 			Handler:    _Runtime_GetFileMeta_Handler,
 		},
+		{
+			MethodName: "InitFileUpload",
+			Handler:    _Runtime_InitFileUpload_Handler,
+		},
+		{
+			MethodName: "CommitFileUpload",
+			Handler:    _Runtime_CommitFileUpload_Handler,
+		},
+		{
+			MethodName: "AbortFileUpload",
+			Handler:    _Runtime_AbortFileUpload_Handler,
+		},
 		{
 			MethodName: "InvokeBinding",
 			Handler:    _Runtime_InvokeBinding_Handler,
@@ -1074,6 +1255,11 @@ var Runtime_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Runtime_PutFile_Handler,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "PutFileChunk",
+			Handler:       _Runtime_PutFileChunk_Handler,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "runtime.proto",
 }